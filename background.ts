@@ -8,7 +8,7 @@ import Electron, { MessageBoxOptions } from 'electron';
 import _ from 'lodash';
 import semver from 'semver';
 
-import { State } from '@pkg/backend/backend';
+import { RestartReasons, State } from '@pkg/backend/backend';
 import BackendHelper from '@pkg/backend/backendHelper';
 import { getContainerProcessor } from '@pkg/backend/containers/containerFactory';
 import { ContainerProcessor } from '@pkg/backend/containers/containerProcessor';
@@ -31,6 +31,7 @@ import { ContainerEventHandler } from '@pkg/main/containerEvents';
 import { HttpCredentialHelperServer } from '@pkg/main/credentialServer/httpCredentialHelperServer';
 import { DashboardServer } from '@pkg/main/dashboardServer';
 import { DeploymentProfileError, readDeploymentProfiles } from '@pkg/main/deploymentProfiles';
+import DevResourceWatcher from '@pkg/main/devResourceWatcher';
 import { DiagnosticsManager, DiagnosticsResultCollection } from '@pkg/main/diagnostics/diagnostics';
 import { ExtensionErrorCode, isExtensionError } from '@pkg/main/extensions';
 import { ImageEventHandler } from '@pkg/main/imageEvents';
@@ -38,14 +39,18 @@ import { getIpcMainProxy } from '@pkg/main/ipcMain';
 import mainEvents from '@pkg/main/mainEvents';
 import buildApplicationMenu from '@pkg/main/mainmenu';
 import setupNetworking from '@pkg/main/networking';
+import { getResourceMonitor } from '@pkg/main/resourceEvents';
 import { Snapshots } from '@pkg/main/snapshots/snapshots';
 import { Snapshot, SnapshotDialog } from '@pkg/main/snapshots/types';
 import { Tray } from '@pkg/main/tray';
-import setupUpdate from '@pkg/main/update';
+import setupUpdate, {
+  checkForUpdatesNow, downloadUpdate, getUpdateStatus, installUpdate, UpdateStatus,
+} from '@pkg/main/update';
 import { spawnFile } from '@pkg/utils/childProcess';
 import getCommandLineArgs from '@pkg/utils/commandLine';
 import DockerDirManager from '@pkg/utils/dockerDirManager';
-import { isDevEnv } from '@pkg/utils/environment';
+import { isDevBuild, isDevEnv } from '@pkg/utils/environment';
+import { getHostResources } from '@pkg/utils/hostResources';
 import Logging, { clearLoggingDirectory, setLogLevel } from '@pkg/utils/logging';
 import { fetchMacOsVersion, getMacOsVersion } from '@pkg/utils/osVersion';
 import paths from '@pkg/utils/paths';
@@ -88,6 +93,9 @@ let enabledK8s: boolean;
 let pathManager: PathManager;
 const integrationManager: IntegrationManager = getIntegrationManager();
 let noModalDialogs = false;
+// Whether the application was started with `--safe-mode`: with the default
+// settings, and without provisioning scripts or extensions.
+let safeMode = false;
 // Indicates whether the UI should be locked, settings changes should be disallowed
 // and possibly other things should be disallowed. As of the time of writing,
 // set to true when a snapshot is being created or restored.
@@ -104,6 +112,13 @@ let deploymentProfiles: settings.DeploymentProfileType = { defaults: {}, locked:
  */
 let pendingRestartContext: CommandWorkerInterface.CommandContext | undefined;
 
+/**
+ * lastGoodSettings are the settings the backend last started with.  If it
+ * fails to start after a change of settings, the changed settings are restored
+ * from here, so that the user isn't left with a broken instance.
+ */
+let lastGoodSettings: settings.Settings | undefined;
+
 let httpCommandServer: HttpCommandServer|null = null;
 const httpCredentialHelperServer = new HttpCredentialHelperServer();
 
@@ -183,6 +198,9 @@ Electron.app.whenReady().then(async() => {
     // is syntactically correct, but unlikely (because why would someone create a
     // containerd namespace called "--no-modal-dialogs"?
     noModalDialogs = commandLineArgs.includes('--no-modal-dialogs');
+    // Likewise, safe mode determines which settings are loaded, so it's needed
+    // before the command line is processed.
+    safeMode = commandLineArgs.some(arg => ['--safe-mode', '--safe-mode=true'].includes(arg));
     setupProtocolHandlers();
 
     // make sure we have the macOS version cached before calling getMacOsVersion()
@@ -214,8 +232,14 @@ Electron.app.whenReady().then(async() => {
       throw ex;
     }
     try {
-      cfg = settingsImpl.load(deploymentProfiles);
+      if (safeMode) {
+        console.log('Starting in safe mode: using the default settings, without provisioning scripts or extensions.');
+        cfg = settingsImpl.loadSafeMode(deploymentProfiles);
+      } else {
+        cfg = settingsImpl.load(deploymentProfiles);
+      }
       settingsImpl.updateLockedFields(deploymentProfiles.locked);
+      k8smanager.safeMode = safeMode;
     } catch (err: any) {
       const titlePart = err.name || 'Failed to load settings';
       const message = err.message || err.toString();
@@ -255,6 +279,17 @@ Electron.app.whenReady().then(async() => {
     await httpCommandServer.init();
     await httpCredentialHelperServer.init();
 
+    if (isDevBuild) {
+      // Restart the backend when the helpers are rebuilt, so that they are
+      // picked up without restarting the whole application.
+      new DevResourceWatcher((files) => {
+        if ([K8s.State.STARTED, K8s.State.DISABLED].includes(k8smanager.state)) {
+          console.log(`Resources changed (${ files.join(', ') }), restarting the backend.`);
+          doK8sReset('fullRestart', { interactive: false }).catch(ex => console.error(ex));
+        }
+      }).start();
+    }
+
     await initUI();
     await checkForBackendLock();
     await setPathManager(cfg.application.pathManagementStrategy);
@@ -288,6 +323,13 @@ Electron.app.whenReady().then(async() => {
 
     diagnostics.runChecks().catch(console.error);
 
+    if (safeMode) {
+      (new Electron.Notification({
+        title: 'Running in safe mode',
+        body:  'Rancher Desktop started with the default settings, without provisioning scripts or extensions. Quit and start it again to use your own settings.',
+      })).show();
+    }
+
     await startBackend();
   } catch (ex: any) {
     console.error(`Error starting up: ${ ex }`, ex.stack);
@@ -536,6 +578,11 @@ async function startK8sManager() {
   }
   await k8smanager.start(cfg);
 
+  if (safeMode) {
+    console.log('Safe mode: not starting extensions.');
+
+    return;
+  }
   const getEM = (await import('@pkg/main/extensions/manager')).default;
 
   await getEM(k8smanager.containerEngineClient, cfg);
@@ -786,24 +833,97 @@ async function doK8sReset(arg: 'fast' | 'wipe' | 'fullRestart', context: Command
     case 'fullRestart':
       await k8smanager.stop();
       console.log(`Stopped Kubernetes backend cleanly.`);
-      await startK8sManager();
+      await restartK8sManager();
       break;
     case 'wipe':
       console.log('Deleting VM to reset...');
       await k8smanager.del();
       console.log(`Deleted VM to reset exited cleanly.`);
-      await startK8sManager();
+      await restartK8sManager();
       break;
     }
   } catch (ex) {
-    if (context.interactive) {
-      handleFailure(ex);
-    } else {
-      console.error(ex);
-    }
+    await handleRestartFailure(ex, context);
+  }
+}
+
+/**
+ * BackendStateError is thrown when the backend went into the error state
+ * without throwing, as it does for non-fatal errors and when the user declines
+ * a Kubernetes downgrade; the backend has already reported the problem.
+ */
+class BackendStateError extends Error {}
+
+/**
+ * Start the backend again after a change of settings, failing if it ends up
+ * in the error state, so that the settings are rolled back in that case too.
+ */
+async function restartK8sManager() {
+  await startK8sManager();
+  if (k8smanager.state === K8s.State.ERROR) {
+    throw new BackendStateError('The backend failed to start');
   }
 }
 
+/**
+ * Handle a failure to restart the backend: if settings changed since it last
+ * started, restore them and start it again, or else report the failure.
+ */
+async function handleRestartFailure(ex: any, context: CommandWorkerInterface.CommandContext) {
+  const restored = await rollBackSettings();
+
+  if (restored) {
+    const message = `Rancher Desktop failed to start with the new settings, and restored the previous values of ${ restored.join(', ') }: ${ ex }`;
+
+    console.error(message);
+    (new Electron.Notification({ title: 'Settings were rolled back', body: message })).show();
+  } else if (context.interactive && !(ex instanceof BackendStateError)) {
+    handleFailure(ex);
+  } else {
+    console.error(ex);
+  }
+}
+
+/**
+ * Restore the settings that changed since the backend last started, and start
+ * it again.
+ * @returns The names of the restored settings, or undefined if there was
+ *          nothing to restore, or if the backend failed to start again.
+ */
+async function rollBackSettings(): Promise<string[] | undefined> {
+  if (!lastGoodSettings) {
+    return;
+  }
+  const goodSettings = lastGoodSettings;
+  // The backend compares the given settings with those it failed to start with.
+  const changed = Object.keys(await k8smanager.requiresRestartReasons(goodSettings));
+  const lockedSettings = settingsImpl.getLockedSettings();
+  const restored = changed.filter(key => !_.get(lockedSettings, key));
+
+  if (restored.length === 0) {
+    return;
+  }
+  const restoredSettings: RecursivePartial<settings.Settings> = {};
+
+  for (const key of restored) {
+    _.set(restoredSettings, key, _.get(goodSettings, key));
+  }
+  console.log(`Backend failed to start; restoring the previous values of ${ restored.join(', ') }`);
+  writeSettings(restoredSettings);
+  window.send('settings-update', cfg);
+  window.send('preferences/changed');
+  try {
+    await k8smanager.stop();
+    await restartK8sManager();
+  } catch (err) {
+    console.error('Backend failed to start with the previous settings:', err);
+
+    return;
+  }
+
+  return restored;
+}
+
 ipcMainProxy.on('k8s-restart', async() => {
   if (cfg.kubernetes.port !== k8smanager.kubeBackend.desiredPort) {
     // On port change, we need to wipe the VM.
@@ -818,11 +938,11 @@ ipcMainProxy.on('k8s-restart', async() => {
     case K8s.State.DISABLED:
       // Calling start() will restart the backend, possible switching versions
       // as a side-effect.
-      await startK8sManager();
+      await restartK8sManager();
       break;
     }
   } catch (ex) {
-    handleFailure(ex);
+    await handleRestartFailure(ex, { interactive: true });
   }
 });
 
@@ -897,6 +1017,15 @@ ipcMainProxy.on('factory-reset', (event, keepSystemImages) => {
   doFactoryReset(keepSystemImages);
 });
 
+ipcMainProxy.on('restart-safe-mode', () => {
+  // The settings file is untouched in safe mode; a normal start uses it again.
+  const args = process.argv.slice(1).filter(arg => !arg.startsWith('--safe-mode'));
+
+  console.log('Restarting in safe mode.');
+  Electron.app.relaunch({ args: [...args, '--safe-mode'] });
+  Electron.app.quit();
+});
+
 ipcMainProxy.on('show-logs', async(event) => {
   const error = await Electron.shell.openPath(paths.logs);
 
@@ -1226,7 +1355,9 @@ function newK8sManager() {
       if (!cfg.kubernetes.version) {
         writeSettings({ kubernetes: { version: mgr.kubeBackend.version } });
       }
+      lastGoodSettings = _.cloneDeep(cfg);
       currentImageProcessor?.relayNamespaces();
+      getResourceMonitor().start(mgr.executor, mgr.containerEngineClient);
 
       if (enabledK8s) {
         Steve.getInstance().start();
@@ -1235,6 +1366,7 @@ function newK8sManager() {
 
     if (state === K8s.State.STOPPING) {
       Steve.getInstance().stop();
+      getResourceMonitor().stop();
     }
     if (pendingRestartContext !== undefined && !backendIsBusy()) {
       // If we restart immediately the QEMU process in the VM doesn't always respond to a shutdown messages
@@ -1278,7 +1410,8 @@ function validateEarlySettings(cfg: settings.Settings, newSettings: RecursivePar
   // We'd have to add more code to report that.
   // It isn't worth adding that code yet. It might never be needed.
   const newSettingsForValidation = _.omit(newSettings, 'kubernetes.version');
-  const [, errors] = new SettingsValidator().validateSettings(cfg, newSettingsForValidation, lockedFields);
+  // Administrators may give the VM more than the limits of the host.
+  const [, errors] = new SettingsValidator().validateSettings(cfg, newSettingsForValidation, lockedFields, true);
 
   if (errors.length > 0) {
     throw new LockedFieldError(`Error in deployment profiles:\n${ errors.join('\n') }`);
@@ -1301,7 +1434,7 @@ class BackgroundCommandWorker implements CommandWorkerInterface {
    * Use the settings validator to validate settings after doing any
    * initialization.
    */
-  protected async validateSettings(existingSettings: settings.Settings, newSettings: RecursivePartial<settings.Settings>) {
+  protected async validateSettings(existingSettings: settings.Settings, newSettings: RecursivePartial<settings.Settings>, force = false) {
     let clearVersionsAfterTesting = false;
 
     if (newSettings.kubernetes?.version && this.settingsValidator.k8sVersions.length === 0) {
@@ -1321,7 +1454,7 @@ class BackgroundCommandWorker implements CommandWorkerInterface {
       this.settingsValidator.k8sVersions = currentK8sVersions;
     }
 
-    const result = this.settingsValidator.validateSettings(existingSettings, newSettings, settingsImpl.getLockedSettings());
+    const result = this.settingsValidator.validateSettings(existingSettings, newSettings, settingsImpl.getLockedSettings(), force);
 
     if (clearVersionsAfterTesting) {
       this.settingsValidator.k8sVersions = [];
@@ -1350,8 +1483,8 @@ class BackgroundCommandWorker implements CommandWorkerInterface {
     return diagnostics.getChecks(category, checkID);
   }
 
-  runDiagnosticChecks(): Promise<DiagnosticsResultCollection> {
-    return diagnostics.runChecks();
+  runDiagnosticChecks(context: CommandWorkerInterface.CommandContext, categories: string[] = []): Promise<DiagnosticsResultCollection> {
+    return diagnostics.runChecks(categories);
   }
 
   factoryReset(keepSystemImages: boolean) {
@@ -1387,22 +1520,30 @@ class BackgroundCommandWorker implements CommandWorkerInterface {
    *   1. a description of the status of the request, if it was valid
    *   2. a list of any errors in the request body.
    * @param specifiedNewSettings: a subset of the Settings object, containing the desired values
+   * @param restart: if false, changes that need a restart of the backend are
+   *        saved, and applied on its next restart; see getPendingSettings().
+   * @param force: if true, resources of the VM over the limits of the host
+   *        are accepted, with a warning.
    * @returns [{string} description of final state if no error, {string} error message]
    */
-  async updateSettings(context: CommandWorkerInterface.CommandContext, specifiedNewSettings: RecursivePartial<settings.Settings>): Promise<[string, string]> {
+  async updateSettings(context: CommandWorkerInterface.CommandContext, specifiedNewSettings: RecursivePartial<settings.Settings>, restart = true, force = false): Promise<[string, string]> {
     let errors: string[] = [];
+    let warnings: string[] = [];
     let needToUpdate = false;
     let newSettings: RecursivePartial<settings.Settings> = {};
 
     try {
       newSettings = settingsImpl.migrateSpecifiedSettingsToCurrentVersion(specifiedNewSettings);
-      [needToUpdate, errors] = await this.validateSettings(cfg, newSettings);
+      [needToUpdate, errors, , warnings] = await this.validateSettings(cfg, newSettings, force);
     } catch (ex: any) {
       errors.push(ex.message);
     }
     if (errors.length > 0) {
       return ['', `errors in attempt to update settings:\n${ errors.join('\n') }`];
     }
+    // Forced changes are reported along with the status.
+    const status = (description: string): [string, string] => [[description, ...warnings.map(warning => `warning: ${ warning }`)].join('; '), ''];
+
     if (needToUpdate) {
       writeSettings(newSettings);
       // cfg is a global, and at this point newConfig has been merged into it :(
@@ -1420,7 +1561,10 @@ class BackgroundCommandWorker implements CommandWorkerInterface {
     const restartReasons = await k8smanager.requiresRestartReasons(cfg);
 
     if (Object.keys(restartReasons).length === 0) {
-      return ['settings updated; no restart required', ''];
+      return status('settings updated; no restart required');
+    }
+    if (!restart) {
+      return status(`settings saved; the backend will apply changes to ${ Object.keys(restartReasons).join(', ') } on its next restart`);
     }
 
     // Trigger a restart of the backend (possibly delayed).
@@ -1428,17 +1572,102 @@ class BackgroundCommandWorker implements CommandWorkerInterface {
       pendingRestartContext = undefined;
       setImmediate(doFullRestart, context);
 
-      return ['reconfiguring Rancher Desktop to apply changes (this may take a while)', ''];
+      return status('reconfiguring Rancher Desktop to apply changes (this may take a while)');
     } else {
       // Call doFullRestart once the UI is finished starting or stopping
       pendingRestartContext = context;
 
-      return ['UI is currently busy, but will eventually be reconfigured to apply requested changes', ''];
+      return status('UI is currently busy, but will eventually be reconfigured to apply requested changes');
+    }
+  }
+
+  getPendingSettings(): Promise<RestartReasons> {
+    // The backend compares the saved settings with those it's running with.
+    return k8smanager.requiresRestartReasons(cfg);
+  }
+
+  getHostResources() {
+    return getHostResources();
+  }
+
+  getVersion() {
+    return Electron.app.getVersion();
+  }
+
+  getUpdateStatus(): UpdateStatus {
+    return getUpdateStatus();
+  }
+
+  /**
+   * Return why updates can't be driven through the API, if they can't: this
+   * installation can't be updated, or the administrator disabled updates.
+   */
+  protected updateRefusal(): { status: number, data: string } | undefined {
+    if (!getUpdateStatus().configured) {
+      return { status: 503, data: 'This installation of Rancher Desktop can not be updated' };
+    }
+    if (_.get(settingsImpl.getLockedSettings(), 'application.updater.enabled') && !cfg.application.updater.enabled) {
+      return { status: 403, data: 'Updates are disabled by the administrator' };
     }
+
+    return undefined;
+  }
+
+  async checkForUpdates(channel: string): Promise<{ status: number, data?: any }> {
+    const refusal = this.updateRefusal();
+
+    if (refusal) {
+      return refusal;
+    }
+    if (getUpdateStatus().downloading) {
+      return { status: 409, data: 'An update is being downloaded' };
+    }
+    await checkForUpdatesNow(channel);
+
+    return { status: 200, data: getUpdateStatus() };
+  }
+
+  async downloadUpdate(): Promise<{ status: number, data?: any }> {
+    const refusal = this.updateRefusal();
+
+    if (refusal) {
+      return refusal;
+    }
+    const status = getUpdateStatus();
+
+    if (!status.available) {
+      return { status: 409, data: 'No update is available' };
+    }
+    if (status.downloaded) {
+      return { status: 200, data: status };
+    }
+    downloadUpdate();
+
+    return { status: 202, data: getUpdateStatus() };
+  }
+
+  async applyUpdate(): Promise<{ status: number, data?: any }> {
+    const refusal = this.updateRefusal();
+
+    if (refusal) {
+      return refusal;
+    }
+    if (!getUpdateStatus().downloaded) {
+      return { status: 409, data: 'No update has been downloaded' };
+    }
+    installUpdate();
+
+    return { status: 202, data: 'Installing the update; Rancher Desktop restarts once it is installed' };
+  }
+
+  async getKubernetesVersions() {
+    const versions = await k8smanager.kubeBackend.availableVersions;
+
+    return versions.map(entry => ({ version: entry.version.version, channels: entry.channels ?? [] }));
   }
 
-  async proposeSettings(context: CommandWorkerInterface.CommandContext, newSettings: RecursivePartial<settings.Settings>): Promise<[string, string]> {
-    const [, errors] = await this.validateSettings(cfg, newSettings);
+  async proposeSettings(context: CommandWorkerInterface.CommandContext, newSettings: RecursivePartial<settings.Settings>, force = false): Promise<[string, string]> {
+    const [, errors] = await this.validateSettings(cfg, newSettings, force);
 
     if (errors.length > 0) {
       return ['', `Errors in proposed settings:\n${ errors.join('\n') }`];
@@ -1553,8 +1782,9 @@ class BackgroundCommandWorker implements CommandWorkerInterface {
     const backendIsLocked = await readBackendLockFile();
 
     return {
-      vmState: k8smanager.state,
-      locked:  !!backendIsLocked,
+      vmState:  k8smanager.state,
+      subState: k8smanager.subState,
+      locked:   !!backendIsLocked,
     };
   }
 
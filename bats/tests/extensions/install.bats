@@ -108,6 +108,27 @@ encoded_id() { # variant
     assert_line --partial "$(id basic):v0.0.2"
 }
 
+@test 'basic extension - upgrade command' {
+    rdctl extension upgrade "$(id basic):0.0.1"
+    run rdctl extension ls
+    assert_success
+    assert_line --partial "$(id basic):0.0.1"
+
+    run rdctl extension upgrade "$(id basic)"
+    assert_success
+    assert_output "Upgraded extension $(id basic) from 0.0.1 to v0.0.2"
+
+    run rdctl extension upgrade "$(id basic)"
+    assert_success
+    assert_output "Extension $(id basic) is already at v0.0.2"
+}
+
+@test 'basic extension - upgrade command requires an installed extension' {
+    run rdctl extension upgrade "$(id ui)"
+    assert_failure
+    assert_output --partial "extension $(id ui) is not installed"
+}
+
 @test 'basic extension - uninstalling not installed version' {
     rdctl extension uninstall "$(id basic):0.0.1"
     run rdctl extension ls
@@ -0,0 +1,73 @@
+load '../helpers/load'
+
+NAMESPACE=bats-network-policy
+
+assert_nginx_reachable() {
+    run kubectl run --namespace "$NAMESPACE" "client-$RANDOM" --rm --stdin --restart=Never \
+        --image="$IMAGE_BUSYBOX" -- wget -q -T 5 -O - http://nginx
+    assert_success
+    assert_output --partial "Welcome to nginx"
+}
+
+refute_nginx_reachable() {
+    run kubectl run --namespace "$NAMESPACE" "client-$RANDOM" --rm --stdin --restart=Never \
+        --image="$IMAGE_BUSYBOX" -- wget -q -T 5 -O - http://nginx
+    assert_failure
+    refute_output --partial "Welcome to nginx"
+}
+
+@test 'factory reset' {
+    factory_reset
+}
+
+@test 'start k8s' {
+    start_kubernetes --kubernetes.options.network-policy=true
+    wait_for_apiserver
+}
+
+@test 'deploy nginx' {
+    kubectl create namespace "$NAMESPACE"
+    kubectl run --namespace "$NAMESPACE" nginx --image="$IMAGE_NGINX" --port=80 --expose
+    kubectl wait --namespace "$NAMESPACE" --for=condition=Ready pod/nginx --timeout=300s
+}
+
+@test 'nginx is reachable without a network policy' {
+    try --max 6 --delay 10 assert_nginx_reachable
+}
+
+@test 'deny ingress to the namespace' {
+    kubectl apply --filename - <<EOF
+apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: deny-ingress
+  namespace: $NAMESPACE
+spec:
+  podSelector: {}
+  policyTypes: [Ingress]
+EOF
+}
+
+@test 'the network policy is enforced' {
+    try --max 6 --delay 10 refute_nginx_reachable
+}
+
+@test 'disable network policy' {
+    local k3s_pid
+    k3s_pid=$(get_service_pid k3s)
+
+    rdctl set --kubernetes.options.network-policy=false
+
+    # Wait until k3s has restarted
+    try --max 30 --delay 5 refute_service_pid k3s "${k3s_pid}"
+    wait_for_apiserver
+    kubectl wait --namespace "$NAMESPACE" --for=condition=Ready pod/nginx --timeout=300s
+}
+
+@test 'the network policy is not enforced' {
+    try --max 6 --delay 10 assert_nginx_reachable
+}
+
+@test 'remove the namespace' {
+    kubectl delete namespace "$NAMESPACE"
+}
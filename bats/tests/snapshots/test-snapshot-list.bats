@@ -77,8 +77,13 @@ created() {
     # so a difference of 4.9999 could show up as 4
     ((TIME2 - TIME1 > 4))
 
+    run jq_output "select(.name == $(json_string "$EMOJI_SNAPSHOT_NAME")).size > 0"
+    assert_success
+    assert_output true
+
     run rdctl snapshot list
     assert_success
+    assert_output --partial SIZE
     assert_output --partial "$NON_ALNUM_SNAPSHOT_NAME"
     assert_output --partial "$MULTI_WORD_SNAPSHOT_NAME"
     assert_output --partial "$EMOJI_SNAPSHOT_NAME"
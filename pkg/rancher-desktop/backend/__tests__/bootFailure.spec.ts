@@ -0,0 +1,45 @@
+/** @jest-environment node */
+
+import fs from 'fs';
+import os from 'os';
+import path from 'path';
+
+import { getBootFailure } from '@pkg/backend/bootFailure';
+import ProgressTracker from '@pkg/backend/progressTracker';
+
+describe('getBootFailure', () => {
+  let dir: string;
+
+  beforeEach(async() => {
+    dir = await fs.promises.mkdtemp(path.join(os.tmpdir(), 'rd-boot-failure-'));
+  });
+  afterEach(async() => {
+    await fs.promises.rm(dir, { recursive: true, force: true });
+  });
+
+  it('reports the failed step and the end of the existing logs', async() => {
+    const serialLog = path.join(dir, 'serial.log');
+    const lines = Array.from({ length: 60 }, (_, i) => `line ${ i }`);
+
+    await fs.promises.writeFile(serialLog, `${ lines.join('\n') }\n`);
+    const tracker = new ProgressTracker(() => {});
+    const error = await tracker.action('Starting virtual machine', 100, Promise.reject(new Error('exit status 1'))).catch(ex => ex);
+
+    const report = await getBootFailure('lima', error, [serialLog, path.join(dir, 'missing.log')]);
+
+    expect(report).toMatchObject({
+      backend: 'lima',
+      step:    'Starting virtual machine',
+      error:   'exit status 1',
+      logs:    [{ path: serialLog, lines: lines.slice(10) }],
+    });
+  });
+
+  it('includes the standard error of failed commands', async() => {
+    const error = Object.assign(new Error('exit status 1'), { stderr: 'qemu: could not open disk\n' });
+    const report = await getBootFailure('lima', error, []);
+
+    expect(report.error).toEqual('exit status 1: qemu: could not open disk');
+    expect(report.step).toBeUndefined();
+  });
+});
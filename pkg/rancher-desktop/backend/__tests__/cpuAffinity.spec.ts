@@ -0,0 +1,69 @@
+/** @jest-environment node */
+
+import fs from 'fs';
+import os from 'os';
+import path from 'path';
+
+import { expandCPUList, getAffinityCPUs } from '@pkg/backend/cpuAffinity';
+import { CPUAffinityMode } from '@pkg/config/settings';
+
+describe('expandCPUList', () => {
+  it('expands ranges', () => {
+    expect(expandCPUList('0-3,8')).toEqual([0, 1, 2, 3, 8]);
+    expect(expandCPUList('')).toEqual([]);
+  });
+
+  it('rejects invalid lists', () => {
+    expect(() => expandCPUList('3-1')).toThrow('Invalid CPU list');
+    expect(() => expandCPUList('all')).toThrow('Invalid CPU list');
+  });
+});
+
+describe('getAffinityCPUs', () => {
+  let sysfs: string;
+  const affinity = { mode: CPUAffinityMode.NONE, cpus: '', numaNodes: '' };
+
+  async function writeSysfs(file: string, contents: string) {
+    await fs.promises.mkdir(path.join(sysfs, path.dirname(file)), { recursive: true });
+    await fs.promises.writeFile(path.join(sysfs, file), contents);
+  }
+
+  beforeEach(async() => {
+    sysfs = await fs.promises.mkdtemp(path.join(os.tmpdir(), 'rd-sysfs-'));
+  });
+  afterEach(async() => {
+    await fs.promises.rm(sysfs, { recursive: true, force: true });
+  });
+
+  it('does not pin by default', async() => {
+    await expect(getAffinityCPUs(affinity, sysfs)).resolves.toBeUndefined();
+    await expect(getAffinityCPUs({ ...affinity, mode: CPUAffinityMode.CPUS }, sysfs)).resolves.toBeUndefined();
+  });
+
+  it('uses the given CPUs', async() => {
+    await expect(getAffinityCPUs({ ...affinity, mode: CPUAffinityMode.CPUS, cpus: '0-3' }, sysfs)).resolves.toEqual('0-3');
+  });
+
+  it('finds the cores of hybrid CPUs', async() => {
+    await writeSysfs('devices/cpu_core/cpus', '0-11\n');
+    await writeSysfs('devices/cpu_atom/cpus', '12-19\n');
+
+    await expect(getAffinityCPUs({ ...affinity, mode: CPUAffinityMode.PERFORMANCE }, sysfs)).resolves.toEqual('0-11');
+    await expect(getAffinityCPUs({ ...affinity, mode: CPUAffinityMode.EFFICIENCY }, sysfs)).resolves.toEqual('12-19');
+  });
+
+  it('fails without hybrid CPUs', async() => {
+    await expect(getAffinityCPUs({ ...affinity, mode: CPUAffinityMode.PERFORMANCE }, sysfs))
+      .rejects.toThrow('no separate performance cores');
+  });
+
+  it('finds the CPUs of NUMA nodes', async() => {
+    await writeSysfs('devices/system/node/node0/cpulist', '0-7\n');
+    await writeSysfs('devices/system/node/node1/cpulist', '8-15\n');
+
+    await expect(getAffinityCPUs({ ...affinity, mode: CPUAffinityMode.NUMA_NODES, numaNodes: '1' }, sysfs)).resolves.toEqual('8-15');
+    await expect(getAffinityCPUs({ ...affinity, mode: CPUAffinityMode.NUMA_NODES, numaNodes: '0-1' }, sysfs)).resolves.toEqual('0-7,8-15');
+    await expect(getAffinityCPUs({ ...affinity, mode: CPUAffinityMode.NUMA_NODES, numaNodes: '2' }, sysfs))
+      .rejects.toThrow('no NUMA node 2');
+  });
+});
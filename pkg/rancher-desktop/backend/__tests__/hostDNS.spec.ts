@@ -0,0 +1,26 @@
+import { hostDNSResolvConf } from '@pkg/backend/hostDNS';
+
+describe('hostDNSResolvConf', () => {
+  it('uses the resolver and the search domains of the host', () => {
+    const resolvConf = hostDNSResolvConf('192.168.5.3', {
+      source:  'systemd-resolved',
+      servers: ['192.168.1.1', '10.8.0.1'],
+      domains: [
+        { name: 'home.lan', routeOnly: false },
+        { name: 'corp.example', routeOnly: true, servers: ['10.8.0.1'] },
+        { name: 'lab.example', routeOnly: false, servers: ['10.8.0.1'] },
+      ],
+    });
+
+    expect(resolvConf).toEqual(
+      '# Generated by Rancher Desktop from the DNS configuration of the host (systemd-resolved).\n' +
+      '# Domains resolved by specific servers of the host: corp.example\n' +
+      'nameserver 192.168.5.3\n' +
+      'search home.lan lab.example\n');
+  });
+
+  it('leaves out the search line without search domains', () => {
+    expect(hostDNSResolvConf('192.168.5.3', { source: '/etc/resolv.conf', servers: ['1.1.1.1'], domains: [] }))
+      .not.toContain('search');
+  });
+});
@@ -0,0 +1,44 @@
+/** @jest-environment node */
+
+import fs from 'fs';
+
+import { getHostLocale, hostLocaleScript } from '@pkg/backend/hostLocale';
+
+describe('getHostLocale', () => {
+  afterEach(() => {
+    jest.restoreAllMocks();
+  });
+
+  it('converts the locale to its POSIX name', async() => {
+    jest.spyOn(Intl.DateTimeFormat.prototype, 'resolvedOptions').mockReturnValue({
+      locale: 'de-DE', timeZone: 'Europe/Berlin',
+    } as Intl.ResolvedDateTimeFormatOptions);
+    jest.spyOn(fs.promises, 'readlink').mockRejectedValue(new Error('EINVAL'));
+
+    await expect(getHostLocale()).resolves.toEqual({ timezone: 'Europe/Berlin', lang: 'de_DE.UTF-8' });
+  });
+
+  if (process.platform !== 'win32') {
+    it('reads the timezone from /etc/localtime', async() => {
+      jest.spyOn(Intl.DateTimeFormat.prototype, 'resolvedOptions').mockReturnValue({
+        locale: 'en', timeZone: 'UTC',
+      } as Intl.ResolvedDateTimeFormatOptions);
+      jest.spyOn(fs.promises, 'readlink').mockResolvedValue('/var/db/timezone/zoneinfo/America/Vancouver');
+
+      await expect(getHostLocale()).resolves.toEqual({ timezone: 'America/Vancouver', lang: 'en.UTF-8' });
+    });
+  }
+});
+
+describe('hostLocaleScript', () => {
+  it('quotes the timezone and locale', () => {
+    const script = hostLocaleScript({ timezone: `Etc/It's`, lang: 'en_US.UTF-8' });
+
+    expect(script).toContain(`TZ='Etc/It'\\''s'`);
+    expect(script).toContain(`LANG='en_US.UTF-8'`);
+  });
+
+  it('only undoes changes it made', () => {
+    expect(hostLocaleScript()).toMatch(/if \[ -f \/etc\/rancher\/desktop\/host-locale \]/);
+  });
+});
@@ -5,6 +5,7 @@ import os from 'os';
 import path from 'path';
 import util from 'util';
 
+import { KubeConfig } from '@kubernetes/client-node';
 import fetch from 'node-fetch';
 import semver from 'semver';
 
@@ -12,6 +13,7 @@ import K3sHelper, {
   buildVersion, ChannelMapping, NoCachedK3sVersionsError, ReleaseAPIEntry, VersionEntry,
 } from '../k3sHelper';
 
+import { KubeconfigCertificates } from '@pkg/config/settings';
 import paths from '@pkg/utils/paths';
 
 const cachePath = path.join(paths.cache, 'k3s-versions.json');
@@ -428,4 +430,116 @@ describe(K3sHelper, () => {
       expect(() => subject['selectClosestSemVer'](desiredSemver, [])).toThrow(NoCachedK3sVersionsError);
     });
   });
+
+  describe('updateKubeconfigCertificates', () => {
+    const subject = new K3sHelper('x86_64');
+    const encode = (data: string) => Buffer.from(data).toString('base64');
+    let certsDir: string;
+    let config: KubeConfig;
+
+    beforeEach(async() => {
+      certsDir = path.join(await fs.promises.mkdtemp(path.join(os.tmpdir(), 'rd-test-kubeconfig-')), 'rancher-desktop');
+      config = new KubeConfig();
+      config.loadFromOptions({
+        clusters: [
+          {
+            name: 'rancher-desktop', server: 'https://127.0.0.1:6443', caData: encode('ca'), skipTLSVerify: false,
+          },
+          {
+            name: 'other', server: 'https://example.test', caData: encode('other ca'), skipTLSVerify: false,
+          },
+        ],
+        users: [{
+          name: 'rancher-desktop', certData: encode('cert'), keyData: encode('key'),
+        }],
+        contexts: [{
+          name: 'rancher-desktop', cluster: 'rancher-desktop', user: 'rancher-desktop',
+        }],
+        currentContext: 'rancher-desktop',
+      });
+    });
+    afterEach(async() => {
+      await fs.promises.rm(path.dirname(certsDir), { recursive: true, force: true });
+    });
+
+    it('writes the certificates to files', async() => {
+      await subject['updateKubeconfigCertificates'](config, 'rancher-desktop', certsDir, KubeconfigCertificates.FILE);
+
+      expect(config.clusters[0]).toMatchObject({ caData: undefined, caFile: path.join(certsDir, 'ca.crt') });
+      expect(config.clusters[1]).toMatchObject({ caData: encode('other ca'), caFile: undefined });
+      expect(config.users[0]).toMatchObject({
+        certData: undefined,
+        certFile: path.join(certsDir, 'client.crt'),
+        keyData:  undefined,
+        keyFile:  path.join(certsDir, 'client.key'),
+      });
+      await expect(fs.promises.readFile(path.join(certsDir, 'client.key'), 'utf-8')).resolves.toEqual('key');
+      if (os.platform() !== 'win32') {
+        expect((await fs.promises.stat(path.join(certsDir, 'client.key'))).mode & 0o777).toEqual(0o600);
+      }
+    });
+
+    it('embeds the certificates, removing the files', async() => {
+      await subject['updateKubeconfigCertificates'](config, 'rancher-desktop', certsDir, KubeconfigCertificates.FILE);
+      config.clusters[0] = { ...config.clusters[0], caData: encode('ca'), caFile: undefined };
+      await subject['updateKubeconfigCertificates'](config, 'rancher-desktop', certsDir, KubeconfigCertificates.EMBEDDED);
+
+      expect(config.clusters[0]).toMatchObject({ caData: encode('ca'), caFile: undefined });
+      await expect(fs.promises.readdir(certsDir)).resolves.toEqual([]);
+    });
+  });
+
+  describe('OIDC', () => {
+    const oidc = {
+      issuerURL:     'https://sso.example.com',
+      clientID:      'kubernetes',
+      usernameClaim: 'email',
+      groupsClaim:   '',
+      extraScopes:   ['groups'],
+    };
+
+    it('passes the provider to the API server', () => {
+      expect(K3sHelper.apiServerOIDCArgs(oidc)).toEqual(
+        ' --kube-apiserver-arg=oidc-issuer-url=https://sso.example.com' +
+        ' --kube-apiserver-arg=oidc-client-id=kubernetes' +
+        ' --kube-apiserver-arg=oidc-username-claim=email');
+      expect(K3sHelper.apiServerOIDCArgs({ ...oidc, issuerURL: '' })).toEqual('');
+    });
+
+    it('adds and removes the kubeconfig context', () => {
+      const subject = new K3sHelper('x86_64');
+      const config = new KubeConfig();
+
+      config.loadFromOptions({
+        clusters: [{
+          name: 'rancher-desktop', server: 'https://127.0.0.1:6443', skipTLSVerify: false,
+        }],
+        users:    [{ name: 'rancher-desktop' }],
+        contexts: [{
+          name: 'rancher-desktop', cluster: 'rancher-desktop', user: 'rancher-desktop',
+        }],
+        currentContext: 'rancher-desktop',
+      });
+      subject['updateKubeconfigOIDC'](config, 'rancher-desktop', oidc);
+      subject['updateKubeconfigOIDC'](config, 'rancher-desktop', oidc);
+
+      expect(config.contexts).toContainEqual({
+        name: 'rancher-desktop-oidc', cluster: 'rancher-desktop', user: 'rancher-desktop-oidc',
+      });
+      expect(config.users.filter(user => user.name === 'rancher-desktop-oidc')).toEqual([{
+        name: 'rancher-desktop-oidc',
+        exec: expect.objectContaining({
+          command: 'kubectl',
+          args:    ['oidc-login', 'get-token', '--oidc-issuer-url=https://sso.example.com', '--oidc-client-id=kubernetes', '--oidc-extra-scope=groups'],
+        }),
+      }]);
+
+      config.setCurrentContext('rancher-desktop-oidc');
+      subject['updateKubeconfigOIDC'](config, 'rancher-desktop', { ...oidc, issuerURL: '' });
+
+      expect(config.contexts.map(context => context.name)).toEqual(['rancher-desktop']);
+      expect(config.users.map(user => user.name)).toEqual(['rancher-desktop']);
+      expect(config.currentContext).toEqual('rancher-desktop');
+    });
+  });
 });
@@ -0,0 +1,57 @@
+/** @jest-environment node */
+
+import fs from 'fs';
+import os from 'os';
+import path from 'path';
+
+import { getNestedVirtualizationSupport } from '@pkg/backend/nestedVirtualization';
+
+describe('getNestedVirtualizationSupport', () => {
+  let root: string;
+
+  async function writeFile(file: string, contents: string) {
+    await fs.promises.mkdir(path.join(root, path.dirname(file)), { recursive: true });
+    await fs.promises.writeFile(path.join(root, file), contents);
+  }
+
+  function check() {
+    return getNestedVirtualizationSupport(path.join(root, 'proc'), path.join(root, 'sys'));
+  }
+
+  beforeEach(async() => {
+    root = await fs.promises.mkdtemp(path.join(os.tmpdir(), 'rd-nested-'));
+  });
+  afterEach(async() => {
+    await fs.promises.rm(root, { recursive: true, force: true });
+  });
+
+  it('is supported when the KVM module allows nesting', async() => {
+    await writeFile('proc/cpuinfo', 'processor\t: 0\nflags\t\t: fpu vme svm lm\n');
+    await writeFile('sys/module/kvm_amd/parameters/nested', '1\n');
+
+    await expect(check()).resolves.toEqual({ supported: true, module: 'kvm_amd' });
+  });
+
+  it('reports a KVM module that does not allow nesting', async() => {
+    await writeFile('proc/cpuinfo', 'processor\t: 0\nflags\t\t: fpu vme vmx lm\n');
+    await writeFile('sys/module/kvm_intel/parameters/nested', 'N\n');
+
+    await expect(check()).resolves.toMatchObject({
+      supported: false,
+      module:    'kvm_intel',
+      fix:       expect.stringContaining('options kvm_intel nested=1'),
+    });
+  });
+
+  it('reports a missing KVM module', async() => {
+    await writeFile('proc/cpuinfo', 'processor\t: 0\nflags\t\t: fpu vme vmx lm\n');
+
+    await expect(check()).resolves.toMatchObject({ supported: false, reason: expect.stringContaining('not loaded') });
+  });
+
+  it('reports a CPU without virtualization extensions', async() => {
+    await writeFile('proc/cpuinfo', 'processor\t: 0\nflags\t\t: fpu vme lm\n');
+
+    await expect(check()).resolves.toMatchObject({ supported: false, reason: expect.stringContaining('no virtualization extensions') });
+  });
+});
@@ -0,0 +1,66 @@
+/** @jest-environment node */
+
+import ProgressTracker from '@pkg/backend/progressTracker';
+import { runStartupTasks, StartupTask } from '@pkg/backend/startupTasks';
+
+describe('runStartupTasks', () => {
+  const tracker = new ProgressTracker(() => {});
+  let events: string[];
+
+  /** A task that records when it starts and finishes. */
+  function task(name: string, after?: string[]): StartupTask {
+    return {
+      description: name,
+      after,
+      run:         async() => {
+        events.push(`start ${ name }`);
+        await new Promise(resolve => setTimeout(resolve, 10));
+        events.push(`end ${ name }`);
+      },
+    };
+  }
+
+  beforeEach(() => {
+    events = [];
+  });
+
+  it('runs independent tasks concurrently, and dependent ones in order', async() => {
+    await runStartupTasks(tracker, {
+      engine: task('engine', ['certs', 'config']),
+      certs:  task('certs'),
+      config: task('config'),
+      k3s:    task('k3s', ['engine']),
+    });
+
+    expect(events.slice(0, 2).sort()).toEqual(['start certs', 'start config']);
+    expect(events.indexOf('start engine')).toBeGreaterThan(events.indexOf('end certs'));
+    expect(events.indexOf('start engine')).toBeGreaterThan(events.indexOf('end config'));
+    expect(events.indexOf('start k3s')).toBeGreaterThan(events.indexOf('end engine'));
+  });
+
+  it('does not run the tasks depending on a failed one', async() => {
+    const failing: StartupTask = {
+      description: 'Installing CA certificates',
+      run:         () => Promise.reject(new Error('no certificates')),
+    };
+
+    await expect(runStartupTasks(tracker, {
+      certs:  failing,
+      engine: task('engine', ['certs']),
+      config: task('config'),
+    })).rejects.toThrow('no certificates');
+    expect(events).toEqual(['start config', 'end config']);
+  });
+
+  it('rejects unknown and circular dependencies before running any task', async() => {
+    await expect(runStartupTasks(tracker, {
+      certs:  task('certs'),
+      engine: task('engine', ['missing']),
+    })).rejects.toThrow('Unknown startup task missing');
+    await expect(runStartupTasks(tracker, {
+      engine: task('engine', ['k3s']),
+      k3s:    task('k3s', ['engine']),
+    })).rejects.toThrow('depends on itself');
+    expect(events).toEqual([]);
+  });
+});
@@ -18,6 +18,17 @@ export enum State {
   DISABLED = 'DISABLED', // The container backend is ready but the Kubernetes engine is disabled.
 }
 
+/**
+ * SubState is the step the backend is at while it is STARTING.
+ */
+export enum SubState {
+  VM_BOOTING = 'vm-booting', // The VM (or WSL distribution) is being started.
+  IMAGES_LOADING = 'images-loading', // The Kubernetes images are being fetched.
+  PROVISIONING = 'provisioning', // The VM is being configured.
+  ENGINE_STARTING = 'engine-starting', // Waiting for the container engine to be ready.
+  K8S_WAITING = 'k8s-waiting', // Waiting for Kubernetes to be ready.
+}
+
 export class BackendError extends Error {
   constructor(name: string, message: string, fatal = false) {
     super(message);
@@ -110,6 +121,9 @@ export interface VMBackend extends EventEmitter<BackendEvents> {
 
   readonly state: State;
 
+  /** The step the backend is at while the state is STARTING. */
+  readonly subState?: SubState;
+
   /** The number of CPUs in the running VM, or 0 if the VM is not running. */
   readonly cpus: Promise<number>;
 
@@ -179,6 +193,12 @@ export interface VMBackend extends EventEmitter<BackendEvents> {
    */
   noModalDialogs: boolean;
 
+  /**
+   * If true, the backend starts without running the user's provisioning
+   * scripts, to recover from a script that breaks startup.
+   */
+  safeMode: boolean;
+
   readonly executor: VMExecutor;
   readonly kubeBackend: KubernetesBackend;
   readonly containerEngineClient: ContainerEngineClient;
@@ -5,14 +5,38 @@ import semver from 'semver';
 
 import { BackendSettings } from '@pkg/backend/backend';
 import { LockedFieldError } from '@pkg/config/commandLineOptions';
-import { ContainerEngine, Settings } from '@pkg/config/settings';
+import {
+  ContainerEngine,
+  Settings,
+  SwapMode,
+  TrimInterval,
+} from '@pkg/config/settings';
 import * as settingsImpl from '@pkg/config/settingsImpl';
 import SettingsValidator from '@pkg/main/commandServer/settingsValidator';
+import { defaultSwapSizeInGB, getHostResources } from '@pkg/utils/hostResources';
 import Logging from '@pkg/utils/logging';
 import { showMessageBox } from '@pkg/window';
 
 const console = Logging.kube;
 
+/** The environment variables of the container engine, as a shell script. */
+export const ENGINE_ENV_PATH = '/etc/rancher/desktop/engine-env';
+
+/** Records when the file systems of the VM were last trimmed; read by `rdctl disk-usage`. */
+export const TRIM_STATE_PATH = '/var/lib/rancher-desktop/fstrim';
+
+/** The name of the periodic job trimming the file systems of the VM. */
+const TRIM_JOB_NAME = 'rancher-desktop-fstrim';
+
+/** The file holding the kernel parameters of the sysctls setting. */
+const SYSCTL_CONF_PATH = '/etc/sysctl.d/99-rancher-desktop.conf';
+
+/** The swap file of the swap setting with mode "file". */
+const SWAP_FILE_PATH = '/var/lib/rancher-desktop/swapfile';
+
+/** The prefix of the iptables chains implementing the reversePortForwards setting. */
+const REVERSE_PORT_FORWARD_CHAIN = 'RD-REVERSE-FORWARD';
+
 export default class BackendHelper {
   /**
    * Workaround for upstream error https://github.com/containerd/nerdctl/issues/1308
@@ -118,6 +142,211 @@ export default class BackendHelper {
     return patterns;
   }
 
+  /**
+   * Returns the command, to be run as root in the VM, that sets the environment
+   * variables of the container engine.  The variables are written to
+   * ENGINE_ENV_PATH, which is sourced by the OpenRC configuration of containerd
+   * and dockerd (and of buildkitd, which ships its own configuration).
+   * @param environment Entries of the form KEY=VALUE.
+   */
+  static engineEnvironmentCommand(environment: readonly string[]): string[] {
+    const exports = environment.map((entry) => {
+      const [key, ...value] = entry.split('=');
+
+      return `export ${ key }='${ value.join('=').replace(/'/g, `'\\''`) }'`;
+    });
+    const script = `
+      set -o errexit
+      mkdir -p "$(dirname ${ ENGINE_ENV_PATH })"
+      printf '%s\\n' "$@" > ${ ENGINE_ENV_PATH }
+      for service in containerd docker; do
+        conf="/etc/conf.d/$service"
+        touch "$conf"
+        sed -i '/# rancher-desktop-engine-env$/d' "$conf"
+        echo 'if [ -f ${ ENGINE_ENV_PATH } ]; then . ${ ENGINE_ENV_PATH }; fi # rancher-desktop-engine-env' >> "$conf"
+      done
+    `;
+
+    return ['sh', '-c', script, 'sh', ...exports];
+  }
+
+  /**
+   * Parse an entry of the reversePortForwards setting, of the form
+   * GUEST_PORT:HOST_PORT or PORT.
+   */
+  static parseReversePortForward(entry: string): { guestPort: number, hostPort: number } {
+    const [guestPort, hostPort = guestPort] = entry.split(':').map(port => parseInt(port, 10));
+
+    return { guestPort, hostPort };
+  }
+
+  /**
+   * Returns the command, to be run as root in the VM, that forwards TCP
+   * connections to ports of the VM to ports of the host, which is reachable at
+   * hostAddress.  The connections are rewritten with iptables, so that they
+   * work both from the VM and from containers connecting to the VM.  Connections
+   * arriving on the network interfaces of the VM itself (eth*, and the bridged
+   * rd* interfaces) are not forwarded, so that the ports of the host are not
+   * exposed to the network.  Rules from previous runs are replaced; no ports
+   * are forwarded if forwards is empty.
+   * @param forwards Entries of the form GUEST_PORT:HOST_PORT or PORT.
+   */
+  static reversePortForwardCommand(hostAddress: string, forwards: readonly string[]): string[] {
+    const ports = forwards.map((entry) => {
+      const { guestPort, hostPort } = BackendHelper.parseReversePortForward(entry);
+
+      return `${ guestPort }:${ hostPort }`;
+    });
+    const script = `
+      set -o errexit
+      host="$1"
+      shift
+      for chain in ${ REVERSE_PORT_FORWARD_CHAIN }-PRE ${ REVERSE_PORT_FORWARD_CHAIN }-DNAT ${ REVERSE_PORT_FORWARD_CHAIN }-SNAT; do
+        iptables -t nat -N "$chain" 2>/dev/null || iptables -t nat -F "$chain"
+      done
+      # Only connections from the VM itself and from containers are forwarded.
+      iptables -t nat -A ${ REVERSE_PORT_FORWARD_CHAIN }-PRE -i eth+ -j RETURN
+      iptables -t nat -A ${ REVERSE_PORT_FORWARD_CHAIN }-PRE -i rd+ -j RETURN
+      iptables -t nat -A ${ REVERSE_PORT_FORWARD_CHAIN }-PRE -j ${ REVERSE_PORT_FORWARD_CHAIN }-DNAT
+      for rule in \
+        "PREROUTING -m addrtype --dst-type LOCAL -j ${ REVERSE_PORT_FORWARD_CHAIN }-PRE" \
+        "OUTPUT -m addrtype --dst-type LOCAL -j ${ REVERSE_PORT_FORWARD_CHAIN }-DNAT"
+      do
+        iptables -t nat -C $rule 2>/dev/null || iptables -t nat -I $rule
+      done
+      # Remove the jump of earlier versions, which didn't restrict interfaces.
+      iptables -t nat -D PREROUTING -m addrtype --dst-type LOCAL -j ${ REVERSE_PORT_FORWARD_CHAIN }-DNAT 2>/dev/null || true
+      rule="POSTROUTING -j ${ REVERSE_PORT_FORWARD_CHAIN }-SNAT"
+      iptables -t nat -C $rule 2>/dev/null || iptables -t nat -I $rule
+      if [ $# -gt 0 ]; then
+        # Allow connections to localhost in the VM to be forwarded.
+        sysctl -q -w net.ipv4.conf.all.route_localnet=1
+      fi
+      for forward in "$@"; do
+        guest_port="\${forward%%:*}"
+        host_port="\${forward##*:}"
+        iptables -t nat -A ${ REVERSE_PORT_FORWARD_CHAIN }-DNAT -p tcp --dport "$guest_port" -j DNAT --to-destination "$host:$host_port"
+        iptables -t nat -A ${ REVERSE_PORT_FORWARD_CHAIN }-SNAT -p tcp -d "$host" --dport "$host_port" -m addrtype --src-type LOCAL -j MASQUERADE
+      done
+    `;
+
+    return ['sh', '-c', script, 'sh', hostAddress, ...ports];
+  }
+
+  /**
+   * Returns the command, to be run as root in the VM, that trims the file
+   * systems of the VM at the given interval, using the periodic jobs of
+   * crond; with TrimInterval.CONTINUOUS, the file systems are remounted with
+   * the discard option instead.  Each trim records the time and the number of
+   * bytes trimmed in TRIM_STATE_PATH.
+   */
+  static trimCommand(interval: TrimInterval): string[] {
+    const job = `#!/bin/sh
+# Installed by Rancher Desktop; see the trimInterval setting.
+trimmed=$(fstrim -av 2>/dev/null | sed -n 's/.*(\\([0-9]*\\) bytes) trimmed.*/\\1/p' | awk '{ sum += $1 } END { print sum + 0 }')
+mkdir -p "$(dirname ${ TRIM_STATE_PATH })"
+printf 'time=%s\\nbytes=%s\\n' "$(date +%s)" "$trimmed" > ${ TRIM_STATE_PATH }
+`;
+    const script = `
+      set -o errexit
+      interval="$1"
+      rm -f /etc/periodic/*/${ TRIM_JOB_NAME }
+      discard=nodiscard
+      if [ "$interval" = ${ TrimInterval.CONTINUOUS } ]; then
+        discard=discard
+      fi
+      # Only remount file systems with nodiscard if they were remounted with
+      # discard before, as they may have been mounted with it originally.
+      if [ $discard = discard ] || [ -f ${ TRIM_STATE_PATH }.discard ]; then
+        awk '$1 ~ "^/dev/" && $3 ~ "^(ext4|xfs|btrfs)$" { print $2 }' /proc/mounts | while read -r mount; do
+          mount -o remount,$discard "$mount" || true
+        done
+      fi
+      mkdir -p "$(dirname ${ TRIM_STATE_PATH })"
+      if [ $discard = discard ]; then
+        touch ${ TRIM_STATE_PATH }.discard
+      else
+        rm -f ${ TRIM_STATE_PATH }.discard
+      fi
+      case "$interval" in
+      ${ TrimInterval.HOURLY }|${ TrimInterval.DAILY }|${ TrimInterval.WEEKLY })
+        mkdir -p "/etc/periodic/$interval"
+        printf '%s' "$2" > "/etc/periodic/$interval/${ TRIM_JOB_NAME }"
+        chmod 755 "/etc/periodic/$interval/${ TRIM_JOB_NAME }"
+        rc-update add crond default >/dev/null 2>&1 || true
+        rc-service crond start >/dev/null 2>&1 || true
+        ;;
+      esac
+    `;
+
+    return ['sh', '-c', script, 'sh', interval, job];
+  }
+
+  /**
+   * Returns the command, to be run as root in the VM, that writes the kernel
+   * parameters of the sysctls setting to SYSCTL_CONF_PATH and applies them.
+   * Parameters removed from the setting keep their value until the VM
+   * restarts.
+   */
+  static sysctlCommand(sysctls: string[]): string[] {
+    const script = `
+      set -o errexit
+      if [ $# -eq 0 ]; then
+        rm -f ${ SYSCTL_CONF_PATH }
+        exit 0
+      fi
+      mkdir -p "$(dirname ${ SYSCTL_CONF_PATH })"
+      printf '# Written by Rancher Desktop; see the sysctls setting.\\n' > ${ SYSCTL_CONF_PATH }
+      printf '%s\\n' "$@" >> ${ SYSCTL_CONF_PATH }
+      sysctl -q -p ${ SYSCTL_CONF_PATH }
+    `;
+
+    return ['sh', '-c', script, 'sh', ...sysctls];
+  }
+
+  /**
+   * Returns the command, to be run as root in the VM, that sets up the swap
+   * space of the swap setting, replacing the one set up before.  Without the
+   * zram module, the swap space falls back to a file.  With sizeInGB 0, the
+   * size is chosen from the memory of the host.
+   */
+  static swapCommand(mode: SwapMode, sizeInGB: number): string[] {
+    const sizeInMB = Math.round((sizeInGB || defaultSwapSizeInGB(getHostResources().memoryInGB)) * 1024);
+    const script = `
+      set -o errexit
+      mode="$1"
+      size_mb="$2"
+      if grep -q '^/dev/zram0[[:space:]]' /proc/swaps; then
+        swapoff /dev/zram0
+      fi
+      if [ -e /sys/block/zram0/reset ]; then
+        echo 1 > /sys/block/zram0/reset
+      fi
+      if grep -q '^${ SWAP_FILE_PATH }[[:space:]]' /proc/swaps; then
+        swapoff ${ SWAP_FILE_PATH }
+      fi
+      rm -f ${ SWAP_FILE_PATH }
+      if [ "$mode" = ${ SwapMode.DISABLED } ]; then
+        exit 0
+      fi
+      if [ "$mode" = ${ SwapMode.ZRAM } ] && { [ -e /sys/block/zram0 ] || modprobe zram num_devices=1 2>/dev/null; }; then
+        echo zstd > /sys/block/zram0/comp_algorithm 2>/dev/null || true
+        echo "\${size_mb}M" > /sys/block/zram0/disksize
+        mkswap /dev/zram0 >/dev/null
+        # Compressed memory is faster than any disk, so it is used first.
+        swapon -p 100 /dev/zram0
+        exit 0
+      fi
+      mkdir -p "$(dirname ${ SWAP_FILE_PATH })"
+      fallocate -l "\${size_mb}M" ${ SWAP_FILE_PATH } 2>/dev/null || dd if=/dev/zero of=${ SWAP_FILE_PATH } bs=1M count="$size_mb" 2>/dev/null
+      chmod 600 ${ SWAP_FILE_PATH }
+      mkswap ${ SWAP_FILE_PATH } >/dev/null
+      swapon ${ SWAP_FILE_PATH }
+    `;
+
+    return ['sh', '-c', script, 'sh', mode, `${ sizeInMB }`];
+  }
+
   /**
    * k3s versions 1.24.1 to 1.24.3 don't support the --docker option and need to talk to
    * a cri_dockerd endpoint when using the moby engine.
@@ -0,0 +1,103 @@
+/**
+ * Capture of the state of the VM when the backend fails to start, so that the
+ * failure can be diagnosed without digging through the logs; the report is
+ * shown by `rdctl status`.
+ */
+
+import fs from 'fs';
+import path from 'path';
+
+import { getProgressErrorDescription } from './progressTracker';
+
+import Logging from '@pkg/utils/logging';
+import paths from '@pkg/utils/paths';
+
+const console = Logging.background;
+
+/** The name of the report in the logs directory; `rdctl status` reads it. */
+export const BOOT_FAILURE_REPORT = 'boot-failure.json';
+
+/** The number of lines kept from the end of each log. */
+const TAIL_LINES = 50;
+
+export type BootFailureLog = {
+  path: string;
+  /** The last lines of the log. */
+  lines: string[];
+};
+
+export type BootFailure = {
+  time: string;
+  backend: 'lima' | 'wsl';
+  /** The description of the step of the start sequence that failed, if known. */
+  step?: string;
+  error: string;
+  logs: BootFailureLog[];
+};
+
+async function tail(logPath: string): Promise<string[] | undefined> {
+  try {
+    const contents = await fs.promises.readFile(logPath, 'utf-8');
+    const lines = contents.split(/\r?\n/);
+
+    if (lines[lines.length - 1] === '') {
+      lines.pop();
+    }
+
+    return lines.slice(-TAIL_LINES);
+  } catch {
+    // Missing logs are expected, as each VM type writes different ones.
+  }
+}
+
+function describeError(error: any): string {
+  const message = error?.message ?? `${ error }`;
+  const stderr = typeof error?.stderr === 'string' ? error.stderr.trim() : '';
+
+  return stderr && !message.includes(stderr) ? `${ message }: ${ stderr }` : message;
+}
+
+/**
+ * Returns a report of the failure to start, with the end of the given logs
+ * that exist.
+ */
+export async function getBootFailure(backend: BootFailure['backend'], error: any, logPaths: string[]): Promise<BootFailure> {
+  const logs: BootFailureLog[] = [];
+
+  for (const logPath of logPaths) {
+    const lines = await tail(logPath);
+
+    if (lines) {
+      logs.push({ path: logPath, lines });
+    }
+  }
+
+  return {
+    time:  new Date().toISOString(),
+    backend,
+    step:  getProgressErrorDescription(error),
+    error: describeError(error),
+    logs,
+  };
+}
+
+/** Write the report of a failure to start; failing to do so is only logged. */
+export async function captureBootFailure(backend: BootFailure['backend'], error: any, logPaths: string[]) {
+  try {
+    const report = await getBootFailure(backend, error, logPaths);
+
+    await fs.promises.writeFile(path.join(paths.logs, BOOT_FAILURE_REPORT), JSON.stringify(report, undefined, 2), { mode: 0o600 });
+    console.log(`Wrote a report of the failure to start to ${ BOOT_FAILURE_REPORT }.`);
+  } catch (ex) {
+    console.error('Failed to capture the failure to start:', ex);
+  }
+}
+
+/** Remove the report of an earlier failure, once the backend has started. */
+export async function clearBootFailure() {
+  try {
+    await fs.promises.rm(path.join(paths.logs, BOOT_FAILURE_REPORT), { force: true });
+  } catch (ex) {
+    console.error('Failed to remove the report of an earlier failure to start:', ex);
+  }
+}
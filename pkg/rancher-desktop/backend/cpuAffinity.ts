@@ -0,0 +1,84 @@
+/**
+ * Pinning of the VM to host CPUs, as the experimental.virtualMachine.cpuAffinity
+ * setting asks.  Only QEMU on Linux supports it: the threads of the QEMU
+ * process, including the ones running the vCPUs, are pinned with taskset.
+ */
+
+import fs from 'fs';
+import path from 'path';
+
+import { CPUAffinityMode, Settings } from '@pkg/config/settings';
+import * as childProcess from '@pkg/utils/childProcess';
+import Logging from '@pkg/utils/logging';
+
+const console = Logging.lima;
+
+type CPUAffinitySettings = Settings['experimental']['virtualMachine']['cpuAffinity'];
+
+/**
+ * Expand a list like "0-3,8" into the numbers it contains.
+ */
+export function expandCPUList(list: string): number[] {
+  const result: number[] = [];
+
+  for (const range of list.split(',').map(r => r.trim()).filter(r => r)) {
+    const [first, last = first] = range.split('-').map(n => parseInt(n, 10));
+
+    if (isNaN(first) || isNaN(last) || last < first) {
+      throw new Error(`Invalid CPU list "${ list }"`);
+    }
+    for (let cpu = first; cpu <= last; cpu++) {
+      result.push(cpu);
+    }
+  }
+
+  return result;
+}
+
+async function readCPUList(file: string, missing: string): Promise<string> {
+  try {
+    return (await fs.promises.readFile(file, 'utf-8')).trim();
+  } catch (ex: any) {
+    if (ex.code === 'ENOENT') {
+      throw new Error(missing);
+    }
+    throw ex;
+  }
+}
+
+/**
+ * Return the host CPUs the VM should run on, as a list like "0-3,8", or
+ * undefined if it may run on any of them.
+ * @param affinity The cpuAffinity setting.
+ * @param sysfs Where sysfs is mounted; overridden in tests.
+ */
+export async function getAffinityCPUs(affinity: CPUAffinitySettings, sysfs = '/sys'): Promise<string | undefined> {
+  switch (affinity.mode) {
+  case CPUAffinityMode.NONE:
+    return undefined;
+  case CPUAffinityMode.CPUS:
+    return affinity.cpus || undefined;
+  case CPUAffinityMode.PERFORMANCE:
+    return await readCPUList(path.join(sysfs, 'devices', 'cpu_core', 'cpus'),
+      'The host CPU has no separate performance cores');
+  case CPUAffinityMode.EFFICIENCY:
+    return await readCPUList(path.join(sysfs, 'devices', 'cpu_atom', 'cpus'),
+      'The host CPU has no separate efficiency cores');
+  case CPUAffinityMode.NUMA_NODES: {
+    const lists = await Promise.all(expandCPUList(affinity.numaNodes).map((node) => {
+      return readCPUList(path.join(sysfs, 'devices', 'system', 'node', `node${ node }`, 'cpulist'),
+        `The host has no NUMA node ${ node }`);
+    }));
+
+    return lists.filter(list => list).join(',') || undefined;
+  }
+  }
+}
+
+/**
+ * Pin all the threads of the process to the CPUs.
+ */
+export async function pinProcess(pid: number, cpus: string): Promise<void> {
+  await childProcess.spawnFile('taskset', ['--all-tasks', '--cpu-list', '--pid', cpus, `${ pid }`],
+    { stdio: ['ignore', console, console] });
+}
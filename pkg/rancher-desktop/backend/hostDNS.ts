@@ -0,0 +1,64 @@
+/**
+ * Propagation of the DNS configuration of Linux hosts into the VM.
+ *
+ * On hosts running systemd-resolved, /etc/resolv.conf only points to its stub
+ * resolver on 127.0.0.53, which the VM can't reach, and lists none of the
+ * domains routed to the servers of a specific link, such as a VPN.  Instead,
+ * the VM sends all queries to the host resolver of Lima, which resolves them
+ * through systemd-resolved and so follows its split DNS routing, and gets the
+ * search domains of the host as read over D-Bus by `rdctl host-dns`.
+ */
+
+import * as childProcess from '@pkg/utils/childProcess';
+import Logging from '@pkg/utils/logging';
+import { executable } from '@pkg/utils/resources';
+
+const console = Logging.background;
+
+export type HostDNSDomain = {
+  name: string;
+  /** Route-only domains select the servers of names in them, but aren't searched. */
+  routeOnly: boolean;
+  servers?: string[];
+};
+
+export type HostDNSConfig = {
+  /** systemd-resolved, NetworkManager, or the path of a resolv.conf file. */
+  source: string;
+  servers: string[];
+  domains: HostDNSDomain[];
+};
+
+/**
+ * Returns the DNS configuration of the host, or undefined if it can't be
+ * detected.
+ */
+export async function getHostDNS(): Promise<HostDNSConfig | undefined> {
+  try {
+    const { stdout } = await childProcess.spawnFile(executable('rdctl'), ['host-dns'], { stdio: ['ignore', 'pipe', console] });
+
+    return JSON.parse(stdout);
+  } catch (ex) {
+    console.error('Failed to detect the DNS configuration of the host:', ex);
+  }
+}
+
+/**
+ * Returns the contents of /etc/resolv.conf for the VM, using the given
+ * resolver and the search domains of the host.
+ */
+export function hostDNSResolvConf(resolver: string, config: HostDNSConfig): string {
+  const lines = [`# Generated by Rancher Desktop from the DNS configuration of the host (${ config.source }).`];
+  const routed = config.domains.filter(domain => domain.routeOnly).map(domain => domain.name);
+  const search = config.domains.filter(domain => !domain.routeOnly).map(domain => domain.name);
+
+  if (routed.length > 0) {
+    lines.push(`# Domains resolved by specific servers of the host: ${ routed.join(' ') }`);
+  }
+  lines.push(`nameserver ${ resolver }`);
+  if (search.length > 0) {
+    lines.push(`search ${ search.join(' ') }`);
+  }
+
+  return `${ lines.join('\n') }\n`;
+}
@@ -0,0 +1,132 @@
+/**
+ * Propagation of the timezone and locale of the host into the VM, so that
+ * logs and scheduled jobs use local time instead of UTC.
+ *
+ * Containers are out of scope: neither dockerd nor containerd has a setting
+ * for the default environment of containers, so they keep using UTC unless
+ * they set TZ or mount /etc/localtime themselves.
+ */
+
+import fs from 'fs';
+import timers from 'timers';
+
+import Logging from '@pkg/utils/logging';
+
+const console = Logging.background;
+
+/** How often to check whether the timezone of the host changed. */
+const CHECK_INTERVAL = 60_000;
+
+/** Remembers the timezone applied to the VM, so that it can be undone. */
+const STATE_PATH = '/etc/rancher/desktop/host-locale';
+const PROFILE_PATH = '/etc/profile.d/rancher-desktop-locale.sh';
+
+export type HostLocale = {
+  /** The IANA name of the timezone, e.g. "Europe/Berlin". */
+  timezone: string;
+  /** The POSIX name of the locale, e.g. "de_DE.UTF-8". */
+  lang: string;
+};
+
+/**
+ * Returns the timezone of the host. On macOS and Linux, it is read from
+ * /etc/localtime, as the timezone known to the runtime is not updated when it
+ * changes.
+ */
+async function getHostTimezone(): Promise<string> {
+  if (process.platform !== 'win32') {
+    try {
+      const match = /\/zoneinfo\/(.+)$/.exec(await fs.promises.readlink('/etc/localtime'));
+
+      if (match) {
+        return match[1];
+      }
+    } catch {
+      // /etc/localtime is a copy, or doesn't exist.
+    }
+  }
+
+  return Intl.DateTimeFormat().resolvedOptions().timeZone || 'UTC';
+}
+
+export async function getHostLocale(): Promise<HostLocale> {
+  const { locale } = Intl.DateTimeFormat().resolvedOptions();
+  const [language, region] = locale.split('-').filter(part => /^[a-zA-Z]{2,3}$/.test(part));
+
+  return {
+    timezone: await getHostTimezone(),
+    lang:     `${ language || 'en' }${ region ? `_${ region.toUpperCase() }` : '' }.UTF-8`,
+  };
+}
+
+function quote(value: string): string {
+  return `'${ value.replace(/'/g, `'\\''`) }'`;
+}
+
+/**
+ * Returns a script, to be run as root in the VM, that sets the timezone and
+ * the default locale of the VM; without a locale, it undoes the changes made
+ * by an earlier run.
+ */
+export function hostLocaleScript(locale?: HostLocale): string {
+  if (!locale) {
+    return `
+      if [ -f ${ STATE_PATH } ]; then
+        rm -f /etc/localtime /etc/timezone ${ PROFILE_PATH } ${ STATE_PATH }
+      fi
+    `;
+  }
+
+  return `
+    set -o errexit
+    TZ=${ quote(locale.timezone) }
+    LANG=${ quote(locale.lang) }
+    mkdir -p "$(dirname ${ STATE_PATH })" "$(dirname ${ PROFILE_PATH })"
+    if [ -f "/usr/share/zoneinfo/$TZ" ]; then
+      ln -sf "/usr/share/zoneinfo/$TZ" /etc/localtime
+      echo "$TZ" > /etc/timezone
+    fi
+    printf 'export TZ=%s\\nexport LANG=%s\\n' "$TZ" "$LANG" > ${ PROFILE_PATH }
+    echo "$TZ" > ${ STATE_PATH }
+  `;
+}
+
+/**
+ * Watches for changes of the timezone of the host, e.g. when travelling, and
+ * calls the callback with the new locale.
+ */
+export class HostLocaleWatcher {
+  protected timer: NodeJS.Timeout | undefined;
+  protected current: HostLocale | undefined;
+
+  constructor(protected readonly apply: (locale: HostLocale) => Promise<void>) {}
+
+  /** Start watching; the locale is assumed to have been applied already. */
+  start(locale: HostLocale) {
+    this.stop();
+    this.current = locale;
+    this.timer = timers.setInterval(() => this.check(), CHECK_INTERVAL);
+  }
+
+  stop() {
+    if (this.timer) {
+      timers.clearInterval(this.timer);
+      this.timer = undefined;
+    }
+  }
+
+  protected async check() {
+    const locale = await getHostLocale();
+
+    if (locale.timezone === this.current?.timezone && locale.lang === this.current?.lang) {
+      return;
+    }
+    console.log(`Host locale changed to ${ locale.timezone } (${ locale.lang }), updating the VM.`);
+    this.current = locale;
+    try {
+      await this.apply(locale);
+    } catch (ex) {
+      console.error('Failed to update the locale of the VM:', ex);
+    }
+  }
+}
@@ -308,6 +308,9 @@ export abstract class ImageProcessor extends EventEmitter {
 
           console.log(`> ${ this.processorName } ${ subcommandName }${ argsString }:${ formatBreak }${ result.stdout.replace(/(?!<\r)\n/g, '\r\n') }`);
         }
+        if (sendNotifications) {
+          mainEvents.emit('image-operation', subcommandName, signal ? -1 : code ?? -1);
+        }
         if (code === 0) {
           if (sendNotifications) {
             window.send('ok:images-process-output', result.stdout);
@@ -7,7 +7,9 @@ import stream from 'stream';
 import tls from 'tls';
 import util from 'util';
 
-import { CustomObjectsApi, KubeConfig, V1ObjectMeta, findHomeDir } from '@kubernetes/client-node';
+import {
+  CoreV1Api, CustomObjectsApi, KubeConfig, V1ObjectMeta, V1Taint, findHomeDir,
+} from '@kubernetes/client-node';
 import { ActionOnInvalid } from '@kubernetes/client-node/dist/config_types';
 import _ from 'lodash';
 import { Response } from 'node-fetch';
@@ -19,6 +21,7 @@ import { Architecture, VMExecutor } from './backend';
 import * as K8s from '@pkg/backend/k8s';
 import { KubeClient } from '@pkg/backend/kube/client';
 import { loadFromString, exportConfig } from '@pkg/backend/kubeconfig';
+import { KubeconfigCertificates } from '@pkg/config/settings';
 import { checkConnectivity } from '@pkg/main/networking';
 import { isUnixError } from '@pkg/typings/unix.interface';
 import DownloadProgressListener from '@pkg/utils/DownloadProgressListener';
@@ -915,8 +918,10 @@ export default class K3sHelper extends events.EventEmitter {
    * set as the current context.  This assumes that K3s is already running.
    *
    * @param configReader A function that returns the kubeconfig from the K3s VM.
+   * @param settings The Kubernetes settings, for how the kubeconfig refers
+   *        to the certificates and whether it logs in with OIDC.
    */
-  async updateKubeconfig(configReader: () => Promise<string>): Promise<void> {
+  async updateKubeconfig(configReader: () => Promise<string>, settings?: K8s.BackendSettings['kubernetes']): Promise<void> {
     const contextName = 'rancher-desktop';
     const workDir = await fs.promises.mkdtemp(path.join(os.tmpdir(), 'rancher-desktop-kubeconfig-'));
 
@@ -955,6 +960,9 @@ export default class K3sHelper extends events.EventEmitter {
       const userPath = await K3sHelper.findKubeConfigToUpdate(contextName);
       const userConfig = new KubeConfig();
 
+      await this.updateKubeconfigCertificates(workConfig, contextName, path.join(path.dirname(userPath), contextName),
+        settings?.kubeconfig.certificates ?? KubeconfigCertificates.EMBEDDED);
+
       // @kubernetes/client-node throws when merging things that already exist
       const merge = <T extends { name: string }>(list: T[], additions: T[]) => {
         for (const addition of additions) {
@@ -982,6 +990,7 @@ export default class K3sHelper extends events.EventEmitter {
       merge(userConfig.contexts, workConfig.contexts);
       merge(userConfig.users, workConfig.users);
       merge(userConfig.clusters, workConfig.clusters);
+      this.updateKubeconfigOIDC(userConfig, contextName, settings?.oidc);
       userConfig.currentContext ||= contextName;
       // Use custom exportConfig() that supports the `proxy-url` cluster field.
       const userYAML = this.ensureContentsAreYAML(exportConfig(userConfig));
@@ -1000,6 +1009,87 @@ export default class K3sHelper extends events.EventEmitter {
     }
   }
 
+  /**
+   * Write the certificates of the cluster and the user of the given name to
+   * files in certsDir, and refer to them instead of embedding their data in
+   * the kubeconfig; when they are embedded, remove any files left over.
+   */
+  protected async updateKubeconfigCertificates(config: KubeConfig, name: string, certsDir: string, certificates: KubeconfigCertificates) {
+    const files = { ca: path.join(certsDir, 'ca.crt'), cert: path.join(certsDir, 'client.crt'), key: path.join(certsDir, 'client.key') };
+
+    if (certificates !== KubeconfigCertificates.FILE) {
+      await Promise.all(Object.values(files).map(file => fs.promises.rm(file, { force: true })));
+
+      return;
+    }
+
+    const write = async(filePath: string, data?: string) => {
+      if (!data) {
+        return undefined;
+      }
+      await fs.promises.writeFile(filePath, Buffer.from(data, 'base64'), { mode: 0o600 });
+
+      return filePath;
+    };
+
+    await fs.promises.mkdir(certsDir, { recursive: true, mode: 0o700 });
+    for (const [i, cluster] of config.clusters.entries()) {
+      if (cluster.name === name && cluster.caData) {
+        config.clusters[i] = {
+          ...cluster, caData: undefined, caFile: await write(files.ca, cluster.caData),
+        };
+      }
+    }
+    for (const [i, user] of config.users.entries()) {
+      if (user.name === name && (user.certData || user.keyData)) {
+        config.users[i] = {
+          ...user,
+          certData: undefined,
+          certFile: await write(files.cert, user.certData) ?? user.certFile,
+          keyData:  undefined,
+          keyFile:  await write(files.key, user.keyData) ?? user.keyFile,
+        };
+      }
+    }
+  }
+
+  /**
+   * Add a context that logs in to the cluster of the given name with the
+   * OIDC provider, through the kubelogin plugin of kubectl (`kubectl
+   * oidc-login`), or remove it when OIDC is disabled.
+   */
+  protected updateKubeconfigOIDC(config: KubeConfig, clusterName: string, oidc?: K8s.BackendSettings['kubernetes']['oidc']) {
+    const name = `${ clusterName }-oidc`;
+
+    config.contexts = config.contexts.filter(context => context.name !== name);
+    config.users = config.users.filter(user => user.name !== name);
+    if (!oidc?.issuerURL) {
+      if (config.currentContext === name) {
+        config.currentContext = clusterName;
+      }
+
+      return;
+    }
+    config.users.push({
+      name,
+      exec: {
+        apiVersion: 'client.authentication.k8s.io/v1beta1',
+        command:    'kubectl',
+        args:       [
+          'oidc-login',
+          'get-token',
+          `--oidc-issuer-url=${ oidc.issuerURL }`,
+          `--oidc-client-id=${ oidc.clientID }`,
+          ...oidc.extraScopes.map(scope => `--oidc-extra-scope=${ scope }`),
+        ],
+        interactiveMode: 'IfAvailable',
+      },
+    });
+    config.contexts.push({
+      name, cluster: clusterName, user: name,
+    });
+  }
+
   /**
    * We normally parse all the config files, yaml and json, with yaml.parse, so yaml.parse
    * should work with json here.
@@ -1014,6 +1104,75 @@ export default class K3sHelper extends events.EventEmitter {
     return contents;
   }
 
+  /**
+   * Install the certificate authority that K3s signs the certificate of the
+   * API server with; this must be done before K3s starts.  K3s only creates
+   * the certificates of a new cluster, so the Kubernetes state is deleted if
+   * the cluster was created with another certificate authority.
+   * @param executor The interface to run commands in the VM.
+   * @param certificateAuthority The paths to the PEM files on the host.
+   */
+  async installCertificateAuthority(executor: VMExecutor, certificateAuthority: K8s.BackendSettings['kubernetes']['certificateAuthority']) {
+    if (!certificateAuthority.certificate) {
+      return;
+    }
+    const tlsDir = '/var/lib/rancher/k3s/server/tls';
+    const normalize = (pem: string) => pem.replace(/\r/g, '').trim();
+    const [certificate, key] = await Promise.all([
+      fs.promises.readFile(certificateAuthority.certificate, 'utf-8'),
+      fs.promises.readFile(certificateAuthority.key, 'utf-8'),
+    ]);
+    let installed = '';
+
+    try {
+      installed = await executor.execCommand({
+        capture: true, root: true, expectFailure: true,
+      }, 'cat', `${ tlsDir }/server-ca.crt`);
+    } catch {
+      // There is no cluster yet.
+    }
+    if (normalize(installed) === normalize(certificate)) {
+      return;
+    }
+    if (installed) {
+      console.log('The certificate authority of the API server changed; deleting the Kubernetes state.');
+      await this.deleteKubeState(executor);
+    }
+    await executor.execCommand({ root: true }, 'mkdir', '-p', tlsDir);
+    await executor.writeFile(`${ tlsDir }/server-ca.crt`, certificate, 0o644);
+    await executor.writeFile(`${ tlsDir }/server-ca.key`, key, 0o600);
+  }
+
+  /**
+   * The arguments of K3s making the API server accept the ID tokens of the
+   * OIDC provider, each with a leading space; empty if OIDC is disabled.
+   */
+  static apiServerOIDCArgs(oidc: K8s.BackendSettings['kubernetes']['oidc']): string {
+    if (!oidc.issuerURL) {
+      return '';
+    }
+    const args = {
+      'oidc-issuer-url':     oidc.issuerURL,
+      'oidc-client-id':      oidc.clientID,
+      'oidc-username-claim': oidc.usernameClaim,
+      'oidc-groups-claim':   oidc.groupsClaim,
+    };
+
+    return Object.entries(args)
+      .filter(([, value]) => value)
+      .map(([key, value]) => ` --kube-apiserver-arg=${ key }=${ value }`)
+      .join('');
+  }
+
+  /**
+   * The severity of a change of the certificate authority of the API server:
+   * setting it resets Kubernetes (see installCertificateAuthority), while
+   * clearing it restarts Kubernetes, which keeps the certificates it has.
+   */
+  static certificateAuthorityChangeSeverity(_: string, desired: string): 'restart' | 'reset' {
+    return desired ? 'reset' : 'restart';
+  }
+
   /**
    * Delete state related to Kubernetes.  This will ensure that images are not
    * deleted.
@@ -1034,6 +1193,22 @@ export default class K3sHelper extends events.EventEmitter {
     await Promise.all(directories.map(d => executor.execCommand({ root: true }, 'rm', '-rf', d)));
   }
 
+  /**
+   * Remove the iptables rules of the network policy controller of K3s, which
+   * it leaves behind when it is disabled; this must be done before K3s starts.
+   * Failures are only logged, as the rules may also not exist.
+   * @param executor The interface to run commands in the VM.
+   */
+  async removeNetworkPolicyRules(executor: VMExecutor) {
+    const script = 'for cmd in iptables ip6tables; do "$cmd-save" | grep -v KUBE-ROUTER | "$cmd-restore"; done';
+
+    try {
+      await executor.execCommand({ root: true, expectFailure: true }, '/bin/sh', '-c', script);
+    } catch (ex) {
+      console.log(`Failed to remove the network policy rules: ${ ex }`);
+    }
+  }
+
   /**
    * Manually uninstall the K3s-installed copy of Traefik, if it exists.
    * This exists to work around https://github.com/k3s-io/k3s/issues/5103
@@ -1062,6 +1237,79 @@ export default class K3sHelper extends events.EventEmitter {
     }
   }
 
+  /**
+   * Apply the labels and taints from the settings to the nodes. The labels
+   * and taints that were applied are recorded in an annotation on the node, so
+   * that the ones removed from the settings are removed from the node the next
+   * time this is called.
+   * @param labels Labels in the form KEY=VALUE.
+   * @param taints Taints in the form KEY[=VALUE]:EFFECT.
+   */
+  async updateNodeLabelsAndTaints(client: KubeClient, labels: readonly string[], taints: readonly string[]) {
+    const annotation = 'rancherdesktop.io/node-config';
+    const coreApi = client.k8sClient.makeApiClient(CoreV1Api);
+    const desiredLabels = Object.fromEntries(labels.map((label) => {
+      const [key, ...value] = label.split('=');
+
+      return [key, value.join('=')];
+    }));
+    const desiredTaints = taints.map((taint): V1Taint => {
+      const effectIndex = taint.lastIndexOf(':');
+      const [key, ...value] = taint.substring(0, effectIndex).split('=');
+
+      return {
+        key,
+        value:  value.length > 0 ? value.join('=') : undefined,
+        effect: taint.substring(effectIndex + 1),
+      };
+    });
+    const taintID = (taint: V1Taint) => `${ taint.key }:${ taint.effect }`;
+    const maxAttempts = 30;
+
+    // The node may not be registered yet, and updates fail if the node is
+    // modified concurrently (e.g. by the kubelet), so retry for a while.
+    for (let attempt = 1; attempt <= maxAttempts; attempt++) {
+      try {
+        const { body: { items: nodes } } = await coreApi.listNode();
+
+        if (nodes.length === 0) {
+          throw new Error('no nodes are registered');
+        }
+        for (const node of nodes) {
+          const metadata = node.metadata ??= {};
+          const annotations = metadata.annotations ??= {};
+          const nodeLabels = metadata.labels ??= {};
+
+          if (!annotations[annotation] && labels.length === 0 && taints.length === 0) {
+            continue;
+          }
+          const previous: { labels?: string[], taints?: string[] } = JSON.parse(annotations[annotation] ?? '{}');
+
+          for (const key of previous.labels ?? []) {
+            delete nodeLabels[key];
+          }
+          Object.assign(nodeLabels, desiredLabels);
+          const replacedTaints = new Set((previous.taints ?? []).concat(desiredTaints.map(taintID)));
+          const spec = node.spec ??= {};
+
+          spec.taints = (spec.taints ?? []).filter(taint => !replacedTaints.has(taintID(taint))).concat(desiredTaints);
+          annotations[annotation] = JSON.stringify({ labels: Object.keys(desiredLabels), taints: desiredTaints.map(taintID) });
+          await coreApi.replaceNode(metadata.name ?? '', node);
+        }
+
+        return;
+      } catch (ex) {
+        if (attempt === maxAttempts) {
+          console.error('Error updating node labels and taints', ex);
+
+          return;
+        }
+        console.debug(`Failed to update node labels and taints (attempt ${ attempt }), retrying`, ex);
+        await util.promisify(setTimeout)(1_000);
+      }
+    }
+  }
+
   /**
    * Rancher Desktop's exposed `kubectl` utility is actually a wrapper around `kuberlr`,
    * which guarantees that the actual true `kubectl` utility is compatible
@@ -145,8 +145,12 @@ export default class LimaKubernetesBackend extends events.EventEmitter implement
     if (!config.kubernetes.options.flannel) {
       await this.vm.execCommand({ root: true }, 'rm', '-f', '/etc/cni/net.d/10-flannel.conflist');
     }
+    if (!config.kubernetes.options.networkPolicy) {
+      await this.k3sHelper.removeNetworkPolicyRules(this.vm);
+    }
 
     await this.progressTracker.action('Starting k3s', 100, async() => {
+      await this.k3sHelper.installCertificateAuthority(this.vm, config.kubernetes.certificateAuthority);
       // Run rc-update as we have dynamic dependencies.
       await this.vm.execCommand({ root: true }, '/sbin/rc-update', '--update');
       await this.vm.execCommand({ root: true }, '/sbin/rc-service', '--ifnotstarted', 'k3s', 'start');
@@ -184,7 +188,8 @@ export default class LimaKubernetesBackend extends events.EventEmitter implement
           k3sEndpoint = k3sConfig?.clusters?.[0]?.cluster?.server;
 
           return k3sConfigString;
-        }));
+        },
+        config.kubernetes));
 
     this.client = kubeClient || new KubeClient();
 
@@ -234,6 +239,12 @@ export default class LimaKubernetesBackend extends events.EventEmitter implement
           await new Promise(resolve => setTimeout(resolve, 5000));
         });
     }
+    if (this.client && this.cfg) {
+      await this.progressTracker.action(
+        'Updating node labels and taints',
+        50,
+        this.k3sHelper.updateNodeLabelsAndTaints(this.client, this.cfg.kubernetes.node.labels, this.cfg.kubernetes.node.taints));
+    }
 
     return k3sEndpoint;
   }
@@ -341,9 +352,18 @@ export default class LimaKubernetesBackend extends events.EventEmitter implement
         config.ADDITIONAL_ARGS += ' --flannel-backend=none --disable-network-policy';
       }
     }
+    // Without flannel, the network policy controller is already disabled.
+    if (!cfg.kubernetes.options.networkPolicy && (cfg.kubernetes.options.flannel || os.platform() !== 'darwin')) {
+      console.log(`Disabling network policy`);
+      config.ADDITIONAL_ARGS += ' --disable-network-policy';
+    }
     if (!cfg.kubernetes.options.traefik) {
       config.ADDITIONAL_ARGS += ' --disable traefik';
     }
+    if (cfg.kubernetes.storage.path) {
+      config.ADDITIONAL_ARGS += ` --default-local-storage-path ${ cfg.kubernetes.storage.path }`;
+    }
+    config.ADDITIONAL_ARGS += K3sHelper.apiServerOIDCArgs(cfg.kubernetes.oidc);
     await this.vm.writeFile('/etc/init.d/cri-dockerd', SERVICE_CRI_DOCKERD_SCRIPT, 0o755);
     await this.vm.writeConf('cri-dockerd', {
       LOG_DIR: paths.logs,
@@ -383,13 +403,25 @@ export default class LimaKubernetesBackend extends events.EventEmitter implement
 
           return 'restart';
         },
-        'application.adminAccess':               undefined,
-        'containerEngine.allowedImages.enabled': undefined,
-        'containerEngine.name':                  undefined,
-        'kubernetes.port':                       undefined,
-        'kubernetes.enabled':                    undefined,
-        'kubernetes.options.traefik':            undefined,
-        'kubernetes.options.flannel':            undefined,
+        'application.adminAccess':                     undefined,
+        'containerEngine.allowedImages.enabled':       undefined,
+        'containerEngine.name':                        undefined,
+        'kubernetes.port':                             undefined,
+        'kubernetes.enabled':                          undefined,
+        'kubernetes.options.traefik':                  undefined,
+        'kubernetes.options.flannel':                  undefined,
+        'kubernetes.options.networkPolicy':            undefined,
+        'kubernetes.node.labels':                      undefined,
+        'kubernetes.node.taints':                      undefined,
+        'kubernetes.storage.path':                     undefined,
+        'kubernetes.certificateAuthority.certificate': K3sHelper.certificateAuthorityChangeSeverity,
+        'kubernetes.certificateAuthority.key':         undefined,
+        'kubernetes.kubeconfig.certificates':          undefined,
+        'kubernetes.oidc.issuerURL':                   undefined,
+        'kubernetes.oidc.clientID':                    undefined,
+        'kubernetes.oidc.usernameClaim':               undefined,
+        'kubernetes.oidc.groupsClaim':                 undefined,
+        'kubernetes.oidc.extraScopes':                 undefined,
       },
       extra,
     );
@@ -186,7 +186,13 @@ export default class WSLKubernetesBackend extends events.EventEmitter implements
     if (!config.kubernetes.options.flannel) {
       await this.vm.execCommand('busybox', 'rm', '-f', '/etc/cni/net.d/10-flannel.conflist');
     }
-    await this.progressTracker.action('Starting k3s', 100, this.vm.startService('k3s'));
+    if (!config.kubernetes.options.networkPolicy) {
+      await this.k3sHelper.removeNetworkPolicyRules(this.vm);
+    }
+    await this.progressTracker.action('Starting k3s', 100, async() => {
+      await this.k3sHelper.installCertificateAuthority(this.vm, config.kubernetes.certificateAuthority);
+      await this.vm.startService('k3s');
+    });
 
     if (this.vm.currentAction !== Action.STARTING) {
       // User aborted
@@ -215,7 +221,8 @@ export default class WSLKubernetesBackend extends events.EventEmitter implements
         const rdNetworking = `--rd-networking=${ config?.experimental.virtualMachine.networkingTunnel }`;
 
         await this.k3sHelper.updateKubeconfig(
-          async() => await this.vm.execCommand({ capture: true }, await this.vm.getWSLHelperPath(), 'k3s', 'kubeconfig', rdNetworking));
+          async() => await this.vm.execCommand({ capture: true }, await this.vm.getWSLHelperPath(), 'k3s', 'kubeconfig', rdNetworking),
+          config.kubernetes);
       });
 
     const client = this.client = kubeClient || new KubeClient();
@@ -260,6 +267,10 @@ export default class WSLKubernetesBackend extends events.EventEmitter implements
         'Skipping node checks, flannel is disabled',
         100, Promise.resolve({}));
     }
+    await this.progressTracker.action(
+      'Updating node labels and taints',
+      50,
+      this.k3sHelper.updateNodeLabelsAndTaints(client, config.kubernetes.node.labels, config.kubernetes.node.taints));
 
     return '';
   }
@@ -291,15 +302,27 @@ export default class WSLKubernetesBackend extends events.EventEmitter implements
 
           return 'restart';
         },
-        'containerEngine.allowedImages.enabled': undefined,
-        'containerEngine.name':                  undefined,
-        'kubernetes.enabled':                    undefined,
-        'kubernetes.ingress.localhostOnly':      undefined,
-        'kubernetes.options.flannel':            undefined,
-        'kubernetes.options.traefik':            undefined,
-        'kubernetes.port':                       undefined,
-        'virtualMachine.hostResolver':           undefined,
-        'WSL.integrations':                      undefined,
+        'containerEngine.allowedImages.enabled':       undefined,
+        'containerEngine.name':                        undefined,
+        'kubernetes.certificateAuthority.certificate': K3sHelper.certificateAuthorityChangeSeverity,
+        'kubernetes.certificateAuthority.key':         undefined,
+        'kubernetes.enabled':                          undefined,
+        'kubernetes.ingress.localhostOnly':            undefined,
+        'kubernetes.kubeconfig.certificates':          undefined,
+        'kubernetes.node.labels':                      undefined,
+        'kubernetes.node.taints':                      undefined,
+        'kubernetes.oidc.clientID':                    undefined,
+        'kubernetes.oidc.extraScopes':                 undefined,
+        'kubernetes.oidc.groupsClaim':                 undefined,
+        'kubernetes.oidc.issuerURL':                   undefined,
+        'kubernetes.oidc.usernameClaim':               undefined,
+        'kubernetes.options.flannel':                  undefined,
+        'kubernetes.options.networkPolicy':            undefined,
+        'kubernetes.options.traefik':                  undefined,
+        'kubernetes.port':                             undefined,
+        'kubernetes.storage.path':                     undefined,
+        'virtualMachine.hostResolver':                 undefined,
+        'WSL.integrations':                            undefined,
       },
       extras,
     ));
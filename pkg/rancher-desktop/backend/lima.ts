@@ -20,12 +20,18 @@ import tar from 'tar-stream';
 import yaml from 'yaml';
 
 import {
-  Architecture, BackendError, BackendEvents, BackendProgress, BackendSettings, execOptions, FailureDetails, RestartReasons, State, VMBackend, VMExecutor,
+  Architecture, BackendError, BackendEvents, BackendProgress, BackendSettings, execOptions, FailureDetails, RestartReasons, State, SubState, VMBackend, VMExecutor,
 } from './backend';
 import BackendHelper from './backendHelper';
+import { captureBootFailure, clearBootFailure } from './bootFailure';
+import { expandCPUList, getAffinityCPUs, pinProcess } from './cpuAffinity';
+import { getNestedVirtualizationSupport } from './nestedVirtualization';
 import { ContainerEngineClient, MobyClient, NerdctlClient } from './containerClient';
+import { getHostDNS, hostDNSResolvConf } from './hostDNS';
+import { getHostLocale, hostLocaleScript, HostLocaleWatcher } from './hostLocale';
 import * as K8s from './k8s';
 import ProgressTracker, { getProgressErrorDescription } from './progressTracker';
+import { runStartupTasks, StartupTask } from './startupTasks';
 
 import DEPENDENCY_VERSIONS from '@pkg/assets/dependencies.yaml';
 import DEFAULT_CONFIG from '@pkg/assets/lima-config.yaml';
@@ -34,12 +40,21 @@ import FLANNEL_CONFLIST from '@pkg/assets/scripts/10-flannel.conflist';
 import SERVICE_BUILDKITD_CONF from '@pkg/assets/scripts/buildkit.confd';
 import SERVICE_BUILDKITD_INIT from '@pkg/assets/scripts/buildkit.initd';
 import DOCKER_CREDENTIAL_SCRIPT from '@pkg/assets/scripts/docker-credential-rancher-desktop';
+import GIT_CREDENTIAL_SCRIPT from '@pkg/assets/scripts/git-credential-rancher-desktop';
 import CONTAINERD_CONFIG from '@pkg/assets/scripts/k3s-containerd-config.toml';
 import LOGROTATE_LIMA_GUESTAGENT_SCRIPT from '@pkg/assets/scripts/logrotate-lima-guestagent';
 import LOGROTATE_OPENRESTY_SCRIPT from '@pkg/assets/scripts/logrotate-openresty';
 import NERDCTL from '@pkg/assets/scripts/nerdctl';
 import NGINX_CONF from '@pkg/assets/scripts/nginx.conf';
-import { ContainerEngine, MountType, VMType } from '@pkg/config/settings';
+import {
+  ContainerEngine,
+  CPUAffinityMode,
+  MountType,
+  SwapMode,
+  TrimInterval,
+  VMType,
+} from '@pkg/config/settings';
+import { gitConfigForVM, updateGitConfig } from '@pkg/main/credentialServer/gitCredentials';
 import { getServerCredentialsPath, ServerState } from '@pkg/main/credentialServer/httpCredentialHelperServer';
 import mainEvents from '@pkg/main/mainEvents';
 import * as childProcess from '@pkg/utils/childProcess';
@@ -116,6 +131,7 @@ export type LimaConfiguration = {
   ssh: {
     localPort: number;
     loadDotSSHPubKeys?: boolean;
+    forwardAgent?: boolean;
   }
   firmware?: {
     legacyBIOS?: boolean;
@@ -244,6 +260,8 @@ const ALPINE_VERSION = DEPENDENCY_VERSIONS.alpineLimaISO.alpineVersion;
 const ETC_RANCHER_DESKTOP_DIR = '/etc/rancher/desktop';
 const CREDENTIAL_FORWARDER_SETTINGS_PATH = path.join(ETC_RANCHER_DESKTOP_DIR, 'credfwd');
 const DOCKER_CREDENTIAL_PATH = '/usr/local/bin/docker-credential-rancher-desktop';
+const GIT_CREDENTIAL_PATH = '/usr/local/bin/git-credential-rancher-desktop';
+const SYSTEM_GIT_CONFIG_PATH = '/etc/gitconfig';
 const ROOT_DOCKER_CONFIG_DIR = '/root/.docker';
 const ROOT_DOCKER_CONFIG_PATH = path.join(ROOT_DOCKER_CONFIG_DIR, 'config.json');
 
@@ -346,6 +364,9 @@ export default class LimaBackend extends events.EventEmitter implements VMBacken
   /** A transient property that prevents prompting via modal UI elements. */
   #noModalDialogs = false;
 
+  /** Follows changes of the timezone of the host, if the hostLocale setting is enabled. */
+  protected hostLocaleWatcher = new HostLocaleWatcher(locale => this.execCommand({ root: true }, 'sh', '-c', hostLocaleScript(locale)));
+
   get noModalDialogs() {
     return this.#noModalDialogs;
   }
@@ -354,6 +375,9 @@ export default class LimaBackend extends events.EventEmitter implements VMBacken
     this.#noModalDialogs = value;
   }
 
+  /** A transient property that skips the user's provisioning scripts. */
+  safeMode = false;
+
   /** Helper object to manage progress notifications. */
   progressTracker;
 
@@ -375,8 +399,21 @@ export default class LimaBackend extends events.EventEmitter implements VMBacken
     return this.internalState;
   }
 
+  protected internalSubState: SubState | undefined;
+  get subState() {
+    return this.internalSubState;
+  }
+
+  protected setSubState(subState: SubState) {
+    console.log(`Backend is starting: ${ subState }`);
+    this.internalSubState = subState;
+  }
+
   protected async setState(state: State) {
     this.internalState = state;
+    if (state !== State.STARTING) {
+      this.internalSubState = undefined;
+    }
     this.emit('state-changed', this.state);
     switch (this.state) {
     case State.STOPPING:
@@ -678,7 +715,10 @@ export default class LimaBackend extends events.EventEmitter implements VMBacken
       memory:       (this.cfg?.virtualMachine.memoryInGB || 4) * 1024 * 1024 * 1024,
       mounts:       this.getMounts(),
       mountType:    this.cfg?.experimental.virtualMachine.mount.type,
-      ssh:          { localPort: await this.sshPort },
+      ssh:          {
+        localPort:    await this.sshPort,
+        forwardAgent: !!this.cfg?.experimental.virtualMachine.sshAgentForwarding,
+      },
       hostResolver: {
         hosts: {
           // As far as lima is concerned, the instance name is 'lima-0'.
@@ -1811,9 +1851,26 @@ export default class LimaBackend extends events.EventEmitter implements VMBacken
     }
 
     await this.progressTracker.action('Starting virtual machine', 100, async() => {
+      // The user's provisioning scripts are in lima's override file, which it
+      // only reads when starting the VM; set it aside for that in safe mode.
+      const overridePath = path.join(paths.lima, '_config', 'override.yaml');
+      const hiddenOverridePath = `${ overridePath }.safe-mode`;
+
+      await this.restoreOverrideConfig(overridePath, hiddenOverridePath);
+      if (this.safeMode) {
+        try {
+          await fs.promises.rename(overridePath, hiddenOverridePath);
+          console.log('Safe mode: skipping provisioning scripts.');
+        } catch (ex: any) {
+          if (ex.code !== 'ENOENT') {
+            throw ex;
+          }
+        }
+      }
       try {
         await this.lima('start', '--tty=false', await this.isRegistered ? MACHINE_NAME : this.CONFIG_PATH);
       } finally {
+        await this.restoreOverrideConfig(overridePath, hiddenOverridePath);
         // Symlink the logs (especially if start failed) so the users can find them
         const machineDir = path.join(paths.lima, MACHINE_NAME);
 
@@ -1831,6 +1888,52 @@ export default class LimaBackend extends events.EventEmitter implements VMBacken
         }
       }
     });
+    if (process.platform === 'linux') {
+      await this.progressTracker.action('Pinning the VM to host CPUs', 50, this.pinVMCPUs());
+    }
+  }
+
+  /**
+   * Pin the QEMU process to the host CPUs the cpuAffinity setting selects.
+   * Failing to do so only affects performance, so it is only logged.
+   */
+  protected async pinVMCPUs() {
+    const affinity = this.cfg?.experimental.virtualMachine.cpuAffinity;
+
+    if (!affinity || affinity.mode === CPUAffinityMode.NONE) {
+      return;
+    }
+    try {
+      const cpus = await getAffinityCPUs(affinity);
+
+      if (!cpus) {
+        return;
+      }
+      const pid = parseInt(await fs.promises.readFile(path.join(paths.lima, MACHINE_NAME, 'qemu.pid'), 'utf-8'), 10);
+      const numberCPUs = this.cfg?.virtualMachine.numberCPUs ?? 0;
+
+      if (expandCPUList(cpus).length < numberCPUs) {
+        console.log(`The VM has ${ numberCPUs } CPUs, but is pinned to fewer host CPUs (${ cpus })`);
+      }
+      await pinProcess(pid, cpus);
+      console.log(`Pinned the VM to the host CPUs ${ cpus }`);
+    } catch (err: any) {
+      console.log('Error trying to pin the VM to host CPUs:', err);
+    }
+  }
+
+  /**
+   * Put back the override file set aside in safe mode, including after a
+   * previous run was interrupted while starting the VM.
+   */
+  protected async restoreOverrideConfig(overridePath: string, hiddenOverridePath: string) {
+    try {
+      await fs.promises.rename(hiddenOverridePath, overridePath);
+    } catch (ex: any) {
+      if (ex.code !== 'ENOENT') {
+        console.error(`Failed to restore ${ overridePath }:`, ex);
+      }
+    }
   }
 
   async start(config_: BackendSettings): Promise<void> {
@@ -1872,9 +1975,11 @@ export default class LimaBackend extends events.EventEmitter implements VMBacken
           }
         }
         // Start the VM; if it's already running, this does nothing.
+        this.setSubState(SubState.VM_BOOTING);
         await this.startVM();
 
         if (config.kubernetes.enabled) {
+          this.setSubState(SubState.IMAGES_LOADING);
           [kubernetesVersion, isDowngrade] = await this.kubeBackend.download(config);
 
           if (typeof (kubernetesVersion) === 'undefined') {
@@ -1911,35 +2016,62 @@ export default class LimaBackend extends events.EventEmitter implements VMBacken
           await this.kubeBackend.deleteIncompatibleData(kubernetesVersion);
         }
 
-        await Promise.all([
-          this.progressTracker.action('Installing CA certificates', 50, this.installCACerts()),
-          this.progressTracker.action('Configuring image proxy', 50, this.configureOpenResty(config)),
-          this.progressTracker.action('Configuring containerd', 50, this.configureContainerd()),
-          this.progressTracker.action('Configuring logrotate', 50, this.configureLogrotate()),
-        ]);
+        // The steps only wait for the ones they depend on; the ones that
+        // only write files into the VM run alongside starting the engine.
+        const startupTasks: Record<string, StartupTask> = {
+          certificates:      { description: 'Installing CA certificates', run: () => this.installCACerts() },
+          imageProxy:        { description: 'Configuring image proxy', run: () => this.configureOpenResty(config) },
+          containerd:        { description: 'Configuring containerd', run: () => this.configureContainerd() },
+          logrotate:         { description: 'Configuring logrotate', run: () => this.configureLogrotate() },
+          sysctl:            { description: 'Configuring kernel parameters', run: () => this.installSysctls() },
+          swap:              { description: 'Configuring swap', run: () => this.installSwap() },
+          engine:            {
+            description: 'Starting container engine',
+            after:       ['certificates', 'imageProxy', 'containerd', 'sysctl', 'swap'],
+            run:         async() => {
+              if (config.containerEngine.allowedImages.enabled) {
+                await this.startService('openresty');
+              }
+              await this.execCommand({ root: true }, ...BackendHelper.engineEnvironmentCommand(config.containerEngine.environment));
+              switch (config.containerEngine.name) {
+              case ContainerEngine.CONTAINERD:
+                await this.startService('containerd');
+                break;
+              case ContainerEngine.MOBY:
+                await this.startService('docker');
+                break;
+              case ContainerEngine.NONE:
+                throw new Error('No container engine is set');
+              }
+            },
+          },
+          buildkit:          { description: 'Installing Buildkit', run: () => this.writeBuildkitScripts() },
+          trivy:             { description: 'Installing image scanner', run: () => this.installTrivy() },
+          credentialHelper:  { description: 'Installing credential helper', run: () => this.installCredentialHelper() },
+          git:               { description: 'Configuring git', run: () => this.installGitBridge() },
+          locale:            { description: 'Configuring locale', run: () => this.installHostLocale() },
+          dns:               { description: 'Configuring DNS', run: () => this.installHostDNS() },
+          ports:             { description: 'Forwarding host ports', run: () => this.installReversePortForwards() },
+          trim:              { description: 'Configuring disk trimming', run: () => this.installTrim() },
+        };
+        const version = kubernetesVersion;
 
-        if (config.containerEngine.allowedImages.enabled) {
-          await this.startService('openresty');
-        }
-        switch (config.containerEngine.name) {
-        case ContainerEngine.CONTAINERD:
-          await this.startService('containerd');
-          break;
-        case ContainerEngine.MOBY:
-          await this.startService('docker');
-          break;
-        case ContainerEngine.NONE:
-          throw new Error('No container engine is set');
+        if (process.platform === 'linux' && config.experimental.virtualMachine.nestedVirtualization) {
+          startupTasks.nestedVirtualization = {
+            description: 'Enabling nested virtualization',
+            run:         () => this.installNestedVirtualization(),
+          };
         }
-        if (kubernetesVersion) {
-          await this.kubeBackend.install(config, kubernetesVersion, this.#adminAccess);
+        if (version) {
+          startupTasks.k3s = {
+            description: 'Installing k3s',
+            after:       ['engine'],
+            run:         () => this.kubeBackend.install(config, version, this.#adminAccess),
+          };
         }
 
-        await this.progressTracker.action('Installing Buildkit', 50, this.writeBuildkitScripts());
-        await Promise.all([
-          this.progressTracker.action('Installing image scanner', 50, this.installTrivy()),
-          this.progressTracker.action('Installing credential helper', 50, this.installCredentialHelper()),
-        ]);
+        this.setSubState(SubState.PROVISIONING);
+        await runStartupTasks(this.progressTracker, startupTasks);
 
         if (this.currentAction !== Action.STARTING) {
           // User aborted
@@ -1956,12 +2088,14 @@ export default class LimaBackend extends events.EventEmitter implements VMBacken
           break;
         }
 
+        this.setSubState(SubState.ENGINE_STARTING);
         await this.#containerEngineClient.waitForReady();
 
         /** k3sEndpoint is the Kubernetes endpoint we want to use for the docker config. */
         let k3sEndpoint: string | undefined;
 
         if (kubernetesVersion) {
+          this.setSubState(SubState.K8S_WAITING);
           k3sEndpoint = await this.kubeBackend.start(config, kubernetesVersion);
         }
         if (config.containerEngine.name === ContainerEngine.MOBY) {
@@ -1972,8 +2106,10 @@ export default class LimaBackend extends events.EventEmitter implements VMBacken
         }
 
         await this.setState(config.kubernetes.enabled ? State.STARTED : State.DISABLED);
+        await clearBootFailure();
       } catch (err) {
         console.error('Error starting lima:', err);
+        await captureBootFailure('lima', err, this.bootFailureLogs);
         await this.setState(State.ERROR);
         if (err instanceof BackendError) {
           if (!err.fatal) {
@@ -1987,6 +2123,16 @@ export default class LimaBackend extends events.EventEmitter implements VMBacken
     });
   }
 
+  /** The logs included in the report of a failure to start. */
+  protected get bootFailureLogs(): string[] {
+    const machineDir = path.join(paths.lima, MACHINE_NAME);
+
+    return [
+      ...['serial.log', 'serialv.log', 'ha.stderr.log'].map(name => path.join(machineDir, name)),
+      console.path,
+    ];
+  }
+
   protected async startService(serviceName: string) {
     await this.progressTracker.action(`Starting ${ serviceName }`, 50, async() => {
       await this.execCommand({ root: true }, '/sbin/rc-service', '--ifnotstarted', serviceName, 'start');
@@ -2065,6 +2211,147 @@ CREDFWD_URL='http://${ SLIRP.HOST_GATEWAY }:${ stateInfo.port }'
     }
   }
 
+  /**
+   * Make the ports of the host listed in the reversePortForwards setting
+   * available in the VM, through the SLIRP gateway.
+   */
+  protected async installReversePortForwards() {
+    const forwards = this.cfg?.experimental.virtualMachine.reversePortForwards ?? [];
+
+    try {
+      await this.execCommand({ root: true }, ...BackendHelper.reversePortForwardCommand(SLIRP.HOST_GATEWAY, forwards));
+    } catch (err: any) {
+      console.log('Error trying to forward ports of the host:', err);
+    }
+  }
+
+  /**
+   * Apply the kernel parameters of the sysctls setting, before containers
+   * start, so that they see them.
+   */
+  protected async installSysctls() {
+    const sysctls = this.cfg?.experimental.virtualMachine.sysctls ?? [];
+
+    try {
+      await this.execCommand({ root: true }, ...BackendHelper.sysctlCommand(sysctls));
+    } catch (err: any) {
+      console.log('Error trying to set kernel parameters:', err);
+    }
+  }
+
+  /**
+   * Set up the swap space of the swap setting, before containers start, so
+   * that memory-heavy builds swap rather than get k3s killed.
+   */
+  protected async installSwap() {
+    const { mode, sizeInGB } = this.cfg?.experimental.virtualMachine.swap ?? { mode: SwapMode.ZRAM, sizeInGB: 0 };
+
+    try {
+      await this.execCommand({ root: true }, ...BackendHelper.swapCommand(mode, sizeInGB));
+    } catch (err: any) {
+      console.log('Error trying to set up swap:', err);
+    }
+  }
+
+  /**
+   * Trim the file systems of the VM as often as the trimInterval setting
+   * asks, so that deleted data is released to the disk image.
+   */
+  protected async installTrim() {
+    const interval = this.cfg?.experimental.virtualMachine.trimInterval ?? TrimInterval.WEEKLY;
+
+    try {
+      await this.execCommand({ root: true }, ...BackendHelper.trimCommand(interval));
+    } catch (err: any) {
+      console.log('Error trying to configure disk trimming:', err);
+    }
+  }
+
+  /**
+   * Load KVM in the VM, so that it can run virtual machines itself.  If the
+   * host doesn't support it, this is only logged; the diagnostics report why.
+   */
+  protected async installNestedVirtualization() {
+    try {
+      const support = await getNestedVirtualizationSupport();
+
+      if (!support.supported || !support.module) {
+        console.log(`Nested virtualization is not available: ${ support.reason }`);
+
+        return;
+      }
+      await this.execCommand({ root: true }, 'modprobe', support.module);
+    } catch (err: any) {
+      console.log('Error trying to enable nested virtualization:', err);
+    }
+  }
+
+  /**
+   * Apply the timezone and locale of the host to the VM, and follow changes
+   * of the timezone, when the hostLocale setting is enabled; undo it otherwise.
+   */
+  protected async installHostLocale() {
+    try {
+      if (this.cfg?.experimental.virtualMachine.hostLocale) {
+        const locale = await getHostLocale();
+
+        await this.execCommand({ root: true }, 'sh', '-c', hostLocaleScript(locale));
+        this.hostLocaleWatcher.start(locale);
+      } else {
+        await this.execCommand({ root: true }, 'sh', '-c', hostLocaleScript());
+      }
+    } catch (err: any) {
+      console.log('Error trying to update the locale of the VM:', err);
+    }
+  }
+
+  /**
+   * On Linux, configure the resolver of the VM with the search domains of the
+   * host, as detected from systemd-resolved or NetworkManager.
+   */
+  protected async installHostDNS() {
+    if (process.platform !== 'linux') {
+      return;
+    }
+    try {
+      const config = await getHostDNS();
+
+      if (config) {
+        console.log(`Using the DNS configuration of the host from ${ config.source }.`);
+        await this.writeFile('/etc/resolv.conf', hostDNSResolvConf(await this.getResolver(), config), 0o644);
+      }
+    } catch (err: any) {
+      console.log('Error trying to update the DNS configuration of the VM:', err);
+    }
+  }
+
+  /**
+   * Configure git in the VM to use the credentials and identity of the host
+   * when the gitBridge setting is enabled, and undo it otherwise.
+   */
+  protected async installGitBridge() {
+    try {
+      const enabled = !!this.cfg?.experimental.virtualMachine.gitBridge;
+      // Only our block of the system git configuration is replaced; a missing
+      // file reads as empty, but other errors must not lose the rest of it.
+      const current = await this.execCommand({ capture: true, root: true }, '/bin/sh', '-c', `[ ! -e ${ SYSTEM_GIT_CONFIG_PATH } ] || cat ${ SYSTEM_GIT_CONFIG_PATH }`);
+      const contents = updateGitConfig(current, enabled ? await gitConfigForVM() : '');
+
+      if (enabled) {
+        await this.writeFile(GIT_CREDENTIAL_PATH, GIT_CREDENTIAL_SCRIPT, 0o755);
+      } else {
+        await this.execCommand({ root: true }, 'rm', '-f', GIT_CREDENTIAL_PATH);
+      }
+      if (!contents) {
+        await this.execCommand({ root: true }, 'rm', '-f', SYSTEM_GIT_CONFIG_PATH);
+      } else if (contents !== current) {
+        await this.writeFile(SYSTEM_GIT_CONFIG_PATH, contents, 0o644);
+      }
+    } catch (err: any) {
+      console.log('Error trying to update the git configuration:', err);
+    }
+  }
+
   async stop(): Promise<void> {
     // When we manually call stop, the subprocess will terminate, which will
     // cause stop to get called again.  Prevent the reentrancy.
@@ -2076,6 +2363,7 @@ CREDFWD_URL='http://${ SLIRP.HOST_GATEWAY }:${ stateInfo.port }'
       return;
     }
     this.currentAction = Action.STOPPING;
+    this.hostLocaleWatcher.stop();
     this.#containerEngineClient = undefined;
 
     await this.progressTracker.action('Stopping services', 10, async() => {
@@ -2158,11 +2446,24 @@ CREDFWD_URL='http://${ SLIRP.HOST_GATEWAY }:${ stateInfo.port }'
       return reasons; // No need to restart if nothing exists
     }
     Object.assign(reasons, this.kubeBackend.k3sHelper.requiresRestartReasons(this.cfg, cfg, {
+      'containerEngine.environment':                          undefined,
+      'experimental.virtualMachine.cpuAffinity.cpus':         undefined,
+      'experimental.virtualMachine.cpuAffinity.mode':         undefined,
+      'experimental.virtualMachine.cpuAffinity.numaNodes':    undefined,
+      'experimental.virtualMachine.gitBridge':                undefined,
+      'experimental.virtualMachine.hostLocale':               undefined,
       'experimental.virtualMachine.mount.9p.cacheMode':       undefined,
       'experimental.virtualMachine.mount.9p.msizeInKib':      undefined,
       'experimental.virtualMachine.mount.9p.protocolVersion': undefined,
       'experimental.virtualMachine.mount.9p.securityModel':   undefined,
       'experimental.virtualMachine.mount.type':               undefined,
+      'experimental.virtualMachine.nestedVirtualization':     undefined,
+      'experimental.virtualMachine.reversePortForwards':      undefined,
+      'experimental.virtualMachine.sshAgentForwarding':       undefined,
+      'experimental.virtualMachine.swap.mode':                undefined,
+      'experimental.virtualMachine.swap.sizeInGB':            undefined,
+      'experimental.virtualMachine.sysctls':                  undefined,
+      'experimental.virtualMachine.trimInterval':             undefined,
       'experimental.virtualMachine.useRosetta':               undefined,
       'experimental.virtualMachine.type':                     undefined,
     }));
@@ -105,6 +105,8 @@ export default class MockBackend extends events.EventEmitter implements VMExecut
 
   noModalDialogs = true;
 
+  safeMode = false;
+
   async handleSettingsUpdate(_: BackendSettings): Promise<void> {}
 
   requiresRestartReasons(config: RecursivePartial<BackendSettings>): Promise<RestartReasons> {
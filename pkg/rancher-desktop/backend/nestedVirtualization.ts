@@ -0,0 +1,66 @@
+/**
+ * Nested virtualization, as the experimental.virtualMachine.nestedVirtualization
+ * setting asks.  Only QEMU on Linux supports it: QEMU gives the VM the CPU of
+ * the host, with its virtualization extensions if the KVM module of the host
+ * allows nesting, and the VM then only needs to load KVM itself.
+ */
+
+import fs from 'fs';
+import path from 'path';
+
+export interface NestedVirtualizationSupport {
+  supported: boolean;
+  /** The KVM module for the CPU of the host, kvm_intel or kvm_amd. */
+  module?: string;
+  /** Why the host doesn't support nested virtualization. */
+  reason?: string;
+  /** How the user could make the host support it. */
+  fix?: string;
+}
+
+/**
+ * Check whether the host lets the VM run virtual machines itself.
+ * @param procfs Where procfs is mounted; overridden in tests.
+ * @param sysfs Where sysfs is mounted; overridden in tests.
+ */
+export async function getNestedVirtualizationSupport(procfs = '/proc', sysfs = '/sys'): Promise<NestedVirtualizationSupport> {
+  const cpuInfo = await fs.promises.readFile(path.join(procfs, 'cpuinfo'), 'utf-8');
+  const flags = /^flags\s*:(.*)$/m.exec(cpuInfo)?.[1].trim().split(/\s+/) ?? [];
+  const modules: Record<string, string> = { vmx: 'kvm_intel', svm: 'kvm_amd' };
+  const kvmModule = flags.map(flag => modules[flag]).find(m => m);
+
+  if (!kvmModule) {
+    return {
+      supported: false,
+      reason:    'The host CPU has no virtualization extensions (Intel VT-x or AMD-V) to pass to the VM.',
+      fix:       'Enable the virtualization extensions in the firmware settings of the host, or disable nested virtualization.',
+    };
+  }
+
+  let nested: string;
+
+  try {
+    nested = (await fs.promises.readFile(path.join(sysfs, 'module', kvmModule, 'parameters', 'nested'), 'utf-8')).trim();
+  } catch (ex: any) {
+    if (ex.code !== 'ENOENT') {
+      throw ex;
+    }
+
+    return {
+      supported: false,
+      module:    kvmModule,
+      reason:    `The ${ kvmModule } kernel module is not loaded on the host.`,
+      fix:       `Load the ${ kvmModule } kernel module on the host.`,
+    };
+  }
+  if (!['Y', '1'].includes(nested)) {
+    return {
+      supported: false,
+      module:    kvmModule,
+      reason:    `The ${ kvmModule } kernel module of the host does not allow nested virtualization.`,
+      fix:       `Add "options ${ kvmModule } nested=1" to /etc/modprobe.d/kvm.conf on the host, and reload the module or restart the host.`,
+    };
+  }
+
+  return { supported: true, module: kvmModule };
+}
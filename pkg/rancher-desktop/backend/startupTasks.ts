@@ -0,0 +1,68 @@
+import ProgressTracker from './progressTracker';
+
+/**
+ * StartupTask is a step of starting the backend that can run concurrently
+ * with the other steps, once the steps it depends on are done.
+ */
+export interface StartupTask {
+  /** The description of the step, shown as progress. */
+  description: string;
+  /** The names of the tasks that must be done before this one starts. */
+  after?: string[];
+  run: () => Promise<void>;
+}
+
+/**
+ * Check that the tasks only depend on existing tasks, without cycles.
+ */
+function checkStartupTasks(tasks: Record<string, StartupTask>) {
+  const checked = new Set<string>();
+  const visiting = new Set<string>();
+  const check = (name: string) => {
+    if (checked.has(name)) {
+      return;
+    }
+    if (!tasks[name]) {
+      throw new Error(`Unknown startup task ${ name }`);
+    }
+    if (visiting.has(name)) {
+      throw new Error(`Startup task ${ name } depends on itself`);
+    }
+    visiting.add(name);
+    tasks[name].after?.forEach(check);
+    visiting.delete(name);
+    checked.add(name);
+  };
+
+  Object.keys(tasks).forEach(check);
+}
+
+/**
+ * Run the tasks, each one as soon as the tasks it depends on are done. If a
+ * task fails, the tasks depending on it don't run, and the returned promise
+ * is rejected with the error once the tasks already running are done.
+ * @param tracker The progress tracker the tasks are reported to.
+ * @param tasks The tasks, by name.
+ * @param priority The priority of the progress of the tasks.
+ */
+export async function runStartupTasks(tracker: ProgressTracker, tasks: Record<string, StartupTask>, priority = 50): Promise<void> {
+  checkStartupTasks(tasks);
+
+  const promises: Record<string, Promise<void>> = {};
+  const start = (name: string): Promise<void> => {
+    if (!(name in promises)) {
+      const task = tasks[name];
+
+      promises[name] = Promise.all((task.after ?? []).map(start))
+        .then(() => tracker.action(task.description, priority, task.run()));
+    }
+
+    return promises[name];
+  };
+  const results = await Promise.allSettled(Object.keys(tasks).map(start));
+  const failure = results.find(result => result.status === 'rejected');
+
+  if (failure) {
+    throw (failure as PromiseRejectedResult).reason;
+  }
+}
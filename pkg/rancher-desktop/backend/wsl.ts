@@ -13,10 +13,12 @@ import semver from 'semver';
 import tar from 'tar-stream';
 
 import {
-  BackendError, BackendEvents, BackendProgress, BackendSettings, execOptions, FailureDetails, RestartReasons, State, VMBackend, VMExecutor,
+  BackendError, BackendEvents, BackendProgress, BackendSettings, execOptions, FailureDetails, RestartReasons, State, SubState, VMBackend, VMExecutor,
 } from './backend';
 import BackendHelper from './backendHelper';
+import { captureBootFailure, clearBootFailure } from './bootFailure';
 import { ContainerEngineClient, MobyClient, NerdctlClient } from './containerClient';
+import { getHostLocale, hostLocaleScript, HostLocaleWatcher } from './hostLocale';
 import K3sHelper from './k3sHelper';
 import ProgressTracker, { getProgressErrorDescription } from './progressTracker';
 
@@ -27,10 +29,14 @@ import SERVICE_BUILDKITD_INIT from '@pkg/assets/scripts/buildkit.initd';
 import CONFIGURE_IMAGE_ALLOW_LIST from '@pkg/assets/scripts/configure-allowed-images';
 import SERVICE_SCRIPT_DNSMASQ_GENERATE from '@pkg/assets/scripts/dnsmasq-generate.initd';
 import DOCKER_CREDENTIAL_SCRIPT from '@pkg/assets/scripts/docker-credential-rancher-desktop';
+import GIT_CREDENTIAL_SCRIPT from '@pkg/assets/scripts/git-credential-rancher-desktop';
+import GUEST_AGENT_RESYNC_SCRIPT from '@pkg/assets/scripts/guestagent-resync';
 import INSTALL_WSL_HELPERS_SCRIPT from '@pkg/assets/scripts/install-wsl-helpers';
 import CONTAINERD_CONFIG from '@pkg/assets/scripts/k3s-containerd-config.toml';
 import LOGROTATE_K3S_SCRIPT from '@pkg/assets/scripts/logrotate-k3s';
 import LOGROTATE_OPENRESTY_SCRIPT from '@pkg/assets/scripts/logrotate-openresty';
+import MEMORY_RECLAIM_SCRIPT from '@pkg/assets/scripts/memory-reclaim';
+import SERVICE_MEMORY_RECLAIM_INIT from '@pkg/assets/scripts/memory-reclaim.initd';
 import SERVICE_SCRIPT_MOPROXY from '@pkg/assets/scripts/moproxy.initd';
 import NERDCTL from '@pkg/assets/scripts/nerdctl';
 import NGINX_CONF from '@pkg/assets/scripts/nginx.conf';
@@ -44,7 +50,13 @@ import SCRIPT_DATA_WSL_CONF from '@pkg/assets/scripts/wsl-data.conf';
 import WSL_EXEC from '@pkg/assets/scripts/wsl-exec';
 import WSL_INIT_SCRIPT from '@pkg/assets/scripts/wsl-init';
 import WSL_INIT_RD_NETWORKING_SCRIPT from '@pkg/assets/scripts/wsl-init-rd-networking';
-import { ContainerEngine } from '@pkg/config/settings';
+import {
+  ContainerEngine,
+  MemoryReclaimMode,
+  SwapMode,
+  TrimInterval,
+} from '@pkg/config/settings';
+import { gitConfigForVM, updateGitConfig } from '@pkg/main/credentialServer/gitCredentials';
 import { getServerCredentialsPath, ServerState } from '@pkg/main/credentialServer/httpCredentialHelperServer';
 import mainEvents from '@pkg/main/mainEvents';
 import { getVtunnelInstance, getVtunnelConfigPath } from '@pkg/main/networking/vtunnel';
@@ -69,8 +81,12 @@ const DATA_INSTANCE_NAME = 'rancher-desktop-data';
 const ETC_RANCHER_DESKTOP_DIR = '/etc/rancher/desktop';
 const CREDENTIAL_FORWARDER_SETTINGS_PATH = `${ ETC_RANCHER_DESKTOP_DIR }/credfwd`;
 const DOCKER_CREDENTIAL_PATH = '/usr/local/bin/docker-credential-rancher-desktop';
+const GIT_CREDENTIAL_PATH = '/usr/local/bin/git-credential-rancher-desktop';
+const SYSTEM_GIT_CONFIG_PATH = '/etc/gitconfig';
 const ROOT_DOCKER_CONFIG_DIR = '/root/.docker';
 const ROOT_DOCKER_CONFIG_PATH = `${ ROOT_DOCKER_CONFIG_DIR }/config.json`;
+/** The prefix of the names of the vtunnels implementing the reversePortForwards setting. */
+const REVERSE_PORT_FORWARD_TUNNEL = 'Reverse Port Forward';
 
 /**
  * Enumeration for tracking what operation the backend is undergoing.
@@ -251,9 +267,15 @@ export default class WSLBackend extends events.EventEmitter implements VMBackend
     this.#noModalDialogs = value;
   }
 
+  /** A transient property that skips the user's provisioning scripts. */
+  safeMode = false;
+
   /** Vtunnel Proxy management singleton. */
   protected vtun = getVtunnelInstance();
 
+  /** Follows changes of the timezone of the host, if the hostLocale setting is enabled. */
+  protected hostLocaleWatcher = new HostLocaleWatcher(locale => this.execCommand('sh', '-c', hostLocaleScript(locale)));
+
   /**
    * The current operation underway; used to avoid responding to state changes
    * when we're in the process of doing a different one.
@@ -280,8 +302,21 @@ export default class WSLBackend extends events.EventEmitter implements VMBackend
     return this.internalState;
   }
 
+  protected internalSubState: SubState | undefined;
+  get subState() {
+    return this.internalSubState;
+  }
+
+  protected setSubState(subState: SubState) {
+    console.log(`Backend is starting: ${ subState }`);
+    this.internalSubState = subState;
+  }
+
   protected async setState(state: State) {
     this.internalState = state;
+    if (state !== State.STARTING) {
+      this.internalSubState = undefined;
+    }
     this.emit('state-changed', this.state);
     switch (this.state) {
     case State.STOPPING:
@@ -512,13 +547,15 @@ export default class WSLBackend extends events.EventEmitter implements VMBackend
    * start/stop Privileged Service based on a given command [start|stop],
    * also, it returns a boolean to indicate if privileged services
    * is enabled.
+   * @param args Additional arguments, such as `--firewall` on start.
    */
-  protected async invokePrivilegedService(cmd: 'start' | 'stop'): Promise<boolean> {
+  protected async invokePrivilegedService(cmd: 'start' | 'stop', ...args: string[]): Promise<boolean> {
     const privilegedServicePath = path.join(paths.resources, 'win32', 'internal', 'privileged-service.exe');
+    const logArgs = this.cfg?.application.systemLog ? ['--system-log'] : [];
     let privilegedServiceEnabled = true;
 
     try {
-      await childProcess.spawnFile(privilegedServicePath, [cmd]);
+      await childProcess.spawnFile(privilegedServicePath, [cmd, ...args, ...logArgs]);
     } catch (error) {
       privilegedServiceEnabled = false;
     }
@@ -831,6 +868,155 @@ export default class WSLBackend extends events.EventEmitter implements VMBackend
     }
   }
 
+  /**
+   * Register a vtunnel for each entry of the reversePortForwards setting: the
+   * peer in the VM listens on the guest port, and connections are forwarded
+   * to the host port.  This is only used without networkingTunnel; see
+   * installReversePortForwards().
+   */
+  protected addReversePortForwardTunnels(forwards: readonly string[]) {
+    this.vtun.removeTunnels(REVERSE_PORT_FORWARD_TUNNEL);
+    forwards.forEach((entry, index) => {
+      const { guestPort, hostPort } = BackendHelper.parseReversePortForward(entry);
+
+      this.vtun.addTunnel({
+        name:                  `${ REVERSE_PORT_FORWARD_TUNNEL } ${ guestPort }`,
+        handshakePort:         17402 + 2 * index,
+        vsockHostPort:         17401 + 2 * index,
+        peerAddress:           '0.0.0.0',
+        peerPort:              guestPort,
+        upstreamServerAddress: `127.0.0.1:${ hostPort }`,
+      });
+    });
+  }
+
+  /**
+   * With networkingTunnel, the host is reachable from the VM, so the ports of
+   * the reversePortForwards setting are forwarded to it directly; otherwise,
+   * the vtunnels do the forwarding, and any previous rules are removed.
+   */
+  protected async installReversePortForwards() {
+    const virtualNetworkHostAddr = '192.168.127.254';
+    const rdNetworking = !!this.cfg?.experimental.virtualMachine.networkingTunnel;
+    const forwards = rdNetworking ? this.cfg?.experimental.virtualMachine.reversePortForwards ?? [] : [];
+
+    try {
+      await this.execCommand(...BackendHelper.reversePortForwardCommand(virtualNetworkHostAddr, forwards));
+    } catch (err: any) {
+      console.log('Error trying to forward ports of the host:', err);
+    }
+  }
+
+  /**
+   * Apply the kernel parameters of the sysctls setting.  The distributions
+   * share the kernel of the WSL VM, so they apply to all of them.
+   */
+  protected async installSysctls() {
+    const sysctls = this.cfg?.experimental.virtualMachine.sysctls ?? [];
+
+    try {
+      await this.execCommand(...BackendHelper.sysctlCommand(sysctls));
+    } catch (err: any) {
+      console.log('Error trying to set kernel parameters:', err);
+    }
+  }
+
+  /**
+   * Set up the swap space of the swap setting.  WSL gives the VM swap space
+   * of its own; this adds to it, for the distributions share the kernel.
+   */
+  protected async installSwap() {
+    const { mode, sizeInGB } = this.cfg?.experimental.virtualMachine.swap ?? { mode: SwapMode.ZRAM, sizeInGB: 0 };
+
+    try {
+      await this.execCommand(...BackendHelper.swapCommand(mode, sizeInGB));
+    } catch (err: any) {
+      console.log('Error trying to set up swap:', err);
+    }
+  }
+
+  /**
+   * Trim the file systems of the distributions as often as the trimInterval
+   * setting asks, so that deleted data is released to the virtual disks.
+   */
+  protected async installTrim() {
+    const interval = this.cfg?.experimental.virtualMachine.trimInterval ?? TrimInterval.WEEKLY;
+
+    try {
+      await this.execCommand(...BackendHelper.trimCommand(interval));
+    } catch (err: any) {
+      console.log('Error trying to configure disk trimming:', err);
+    }
+  }
+
+  /**
+   * Install the service returning the memory of the page cache of the idle VM
+   * to the host, as the memoryReclaim setting asks; WSL takes the memory the
+   * VM frees back.  The service is started after provisioning.
+   */
+  protected async installMemoryReclaim() {
+    const { mode, minimumInGB } = this.cfg?.experimental.virtualMachine.memoryReclaim ?? {};
+
+    if (!mode || mode === MemoryReclaimMode.DISABLED) {
+      await this.execCommand('rm', '-f', '/etc/init.d/memory-reclaim', '/etc/conf.d/memory-reclaim', '/usr/local/bin/memory-reclaim');
+
+      return;
+    }
+    await this.writeFile('/usr/local/bin/memory-reclaim', MEMORY_RECLAIM_SCRIPT, 0o755);
+    await this.writeFile('/etc/init.d/memory-reclaim', SERVICE_MEMORY_RECLAIM_INIT, 0o755);
+    await this.writeConf('memory-reclaim', {
+      MEMORY_RECLAIM_MODE:       mode,
+      MEMORY_RECLAIM_MINIMUM_MB: `${ Math.round((minimumInGB ?? 0) * 1024) }`,
+      LOG_DIR:                   await this.wslify(paths.logs),
+    });
+  }
+
+  /**
+   * Apply the timezone and locale of the host to the VM, and follow changes
+   * of the timezone, when the hostLocale setting is enabled; undo it otherwise.
+   */
+  protected async installHostLocale() {
+    try {
+      if (this.cfg?.experimental.virtualMachine.hostLocale) {
+        const locale = await getHostLocale();
+
+        await this.execCommand('sh', '-c', hostLocaleScript(locale));
+        this.hostLocaleWatcher.start(locale);
+      } else {
+        await this.execCommand('sh', '-c', hostLocaleScript());
+      }
+    } catch (err: any) {
+      console.log('Error trying to update the locale of the VM:', err);
+    }
+  }
+
+  /**
+   * Configure git in the VM to use the credentials and identity of the host
+   * when the gitBridge setting is enabled, and undo it otherwise.
+   */
+  protected async installGitBridge() {
+    try {
+      const enabled = !!this.cfg?.experimental.virtualMachine.gitBridge;
+      // Only our block of the system git configuration is replaced; a missing
+      // file reads as empty, but other errors must not lose the rest of it.
+      const current = await this.execCommand({ capture: true }, '/bin/sh', '-c', `[ ! -e ${ SYSTEM_GIT_CONFIG_PATH } ] || cat ${ SYSTEM_GIT_CONFIG_PATH }`);
+      const contents = updateGitConfig(current, enabled ? await gitConfigForVM() : '');
+
+      if (enabled) {
+        await this.writeFile(GIT_CREDENTIAL_PATH, GIT_CREDENTIAL_SCRIPT, 0o755);
+      } else {
+        await this.execCommand('rm', '-f', GIT_CREDENTIAL_PATH);
+      }
+      if (!contents) {
+        await this.execCommand('rm', '-f', SYSTEM_GIT_CONFIG_PATH);
+      } else if (contents !== current) {
+        await this.writeFile(SYSTEM_GIT_CONFIG_PATH, contents, 0o644);
+      }
+    } catch (err: any) {
+      console.log('Error trying to update the git configuration:', err);
+    }
+  }
+
   /**
    * Return the Linux path to the moproxy executable.
    */
@@ -919,6 +1105,7 @@ export default class WSLBackend extends events.EventEmitter implements VMBackend
 
     await Promise.all([
       this.wslInstall(guestAgentPath, '/usr/local/bin/'),
+      this.writeFile('/usr/local/bin/guestagent-resync', GUEST_AGENT_RESYNC_SCRIPT, 0o755),
       this.writeFile('/etc/init.d/rancher-desktop-guestagent', SERVICE_GUEST_AGENT_INIT, 0o755),
       this.writeConf('rancher-desktop-guestagent', guestAgentConfig),
     ]);
@@ -1303,6 +1490,7 @@ export default class WSLBackend extends events.EventEmitter implements VMBackend
     this.#containerEngineClient = undefined;
     await this.progressTracker.action('Initializing Rancher Desktop', 10, async() => {
       try {
+        this.setSubState(SubState.VM_BOOTING);
         const prepActions = [(async() => {
           await this.ensureDistroRegistered();
           await this.upgradeDistroAsNeeded();
@@ -1311,14 +1499,18 @@ export default class WSLBackend extends events.EventEmitter implements VMBackend
         })()];
 
         if (!this.cfg?.experimental.virtualMachine.networkingTunnel) {
+          this.addReversePortForwardTunnels(config.experimental.virtualMachine.reversePortForwards);
           await this.vtun.start();
         }
 
         const rdNetworking = !!config?.experimental.virtualMachine.networkingTunnel;
 
-        this.privilegedServiceEnabled = rdNetworking ? false : await this.invokePrivilegedService('start');
+        const privilegedServiceArgs = config.experimental.virtualMachine.firewallRules ? ['--firewall'] : [];
+
+        this.privilegedServiceEnabled = rdNetworking ? false : await this.invokePrivilegedService('start', ...privilegedServiceArgs);
 
         if (config.kubernetes.enabled) {
+          this.setSubState(SubState.IMAGES_LOADING);
           prepActions.push((async() => {
             [kubernetesVersion] = await this.kubeBackend.download(config);
           })());
@@ -1344,6 +1536,7 @@ export default class WSLBackend extends events.EventEmitter implements VMBackend
 
         const distroLock = await this.progressTracker.action('Mounting WSL data', 100, this.mountData());
 
+        this.setSubState(SubState.PROVISIONING);
         const installerActions = [
           this.progressTracker.action('Starting WSL environment', 100, async() => {
             const rdNetworkingDNS = '192.168.127.1';
@@ -1356,6 +1549,13 @@ export default class WSLBackend extends events.EventEmitter implements VMBackend
                 // This must run after /etc/rancher is mounted
                 await this.installCredentialHelper();
               }),
+              this.progressTracker.action('Configuring git', 10, this.installGitBridge()),
+              this.progressTracker.action('Configuring locale', 10, this.installHostLocale()),
+              this.progressTracker.action('Forwarding host ports', 10, this.installReversePortForwards()),
+              this.progressTracker.action('Configuring disk trimming', 10, this.installTrim()),
+              this.progressTracker.action('Configuring kernel parameters', 10, this.installSysctls()),
+              this.progressTracker.action('Configuring swap', 10, this.installSwap()),
+              this.progressTracker.action('Configuring memory reclaim', 10, this.installMemoryReclaim()),
               this.progressTracker.action('DNS configuration', 50, async() => {
                 if (this.cfg?.experimental.virtualMachine.networkingTunnel) {
                   console.debug(`setting DNS server to ${ rdNetworkingDNS }  for rancher desktop networking`);
@@ -1419,6 +1619,7 @@ export default class WSLBackend extends events.EventEmitter implements VMBackend
                 });
                 await this.writeFile(`/etc/init.d/buildkitd`, SERVICE_BUILDKITD_INIT, 0o755);
                 await this.writeFile(`/etc/conf.d/buildkitd`, SERVICE_BUILDKITD_CONF);
+                await this.execCommand(...BackendHelper.engineEnvironmentCommand(config.containerEngine.environment));
               }),
               this.progressTracker.action('Proxy Config Setup', 50, async() => {
                 await this.execCommand('mkdir', '-p', '/etc/moproxy');
@@ -1486,7 +1687,14 @@ export default class WSLBackend extends events.EventEmitter implements VMBackend
               if (!config.kubernetes.options.flannel) {
                 console.log(`Disabling flannel and network policy`);
                 k3sConf.ADDITIONAL_ARGS += ' --flannel-backend=none --disable-network-policy';
+              } else if (!config.kubernetes.options.networkPolicy) {
+                console.log(`Disabling network policy`);
+                k3sConf.ADDITIONAL_ARGS += ' --disable-network-policy';
+              }
+              if (config.kubernetes.storage.path) {
+                k3sConf.ADDITIONAL_ARGS += ` --default-local-storage-path ${ config.kubernetes.storage.path }`;
               }
+              k3sConf.ADDITIONAL_ARGS += K3sHelper.apiServerOIDCArgs(config.kubernetes.oidc);
 
               await this.writeConf('k3s', k3sConf);
             }),
@@ -1501,14 +1709,22 @@ export default class WSLBackend extends events.EventEmitter implements VMBackend
           distroLock.kill('SIGTERM');
         }
 
-        await this.progressTracker.action('Running provisioning scripts', 100, this.runProvisioningScripts());
+        if (this.safeMode) {
+          console.log('Safe mode: skipping provisioning scripts.');
+        } else {
+          await this.progressTracker.action('Running provisioning scripts', 100, this.runProvisioningScripts());
+        }
 
+        if (config.experimental.virtualMachine.memoryReclaim.mode !== MemoryReclaimMode.DISABLED) {
+          await this.progressTracker.action('Starting memory reclaim', 50, this.startService('memory-reclaim'));
+        }
         if (config.experimental.virtualMachine.proxy.enabled && config.experimental.virtualMachine.proxy.address && config.experimental.virtualMachine.proxy.port) {
           await this.progressTracker.action('Starting proxy', 100, this.startService('moproxy'));
         }
         if (config.containerEngine.allowedImages.enabled) {
           await this.progressTracker.action('Starting image proxy', 100, this.startService('openresty'));
         }
+        this.setSubState(SubState.ENGINE_STARTING);
         await this.progressTracker.action('Starting container engine', 0, this.startService(config.containerEngine.name === ContainerEngine.MOBY ? 'docker' : 'containerd'));
 
         switch (config.containerEngine.name) {
@@ -1525,6 +1741,7 @@ export default class WSLBackend extends events.EventEmitter implements VMBackend
         await this.progressTracker.action('Waiting for container engine to be ready', 0, this.containerEngineClient.waitForReady());
 
         if (kubernetesVersion) {
+          this.setSubState(SubState.K8S_WAITING);
           await this.progressTracker.action('Starting Kubernetes', 100, this.kubeBackend.start(config, kubernetesVersion));
         }
 
@@ -1535,7 +1752,9 @@ export default class WSLBackend extends events.EventEmitter implements VMBackend
         }
 
         await this.setState(config.kubernetes.enabled ? State.STARTED : State.DISABLED);
+        await clearBootFailure();
       } catch (ex) {
+        await captureBootFailure('wsl', ex, [Logging['wsl-init'].path, console.path, Logging['wsl-exec'].path]);
         await this.setState(State.ERROR);
         throw ex;
       } finally {
@@ -1649,6 +1868,7 @@ export default class WSLBackend extends events.EventEmitter implements VMBackend
       return;
     }
     this.currentAction = Action.STOPPING;
+    this.hostLocaleWatcher.stop();
     try {
       await this.setState(State.STOPPING);
       await this.kubeBackend.stop();
@@ -1739,7 +1959,20 @@ export default class WSLBackend extends events.EventEmitter implements VMBackend
     }
 
     return Promise.resolve(this.kubeBackend.requiresRestartReasons(
-      this.cfg, cfg, { 'experimental.virtualMachine.networkingTunnel': { current: this.cfg.experimental.virtualMachine.networkingTunnel } }));
+      this.cfg, cfg, {
+        'containerEngine.environment':                           undefined,
+        'experimental.virtualMachine.firewallRules':             undefined,
+        'experimental.virtualMachine.gitBridge':                 undefined,
+        'experimental.virtualMachine.hostLocale':                undefined,
+        'experimental.virtualMachine.memoryReclaim.minimumInGB': undefined,
+        'experimental.virtualMachine.memoryReclaim.mode':        undefined,
+        'experimental.virtualMachine.networkingTunnel':          { current: this.cfg.experimental.virtualMachine.networkingTunnel },
+        'experimental.virtualMachine.reversePortForwards':       undefined,
+        'experimental.virtualMachine.swap.mode':                 undefined,
+        'experimental.virtualMachine.swap.sizeInGB':             undefined,
+        'experimental.virtualMachine.sysctls':                   undefined,
+        'experimental.virtualMachine.trimInterval':              undefined,
+      }));
   }
 
   /**
@@ -237,6 +237,24 @@ describe('commandLineOptions', () => {
       expect(newPrefs).toEqual(origPrefs);
     });
 
+    test('should replace array values given as JSON', () => {
+      const newPrefs = updateFromCommandLine(prefs, lockedSettings, [
+        '--kubernetes.node.labels=["tier=frontend","zone=a"]',
+        '--kubernetes.node.taints', '["dedicated=gpu:NoSchedule"]',
+      ]);
+
+      expect(newPrefs.kubernetes.node.labels).toEqual(['tier=frontend', 'zone=a']);
+      expect(newPrefs.kubernetes.node.taints).toEqual(['dedicated=gpu:NoSchedule']);
+    });
+
+    test('should complain about array values that are not lists of strings', () => {
+      const arg = '--kubernetes.node.labels';
+
+      expect(() => {
+        updateFromCommandLine(prefs, lockedSettings, [`${ arg }=[1]`]);
+      }).toThrow(`Value of ${ arg } must be a JSON array of strings`);
+    });
+
     test('should ignore non-option arguments', () => {
       const arg = 'doesnt.start.with.dash.dash=some-value';
       const newPrefs = updateFromCommandLine(prefs, lockedSettings, [arg]);
@@ -352,6 +370,26 @@ describe('commandLineOptions', () => {
     });
   });
 
+  describe('--safe-mode', () => {
+    afterEach(() => {
+      TransientSettings.update({ safeMode: false });
+    });
+
+    test('sets the value accordingly', () => {
+      updateFromCommandLine(prefs, lockedSettings, ['--safe-mode']);
+      expect(TransientSettings.value.safeMode).toBeTruthy();
+      updateFromCommandLine(prefs, lockedSettings, ['--safe-mode=false']);
+      expect(TransientSettings.value.safeMode).toBeFalsy();
+    });
+
+    test('can be combined with settings', () => {
+      const newPrefs = updateFromCommandLine(prefs, lockedSettings, ['--safe-mode', '--kubernetes.enabled=false']);
+
+      expect(TransientSettings.value.safeMode).toBeTruthy();
+      expect(newPrefs.kubernetes.enabled).toBeFalsy();
+    });
+  });
+
   describe('getObjectRepresentation', () => {
     test('handles more than 2 dots', () => {
       expect(getObjectRepresentation('a.b.c.d' as RecursiveKeys<settings.Settings>, 3))
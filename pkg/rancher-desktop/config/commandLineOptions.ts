@@ -3,7 +3,7 @@ import { join } from 'path';
 import _ from 'lodash';
 
 import { LockedSettingsType, Settings } from '@pkg/config/settings';
-import { save, turnFirstRunOff } from '@pkg/config/settingsImpl';
+import { merge, save, turnFirstRunOff } from '@pkg/config/settingsImpl';
 import { TransientSettings } from '@pkg/config/transientSettings';
 import SettingsValidator from '@pkg/main/commandServer/settingsValidator';
 import Logging from '@pkg/utils/logging';
@@ -55,14 +55,16 @@ export function updateFromCommandLine(cfg: Settings, lockedFields: LockedSetting
     const equalPosition = arg.indexOf('=');
     const [fqFieldName, value] = equalPosition === -1 ? [arg.substring(2), ''] : [arg.substring(2, equalPosition), arg.substring(equalPosition + 1)];
 
-    if (fqFieldName === 'no-modal-dialogs') {
+    if (fqFieldName === 'no-modal-dialogs' || fqFieldName === 'safe-mode') {
+      const transientField = fqFieldName === 'safe-mode' ? 'safeMode' : 'noModalDialogs';
+
       switch (value) {
       case '':
       case 'true':
-        TransientSettings.update({ noModalDialogs: true });
+        TransientSettings.update({ [transientField]: true });
         break;
       case 'false':
-        TransientSettings.update({ noModalDialogs: false });
+        TransientSettings.update({ [transientField]: false });
         break;
       default:
         throw new Error(`Invalid associated value for ${ arg }: must be unspecified (set to true), true or false`);
@@ -70,7 +72,7 @@ export function updateFromCommandLine(cfg: Settings, lockedFields: LockedSetting
       processingExternalArguments = false;
       continue;
     }
-    const currentValue: boolean|string|number|Record<string, undefined>|undefined = _.get(cfg, fqFieldName);
+    const currentValue: boolean|string|number|string[]|Record<string, undefined>|undefined = _.get(cfg, fqFieldName);
 
     if (currentValue === undefined) {
       // Ignore unrecognized command-line options until we get to one we recognize
@@ -88,7 +90,26 @@ export function updateFromCommandLine(cfg: Settings, lockedFields: LockedSetting
     // First ensure we aren't trying to overwrite a non-leaf, and then determine the value to assign.
     switch (currentValueType) {
     case 'object':
-      throw new Error(`Can't overwrite existing setting ${ arg } in current settings at ${ join(paths.config, 'settings.json') }`);
+      if (!Array.isArray(currentValue)) {
+        throw new Error(`Can't overwrite existing setting ${ arg } in current settings at ${ join(paths.config, 'settings.json') }`);
+      }
+      // Arrays are given as JSON, e.g. --kubernetes.node.labels='["a=b"]'
+      if (equalPosition === -1) {
+        if (i === lim - 1) {
+          throw new Error(`No value provided for option ${ arg } in command-line [${ commandLineArgs.join(' ') }]`);
+        }
+        i += 1;
+        finalValue = commandLineArgs[i];
+      }
+      try {
+        finalValue = JSON.parse(finalValue);
+      } catch (err) {
+        throw new Error(`Can't evaluate --${ fqFieldName }=${ finalValue } as an array: ${ err }`);
+      }
+      if (!Array.isArray(finalValue) || finalValue.some(v => typeof v !== 'string')) {
+        throw new TypeError(`Value of --${ fqFieldName } must be a JSON array of strings`);
+      }
+      break;
     case 'boolean':
       // --some-boolean-setting ==> --some-boolean-setting=true
       if (equalPosition === -1) {
@@ -134,7 +155,9 @@ export function updateFromCommandLine(cfg: Settings, lockedFields: LockedSetting
     }
     settingsValidator.k8sVersions = limitedK8sVersionList;
   }
-  const [needToUpdate, errors, isFatal] = settingsValidator.validateSettings(cfg, newSettings, lockedFields);
+  // The application can't ask for confirmation while starting, so resources of
+  // the VM over the limits of the host are accepted.
+  const [needToUpdate, errors, isFatal] = settingsValidator.validateSettings(cfg, newSettings, lockedFields, true);
 
   if (errors.length > 0) {
     const errorString = `Error in command-line options:\n${ errors.join('\n') }`;
@@ -148,7 +171,7 @@ export function updateFromCommandLine(cfg: Settings, lockedFields: LockedSetting
     throw new Error(errorString);
   }
   if (needToUpdate) {
-    cfg = _.merge(cfg, newSettings);
+    cfg = merge(cfg, newSettings);
     save(cfg);
   } else {
     console.debug(`No need to update preferences based on command-line options ${ commandLineArgs.join(', ') }`);
@@ -162,7 +185,7 @@ export function updateFromCommandLine(cfg: Settings, lockedFields: LockedSetting
 // but it also does some error checking.
 // On the happy path, it's exactly like `lodash.set`
 // exported for unit tests only
-export function getObjectRepresentation(fqFieldAccessor: RecursiveKeys<Settings>, finalValue: boolean|number|string): RecursivePartial<Settings> {
+export function getObjectRepresentation(fqFieldAccessor: RecursiveKeys<Settings>, finalValue: boolean|number|string|string[]): RecursivePartial<Settings> {
   if (!fqFieldAccessor) {
     throw new Error("Invalid command-line option: can't be the empty string.");
   }
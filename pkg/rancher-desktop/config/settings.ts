@@ -48,6 +48,39 @@ export enum CacheMode {
   MMAP = 'mmap',
 }
 
+export enum TrimInterval {
+  NEVER = 'never',
+  CONTINUOUS = 'continuous',
+  HOURLY = 'hourly',
+  DAILY = 'daily',
+  WEEKLY = 'weekly',
+}
+
+export enum CPUAffinityMode {
+  NONE = 'none',
+  CPUS = 'cpus',
+  PERFORMANCE = 'performance',
+  EFFICIENCY = 'efficiency',
+  NUMA_NODES = 'numa-nodes',
+}
+
+export enum MemoryReclaimMode {
+  DISABLED = 'disabled',
+  GRADUAL = 'gradual',
+  DROP_CACHE = 'dropcache',
+}
+
+export enum SwapMode {
+  DISABLED = 'disabled',
+  ZRAM = 'zram',
+  FILE = 'file',
+}
+
+export enum KubeconfigCertificates {
+  EMBEDDED = 'embedded',
+  FILE = 'file',
+}
+
 export class SettingsError extends Error {
   toString() {
     // This is needed on linux. Without it, we get a randomish replacement
@@ -77,13 +110,24 @@ export const defaultSettings = {
     startInBackground:      false,
     hideNotificationIcon:   false,
     window:                 { quitOnClose: false },
+    /**
+     * windows only: if set, the privileged service and the background
+     * wsl-helper processes also send warnings and errors to the Windows
+     * Event Log.
+     */
+    systemLog:              false,
   },
   containerEngine: {
     allowedImages: {
       enabled:  false,
       patterns: [] as Array<string>,
     },
-    name: ContainerEngine.MOBY,
+    name:        ContainerEngine.MOBY,
+    /**
+     * Environment variables (KEY=VALUE) for containerd, dockerd, and
+     * buildkitd, e.g. for proxies or Go module mirrors.
+     */
+    environment: [] as string[],
   },
   virtualMachine: {
     memoryInGB:   2,
@@ -100,8 +144,54 @@ export const defaultSettings = {
     version: '',
     port:    6443,
     enabled: true,
-    options: { traefik: true, flannel: true },
+    options: {
+      traefik:       true,
+      flannel:       true,
+      /**
+       * Whether the network policy controller of K3s enforces NetworkPolicies
+       * alongside flannel; without flannel, the CNI installed instead does.
+       */
+      networkPolicy: true,
+    },
     ingress: { localhostOnly: false },
+    /**
+     * Labels (KEY=VALUE) and taints (KEY[=VALUE]:EFFECT) applied to the node
+     * whenever Kubernetes starts.
+     */
+    node:    {
+      labels: [] as string[],
+      taints: [] as string[],
+    },
+    /**
+     * Directory in the VM where the local-path provisioner stores persistent
+     * volumes; the K3s default (/var/lib/rancher/k3s/storage) if empty.
+     */
+    storage: { path: '' },
+    /**
+     * PEM files on the host with the certificate authority that signs the
+     * certificate of the API server, instead of one generated by K3s. K3s
+     * only uses it for a new cluster, so setting it resets Kubernetes; when
+     * it is cleared, the cluster keeps its certificates until it is reset.
+     */
+    certificateAuthority: { certificate: '', key: '' },
+    /**
+     * Whether the kubeconfig embeds the certificates, or refers to files
+     * written next to it, for tools that can't read inline certificates.
+     */
+    kubeconfig: { certificates: KubeconfigCertificates.EMBEDDED },
+    /**
+     * OpenID Connect provider whose ID tokens the API server accepts, so the
+     * cluster knows the same single sign-on identities as shared clusters;
+     * disabled if the issuer URL is empty. Empty claims keep the defaults of
+     * the API server: the "sub" claim for the user name, and no groups.
+     */
+    oidc: {
+      issuerURL:     '',
+      clientID:      '',
+      usernameClaim: '',
+      groupsClaim:   '',
+      extraScopes:   [] as string[],
+    },
   },
   portForwarding: { includeKubernetesServices: false },
   images:         {
@@ -122,12 +212,28 @@ export const defaultSettings = {
   experimental: {
     virtualMachine: {
       /** can only be set to VMType.VZ on macOS Ventura and later */
-      type:        VMType.QEMU,
+      type:               VMType.QEMU,
       /** can only be used when type is VMType.VZ, and only on aarch64 */
-      useRosetta:  false,
+      useRosetta:         false,
       /** macOS only: if set, use socket_vmnet instead of vde_vmnet. */
-      socketVMNet: false,
-      mount:       {
+      socketVMNet:        false,
+      /**
+       * macOS and Linux only: if set, forward the SSH agent of the host to
+       * shells in the VM, so that git can use the keys of the host.
+       */
+      sshAgentForwarding: false,
+      /**
+       * if set, git in the VM, and in integrated WSL distributions, uses the
+       * credential helpers, user name, and email configured on the host.
+       */
+      gitBridge:          false,
+      /**
+       * if set, the VM uses the timezone and locale of the host, and follows
+       * changes of the timezone of the host.  Containers are not affected, as
+       * neither container engine has a default environment for containers.
+       */
+      hostLocale:         false,
+      mount:              {
         type: MountType.REVERSE_SSHFS,
         '9p': {
           securityModel:   SecurityModel.NONE,
@@ -137,8 +243,68 @@ export const defaultSettings = {
         },
       },
       /** windows only: if set, use gvisor based network rather than host-resolver/dnsmasq. */
-      networkingTunnel: false,
-      proxy:            {
+      networkingTunnel:    false,
+      /**
+       * windows only: if set, the privileged service adds Windows Defender
+       * Firewall rules allowing inbound connections to ports containers
+       * publish on all interfaces, and removes them with the ports.
+       */
+      firewallRules:       false,
+      /**
+       * Ports of the host made available in the VM and to containers, as
+       * GUEST_PORT:HOST_PORT, or PORT if both are the same.
+       */
+      reversePortForwards: [] as string[],
+      /**
+       * Kernel parameters of the VM, as KEY=VALUE, applied whenever it
+       * starts; for example, higher inotify limits for service meshes.
+       */
+      sysctls:             [] as string[],
+      /**
+       * How often the file systems of the VM are trimmed, so that deleted
+       * images and containers are released to the disk images on the host;
+       * "continuous" mounts them with the discard option instead.
+       */
+      trimInterval:        TrimInterval.WEEKLY,
+      /**
+       * windows only: return the memory the VM only uses for its page cache
+       * to the host while the VM is idle, as WSL takes free pages back.
+       * "gradual" reclaims a tenth of the cache at a time, "dropcache" all of
+       * it at once.  The VM keeps at least minimumInGB of memory in use.
+       */
+      memoryReclaim:       {
+        mode:        MemoryReclaimMode.DISABLED,
+        minimumInGB: 1,
+      },
+      /**
+       * Swap space of the VM, so that memory-heavy builds don't get k3s
+       * killed when the VM runs out of memory.  "zram" swaps to compressed
+       * memory, "file" to a file on the disk of the VM.  With sizeInGB 0, the
+       * size is chosen from the memory of the host.
+       */
+      swap:                {
+        mode:     SwapMode.ZRAM,
+        sizeInGB: 0,
+      },
+      /**
+       * linux only: the host CPUs the VM runs on.  The VM can be pinned to a
+       * list of CPUs, to the performance or efficiency cores of hybrid CPUs,
+       * or to the CPUs of NUMA nodes.
+       */
+      cpuAffinity:         {
+        mode:      CPUAffinityMode.NONE,
+        /** The CPUs for mode "cpus", as a list like "0-3,8". */
+        cpus:      '',
+        /** The NUMA nodes for mode "numa-nodes", as a list like "0". */
+        numaNodes: '',
+      },
+      /**
+       * linux only: if set, the VM can run virtual machines itself, e.g. for
+       * kind nodes or VMs in containers, when the host supports nested
+       * virtualization.
+       */
+      nestedVirtualization: false,
+      proxy:                {
         enabled:  false,
         address:  '',
         password: '',
@@ -26,6 +26,9 @@ let lockedSettings: LockedSettingsType = {};
 
 let _isFirstRun = false;
 let settings: Settings | undefined;
+// In safe mode, settings are only kept in memory, so that the settings file
+// is in effect again on the next normal start.
+let safeMode = false;
 
 /**
  * Load the settings file from disk, doing any migrations as necessary.
@@ -55,6 +58,12 @@ function loadFromDisk(): Settings {
 }
 
 export function save(cfg: Settings) {
+  if (safeMode) {
+    console.log('Running in safe mode; not saving settings.');
+    settings = cfg;
+
+    return;
+  }
   try {
     fs.mkdirSync(paths.config, { recursive: true });
     const rawdata = JSON.stringify(cfg);
@@ -99,6 +108,22 @@ export function createSettings(deploymentProfiles: DeploymentProfileType): Setti
   return finishConfiguringSettings(cfg, deploymentProfiles);
 }
 
+/**
+ * Start from the default settings, with the deployment profiles applied,
+ * ignoring the settings file; used to recover from settings that keep the
+ * application from starting, without a factory reset.  Nothing is saved until
+ * the application restarts.
+ */
+export function loadSafeMode(deploymentProfiles: DeploymentProfileType): Settings {
+  const cfg = clone(defaultSettings);
+
+  safeMode = true;
+  cfg.virtualMachine.memoryInGB = getDefaultMemory();
+  merge(cfg, deploymentProfiles.defaults);
+
+  return finishConfiguringSettings(cfg, deploymentProfiles);
+}
+
 /**
  * Used for unit testing only.
  * Could be used in core code if we ever want to reload changed deployment profiles, but that isn't needed now.
@@ -14,6 +14,7 @@ export type NavItemName = typeof navItemNames[number];
 
 export const defaultTransientSettings = {
   noModalDialogs: false,
+  safeMode:       false,
   preferences:    {
     navItem: {
       current:     'Application' as NavItemName,
@@ -9,6 +9,7 @@ import { State } from '@pkg/backend/k8s';
 import { Settings, ContainerEngine } from '@pkg/config/settings';
 import { runInDebugMode } from '@pkg/config/settingsImpl';
 import type { IntegrationManager } from '@pkg/integrations/integrationManager';
+import { gitConfigForVM, updateGitConfig, WSL_INTEGRATION_GIT_CREDENTIAL_HELPER } from '@pkg/main/credentialServer/gitCredentials';
 import mainEvents from '@pkg/main/mainEvents';
 import BackgroundProcess from '@pkg/utils/backgroundProcess';
 import { spawn, spawnFile } from '@pkg/utils/childProcess';
@@ -30,6 +31,9 @@ const DISTRO_BLACKLIST = [
   'docker-desktop-data', // Not meant for interactive use
 ];
 
+/** The system git configuration of WSL distributions. */
+const SYSTEM_GIT_CONFIG_PATH = '/etc/gitconfig';
+
 /**
  * Represents a WSL distro, as output by `wsl.exe --list --verbose`.
  */
@@ -53,6 +57,7 @@ export class WSLDistro {
  * - Docker socket forwarding.
  * - Kubeconfig.
  * - docker CLI plugin executables (WSL distributions only).
+ * - git credentials and identity (WSL distributions only).
  */
 export default class WindowsIntegrationManager implements IntegrationManager {
   /** A snapshot of the application-wide settings. */
@@ -76,9 +81,13 @@ export default class WindowsIntegrationManager implements IntegrationManager {
   /** Extra debugging arguments for wsl-helper. */
   protected wslHelperDebugArgs: string[] = [];
 
+  /** Extra logging arguments for wsl-helper running on the Windows host. */
+  protected wslHelperHostLogArgs: string[] = [];
+
   constructor() {
     mainEvents.on('settings-update', (settings) => {
       this.wslHelperDebugArgs = runInDebugMode(settings.application.debug) ? ['--verbose'] : [];
+      this.wslHelperHostLogArgs = settings.application.systemLog ? ['--system-log'] : [];
       this.settings = clone(settings);
       this.sync();
     });
@@ -100,7 +109,7 @@ export default class WindowsIntegrationManager implements IntegrationManager {
 
           return spawn(
             executable('wsl-helper'),
-            ['docker-proxy', 'serve', ...this.wslHelperDebugArgs], {
+            ['docker-proxy', 'serve', ...this.wslHelperDebugArgs, ...this.wslHelperHostLogArgs], {
               stdio:       ['ignore', stream, stream],
               windowsHide: true,
             });
@@ -147,6 +156,7 @@ export default class WindowsIntegrationManager implements IntegrationManager {
         this.syncDistroSocketProxy(distro, state),
         this.syncDistroDockerPlugins(distro, state),
         this.syncDistroKubeconfig(distro, kubeconfigPath, state),
+        this.syncDistroGitConfig(distro, state),
       ]);
     } catch (ex) {
       console.error(`Failed to sync integration for ${ distro }: ${ ex }`);
@@ -408,6 +418,39 @@ export default class WindowsIntegrationManager implements IntegrationManager {
     }
   }
 
+  /**
+   * Configure git in the distribution to use the credentials and identity of
+   * the host when the gitBridge setting is enabled, and undo it otherwise.
+   * Only the block of /etc/gitconfig written by Rancher Desktop is changed.
+   */
+  protected async syncDistroGitConfig(distro: string, state: boolean) {
+    const enabled = state && !!this.settings.experimental?.virtualMachine?.gitBridge;
+
+    try {
+      const current = await this.captureCommand({ distro }, '/bin/sh', '-c', `[ ! -e ${ SYSTEM_GIT_CONFIG_PATH } ] || cat ${ SYSTEM_GIT_CONFIG_PATH }`);
+      const contents = updateGitConfig(current, enabled ? await gitConfigForVM(WSL_INTEGRATION_GIT_CREDENTIAL_HELPER) : '');
+
+      if (contents === current) {
+        return;
+      }
+      console.debug(`Updating ${ distro } git configuration`);
+      await this.execCommand(
+        {
+          distro,
+          root: true,
+          env:  {
+            ...process.env,
+            RD_GIT_CONFIG: contents,
+            WSLENV:        `${ process.env.WSLENV }:RD_GIT_CONFIG`,
+          },
+        },
+        '/bin/sh', '-c', `if [ -n "$RD_GIT_CONFIG" ]; then printf '%s' "$RD_GIT_CONFIG" > ${ SYSTEM_GIT_CONFIG_PATH }; else rm -f ${ SYSTEM_GIT_CONFIG_PATH }; fi`,
+      );
+    } catch (error: any) {
+      console.error(`Could not update ${ distro } git configuration`, error);
+    }
+  }
+
   protected async syncHostFile() {
     await Promise.all(
       (await this.supportedDistros).map((distro) => {
@@ -5,11 +5,13 @@ import os from 'os';
 import path from 'path';
 
 import * as settings from '@pkg/config/settings';
-import { readDeploymentProfiles, validateDeploymentProfile } from '@pkg/main/deploymentProfiles';
+import { getDeploymentProfileProblems, readDeploymentProfiles, validateDeploymentProfile } from '@pkg/main/deploymentProfiles';
 import { spawnFile } from '@pkg/utils/childProcess';
 import { RecursivePartial } from '@pkg/utils/typeUtils';
 
 const [describeWindows, describeNotWindows] = process.platform === 'win32' ? [describe, describe.skip] : [describe.skip, describe];
+// Profiles are read as JSON files only on Linux; macOS uses plutil.
+const testLinux = process.platform === 'linux' ? test : test.skip;
 
 describe('deployment profiles', () => {
   describeWindows('windows deployment profiles', () => {
@@ -310,16 +312,11 @@ describe('deployment profiles', () => {
               `kubernetes\\version': expecting value of type string, got a registry object`,
               `WSL\\integrations': expecting value of type object, got a SZ, value: '"should be a sub-object"'`,
             ].map(s => `Error for field '${ FULL_DEFAULTS_PATH_IN_MESSAGE }\\${ s }`);
-            let error: Error | undefined;
-
-            try {
-              await readDeploymentProfiles(REGISTRY_PROFILE_PATHS);
-            } catch (ex: any) {
-              error = ex;
-            }
-            expect(error).toBeInstanceOf(Error);
-            expectedErrors.unshift('Error in registry settings:');
-            expect((error?.message ?? '').split('\n')).toEqual(expect.arrayContaining(expectedErrors));
+            const profile = await readDeploymentProfiles(REGISTRY_PROFILE_PATHS);
+
+            expect(profile.defaults).not.toHaveProperty('application.adminAccess');
+            expect(profile.defaults).not.toHaveProperty('containerEngine.name');
+            expect(getDeploymentProfileProblems()).toEqual(expect.arrayContaining(expectedErrors.map(message => expect.objectContaining({ severity: 'error', message }))));
           });
         });
       });
@@ -401,5 +398,32 @@ describe('deployment profiles', () => {
       expect(error).toBeInstanceOf(Error);
       expect((error?.message ?? '').split('\n')).toEqual(expect.arrayContaining(expectedErrors));
     });
+    testLinux('drops invalid and unknown fields from defaults', async() => {
+      jest.spyOn(fs, 'readFileSync').mockImplementation((filePath) => {
+        if (path.basename(filePath.toString()) === 'rancher-desktop.defaults.json') {
+          return JSON.stringify({
+            version:         settings.CURRENT_SETTINGS_VERSION,
+            containerEngine: { name: 5, allowedImages: { enabled: true } },
+            kubernetes:      { enabled: false, colour: 'blue' },
+          });
+        }
+        throw Object.assign(new Error(`${ filePath } not found`), { code: 'ENOENT' });
+      });
+      try {
+        const profile = await readDeploymentProfiles();
+
+        expect(profile.defaults).toEqual({
+          version:         settings.CURRENT_SETTINGS_VERSION,
+          containerEngine: { allowedImages: { enabled: true } },
+          kubernetes:      { enabled: false },
+        });
+        expect(getDeploymentProfileProblems()).toEqual([
+          expect.objectContaining({ severity: 'error', message: `Error for field 'containerEngine.name': expecting value of type string, got '5'` }),
+          expect.objectContaining({ severity: 'warning', message: `Ignoring field 'kubernetes.colour': not a known setting` }),
+        ]);
+      } finally {
+        jest.restoreAllMocks();
+      }
+    });
   });
 });
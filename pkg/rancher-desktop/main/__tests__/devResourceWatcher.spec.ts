@@ -0,0 +1,47 @@
+/** @jest-environment node */
+
+import fs from 'fs';
+import os from 'os';
+import path from 'path';
+
+import DevResourceWatcher from '@pkg/main/devResourceWatcher';
+import paths from '@pkg/utils/paths';
+
+describe('DevResourceWatcher', () => {
+  let resources: string;
+  let watcher: DevResourceWatcher | undefined;
+
+  beforeEach(async() => {
+    resources = await fs.promises.mkdtemp(path.join(os.tmpdir(), 'rd-dev-resources-'));
+    await fs.promises.mkdir(path.join(resources, os.platform(), 'bin'), { recursive: true });
+    jest.replaceProperty(paths, 'resources', resources);
+  });
+
+  afterEach(async() => {
+    watcher?.stop();
+    jest.restoreAllMocks();
+    await fs.promises.rm(resources, { recursive: true, force: true });
+  });
+
+  it('watches the binaries for the host and the VM', () => {
+    watcher = new DevResourceWatcher(jest.fn());
+
+    expect(watcher.directories).toEqual(expect.arrayContaining([
+      path.join(resources, os.platform(), 'bin'),
+      path.join(resources, 'linux', 'internal'),
+    ]));
+  });
+
+  it('reports changes once they settle', async() => {
+    const changes = new Promise<string[]>((resolve) => {
+      watcher = new DevResourceWatcher(resolve);
+    });
+    const binary = path.join(resources, os.platform(), 'bin', 'rdctl');
+
+    watcher?.start();
+    await fs.promises.writeFile(binary, 'first');
+    await fs.promises.writeFile(binary, 'second');
+
+    await expect(changes).resolves.toEqual([binary]);
+  });
+});
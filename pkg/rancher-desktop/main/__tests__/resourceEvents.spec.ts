@@ -0,0 +1,54 @@
+import {
+  containerFromCgroup,
+  HIGH_PRESSURE,
+  NORMAL_PRESSURE,
+  parseOOMKill,
+  PressureTracker,
+} from '@pkg/main/resourceEvents';
+
+const containerID = '0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef';
+
+describe('parseOOMKill', () => {
+  it('parses the oom-kill record of the kernel', () => {
+    const record = `oom-kill:constraint=CONSTRAINT_MEMCG,nodemask=(null),cpuset=${ containerID },mems_allowed=0,` +
+      `oom_memcg=/default/${ containerID },task_memcg=/default/${ containerID },task=node,pid=1234,uid=0`;
+
+    expect(parseOOMKill(record)).toEqual({ process: 'node', pid: 1234, cgroup: `/default/${ containerID }` });
+  });
+
+  it('ignores other records', () => {
+    expect(parseOOMKill('Out of memory: Killed process 1234 (node)')).toBeUndefined();
+    expect(parseOOMKill('oom-kill:constraint=CONSTRAINT_NONE')).toBeUndefined();
+  });
+});
+
+describe('containerFromCgroup', () => {
+  it.each([
+    [`/docker/${ containerID }`, { id: containerID }],
+    [`/system.slice/docker-${ containerID }.scope`, { id: containerID }],
+    [`/default/${ containerID }`, { id: containerID, namespace: 'default' }],
+    [`/system.slice/nerdctl-${ containerID }.scope`, { id: containerID }],
+    [`/kubepods/burstable/pod0a1b2c3d-1234/${ containerID }`, { id: containerID, namespace: 'k8s.io' }],
+    [`/kubepods.slice/kubepods-pod0a1b2c3d.slice/cri-containerd-${ containerID }.scope`, { id: containerID, namespace: 'k8s.io' }],
+  ])('finds the container of %s', (cgroup, expected) => {
+    expect(containerFromCgroup(cgroup)).toEqual(expected);
+  });
+
+  it.each(['', '/', '/system.slice/sshd.service', '/kubepods/burstable/pod0a1b2c3d-1234'])('finds no container in %j', (cgroup) => {
+    expect(containerFromCgroup(cgroup)).toBeUndefined();
+  });
+});
+
+describe('PressureTracker', () => {
+  it('reports when the pressure becomes high and when it is over', () => {
+    const tracker = new PressureTracker();
+
+    expect(tracker.update('memory', HIGH_PRESSURE - 1, 0)).toBeUndefined();
+    expect(tracker.update('memory', HIGH_PRESSURE, 10)).toEqual({ resource: 'memory', high: true, some: HIGH_PRESSURE, full: 10 });
+    expect(tracker.update('cpu', 10, 0)).toBeUndefined();
+    // The pressure stays high until it falls under the normal level.
+    expect(tracker.update('memory', NORMAL_PRESSURE, 0)).toBeUndefined();
+    expect(tracker.update('memory', NORMAL_PRESSURE - 1, 0)).toEqual({ resource: 'memory', high: false, some: NORMAL_PRESSURE - 1, full: 0 });
+    expect(tracker.update('memory', NORMAL_PRESSURE - 1, 0)).toBeUndefined();
+  });
+});
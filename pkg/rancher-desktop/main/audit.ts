@@ -0,0 +1,26 @@
+import fs from 'fs';
+import path from 'path';
+
+import Logging, { AUDIT_LOG_NAME } from '@pkg/utils/logging';
+import paths from '@pkg/utils/paths';
+
+const console = Logging.background;
+
+/**
+ * Record a policy decision, such as a denied extension install, in the audit
+ * log, for administrators to collect.  Entries are JSON objects, one per line;
+ * unlike the other logs, the audit log is kept across restarts.
+ * @param event The kind of event, e.g. `extension-install-denied`.
+ * @param details What the event is about.
+ */
+export default async function audit(event: string, details: Record<string, any>) {
+  const entry = JSON.stringify({
+    time: new Date().toISOString(), event, ...details,
+  });
+
+  try {
+    await fs.promises.appendFile(path.join(paths.logs, AUDIT_LOG_NAME), `${ entry }\n`, { mode: 0o600 });
+  } catch (ex) {
+    console.error(`Failed to write ${ event } event to the audit log:`, ex);
+  }
+}
@@ -0,0 +1,31 @@
+/** @jest-environment node */
+
+import { isAPICredentialsServerURL } from '@pkg/main/commandServer/apiCredentials';
+
+jest.mock('@pkg/utils/childProcess');
+
+describe('isAPICredentialsServerURL', () => {
+  it.each([
+    'rancher-desktop-api',
+    'rancher-desktop-api-viewer',
+    ' rancher-desktop-api\n',
+    'Rancher-Desktop-API',
+    'https://rancher-desktop-api',
+    'https://RANCHER-DESKTOP-API:443/',
+    'rancher-desktop-api:1234',
+    'rancher-desktop-api.',
+    'user@rancher-desktop-api/v1',
+    'https://ranch%65r-desktop-api/',
+  ])('matches %j', (serverURL) => {
+    expect(isAPICredentialsServerURL(serverURL)).toBe(true);
+  });
+
+  it.each([
+    'https://index.docker.io/v1/',
+    'ghcr.io',
+    'rancher-desktop',
+    '',
+  ])('does not match %j', (serverURL) => {
+    expect(isAPICredentialsServerURL(serverURL)).toBe(false);
+  });
+});
@@ -0,0 +1,38 @@
+import { State, VMBackend } from '@pkg/backend/backend';
+import { EventStream, formatStreamEvent, StreamEvent } from '@pkg/main/commandServer/eventStream';
+import mainEvents from '@pkg/main/mainEvents';
+
+describe('EventStream', () => {
+  it('relays the events of the main process until closed', () => {
+    const events: StreamEvent[] = [];
+    const stream = new EventStream(event => events.push(event));
+
+    mainEvents.emit('k8s-check-state', { state: State.STARTED } as VMBackend);
+    mainEvents.emit('backend-locked-update', 'Restoring snapshot', 'restore');
+    mainEvents.emit('backend-locked-update', '');
+    mainEvents.emit('image-operation', 'pull', 0);
+    mainEvents.emit('diagnostics-update', 'DOCKER_CLI_SYMLINK', false);
+    mainEvents.emit('resource-oom-kill', { process: 'node', pid: 1234, cgroup: '/default/0123', container: { id: '0123', name: 'web' } });
+    mainEvents.emit('resource-pressure', { resource: 'memory', high: true, some: 60, full: 20 });
+    stream.close();
+    mainEvents.emit('image-operation', 'push', 1);
+
+    expect(events.map(({ type, data }) => ({ type, data }))).toEqual([
+      { type: 'backend-state-changed', data: { state: 'STARTED' } },
+      { type: 'backend-lock-changed', data: { locked: true, action: 'restore' } },
+      { type: 'backend-lock-changed', data: { locked: false } },
+      { type: 'image-operation', data: { operation: 'pull', exitCode: 0 } },
+      { type: 'diagnostics-updated', data: { id: 'DOCKER_CLI_SYMLINK', passed: false } },
+      { type: 'oom-killed', data: { process: 'node', pid: 1234, cgroup: '/default/0123', container: { id: '0123', name: 'web' } } },
+      { type: 'resource-pressure', data: { resource: 'memory', high: true, some: 60, full: 20 } },
+    ]);
+  });
+});
+
+describe('formatStreamEvent', () => {
+  it('writes the event as a single data line', () => {
+    const event: StreamEvent = { type: 'settings-changed', time: '2024-05-01T10:00:00.000Z', data: {} };
+
+    expect(formatStreamEvent(event)).toEqual(`event: settings-changed\ndata: ${ JSON.stringify(event) }\n\n`);
+  });
+});
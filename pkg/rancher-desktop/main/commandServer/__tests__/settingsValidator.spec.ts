@@ -23,13 +23,22 @@ const cfg = _.merge(
   });
 const subject = new SettingsValidator();
 let spyPlatform: jest.SpiedFunction<typeof os.platform>;
+let spyTotalMem: jest.SpiedFunction<typeof os.totalmem>;
+let spyCPUs: jest.SpiedFunction<typeof os.cpus>;
 
 beforeEach(() => {
   spyPlatform = jest.spyOn(os, 'platform');
+  // The resources of the VM are checked against a host with 16 GB and 8 CPUs.
+  spyTotalMem = jest.spyOn(os, 'totalmem').mockReturnValue(16 * 2 ** 30);
+  spyCPUs = jest.spyOn(os, 'cpus').mockReturnValue(Array(8).fill({
+    model: 'cpu', speed: 0, times: { user: 0, nice: 0, sys: 0, idle: 0, irq: 0 },
+  }));
 });
 
 afterEach(() => {
   spyPlatform.mockRestore();
+  spyTotalMem.mockRestore();
+  spyCPUs.mockRestore();
 });
 
 cfg.virtualMachine.memoryInGB ||= getDefaultMemory();
@@ -72,14 +81,23 @@ describe(SettingsValidator, () => {
       ['application', 'pathManagementStrategy'],
       ['containerEngine', 'allowedImages', 'locked'],
       ['containerEngine', 'name'],
+      ['experimental', 'virtualMachine', 'cpuAffinity'],
+      ['experimental', 'virtualMachine', 'memoryReclaim', 'mode'],
       ['experimental', 'virtualMachine', 'mount', '9p', 'cacheMode'],
       ['experimental', 'virtualMachine', 'mount', '9p', 'msizeInKib'],
       ['experimental', 'virtualMachine', 'mount', '9p', 'protocolVersion'],
       ['experimental', 'virtualMachine', 'mount', '9p', 'securityModel'],
       ['experimental', 'virtualMachine', 'mount', 'type'],
+      ['experimental', 'virtualMachine', 'swap', 'mode'],
+      ['experimental', 'virtualMachine', 'trimInterval'],
       ['experimental', 'virtualMachine', 'type'],
       ['experimental', 'virtualMachine', 'useRosetta'],
       ['experimental', 'virtualMachine', 'proxy', 'noproxy'],
+      ['kubernetes', 'certificateAuthority', 'certificate'],
+      ['kubernetes', 'certificateAuthority', 'key'],
+      ['kubernetes', 'kubeconfig', 'certificates'],
+      ['kubernetes', 'oidc'],
+      ['kubernetes', 'storage', 'path'],
       ['kubernetes', 'version'],
       ['version'],
       ['WSL', 'integrations'],
@@ -87,18 +105,23 @@ describe(SettingsValidator, () => {
 
     // Fields that can only be set on specific platforms.
     const platformSpecificFields: Record<string, ReturnType<typeof os.platform>> = {
-      'application.adminAccess':                      'linux',
-      'experimental.virtualMachine.socketVMNet':      'darwin',
-      'experimental.virtualMachine.networkingTunnel': 'win32',
-      'experimental.virtualMachine.proxy.enabled':    'win32',
-      'experimental.virtualMachine.proxy.address':    'win32',
-      'experimental.virtualMachine.proxy.password':   'win32',
-      'experimental.virtualMachine.proxy.port':       'win32',
-      'experimental.virtualMachine.proxy.username':   'win32',
-      'kubernetes.ingress.localhostOnly':             'win32',
-      'virtualMachine.hostResolver':                  'win32',
-      'virtualMachine.memoryInGB':                    'darwin',
-      'virtualMachine.numberCPUs':                    'linux',
+      'application.adminAccess':                               'linux',
+      'application.systemLog':                                 'win32',
+      'experimental.virtualMachine.socketVMNet':               'darwin',
+      'experimental.virtualMachine.sshAgentForwarding':        'linux',
+      'experimental.virtualMachine.nestedVirtualization':      'linux',
+      'experimental.virtualMachine.networkingTunnel':          'win32',
+      'experimental.virtualMachine.firewallRules':             'win32',
+      'experimental.virtualMachine.memoryReclaim.minimumInGB': 'win32',
+      'experimental.virtualMachine.proxy.enabled':             'win32',
+      'experimental.virtualMachine.proxy.address':             'win32',
+      'experimental.virtualMachine.proxy.password':            'win32',
+      'experimental.virtualMachine.proxy.port':                'win32',
+      'experimental.virtualMachine.proxy.username':            'win32',
+      'kubernetes.ingress.localhostOnly':                      'win32',
+      'virtualMachine.hostResolver':                           'win32',
+      'virtualMachine.memoryInGB':                             'darwin',
+      'virtualMachine.numberCPUs':                             'linux',
     };
 
     const spyValidateSettings = jest.spyOn(subject, 'validateSettings');
@@ -224,6 +247,67 @@ describe(SettingsValidator, () => {
     });
   });
 
+  describe('virtualMachine host resources', () => {
+    beforeEach(() => {
+      spyPlatform.mockReturnValue('darwin');
+    });
+
+    it('accepts resources up to the limits of the host', () => {
+      const input: RecursivePartial<settings.Settings> = { virtualMachine: { memoryInGB: 12, numberCPUs: 8 } };
+      const [needToUpdate, errors, , warnings] = subject.validateSettings(cfg, input);
+
+      expect({ needToUpdate, errors, warnings }).toEqual({
+        needToUpdate: true,
+        errors:       [],
+        warnings:     [],
+      });
+    });
+
+    it('refuses more than the host has', () => {
+      const input: RecursivePartial<settings.Settings> = { virtualMachine: { memoryInGB: 17, numberCPUs: 9 } };
+      const [needToUpdate, errors] = subject.validateSettings(cfg, input, {}, true);
+
+      expect({ needToUpdate, errors }).toEqual({
+        needToUpdate: false,
+        errors:       [
+          'field "virtualMachine.memoryInGB" is 17 GB, but the host only has 16 GB',
+          'field "virtualMachine.numberCPUs" is 9 CPUs, but the host only has 8 CPUs',
+        ],
+      });
+    });
+
+    it('refuses more than the limit unless forced', () => {
+      const input: RecursivePartial<settings.Settings> = { virtualMachine: { memoryInGB: 13 } };
+      const [needToUpdate, errors] = subject.validateSettings(cfg, input);
+
+      expect({ needToUpdate, errors }).toEqual({
+        needToUpdate: false,
+        errors:       ['field "virtualMachine.memoryInGB" is 13 GB, over the limit of 12 GB for this host; force the change to accept it'],
+      });
+    });
+
+    it('warns about forced values over the limit', () => {
+      const input: RecursivePartial<settings.Settings> = { virtualMachine: { memoryInGB: 13 } };
+      const [needToUpdate, errors, , warnings] = subject.validateSettings(cfg, input, {}, true);
+
+      expect({ needToUpdate, errors, warnings }).toEqual({
+        needToUpdate: true,
+        errors:       [],
+        warnings:     ['field "virtualMachine.memoryInGB" is 13 GB, over the limit of 12 GB for this host'],
+      });
+    });
+
+    it('accepts unchanged values over the limit', () => {
+      const current = _.merge({}, cfg, { virtualMachine: { memoryInGB: 32 } });
+      const [needToUpdate, errors] = subject.validateSettings(current, { virtualMachine: { memoryInGB: 32 } });
+
+      expect({ needToUpdate, errors }).toEqual({
+        needToUpdate: false,
+        errors:       [],
+      });
+    });
+  });
+
   describe('containerEngine.name', () => {
     function configWithValue(value: string | settings.ContainerEngine): settings.Settings {
       return {
@@ -499,6 +583,393 @@ describe(SettingsValidator, () => {
     });
   });
 
+  describe('kubernetes.node', () => {
+    it('accepts valid labels and taints', () => {
+      const input: RecursivePartial<settings.Settings> = {
+        kubernetes: {
+          node: {
+            labels: ['tier=frontend', 'example.com/zone=', 'rancherdesktop.io/role=dev_1'],
+            taints: ['dedicated=gpu:NoSchedule', 'example.com/maintenance:NoExecute'],
+          },
+        },
+      };
+      const [needToUpdate, errors] = subject.validateSettings(cfg, input);
+
+      expect({ needToUpdate, errors }).toEqual({
+        needToUpdate: true,
+        errors:       [],
+      });
+    });
+
+    it('rejects malformed labels', () => {
+      const input: RecursivePartial<settings.Settings> = { kubernetes: { node: { labels: ['tier=frontend', 'no-value', '-bad=key'] } } };
+      const [needToUpdate, errors, isFatal] = subject.validateSettings(cfg, input);
+
+      expect({ needToUpdate, errors, isFatal }).toEqual({
+        needToUpdate: false,
+        errors:       ['field "kubernetes.node.labels" has invalid entries: "no-value", "-bad=key"; labels must have the form KEY=VALUE'],
+        isFatal:      false,
+      });
+    });
+
+    it('rejects taints with an unknown effect', () => {
+      const input: RecursivePartial<settings.Settings> = { kubernetes: { node: { taints: ['dedicated=gpu:NoRun', 'dedicated=gpu'] } } };
+      const [needToUpdate, errors, isFatal] = subject.validateSettings(cfg, input);
+
+      expect({ needToUpdate, errors, isFatal }).toEqual({
+        needToUpdate: false,
+        errors:       ['field "kubernetes.node.taints" has invalid entries: "dedicated=gpu:NoRun", "dedicated=gpu"; taints must have the form KEY[=VALUE]:EFFECT, where EFFECT is NoSchedule, PreferNoSchedule, or NoExecute'],
+        isFatal:      false,
+      });
+    });
+
+    it('complains about duplicate labels', () => {
+      const input: RecursivePartial<settings.Settings> = { kubernetes: { node: { labels: ['tier=frontend', 'tier=frontend'] } } };
+      const [needToUpdate, errors] = subject.validateSettings(cfg, input);
+
+      expect({ needToUpdate, errors }).toEqual({
+        needToUpdate: false,
+        errors:       ['field "kubernetes.node.labels" has duplicate entries: "tier=frontend"'],
+      });
+    });
+  });
+
+  describe('containerEngine.environment', () => {
+    it('accepts valid variables', () => {
+      const input: RecursivePartial<settings.Settings> = {
+        containerEngine: { environment: ['HTTP_PROXY=http://proxy.example.com:3128', 'GOPROXY=direct', 'EMPTY=', 'QUERY=a=b'] },
+      };
+      const [needToUpdate, errors] = subject.validateSettings(cfg, input);
+
+      expect({ needToUpdate, errors }).toEqual({
+        needToUpdate: true,
+        errors:       [],
+      });
+    });
+
+    it('rejects malformed variables', () => {
+      const input: RecursivePartial<settings.Settings> = { containerEngine: { environment: ['GOPROXY=direct', 'NO_VALUE', '1BAD=x'] } };
+      const [needToUpdate, errors, isFatal] = subject.validateSettings(cfg, input);
+
+      expect({ needToUpdate, errors, isFatal }).toEqual({
+        needToUpdate: false,
+        errors:       ['field "containerEngine.environment" has invalid entries: "NO_VALUE", "1BAD=x"; environment variables must have the form KEY=VALUE'],
+        isFatal:      false,
+      });
+    });
+  });
+
+  describe('experimental.virtualMachine.reversePortForwards', () => {
+    it('accepts valid forwards', () => {
+      const input: RecursivePartial<settings.Settings> = { experimental: { virtualMachine: { reversePortForwards: ['5432', '9229:9230', '65535:1'] } } };
+      const [needToUpdate, errors] = subject.validateSettings(cfg, input);
+
+      expect({ needToUpdate, errors }).toEqual({
+        needToUpdate: true,
+        errors:       [],
+      });
+    });
+
+    it('rejects malformed forwards', () => {
+      const input: RecursivePartial<settings.Settings> = { experimental: { virtualMachine: { reversePortForwards: ['5432', '0', '65536', '1:2:3', 'db'] } } };
+      const [needToUpdate, errors, isFatal] = subject.validateSettings(cfg, input);
+
+      expect({ needToUpdate, errors, isFatal }).toEqual({
+        needToUpdate: false,
+        errors:       ['field "experimental.virtualMachine.reversePortForwards" has invalid entries: "0", "65536", "1:2:3", "db"; reverse port forwards must have the form GUEST_PORT:HOST_PORT or PORT'],
+        isFatal:      false,
+      });
+    });
+
+    it('rejects guest ports used by Rancher Desktop or forwarded twice', () => {
+      const input: RecursivePartial<settings.Settings> = {
+        experimental: { virtualMachine: { reversePortForwards: ['22:2222', '6443', '10250:1', '5432', '5432:5433'] } },
+        kubernetes:   { port: 7443 },
+      };
+      const [needToUpdate, errors] = subject.validateSettings(cfg, input);
+
+      expect({ needToUpdate, errors }).toEqual({
+        needToUpdate: false,
+        errors:       ['field "experimental.virtualMachine.reversePortForwards" has invalid guest ports: port 22 is used by Rancher Desktop; port 6443 is used by Rancher Desktop; port 10250 is used by Rancher Desktop; port 5432 is forwarded more than once'],
+      });
+    });
+
+    it('rejects the Kubernetes API port', () => {
+      const input: RecursivePartial<settings.Settings> = {
+        experimental: { virtualMachine: { reversePortForwards: ['7443:8080'] } },
+        kubernetes:   { port: 7443 },
+      };
+      const [, errors] = subject.validateSettings(cfg, input);
+
+      expect(errors).toEqual(['field "experimental.virtualMachine.reversePortForwards" has invalid guest ports: port 7443 is used by Rancher Desktop']);
+    });
+  });
+
+  describe('experimental.virtualMachine.sysctls', () => {
+    it('accepts valid kernel parameters', () => {
+      const input: RecursivePartial<settings.Settings> = { experimental: { virtualMachine: { sysctls: ['fs.inotify.max_user_watches=524288', 'net/ipv4/ip_local_port_range=1024 65535', 'vm.overcommit_memory=1'] } } };
+      const [needToUpdate, errors] = subject.validateSettings(cfg, input);
+
+      expect({ needToUpdate, errors }).toEqual({
+        needToUpdate: true,
+        errors:       [],
+      });
+    });
+
+    it('rejects malformed kernel parameters', () => {
+      const input: RecursivePartial<settings.Settings> = { experimental: { virtualMachine: { sysctls: ['fs.inotify.max_user_watches=524288', 'fs.inotify.max_user_instances', '=1', 'kernel..panic=1', 'vm max_map_count=1'] } } };
+      const [needToUpdate, errors] = subject.validateSettings(cfg, input);
+
+      expect({ needToUpdate, errors }).toEqual({
+        needToUpdate: false,
+        errors:       ['field "experimental.virtualMachine.sysctls" has invalid entries: "fs.inotify.max_user_instances", "=1", "kernel..panic=1", "vm max_map_count=1"; kernel parameters must have the form KEY=VALUE'],
+      });
+    });
+  });
+
+  describe('experimental.virtualMachine.trimInterval', () => {
+    it.each(Object.values(settings.TrimInterval))('accepts %j', (trimInterval) => {
+      const input: RecursivePartial<settings.Settings> = { experimental: { virtualMachine: { trimInterval } } };
+      const [, errors] = subject.validateSettings(cfg, input);
+
+      expect(errors).toEqual([]);
+    });
+
+    it('rejects unknown intervals', () => {
+      const input: RecursivePartial<settings.Settings> = { experimental: { virtualMachine: { trimInterval: 'monthly' as settings.TrimInterval } } };
+      const [needToUpdate, errors] = subject.validateSettings(cfg, input);
+
+      expect(needToUpdate).toBe(false);
+      expect(errors).toHaveLength(1);
+      expect(errors[0]).toContain('experimental.virtualMachine.trimInterval');
+    });
+  });
+
+  describe('experimental.virtualMachine.memoryReclaim', () => {
+    beforeEach(() => {
+      spyPlatform.mockReturnValue('win32');
+    });
+
+    it.each(Object.values(settings.MemoryReclaimMode))('accepts mode %j', (mode) => {
+      const input: RecursivePartial<settings.Settings> = { experimental: { virtualMachine: { memoryReclaim: { mode } } } };
+      const [, errors] = subject.validateSettings(cfg, input);
+
+      expect(errors).toEqual([]);
+    });
+
+    it('rejects unknown modes', () => {
+      const input: RecursivePartial<settings.Settings> = { experimental: { virtualMachine: { memoryReclaim: { mode: 'aggressive' as settings.MemoryReclaimMode } } } };
+      const [needToUpdate, errors] = subject.validateSettings(cfg, input);
+
+      expect(needToUpdate).toBe(false);
+      expect(errors).toHaveLength(1);
+      expect(errors[0]).toContain('experimental.virtualMachine.memoryReclaim.mode');
+    });
+
+    it('rejects negative minimums', () => {
+      const input: RecursivePartial<settings.Settings> = { experimental: { virtualMachine: { memoryReclaim: { minimumInGB: -1 } } } };
+      const [needToUpdate, errors] = subject.validateSettings(cfg, input);
+
+      expect(needToUpdate).toBe(false);
+      expect(errors).toHaveLength(1);
+      expect(errors[0]).toContain('experimental.virtualMachine.memoryReclaim.minimumInGB');
+    });
+
+    it('is not supported on other platforms', () => {
+      spyPlatform.mockReturnValue('darwin');
+      const input: RecursivePartial<settings.Settings> = { experimental: { virtualMachine: { memoryReclaim: { mode: settings.MemoryReclaimMode.GRADUAL } } } };
+      const [needToUpdate, errors] = subject.validateSettings(cfg, input);
+
+      expect(needToUpdate).toBe(false);
+      expect(errors).toHaveLength(1);
+      expect(errors[0]).toContain('experimental.virtualMachine.memoryReclaim.mode');
+    });
+  });
+
+  describe('experimental.virtualMachine.swap', () => {
+    it.each(Object.values(settings.SwapMode))('accepts mode %j', (mode) => {
+      const input: RecursivePartial<settings.Settings> = { experimental: { virtualMachine: { swap: { mode } } } };
+      const [, errors] = subject.validateSettings(cfg, input);
+
+      expect(errors).toEqual([]);
+    });
+
+    it('rejects unknown modes', () => {
+      const input: RecursivePartial<settings.Settings> = { experimental: { virtualMachine: { swap: { mode: 'zswap' as settings.SwapMode } } } };
+      const [needToUpdate, errors] = subject.validateSettings(cfg, input);
+
+      expect(needToUpdate).toBe(false);
+      expect(errors).toHaveLength(1);
+      expect(errors[0]).toContain('experimental.virtualMachine.swap.mode');
+    });
+
+    it('rejects negative sizes', () => {
+      const input: RecursivePartial<settings.Settings> = { experimental: { virtualMachine: { swap: { sizeInGB: -1 } } } };
+      const [needToUpdate, errors] = subject.validateSettings(cfg, input);
+
+      expect(needToUpdate).toBe(false);
+      expect(errors).toHaveLength(1);
+      expect(errors[0]).toContain('experimental.virtualMachine.swap.sizeInGB');
+    });
+  });
+
+  describe('experimental.virtualMachine.cpuAffinity', () => {
+    beforeEach(() => {
+      spyPlatform.mockReturnValue('linux');
+    });
+
+    it.each(Object.values(settings.CPUAffinityMode))('accepts mode %j', (mode) => {
+      const input: RecursivePartial<settings.Settings> = { experimental: { virtualMachine: { cpuAffinity: { mode } } } };
+      const [, errors] = subject.validateSettings(cfg, input);
+
+      expect(errors).toEqual([]);
+    });
+
+    it.each(['0', '0-3,8', '2,4-5', ''])('accepts the list %j', (cpus) => {
+      const input: RecursivePartial<settings.Settings> = { experimental: { virtualMachine: { cpuAffinity: { cpus, numaNodes: cpus } } } };
+      const [, errors] = subject.validateSettings(cfg, input);
+
+      expect(errors).toEqual([]);
+    });
+
+    it.each(['0-', 'all', '1,,2', '0 1'])('rejects the list %j', (cpus) => {
+      const input: RecursivePartial<settings.Settings> = { experimental: { virtualMachine: { cpuAffinity: { cpus } } } };
+      const [needToUpdate, errors] = subject.validateSettings(cfg, input);
+
+      expect(needToUpdate).toBe(false);
+      expect(errors).toEqual([`field "experimental.virtualMachine.cpuAffinity.cpus" has an invalid value "${ cpus }"; it must be a list like 0-3,8`]);
+    });
+
+    it('is not supported on other platforms', () => {
+      spyPlatform.mockReturnValue('darwin');
+      const input: RecursivePartial<settings.Settings> = { experimental: { virtualMachine: { cpuAffinity: { mode: settings.CPUAffinityMode.PERFORMANCE } } } };
+      const [needToUpdate, errors] = subject.validateSettings(cfg, input);
+
+      expect(needToUpdate).toBe(false);
+      expect(errors).toHaveLength(1);
+      expect(errors[0]).toContain('experimental.virtualMachine.cpuAffinity.mode');
+    });
+  });
+
+  describe('kubernetes.storage.path', () => {
+    it.each(['/mnt/volumes', '/var/lib/rancher/k3s/storage', ''])('accepts %j', (path) => {
+      const [needToUpdate, errors] = subject.validateSettings(cfg, { kubernetes: { storage: { path } } });
+
+      expect({ needToUpdate, errors }).toEqual({
+        needToUpdate: path !== cfg.kubernetes.storage.path,
+        errors:       [],
+      });
+    });
+
+    it.each([
+      'volumes',
+      '/mnt/my volumes',
+      '/mnt/$(reboot)',
+      '/mnt/`reboot`',
+      '/mnt/a";reboot;"',
+      '/mnt/a\\b',
+    ])('rejects %j', (path) => {
+      const [needToUpdate, errors] = subject.validateSettings(cfg, { kubernetes: { storage: { path } } });
+
+      expect({ needToUpdate, errors }).toEqual({
+        needToUpdate: false,
+        errors:       [`Invalid value for "kubernetes.storage.path": <${ JSON.stringify(path) }>; must be an absolute path in the VM containing only letters, digits, '.', '_', '-', and '/'`],
+      });
+    });
+  });
+
+  describe('kubernetes.certificateAuthority', () => {
+    const certificateAuthority = { certificate: '/certs/ca.crt', key: '/certs/ca.key' };
+
+    it('accepts a certificate with its key', () => {
+      const [needToUpdate, errors] = subject.validateSettings(cfg, { kubernetes: { certificateAuthority } });
+
+      expect({ needToUpdate, errors }).toEqual({ needToUpdate: true, errors: [] });
+    });
+
+    it('accepts clearing both', () => {
+      const current = _.merge({}, cfg, { kubernetes: { certificateAuthority } });
+      const [needToUpdate, errors] = subject.validateSettings(current, { kubernetes: { certificateAuthority: { certificate: '', key: '' } } });
+
+      expect({ needToUpdate, errors }).toEqual({ needToUpdate: true, errors: [] });
+    });
+
+    it('rejects a certificate without a key', () => {
+      const [needToUpdate, errors] = subject.validateSettings(cfg, { kubernetes: { certificateAuthority: { certificate: '/certs/ca.crt' } } });
+
+      expect({ needToUpdate, errors }).toEqual({
+        needToUpdate: false,
+        errors:       ['kubernetes.certificateAuthority.certificate and kubernetes.certificateAuthority.key must be set together'],
+      });
+    });
+
+    it('rejects relative paths', () => {
+      const [needToUpdate, errors] = subject.validateSettings(cfg, { kubernetes: { certificateAuthority: { certificate: 'ca.crt', key: '/certs/ca.key' } } });
+
+      expect({ needToUpdate, errors }).toEqual({
+        needToUpdate: false,
+        errors:       ['Invalid value for "kubernetes.certificateAuthority.certificate": <"ca.crt">; must be an absolute path'],
+      });
+    });
+  });
+
+  describe('kubernetes.kubeconfig.certificates', () => {
+    it.each(Object.values(settings.KubeconfigCertificates))('accepts %j', (certificates) => {
+      const [needToUpdate, errors] = subject.validateSettings(cfg, { kubernetes: { kubeconfig: { certificates } } });
+
+      expect({ needToUpdate, errors }).toEqual({
+        needToUpdate: certificates !== cfg.kubernetes.kubeconfig.certificates,
+        errors:       [],
+      });
+    });
+
+    it('rejects other values', () => {
+      const [needToUpdate, errors] = subject.validateSettings(cfg, { kubernetes: { kubeconfig: { certificates: 'inline' as any } } });
+
+      expect({ needToUpdate, errors }).toEqual({
+        needToUpdate: false,
+        errors:       [expect.stringContaining('Invalid value for "kubernetes.kubeconfig.certificates": <"inline">')],
+      });
+    });
+  });
+
+  describe('kubernetes.oidc', () => {
+    const oidc = {
+      issuerURL:     'https://sso.example.com/realms/dev',
+      clientID:      'kubernetes',
+      usernameClaim: 'email',
+      groupsClaim:   'groups',
+      extraScopes:   ['email', 'groups'],
+    };
+
+    it('accepts a provider', () => {
+      const [needToUpdate, errors] = subject.validateSettings(cfg, { kubernetes: { oidc } });
+
+      expect({ needToUpdate, errors }).toEqual({ needToUpdate: true, errors: [] });
+    });
+
+    it('requires a client ID', () => {
+      const [needToUpdate, errors] = subject.validateSettings(cfg, { kubernetes: { oidc: { issuerURL: oidc.issuerURL } } });
+
+      expect({ needToUpdate, errors }).toEqual({
+        needToUpdate: false,
+        errors:       ['kubernetes.oidc.clientID is required when kubernetes.oidc.issuerURL is set'],
+      });
+    });
+
+    it.each(['http://sso.example.com', 'sso.example.com', 'https://sso.example.com/$(reboot)'])('rejects issuer %j', (issuerURL) => {
+      const [, errors] = subject.validateSettings(cfg, { kubernetes: { oidc: { ...oidc, issuerURL } } });
+
+      expect(errors).toEqual([`field "kubernetes.oidc.issuerURL" has an invalid value "${ issuerURL }"; it must be an https URL`]);
+    });
+
+    it('rejects scopes with whitespace', () => {
+      const [, errors] = subject.validateSettings(cfg, { kubernetes: { oidc: { ...oidc, extraScopes: ['offline access'] } } });
+
+      expect(errors).toEqual(['field "kubernetes.oidc.extraScopes" has invalid entries: "offline access"; scopes must not contain whitespace or quotes']);
+    });
+  });
+
   describe('locked fields', () => {
     describe('containerEngine.allowedImages', () => {
       const allowedImageListConfig: settings.Settings = _.merge({}, cfg, {
@@ -692,8 +1163,10 @@ describe(SettingsValidator, () => {
       ['should accept names with a bare component', { image: 'tag' }, []],
       ['should accept names with a domain', { 'registry.test/name': 'tag' }, []],
       ['should accept names with multiple components', { 'registry.test/dir/name': 'tag' }, []],
+      ['should accept digests', { image: `sha256:${ '0123456789abcdef'.repeat(4) }` }, []],
       ['should reject invalid tags', { image: 'hello world' }, ['application.extensions.installed: "image" has invalid tag "hello world"']],
       ['should reject overly-long tags', { image: longString }, [`application.extensions.installed: "image" has invalid tag "${ longString }"`]],
+      ['should reject invalid digests', { image: 'sha256:xyz' }, ['application.extensions.installed: "image" has invalid tag "sha256:xyz"']],
     ])('%s', (...[, input, expectedErrors]) => {
       const [, errors] = subject.validateSettings(cfg, { application: { extensions: { installed: input } } });
 
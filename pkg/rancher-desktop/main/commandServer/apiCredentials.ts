@@ -0,0 +1,92 @@
+import { runCredHelper } from '@pkg/main/credentialServer/credentialUtils';
+import Logging from '@pkg/utils/logging';
+
+const console = Logging.server;
+
+/**
+ * The server URLs under which the API credentials, and those of the viewer
+ * role, are kept in the keychain.  rdctl looks them up by these names; see
+ * `keychainPassword` in its config package.
+ */
+export const API_CREDENTIALS_SERVER_URL = 'rancher-desktop-api';
+export const API_VIEWER_CREDENTIALS_SERVER_URL = 'rancher-desktop-api-viewer';
+
+const apiCredentialsHosts = [API_CREDENTIALS_SERVER_URL, API_VIEWER_CREDENTIALS_SERVER_URL];
+
+/**
+ * Whether the server URL refers to the API credentials in the keychain.  The
+ * credential helpers parse the URLs they are given, so that, for example,
+ * `https://Rancher-Desktop-API:443/` may find the same entry; the URL is
+ * compared by host name, the way they would see it.
+ */
+export function isAPICredentialsServerURL(serverURL: string): boolean {
+  const trimmed = serverURL.trim().toLowerCase();
+
+  if (apiCredentialsHosts.some(host => trimmed.includes(host))) {
+    return true;
+  }
+  try {
+    const url = new URL(/^[a-z][a-z0-9+.-]*:\/\//.test(trimmed) ? trimmed : `https://${ trimmed }`);
+
+    return apiCredentialsHosts.includes(url.hostname.replace(/\.+$/, ''));
+  } catch {
+    return false;
+  }
+}
+
+/**
+ * The credential helpers that store secrets in the keychain of the OS; these
+ * ship with the application.
+ */
+const keychainHelpers: Partial<Record<NodeJS.Platform, string>> = {
+  darwin: 'osxkeychain',
+  linux:  'secretservice',
+  win32:  'wincred',
+};
+
+/** How long to wait for the credential helper, e.g. for an unlocked keyring. */
+const STORE_TIMEOUT = 5_000;
+
+/**
+ * Store the API credentials in the keychain of the OS, so that they don't have
+ * to be written to rd-engine.json in plain text.
+ * @param serverURL The key under which to store them; the viewer credentials
+ * are stored under API_VIEWER_CREDENTIALS_SERVER_URL.
+ * @returns The name of the credential helper that stores them (a suffix of
+ * `docker-credential-`), or undefined if they could not be stored, in which
+ * case the caller should fall back to writing the password to the file.
+ */
+export async function storeAPICredentials(user: string, password: string, serverURL = API_CREDENTIALS_SERVER_URL): Promise<string | undefined> {
+  const helper = keychainHelpers[process.platform];
+
+  if (!helper) {
+    return undefined;
+  }
+  if (process.env.RD_TEST === 'e2e') {
+    // The end-to-end tests read the password from rd-engine.json.
+    console.debug('Not storing the API credentials in the keychain while running end-to-end tests.');
+
+    return undefined;
+  }
+  const payload = JSON.stringify({
+    ServerURL: serverURL, Username: user, Secret: password,
+  });
+  const timeoutError = Symbol('timeout');
+
+  try {
+    const timeoutPromise = new Promise(resolve => setTimeout(() => resolve(timeoutError), STORE_TIMEOUT));
+    const result = await Promise.race([runCredHelper(helper, 'store', payload), timeoutPromise]);
+
+    if (Object.is(result, timeoutError)) {
+      console.log(`Timed out storing the ${ serverURL } credentials with docker-credential-${ helper }; writing them to rd-engine.json.`);
+
+      return undefined;
+    }
+
+    return helper;
+  } catch (ex) {
+    console.log(`Failed to store the ${ serverURL } credentials with docker-credential-${ helper }; writing them to rd-engine.json:`, ex);
+
+    return undefined;
+  }
+}
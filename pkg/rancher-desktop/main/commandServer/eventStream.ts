@@ -0,0 +1,58 @@
+/**
+ * EventStream relays the events of the main process that automation may want
+ * to react to, such as changes of the backend state, as server-sent events;
+ * they are served by `GET /v1/events` and followed by `rdctl events`.
+ */
+
+import type { VMBackend } from '@pkg/backend/backend';
+import mainEvents from '@pkg/main/mainEvents';
+
+export type StreamEventType =
+  'settings-changed' | 'backend-state-changed' | 'backend-lock-changed' | 'image-operation' | 'diagnostics-updated' |
+  'oom-killed' | 'resource-pressure';
+
+export type StreamEvent = {
+  type: StreamEventType;
+  time: string;
+  data: Record<string, unknown>;
+};
+
+/**
+ * Returns the event in the text/event-stream format; the data is a single
+ * line of JSON.
+ */
+export function formatStreamEvent(event: StreamEvent): string {
+  return `event: ${ event.type }\ndata: ${ JSON.stringify(event) }\n\n`;
+}
+
+export class EventStream {
+  protected readonly listeners: [string, (...args: any[]) => void][];
+
+  constructor(protected readonly send: (event: StreamEvent) => void) {
+    this.listeners = [
+      ['settings-update', () => this.relay('settings-changed', {})],
+      ['k8s-check-state', (mgr: VMBackend) => this.relay('backend-state-changed', { state: mgr.state })],
+      ['backend-locked-update', (explanation: string, action?: string) => {
+        this.relay('backend-lock-changed', { locked: !!explanation, ...(action ? { action } : {}) });
+      }],
+      ['image-operation', (operation: string, exitCode: number) => this.relay('image-operation', { operation, exitCode })],
+      ['diagnostics-update', (id: string, passed: boolean) => this.relay('diagnostics-updated', { id, passed })],
+      ['resource-oom-kill', (kill: Record<string, unknown>) => this.relay('oom-killed', kill)],
+      ['resource-pressure', (change: Record<string, unknown>) => this.relay('resource-pressure', change)],
+    ];
+    for (const [event, listener] of this.listeners) {
+      mainEvents.on(event as any, listener);
+    }
+  }
+
+  protected relay(type: StreamEventType, data: Record<string, unknown>) {
+    this.send({ type, time: new Date().toISOString(), data });
+  }
+
+  /** Stop relaying events. */
+  close() {
+    for (const [event, listener] of this.listeners) {
+      mainEvents.off(event as any, listener);
+    }
+  }
+}
@@ -1,22 +1,29 @@
 import fs from 'fs';
 import http from 'http';
+import os from 'os';
 import path from 'path';
 import { URL } from 'url';
 
 import express from 'express';
 import _ from 'lodash';
 
-import { State } from '@pkg/backend/backend';
+import API_SPEC from '@pkg/assets/specs/command-api.yaml';
+import { RestartReasons, State, SubState } from '@pkg/backend/backend';
 import type { Settings } from '@pkg/config/settings';
 import type { TransientSettings } from '@pkg/config/transientSettings';
+import { API_VIEWER_CREDENTIALS_SERVER_URL, storeAPICredentials } from '@pkg/main/commandServer/apiCredentials';
+import { EventStream, formatStreamEvent } from '@pkg/main/commandServer/eventStream';
 import type { DiagnosticsResultCollection } from '@pkg/main/diagnostics/diagnostics';
 import { ExtensionMetadata } from '@pkg/main/extensions/types';
 import mainEvents from '@pkg/main/mainEvents';
 import { getVtunnelInstance } from '@pkg/main/networking/vtunnel';
 import * as serverHelper from '@pkg/main/serverHelper';
 import { Snapshot } from '@pkg/main/snapshots/types';
+import type { UpdateStatus } from '@pkg/main/update';
+import type { HostResources } from '@pkg/utils/hostResources';
 import Logging from '@pkg/utils/logging';
 import paths from '@pkg/utils/paths';
+import { dropRedacted, redactSecrets } from '@pkg/utils/redact';
 import { jsonStringifyWithWhiteSpace } from '@pkg/utils/stringify';
 import { RecursivePartial } from '@pkg/utils/typeUtils';
 
@@ -26,6 +33,8 @@ import { RecursivePartial } from '@pkg/utils/typeUtils';
 export type BackendState = {
   // The state of the VM/backend.
   vmState: State,
+  // The step the backend is at while the state is STARTING.
+  subState?: SubState,
   // Whether the backend is locked. If true, changes cannot
   // be made by the user until it is unlocked.
   locked: boolean,
@@ -38,17 +47,33 @@ export type ServerState = {
   pid: number;
 };
 
+/**
+ * Roles of API users: admins can use all endpoints, while viewers can only
+ * use the GET endpoints, which don't change anything. This lets dashboards and
+ * prompts use credentials that can't modify the application.
+ */
+type Role = 'admin' | 'viewer';
+
 type DispatchFunctionType = (request: express.Request, response: express.Response, context: commandContext) => Promise<void>;
 type HttpMethod = 'get' | 'put' | 'post';
 
 const console = Logging.server;
 const SERVER_PORT = 6107;
 const SERVER_FILE_BASENAME = 'rd-engine.json';
+const SERVER_SOCKET_BASENAME = 'rd-engine.sock';
 const MAX_REQUEST_BODY_LENGTH = 4194304; // 4MiB
+/** How often a comment is sent on event streams, so that idle connections aren't dropped. */
+const EVENT_STREAM_KEEPALIVE_INTERVAL = 30_000;
 
 export class HttpCommandServer {
   protected vtun = getVtunnelInstance();
   protected server = http.createServer();
+  /**
+   * The server on a Unix socket (or a named pipe on Windows), which rdctl
+   * prefers over TCP: it can't clash with other local services, and only the
+   * user can connect to it.
+   */
+  protected socketServer: http.Server | undefined;
   protected app = express();
   protected readonly externalState: ServerState = {
     user:     'user',
@@ -64,6 +89,15 @@ export class HttpCommandServer {
     pid:      process.pid,
   };
 
+  /**
+   * Credentials for the viewer role, written to rd-engine.json as `viewer`;
+   * the password is kept in the keychain if possible, like the main one.
+   */
+  protected readonly viewerCredentials = {
+    user:     'viewer',
+    password: serverHelper.randomStr(),
+  };
+
   protected commandWorker: CommandWorkerInterface;
 
   protected dispatchTable: Record<HttpMethod, Record<string, readonly [number, DispatchFunctionType]>> = _.merge(
@@ -73,12 +107,24 @@ export class HttpCommandServer {
         '/v1/diagnostic_categories': [0, this.diagnosticCategories],
         '/v1/diagnostic_ids':        [0, this.diagnosticIDsForCategory],
         '/v1/diagnostic_checks':     [0, this.diagnosticChecks],
+        '/v1/host_resources':        [1, this.getHostResources],
+        '/v1/kubernetes_versions':   [1, this.listKubernetesVersions],
         '/v1/settings':              [0, this.listSettings],
         '/v1/settings/locked':       [0, this.listLockedSettings],
+        '/v1/settings/pending':      [1, this.listPendingSettings],
+        '/v1/settings/schema':       [1, this.getSettingsSchema],
         '/v1/transient_settings':    [0, this.listTransientSettings],
         '/v1/backend_state':         [1, this.getBackendState],
+        '/v1/events':                [1, this.streamEvents],
+        '/v1/version':               [1, this.getVersion],
+        '/v1/update':                [1, this.getUpdateStatus],
+      },
+      post: {
+        '/v1/diagnostic_checks': [0, this.diagnosticRunChecks],
+        '/v1/update/check':      [1, this.checkForUpdates],
+        '/v1/update/download':   [1, this.downloadUpdate],
+        '/v1/update/apply':      [1, this.applyUpdate],
       },
-      post: { '/v1/diagnostic_checks': [0, this.diagnosticRunChecks] },
       put:  {
         '/v1/factory_reset':      [0, this.factoryReset],
         '/v1/propose_settings':   [0, this.proposeSettings],
@@ -128,25 +174,84 @@ export class HttpCommandServer {
     }
     const statePath = path.join(paths.appHome, SERVER_FILE_BASENAME);
 
+    this.app
+      .set('etag', 'strong')
+      .disable('x-powered-by')
+      .use(this.handleCORS)
+      .use(this.checkAuth);
+    this.setupRoutes();
+
     await fs.promises.mkdir(paths.appHome, { recursive: true });
+    const socket = await this.listenOnSocket();
+    const credentialStore = await storeAPICredentials(this.externalState.user, this.externalState.password);
+    const viewerStore = credentialStore &&
+      await storeAPICredentials(this.viewerCredentials.user, this.viewerCredentials.password, API_VIEWER_CREDENTIALS_SERVER_URL);
+    // Only write the passwords that couldn't be kept in the keychain; rdctl
+    // gets the others from the credential helper named in `credentialStore`.
+    const state = credentialStore ? { ..._.omit(this.externalState, 'password'), credentialStore } : this.externalState;
+    const viewer = viewerStore ? _.omit(this.viewerCredentials, 'password') : this.viewerCredentials;
+
     await fs.promises.writeFile(statePath,
-      jsonStringifyWithWhiteSpace(this.externalState),
+      jsonStringifyWithWhiteSpace({ ...state, ...(socket ? { socket } : {}), viewer }),
       { mode: 0o600 });
 
     this.server = this.app
-      .disable('etag')
-      .disable('x-powered-by')
-      .use(this.handleCORS)
-      .use(this.checkAuth)
       .listen(SERVER_PORT, localHost)
       .on('error', (err) => {
         console.log(`Error: ${ err }`);
       });
 
-    this.setupRoutes();
     console.log('CLI server is now ready.');
   }
 
+  /**
+   * The path of the Unix socket, or the name of the pipe on Windows, that the
+   * server also listens on.
+   */
+  protected get socketPath(): string {
+    if (process.platform === 'win32') {
+      // Pipe names are global, so they must differ between users.
+      return `\\\\.\\pipe\\rancher-desktop-api-${ os.userInfo().username }`;
+    }
+
+    return path.join(paths.appHome, SERVER_SOCKET_BASENAME);
+  }
+
+  /**
+   * Listen on the Unix socket or named pipe as well as on TCP.
+   * @returns The path to write to rd-engine.json, or undefined if the server
+   * couldn't listen on it; rdctl then keeps using TCP.
+   */
+  protected async listenOnSocket(): Promise<string | undefined> {
+    const socketPath = this.socketPath;
+
+    try {
+      if (process.platform !== 'win32') {
+        // Remove the socket left behind if the application crashed.
+        await fs.promises.rm(socketPath, { force: true });
+      }
+      await new Promise<void>((resolve, reject) => {
+        this.socketServer = http.createServer(this.app)
+          .once('error', reject)
+          .listen(socketPath, () => resolve());
+      });
+      if (process.platform !== 'win32') {
+        await fs.promises.chmod(socketPath, 0o600);
+      }
+      this.socketServer?.on('error', (err) => {
+        console.log(`Error on ${ socketPath }: ${ err }`);
+      });
+
+      return socketPath;
+    } catch (ex) {
+      console.log(`Failed to listen on ${ socketPath }, only using TCP:`, ex);
+      this.socketServer?.close();
+      this.socketServer = undefined;
+
+      return undefined;
+    }
+  }
+
   /**
    * Set up HTTP routes for express.
    * This takes the information from the route decorators and applies it to the
@@ -169,6 +274,12 @@ export class HttpCommandServer {
         maxVersion = Math.max(version, maxVersion);
 
         this.app[method](`/v${ version }/${ path }`, (req, resp, next) => {
+          if (method !== 'get' && resp.locals.role !== 'admin') {
+            console.log(`403: ${ req.method } ${ req.path } requires the admin role.`);
+            resp.status(403).type('txt').send(`Forbidden: ${ req.method } ${ req.path } requires admin credentials`);
+
+            return;
+          }
           const context: commandContext = { interactive: resp.locals.interactive };
 
           handler.call(this, req, resp, context).catch(next);
@@ -207,10 +318,13 @@ export class HttpCommandServer {
   protected checkAuth = (request: express.Request, response: express.Response, next: express.NextFunction) => {
     const authHeader = request.headers.authorization ?? '';
     const userDB = {
-      [this.externalState.user]:    this.externalState.password,
-      [this.interactiveState.user]: this.interactiveState.password,
+      [this.externalState.user]:     this.externalState.password,
+      [this.interactiveState.user]:  this.interactiveState.password,
+      [this.viewerCredentials.user]: this.viewerCredentials.password,
     };
 
+    let role: Role = 'admin';
+
     switch (serverHelper.basicAuth(userDB, authHeader)) {
     case this.externalState.user:
       response.locals.interactive = false;
@@ -218,11 +332,16 @@ export class HttpCommandServer {
     case this.interactiveState.user:
       response.locals.interactive = true;
       break;
+    case this.viewerCredentials.user:
+      response.locals.interactive = false;
+      role = 'viewer';
+      break;
     default:
       response.type('txt').sendStatus(401);
 
       return;
     }
+    response.locals.role = role;
     next();
   };
 
@@ -311,8 +430,24 @@ export class HttpCommandServer {
       .send(jsonStringifyWithWhiteSpace(checks));
   }
 
+  /**
+   * Handle `POST /v1/diagnostic_checks` requests, running the checks of the
+   * categories given with the `category` query parameters, or all checks.
+   */
   protected async diagnosticRunChecks(request: express.Request, response: express.Response, context: commandContext): Promise<void> {
-    const results = await this.commandWorker.runDiagnosticChecks(context);
+    const url = new URL(`http://localhost/${ request.url }`);
+    const categories = url.searchParams.getAll('category');
+    const knownCategories = this.commandWorker.getDiagnosticCategories(context) ?? [];
+    const unknownCategories = categories.filter(category => !knownCategories.includes(category));
+
+    if (unknownCategories.length > 0) {
+      console.debug('diagnostic_run: failed 400');
+      response.status(400).type('txt')
+        .send(`Unknown diagnostic categories: ${ unknownCategories.join(', ') }; the categories are ${ knownCategories.join(', ') }`);
+
+      return;
+    }
+    const results = await this.commandWorker.runDiagnosticChecks(context, categories);
 
     console.debug('diagnostic_run: succeeded 200');
     response.status(200).type('json')
@@ -341,12 +476,71 @@ export class HttpCommandServer {
     return Promise.resolve();
   }
 
+  /**
+   * Handle `GET /v1/version` requests, so that clients such as rdctl can tell
+   * whether they come from the same release as the application.
+   */
+  protected getVersion(request: express.Request, response: express.Response, context: commandContext): Promise<void> {
+    console.debug('getVersion: succeeded 200');
+    response.status(200).json({ version: this.commandWorker.getVersion() });
+
+    return Promise.resolve();
+  }
+
+  /** Handle `GET /v1/update` requests, returning the state of the updater. */
+  protected getUpdateStatus(request: express.Request, response: express.Response, context: commandContext): Promise<void> {
+    console.debug('getUpdateStatus: succeeded 200');
+    response.status(200).json(this.commandWorker.getUpdateStatus());
+
+    return Promise.resolve();
+  }
+
+  /**
+   * Handle `POST /v1/update/check` requests, checking for updates in the
+   * release channel given by the `channel` query parameter, if any.
+   */
+  protected async checkForUpdates(request: express.Request, response: express.Response, context: commandContext): Promise<void> {
+    const channel = request.query.channel ?? '';
+
+    if (typeof channel !== 'string') {
+      response.status(400).type('txt').send(`Invalid channel ${ JSON.stringify(channel) }: not a string.`);
+
+      return;
+    }
+    this.sendUpdateResult('update/check', response, await this.commandWorker.checkForUpdates(channel));
+  }
+
+  /** Handle `POST /v1/update/download` requests. */
+  protected async downloadUpdate(request: express.Request, response: express.Response, context: commandContext): Promise<void> {
+    this.sendUpdateResult('update/download', response, await this.commandWorker.downloadUpdate());
+  }
+
+  /** Handle `POST /v1/update/apply` requests; the application then restarts. */
+  protected async applyUpdate(request: express.Request, response: express.Response, context: commandContext): Promise<void> {
+    this.sendUpdateResult('update/apply', response, await this.commandWorker.applyUpdate());
+  }
+
+  protected sendUpdateResult(endpoint: string, response: express.Response, { status, data }: { status: number, data?: any }) {
+    console.debug(`${ endpoint }: ${ status < 300 ? 'succeeded' : 'failed' } ${ status }`);
+    if (typeof data === 'string') {
+      response.status(status).type('txt').send(data);
+    } else {
+      response.status(status).json(data);
+    }
+  }
+
+  /**
+   * Handle `GET /v?/settings` requests. Secrets are redacted unless the
+   * `showSecrets` query parameter is set by an admin.
+   */
   protected listSettings(request: express.Request, response: express.Response, context: commandContext): Promise<void> {
     const settings = this.commandWorker.getSettings(context);
 
     if (settings) {
+      const showSecrets = request.query.showSecrets === 'true' && response.locals.role === 'admin';
+
       console.debug('listSettings: succeeded 200');
-      response.status(200).type('txt').send(settings);
+      response.status(200).type('txt').send(showSecrets ? settings : jsonStringifyWithWhiteSpace(redactSecrets(JSON.parse(settings))));
     } else {
       console.debug('listSettings: failed 200');
       response.status(404).type('txt').send('No settings found');
@@ -369,6 +563,43 @@ export class HttpCommandServer {
     return Promise.resolve();
   }
 
+  protected async listPendingSettings(request: express.Request, response: express.Response, context: commandContext): Promise<void> {
+    const reasons = await this.commandWorker.getPendingSettings(context);
+
+    console.debug('listPendingSettings: succeeded 200');
+    response.status(200).type('json').send(jsonStringifyWithWhiteSpace(reasons));
+  }
+
+  /**
+   * Handle `GET /v?/host_resources` requests, returning the capacity of the
+   * host and the limits of the resources of the VM, so that clients can show
+   * them.
+   */
+  protected getHostResources(request: express.Request, response: express.Response, context: commandContext): Promise<void> {
+    console.debug('getHostResources: succeeded 200');
+    response.status(200).type('json').send(jsonStringifyWithWhiteSpace(this.commandWorker.getHostResources()));
+
+    return Promise.resolve();
+  }
+
+  /**
+   * Handle `GET /v?/settings/schema` requests, returning the JSON schema of
+   * the settings from the API spec.
+   */
+  protected getSettingsSchema(request: express.Request, response: express.Response, context: commandContext): Promise<void> {
+    console.debug('getSettingsSchema: succeeded 200');
+    response.status(200).type('json').send(jsonStringifyWithWhiteSpace(API_SPEC.components.schemas.preferences));
+
+    return Promise.resolve();
+  }
+
+  protected async listKubernetesVersions(request: express.Request, response: express.Response, context: commandContext): Promise<void> {
+    const versions = await this.commandWorker.getKubernetesVersions();
+
+    console.debug('listKubernetesVersions: succeeded 200');
+    response.status(200).type('json').send(jsonStringifyWithWhiteSpace(versions));
+  }
+
   protected listEndpoints(version: string, request: express.Request, response: express.Response): Promise<void> {
     // Determine all API paths, possibly filtered by the requested version.
     const apiPaths: [Uppercase<HttpMethod>, string][] = [];
@@ -470,7 +701,13 @@ export class HttpCommandServer {
       [errorCode, error] = body;
     } else {
       try {
-        [result, error] = await this.commandWorker.updateSettings(context, body);
+        // With `restart=false`, changes needing a restart are applied on the next one.
+        const restart = request.query.restart !== 'false';
+        // With `force=true`, resources of the VM over the limits of the host are accepted.
+        const force = request.query.force === 'true';
+
+        // Secrets read back from `GET /settings` are redacted; keep the current values.
+        [result, error] = await this.commandWorker.updateSettings(context, dropRedacted(body), restart, force);
       } catch (ex) {
         console.error(`updateSettings: exception when updating:`, ex);
         errorCode = 500;
@@ -497,7 +734,7 @@ export class HttpCommandServer {
       if (Array.isArray(body)) {
         [errorCode, error] = body;
       } else {
-        [result, error] = await this.commandWorker.proposeSettings(context, body);
+        [result, error] = await this.commandWorker.proposeSettings(context, dropRedacted(body), request.query.force === 'true');
         console.error(`propose: ${ JSON.stringify(body) } -> ${ result }`);
       }
     } catch (ex) {
@@ -561,6 +798,8 @@ export class HttpCommandServer {
 
   closeServer() {
     this.server.close();
+    // Closing the server also removes the Unix socket.
+    this.socketServer?.close();
   }
 
   protected listTransientSettings(request: express.Request, response: express.Response, context: commandContext): Promise<void> {
@@ -664,6 +903,28 @@ export class HttpCommandServer {
     return Promise.resolve();
   }
 
+  /**
+   * Stream the events of the main process as server-sent events, until the
+   * client disconnects.
+   */
+  protected async streamEvents(request: express.Request, response: express.Response, context: commandContext): Promise<void> {
+    response.writeHead(200, {
+      'Content-Type':  'text/event-stream',
+      'Cache-Control': 'no-cache',
+      Connection:      'keep-alive',
+    });
+    // Send the headers right away, so the client knows it is subscribed.
+    response.write(': subscribed\n\n');
+    const stream = new EventStream(event => response.write(formatStreamEvent(event)));
+    const keepalive = setInterval(() => response.write(': keepalive\n\n'), EVENT_STREAM_KEEPALIVE_INTERVAL);
+
+    console.debug('GET events: streaming');
+    await new Promise<void>(resolve => request.once('close', resolve));
+    clearInterval(keepalive);
+    stream.close();
+    response.end();
+  }
+
   protected async setBackendState(request: express.Request, response: express.Response, context: commandContext): Promise<void> {
     let result = 'received backend state';
     let statusCode = 202;
@@ -790,13 +1051,33 @@ export interface CommandWorkerInterface {
   factoryReset: (keepSystemImages: boolean) => void;
   getSettings: (context: commandContext) => string;
   getLockedSettings: (context: commandContext) => string;
-  updateSettings: (context: commandContext, newSettings: RecursivePartial<Settings>) => Promise<[string, string]>;
-  proposeSettings: (context: commandContext, newSettings: RecursivePartial<Settings>) => Promise<[string, string]>;
+  updateSettings: (context: commandContext, newSettings: RecursivePartial<Settings>, restart?: boolean, force?: boolean) => Promise<[string, string]>;
+  /** Get the saved settings that the backend applies on its next restart */
+  getPendingSettings: (context: commandContext) => Promise<RestartReasons>;
+  /** List the Kubernetes versions that can be selected */
+  getKubernetesVersions: () => Promise<{ version: string, channels: string[] }[]>;
+  proposeSettings: (context: commandContext, newSettings: RecursivePartial<Settings>, force?: boolean) => Promise<[string, string]>;
+  /** Get the capacity of the host, and the limits of the resources of the VM */
+  getHostResources: () => HostResources;
+  /** Get the version of the application */
+  getVersion: () => string;
+  /** Get the state of the updater */
+  getUpdateStatus: () => UpdateStatus;
+  /**
+   * Check for updates in the given release channel, empty for any release;
+   * returns the HTTP status code, with the state of the updater or an error.
+   */
+  checkForUpdates: (channel: string) => Promise<{ status: number, data?: any }>;
+  /** Start downloading the available update */
+  downloadUpdate: () => Promise<{ status: number, data?: any }>;
+  /** Install the downloaded update, restarting the application */
+  applyUpdate: () => Promise<{ status: number, data?: any }>;
   requestShutdown: (context: commandContext) => void;
   getDiagnosticCategories: (context: commandContext) => string[]|undefined;
   getDiagnosticIdsByCategory: (category: string, context: commandContext) => string[]|undefined;
   getDiagnosticChecks: (category: string|null, checkID: string|null, context: commandContext) => Promise<DiagnosticsResultCollection>;
-  runDiagnosticChecks: (context: commandContext) => Promise<DiagnosticsResultCollection>;
+  /** Run the checks of the given categories, or all checks if none is given */
+  runDiagnosticChecks: (context: commandContext, categories?: string[]) => Promise<DiagnosticsResultCollection>;
   getTransientSettings: (context: commandContext) => string;
   updateTransientSettings: (context: commandContext, newTransientSettings: RecursivePartial<TransientSettings>) => Promise<[string, string]>;
   /** Get the state of the backend */
@@ -1,4 +1,5 @@
 import os from 'os';
+import path from 'path';
 
 import Electron from 'electron';
 import _ from 'lodash';
@@ -6,23 +7,59 @@ import semver from 'semver';
 
 import {
   CacheMode,
+  CPUAffinityMode,
   defaultSettings,
+  KubeconfigCertificates,
   LockedSettingsType,
+  MemoryReclaimMode,
   MountType,
   ProtocolVersion,
   SecurityModel,
   Settings,
+  SwapMode,
+  TrimInterval,
   VMType,
 } from '@pkg/config/settings';
 import { NavItemName, navItemNames, TransientSettings } from '@pkg/config/transientSettings';
 import { PathManagementStrategy } from '@pkg/integrations/pathManager';
-import { parseImageReference, validateImageName, validateImageTag } from '@pkg/utils/dockerUtils';
+import {
+  parseImageReference,
+  validateImageDigest,
+  validateImageName,
+  validateImageTag,
+} from '@pkg/utils/dockerUtils';
+import { getHostResources, HostResources } from '@pkg/utils/hostResources';
 import { getMacOsVersion } from '@pkg/utils/osVersion';
 import { RecursivePartial } from '@pkg/utils/typeUtils';
 import { preferencesNavItems } from '@pkg/window/preferenceConstants';
 
 type settingsLike = Record<string, any>;
 
+// Kubernetes label keys are an optional DNS subdomain prefix and a name;
+// values are like names, but may be empty.
+const labelKeyPattern = '(?:[a-z0-9](?:[-a-z0-9]*[a-z0-9])?(?:\\.[a-z0-9](?:[-a-z0-9]*[a-z0-9])?)*/)?[A-Za-z0-9](?:[-A-Za-z0-9_.]*[A-Za-z0-9])?';
+const labelValuePattern = '(?:[A-Za-z0-9](?:[-A-Za-z0-9_.]*[A-Za-z0-9])?)?';
+const nodeLabelRE = new RegExp(`^${ labelKeyPattern }=${ labelValuePattern }$`);
+const nodeTaintRE = new RegExp(`^${ labelKeyPattern }(?:=${ labelValuePattern })?:(?:NoSchedule|PreferNoSchedule|NoExecute)$`);
+const environmentVariableRE = /^[A-Za-z_][A-Za-z0-9_]*=[^\n]*$/;
+const portPattern = '(?:[1-9][0-9]{0,3}|[1-5][0-9]{4}|6[0-4][0-9]{3}|65[0-4][0-9]{2}|655[0-2][0-9]|6553[0-5])';
+const reversePortForwardRE = new RegExp(`^(?:${ portPattern }:)?${ portPattern }$`);
+const sysctlRE = /^[A-Za-z0-9_]+(?:[./][-A-Za-z0-9_]+)*=[^\n]*$/;
+// OIDC settings are passed to K3s unquoted, so they are restricted to
+// characters that need no quoting.
+const oidcIssuerURLRE = /^(?:https:\/\/[-A-Za-z0-9._~:/%@+]+)?$/;
+const oidcTokenRE = /^[-A-Za-z0-9._~:/%@+]*$/;
+// Lists of CPUs or NUMA nodes, like "0-3,8"; empty means none.
+const cpuListRE = /^(?:\d+(?:-\d+)?(?:,\d+(?:-\d+)?)*)?$/;
+
+/**
+ * Ports in the VM that Rancher Desktop itself uses, and which therefore can't
+ * be forwarded to the host: SSH, DNS, the vtunnel peers of the credential
+ * server, the privileged service, and the API, the Kubernetes API server
+ * (the kubernetes.port setting is checked as well), and the K3s components.
+ */
+const reservedGuestPorts = [22, 53, 3030, 3040, 6107, 6443, 6444, ..._.range(10248, 10260)];
+
 /**
  * ValidatorFunc describes a validation function; it is used to check if a
  * given proposed setting is compatible.
@@ -66,14 +103,25 @@ export default class SettingsValidator {
   synonymsTable: settingsLike|null = null;
   lockedSettings: LockedSettingsType = { };
   protected isFatal = false;
+  protected force = false;
+  protected warnings: string[] = [];
 
+  /**
+   * @param force Accept resources of the VM over the limits of the host,
+   *        reporting them as warnings instead of errors.
+   * @returns Whether there are changes to apply, the errors, whether they are
+   *          fatal, and the warnings.
+   */
   validateSettings(
     currentSettings: Settings,
     newSettings: RecursivePartial<Settings>,
     lockedSettings: LockedSettingsType = {},
-  ): [boolean, string[], boolean] {
+    force = false,
+  ): [boolean, string[], boolean, string[]] {
     this.lockedSettings = lockedSettings;
     this.isFatal = false;
+    this.force = force;
+    this.warnings = [];
     this.allowedSettings ||= {
       version:     this.checkUnchanged,
       application: {
@@ -94,6 +142,7 @@ export default class SettingsValidator {
         startInBackground:      this.checkBoolean,
         hideNotificationIcon:   this.checkBoolean,
         window:                 { quitOnClose: this.checkBoolean },
+        systemLog:              this.checkPlatform('win32', this.checkBoolean),
       },
       containerEngine: {
         allowedImages: {
@@ -101,11 +150,14 @@ export default class SettingsValidator {
           patterns: this.checkUniqueStringArray,
         },
         // 'docker' has been canonicalized to 'moby' already, but we want to include it as a valid value in the error message
-        name: this.checkEnum('containerd', 'moby', 'docker'),
+        name:        this.checkEnum('containerd', 'moby', 'docker'),
+        environment: this.checkMulti(
+          this.checkUniqueStringArray,
+          this.checkStringArrayFormat(environmentVariableRE, 'environment variables must have the form KEY=VALUE')),
       },
       virtualMachine: {
-        memoryInGB:   this.checkLima(this.checkNumber(1, Number.POSITIVE_INFINITY)),
-        numberCPUs:   this.checkLima(this.checkNumber(1, Number.POSITIVE_INFINITY)),
+        memoryInGB:   this.checkLima(this.checkMulti(this.checkNumber(1, Number.POSITIVE_INFINITY), this.checkHostResource('memoryInGB', 'GB'))),
+        numberCPUs:   this.checkLima(this.checkMulti(this.checkNumber(1, Number.POSITIVE_INFINITY), this.checkHostResource('numberCPUs', 'CPUs'))),
         hostResolver: this.checkPlatform('win32', this.checkBoolean),
       },
       experimental: {
@@ -122,10 +174,36 @@ export default class SettingsValidator {
               cacheMode:       this.checkLima(this.check9P(this.checkEnum(...Object.values(CacheMode)))),
             },
           },
-          socketVMNet:      this.checkPlatform('darwin', this.checkBoolean),
-          networkingTunnel: this.checkPlatform('win32', this.checkBoolean),
-          useRosetta:       this.checkPlatform('darwin', this.checkRosetta),
-          type:             this.checkPlatform('darwin', this.checkMulti(
+          socketVMNet:         this.checkPlatform('darwin', this.checkBoolean),
+          sshAgentForwarding:  this.checkLima(this.checkBoolean),
+          gitBridge:           this.checkBoolean,
+          hostLocale:          this.checkBoolean,
+          networkingTunnel:    this.checkPlatform('win32', this.checkBoolean),
+          firewallRules:       this.checkPlatform('win32', this.checkBoolean),
+          reversePortForwards: this.checkMulti(
+            this.checkUniqueStringArray,
+            this.checkStringArrayFormat(reversePortForwardRE, 'reverse port forwards must have the form GUEST_PORT:HOST_PORT or PORT'),
+            this.checkReversePortForwardGuestPorts),
+          sysctls:             this.checkMulti(
+            this.checkUniqueStringArray,
+            this.checkStringArrayFormat(sysctlRE, 'kernel parameters must have the form KEY=VALUE')),
+          trimInterval:        this.checkEnum(...Object.values(TrimInterval)),
+          memoryReclaim:       {
+            mode:        this.checkPlatform('win32', this.checkEnum(...Object.values(MemoryReclaimMode))),
+            minimumInGB: this.checkPlatform('win32', this.checkNumber(0, Number.POSITIVE_INFINITY)),
+          },
+          swap:                {
+            mode:     this.checkEnum(...Object.values(SwapMode)),
+            sizeInGB: this.checkNumber(0, Number.POSITIVE_INFINITY),
+          },
+          cpuAffinity:         {
+            mode:      this.checkPlatform('linux', this.checkEnum(...Object.values(CPUAffinityMode))),
+            cpus:      this.checkPlatform('linux', this.checkMulti(this.checkString, this.checkStringFormat(cpuListRE, 'it must be a list like 0-3,8'))),
+            numaNodes: this.checkPlatform('linux', this.checkMulti(this.checkString, this.checkStringFormat(cpuListRE, 'it must be a list like 0,1'))),
+          },
+          nestedVirtualization: this.checkPlatform('linux', this.checkBoolean),
+          useRosetta:           this.checkPlatform('darwin', this.checkRosetta),
+          type:                 this.checkPlatform('darwin', this.checkMulti(
             this.checkEnum(...Object.values(VMType)),
             this.checkVMType),
           ),
@@ -144,8 +222,39 @@ export default class SettingsValidator {
         version: this.checkKubernetesVersion,
         port:    this.checkNumber(1, 65535),
         enabled: this.checkBoolean,
-        options: { traefik: this.checkBoolean, flannel: this.checkBoolean },
+        options: {
+          traefik: this.checkBoolean, flannel: this.checkBoolean, networkPolicy: this.checkBoolean,
+        },
         ingress: { localhostOnly: this.checkPlatform('win32', this.checkBoolean) },
+        node:    {
+          labels: this.checkMulti(
+            this.checkUniqueStringArray,
+            this.checkStringArrayFormat(nodeLabelRE, 'labels must have the form KEY=VALUE')),
+          taints: this.checkMulti(
+            this.checkUniqueStringArray,
+            this.checkStringArrayFormat(nodeTaintRE, 'taints must have the form KEY[=VALUE]:EFFECT, where EFFECT is NoSchedule, PreferNoSchedule, or NoExecute')),
+        },
+        storage:              { path: this.checkStoragePath },
+        certificateAuthority: {
+          certificate: this.checkCertificateAuthority,
+          key:         this.checkCertificateAuthority,
+        },
+        kubeconfig: { certificates: this.checkEnum(...Object.values(KubeconfigCertificates)) },
+        oidc:       {
+          issuerURL: this.checkMulti(
+            this.checkString,
+            this.checkStringFormat(oidcIssuerURLRE, 'it must be an https URL'),
+            this.checkOIDCClientID),
+          clientID: this.checkMulti(
+            this.checkString,
+            this.checkStringFormat(oidcTokenRE, 'it must not contain whitespace or quotes'),
+            this.checkOIDCClientID),
+          usernameClaim: this.checkMulti(this.checkString, this.checkStringFormat(oidcTokenRE, 'it must not contain whitespace or quotes')),
+          groupsClaim:   this.checkMulti(this.checkString, this.checkStringFormat(oidcTokenRE, 'it must not contain whitespace or quotes')),
+          extraScopes:   this.checkMulti(
+            this.checkUniqueStringArray,
+            this.checkStringArrayFormat(oidcTokenRE, 'scopes must not contain whitespace or quotes')),
+        },
       },
       portForwarding: { includeKubernetesServices: this.checkBoolean },
       images:         {
@@ -172,7 +281,7 @@ export default class SettingsValidator {
       '',
     );
 
-    return [needToUpdate && errors.length === 0, errors, this.isFatal];
+    return [needToUpdate && errors.length === 0, errors, this.isFatal, this.warnings];
   }
 
   validateTransientSettings(
@@ -181,6 +290,8 @@ export default class SettingsValidator {
   ): [boolean, string[]] {
     this.allowedTransientSettings ||= {
       noModalDialogs: this.checkBoolean,
+      // Safe mode can only be chosen when the application starts.
+      safeMode:       this.checkUnchanged,
       preferences:    {
         navItem: {
           current:     this.checkPreferencesNavItemCurrent,
@@ -447,6 +558,39 @@ export default class SettingsValidator {
     };
   }
 
+  /**
+   * checkHostResource refuses more of a resource of the VM than the host has,
+   * and more than the limit of the host unless forced; forced values over the
+   * limit are reported as warnings.  Unchanged values are accepted, so that
+   * settings saved on a larger host keep working.
+   */
+  protected checkHostResource(resource: keyof HostResources['limits'], unit: string) {
+    return (mergedSettings: Settings, currentValue: number, desiredValue: number, errors: string[], fqname: string) => {
+      if (typeof desiredValue !== 'number' || desiredValue === currentValue) {
+        return false;
+      }
+      const host = getHostResources();
+
+      if (desiredValue > host[resource]) {
+        errors.push(`field "${ fqname }" is ${ desiredValue } ${ unit }, but the host only has ${ host[resource] } ${ unit }`);
+
+        return false;
+      }
+      if (desiredValue > host.limits[resource]) {
+        const message = `field "${ fqname }" is ${ desiredValue } ${ unit }, over the limit of ${ host.limits[resource] } ${ unit } for this host`;
+
+        if (!this.force) {
+          errors.push(`${ message }; force the change to accept it`);
+
+          return false;
+        }
+        this.warnings.push(message);
+      }
+
+      return true;
+    };
+  }
+
   protected checkEnum(...validValues: string[]) {
     return <S>(mergedSettings: S, currentValue: string, desiredValue: string, errors: string[], fqname: string) => {
       const explanation = `must be one of ${ JSON.stringify(validValues) }`;
@@ -477,6 +621,72 @@ export default class SettingsValidator {
     return currentValue !== desiredValue;
   }
 
+  /**
+   * checkStoragePath checks that the local-path provisioner storage location
+   * is empty (for the K3s default) or an absolute path in the VM. The path is
+   * written into the shell configuration of the K3s service, so only
+   * characters that need no quoting are allowed.
+   */
+  protected checkStoragePath(mergedSettings: Settings, currentValue: string, desiredValue: string, errors: string[], fqname: string): boolean {
+    if (typeof desiredValue !== 'string') {
+      errors.push(this.invalidSettingMessage(fqname, desiredValue));
+
+      return false;
+    }
+    if (desiredValue !== '' && !/^\/[A-Za-z0-9._/-]*$/.test(desiredValue)) {
+      errors.push(`${ this.invalidSettingMessage(fqname, desiredValue) }; must be an absolute path in the VM containing only letters, digits, '.', '_', '-', and '/'`);
+
+      return false;
+    }
+
+    return currentValue !== desiredValue;
+  }
+
+  /**
+   * checkCertificateAuthority checks that the certificate authority files of
+   * the API server are empty or absolute paths on the host, and that the
+   * certificate and the key are set together.
+   */
+  protected checkCertificateAuthority(mergedSettings: Settings, currentValue: string, desiredValue: string, errors: string[], fqname: string): boolean {
+    if (typeof desiredValue !== 'string') {
+      errors.push(this.invalidSettingMessage(fqname, desiredValue));
+
+      return false;
+    }
+    if (desiredValue !== '' && !path.isAbsolute(desiredValue)) {
+      errors.push(`${ this.invalidSettingMessage(fqname, desiredValue) }; must be an absolute path`);
+
+      return false;
+    }
+    const { certificate, key } = mergedSettings.kubernetes.certificateAuthority;
+    const message = 'kubernetes.certificateAuthority.certificate and kubernetes.certificateAuthority.key must be set together';
+
+    if (!certificate !== !key) {
+      if (!errors.includes(message)) {
+        errors.push(message);
+      }
+
+      return false;
+    }
+
+    return currentValue !== desiredValue;
+  }
+
+  /**
+   * checkOIDCClientID checks that the client ID is set when the OIDC issuer
+   * is; the API server needs it to check the audience of the tokens.
+   */
+  protected checkOIDCClientID(mergedSettings: Settings, currentValue: string, desiredValue: string, errors: string[], fqname: string): boolean {
+    const { issuerURL, clientID } = mergedSettings.kubernetes.oidc;
+    const message = 'kubernetes.oidc.clientID is required when kubernetes.oidc.issuerURL is set';
+
+    if (issuerURL && !clientID && !errors.includes(message)) {
+      errors.push(message);
+    }
+
+    return false;
+  }
+
   protected checkKubernetesVersion(mergedSettings: Settings, currentValue: string, desiredVersion: string, errors: string[], _: string): boolean {
     /**
      * desiredVersion can be an empty string when Kubernetes is disabled, but otherwise it must be a valid version.
@@ -546,6 +756,74 @@ export default class SettingsValidator {
     return currentValue.length !== desiredValue.length || currentValue.some((v, i) => v !== desiredValue[i]);
   }
 
+  /**
+   * checkStringArrayFormat returns a validator that checks that every entry in
+   * an array of strings matches the pattern. It is meant to be combined with
+   * checkUniqueStringArray, which checks the type and reports changes.
+   */
+  protected checkStringArrayFormat(pattern: RegExp, explanation: string) {
+    return (mergedSettings: Settings, currentValue: string[], desiredValue: string[], errors: string[], fqname: string): boolean => {
+      if (!Array.isArray(desiredValue)) {
+        return false;
+      }
+      const invalidValues = desiredValue.filter(s => typeof (s) === 'string' && !pattern.test(s));
+
+      if (invalidValues.length > 0) {
+        errors.push(`field "${ fqname }" has invalid entries: "${ invalidValues.join('", "') }"; ${ explanation }`);
+      }
+
+      return false;
+    };
+  }
+
+  /**
+   * checkStringFormat returns a validator that checks that a string matches
+   * the pattern. It is meant to be combined with checkString, which checks
+   * the type and reports changes.
+   */
+  protected checkStringFormat(pattern: RegExp, explanation: string) {
+    return (mergedSettings: Settings, currentValue: string, desiredValue: string, errors: string[], fqname: string): boolean => {
+      if (typeof desiredValue === 'string' && !pattern.test(desiredValue)) {
+        errors.push(`field "${ fqname }" has an invalid value "${ desiredValue }"; ${ explanation }`);
+      }
+
+      return false;
+    };
+  }
+
+  /**
+   * checkReversePortForwardGuestPorts checks that the guest ports of the
+   * reverse port forwards are neither used by Rancher Desktop in the VM nor
+   * forwarded twice.  Malformed entries are left to checkStringArrayFormat.
+   */
+  protected checkReversePortForwardGuestPorts(mergedSettings: Settings, currentValue: string[], desiredValue: string[], errors: string[], fqname: string): boolean {
+    if (!Array.isArray(desiredValue)) {
+      return false;
+    }
+    const reserved = new Set([...reservedGuestPorts, mergedSettings.kubernetes.port]);
+    const seen = new Set<number>();
+    const problems: string[] = [];
+
+    for (const entry of desiredValue) {
+      if (typeof entry !== 'string' || !reversePortForwardRE.test(entry)) {
+        continue;
+      }
+      const guestPort = parseInt(entry.split(':')[0], 10);
+
+      if (reserved.has(guestPort)) {
+        problems.push(`port ${ guestPort } is used by Rancher Desktop`);
+      } else if (seen.has(guestPort)) {
+        problems.push(`port ${ guestPort } is forwarded more than once`);
+      }
+      seen.add(guestPort);
+    }
+    if (problems.length > 0) {
+      errors.push(`field "${ fqname }" has invalid guest ports: ${ problems.join('; ') }`);
+    }
+
+    return false;
+  }
+
   protected findDuplicates(list: string[]): string[] {
     let whiteSpaceMembers = [];
     const firstInstance = new Set<string>();
@@ -592,7 +870,7 @@ export default class SettingsValidator {
       }
       if (typeof tag !== 'string') {
         errors.push(`${ fqname }: "${ name }" has non-string tag "${ tag }"`);
-      } else if (!validateImageTag(tag)) {
+      } else if (!validateImageTag(tag) && !validateImageDigest(tag)) {
         errors.push(`${ fqname }: "${ name }" has invalid tag "${ tag }"`);
       }
     }
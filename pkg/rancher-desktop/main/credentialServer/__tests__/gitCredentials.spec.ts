@@ -0,0 +1,96 @@
+/** @jest-environment node */
+
+import { gitConfigForVM, runGitCredential, updateGitConfig } from '@pkg/main/credentialServer/gitCredentials';
+import { spawnFile } from '@pkg/utils/childProcess';
+
+jest.mock('@pkg/utils/childProcess');
+
+describe('runGitCredential', () => {
+  afterEach(() => {
+    jest.resetAllMocks();
+  });
+
+  it.each([
+    ['get', 'fill'],
+    ['store', 'approve'],
+    ['erase', 'reject'],
+  ])('runs %s as git credential %s', async(action, command) => {
+    jest.mocked(spawnFile).mockImplementation((file, args, options) => {
+      expect(file).toEqual('git');
+      expect(args).toEqual(['credential', command]);
+      expect(options).toMatchObject({ env: { GIT_TERMINAL_PROMPT: '0', GIT_ASKPASS: '' } });
+
+      return Promise.resolve({ stdout: 'username=pika\npassword=chu\n' }) as any;
+    });
+
+    await expect(runGitCredential(action, 'protocol=https\nhost=example.com\n'))
+      .resolves.toEqual('username=pika\npassword=chu\n');
+  });
+
+  it('rejects unknown actions', async() => {
+    await expect(runGitCredential('list', '')).rejects.toThrow(/Unknown git credential action 'list'/);
+    expect(spawnFile).not.toHaveBeenCalled();
+  });
+});
+
+describe('gitConfigForVM', () => {
+  afterEach(() => {
+    jest.resetAllMocks();
+  });
+
+  it('includes the identity of the host', async() => {
+    const values: Record<string, string> = { 'user.name': 'Pika "Chu"', 'user.email': 'pika@example.com' };
+
+    jest.mocked(spawnFile).mockImplementation((file, args) => {
+      return Promise.resolve({ stdout: `${ values[(args as string[])[3]] }\n` }) as any;
+    });
+
+    await expect(gitConfigForVM()).resolves.toEqual([
+      '# BEGIN Rancher Desktop git bridge; changes will be overwritten.',
+      '[credential]',
+      '\thelper = "rancher-desktop"',
+      '[user]',
+      '\tname = "Pika \\"Chu\\""',
+      '\temail = "pika@example.com"',
+      '# END Rancher Desktop git bridge',
+      '',
+    ].join('\n'));
+  });
+
+  it('omits the identity when it is not configured', async() => {
+    jest.mocked(spawnFile).mockRejectedValue(new Error('exit code 1'));
+
+    await expect(gitConfigForVM()).resolves.toEqual([
+      '# BEGIN Rancher Desktop git bridge; changes will be overwritten.',
+      '[credential]',
+      '\thelper = "rancher-desktop"',
+      '# END Rancher Desktop git bridge',
+      '',
+    ].join('\n'));
+  });
+});
+
+describe('updateGitConfig', () => {
+  const block = '# BEGIN Rancher Desktop git bridge; changes will be overwritten.\n[credential]\n\thelper = "rancher-desktop"\n# END Rancher Desktop git bridge\n';
+  const userConfig = '[core]\n\tautocrlf = input\n';
+
+  it('creates the file', () => {
+    expect(updateGitConfig('', block)).toEqual(block);
+  });
+
+  it('keeps the configuration of the user', () => {
+    expect(updateGitConfig(userConfig, block)).toEqual(`${ userConfig }${ block }`);
+  });
+
+  it('replaces its block', () => {
+    const old = block.replace('rancher-desktop"', 'old"');
+
+    expect(updateGitConfig(`${ userConfig }${ old }[alias]\n\tco = checkout\n`, block))
+      .toEqual(`${ userConfig }[alias]\n\tco = checkout\n${ block }`);
+  });
+
+  it('removes its block', () => {
+    expect(updateGitConfig(`${ userConfig }${ block }`, '')).toEqual(userConfig);
+    expect(updateGitConfig(block, '')).toEqual('');
+  });
+});
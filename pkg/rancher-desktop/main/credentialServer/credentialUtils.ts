@@ -111,7 +111,7 @@ async function getCredentialHelperInfo(command: string, payload: string): Promis
  * @param command The one-word command to run
  * @param input Any input to the helper, to be sent as standard input.
  */
-async function runCredHelper(helper: string, command: string, input?: string): Promise<string> {
+export async function runCredHelper(helper: string, command: string, input?: string): Promise<string> {
   // The PATH needs to contain our resources directory (on macOS that would
   // not be in the application's PATH).
   // NOTE: This needs to match DockerDirManager.spawnFileWithExtraPath
@@ -0,0 +1,127 @@
+/**
+ * Bridging of git credentials and identity into the VM: the
+ * git-credential-rancher-desktop helper in the VM forwards its requests to
+ * the credential server, which passes them to `git credential` on the host so
+ * that the credential helpers configured on the host are used.
+ */
+
+import stream from 'stream';
+
+import { spawnFile } from '@pkg/utils/childProcess';
+import Logging from '@pkg/utils/logging';
+
+const console = Logging.server;
+
+/**
+ * Mark the block of the system git configuration written by Rancher Desktop;
+ * the rest of the file is left alone.
+ */
+const GIT_CONFIG_BEGIN = '# BEGIN Rancher Desktop git bridge; changes will be overwritten.';
+const GIT_CONFIG_END = '# END Rancher Desktop git bridge';
+
+/**
+ * The credential helper for WSL distributions integrated with Rancher Desktop:
+ * instead of going through the credential server, it runs `git credential` on
+ * the Windows host directly, through WSL interop.
+ */
+export const WSL_INTEGRATION_GIT_CREDENTIAL_HELPER =
+  '!f() { case "$1" in get) c=fill ;; store) c=approve ;; erase) c=reject ;; *) exit 0 ;; esac; exec git.exe credential "$c"; }; f';
+
+/** Maps the actions of git credential helpers to `git credential` commands. */
+const gitCredentialCommands: Record<string, string> = {
+  get:   'fill',
+  store: 'approve',
+  erase: 'reject',
+};
+
+/**
+ * Run `git credential` on the host for the action requested by the credential
+ * helper in the VM.
+ * @param action The credential helper action: get, store, or erase.
+ * @param input The credential description, as sent by git to the helper.
+ */
+export async function runGitCredential(action: string, input: string): Promise<string> {
+  const command = gitCredentialCommands[action];
+
+  if (!command) {
+    throw new Error(`Unknown git credential action '${ action }', must be one of [${ Object.keys(gitCredentialCommands).sort().join('|') }]`);
+  }
+  // Never prompt on the host for requests from the VM; an empty GIT_ASKPASS
+  // also keeps git from falling back to SSH_ASKPASS.
+  const { stdout } = await spawnFile('git', ['credential', command], {
+    env:   { ...process.env, GIT_TERMINAL_PROMPT: '0', GIT_ASKPASS: '' },
+    stdio: [stream.Readable.from(input), 'pipe', console],
+  });
+
+  return stdout;
+}
+
+function quoteGitConfigValue(value: string): string {
+  return `"${ value.replace(/\\/g, '\\\\').replace(/"/g, '\\"') }"`;
+}
+
+async function getGlobalGitConfig(key: string): Promise<string> {
+  try {
+    const { stdout } = await spawnFile('git', ['config', '--global', '--get', key], { stdio: ['ignore', 'pipe', console] });
+
+    return stdout.trim();
+  } catch {
+    // git exits with an error when the key isn't set, or isn't installed.
+    return '';
+  }
+}
+
+/**
+ * Returns the block of the system git configuration for the VM, which uses
+ * the credential helper forwarding to the host and the user name and email
+ * of the host.
+ * @param helper The credential helper to use.
+ */
+export async function gitConfigForVM(helper = 'rancher-desktop'): Promise<string> {
+  const lines = [GIT_CONFIG_BEGIN, '[credential]', `\thelper = ${ quoteGitConfigValue(helper) }`];
+  const user: Record<string, string> = {
+    name:  await getGlobalGitConfig('user.name'),
+    email: await getGlobalGitConfig('user.email'),
+  };
+
+  if (user.name || user.email) {
+    lines.push('[user]');
+    for (const [key, value] of Object.entries(user)) {
+      if (value) {
+        lines.push(`\t${ key } = ${ quoteGitConfigValue(value) }`);
+      }
+    }
+  }
+  lines.push(GIT_CONFIG_END);
+
+  return `${ lines.join('\n') }\n`;
+}
+
+/**
+ * Replace the block written by Rancher Desktop in a system git configuration.
+ * @param contents The current contents of the file, or an empty string.
+ * @param block The new block, as returned by gitConfigForVM(), or an empty
+ * string to remove it.
+ * @returns The new contents of the file; an empty string if nothing is left.
+ */
+export function updateGitConfig(contents: string, block: string): string {
+  const lines: string[] = [];
+  let inBlock = false;
+
+  for (const line of contents.split('\n')) {
+    if (line === GIT_CONFIG_BEGIN) {
+      inBlock = true;
+    } else if (inBlock && line === GIT_CONFIG_END) {
+      inBlock = false;
+    } else if (!inBlock) {
+      lines.push(line);
+    }
+  }
+  const rest = lines.join('\n').replace(/\n*$/, '');
+
+  if (!rest) {
+    return block;
+  }
+
+  return `${ rest }\n${ block }`;
+}
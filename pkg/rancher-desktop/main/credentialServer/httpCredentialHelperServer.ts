@@ -3,8 +3,13 @@ import http from 'http';
 import path from 'path';
 import { URL } from 'url';
 
+import _ from 'lodash';
+
 import runCredentialHelper from './credentialUtils';
+import { runGitCredential } from './gitCredentials';
 
+import { isAPICredentialsServerURL } from '@pkg/main/commandServer/apiCredentials';
+import mainEvents from '@pkg/main/mainEvents';
 import { getVtunnelInstance } from '@pkg/main/networking/vtunnel';
 import * as serverHelper from '@pkg/main/serverHelper';
 import Logging from '@pkg/utils/logging';
@@ -65,6 +70,14 @@ export class HttpCredentialHelperServer {
   };
 
   protected listenAddr = '127.0.0.1';
+  /** Whether the VM may use the git credentials of the host. */
+  protected gitBridge = false;
+
+  constructor() {
+    mainEvents.on('settings-update', (cfg) => {
+      this.gitBridge = cfg.experimental.virtualMachine.gitBridge;
+    });
+  }
 
   async init() {
     if (process.platform === 'win32') {
@@ -121,7 +134,11 @@ export class HttpCredentialHelperServer {
         return;
       }
 
-      await this.doCommand(commandName, data, request, response);
+      if (commandName === 'git') {
+        await this.doGitCommand(pathParts[1] ?? '', data, request, response);
+      } else {
+        await this.doCommand(commandName, data, request, response);
+      }
     } catch (err) {
       console.debug(`FAILURE: Processing request ${ request.method } ${ path }`);
       console.log(`Error handling ${ request.url }`, err);
@@ -152,6 +169,32 @@ export class HttpCredentialHelperServer {
     }
   }
 
+  protected async doGitCommand(
+    action: string,
+    data: string,
+    request: http.IncomingMessage,
+    response: http.ServerResponse): Promise<void> {
+    if (!this.gitBridge) {
+      response.writeHead(403, { 'Content-Type': 'text/plain' });
+      response.write('Sharing git credentials with the VM is disabled.\n');
+
+      return;
+    }
+    try {
+      if (request.method !== 'POST') {
+        throw new Error(`Expecting a POST method for the git credential request, received ${ request.method }`);
+      }
+      const stdout = await runGitCredential(action, data);
+
+      response.writeHead(200, { 'Content-Type': 'text/plain' });
+      response.write(ensureEndsWithNewline(stdout));
+    } catch (err: any) {
+      console.debug(`FAILURE: Processing git credential request ${ action }`);
+      response.writeHead(400, { 'Content-Type': 'text/plain' });
+      response.write(ensureEndsWithNewline(err.stderr || err.message || `${ err }`));
+    }
+  }
+
   protected async runCommand(
     commandName: string,
     data: string,
@@ -175,16 +218,46 @@ export class HttpCredentialHelperServer {
     if (requestCheckError) {
       throw new Error(requestCheckError);
     }
+    if (this.isAPICredentialsRequest(commandName, data)) {
+      // The API credentials in the keychain are for the host only.
+      throw new Error('The credentials of the Rancher Desktop API are not available through the credential server.');
+    }
 
-    const output = await runCredentialHelper(commandName, data);
+    let output = await runCredentialHelper(commandName, data);
 
     if (!checkerFn(output)) {
       throw new Error(`Invalid output for ${ commandName } command.`);
     }
+    if (commandName === 'list') {
+      const entries = _.omitBy(JSON.parse(output), (_user, serverURL) => isAPICredentialsServerURL(serverURL));
+
+      output = JSON.stringify(entries);
+    }
 
     return output;
   }
 
+  /**
+   * Whether the request is about the API credentials the application keeps in
+   * the keychain, under any spelling of their server URL.
+   */
+  protected isAPICredentialsRequest(commandName: string, data: string): boolean {
+    switch (commandName) {
+    case 'get':
+    case 'erase':
+      return isAPICredentialsServerURL(data);
+    case 'store':
+      try {
+        return isAPICredentialsServerURL(String(JSON.parse(data).ServerURL ?? ''));
+      } catch {
+        // Let the helper reject the payload, but never with our credentials.
+        return isAPICredentialsServerURL(data);
+      }
+    }
+
+    return false;
+  }
+
   closeServer() {
     this.server.close();
   }
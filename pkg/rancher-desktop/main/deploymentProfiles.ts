@@ -23,6 +23,31 @@ export class DeploymentProfileError extends Error {
   }
 }
 
+/**
+ * DeploymentProfileProblem is a problem found while loading a deployment
+ * profile.  Errors are for fields with invalid values, and warnings for
+ * unknown fields; both kinds of fields are left out of the profile.
+ */
+export type DeploymentProfileProblem = {
+  /** The file or registry key containing the profile. */
+  source:   string;
+  severity: 'error' | 'warning';
+  message:  string;
+};
+
+let problems: DeploymentProfileProblem[] = [];
+
+/**
+ * Get the problems found by the last call to readDeploymentProfiles().
+ */
+export function getDeploymentProfileProblems(): DeploymentProfileProblem[] {
+  return problems;
+}
+
+function addProblems(source: string, severity: DeploymentProfileProblem['severity'], messages: string[]) {
+  problems.push(...messages.map(message => ({ source, severity, message })));
+}
+
 const REGISTRY_PROFILE_PATHS = [
   ['SOFTWARE', 'Policies', 'Rancher Desktop'], // Recommended (default) location
   ['SOFTWARE', 'Rancher Desktop', 'Profile'], // Old location for backward-compatibility
@@ -33,13 +58,16 @@ const REGISTRY_PROFILE_PATHS = [
  * priority over user level profiles.  If the system directory contains a
  * defaults or locked profile, the user directory will not be read.
  * @returns type validated defaults and locked deployment profiles, and throws
- *          an error if there is an error parsing the locked profile.
+ *          an error if there is an error parsing the locked profile.  Invalid
+ *          fields of the defaults profile are dropped, and reported by
+ *          getDeploymentProfileProblems().
  * NOTE: The renderer process can not access the 'native-reg' library, so the
  *       win32 portions of the deployment profile reader functions must be
  *       located in the main process.
  */
 
 export async function readDeploymentProfiles(registryProfilePath = REGISTRY_PROFILE_PATHS): Promise<settings.DeploymentProfileType> {
+  problems = [];
   if (process.platform === 'win32') {
     const win32DeploymentReader = new Win32DeploymentReader(registryProfilePath);
 
@@ -98,7 +126,7 @@ export async function readDeploymentProfiles(registryProfilePath = REGISTRY_PROF
     locked = settingsImpl.migrateSpecifiedSettingsToCurrentVersion(locked);
   }
 
-  profiles.defaults = validateDeploymentProfile(fullDefaultPath, defaults, settings.defaultSettings, []) ?? {};
+  profiles.defaults = validateDeploymentProfile(fullDefaultPath, defaults, settings.defaultSettings, [], true) ?? {};
   profiles.locked = validateDeploymentProfile(fullLockedPath, locked, settings.defaultSettings, []) ?? {};
 
   return profiles;
@@ -213,6 +241,9 @@ class Win32DeploymentReader {
 
         try {
           defaults = defaultsKey ? this.readRegistryUsingSchema(settings.defaultSettings, defaultsKey, [DEFAULTS_HIVE_NAME]) : {};
+          // Invalid values in the defaults hive have already been dropped;
+          // report them without preventing startup.
+          addProblems(this.fullRegistryPath(DEFAULTS_HIVE_NAME), 'error', this.errors.splice(0));
           locked = lockedKey ? this.readRegistryUsingSchema(settings.defaultSettings, lockedKey, [LOCKED_HIVE_NAME]) : {};
         } catch (err) {
           console.error('Error reading deployment profile: ', err);
@@ -300,7 +331,10 @@ class Win32DeploymentReader {
     }
     if (unknownKeys.length) {
       unknownKeys.sort(caseInsensitiveComparator.compare);
-      console.error(`Unrecognized keys in registry at ${ this.fullRegistryPath(...pathParts) }: [${ unknownKeys.join(', ') }]`);
+      const msg = `Unrecognized keys in registry at ${ this.fullRegistryPath(...pathParts) }: [${ unknownKeys.join(', ') }]`;
+
+      console.error(msg);
+      addProblems(this.fullRegistryPath(pathParts[0]), 'warning', [msg]);
     }
 
     // First process the nested keys, then process any values
@@ -366,7 +400,10 @@ class Win32DeploymentReader {
     }
     if (unknownValueNames.length > 0) {
       unknownValueNames.sort(caseInsensitiveComparator.compare);
-      console.error(`Unrecognized value names in registry at ${ this.fullRegistryPath(...pathParts) }: [${ unknownValueNames.join(', ') }]`);
+      const msg = `Unrecognized value names in registry at ${ this.fullRegistryPath(...pathParts) }: [${ unknownValueNames.join(', ') }]`;
+
+      console.error(msg);
+      addProblems(this.fullRegistryPath(pathParts[0]), 'warning', [msg]);
     }
 
     return newObject;
@@ -504,14 +541,22 @@ class Win32DeploymentReader {
  * @param profile The profile to be validated
  * @param schema The structure (usually defaultSettings) used as a template
  * @param parentPathParts The parent path for the current schema key.
- * @returns The original profile, less any invalid fields
+ * @param dropInvalidFields If true, drop invalid fields and report them as
+ *        problems instead of throwing an error.
+ * @returns The original profile, less any unknown or dropped fields
  */
-export function validateDeploymentProfile(inputPath: string, profile: any, schema: any, parentPathParts: string[]): RecursivePartial<settings.Settings> {
+export function validateDeploymentProfile(inputPath: string, profile: any, schema: any, parentPathParts: string[], dropInvalidFields = false): RecursivePartial<settings.Settings> {
   const errors: string[] = [];
+  const warnings: string[] = [];
 
-  validateDeploymentProfileWithErrors(profile, errors, schema, parentPathParts);
+  validateDeploymentProfileWithErrors(profile, errors, warnings, schema, parentPathParts, dropInvalidFields);
+  addProblems(inputPath, 'warning', warnings);
   if (errors.length) {
-    throw new DeploymentProfileError(`Error in deployment file ${ inputPath }:\n${ errors.join('\n') }`);
+    if (!dropInvalidFields) {
+      throw new DeploymentProfileError(`Error in deployment file ${ inputPath }:\n${ errors.join('\n') }`);
+    }
+    console.error(`Ignoring invalid fields in deployment file ${ inputPath }:\n${ errors.join('\n') }`);
+    addProblems(inputPath, 'error', errors);
   }
 
   return profile;
@@ -521,11 +566,13 @@ export function validateDeploymentProfile(inputPath: string, profile: any, schem
  * Do simple type validation of a deployment profile
  * @param profile The profile to be validated, modified in place
  * @param errors An array of error messages, built up in place
+ * @param warnings An array of messages about ignored fields, built up in place
  * @param schema The structure (usually defaultSettings) used as a template
  * @param parentPathParts The parent path for the current schema key.
- * @returns The original profile, less any invalid fields
+ * @param dropInvalidFields If true, fields with errors are deleted from the profile.
+ * @returns The original profile, less any unknown fields
  */
-function validateDeploymentProfileWithErrors(profile: any, errors: string[], schema: any, parentPathParts: string[]) {
+function validateDeploymentProfileWithErrors(profile: any, errors: string[], warnings: string[], schema: any, parentPathParts: string[], dropInvalidFields: boolean) {
   if (typeof profile !== 'object') {
     return profile;
   }
@@ -536,11 +583,13 @@ function validateDeploymentProfileWithErrors(profile: any, errors: string[], sch
   for (const key in profile) {
     if (!(key in schema)) {
       console.log(`Deployment Profile ignoring '${ fullPath(key) }': not in schema.`);
+      warnings.push(`Ignoring field '${ fullPath(key) }': not a known setting`);
       delete profile[key];
       continue;
     }
     const schemaVal = schema[key];
     const profileVal = profile[key];
+    const errorCount = errors.length;
 
     if (Array.isArray(profileVal) || Array.isArray(schemaVal)) {
       if (Array.isArray(profileVal) !== Array.isArray(schemaVal)) {
@@ -560,7 +609,11 @@ function validateDeploymentProfileWithErrors(profile: any, errors: string[], sch
       errors.push(`Error for field '${ fullPath(key) }': expecting value of type ${ typeof schemaVal }, got '${ JSON.stringify(profileVal) }'`);
     } else {
       // Finally recurse and compare the schema sub-object with the specified sub-object
-      validateDeploymentProfileWithErrors(profileVal, errors, schemaVal, [...parentPathParts, key]);
+      validateDeploymentProfileWithErrors(profileVal, errors, warnings, schemaVal, [...parentPathParts, key], dropInvalidFields);
+      continue;
+    }
+    if (dropInvalidFields && errors.length > errorCount) {
+      delete profile[key];
     }
   }
 
@@ -0,0 +1,79 @@
+/**
+ * In development builds, watch the directories holding the helpers used by
+ * the backend, so that the backend can be restarted when they are rebuilt,
+ * instead of restarting the whole application.
+ */
+
+import fs from 'fs';
+import os from 'os';
+import path from 'path';
+import timers from 'timers';
+
+import Logging from '@pkg/utils/logging';
+import paths from '@pkg/utils/paths';
+
+const console = Logging.background;
+
+/** Wait for changes to settle, as a build writes many files. */
+const DEBOUNCE_DELAY = 2_000;
+
+export default class DevResourceWatcher {
+  protected watchers: fs.FSWatcher[] = [];
+  protected timer: NodeJS.Timeout | undefined;
+  protected changed = new Set<string>();
+
+  /**
+   * @param onChange Called with the paths of the changed files.
+   */
+  constructor(protected readonly onChange: (files: string[]) => void) {}
+
+  /**
+   * The directories holding the helpers: the host binaries, and the binaries
+   * used in the VM.  Watching is not recursive, as that is not supported
+   * everywhere.
+   */
+  get directories(): string[] {
+    const platforms = Array.from(new Set([os.platform(), 'linux']));
+
+    return platforms.flatMap(platform => ['bin', 'internal'].map(dir => path.join(paths.resources, platform, dir)));
+  }
+
+  start() {
+    for (const dir of this.directories) {
+      if (!fs.existsSync(dir)) {
+        continue;
+      }
+      try {
+        this.watchers.push(fs.watch(dir, (_, filename) => this.record(dir, filename)));
+        console.debug(`Watching ${ dir } for changes.`);
+      } catch (ex) {
+        console.debug(`Failed to watch ${ dir }:`, ex);
+      }
+    }
+  }
+
+  stop() {
+    for (const watcher of this.watchers) {
+      watcher.close();
+    }
+    this.watchers = [];
+    if (this.timer) {
+      timers.clearTimeout(this.timer);
+      this.timer = undefined;
+    }
+  }
+
+  protected record(dir: string, filename: string | null) {
+    this.changed.add(filename ? path.join(dir, filename) : dir);
+    if (this.timer) {
+      timers.clearTimeout(this.timer);
+    }
+    this.timer = timers.setTimeout(() => {
+      const files = Array.from(this.changed).sort();
+
+      this.timer = undefined;
+      this.changed.clear();
+      this.onChange(files);
+    }, DEBOUNCE_DELAY);
+  }
+}
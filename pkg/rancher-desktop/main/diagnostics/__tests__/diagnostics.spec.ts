@@ -126,6 +126,17 @@ describe(DiagnosticsManager, () => {
     });
     await internetCheck.not.toMatchObject({ checks: { 0: { fixes: { description: expect.any(String) } } } });
   });
+
+  test('it runs the checks of the given categories', async() => {
+    const checkers = mockDiagnostics.map(checker => ({ ...checker, check: jest.fn(checker.check) }));
+    const manager = new DiagnosticsManager(checkers);
+    const results = await manager.runChecks(['Networking']);
+
+    expect(results.checks.map(check => check.id)).toEqual(['CONNECTED_TO_INTERNET']);
+    expect(checkers[0].check).not.toHaveBeenCalled();
+    expect(checkers[1].check).not.toHaveBeenCalled();
+    expect(checkers[2].check).toHaveBeenCalledTimes(1);
+  });
 });
 
 dayjs.extend(relativeTime);
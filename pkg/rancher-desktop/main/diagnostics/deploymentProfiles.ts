@@ -0,0 +1,39 @@
+import { getDeploymentProfileProblems } from '@pkg/main/deploymentProfiles';
+import Logging from '@pkg/utils/logging';
+
+import type { DiagnosticsCategory, DiagnosticsChecker, DiagnosticsCheckerResult } from './types';
+
+const console = Logging.diagnostics;
+
+/**
+ * Report the fields of the deployment profiles that were ignored when they
+ * were loaded, because they are invalid or unknown.
+ */
+const DeploymentProfilesChecker: DiagnosticsChecker = {
+  id:       'DEPLOYMENT_PROFILES',
+  category: 'Utilities' as DiagnosticsCategory,
+  applicable(): Promise<boolean> {
+    return Promise.resolve(true);
+  },
+  check(): Promise<DiagnosticsCheckerResult> {
+    const problems = getDeploymentProfileProblems();
+
+    console.debug(`${ this.id }: found ${ problems.length } problems`);
+    if (problems.length === 0) {
+      return Promise.resolve({
+        description: 'The deployment profiles are valid.',
+        fixes:       [],
+        passed:      true,
+      });
+    }
+    const lines = problems.map(({ source, severity, message }) => `- ${ severity } in \`${ source }\`: ${ message }`);
+
+    return Promise.resolve({
+      description: `Some fields of the deployment profiles were ignored:\n${ lines.join('\n') }`,
+      fixes:       [{ description: 'Fix the deployment profiles; check them with `rdctl profile lint FILE`.' }],
+      passed:      false,
+    });
+  },
+};
+
+export default DeploymentProfilesChecker;
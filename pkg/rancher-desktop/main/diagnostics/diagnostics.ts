@@ -55,6 +55,8 @@ export class DiagnosticsManager {
         import('./wslFromStore'),
         import('./mockForScreenshots'),
         import('./limaDarwin'),
+        import('./deploymentProfiles'),
+        import('./nestedVirtualization'),
       ])).map(obj => obj.default);
 
       return (await Promise.all(imports)).flat();
@@ -141,20 +143,27 @@ export class DiagnosticsManager {
     try {
       this.results[checker.id] = await checker.check();
       console.debug(`Check ${ checker.id } result: ${ JSON.stringify(this.results[checker.id]) }`);
+      mainEvents.emit('diagnostics-update', checker.id, this.results[checker.id].passed);
     } catch (e) {
       console.error(`ERROR checking ${ checker.id }`, { e });
     }
   }
 
   /**
-   * Run all checks, and return the results.
+   * Run the checks of the given categories, or all checks if none is given,
+   * and return their results.
    */
-  async runChecks(): Promise<DiagnosticsResultCollection> {
-    await Promise.all((await this.applicableCheckers(null, null)).map(async(checker) => {
-      await this.runChecker(checker);
-    }));
+  async runChecks(categoryNames: string[] = []): Promise<DiagnosticsResultCollection> {
+    const inCategories = (category: string) => categoryNames.length === 0 || categoryNames.includes(category);
+
+    await Promise.all((await this.applicableCheckers(null, null))
+      .filter(checker => inCategories(checker.category))
+      .map(async(checker) => {
+        await this.runChecker(checker);
+      }));
     this.lastUpdate = new Date();
+    const results = await this.getChecks(null, null);
 
-    return this.getChecks(null, null);
+    return { ...results, checks: results.checks.filter(check => inCategories(check.category)) };
   }
 }
@@ -0,0 +1,42 @@
+import { DiagnosticsCategory, DiagnosticsChecker } from './types';
+
+import { getNestedVirtualizationSupport } from '@pkg/backend/nestedVirtualization';
+import mainEvents from '@pkg/main/mainEvents';
+import Logging from '@pkg/utils/logging';
+
+const console = Logging.diagnostics;
+
+let nestedVirtualization = false;
+
+mainEvents.on('settings-update', (cfg) => {
+  nestedVirtualization = cfg.experimental.virtualMachine.nestedVirtualization;
+});
+
+/**
+ * CheckNestedVirtualization reports whether the host lets the VM run virtual
+ * machines itself, once nested virtualization is enabled.
+ */
+const CheckNestedVirtualization: DiagnosticsChecker = {
+  id:       'NESTED_VIRTUALIZATION',
+  category: DiagnosticsCategory.ContainerEngine,
+  applicable() {
+    return Promise.resolve(process.platform === 'linux' && nestedVirtualization);
+  },
+  async check() {
+    const support = await getNestedVirtualizationSupport();
+    const result = {
+      description: 'Nested virtualization is enabled; containers can use /dev/kvm in the VM.',
+      passed:      support.supported,
+      fixes:       support.fix ? [{ description: support.fix }] : [],
+    };
+
+    if (!support.supported) {
+      result.description = `Nested virtualization is enabled, but the host does not support it: ${ support.reason }`;
+    }
+    console.debug(`${ this.id }: result=${ JSON.stringify(result) }`);
+
+    return result;
+  },
+};
+
+export default CheckNestedVirtualization;
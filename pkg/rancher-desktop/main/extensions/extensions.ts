@@ -11,8 +11,9 @@ import {
 } from './types';
 
 import type { ContainerEngineClient } from '@pkg/backend/containerClient';
+import audit from '@pkg/main/audit';
 import mainEvents from '@pkg/main/mainEvents';
-import { parseImageReference } from '@pkg/utils/dockerUtils';
+import { joinImageReference, parseImageReference } from '@pkg/utils/dockerUtils';
 import Logging from '@pkg/utils/logging';
 import paths from '@pkg/utils/paths';
 import { defined } from '@pkg/utils/typeUtils';
@@ -90,7 +91,7 @@ export class ExtensionImpl implements Extension {
 
   /** The extension ID (the image ID), excluding the tag */
   id: string;
-  /** The extension image tag */
+  /** The extension image tag, or the digest the extension is pinned to */
   version: string;
   /** The directory this extension will be installed into */
   readonly dir: string;
@@ -105,7 +106,7 @@ export class ExtensionImpl implements Extension {
   }
 
   get image() {
-    return `${ this.id }:${ this.version }`;
+    return joinImageReference(this.id, this.version);
   }
 
   /** Extension metadata */
@@ -221,7 +222,14 @@ export class ExtensionImpl implements Extension {
   async install(allowedImages: readonly string[] | undefined): Promise<boolean> {
     const metadata = await this.metadata;
 
-    ExtensionImpl.checkInstallAllowed(allowedImages, this.image);
+    try {
+      ExtensionImpl.checkInstallAllowed(allowedImages, this.image);
+    } catch (ex: any) {
+      await audit('extension-install-denied', {
+        image: this.image, reason: ex.message, allowedImages,
+      });
+      throw ex;
+    }
     console.debug(`Image ${ this.image } is allowed to install: ${ allowedImages }`);
 
     await fs.promises.mkdir(this.dir, { recursive: true });
@@ -487,6 +495,11 @@ export class ExtensionImpl implements Extension {
     }
 
     mainEvents.emit('settings-write', { application: { extensions: { installed: { [this.id]: undefined } } } });
+    // The image may be rebuilt under the same tag before it is installed
+    // again, e.g. by `rdctl extension dev`; read it afresh then.
+    this._metadata = undefined;
+    this._labels = undefined;
+    this._composeFile = undefined;
 
     return true;
   }
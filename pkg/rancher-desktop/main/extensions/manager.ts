@@ -8,7 +8,7 @@ import semver from 'semver';
 
 import { ExtensionErrorImpl, ExtensionImpl } from './extensions';
 import {
-  Extension, ExtensionErrorCode, ExtensionManager, SpawnOptions, SpawnResult,
+  Extension, ExtensionErrorCode, ExtensionManager, SpawnOptions, SpawnResult, isExtensionError,
 } from './types';
 
 import type { ContainerEngineClient } from '@pkg/backend/containerClient';
@@ -17,7 +17,7 @@ import { getIpcMainProxy } from '@pkg/main/ipcMain';
 import mainEvents from '@pkg/main/mainEvents';
 import type { IpcMainEvents, IpcMainInvokeEvents, IpcRendererEvents } from '@pkg/typings/electron-ipc';
 import { demoMarketplace } from '@pkg/utils/_demo_marketplace_items';
-import { parseImageReference } from '@pkg/utils/dockerUtils';
+import { joinImageReference, parseImageReference } from '@pkg/utils/dockerUtils';
 import fetch, { RequestInit } from '@pkg/utils/fetch';
 import Logging from '@pkg/utils/logging';
 import paths from '@pkg/utils/paths';
@@ -256,13 +256,21 @@ export class ExtensionManagerImpl implements ExtensionManager {
       }
 
       tasks.push((async(repo: string, tag: string) => {
-        const id = `${ repo }:${ tag }`;
+        const id = joinImageReference(repo, tag);
+        let extension: Extension | undefined;
 
         try {
-          return await (await this.getExtension(id)).install(allowList);
-        } catch (ex) {
+          extension = await this.getExtension(id);
+
+          return await extension.install(allowList);
+        } catch (ex: any) {
           console.error(`Failed to install extension ${ id }`, ex);
           mainEvents.emit('settings-write', { application: { extensions: { installed: { [repo]: undefined } } } });
+          if (extension && isExtensionError(ex) && ex.code === ExtensionErrorCode.INSTALL_DENIED) {
+            // The allow list changed since the extension was installed.
+            console.log(`Removing extension ${ id }, which is no longer allowed.`);
+            await extension.uninstall().catch(err => console.error(`Failed to remove extension ${ id }:`, err));
+          }
         }
       })(repo, tag));
     }
@@ -309,7 +317,10 @@ export class ExtensionManagerImpl implements ExtensionManager {
   #supportedExtensions: Record<string, boolean> | undefined;
 
   async getExtension(image: string, options: { preferInstalled?: boolean } = {}): Promise<Extension> {
-    let [, imageName, tag] = /^(.*):(.*?)$/.exec(image) ?? ['', image, undefined];
+    // An image pinned to a digest uses the digest as its version, ignoring any
+    // tag before it.
+    let [, imageName, tag] = /^(.*?)(?::[^:/@]+)?@(sha256:[0-9a-f]{64})$/.exec(image) ??
+      /^(.*):(.*?)$/.exec(image) ?? ['', image, undefined];
 
     // The build process uses an older TypeScript that can't infer imageName correctly.
     imageName ??= image;
@@ -9,6 +9,7 @@ import type { VMBackend } from '@pkg/backend/backend';
 import type { Settings } from '@pkg/config/settings';
 import type { TransientSettings } from '@pkg/config/transientSettings';
 import { DiagnosticsCheckerResult } from '@pkg/main/diagnostics/types';
+import type { ContainerInfo, OOMKill, PressureChange } from '@pkg/main/resourceEvents';
 import { RecursivePartial, RecursiveReadonly } from '@pkg/utils/typeUtils';
 
 /**
@@ -100,6 +101,34 @@ interface MainEventNames {
    */
   'diagnostics-trigger'(id: string): DiagnosticsCheckerResult | undefined;
 
+  /**
+   * Emitted when a diagnostics checker has been run.
+   * @param id The ID of the checker.
+   * @param passed Whether the check passed.
+   */
+  'diagnostics-update'(id: string, passed: boolean): void;
+
+  /**
+   * Emitted when an image operation requested by the user, such as a pull or
+   * a build, has finished.
+   * @param operation The subcommand of the image operation, e.g. "pull".
+   * @param exitCode The exit code of the operation; -1 if it was killed.
+   */
+  'image-operation'(operation: string, exitCode: number): void;
+
+  /**
+   * Emitted when the kernel of the VM killed a process for running out of
+   * memory.
+   * @param kill The process, and the container it ran in, if any.
+   */
+  'resource-oom-kill'(kill: OOMKill & { container?: ContainerInfo }): void;
+
+  /**
+   * Emitted when the pressure on a resource of the VM became high, or is
+   * over.
+   */
+  'resource-pressure'(change: PressureChange): void;
+
   /**
    * Emitted when an extension is uninstalled via the extension manager.
    * @param id The ID of the extension that was uninstalled.
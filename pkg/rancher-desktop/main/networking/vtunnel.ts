@@ -24,13 +24,27 @@ export interface VtunnelConfig {
   upstreamServerAddress: string;
 }
 
+/**
+ * Configuration Object for a Vtunnel into the VM: the host process listens on
+ * a named pipe, and the peer dials into an address in the VM.
+ */
+export interface VtunnelToVMConfig {
+  name: string;
+  handshakePort: number;
+  /** The named pipe the host listens on, e.g. npipe:////./pipe/docker_engine. */
+  hostListenAddress: string;
+  vsockPeerPort: number;
+  /** Either IP:Port, or a unix socket, e.g. unix:///var/run/docker.sock. */
+  peerUpstreamAddress: string;
+}
+
 let instance: VTunnel | undefined;
 
 /**
  * Vtunnel is a management and integration class for Vtunnel Proxy of Rancher Desktop.
  */
 class VTunnel {
-  private _vtunnelConfig: VtunnelConfig[] = [];
+  private _vtunnelConfig: (VtunnelConfig | VtunnelToVMConfig)[] = [];
   private vsockProxy = new BackgroundProcess('Vtunnel Host Process', {
     spawn: async() => {
       const executable = path.join(paths.resources, 'win32', 'internal', 'vtunnel.exe');
@@ -47,14 +61,20 @@ class VTunnel {
 
   private async generateConfig() {
     const conf = {
-      tunnel: this._vtunnelConfig.map(c => ({
+      tunnel: this._vtunnelConfig.map(c => 'hostListenAddress' in c ? {
+        name:                    c.name,
+        'handshake-port':        c.handshakePort,
+        'host-listen-address':   c.hostListenAddress,
+        'vsock-peer-port':       c.vsockPeerPort,
+        'peer-upstream-address': c.peerUpstreamAddress,
+      } : {
         name:                      c.name,
         'handshake-port':          c.handshakePort,
         'vsock-host-port':         c.vsockHostPort,
         'peer-address':            c.peerAddress,
         'peer-port':               c.peerPort,
         'upstream-server-address': c.upstreamServerAddress,
-      })),
+      }),
     };
 
     const configYaml = yaml.stringify(conf);
@@ -65,10 +85,17 @@ class VTunnel {
   /**
    * addTunnel adds a new configuration to an existing list of configs.
    */
-  addTunnel(config: VtunnelConfig) {
+  addTunnel(config: VtunnelConfig | VtunnelToVMConfig) {
     this._vtunnelConfig.push(config);
   }
 
+  /**
+   * removeTunnels removes the configurations whose name starts with the prefix.
+   */
+  removeTunnels(namePrefix: string) {
+    this._vtunnelConfig = this._vtunnelConfig.filter(c => !c.name.startsWith(namePrefix));
+  }
+
   /**
    * start generates the final configuration yaml file and starts the
    * Vtunnel Host process.
@@ -0,0 +1,254 @@
+/**
+ * This module turns the processes the kernel of the VM kills for running out
+ * of memory, and the resource pressure of the VM, into notifications and
+ * events, naming the container or the pod affected; they are read from the
+ * resource-monitor script running in the VM.
+ */
+
+import { ChildProcess } from 'child_process';
+import readline from 'readline';
+
+import Electron from 'electron';
+
+import RESOURCE_MONITOR_SCRIPT from '@pkg/assets/scripts/resource-monitor';
+import type { VMExecutor } from '@pkg/backend/backend';
+import type { ContainerEngineClient } from '@pkg/backend/containerClient';
+import mainEvents from '@pkg/main/mainEvents';
+import Logging from '@pkg/utils/logging';
+
+const console = Logging.background;
+
+/** The share of the time tasks must stall on a resource for its pressure to be high. */
+export const HIGH_PRESSURE = 50;
+
+/** The share under which high pressure is over; lower, so that it doesn't flap. */
+export const NORMAL_PRESSURE = 25;
+
+/** A process the kernel killed for running out of memory. */
+export interface OOMKill {
+  /** The name of the process. */
+  process: string;
+  pid: number;
+  /** The cgroup of the process, e.g. /kubepods/burstable/pod<UID>/<ID>. */
+  cgroup: string;
+}
+
+/** The container of a process, as identified by its cgroup. */
+export interface ContainerRef {
+  id: string;
+  /** The containerd namespace of the container, if it isn't a moby one. */
+  namespace?: string;
+}
+
+/** A container affected by an event, as reported in the events. */
+export interface ContainerInfo extends ContainerRef {
+  name?: string;
+  /** The pod of the container, as NAMESPACE/NAME. */
+  pod?: string;
+}
+
+/** A change of the pressure on a resource of the VM. */
+export interface PressureChange {
+  resource: string;
+  high: boolean;
+  /** The share of the last 10 seconds some tasks stalled on the resource. */
+  some: number;
+  /** The share of the last 10 seconds all tasks stalled on the resource. */
+  full: number;
+}
+
+/**
+ * Parse the oom-kill record of the kernel, e.g.
+ * "oom-kill:constraint=CONSTRAINT_MEMCG,...,task_memcg=/default/ID,task=node,pid=1234,uid=0".
+ */
+export function parseOOMKill(record: string): OOMKill | undefined {
+  if (!record.startsWith('oom-kill:')) {
+    return undefined;
+  }
+  const fields: Record<string, string> = {};
+
+  for (const field of record.substring('oom-kill:'.length).split(',')) {
+    const [key, ...value] = field.split('=');
+
+    fields[key] = value.join('=');
+  }
+  if (!fields.task || !fields.pid) {
+    return undefined;
+  }
+
+  return {
+    process: fields.task,
+    pid:     parseInt(fields.pid, 10),
+    cgroup:  fields.task_memcg ?? '',
+  };
+}
+
+/**
+ * Return the container a cgroup belongs to, for the cgroups of docker,
+ * nerdctl and Kubernetes, with either the cgroupfs or the systemd driver.
+ */
+export function containerFromCgroup(cgroup: string): ContainerRef | undefined {
+  const segments = cgroup.split('/').filter(segment => segment);
+  const last = (segments.pop() ?? '').replace(/^(?:docker|nerdctl|cri-containerd)-/, '').replace(/\.scope$/, '');
+
+  if (!/^[0-9a-f]{64}$/.test(last)) {
+    return undefined;
+  }
+  if (segments.some(segment => segment.startsWith('kubepods'))) {
+    return { id: last, namespace: 'k8s.io' };
+  }
+  if (segments.length === 1 && !['docker', 'system.slice'].includes(segments[0])) {
+    // nerdctl with cgroupfs puts the containers under their namespace.
+    return { id: last, namespace: segments[0] };
+  }
+
+  return { id: last };
+}
+
+/**
+ * PressureTracker reports when the pressure on a resource becomes high, and
+ * when it is over.
+ */
+export class PressureTracker {
+  protected high = new Set<string>();
+
+  /**
+   * Record the pressure on a resource, as reported by the monitor; returns
+   * the change, if the pressure became high or is over.
+   */
+  update(resource: string, some: number, full: number): PressureChange | undefined {
+    const wasHigh = this.high.has(resource);
+    const isHigh = wasHigh ? some >= NORMAL_PRESSURE : some >= HIGH_PRESSURE;
+
+    if (isHigh === wasHigh) {
+      return undefined;
+    }
+    if (isHigh) {
+      this.high.add(resource);
+    } else {
+      this.high.delete(resource);
+    }
+
+    return { resource, high: isHigh, some, full };
+  }
+}
+
+/**
+ * Return the name and the pod of the container, as the container engine
+ * knows them; the container may be gone already.
+ */
+async function describeContainer(client: ContainerEngineClient, ref: ContainerRef): Promise<ContainerInfo> {
+  try {
+    const { stdout } = await client.runClient(['container', 'inspect', ref.id], 'pipe', { namespace: ref.namespace });
+    const [details] = JSON.parse(stdout);
+    const labels: Record<string, string> = details?.Config?.Labels ?? {};
+    const podName = labels['io.kubernetes.pod.name'];
+    const info: ContainerInfo = { ...ref, name: labels['io.kubernetes.container.name'] ?? details?.Name?.replace(/^\//, '') };
+
+    if (podName) {
+      info.pod = `${ labels['io.kubernetes.pod.namespace'] ?? 'default' }/${ podName }`;
+    }
+
+    return info;
+  } catch (ex) {
+    console.debug(`Could not inspect container ${ ref.id }:`, ex);
+
+    return ref;
+  }
+}
+
+/** Describe the container for the notifications. */
+function containerDescription(container: ContainerInfo): string {
+  const name = container.name ?? container.id.substring(0, 12);
+
+  return container.pod ? `container ${ name } of pod ${ container.pod }` : `container ${ name }`;
+}
+
+/**
+ * ResourceMonitor runs the resource-monitor script in the VM while the
+ * backend is running, and relays what it reports.
+ */
+export class ResourceMonitor {
+  protected process: ChildProcess | undefined;
+  protected pressure = new PressureTracker();
+
+  /** Start monitoring the VM; any previous monitoring is stopped. */
+  start(executor: VMExecutor, client: ContainerEngineClient) {
+    this.stop();
+    const command = ['sh', '-c', RESOURCE_MONITOR_SCRIPT, 'resource-monitor'];
+    // Reading the kernel records needs root, which commands are on WSL.
+    const child = executor.backend === 'wsl' ? executor.spawn(...command) : executor.spawn({ root: true }, ...command);
+
+    this.process = child;
+    this.pressure = new PressureTracker();
+    child.on('exit', (code, signal) => {
+      if (this.process === child) {
+        console.log(`The resource monitor exited with ${ signal ?? code }`);
+        this.process = undefined;
+      }
+    });
+    if (child.stdout) {
+      readline.createInterface({ input: child.stdout }).on('line', (line) => {
+        this.handleLine(line, client).catch((ex) => {
+          console.error(`Failed to report ${ line }:`, ex);
+        });
+      });
+    }
+  }
+
+  /** Stop monitoring the VM. */
+  stop() {
+    this.process?.kill();
+    this.process = undefined;
+  }
+
+  protected async handleLine(line: string, client: ContainerEngineClient) {
+    const [kind, ...fields] = line.split(' ');
+
+    switch (kind) {
+    case 'oom': {
+      const kill = parseOOMKill(fields.join(' '));
+
+      if (!kill) {
+        return;
+      }
+      const ref = containerFromCgroup(kill.cgroup);
+      const container = ref ? await describeContainer(client, ref) : undefined;
+
+      console.log(`Out of memory: killed ${ kill.process } (${ kill.pid }) in ${ kill.cgroup || 'the VM' }`);
+      mainEvents.emit('resource-oom-kill', { ...kill, ...(container ? { container } : {}) });
+      new Electron.Notification({
+        title: 'Out of memory',
+        body:  `The process ${ kill.process } of ${ container ? containerDescription(container) : 'the VM' } was killed for running out of memory.`,
+      }).show();
+      break;
+    }
+    case 'pressure': {
+      const [resource, some, full] = fields;
+      const change = this.pressure.update(resource, parseFloat(some), parseFloat(full));
+
+      if (!change) {
+        return;
+      }
+      console.log(`The ${ resource } pressure of the VM is ${ change.high ? 'high' : 'over' }: ${ some }% some, ${ full }% full`);
+      mainEvents.emit('resource-pressure', change);
+      if (change.high) {
+        new Electron.Notification({
+          title: `High ${ resource } pressure`,
+          body:  `Processes in the VM stall on ${ resource } ${ Math.round(change.some) }% of the time; containers may be slow or get killed.`,
+        }).show();
+      }
+      break;
+    }
+    }
+  }
+}
+
+let monitor: ResourceMonitor | undefined;
+
+/** Return the ResourceMonitor of the application. */
+export function getResourceMonitor(): ResourceMonitor {
+  monitor ??= new ResourceMonitor();
+
+  return monitor;
+}
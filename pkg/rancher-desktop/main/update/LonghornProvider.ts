@@ -19,6 +19,14 @@ import getWSLVersion from '@pkg/utils/wslVersion';
 const console = Logging.update;
 const gCachePath = path.join(paths.cache, 'updater-longhorn.json');
 
+/**
+ * The release channel, i.e. the tag of the releases to pick from; any release
+ * if empty.
+ */
+let gChannel = '';
+/** Whether the next check must ignore the cached information. */
+let gCacheStale = false;
+
 /**
  * If Upgrade Responder doesn't have a requestIntervalInMinutes field (or if
  * it's zero), use this value instead.  Note that the server can still set it to
@@ -137,6 +145,8 @@ interface GitHubReleaseInfo {
 interface LonghornCache {
   /** The minimum time (in Unix epoch) we should next check for an update. */
   nextUpdateTime: number;
+  /** The release channel the release was picked from; any if missing. */
+  channel?: string;
   /**
    * Whether there is an unsupported version of Rancher Desktop that is
    * newer than the latest supported version.
@@ -216,6 +226,21 @@ export async function setHasQueuedUpdate(isQueued: boolean): Promise<void> {
   }
 }
 
+/** Return the release channel of the checks; empty for any release. */
+export function getUpdateChannel(): string {
+  return gChannel;
+}
+
+/**
+ * Select the release channel of the checks, e.g. for `rdctl update check
+ * --channel`; empty for any release. The next check ignores the cached
+ * information, so that it asks Upgrade Responder.
+ */
+export function setUpdateChannel(channel: string) {
+  gChannel = channel;
+  gCacheStale = true;
+}
+
 /**
  * Return the OS version of whatever platform we are running on.
  * Note that this is *not* the kernel version; it is the OS version,
@@ -267,7 +292,7 @@ export async function getWslVersionString(): Promise<string | undefined> {
  * Fetch info on available versions of Rancher Desktop, as well as other
  * things, from the Upgrade Responder server.
  */
-export async function queryUpgradeResponder(url: string, currentVersion: semver.SemVer): Promise<UpgradeResponderQueryResult> {
+export async function queryUpgradeResponder(url: string, currentVersion: semver.SemVer, channel = ''): Promise<UpgradeResponderQueryResult> {
   const requestPayload: UpgradeResponderRequestPayload = {
     appVersion: currentVersion.toString(),
     extraInfo:  {
@@ -299,7 +324,12 @@ export async function queryUpgradeResponder(url: string, currentVersion: semver.
 
   console.debug(`Upgrade Responder response:`, util.inspect(response, true, null));
 
-  const allVersions = response.versions;
+  // With a channel, only the releases tagged with it are candidates.
+  const allVersions = response.versions.filter(version => !channel || version.Tags?.includes(channel));
+
+  if (channel && allVersions.length === 0) {
+    throw newError(`Could not find any version in the channel ${ channel }`, 'ERR_UPDATER_LATEST_VERSION_NOT_FOUND');
+  }
 
   // If Upgrade Responder does not send the Supported field,
   // assume that the version is supported.
@@ -367,7 +397,7 @@ export default class LonghornProvider extends Provider<LonghornUpdateInfo> {
       const rawCache = await fs.promises.readFile(gCachePath, 'utf-8');
       const cache: LonghornCache = JSON.parse(rawCache);
 
-      if (cache.nextUpdateTime > Date.now()) {
+      if (!gCacheStale && cache.nextUpdateTime > Date.now() && (cache.channel ?? '') === gChannel) {
         return cache;
       }
     } catch (error) {
@@ -377,7 +407,10 @@ export default class LonghornProvider extends Provider<LonghornUpdateInfo> {
       }
     }
 
-    const queryResult = await queryUpgradeResponder(this.configuration.upgradeServer, this.updater.currentVersion);
+    const channel = gChannel;
+    const queryResult = await queryUpgradeResponder(this.configuration.upgradeServer, this.updater.currentVersion, channel);
+
+    gCacheStale = false;
     const { latest, unsupportedUpdateAvailable } = queryResult;
     const requestIntervalInMinutes = queryResult.requestIntervalInMinutes || defaultUpdateIntervalInMinutes;
     const requestIntervalInMs = requestIntervalInMinutes * 1000 * 60;
@@ -427,6 +460,7 @@ export default class LonghornProvider extends Provider<LonghornUpdateInfo> {
 
     const cache: LonghornCache = {
       nextUpdateTime: nextRequestTime,
+      ...(channel ? { channel } : {}),
       unsupportedUpdateAvailable,
       isInstallable:  false, // Always false, we'll update this later.
       release:        {
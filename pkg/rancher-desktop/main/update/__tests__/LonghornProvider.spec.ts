@@ -240,6 +240,31 @@ describe('queryUpgradeResponder', () => {
     expect(body.appVersion).toBe(appVersion);
   });
 
+  it('should only pick the versions of the channel', async() => {
+    jest.mocked(getWSLVersion).mockResolvedValue(standardMockedVersion);
+    jest.mocked(fetch as ()=>Promise<any>).mockResolvedValue({
+      json: () => Promise.resolve({
+        requestIntervalInMinutes: 100,
+        versions:                 [
+          {
+            Name:        'v1.2.3',
+            ReleaseDate: 'testreleasedate',
+            Tags:        ['v1.2.3', 'stable'],
+          },
+          {
+            Name:        'v1.3.0-rc.1',
+            ReleaseDate: 'testreleasedate',
+            Tags:        ['v1.3.0-rc.1', 'prerelease'],
+          },
+        ],
+      }),
+    });
+
+    await expect(queryUpgradeResponder('testurl', new semver.SemVer('v1.2.3'), 'stable')).resolves.toMatchObject({ latest: { Name: 'v1.2.3' } });
+    await expect(queryUpgradeResponder('testurl', new semver.SemVer('v1.2.3'), 'prerelease')).resolves.toMatchObject({ latest: { Name: 'v1.3.0-rc.1' } });
+    await expect(queryUpgradeResponder('testurl', new semver.SemVer('v1.2.3'), 'nightly')).rejects.toThrow('Could not find any version in the channel nightly');
+  });
+
   describeWindows('when we can get WSL version', () => {
     it('should include wslVersion when using store WSL', async() => {
       jest.mocked(getWSLVersion).mockResolvedValue(standardMockedVersion);
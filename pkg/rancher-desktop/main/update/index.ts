@@ -14,7 +14,9 @@ import {
 import { ElectronAppAdapter } from 'electron-updater/out/ElectronAppAdapter';
 import yaml from 'yaml';
 
-import LonghornProvider, { hasQueuedUpdate, LonghornUpdateInfo, setHasQueuedUpdate } from './LonghornProvider';
+import LonghornProvider, {
+  getUpdateChannel, hasQueuedUpdate, LonghornUpdateInfo, setHasQueuedUpdate, setUpdateChannel,
+} from './LonghornProvider';
 import MsiUpdater from './MSIUpdater';
 
 import { Settings } from '@pkg/config/settings';
@@ -61,6 +63,31 @@ const updateState: UpdateState = {
   configured: false, available: false, downloaded: false,
 };
 
+/** The download of the update in progress, if any. */
+let download: Promise<unknown> | undefined;
+
+/**
+ * UpdateStatus is the state of the updater, as reported to clients of the API
+ * such as `rdctl update`.
+ */
+export type UpdateStatus = {
+  /** Whether this installation can be updated. */
+  configured: boolean;
+  /** The release channel of the checks; empty for any release. */
+  channel: string;
+  available: boolean;
+  downloading: boolean;
+  downloaded: boolean;
+  /** The latest release found by the last check. */
+  version?: string;
+  releaseDate?: string;
+  /** Whether a newer release exists, which isn't supported on this host. */
+  unsupportedUpdateAvailable?: boolean;
+  /** The progress of the download. */
+  progress?: Pick<ProgressInfo, 'percent' | 'transferred' | 'total' | 'bytesPerSecond'>;
+  error?: string;
+};
+
 Electron.ipcMain.on('update-state', () => {
   window.send('update-state', updateState);
 });
@@ -72,6 +99,22 @@ Electron.ipcMain.on('update-apply', () => {
   autoUpdater.quitAndInstall();
 });
 
+/** Keep track of the download of an update, until it finishes. */
+function trackDownload(promise: Promise<unknown> | null | undefined) {
+  if (!promise) {
+    return;
+  }
+  const done = () => {
+    if (download === promise) {
+      download = undefined;
+    }
+  };
+
+  download = promise;
+  // Errors are reported by the error event.
+  promise.then(done, done);
+}
+
 function isLonghornUpdateInfo(info: UpdateInfo | LonghornUpdateInfo): info is LonghornUpdateInfo {
   return (info as LonghornUpdateInfo).nextUpdateTime !== undefined;
 }
@@ -295,6 +338,7 @@ async function triggerUpdateCheck() {
       // App update is disabled (likely because the app is not packaged).
       return;
     }
+    trackDownload(result.downloadPromise);
 
     if (!isLonghornUpdateInfo(result.updateInfo)) {
       throw new Error('result.updateInfo is not of type LonghornUpdateInfo');
@@ -314,3 +358,76 @@ async function triggerUpdateCheck() {
   }
   updateTimer = timers.setTimeout(triggerUpdateCheck, updateInterval);
 }
+
+/** Return the state of the updater, for the API. */
+export function getUpdateStatus(): UpdateStatus {
+  const {
+    configured, available, downloaded, error, info, progress,
+  } = updateState;
+
+  return {
+    configured,
+    channel:     getUpdateChannel(),
+    available,
+    downloading: !!download,
+    downloaded,
+    ...(info ? {
+      version:                    info.version,
+      releaseDate:                info.releaseDate,
+      unsupportedUpdateAvailable: info.unsupportedUpdateAvailable,
+    } : {}),
+    ...(progress && download ? {
+      progress: {
+        percent:        progress.percent,
+        transferred:    progress.transferred,
+        total:          progress.total,
+        bytesPerSecond: progress.bytesPerSecond,
+      },
+    } : {}),
+    ...(error ? { error: error.message } : {}),
+  };
+}
+
+/**
+ * Check for updates now, in the given release channel; as with the periodic
+ * checks, an available update starts downloading.
+ * @precondition The updater is configured, and no update is being downloaded.
+ */
+export async function checkForUpdatesNow(channel: string): Promise<void> {
+  setUpdateChannel(channel);
+  updateState.error = undefined;
+  try {
+    const result = await autoUpdater.checkForUpdates();
+
+    trackDownload(result?.downloadPromise);
+  } catch (ex) {
+    console.error('Failed to check for updates:', ex);
+    updateState.error = ex as Error;
+  }
+}
+
+/**
+ * Start downloading the update found by the last check, unless it is being
+ * downloaded already.
+ * @precondition An update is available.
+ */
+export function downloadUpdate() {
+  if (!download) {
+    updateState.error = undefined;
+    trackDownload(autoUpdater.downloadUpdate());
+  }
+}
+
+/**
+ * Quit, install the downloaded update, and restart.
+ * @precondition The update has been downloaded.
+ */
+export function installUpdate() {
+  if (process.env.RD_FORCE_UPDATES_ENABLED) {
+    console.log('Not installing the update, as updates are forced for development.');
+
+    return;
+  }
+  // Let the caller reply before quitting.
+  timers.setImmediate(() => autoUpdater.quitAndInstall());
+}
@@ -25,6 +25,7 @@ export interface IpcMainEvents {
   'k8s-integrations': () => void;
   'k8s-integration-set': (name: string, newState: boolean) => void;
   'factory-reset': (keepSystemImages: boolean) => void;
+  'restart-safe-mode': () => void;
   'get-app-version': () => void;
   'update-network-status': (status: boolean) => void;
 
@@ -1,4 +1,6 @@
-import { imageInfo, parseImageReference } from '../dockerUtils';
+import { imageInfo, joinImageReference, parseImageReference } from '../dockerUtils';
+
+const digest = `sha256:${ '0123456789abcdef'.repeat(4) }`;
 
 describe('parseImageReference', () => {
   const dockerHub = new URL('https://index.docker.io');
@@ -8,11 +10,14 @@ describe('parseImageReference', () => {
     'dir/name':                         new imageInfo(dockerHub, 'dir/name'),
     'registry.test/thing':              new imageInfo(new URL('https://registry.test/'), 'thing' ),
     'registry.test:5000/org/thing:tag': new imageInfo(new URL('https://registry.test:5000/'), 'org/thing', 'tag'),
+    [`name@${ digest }`]:               new imageInfo(dockerHub, 'library/name', undefined, digest),
+    [`name:tag@${ digest }`]:           new imageInfo(dockerHub, 'library/name', 'tag', digest),
     _:                                  null,
     ':10/tag':                          null,
     [`xxx:${ Array(130).join('x') }`]:  null,
     'name:':                            null,
     'dir/':                             null,
+    'name@sha256:xyz':                  null,
     '':                                 null,
   };
 
@@ -35,3 +40,12 @@ describe('parseImageReference', () => {
     });
   });
 });
+
+describe('joinImageReference', () => {
+  test.each([
+    ['tag', 'name:tag'],
+    [digest, `name@${ digest }`],
+  ])('%s', (version, expected) => {
+    expect(joinImageReference('name', version)).toEqual(expected);
+  });
+});
@@ -16,11 +16,14 @@ export class imageInfo {
   name: string;
   /** Any tags (e.g. `latest`, `15.4`) */
   tag?: string;
+  /** Any digest the image is pinned to (e.g. `sha256:0123...`) */
+  digest?: string;
 
-  constructor(registry: URL, name: string, tag?: string) {
+  constructor(registry: URL, name: string, tag?: string, digest?: string) {
     this.registry = registry;
     this.name = name;
     this.tag = tag;
+    this.digest = digest;
   }
 
   /**
@@ -96,10 +99,17 @@ const { ImageNameRegExp, ImageNamePrefixRegExp } = (function() {
  */
 const ImageTagRegExp = /[\w][\w.-]{0,127}/;
 
+/**
+ * ImageDigestRegExp is a regular expression that matches a docker image digest
+ * (that is, only the bit after the at sign).
+ */
+const ImageDigestRegExp = /sha256:[0-9a-f]{64}/;
+
 const ImageRefRegExp = makeRE`
   ^
   ${ ImageNameRegExp }
   (?::(?<tag>${ ImageTagRegExp }))?
+  (?:@(?<digest>${ ImageDigestRegExp }))?
   $
   `;
 
@@ -142,7 +152,7 @@ export function parseImageReference(reference: string, prefix = false): imageInf
     name = `library/${ name }`;
   }
 
-  return new imageInfo(new URL(registry), name, result.groups['tag']);
+  return new imageInfo(new URL(registry), name, result.groups['tag'], result.groups['digest']);
 }
 
 /**
@@ -159,3 +169,18 @@ export function validateImageName(name: string): boolean {
 export function validateImageTag(tag: string): boolean {
   return makeRE`^${ ImageTagRegExp }$`.test(tag);
 }
+
+/**
+ * Check if a given string is a valid docker image digest.
+ */
+export function validateImageDigest(digest: string): boolean {
+  return makeRE`^${ ImageDigestRegExp }$`.test(digest);
+}
+
+/**
+ * Join an image name and a version, which is either a tag or a digest, into an
+ * image reference.
+ */
+export function joinImageReference(name: string, version: string): string {
+  return validateImageDigest(version) ? `${ name }@${ version }` : `${ name }:${ version }`;
+}
@@ -0,0 +1,42 @@
+import os from 'os';
+
+/**
+ * The share of the memory of the host the VM can have; more than that leaves
+ * too little for the host, and is only accepted when forced.
+ */
+export const MAX_MEMORY_FRACTION = 0.8;
+
+/**
+ * The capacity of the host, and the largest resources of the VM that are
+ * accepted without forcing them.
+ */
+export interface HostResources {
+  memoryInGB: number;
+  numberCPUs: number;
+  limits: {
+    memoryInGB: number;
+    numberCPUs: number;
+  };
+}
+
+/**
+ * The size of the swap space of the VM when the swap setting leaves it to be
+ * chosen: a quarter of the memory of the host, from 1 to 8 GB.
+ */
+export function defaultSwapSizeInGB(hostMemoryInGB: number): number {
+  return Math.min(8, Math.max(1, Math.floor(hostMemoryInGB / 4)));
+}
+
+export function getHostResources(): HostResources {
+  const memoryInGB = Math.ceil(os.totalmem() / 2 ** 30);
+  const numberCPUs = os.cpus().length;
+
+  return {
+    memoryInGB,
+    numberCPUs,
+    limits: {
+      memoryInGB: Math.max(1, Math.floor(os.totalmem() * MAX_MEMORY_FRACTION / 2 ** 30)),
+      numberCPUs,
+    },
+  };
+}
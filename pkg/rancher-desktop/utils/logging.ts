@@ -25,6 +25,10 @@ import stream from 'stream';
 import util from 'util';
 
 import paths from '@pkg/utils/paths';
+import { redactText } from '@pkg/utils/redact';
+
+/** The name of the audit log, which is kept across restarts; see main/audit. */
+export const AUDIT_LOG_NAME = 'audit.log';
 
 type consoleKey = 'log' | 'error' | 'info' | 'warn';
 type logLevel = 'debug' | 'info';
@@ -158,7 +162,9 @@ export class Log {
   }
 
   protected logWithDate(method: consoleKey, message: any, optionalParameters: any[]) {
-    this.console[method](`%s: ${ message }`, new Date(), ...optionalParameters);
+    const text = util.format(`%s: ${ message }`, new Date(), ...optionalParameters);
+
+    this.console[method]('%s', redactText(text));
   }
 
   async sync() {
@@ -204,7 +210,7 @@ export function clearLoggingDirectory(): void {
   const entries = fs.readdirSync(paths.logs, { withFileTypes: true });
 
   for (const entry of entries) {
-    if (entry.isFile() && entry.name.endsWith('.log')) {
+    if (entry.isFile() && entry.name.endsWith('.log') && entry.name !== AUDIT_LOG_NAME) {
       const topic = path.basename(entry.name, '.log');
 
       if (!logs.has(topic)) {
@@ -0,0 +1,65 @@
+/**
+ * Redaction of secrets (passwords, tokens, and registry credentials) from
+ * anything Rancher Desktop writes out: logs, API responses, and listings of
+ * the settings.
+ */
+
+import _ from 'lodash';
+
+/** The replacement for redacted values. */
+export const REDACTED = '********';
+
+/**
+ * Keys whose values are secrets: anything mentioning passwords, secrets,
+ * tokens, or credentials, and the `auth` entries of docker configs.
+ */
+const secretKeySource = String.raw`[\w.-]*(?:password|passwd|secret|token|credential)[\w.-]*|auth|identitytoken`;
+const secretKeyRE = new RegExp(`^(?:${ secretKeySource })$`, 'i');
+
+const secretTextREs = [
+  // KEY=VALUE, KEY: VALUE, and "KEY": "VALUE"
+  new RegExp(String.raw`(["']?\b(?:${ secretKeySource })["']?\s*[:=]\s*["']?)([^"'\s,;&}]+)`, 'gi'),
+  // Authorization headers
+  /(\bauthorization:?\s*(?:basic|bearer)\s+)(\S+)/gi,
+  // Passwords in URLs
+  /(\b[a-z][a-z0-9+.-]*:\/\/[^\s:@/]+:)([^\s@/]+)(?=@)/gi,
+];
+
+export function isSecretKey(key: string): boolean {
+  return secretKeyRE.test(key);
+}
+
+/**
+ * Returns a copy of the value, with all non-empty strings stored under secret
+ * keys replaced by REDACTED.
+ */
+export function redactSecrets<T>(value: T): T {
+  return _.cloneDeepWith(value, (item, key) => {
+    if (typeof key === 'string' && typeof item === 'string' && item !== '' && isSecretKey(key)) {
+      return REDACTED;
+    }
+  });
+}
+
+/**
+ * Replaces secrets in free-form text, such as log messages.
+ */
+export function redactText(text: string): string {
+  return secretTextREs.reduce((result, pattern) => result.replace(pattern, `$1${ REDACTED }`), text);
+}
+
+/**
+ * Drops the secrets in the changes that are still redacted, so that settings
+ * read with redacted secrets can be written back without overwriting them.
+ */
+export function dropRedacted<T>(changes: T): T {
+  if (Array.isArray(changes) || !_.isPlainObject(changes)) {
+    return changes;
+  }
+
+  return _.transform(changes as Record<string, any>, (result: Record<string, any>, value, key) => {
+    if (!(isSecretKey(key) && value === REDACTED)) {
+      result[key] = dropRedacted(value);
+    }
+  }, {}) as T;
+}
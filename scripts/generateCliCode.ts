@@ -82,11 +82,22 @@ interface commandFlagType {
 type yamlObject = any;
 
 type goTypeName = 'string' | 'bool' | 'int' | 'array';
-type goCmdFlagTypeName = 'String' | 'Bool' | 'Int' | 'Array';
+type goCmdFlagTypeName = 'String' | 'Bool' | 'Int' | 'StringArray';
 type typeValue = goTypeName | settingsTreeType | 'hash';
 type settingsTypeObject = { type: typeValue };
 type settingsTreeType = Record<string, settingsTypeObject>;
 
+/**
+ * Array settings that can be set from the command line. Other array settings,
+ * such as the allowed image patterns, are left to the API and to deployment
+ * profiles.
+ */
+const cliArraySettings = [
+  'containerEngine.environment',
+  'kubernetes.node.labels',
+  'kubernetes.node.taints',
+];
+
 function assert(predicate: boolean, error: string) {
   if (!predicate) {
     throw new Error(error);
@@ -203,6 +214,9 @@ class Generator {
       const onlyLineParts = [indent, capitalize(propertyName), ' '];
 
       if (typeWrapper.type === 'array') {
+        if (includeJSONTag) {
+          onlyLineParts.push('*');
+        }
         onlyLineParts.push('[]string');
       } else if (typeWrapper.type === 'hash') {
         onlyLineParts.push('map[string]interface{}');
@@ -232,8 +246,8 @@ class Generator {
       return `, strconv.Itoa(specifiedSettings.${ capitalizedName })`;
     case 'String':
       return `, specifiedSettings.${ capitalizedName }`;
-    case 'Array':
-      return '';
+    case 'StringArray':
+      return `+"="+arrayArgument(specifiedSettings.${ capitalizedName })`;
     }
   }
 
@@ -295,8 +309,7 @@ class Generator {
     case 'integer':
       return this.walkPropertyInteger(propertyName, preference, notAvailable, settingsTree);
     case 'array':
-      // not yet available
-      return this.walkPropertyArray(propertyName, preference, settingsTree);
+      return this.walkPropertyArray(propertyName, preference, notAvailable, settingsTree);
     default:
       throw new Error(`walkProperty: unexpected preference.type: '${ preference.type }'`);
     }
@@ -305,12 +318,13 @@ class Generator {
   protected walkPropertyArray(
     propertyName: string,
     preference: yamlObject,
+    notAvailable: boolean,
     settingsTree: settingsTreeType,
   ): void {
     this.updateLeaf(propertyName, capitalizeParts(propertyName),
-      'array', 'Array', 'nil',
+      'array', 'StringArray', 'nil',
       preference,
-      true,
+      notAvailable || !cliArraySettings.includes(propertyName),
       settingsTree);
   }
 
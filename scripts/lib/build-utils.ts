@@ -263,6 +263,50 @@ export default {
     return process.env.M1 ? 'arm64' : process.arch;
   },
 
+  /**
+   * Whether to keep the debug information of the go executables, when
+   * RD_GO_DEBUG is set; by default it is stripped, as it makes up a large part
+   * of their size, and some of them are copied into every WSL distribution.
+   */
+  get goDebug(): boolean {
+    return !!process.env.RD_GO_DEBUG;
+  },
+
+  /**
+   * Get the linker flags of the go executables.
+   * @param variables The string variables to set, by their fully qualified
+   *        names; the values must not contain spaces.
+   */
+  goLdFlags(variables: Record<string, string> = {}): string {
+    const flags = this.goDebug ? [] : ['-s', '-w'];
+
+    for (const [name, value] of Object.entries(variables)) {
+      flags.push('-X', `${ name }=${ value }`);
+    }
+
+    return flags.join(' ');
+  },
+
+  /**
+   * The version variables of rdctl, identifying the build.
+   */
+  get rdctlVersionVariables(): Record<string, string> {
+    const pkg = 'github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/version';
+    const variables: Record<string, string> = {
+      [`${ pkg }.Version`]:   this.packageMeta.version,
+      [`${ pkg }.BuildDate`]: new Date().toISOString().replace(/\.\d+Z$/, 'Z'),
+    };
+
+    try {
+      variables[`${ pkg }.Commit`] = childProcess.execFileSync('git', ['rev-parse', 'HEAD'], { cwd: this.rootDir, encoding: 'utf-8' }).trim();
+    } catch {
+      // Not building from a git checkout; rdctl falls back to the commit go
+      // embeds, if any.
+    }
+
+    return variables;
+  },
+
   /**
    * Build the WSL helper application for Windows.
    */
@@ -276,7 +320,7 @@ export default {
       const exeName = `${ exeRoot }${ platform === 'win32' ? '.exe' : '' }`;
       const outFile = path.join(this.rootDir, 'resources', platform, 'internal', exeName);
 
-      await this.spawn('go', 'build', '-ldflags', '-s -w', '-o', outFile, '.', {
+      await this.spawn('go', 'build', '-ldflags', this.goLdFlags(), '-o', outFile, '.', {
         cwd: path.join(this.rootDir, 'src', 'go', 'wsl-helper'),
         env: {
           ...process.env,
@@ -311,7 +355,7 @@ export default {
       outFile = path.join(parentDir, 'nerdctl-stub');
     }
     // The linux build produces both nerdctl-stub and nerdctl
-    await this.spawn('go', 'build', '-ldflags', '-s -w', '-o', outFile, '.', {
+    await this.spawn('go', 'build', '-ldflags', this.goLdFlags(), '-o', outFile, '.', {
       cwd: path.join(this.rootDir, 'src', 'go', 'nerdctl-stub'),
       env: {
         ...process.env,
@@ -331,7 +375,7 @@ export default {
       console.log('Building RDX proxying image...');
 
       // Build the golang executable
-      await this.spawn('go', 'build', '-ldflags', '-s -w', '-o', executablePath, '.', {
+      await this.spawn('go', 'build', '-ldflags', this.goLdFlags(), '-o', executablePath, '.', {
         cwd: path.join(this.rootDir, 'src', 'go', 'extension-proxy'),
         env: {
           ...process.env,
@@ -440,7 +484,9 @@ export default {
     const parentDir = path.join(this.rootDir, 'resources', platform, childDir);
     const outFile = path.join(parentDir, target);
 
-    await this.spawn('go', 'build', '-ldflags', '-s -w', '-o', outFile, '.', {
+    const variables = name === 'rdctl' ? this.rdctlVersionVariables : {};
+
+    await this.spawn('go', 'build', '-ldflags', this.goLdFlags(variables), '-o', outFile, '.', {
       cwd: path.join(this.rootDir, 'src', 'go', name),
       env: {
         ...process.env,
@@ -30,6 +30,10 @@ type configStruct struct {
 	Commands []commandEntry `json:"commands"`
 	Results  []bool         `json:"results,omitempty"`
 	Errors   []string       `json:"errors,omitempty"`
+
+	// Simulation, if given, handles the commands that match none of the
+	// commands above, and is updated with their effects.
+	Simulation *simulation `json:"simulation,omitempty"`
 }
 
 func writeFile(file *os.File, config *configStruct, errFmt string, v ...any) {
@@ -76,7 +80,7 @@ func main() {
 		log.Fatalf("Failed to unmarshal config file %s: %s", confPath, err)
 	}
 
-	if len(config.Commands) < 1 {
+	if len(config.Commands) < 1 && config.Simulation == nil {
 		writeFile(file, &config, "Could not find any commands")
 	}
 	if len(config.Results) < len(config.Commands) {
@@ -119,11 +123,26 @@ func main() {
 		}
 	}
 
+	if !matched && config.Simulation != nil {
+		if simulated, ok := config.Simulation.run(args); ok {
+			// wsl.exe writes its own messages in UTF-16 LE.
+			cmd = commandEntry{
+				Stdout:  simulated.stdout,
+				Stderr:  simulated.stderr,
+				UTF16LE: true,
+				Code:    simulated.code,
+			}
+			index = -1
+			matched = true
+		}
+	}
 	if !matched {
 		writeFile(file, &config, "Could not find command with args %s",
 			strings.Join(args, " "))
 	}
-	config.Results[index] = true
+	if index >= 0 {
+		config.Results[index] = true
+	}
 
 	encoding := unicode.UTF8
 	if cmd.UTF16LE {
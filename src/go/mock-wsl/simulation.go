@@ -0,0 +1,289 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+)
+
+const (
+	StateRunning = "Running"
+	StateStopped = "Stopped"
+)
+
+// Exit code of wsl.exe when it fails, as it exits with -1.
+const errorCode = -1
+
+// distribution is the simulated state of a WSL distribution.
+type distribution struct {
+	Name string `json:"name"`
+	// State is StateRunning or StateStopped; stopped if not given.
+	State string `json:"state,omitempty"`
+	// Version is the WSL version the distribution runs on; 2 if not given.
+	Version int  `json:"version,omitempty"`
+	Default bool `json:"default,omitempty"`
+	// BasePath is the directory holding the disk image of the distribution,
+	// if it was imported.
+	BasePath string `json:"basePath,omitempty"`
+}
+
+// simulation models the state of WSL, for the commands that don't match any
+// of the scripted commands.
+type simulation struct {
+	// Version is reported by `wsl --version`.
+	Version       string         `json:"version,omitempty"`
+	Distributions []distribution `json:"distributions"`
+}
+
+// result is the outcome of a simulated command.
+type result struct {
+	stdout string
+	stderr string
+	code   int
+}
+
+func failure(format string, v ...any) result {
+	return result{stderr: fmt.Sprintf(format, v...) + "\r\n", code: errorCode}
+}
+
+func (s *simulation) find(name string) *distribution {
+	for i := range s.Distributions {
+		if strings.EqualFold(s.Distributions[i].Name, name) {
+			return &s.Distributions[i]
+		}
+	}
+	return nil
+}
+
+func distroNotFound() result {
+	return failure("There is no distribution with the supplied name.\r\nError code: Wsl/Service/WSL_E_DISTRO_NOT_FOUND")
+}
+
+// run simulates the command, updating the state; it returns false if the
+// command isn't simulated.
+func (s *simulation) run(args []string) (result, bool) {
+	if len(args) == 0 {
+		return result{}, false
+	}
+	switch args[0] {
+	case "--list", "-l":
+		return s.list(args[1:])
+	case "--version":
+		if len(args) != 1 {
+			return result{}, false
+		}
+		version := s.Version
+		if version == "" {
+			version = "2.0.9.0"
+		}
+		return result{stdout: fmt.Sprintf("WSL version: %s\r\n", version)}, true
+	case "--terminate", "-t":
+		if len(args) != 2 {
+			return result{}, false
+		}
+		distro := s.find(args[1])
+		if distro == nil {
+			return distroNotFound(), true
+		}
+		distro.State = StateStopped
+		return result{stdout: "The operation completed successfully. \r\n"}, true
+	case "--shutdown":
+		if len(args) != 1 {
+			return result{}, false
+		}
+		for i := range s.Distributions {
+			s.Distributions[i].State = StateStopped
+		}
+		return result{}, true
+	case "--unregister":
+		if len(args) != 2 {
+			return result{}, false
+		}
+		return s.unregister(args[1]), true
+	case "--import":
+		return s.importDistro(args[1:])
+	case "--export":
+		if len(args) != 3 {
+			return result{}, false
+		}
+		return s.export(args[1], args[2]), true
+	case "--set-default", "-s":
+		if len(args) != 2 {
+			return result{}, false
+		}
+		distro := s.find(args[1])
+		if distro == nil {
+			return distroNotFound(), true
+		}
+		for i := range s.Distributions {
+			s.Distributions[i].Default = false
+		}
+		distro.Default = true
+		return result{stdout: "The operation completed successfully. \r\n"}, true
+	case "--set-version":
+		if len(args) != 3 || (args[2] != "1" && args[2] != "2") {
+			return result{}, false
+		}
+		distro := s.find(args[1])
+		if distro == nil {
+			return distroNotFound(), true
+		}
+		distro.Version = int(args[2][0] - '0')
+		return result{stdout: "The operation completed successfully. \r\n"}, true
+	case "--distribution", "-d":
+		// Running a command starts the distribution; the commands themselves
+		// must be scripted.
+		if len(args) < 2 {
+			return result{}, false
+		}
+		distro := s.find(args[1])
+		if distro == nil {
+			return distroNotFound(), true
+		}
+		distro.State = StateRunning
+		return result{}, true
+	}
+	return result{}, false
+}
+
+func (s *simulation) list(args []string) (result, bool) {
+	var quiet, verbose, running bool
+	for _, arg := range args {
+		switch arg {
+		case "--quiet", "-q":
+			quiet = true
+		case "--verbose", "-v":
+			verbose = true
+		case "--running":
+			running = true
+		default:
+			return result{}, false
+		}
+	}
+	var distros []distribution
+	for _, distro := range s.Distributions {
+		if !running || distro.State == StateRunning {
+			distros = append(distros, distro)
+		}
+	}
+	if len(distros) == 0 {
+		if running {
+			return failure("There are no running distributions."), true
+		}
+		return failure("Windows Subsystem for Linux has no installed distributions."), true
+	}
+	var output strings.Builder
+	switch {
+	case quiet:
+		for _, distro := range distros {
+			fmt.Fprintf(&output, "%s\r\n", distro.Name)
+		}
+	case verbose:
+		writer := tabwriter.NewWriter(&output, 0, 4, 2, ' ', 0)
+		fmt.Fprint(writer, "  NAME\tSTATE\tVERSION\r\n")
+		for _, distro := range distros {
+			marker := " "
+			if distro.Default {
+				marker = "*"
+			}
+			fmt.Fprintf(writer, "%s %s\t%s\t%d\r\n", marker, distro.Name, distro.state(), distro.version())
+		}
+		_ = writer.Flush()
+	default:
+		output.WriteString("Windows Subsystem for Linux Distributions:\r\n")
+		for _, distro := range distros {
+			if distro.Default {
+				fmt.Fprintf(&output, "%s (Default)\r\n", distro.Name)
+			} else {
+				fmt.Fprintf(&output, "%s\r\n", distro.Name)
+			}
+		}
+	}
+	return result{stdout: output.String()}, true
+}
+
+func (s *simulation) unregister(name string) result {
+	for i, distro := range s.Distributions {
+		if strings.EqualFold(distro.Name, name) {
+			if distro.BasePath != "" {
+				_ = os.Remove(filepath.Join(distro.BasePath, "ext4.vhdx"))
+			}
+			s.Distributions = append(s.Distributions[:i], s.Distributions[i+1:]...)
+			return result{stdout: "Unregistering.\r\nThe operation completed successfully. \r\n"}
+		}
+	}
+	return distroNotFound()
+}
+
+// importDistro simulates `wsl --import <name> <location> <file> [--version N]`;
+// the disk image of the distribution is a copy of the file.
+func (s *simulation) importDistro(args []string) (result, bool) {
+	version := 2
+	switch {
+	case len(args) == 5 && args[3] == "--version" && (args[4] == "1" || args[4] == "2"):
+		version = int(args[4][0] - '0')
+	case len(args) != 3:
+		return result{}, false
+	}
+	name, location, file := args[0], args[1], args[2]
+	if s.find(name) != nil {
+		return failure("A distribution with the supplied name already exists.\r\nError code: Wsl/Service/RegisterDistro/ERROR_ALREADY_EXISTS"), true
+	}
+	contents, err := os.ReadFile(file)
+	if err != nil {
+		return failure("The system cannot find the file specified.\r\nError code: Wsl/Service/RegisterDistro/ERROR_FILE_NOT_FOUND"), true
+	}
+	if err := os.MkdirAll(location, 0o755); err != nil {
+		return failure("%s", err), true
+	}
+	if err := os.WriteFile(filepath.Join(location, "ext4.vhdx"), contents, 0o644); err != nil {
+		return failure("%s", err), true
+	}
+	hasDefault := false
+	for _, distro := range s.Distributions {
+		hasDefault = hasDefault || distro.Default
+	}
+	s.Distributions = append(s.Distributions, distribution{
+		Name:     name,
+		State:    StateStopped,
+		Version:  version,
+		Default:  !hasDefault,
+		BasePath: location,
+	})
+	return result{stdout: "Import in progress, this may take a few minutes.\r\nThe operation completed successfully. \r\n"}, true
+}
+
+// export simulates `wsl --export <name> <file>`, writing a placeholder for
+// the contents of the distribution that can be imported again.
+func (s *simulation) export(name, file string) result {
+	distro := s.find(name)
+	if distro == nil {
+		return distroNotFound()
+	}
+	contents := fmt.Sprintf("mock-wsl export of %s\n", distro.Name)
+	if distro.BasePath != "" {
+		if image, err := os.ReadFile(filepath.Join(distro.BasePath, "ext4.vhdx")); err == nil {
+			contents = string(image)
+		}
+	}
+	if err := os.WriteFile(file, []byte(contents), 0o644); err != nil {
+		return failure("%s", err)
+	}
+	return result{stdout: "Export in progress, this may take a few minutes.\r\nThe operation completed successfully. \r\n"}
+}
+
+func (d distribution) state() string {
+	if d.State == "" {
+		return StateStopped
+	}
+	return d.State
+}
+
+func (d distribution) version() int {
+	if d.Version == 0 {
+		return 2
+	}
+	return d.Version
+}
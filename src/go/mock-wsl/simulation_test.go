@@ -0,0 +1,99 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func runSimulation(t *testing.T, s *simulation, args ...string) result {
+	t.Helper()
+	r, ok := s.run(args)
+	if !ok {
+		t.Fatalf("wsl %v was not simulated", args)
+	}
+	return r
+}
+
+func TestSimulation(t *testing.T) {
+	s := &simulation{Distributions: []distribution{
+		{Name: "Ubuntu", State: StateRunning, Default: true},
+		{Name: "rancher-desktop", Version: 2},
+	}}
+
+	if r := runSimulation(t, s, "--list", "--quiet"); r.stdout != "Ubuntu\r\nrancher-desktop\r\n" {
+		t.Errorf("unexpected distributions %q", r.stdout)
+	}
+	expected := "  NAME             STATE    VERSION\r\n" +
+		"* Ubuntu           Running  2\r\n" +
+		"  rancher-desktop  Stopped  2\r\n"
+	if r := runSimulation(t, s, "-l", "-v"); r.stdout != expected {
+		t.Errorf("unexpected verbose list %q", r.stdout)
+	}
+	if r := runSimulation(t, s, "--list", "--running", "--quiet"); r.stdout != "Ubuntu\r\n" {
+		t.Errorf("unexpected running distributions %q", r.stdout)
+	}
+
+	runSimulation(t, s, "--terminate", "Ubuntu")
+	if r := runSimulation(t, s, "--list", "--running", "--quiet"); r.code != errorCode {
+		t.Errorf("distributions still running: %q", r.stdout)
+	}
+	if r := runSimulation(t, s, "--terminate", "missing"); r.code != errorCode {
+		t.Errorf("terminated a missing distribution")
+	}
+	runSimulation(t, s, "--distribution", "rancher-desktop", "--exec", "true")
+	if r := runSimulation(t, s, "--list", "--running", "--quiet"); r.stdout != "rancher-desktop\r\n" {
+		t.Errorf("running a command didn't start the distribution: %q", r.stdout)
+	}
+	runSimulation(t, s, "--shutdown")
+	if s.find("rancher-desktop").State != StateStopped {
+		t.Errorf("the distribution kept running after shutdown")
+	}
+
+	if _, ok := s.run([]string{"--install"}); ok {
+		t.Errorf("unknown command simulated")
+	}
+}
+
+func TestSimulationImportExport(t *testing.T) {
+	dir := t.TempDir()
+	s := &simulation{Distributions: []distribution{}}
+	if r := runSimulation(t, s, "--list", "--quiet"); r.code != errorCode {
+		t.Errorf("listed distributions when there are none: %q", r.stdout)
+	}
+
+	tarball := filepath.Join(dir, "distro.tar")
+	if err := os.WriteFile(tarball, []byte("contents"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	location := filepath.Join(dir, "distro")
+	if r := runSimulation(t, s, "--import", "rancher-desktop", location, tarball, "--version", "2"); r.code != 0 {
+		t.Fatalf("import failed: %q", r.stderr)
+	}
+	if r := runSimulation(t, s, "--import", "rancher-desktop", location, tarball); r.code != errorCode {
+		t.Errorf("imported a distribution twice")
+	}
+	distro := s.find("rancher-desktop")
+	if distro == nil || !distro.Default || distro.BasePath != location {
+		t.Fatalf("unexpected imported distribution %+v", distro)
+	}
+
+	exported := filepath.Join(dir, "exported.tar")
+	if r := runSimulation(t, s, "--export", "rancher-desktop", exported); r.code != 0 {
+		t.Fatalf("export failed: %q", r.stderr)
+	}
+	if contents, err := os.ReadFile(exported); err != nil || string(contents) != "contents" {
+		t.Errorf("unexpected export %q (%v)", contents, err)
+	}
+
+	runSimulation(t, s, "--unregister", "rancher-desktop")
+	if len(s.Distributions) != 0 {
+		t.Errorf("distribution not unregistered")
+	}
+	if _, err := os.Stat(filepath.Join(location, "ext4.vhdx")); !os.IsNotExist(err) {
+		t.Errorf("disk image left behind: %v", err)
+	}
+	if r := runSimulation(t, s, "--unregister", "rancher-desktop"); r.code != errorCode {
+		t.Errorf("unregistered a missing distribution")
+	}
+}
@@ -18,12 +18,9 @@ type spawnOptions struct {
 
 func main() {
 	opts := spawnOptions{
-		distro:  os.Getenv("RD_WSL_DISTRO"),
+		distro:  distroName(),
 		nerdctl: os.Getenv("RD_NERDCTL"),
 	}
-	if opts.distro == "" {
-		opts.distro = "rancher-desktop"
-	}
 	if opts.nerdctl == "" {
 		opts.nerdctl = "/usr/local/bin/nerdctl"
 	}
@@ -50,3 +47,11 @@ func main() {
 		log.Fatal(err)
 	}
 }
+
+// distroName returns the name of the WSL distribution for rancher-desktop.
+func distroName() string {
+	if distro := os.Getenv("RD_WSL_DISTRO"); distro != "" {
+		return distro
+	}
+	return "rancher-desktop"
+}
@@ -117,6 +117,7 @@ func cleanupParseArgs() error {
 // doBindMount does the meat of the bind mounting.  Given a path, it makes a
 // mount inside workdir and returns the mounted path.
 func doBindMount(sourcePath string) (string, error) {
+	sourcePath = checkPathCase(sourcePath, caseModeFromEnv())
 	info, err := os.Stat(sourcePath)
 	if err != nil {
 		return "", fmt.Errorf("could not stat %s: %w", sourcePath, err)
@@ -55,14 +55,8 @@ func pathToWSL(arg string) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	slashPath := filepath.ToSlash(absPath)
-	vol := filepath.VolumeName(absPath)
-	if len(vol) > 0 && vol[len(vol)-1] == ':' {
-		volName := strings.ToLower(vol[:len(vol)-1])
-		return "/mnt/" + volName + slashPath[len(vol):], nil
-	}
-	// volume name is not what we expected
-	return slashPath, nil
+	absPath = checkPathCase(absPath, caseModeFromEnv())
+	return windowsPathToWSL(absPath, distroName())
 }
 
 // volumeArgHandler handles the argument for `nerdctl run --volume=...`
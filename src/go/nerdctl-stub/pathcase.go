@@ -0,0 +1,101 @@
+// This file contains the detection of host paths that differ only by case
+// from the files they refer to. Windows file systems ignore case, but the
+// paths are case sensitive once they are mounted into containers, so such
+// paths lead to files not being found there.
+
+package main
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// caseMismatchEnv selects what to do about host paths that differ by case
+// from the files they refer to.
+const caseMismatchEnv = "RD_NERDCTL_CASE_MISMATCH"
+
+type caseMode string
+
+const (
+	// caseWarn logs a warning, and uses the path as given.
+	caseWarn caseMode = "warn"
+	// caseFix uses the path with the case of the files.
+	caseFix caseMode = "fix"
+	// caseIgnore uses the path as given, without checking it.
+	caseIgnore caseMode = "ignore"
+)
+
+// caseModeFromEnv returns the mode set in the environment; it warns by
+// default.
+func caseModeFromEnv() caseMode {
+	switch mode := caseMode(strings.ToLower(os.Getenv(caseMismatchEnv))); mode {
+	case caseFix, caseIgnore:
+		return mode
+	case caseWarn, "":
+	default:
+		log.Printf("Ignoring unknown %s=%s; expected %s, %s or %s", caseMismatchEnv, mode, caseWarn, caseFix, caseIgnore)
+	}
+	return caseWarn
+}
+
+// checkPathCase returns the path to use for the host path in the given mode.
+func checkPathCase(path string, mode caseMode) string {
+	if mode == caseIgnore {
+		return path
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	actual := actualPathCase(absPath)
+	if actual == absPath {
+		return path
+	}
+	if mode == caseFix {
+		return actual
+	}
+	log.Printf("Warning: %s differs in case from %s; files may not be found in the container (set %s=%s to use the latter)",
+		path, actual, caseMismatchEnv, caseFix)
+	return path
+}
+
+// actualPathCase returns the absolute path with the case of the existing
+// files and directories it refers to. The components that don't exist, or
+// that match several entries that differ only by case, are kept as they are.
+func actualPathCase(path string) string {
+	volume := filepath.VolumeName(path)
+	rest := strings.TrimLeft(path[len(volume):], string(filepath.Separator))
+	if rest == "" {
+		return path
+	}
+	dir := volume + string(filepath.Separator)
+	components := strings.Split(rest, string(filepath.Separator))
+	for i, component := range components {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return filepath.Join(append([]string{dir}, components[i:]...)...)
+		}
+		match := ""
+		for _, entry := range entries {
+			if entry.Name() == component {
+				match = component
+				break
+			}
+			if strings.EqualFold(entry.Name(), component) {
+				if match != "" {
+					// Ambiguous; keep the component as it is.
+					match = component
+				} else {
+					match = entry.Name()
+				}
+			}
+		}
+		if match == "" {
+			return filepath.Join(append([]string{dir}, components[i:]...)...)
+		}
+		dir = filepath.Join(dir, match)
+	}
+	return dir
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActualPathCase(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "Project", "Src"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Project", "Src", "Main.go"), nil, 0o644))
+
+	cases := map[string]string{
+		filepath.Join(dir, "Project", "Src"):                filepath.Join(dir, "Project", "Src"),
+		filepath.Join(dir, "project", "SRC", "main.go"):     filepath.Join(dir, "Project", "Src", "Main.go"),
+		filepath.Join(dir, "project", "missing", "Main.go"): filepath.Join(dir, "Project", "missing", "Main.go"),
+	}
+	for input, expected := range cases {
+		assert.Equal(t, expected, actualPathCase(input), input)
+	}
+
+	t.Run("ambiguous", func(t *testing.T) {
+		if err := os.Mkdir(filepath.Join(dir, "project"), 0o755); err != nil {
+			t.Skip("the file system ignores case")
+		}
+		assert.Equal(t, filepath.Join(dir, "project"), actualPathCase(filepath.Join(dir, "project")))
+		assert.Equal(t, filepath.Join(dir, "PROJECT"), actualPathCase(filepath.Join(dir, "PROJECT")))
+	})
+}
+
+func TestCheckPathCase(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "Project"), 0o755))
+	input := filepath.Join(dir, "project")
+	assert.Equal(t, input, checkPathCase(input, caseWarn))
+	assert.Equal(t, input, checkPathCase(input, caseIgnore))
+	assert.Equal(t, filepath.Join(dir, "Project"), checkPathCase(input, caseFix))
+
+	t.Setenv(caseMismatchEnv, "FIX")
+	assert.Equal(t, caseFix, caseModeFromEnv())
+	t.Setenv(caseMismatchEnv, "bogus")
+	assert.Equal(t, caseWarn, caseModeFromEnv())
+}
@@ -0,0 +1,48 @@
+// This file contains the conversion of Windows paths to WSL paths; it doesn't
+// depend on the platform, so that it can be tested anywhere.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// windowsPathToWSL converts an absolute Windows path to the path of the same
+// file in the WSL distribution. Besides paths on drives (C:\foo), it accepts
+// long paths (\\?\C:\foo), and UNC paths of the distribution itself
+// (\\wsl$\<distro>\foo or \\wsl.localhost\<distro>\foo); other UNC paths are
+// network shares, which aren't mounted in WSL.
+func windowsPathToWSL(path, distro string) (string, error) {
+	path = strings.ReplaceAll(path, "/", `\`)
+	switch {
+	case len(path) >= 8 && strings.EqualFold(path[:8], `\\?\UNC\`):
+		path = `\\` + path[8:]
+	case strings.HasPrefix(path, `\\?\`), strings.HasPrefix(path, `\\.\`):
+		path = path[4:]
+	}
+	if len(path) >= 2 && path[1] == ':' && isDriveLetter(path[0]) {
+		return "/mnt/" + strings.ToLower(path[:1]) + strings.ReplaceAll(path[2:], `\`, "/"), nil
+	}
+	if !strings.HasPrefix(path, `\\`) {
+		// Not an absolute path we know of; leave it alone.
+		return strings.ReplaceAll(path, `\`, "/"), nil
+	}
+	parts := strings.SplitN(path[2:], `\`, 3)
+	if len(parts) >= 2 && isWSLHost(parts[0]) && strings.EqualFold(parts[1], distro) {
+		if len(parts) == 2 {
+			return "/", nil
+		}
+		return "/" + strings.ReplaceAll(parts[2], `\`, "/"), nil
+	}
+	return "", fmt.Errorf("network path %s can't be used from WSL; map it to a drive letter first", path)
+}
+
+func isDriveLetter(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+// isWSLHost returns whether UNC paths on the host are in WSL distributions.
+func isWSLHost(host string) bool {
+	return strings.EqualFold(host, "wsl$") || strings.EqualFold(host, "wsl.localhost")
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWindowsPathToWSL(t *testing.T) {
+	t.Parallel()
+	cases := map[string]string{
+		`C:\Users\me\project`:                        "/mnt/c/Users/me/project",
+		`d:/src/My Project`:                          "/mnt/d/src/My Project",
+		`C:\`:                                        "/mnt/c/",
+		`\\?\C:\Users\me\node_modules\a`:             "/mnt/c/Users/me/node_modules/a",
+		`\\.\D:\data`:                                "/mnt/d/data",
+		`\\wsl$\rancher-desktop\tmp\x`:               "/tmp/x",
+		`\\wsl.localhost\Rancher-Desktop`:            "/",
+		`\\?\UNC\wsl.localhost\rancher-desktop\root`: "/root",
+		`/already/unix`:                              "/already/unix",
+	}
+	for input, expected := range cases {
+		input, expected := input, expected
+		t.Run(input, func(t *testing.T) {
+			t.Parallel()
+			actual, err := windowsPathToWSL(input, "rancher-desktop")
+			assert.NoError(t, err)
+			assert.Equal(t, expected, actual)
+		})
+	}
+	for _, input := range []string{
+		`\\server\share\project`,
+		`\\?\UNC\server\share\project`,
+		`\\wsl$\Ubuntu\home\me`,
+	} {
+		input := input
+		t.Run(input, func(t *testing.T) {
+			t.Parallel()
+			_, err := windowsPathToWSL(input, "rancher-desktop")
+			assert.ErrorContains(t, err, "network path")
+		})
+	}
+}
@@ -27,7 +27,10 @@ var installCmd = &cobra.Command{
 	Use:   "install",
 	Short: "installs the Rancher Desktop Privileged Service",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return manage.InstallService(svcName, displayName, svcDesc)
+		if err := manage.InstallService(svcName, displayName, svcDesc); err != nil {
+			return err
+		}
+		return manage.InstallEventSource(wslHelperEventSource)
 	},
 }
 
@@ -17,10 +17,14 @@ limitations under the License.
 package cmd
 
 import (
+	"fmt"
 	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
+	"golang.org/x/sys/windows"
 	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/eventlog"
 
 	rancherDesktopSvc "github.com/rancher-sandbox/rancher-desktop/src/go/privileged-service/pkg/svc"
 )
@@ -29,6 +33,11 @@ const svcName = "RancherDesktopPrivilegedService"
 const displayName = "Rancher Desktop Privileged Service"
 const svcDesc = "Privileged process management service for Rancher Desktop"
 
+// wslHelperEventSource is the event source wsl-helper logs under with
+// --system-log (systemlog.Source of wsl-helper); it is registered along with
+// the service, as registering it needs administrative privileges.
+const wslHelperEventSource = "RancherDesktopWSLHelper"
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "privileged-service",
@@ -52,6 +61,28 @@ var rootCmd = &cobra.Command{
 func Execute() {
 	err := rootCmd.Execute()
 	if err != nil {
+		if systemLog {
+			logFailure(err)
+		}
 		os.Exit(1)
 	}
 }
+
+// systemLog sends failures of the management commands to the Event Log; the
+// service itself always logs there.
+var systemLog bool
+
+func init() {
+	rootCmd.PersistentFlags().BoolVar(&systemLog, "system-log", false, "also send failures to the Windows Event Log")
+}
+
+// logFailure writes the error to the Event Log, under the event source that
+// is registered when the service is installed.
+func logFailure(err error) {
+	elog, openErr := eventlog.Open(svcName)
+	if openErr != nil {
+		return
+	}
+	defer elog.Close()
+	_ = elog.Error(uint32(windows.ERROR_EXCEPTION_IN_SERVICE), fmt.Sprintf("%s %s failed: %v", svcName, strings.Join(os.Args[1:], " "), err))
+}
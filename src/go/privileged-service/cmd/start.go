@@ -18,8 +18,10 @@ package cmd
 
 import (
 	"github.com/spf13/cobra"
+	"golang.org/x/sys/windows/svc"
 
 	"github.com/rancher-sandbox/rancher-desktop/src/go/privileged-service/pkg/manage"
+	rancherDesktopSvc "github.com/rancher-sandbox/rancher-desktop/src/go/privileged-service/pkg/svc"
 )
 
 // startCmd represents the start command
@@ -27,10 +29,23 @@ var startCmd = &cobra.Command{
 	Use:   "start",
 	Short: "starts the Rancher Desktop Privileged Service",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return manage.StartService(svcName)
+		if err := manage.StartService(svcName); err != nil {
+			return err
+		}
+		// The setting is sent as a control, rather than as a start argument,
+		// so that it also reaches a service that was already running.
+		control := rancherDesktopSvc.FirewallDisable
+		if firewall {
+			control = rancherDesktopSvc.FirewallEnable
+		}
+		return manage.ControlService(svcName, control, svc.Running)
 	},
 }
 
+// firewall enables firewall rules for ports published on all interfaces.
+var firewall bool
+
 func init() {
+	startCmd.Flags().BoolVar(&firewall, "firewall", false, "add firewall rules for ports published on all interfaces")
 	rootCmd.AddCommand(startCmd)
 }
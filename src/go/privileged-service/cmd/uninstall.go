@@ -24,7 +24,10 @@ var uninstallCmd = &cobra.Command{
 	Use:   "uninstall",
 	Short: "Uninstalls the Rancher Desktop Privileged Service",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return manage.UninstallService(svcName)
+		if err := manage.UninstallService(svcName); err != nil {
+			return err
+		}
+		return manage.UninstallEventSource(wslHelperEventSource)
 	},
 }
 
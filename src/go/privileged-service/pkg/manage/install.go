@@ -111,3 +111,14 @@ func getInstallPath(handle windows.Handle) (string, error) {
 	}
 	return syscall.UTF16ToString(buf), nil
 }
+
+// InstallEventSource registers the event source of another helper, such as
+// wsl-helper, so that the Event Viewer can display its messages; it replaces
+// the source if it is already registered.
+func InstallEventSource(name string) error {
+	_ = eventlog.Remove(name)
+	if err := eventlog.InstallAsEventCreate(name, eventlog.Error|eventlog.Warning|eventlog.Info); err != nil {
+		return fmt.Errorf("setup event log for [%s] failed: %w", name, err)
+	}
+	return nil
+}
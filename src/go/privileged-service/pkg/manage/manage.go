@@ -32,10 +32,11 @@ import (
 const (
 	queryTimeout           = 300 * time.Millisecond
 	desiredStateTimeout    = 10 * time.Second
-	SERVICE_MINIMAL_ACCESS = windows.SERVICE_QUERY_STATUS | windows.SERVICE_START | windows.SERVICE_STOP | windows.SERVICE_INTERROGATE
+	SERVICE_MINIMAL_ACCESS = windows.SERVICE_QUERY_STATUS | windows.SERVICE_START | windows.SERVICE_STOP | windows.SERVICE_INTERROGATE | windows.SERVICE_USER_DEFINED_CONTROL
 )
 
-// Start Service start the Rancher Desktop Privileged Service process in Windows Services
+// Start Service start the Rancher Desktop Privileged Service process in Windows Services,
+// and waits for it to be running
 func StartService(name string) error {
 	m, err := connect()
 	if err != nil {
@@ -52,10 +53,15 @@ func StartService(name string) error {
 			return fmt.Errorf("could not start service: %w", err)
 		}
 	}
-	return nil
+	status, err := s.Query()
+	if err != nil {
+		return fmt.Errorf("could not retrieve service status: %w", err)
+	}
+	return waitForState(s, status, svc.Running)
 }
 
-// Control Service manages Stop, Pause and Continue for Rancher Desktop Privileged Service
+// Control Service manages Stop, Pause and Continue for Rancher Desktop Privileged Service,
+// as well as its user-defined controls
 func ControlService(name string, control svc.Cmd, desiredState svc.State) error {
 	m, err := connect()
 	if err != nil {
@@ -71,8 +77,14 @@ func ControlService(name string, control svc.Cmd, desiredState svc.State) error
 	if err != nil {
 		return fmt.Errorf("could not send control=%d: %w", control, err)
 	}
+	return waitForState(s, status, desiredState)
+}
+
+// waitForState polls the service until it reaches the desired state
+func waitForState(s *mgr.Service, status svc.Status, desiredState svc.State) error {
 	ctx, cancel := context.WithTimeout(context.Background(), desiredStateTimeout)
 	defer cancel()
+	var err error
 	for status.State != desiredState {
 		select {
 		case <-ctx.Done():
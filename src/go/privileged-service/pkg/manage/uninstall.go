@@ -58,3 +58,12 @@ func UninstallService(name string) error {
 	}
 	return nil
 }
+
+// UninstallEventSource removes the event source registered by
+// InstallEventSource; it is not an error if it isn't registered.
+func UninstallEventSource(name string) error {
+	if err := eventlog.Remove(name); err != nil && !errors.Is(err, windows.ERROR_FILE_NOT_FOUND) {
+		return fmt.Errorf("remove event log for [%s] failed: %w", name, err)
+	}
+	return nil
+}
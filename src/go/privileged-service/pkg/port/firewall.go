@@ -0,0 +1,199 @@
+/*
+Copyright © 2023 SUSE LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package port
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/docker/go-connections/nat"
+)
+
+// FirewallRulePrefix is the prefix of the names of the firewall rules owned
+// by Rancher Desktop; rdctl relies on it to list them.
+const FirewallRulePrefix = "Rancher Desktop port "
+
+// firewall manages Windows Defender Firewall rules allowing inbound
+// connections to ports published on all interfaces. Ports published on
+// specific addresses (such as 127.0.0.1) are left alone, as the user chose
+// who can reach them.
+type firewall struct {
+	enabled bool
+	// rules counts the port bindings using each rule, as the IPv4 and IPv6
+	// bindings of a port share the same rule.
+	rules map[string]int
+	mutex sync.Mutex
+}
+
+// firewallRule is the rule for one exposed port binding.
+type firewallRule struct {
+	name     string
+	hostPort string
+	proto    string
+}
+
+func newFirewall() *firewall {
+	return &firewall{
+		rules: make(map[string]int),
+	}
+}
+
+// enable turns the rules on; it returns false if they already were.
+func (f *firewall) enable() bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.enabled {
+		return false
+	}
+	f.enabled = true
+	return true
+}
+
+// disable turns the rules off, removing the existing ones.
+func (f *firewall) disable() []error {
+	f.mutex.Lock()
+	f.enabled = false
+	f.mutex.Unlock()
+	return f.removeAll()
+}
+
+// add creates the rules for the bindings of the given port map, if enabled.
+// The bindings are only counted once all their rules exist; if a rule can't be
+// created, the ones created so far are removed.
+func (f *firewall) add(portMap nat.PortMap) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if !f.enabled {
+		return nil
+	}
+	rules := firewallRules(portMap)
+	var created []string
+	for _, rule := range rules {
+		if f.rules[rule.name] > 0 || slices.Contains(created, rule.name) {
+			continue
+		}
+		if err := runNetsh(firewallAddArgs(rule.name, rule.hostPort, rule.proto)); err != nil {
+			for _, name := range created {
+				_ = runNetsh(firewallDeleteArgs(name))
+			}
+			return err
+		}
+		created = append(created, rule.name)
+	}
+	for _, rule := range rules {
+		f.rules[rule.name]++
+	}
+	return nil
+}
+
+// delete removes the rules for the bindings of the given port map once no
+// other binding uses them. A rule that can't be removed doesn't stop the
+// removal of the others.
+func (f *firewall) delete(portMap nat.PortMap) error {
+	var errs []error
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	for _, rule := range firewallRules(portMap) {
+		if f.rules[rule.name] == 0 {
+			continue
+		}
+		f.rules[rule.name]--
+		if f.rules[rule.name] > 0 {
+			continue
+		}
+		delete(f.rules, rule.name)
+		if err := runNetsh(firewallDeleteArgs(rule.name)); err != nil {
+			errs = append(errs, fmt.Errorf("deleting firewall rule %q failed: %w", rule.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// removeAll removes all the rules created by the firewall.
+func (f *firewall) removeAll() []error {
+	var errs []error
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	for name := range f.rules {
+		if err := runNetsh(firewallDeleteArgs(name)); err != nil {
+			errs = append(errs, fmt.Errorf("deleting firewall rule %q failed: %w", name, err))
+		}
+		delete(f.rules, name)
+	}
+	return errs
+}
+
+// firewallRules returns the rules for the exposed bindings of the given port
+// map, one per binding.
+func firewallRules(portMap nat.PortMap) []firewallRule {
+	var rules []firewallRule
+	for port, bindings := range portMap {
+		for _, binding := range bindings {
+			if !exposed(binding.HostIP) {
+				continue
+			}
+			rules = append(rules, firewallRule{
+				name:     firewallRuleName(binding.HostPort, port.Proto()),
+				hostPort: binding.HostPort,
+				proto:    port.Proto(),
+			})
+		}
+	}
+	return rules
+}
+
+// exposed returns whether a port bound to the given address is reachable from
+// other hosts; an empty address means all interfaces.
+func exposed(hostIP string) bool {
+	if hostIP == "" {
+		return true
+	}
+	ip := net.ParseIP(hostIP)
+	return ip != nil && ip.IsUnspecified()
+}
+
+func firewallRuleName(hostPort, proto string) string {
+	return fmt.Sprintf("%s%s/%s", FirewallRulePrefix, hostPort, strings.ToLower(proto))
+}
+
+func firewallAddArgs(name, hostPort, proto string) []string {
+	return []string{
+		"advfirewall",
+		"firewall",
+		"add",
+		"rule",
+		fmt.Sprintf("name=%s", name),
+		"dir=in",
+		"action=allow",
+		fmt.Sprintf("protocol=%s", strings.ToUpper(proto)),
+		fmt.Sprintf("localport=%s", hostPort),
+	}
+}
+
+func firewallDeleteArgs(name string) []string {
+	return []string{
+		"advfirewall",
+		"firewall",
+		"delete",
+		"rule",
+		fmt.Sprintf("name=%s", name),
+	}
+}
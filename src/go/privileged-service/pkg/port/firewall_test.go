@@ -0,0 +1,207 @@
+/*
+Copyright © 2023 SUSE LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package port
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/docker/go-connections/nat"
+	"github.com/rancher-sandbox/rancher-desktop-agent/pkg/types"
+)
+
+func TestExposed(t *testing.T) {
+	for hostIP, expected := range map[string]bool{
+		"":          true,
+		"0.0.0.0":   true,
+		"::":        true,
+		"127.0.0.1": false,
+		"::1":       false,
+		"10.0.0.5":  false,
+	} {
+		if actual := exposed(hostIP); actual != expected {
+			t.Errorf("exposed(%q) = %t, expected %t", hostIP, actual, expected)
+		}
+	}
+}
+
+func TestFirewallAddArgs(t *testing.T) {
+	name := firewallRuleName("8080", "tcp")
+	if name != "Rancher Desktop port 8080/tcp" {
+		t.Errorf("unexpected rule name %q", name)
+	}
+	expected := []string{"advfirewall", "firewall", "add", "rule", "name=Rancher Desktop port 8080/tcp", "dir=in", "action=allow", "protocol=TCP", "localport=8080"}
+	if actual := firewallAddArgs(name, "8080", "tcp"); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("firewallAddArgs() = %v, expected %v", actual, expected)
+	}
+}
+
+func TestFirewallDisabled(t *testing.T) {
+	f := newFirewall()
+	portMap := nat.PortMap{"80/tcp": []nat.PortBinding{{HostIP: "0.0.0.0", HostPort: "80"}}}
+	if err := f.add(portMap); err != nil {
+		t.Fatalf("add failed: %v", err)
+	}
+	if len(f.rules) != 0 {
+		t.Errorf("disabled firewall added rules: %v", f.rules)
+	}
+	// Deleting rules that were never added must not run netsh.
+	if err := f.delete(portMap); err != nil {
+		t.Errorf("delete failed: %v", err)
+	}
+}
+
+// fakeNetsh replaces netsh for the duration of the test; it records the
+// commands, and fails those containing failOn.
+func fakeNetsh(t *testing.T, failOn string) *[]string {
+	var commands []string
+	original := runNetsh
+	runNetsh = func(args []string) error {
+		command := strings.Join(args, " ")
+		commands = append(commands, command)
+		if failOn != "" && strings.Contains(command, failOn) {
+			return errors.New("netsh failed")
+		}
+		return nil
+	}
+	t.Cleanup(func() { runNetsh = original })
+	return &commands
+}
+
+func TestFirewallAddFailure(t *testing.T) {
+	commands := fakeNetsh(t, "localport=443")
+	f := newFirewall()
+	f.enable()
+	portMap := nat.PortMap{
+		"80/tcp":  []nat.PortBinding{{HostIP: "0.0.0.0", HostPort: "80"}},
+		"443/tcp": []nat.PortBinding{{HostIP: "0.0.0.0", HostPort: "443"}},
+	}
+	if err := f.add(portMap); err == nil {
+		t.Fatal("add succeeded, expected an error")
+	}
+	if len(f.rules) != 0 {
+		t.Errorf("failed add counted rules: %v", f.rules)
+	}
+	// A rule created before the failure must be removed again.
+	var added, deleted int
+	for _, command := range *commands {
+		if strings.Contains(command, "localport=80") {
+			added++
+		}
+		if strings.Contains(command, "delete rule name=Rancher Desktop port 80/tcp") {
+			deleted++
+		}
+	}
+	if added != deleted {
+		t.Errorf("rules were not rolled back: %v", *commands)
+	}
+}
+
+func TestFirewallDeleteFailure(t *testing.T) {
+	commands := fakeNetsh(t, "delete rule name=Rancher Desktop port 443/tcp")
+	f := newFirewall()
+	f.enable()
+	portMap := nat.PortMap{
+		"80/tcp":   []nat.PortBinding{{HostIP: "0.0.0.0", HostPort: "80"}},
+		"443/tcp":  []nat.PortBinding{{HostIP: "0.0.0.0", HostPort: "443"}},
+		"8080/tcp": []nat.PortBinding{{HostIP: "0.0.0.0", HostPort: "8080"}},
+	}
+	if err := f.add(portMap); err != nil {
+		t.Fatalf("add failed: %v", err)
+	}
+	if err := f.delete(portMap); err == nil {
+		t.Error("delete succeeded, expected an error")
+	}
+	// The rules after the failed one must be removed too.
+	var deleted int
+	for _, command := range *commands {
+		if strings.Contains(command, "delete rule") {
+			deleted++
+		}
+	}
+	if deleted != 3 || len(f.rules) != 0 {
+		t.Errorf("unexpected rules %v after commands %v", f.rules, *commands)
+	}
+}
+
+func TestFirewallSharedRule(t *testing.T) {
+	commands := fakeNetsh(t, "")
+	f := newFirewall()
+	f.enable()
+	portMap := nat.PortMap{"80/tcp": []nat.PortBinding{{HostIP: "0.0.0.0", HostPort: "80"}, {HostIP: "::", HostPort: "80"}}}
+	if err := f.add(portMap); err != nil {
+		t.Fatalf("add failed: %v", err)
+	}
+	if len(*commands) != 1 || f.rules["Rancher Desktop port 80/tcp"] != 2 {
+		t.Errorf("unexpected rules %v after commands %v", f.rules, *commands)
+	}
+	if errs := f.disable(); len(errs) != 0 {
+		t.Errorf("disable failed: %v", errs)
+	}
+	if len(*commands) != 2 || len(f.rules) != 0 {
+		t.Errorf("unexpected rules %v after commands %v", f.rules, *commands)
+	}
+}
+
+func TestProxyAddRollback(t *testing.T) {
+	commands := fakeNetsh(t, "advfirewall firewall add")
+	p := newProxy()
+	if err := p.setFirewall(true); err != nil {
+		t.Fatalf("setFirewall failed: %v", err)
+	}
+	port := portProxy{
+		PortMap:      nat.PortMap{"80/tcp": []nat.PortBinding{{HostIP: "0.0.0.0", HostPort: "80"}}},
+		ConnectAddrs: []types.ConnectAddrs{{Network: "tcp", Addr: "192.168.0.1/24"}},
+	}
+	if err := p.add(port); err == nil {
+		t.Fatal("add succeeded, expected an error")
+	}
+	if len(p.portMappings) != 0 {
+		t.Errorf("failed add was recorded: %v", p.portMappings)
+	}
+	last := (*commands)[len(*commands)-1]
+	if !strings.HasPrefix(last, "interface portproxy delete v4tov4 listenport=80") {
+		t.Errorf("portproxy was not rolled back: %v", *commands)
+	}
+}
+
+func TestProxySetFirewall(t *testing.T) {
+	commands := fakeNetsh(t, "")
+	p := newProxy()
+	port := portProxy{
+		PortMap:      nat.PortMap{"80/tcp": []nat.PortBinding{{HostIP: "0.0.0.0", HostPort: "80"}}},
+		ConnectAddrs: []types.ConnectAddrs{{Network: "tcp", Addr: "192.168.0.1/24"}},
+	}
+	if err := p.add(port); err != nil {
+		t.Fatalf("add failed: %v", err)
+	}
+	// Enabling the rules while running covers the ports already published.
+	if err := p.setFirewall(true); err != nil {
+		t.Fatalf("setFirewall failed: %v", err)
+	}
+	if p.firewall.rules["Rancher Desktop port 80/tcp"] != 1 {
+		t.Errorf("no rule for published port after commands %v", *commands)
+	}
+	if err := p.setFirewall(false); err != nil {
+		t.Fatalf("setFirewall failed: %v", err)
+	}
+	if len(p.firewall.rules) != 0 {
+		t.Errorf("rules left after disabling: %v", p.firewall.rules)
+	}
+}
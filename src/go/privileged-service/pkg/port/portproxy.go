@@ -32,6 +32,11 @@ import (
 
 const netsh = "netsh"
 
+// runNetsh runs netsh with the given arguments; tests replace it.
+var runNetsh = func(args []string) error {
+	return command.Exec(netsh, args)
+}
+
 var ErrPortProxy = errors.New("error from PortProxy")
 
 type portProxy struct {
@@ -42,11 +47,13 @@ type portProxy struct {
 type proxy struct {
 	portMappings map[string]portProxy
 	mutex        sync.Mutex
+	firewall     *firewall
 }
 
 func newProxy() *proxy {
 	return &proxy{
 		portMappings: make(map[string]portProxy),
+		firewall:     newFirewall(),
 	}
 }
 
@@ -61,23 +68,34 @@ func (p *proxy) exec(portMapping types.PortMapping) error {
 	return p.add(port)
 }
 
+// add creates the portproxy entries and firewall rules for the given port;
+// if any of them fails, the entries created so far are removed.
 func (p *proxy) add(port portProxy) error {
+	var added []nat.PortBinding
 	for _, v := range port.PortMap {
 		for _, addr := range v {
 			wslIP, err := getConnectAddr(addr.HostIP, port.ConnectAddrs)
 			if err != nil {
+				rollbackNetshAdd(added)
 				return err
 			}
 			args, err := portProxyAddArgs(addr.HostPort, addr.HostIP, wslIP)
 			if err != nil {
+				rollbackNetshAdd(added)
 				return err
 			}
-			err = command.Exec(netsh, args)
+			err = runNetsh(args)
 			if err != nil {
+				rollbackNetshAdd(added)
 				return err
 			}
+			added = append(added, addr)
 		}
 	}
+	if err := p.firewall.add(port.PortMap); err != nil {
+		rollbackNetshAdd(added)
+		return err
+	}
 	hash, err := getHash(port)
 	if err != nil {
 		return err
@@ -99,6 +117,9 @@ func (p *proxy) delete(port portProxy) error {
 	if err := execNetshDelete(port); err != nil {
 		return err
 	}
+	if err := p.firewall.delete(port.PortMap); err != nil {
+		return err
+	}
 
 	hash, err := getHash(port)
 	if err != nil {
@@ -117,6 +138,27 @@ func (p *proxy) delete(port portProxy) error {
 	return nil
 }
 
+// setFirewall turns the firewall rules on or off; when turned on, rules are
+// added for the ports already published.
+func (p *proxy) setFirewall(enabled bool) error {
+	var errs []error
+	if !enabled {
+		errs = p.firewall.disable()
+	} else if p.firewall.enable() {
+		p.mutex.Lock()
+		defer p.mutex.Unlock()
+		for _, port := range p.portMappings {
+			if err := p.firewall.add(port.PortMap); err != nil {
+				errs = append(errs, fmt.Errorf("adding firewall rules for %+v failed: %w", port, err))
+			}
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %+v", ErrPortProxy, errs)
+}
+
 func (p *proxy) removeAll() error {
 	errs := make([]error, 0)
 	p.mutex.Lock()
@@ -126,6 +168,7 @@ func (p *proxy) removeAll() error {
 			errs = append(errs, fmt.Errorf("deleting portproxy: %+v failed: %w", proxy, err))
 		}
 	}
+	errs = append(errs, p.firewall.removeAll()...)
 	if len(errs) == 0 {
 		return nil
 	}
@@ -139,7 +182,7 @@ func execNetshDelete(port portProxy) error {
 			if err != nil {
 				return err
 			}
-			err = command.Exec(netsh, args)
+			err = runNetsh(args)
 			if err != nil {
 				return err
 			}
@@ -148,6 +191,16 @@ func execNetshDelete(port portProxy) error {
 	return nil
 }
 
+// rollbackNetshAdd removes the portproxy entries of the given bindings, which
+// were just added; errors are ignored, as the add has already failed.
+func rollbackNetshAdd(bindings []nat.PortBinding) {
+	for _, addr := range bindings {
+		if args, err := portProxyDeleteArgs(addr.HostPort, addr.HostIP); err == nil {
+			_ = runNetsh(args)
+		}
+	}
+}
+
 // getConnectedAddr selects an IP address from connectAddrs that is the same
 // type (IPv4 or IPv6) as listenIP.
 func getConnectAddr(listenIP string, connectAddrs []types.ConnectAddrs) (string, error) {
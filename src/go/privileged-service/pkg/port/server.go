@@ -52,6 +52,12 @@ func NewServer(elog debug.Log) *Server {
 	}
 }
 
+// SetFirewall turns on or off the Windows Defender Firewall rules for ports
+// published on all interfaces; it may be called while the server is running.
+func (s *Server) SetFirewall(enabled bool) error {
+	return s.proxy.setFirewall(enabled)
+}
+
 // Start initiates the port server on a given host:port
 func (s *Server) Start() error {
 	s.quit = make(chan interface{})
@@ -26,6 +26,14 @@ import (
 	"github.com/rancher-sandbox/rancher-desktop/src/go/privileged-service/pkg/port"
 )
 
+// FirewallEnable and FirewallDisable are the user-defined service controls
+// turning on and off the firewall rules for published ports; unlike start
+// arguments, they also reach a service that is already running.
+const (
+	FirewallEnable  = svc.Cmd(128)
+	FirewallDisable = svc.Cmd(129)
+)
+
 // Supervisor implements service handler interface for
 // Rancher Desktop Privileged Service
 type Supervisor struct {
@@ -70,6 +78,12 @@ loop:
 				s.eventLogger.Info(uint32(windows.NO_ERROR), "port server is stopping")
 				changes <- svc.Status{State: svc.Stopped, Accepts: cmdsAccepted}
 				break loop
+			case FirewallEnable, FirewallDisable:
+				enabled := c.Cmd == FirewallEnable
+				s.eventLogger.Info(uint32(windows.NO_ERROR), fmt.Sprintf("setting firewall rules enabled=%t", enabled))
+				if err := s.portServer.SetFirewall(enabled); err != nil {
+					s.eventLogger.Warning(uint32(windows.ERROR_EXCEPTION_IN_SERVICE), err.Error())
+				}
 			default:
 				s.eventLogger.Error(uint32(windows.ERROR_INVALID_SERVICE_CONTROL), fmt.Sprintf("unexpected control request #%d", c))
 			}
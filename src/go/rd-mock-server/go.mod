@@ -0,0 +1,3 @@
+module github.com/rancher-sandbox/rancher-desktop/src/go/rd-mock-server
+
+go 1.21
@@ -0,0 +1,75 @@
+// rd-mock-server implements the Rancher Desktop management API (as used by
+// rdctl and extensions) with canned, configurable state, so that clients can
+// be developed and tested without a full installation of Rancher Desktop.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+)
+
+// connectionInfo is the format of rd-engine.json, which rdctl reads.
+type connectionInfo struct {
+	User     string `json:"user"`
+	Password string `json:"password"`
+	Port     int    `json:"port"`
+}
+
+func main() {
+	if err := run(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run() error {
+	host := flag.String("host", "127.0.0.1", "address to listen on")
+	port := flag.Int("port", 6107, "port to listen on (0 to pick a free port)")
+	user := flag.String("user", "user", "user name clients must authenticate with")
+	password := flag.String("password", "password", "password clients must authenticate with")
+	statePath := flag.String("state", "", "JSON file with the canned state (see README.md)")
+	configPath := flag.String("write-config", "", "write the connection details to this file, in the format of rd-engine.json")
+	flag.Parse()
+
+	state, err := loadState(*statePath)
+	if err != nil {
+		return err
+	}
+	listener, err := net.Listen("tcp", net.JoinHostPort(*host, fmt.Sprint(*port)))
+	if err != nil {
+		return fmt.Errorf("failed to listen: %w", err)
+	}
+	actualPort := listener.Addr().(*net.TCPAddr).Port
+	if *configPath != "" {
+		contents, err := json.Marshal(connectionInfo{User: *user, Password: *password, Port: actualPort})
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(*configPath, contents, 0o600); err != nil {
+			return fmt.Errorf("failed to write connection details: %w", err)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	mockServer := NewServer(state)
+	mockServer.User, mockServer.Password = *user, *password
+	mockServer.Shutdown = stop
+	server := &http.Server{Handler: mockServer}
+	go func() {
+		<-ctx.Done()
+		_ = server.Close()
+	}()
+	log.Printf("Mock server listening on %s", listener.Addr())
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
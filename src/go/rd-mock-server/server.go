@@ -0,0 +1,440 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// maxRequestBodyLength matches MAX_REQUEST_BODY_LENGTH of the real server, in
+// pkg/rancher-desktop/main/commandServer/httpCommandServer.ts.
+const maxRequestBodyLength = 4 * 1024 * 1024
+
+type handlerFunc func(w http.ResponseWriter, r *http.Request)
+
+// Server implements the Rancher Desktop management API on top of canned
+// state. Changes made through the API are kept in memory only.
+type Server struct {
+	User     string
+	Password string
+	// Shutdown is called after a shutdown or factory reset request.
+	Shutdown func()
+	state    *State
+	mutex    sync.Mutex
+	routes   map[string]handlerFunc
+}
+
+func NewServer(state *State) *Server {
+	s := &Server{state: state}
+	s.routes = map[string]handlerFunc{
+		"GET /v1/about":                 s.about,
+		"GET /v1/diagnostic_categories": s.diagnosticCategories,
+		"GET /v1/diagnostic_ids":        s.diagnosticIDs,
+		"GET /v1/diagnostic_checks":     s.diagnosticChecks,
+		"POST /v1/diagnostic_checks":    s.runDiagnosticChecks,
+		"GET /v1/settings":              s.getJSON(func() any { return s.state.Settings }),
+		"GET /v1/settings/locked":       s.getJSON(func() any { return s.state.LockedSettings }),
+		"GET /v1/transient_settings":    s.getJSON(func() any { return s.state.TransientSettings }),
+		"GET /v1/backend_state":         s.getJSON(func() any { return s.state.BackendState }),
+		"GET /v1/host_resources":        s.getJSON(func() any { return s.state.HostResources }),
+		"GET /v1/version":               s.getJSON(func() any { return map[string]string{"version": s.state.Version} }),
+		"PUT /v1/factory_reset":         s.shutdown("Doing a full factory reset...."),
+		"PUT /v1/propose_settings":      s.proposeSettings,
+		"PUT /v1/settings":              s.updateSettings,
+		"PUT /v1/shutdown":              s.shutdown("Shutting down."),
+		"PUT /v1/transient_settings":    s.updateTransientSettings,
+		"PUT /v1/backend_state":         s.setBackendState,
+		"GET /v1/extensions":            s.getJSON(func() any { return s.state.Extensions }),
+		"POST /v1/extensions/install":   s.installExtension,
+		"POST /v1/extensions/uninstall": s.uninstallExtension,
+		"GET /v1/snapshots":             s.getJSON(func() any { return s.state.Snapshots }),
+		"POST /v1/snapshots":            s.createSnapshot,
+		"POST /v1/snapshot/restore":     s.restoreSnapshot,
+		"POST /v1/snapshots/cancel":     s.cancelSnapshot,
+		"DELETE /v1/snapshots":          s.deleteSnapshot,
+		"GET /v1/update":                s.getJSON(func() any { return s.state.Update }),
+		"POST /v1/update/check":         s.checkForUpdates,
+		"POST /v1/update/download":      s.downloadUpdate,
+		"POST /v1/update/apply":         s.applyUpdate,
+	}
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Printf("%s %s", r.Method, r.URL)
+	user, password, ok := r.BasicAuth()
+	if !ok || user != s.User || password != s.Password {
+		sendText(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	route := fmt.Sprintf("%s %s", r.Method, r.URL.Path)
+	if failure, ok := s.state.Failures[route]; ok {
+		sendText(w, failure.Status, failure.Body)
+		return
+	}
+	switch r.URL.Path {
+	case "/", "/v1", "/v1/":
+		if r.Method == http.MethodGet {
+			s.listEndpoints(w, r)
+			return
+		}
+	}
+	if handler, ok := s.routes[route]; ok {
+		handler(w, r)
+		return
+	}
+	sendText(w, http.StatusNotFound, fmt.Sprintf("Unknown command: %s %s", r.Method, r.URL.Path))
+}
+
+func sendText(w http.ResponseWriter, status int, body string) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(status)
+	_, _ = io.WriteString(w, body)
+}
+
+func sendJSON(w http.ResponseWriter, status int, data any) {
+	body, err := json.Marshal(data)
+	if err != nil {
+		sendText(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+}
+
+// readSettings reads a JSON object from the request body, writing an error
+// response and returning nil on failure.
+func readSettings(w http.ResponseWriter, r *http.Request) map[string]any {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestBodyLength+1))
+	if err != nil {
+		sendText(w, http.StatusBadRequest, "error reading request body")
+		return nil
+	}
+	if len(body) > maxRequestBodyLength {
+		sendText(w, http.StatusRequestEntityTooLarge, "request body is too long")
+		return nil
+	}
+	if len(body) == 0 {
+		sendText(w, http.StatusBadRequest, "no settings specified in the request")
+		return nil
+	}
+	var settings map[string]any
+	if err := json.Unmarshal(body, &settings); err != nil || settings == nil {
+		sendText(w, http.StatusBadRequest, "error processing JSON request block")
+		return nil
+	}
+	return settings
+}
+
+func (s *Server) listEndpoints(w http.ResponseWriter, r *http.Request) {
+	var endpoints []string
+	for route := range s.routes {
+		endpoints = append(endpoints, route)
+	}
+	if r.URL.Path == "/" {
+		endpoints = append(endpoints, "GET /", "GET /v0", "GET /v1")
+	} else {
+		endpoints = append(endpoints, "GET /v1")
+	}
+	sort.Slice(endpoints, func(i, j int) bool {
+		methodI, pathI, _ := strings.Cut(endpoints[i], " ")
+		methodJ, pathJ, _ := strings.Cut(endpoints[j], " ")
+		if pathI != pathJ {
+			return pathI < pathJ
+		}
+		if (methodI == "GET") != (methodJ == "GET") {
+			return methodI == "GET"
+		}
+		return methodI < methodJ
+	})
+	sendJSON(w, http.StatusOK, endpoints)
+}
+
+func (s *Server) getJSON(get func() any) handlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sendJSON(w, http.StatusOK, get())
+	}
+}
+
+func (s *Server) about(w http.ResponseWriter, r *http.Request) {
+	sendText(w, http.StatusOK, "The API is currently at version 1, but is still considered internal and experimental, and is subject to change without any advance notice.")
+}
+
+func (s *Server) diagnosticCategories(w http.ResponseWriter, r *http.Request) {
+	sendJSON(w, http.StatusOK, s.diagnosticCategoryNames())
+}
+
+// diagnosticCategoryNames returns the categories of the checks, in order.
+func (s *Server) diagnosticCategoryNames() []string {
+	var categories []string
+	for _, diagnostic := range s.state.Diagnostics {
+		if !slices.Contains(categories, diagnostic.Category) {
+			categories = append(categories, diagnostic.Category)
+		}
+	}
+	return categories
+}
+
+func (s *Server) diagnosticIDs(w http.ResponseWriter, r *http.Request) {
+	category := r.URL.Query().Get("category")
+	if category == "" {
+		sendText(w, http.StatusBadRequest, "diagnostic_ids: no category specified")
+		return
+	}
+	var ids []string
+	for _, diagnostic := range s.state.Diagnostics {
+		if diagnostic.Category == category {
+			ids = append(ids, diagnostic.ID)
+		}
+	}
+	if len(ids) == 0 {
+		sendText(w, http.StatusNotFound, fmt.Sprintf("No diagnostic checks found in category %s", category))
+		return
+	}
+	sendJSON(w, http.StatusOK, ids)
+}
+
+func (s *Server) diagnosticChecks(w http.ResponseWriter, r *http.Request) {
+	category, id := r.URL.Query().Get("category"), r.URL.Query().Get("id")
+	checks := []Diagnostic{}
+	for _, diagnostic := range s.state.Diagnostics {
+		if (category == "" || diagnostic.Category == category) && (id == "" || diagnostic.ID == id) {
+			checks = append(checks, diagnostic)
+		}
+	}
+	sendJSON(w, http.StatusOK, map[string]any{"last_update": "1970-01-01T00:00:00.000Z", "checks": checks})
+}
+
+// runDiagnosticChecks returns the checks of the categories given, or all
+// checks; running them changes nothing.
+func (s *Server) runDiagnosticChecks(w http.ResponseWriter, r *http.Request) {
+	categories := r.URL.Query()["category"]
+	known := s.diagnosticCategoryNames()
+	var unknown []string
+	for _, category := range categories {
+		if !slices.Contains(known, category) {
+			unknown = append(unknown, category)
+		}
+	}
+	if len(unknown) > 0 {
+		sendText(w, http.StatusBadRequest, fmt.Sprintf("Unknown diagnostic categories: %s; the categories are %s",
+			strings.Join(unknown, ", "), strings.Join(known, ", ")))
+		return
+	}
+	checks := []Diagnostic{}
+	for _, diagnostic := range s.state.Diagnostics {
+		if len(categories) == 0 || slices.Contains(categories, diagnostic.Category) {
+			checks = append(checks, diagnostic)
+		}
+	}
+	sendJSON(w, http.StatusOK, map[string]any{"last_update": "1970-01-01T00:00:00.000Z", "checks": checks})
+}
+
+func (s *Server) updateSettings(w http.ResponseWriter, r *http.Request) {
+	changes := readSettings(w, r)
+	if changes == nil {
+		return
+	}
+	if field, locked := isLocked(s.state.Settings, s.state.LockedSettings, changes); locked {
+		sendText(w, http.StatusBadRequest, fmt.Sprintf("field %q is locked", field))
+		return
+	}
+	mergeSettings(s.state.Settings, changes)
+	sendText(w, http.StatusAccepted, "")
+}
+
+func (s *Server) proposeSettings(w http.ResponseWriter, r *http.Request) {
+	changes := readSettings(w, r)
+	if changes == nil {
+		return
+	}
+	if field, locked := isLocked(s.state.Settings, s.state.LockedSettings, changes); locked {
+		sendText(w, http.StatusBadRequest, fmt.Sprintf("field %q is locked", field))
+		return
+	}
+	sendJSON(w, http.StatusOK, map[string]any{})
+}
+
+func (s *Server) updateTransientSettings(w http.ResponseWriter, r *http.Request) {
+	changes := readSettings(w, r)
+	if changes == nil {
+		return
+	}
+	mergeSettings(s.state.TransientSettings, changes)
+	sendText(w, http.StatusAccepted, "")
+}
+
+func (s *Server) setBackendState(w http.ResponseWriter, r *http.Request) {
+	var state BackendState
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxRequestBodyLength)).Decode(&state); err != nil {
+		sendText(w, http.StatusInternalServerError, fmt.Sprintf("internal error: %s", err))
+		return
+	}
+	// The mock backend transitions instantly.
+	switch state.VMState {
+	case "STARTED", "STARTING":
+		state.VMState = "STARTED"
+	case "STOPPED", "STOPPING":
+		state.VMState = "STOPPED"
+	}
+	s.state.BackendState = state
+	sendText(w, http.StatusAccepted, "received backend state")
+}
+
+func (s *Server) checkForUpdates(w http.ResponseWriter, r *http.Request) {
+	if !s.state.Update.Configured {
+		sendText(w, http.StatusServiceUnavailable, "This installation of Rancher Desktop can not be updated")
+		return
+	}
+	s.state.Update.Channel = r.URL.Query().Get("channel")
+	s.state.Update.Available = s.state.Update.Version != s.state.Version
+	sendJSON(w, http.StatusOK, s.state.Update)
+}
+
+func (s *Server) downloadUpdate(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case !s.state.Update.Configured:
+		sendText(w, http.StatusServiceUnavailable, "This installation of Rancher Desktop can not be updated")
+	case !s.state.Update.Available:
+		sendText(w, http.StatusConflict, "No update is available")
+	case s.state.Update.Downloaded:
+		sendJSON(w, http.StatusOK, s.state.Update)
+	default:
+		s.state.Update.Downloaded = true
+		sendJSON(w, http.StatusAccepted, s.state.Update)
+	}
+}
+
+func (s *Server) applyUpdate(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case !s.state.Update.Configured:
+		sendText(w, http.StatusServiceUnavailable, "This installation of Rancher Desktop can not be updated")
+	case !s.state.Update.Downloaded:
+		sendText(w, http.StatusConflict, "No update has been downloaded")
+	default:
+		s.shutdown("Installing the update; Rancher Desktop restarts once it is installed")(w, r)
+	}
+}
+
+func (s *Server) shutdown(message string) handlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sendText(w, http.StatusAccepted, message)
+		if s.Shutdown != nil {
+			go s.Shutdown()
+		}
+	}
+}
+
+func (s *Server) installExtension(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		sendText(w, http.StatusBadRequest, "Extension ID is required in the id= parameter.")
+		return
+	}
+	name, tag := splitReference(id)
+	if tag == "" {
+		tag = "latest"
+	}
+	if existing, ok := s.state.Extensions[name]; ok && existing.Version == tag {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	s.state.Extensions[name] = Extension{Version: tag, Metadata: map[string]any{}, Labels: map[string]string{}}
+	w.WriteHeader(http.StatusCreated)
+}
+
+// splitReference splits an image reference into the name of the image and its
+// digest or tag, if it has any; a tag before a digest is ignored.
+func splitReference(id string) (name, version string) {
+	name, digest, pinned := strings.Cut(id, "@")
+	if colon := strings.LastIndex(name, ":"); colon > strings.LastIndex(name, "/") {
+		name, version = name[:colon], name[colon+1:]
+	}
+	if pinned {
+		version = digest
+	}
+	return name, version
+}
+
+func (s *Server) uninstallExtension(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		sendText(w, http.StatusBadRequest, "Extension ID is required in the id= parameter.")
+		return
+	}
+	name, _ := splitReference(id)
+	if _, ok := s.state.Extensions[name]; !ok {
+		sendText(w, http.StatusNotFound, fmt.Sprintf("Extension %s is not installed", id))
+		return
+	}
+	delete(s.state.Extensions, name)
+	sendText(w, http.StatusCreated, fmt.Sprintf("Deleted %s", id))
+}
+
+func (s *Server) findSnapshot(name string) int {
+	for i, snapshot := range s.state.Snapshots {
+		if snapshot.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *Server) createSnapshot(w http.ResponseWriter, r *http.Request) {
+	var snapshot Snapshot
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxRequestBodyLength)).Decode(&snapshot); err != nil {
+		sendText(w, http.StatusBadRequest, "The snapshot is invalid")
+		return
+	}
+	if snapshot.Name == "" {
+		sendText(w, http.StatusBadRequest, "The name field is required")
+		return
+	}
+	if s.findSnapshot(snapshot.Name) >= 0 {
+		sendText(w, http.StatusBadRequest, fmt.Sprintf("name %q already in use", snapshot.Name))
+		return
+	}
+	s.state.Snapshots = append(s.state.Snapshots, newSnapshot(snapshot.Name, snapshot.Description))
+	sendText(w, http.StatusOK, "Snapshot successfully created")
+}
+
+func (s *Server) restoreSnapshot(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		sendText(w, http.StatusBadRequest, "Snapshot name is required in query parameters")
+		return
+	}
+	if s.findSnapshot(name) < 0 {
+		sendText(w, http.StatusBadRequest, fmt.Sprintf("Can't find snapshot with name %q", name))
+		return
+	}
+	sendText(w, http.StatusOK, "Snapshot successfully restored")
+}
+
+func (s *Server) cancelSnapshot(w http.ResponseWriter, r *http.Request) {
+	sendText(w, http.StatusOK, "Snapshot operation canceled")
+}
+
+func (s *Server) deleteSnapshot(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		sendText(w, http.StatusBadRequest, "Snapshot name is required in query parameters")
+		return
+	}
+	index := s.findSnapshot(name)
+	if index < 0 {
+		sendText(w, http.StatusBadRequest, fmt.Sprintf("Can't find snapshot with name %q", name))
+		return
+	}
+	s.state.Snapshots = append(s.state.Snapshots[:index], s.state.Snapshots[index+1:]...)
+	sendText(w, http.StatusOK, "Snapshot successfully deleted")
+}
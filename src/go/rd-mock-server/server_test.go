@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newTestServer(t *testing.T, state *State) *httptest.Server {
+	t.Helper()
+	mockServer := NewServer(state)
+	mockServer.User, mockServer.Password = "user", "password"
+	server := httptest.NewServer(mockServer)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func request(t *testing.T, server *httptest.Server, method, path, body string) (int, string) {
+	t.Helper()
+	req, err := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.SetBasicAuth("user", "password")
+	response, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	contents, err := io.ReadAll(response.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return response.StatusCode, string(contents)
+}
+
+func TestAuthentication(t *testing.T) {
+	server := newTestServer(t, defaultState())
+	response, err := http.Get(server.URL + "/v1/settings")
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected status 401, got %d", response.StatusCode)
+	}
+}
+
+func TestSettings(t *testing.T) {
+	state := defaultState()
+	state.LockedSettings = map[string]any{"containerEngine": map[string]any{"name": true}}
+	server := newTestServer(t, state)
+
+	status, _ := request(t, server, "PUT", "/v1/settings", `{"kubernetes": {"enabled": false}}`)
+	if status != http.StatusAccepted {
+		t.Fatalf("expected status 202, got %d", status)
+	}
+	status, body := request(t, server, "GET", "/v1/settings", "")
+	if status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", status)
+	}
+	var settings struct {
+		Kubernetes struct {
+			Enabled bool   `json:"enabled"`
+			Version string `json:"version"`
+		} `json:"kubernetes"`
+	}
+	if err := json.Unmarshal([]byte(body), &settings); err != nil {
+		t.Fatal(err)
+	}
+	if settings.Kubernetes.Enabled || settings.Kubernetes.Version == "" {
+		t.Errorf("expected the change to be merged into the settings, got %s", body)
+	}
+
+	status, body = request(t, server, "PUT", "/v1/settings", `{"containerEngine": {"name": "containerd"}}`)
+	if status != http.StatusBadRequest || !strings.Contains(body, "containerEngine.name") {
+		t.Errorf("expected locked field to be rejected, got %d %s", status, body)
+	}
+	status, _ = request(t, server, "PUT", "/v1/settings", `{"containerEngine": {"name": "moby"}}`)
+	if status != http.StatusAccepted {
+		t.Errorf("expected unchanged locked field to be accepted, got %d", status)
+	}
+
+	// Like the real server, accept payloads of up to 4MiB.
+	large := `{"containerEngine": {"allowedImages": {"patterns": ["` + strings.Repeat("a", 64*1024) + `"]}}}`
+	if status, body := request(t, server, "PUT", "/v1/settings", large); status != http.StatusAccepted {
+		t.Errorf("expected a 64KiB payload to be accepted, got %d %s", status, body)
+	}
+	tooLarge := `{"containerEngine": {"allowedImages": {"patterns": ["` + strings.Repeat("a", maxRequestBodyLength) + `"]}}}`
+	if status, _ := request(t, server, "PUT", "/v1/settings", tooLarge); status != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected a payload over 4MiB to be rejected, got %d", status)
+	}
+}
+
+func TestSnapshots(t *testing.T) {
+	server := newTestServer(t, defaultState())
+	if status, _ := request(t, server, "POST", "/v1/snapshots", `{"name": "first"}`); status != http.StatusOK {
+		t.Fatalf("expected status 200 creating snapshot, got %d", status)
+	}
+	if status, _ := request(t, server, "POST", "/v1/snapshots", `{"name": "first"}`); status != http.StatusBadRequest {
+		t.Errorf("expected duplicate snapshot to be rejected, got %d", status)
+	}
+	_, body := request(t, server, "GET", "/v1/snapshots", "")
+	if !strings.Contains(body, `"name":"first"`) {
+		t.Errorf("expected snapshot to be listed, got %s", body)
+	}
+	if status, _ := request(t, server, "DELETE", "/v1/snapshots?name=first", ""); status != http.StatusOK {
+		t.Errorf("expected status 200 deleting snapshot, got %d", status)
+	}
+	if _, body := request(t, server, "GET", "/v1/snapshots", ""); body != "[]" {
+		t.Errorf("expected no snapshots, got %s", body)
+	}
+}
+
+func TestExtensions(t *testing.T) {
+	server := newTestServer(t, defaultState())
+	digest := "sha256:" + strings.Repeat("0123456789abcdef", 4)
+	if status, _ := request(t, server, "POST", "/v1/extensions/install?id=registry.test:5000/rd/extension", ""); status != http.StatusCreated {
+		t.Fatalf("expected status 201 installing extension, got %d", status)
+	}
+	if status, _ := request(t, server, "POST", "/v1/extensions/install?id=registry.test:5000/rd/extension:latest", ""); status != http.StatusNoContent {
+		t.Errorf("expected status 204 installing the installed version, got %d", status)
+	}
+	if status, _ := request(t, server, "POST", "/v1/extensions/install?id=registry.test:5000/rd/extension%40"+digest, ""); status != http.StatusCreated {
+		t.Fatalf("expected status 201 pinning extension, got %d", status)
+	}
+	if _, body := request(t, server, "GET", "/v1/extensions", ""); !strings.Contains(body, `"registry.test:5000/rd/extension":{"version":"`+digest+`"`) {
+		t.Errorf("expected the extension to be pinned to the digest, got %s", body)
+	}
+	if status, _ := request(t, server, "POST", "/v1/extensions/uninstall?id=registry.test:5000/rd/extension", ""); status != http.StatusCreated {
+		t.Errorf("expected status 201 uninstalling extension, got %d", status)
+	}
+}
+
+func TestFailures(t *testing.T) {
+	state := defaultState()
+	state.Failures["GET /v1/backend_state"] = Failure{Status: http.StatusInternalServerError, Body: "boom"}
+	server := newTestServer(t, state)
+	status, body := request(t, server, "GET", "/v1/backend_state", "")
+	if status != http.StatusInternalServerError || body != "boom" {
+		t.Errorf("expected canned failure, got %d %s", status, body)
+	}
+}
+
+func TestUnknownEndpoint(t *testing.T) {
+	server := newTestServer(t, defaultState())
+	status, body := request(t, server, "GET", "/v1/nonexistent", "")
+	if status != http.StatusNotFound || body != "Unknown command: GET /v1/nonexistent" {
+		t.Errorf("expected 404, got %d %s", status, body)
+	}
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// State is the canned state served by the mock server. Every field is
+// optional in the state file; missing fields are filled in from the
+// defaults.
+type State struct {
+	// Version is the version of the application.
+	Version           string               `json:"version"`
+	Settings          map[string]any       `json:"settings"`
+	LockedSettings    map[string]any       `json:"lockedSettings"`
+	TransientSettings map[string]any       `json:"transientSettings"`
+	BackendState      BackendState         `json:"backendState"`
+	HostResources     HostResources        `json:"hostResources"`
+	Diagnostics       []Diagnostic         `json:"diagnostics"`
+	Extensions        map[string]Extension `json:"extensions"`
+	Snapshots         []Snapshot           `json:"snapshots"`
+	Update            UpdateStatus         `json:"update"`
+	// Failures maps "METHOD /path" (e.g. "PUT /v1/settings") to a canned
+	// error response, to test how clients handle errors.
+	Failures map[string]Failure `json:"failures"`
+}
+
+type BackendState struct {
+	VMState string `json:"vmState"`
+	Locked  bool   `json:"locked"`
+}
+
+type HostResources struct {
+	MemoryInGB int                `json:"memoryInGB"`
+	NumberCPUs int                `json:"numberCPUs"`
+	Limits     HostResourceLimits `json:"limits"`
+}
+
+type HostResourceLimits struct {
+	MemoryInGB int `json:"memoryInGB"`
+	NumberCPUs int `json:"numberCPUs"`
+}
+
+type Diagnostic struct {
+	ID            string `json:"id"`
+	Category      string `json:"category"`
+	Description   string `json:"description"`
+	Documentation string `json:"documentation"`
+	Passed        bool   `json:"passed"`
+	Mute          bool   `json:"mute"`
+	Fixes         []any  `json:"fixes"`
+}
+
+type Extension struct {
+	Version  string            `json:"version"`
+	Metadata map[string]any    `json:"metadata"`
+	Labels   map[string]string `json:"labels"`
+}
+
+type Snapshot struct {
+	Name        string `json:"name"`
+	Created     string `json:"created"`
+	Description string `json:"description,omitempty"`
+}
+
+// UpdateStatus is the state of the updater; checking for updates finds the
+// update, if any, and downloads complete instantly.
+type UpdateStatus struct {
+	Configured  bool   `json:"configured"`
+	Channel     string `json:"channel"`
+	Available   bool   `json:"available"`
+	Downloading bool   `json:"downloading"`
+	Downloaded  bool   `json:"downloaded"`
+	Version     string `json:"version,omitempty"`
+	ReleaseDate string `json:"releaseDate,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+type Failure struct {
+	Status int    `json:"status"`
+	Body   string `json:"body"`
+}
+
+// defaultState returns the state of a freshly installed application with
+// the VM running.
+func defaultState() *State {
+	return &State{
+		Settings: map[string]any{
+			"version": 10,
+			"application": map[string]any{
+				"adminAccess": false,
+				"extensions": map[string]any{
+					"allowed": map[string]any{"enabled": false, "list": []any{}},
+				},
+			},
+			"containerEngine": map[string]any{
+				"allowedImages": map[string]any{"enabled": false, "patterns": []any{}},
+				"name":          "moby",
+			},
+			"kubernetes": map[string]any{
+				"enabled": true,
+				"version": "1.28.4",
+				"port":    6443,
+			},
+			"virtualMachine": map[string]any{
+				"memoryInGB": 6,
+				"numberCPUs": 2,
+			},
+		},
+		Version:           "1.11.1",
+		LockedSettings:    map[string]any{},
+		TransientSettings: map[string]any{"noModalDialogs": false, "preferences": map[string]any{}},
+		BackendState:      BackendState{VMState: "STARTED"},
+		HostResources: HostResources{
+			MemoryInGB: 16,
+			NumberCPUs: 8,
+			Limits:     HostResourceLimits{MemoryInGB: 12, NumberCPUs: 8},
+		},
+		Diagnostics: []Diagnostic{
+			{
+				ID:            "CONNECTED_TO_INTERNET",
+				Category:      "Networking",
+				Description:   "The application cannot reach the general internet for updated kubernetes versions and other components, but can still operate.",
+				Documentation: "path#connected_to_internet",
+				Passed:        true,
+				Fixes:         []any{},
+			},
+		},
+		Extensions: map[string]Extension{},
+		Snapshots:  []Snapshot{},
+		Update:     UpdateStatus{Configured: true, Version: "1.11.1"},
+		Failures:   map[string]Failure{},
+	}
+}
+
+// loadState reads the state file, if given, over the default state.
+func loadState(path string) (*State, error) {
+	state := defaultState()
+	if path == "" {
+		return state, nil
+	}
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state file: %w", err)
+	}
+	var loaded State
+	if err := json.Unmarshal(contents, &loaded); err != nil {
+		return nil, fmt.Errorf("failed to parse state file %q: %w", path, err)
+	}
+	if loaded.Version != "" {
+		state.Version = loaded.Version
+	}
+	if loaded.Settings != nil {
+		mergeSettings(state.Settings, loaded.Settings)
+	}
+	if loaded.LockedSettings != nil {
+		state.LockedSettings = loaded.LockedSettings
+	}
+	if loaded.TransientSettings != nil {
+		mergeSettings(state.TransientSettings, loaded.TransientSettings)
+	}
+	if loaded.BackendState.VMState != "" {
+		state.BackendState = loaded.BackendState
+	}
+	if loaded.HostResources.MemoryInGB != 0 {
+		state.HostResources = loaded.HostResources
+	}
+	if loaded.Diagnostics != nil {
+		state.Diagnostics = loaded.Diagnostics
+	}
+	if loaded.Extensions != nil {
+		state.Extensions = loaded.Extensions
+	}
+	if loaded.Snapshots != nil {
+		state.Snapshots = loaded.Snapshots
+	}
+	if loaded.Update.Version != "" {
+		state.Update = loaded.Update
+	}
+	if loaded.Failures != nil {
+		state.Failures = loaded.Failures
+	}
+	return state, nil
+}
+
+// mergeSettings recursively copies the values in changes into settings.
+func mergeSettings(settings, changes map[string]any) {
+	for key, value := range changes {
+		changedMap, changedIsMap := value.(map[string]any)
+		existingMap, existingIsMap := settings[key].(map[string]any)
+		if changedIsMap && existingIsMap {
+			mergeSettings(existingMap, changedMap)
+		} else {
+			settings[key] = value
+		}
+	}
+}
+
+// isLocked returns whether any of the changes touch a locked setting with a
+// different value.
+func isLocked(settings, locked, changes map[string]any) (string, bool) {
+	for key, value := range changes {
+		lockedValue, ok := locked[key]
+		if !ok {
+			continue
+		}
+		changedMap, changedIsMap := value.(map[string]any)
+		lockedMap, lockedIsMap := lockedValue.(map[string]any)
+		if changedIsMap && lockedIsMap {
+			current, _ := settings[key].(map[string]any)
+			if field, found := isLocked(current, lockedMap, changedMap); found {
+				return key + "." + field, true
+			}
+			continue
+		}
+		if lockedValue == true {
+			if current, ok := settings[key]; !ok || fmt.Sprint(current) != fmt.Sprint(value) {
+				return key, true
+			}
+		}
+	}
+	return "", false
+}
+
+func newSnapshot(name, description string) Snapshot {
+	return Snapshot{Name: name, Created: time.Now().UTC().Format(time.RFC3339), Description: description}
+}
@@ -23,18 +23,26 @@ import (
 	"io"
 	"os"
 	"regexp"
+	"strings"
 
 	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/client"
 	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/config"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/output"
 	"github.com/spf13/cobra"
 )
 
 var apiSettings struct {
-	Method    string
-	InputFile string
-	Body      string
+	Method      string
+	InputFile   string
+	Body        string
+	ShowSecrets bool
+	Output      string
+	Field       string
 }
 
+// rawFormat writes API responses as the server sent them.
+const rawFormat = "raw"
+
 // apiCmd represents the api command
 var apiCmd = &cobra.Command{
 	Use:   "api",
@@ -47,6 +55,11 @@ Two ways of specifying a body:
 
 2. --body|-b string: For the 'PUT /settings' endpoint, this must be a valid JSON string.
 
+Successful JSON responses can be written as indented JSON or YAML with --output,
+and a single value can be extracted with --field, e.g. '--field kubernetes.version'
+or a JSONPath template such as '--field {.containerEngine.name}', without piping
+them through jq.
+
 The API is currently at version 1, but is still considered internal and experimental, and
 is subject to change without any advance notice.
 `,
@@ -57,9 +70,14 @@ is subject to change without any advance notice.
 
 func init() {
 	rootCmd.AddCommand(apiCmd)
+	// Only GET requests are allowed in read-only mode; see doAPICommand.
+	markReadOnly(apiCmd)
 	apiCmd.Flags().StringVarP(&apiSettings.Method, "method", "X", "", "method to use")
 	apiCmd.Flags().StringVarP(&apiSettings.InputFile, "input", "", "", "file containing JSON payload to upload (- for standard input)")
 	apiCmd.Flags().StringVarP(&apiSettings.Body, "body", "b", "", "string containing JSON payload to upload")
+	apiCmd.Flags().BoolVar(&apiSettings.ShowSecrets, "show-secrets", false, "ask the server not to redact secrets in the response")
+	output.AddFlag(apiCmd.Flags(), &apiSettings.Output, rawFormat, output.JSON, output.YAML)
+	apiCmd.Flags().StringVar(&apiSettings.Field, "field", "", "write only this field of the response, given as a dotted path or a JSONPath template")
 }
 
 func doAPICommand(cmd *cobra.Command, args []string) error {
@@ -87,12 +105,33 @@ func doAPICommand(cmd *cobra.Command, args []string) error {
 	if apiSettings.InputFile != "" && apiSettings.Body != "" {
 		return fmt.Errorf("api command: --body and --input options cannot both be specified")
 	}
+	formatter, err := apiFormatter()
+	if err != nil {
+		return err
+	}
 	// No longer emit usage info on errors
 	cmd.SilenceUsage = true
-	if apiSettings.InputFile != "" {
-		if apiSettings.Method == "" {
+	if apiSettings.Method == "" {
+		if apiSettings.InputFile != "" || apiSettings.Body != "" {
 			apiSettings.Method = "PUT"
+		} else {
+			apiSettings.Method = "GET"
+		}
+	}
+	if readOnlyMode() && !strings.EqualFold(apiSettings.Method, "GET") {
+		return readOnlyError(fmt.Sprintf("method %s", apiSettings.Method))
+	}
+	if apiSettings.ShowSecrets {
+		if readOnlyMode() {
+			return readOnlyError("--show-secrets")
 		}
+		separator := "?"
+		if strings.Contains(endpoint, "?") {
+			separator = "&"
+		}
+		endpoint += separator + client.ShowSecretsQuery
+	}
+	if apiSettings.InputFile != "" {
 		if apiSettings.InputFile == "-" {
 			contents, err = io.ReadAll(os.Stdin)
 		} else {
@@ -104,20 +143,44 @@ func doAPICommand(cmd *cobra.Command, args []string) error {
 		response, err := rdClient.DoRequestWithPayload(apiSettings.Method, endpoint, bytes.NewBuffer(contents))
 		result, errorPacket, err = client.ProcessRequestForAPI(response, err)
 	} else if apiSettings.Body != "" {
-		if apiSettings.Method == "" {
-			apiSettings.Method = "PUT"
-		}
 		response, err := rdClient.DoRequestWithPayload(apiSettings.Method, endpoint, bytes.NewBufferString(apiSettings.Body))
 		result, errorPacket, err = client.ProcessRequestForAPI(response, err)
 	} else {
-		if apiSettings.Method == "" {
-			apiSettings.Method = "GET"
-		}
 		result, errorPacket, err = client.ProcessRequestForAPI(rdClient.DoRequest(apiSettings.Method, endpoint))
 	}
+	if err == nil && errorPacket == nil && formatter != nil && len(result) > 0 {
+		if !json.Valid(result) {
+			return fmt.Errorf("api command: the response is not JSON; use --output %s", rawFormat)
+		}
+		return formatter.Write(os.Stdout, result)
+	}
 	return displayAPICallResult(result, errorPacket, err)
 }
 
+// apiFormatter returns the formatter of successful responses selected with
+// --output or --field, or nil if they are written as they are.
+func apiFormatter() (*output.Formatter, error) {
+	spec := apiSettings.Output
+	if apiSettings.Field != "" {
+		if spec != rawFormat {
+			return nil, fmt.Errorf("api command: --field and --output options cannot both be specified")
+		}
+		path := apiSettings.Field
+		if !strings.Contains(path, "{") {
+			path = "{." + strings.TrimPrefix(path, ".") + "}"
+		}
+		spec = output.JSONPathPrefix + path
+	}
+	formatter, err := output.NewFormatter(spec, rawFormat, output.JSON, output.YAML)
+	if err != nil {
+		return nil, err
+	}
+	if formatter.Format == rawFormat {
+		return nil, nil
+	}
+	return formatter, nil
+}
+
 func displayAPICallResult(result []byte, errorPacket *client.APIError, err error) error {
 	if err != nil {
 		return err
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIFormatter(t *testing.T) {
+	response := []byte(`{"kubernetes": {"version": "1.27.3"}, "containerEngine": {"name": "moby"}}`)
+	testCases := []struct {
+		Output   string
+		Field    string
+		Expected string
+	}{
+		{Output: "json", Expected: "{\n  \"kubernetes\": {\n    \"version\": \"1.27.3\"\n  },\n  \"containerEngine\": {\n    \"name\": \"moby\"\n  }\n}\n"},
+		{Output: "yaml", Expected: "containerEngine:\n  name: moby\nkubernetes:\n  version: 1.27.3\n"},
+		{Output: "jsonpath={.containerEngine.name}", Expected: "moby\n"},
+		{Output: rawFormat, Field: "kubernetes.version", Expected: "1.27.3\n"},
+		{Output: rawFormat, Field: ".kubernetes.version", Expected: "1.27.3\n"},
+		{Output: rawFormat, Field: "engine={.containerEngine.name}", Expected: "engine=moby\n"},
+		{Output: rawFormat, Field: "{.containerEngine}", Expected: `{"name":"moby"}` + "\n"},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.Output+" "+testCase.Field, func(t *testing.T) {
+			apiSettings.Output, apiSettings.Field = testCase.Output, testCase.Field
+			t.Cleanup(func() { apiSettings.Output, apiSettings.Field = rawFormat, "" })
+			formatter, err := apiFormatter()
+			require.NoError(t, err)
+			require.NotNil(t, formatter)
+			var buf bytes.Buffer
+			require.NoError(t, formatter.Write(&buf, response))
+			assert.Equal(t, testCase.Expected, buf.String())
+		})
+	}
+	t.Run("raw", func(t *testing.T) {
+		apiSettings.Output = rawFormat
+		formatter, err := apiFormatter()
+		require.NoError(t, err)
+		assert.Nil(t, formatter)
+	})
+	t.Run("field and output", func(t *testing.T) {
+		apiSettings.Output, apiSettings.Field = "json", "kubernetes.version"
+		t.Cleanup(func() { apiSettings.Output, apiSettings.Field = rawFormat, "" })
+		_, err := apiFormatter()
+		assert.ErrorContains(t, err, "cannot both be specified")
+	})
+}
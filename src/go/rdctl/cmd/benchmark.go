@@ -0,0 +1,197 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"slices"
+	"text/tabwriter"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/benchmark"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/client"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/config"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/exitcode"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/output"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/vm"
+	"github.com/spf13/cobra"
+)
+
+var benchmarkSettings struct {
+	SizeInMiB int64
+	Output    string
+}
+
+var benchmarkCmd = &cobra.Command{
+	Use:   "benchmark [BENCHMARK...]",
+	Short: "Measure the storage and network throughput of the VM",
+	Long: `Measure the throughput of the VM, by default with all the benchmarks:
+
+  vm-disk     writing and reading a file on the disk of the VM
+  bind-mount  writing and reading a file in a directory of the home directory,
+              which the VM shares with the host, as containers bind-mount it
+  network     sending data between the host and a port of the VM forwarded to
+              localhost, as the published ports of containers are
+
+Each benchmark transfers --size MiB in each direction, and reports MiB/s, so
+that runs with different mount types or backends can be compared. The report
+also gives the platform and the VM and mount types; attach it, with
+--output json, to reports of performance issues.`,
+	ValidArgs: benchmark.Names,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := cobra.OnlyValidArgs(cmd, args); err != nil {
+			return err
+		}
+		if benchmarkSettings.SizeInMiB <= 0 {
+			return fmt.Errorf("invalid size %d: must be positive", benchmarkSettings.SizeInMiB)
+		}
+		formatter, err := output.NewFormatter(benchmarkSettings.Output, tableFormat, output.JSON)
+		if err != nil {
+			return err
+		}
+		cmd.SilenceUsage = true
+		if len(args) == 0 {
+			args = benchmark.Names
+		}
+		appPaths, err := paths.GetPaths()
+		if err != nil {
+			return fmt.Errorf("failed to get paths: %w", err)
+		}
+		runner, err := vm.New(appPaths)
+		if err != nil {
+			return err
+		}
+		if _, err := runner.RootOutput("true"); err != nil {
+			return exitcode.WithCode(fmt.Errorf("the VM is not running: %w", err), exitcode.NotRunning)
+		}
+		report := runBenchmarks(runner, args, benchmarkSettings.SizeInMiB*1024*1024)
+		if formatter.Format != tableFormat {
+			err = formatter.Write(os.Stdout, report)
+		} else {
+			err = writeBenchmarkReport(os.Stdout, report)
+		}
+		if err != nil {
+			return err
+		}
+		switch failed := report.Failed(); {
+		case failed == len(report.Results):
+			return errors.New("all the benchmarks failed")
+		case failed > 0:
+			return exitcode.WithCode(errors.New("some benchmarks failed"), exitcode.PartialSuccess)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(benchmarkCmd)
+	benchmarkCmd.Flags().Int64Var(&benchmarkSettings.SizeInMiB, "size", 256, "MiB to transfer in each direction")
+	output.AddFlag(benchmarkCmd.Flags(), &benchmarkSettings.Output, tableFormat, output.JSON)
+}
+
+// runBenchmarks runs the named benchmarks, in their usual order.
+func runBenchmarks(runner vm.Runner, names []string, size int64) *benchmark.Report {
+	report := benchmark.NewReport()
+	report.VMType, report.MountType = benchmarkBackend()
+	for _, name := range benchmark.Names {
+		if !slices.Contains(names, name) {
+			continue
+		}
+		progress := output.StartProgress(fmt.Sprintf("Running the %s benchmark", name))
+		switch name {
+		case benchmark.VMDisk:
+			report.Results = append(report.Results, benchmark.Disk(runner, name, benchmark.VMDiskDir, size)...)
+		case benchmark.BindMount:
+			report.Results = append(report.Results, bindMountBenchmark(runner, size)...)
+		case benchmark.Network:
+			report.Results = append(report.Results, benchmark.NetworkThroughput(runner, size)...)
+		}
+		progress.Stop()
+	}
+	return report
+}
+
+// bindMountBenchmark runs the disk benchmark in a temporary directory of the
+// home directory, which the VM shares.
+func bindMountBenchmark(runner vm.Runner, size int64) []benchmark.Result {
+	failed := func(err error) []benchmark.Result {
+		return []benchmark.Result{
+			{Benchmark: benchmark.BindMount, Operation: "write", Error: err.Error()},
+			{Benchmark: benchmark.BindMount, Operation: "read", Error: err.Error()},
+		}
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return failed(err)
+	}
+	dir, err := os.MkdirTemp(home, ".rd-benchmark-")
+	if err != nil {
+		return failed(err)
+	}
+	defer os.RemoveAll(dir)
+	guestDir, err := benchmark.GuestPath(runner, dir)
+	if err != nil {
+		return failed(err)
+	}
+	return benchmark.Disk(runner, benchmark.BindMount, guestDir, size)
+}
+
+// benchmarkBackend returns the VM and mount types, if the application is
+// running; they are only reported.
+func benchmarkBackend() (vmType, mountType string) {
+	connectionInfo, err := config.GetConnectionInfo(true)
+	if err != nil || connectionInfo == nil {
+		return "", ""
+	}
+	rdClient := client.NewRDClient(connectionInfo)
+	body, err := client.ProcessRequestForUtility(rdClient.DoRequest("GET", client.VersionCommand("", "settings")))
+	if err != nil {
+		return "", ""
+	}
+	return parseBenchmarkBackend(body)
+}
+
+func parseBenchmarkBackend(body []byte) (vmType, mountType string) {
+	if runtime.GOOS == "windows" {
+		// WSL shares the drives of the host itself, whatever the settings.
+		return "wsl", ""
+	}
+	var settings struct {
+		Experimental struct {
+			VirtualMachine struct {
+				Type  string `json:"type"`
+				Mount struct {
+					Type string `json:"type"`
+				} `json:"mount"`
+			} `json:"virtualMachine"`
+		} `json:"experimental"`
+	}
+	if err := json.Unmarshal(body, &settings); err != nil {
+		return "", ""
+	}
+	return settings.Experimental.VirtualMachine.Type, settings.Experimental.VirtualMachine.Mount.Type
+}
+
+func writeBenchmarkReport(w io.Writer, report *benchmark.Report) error {
+	fmt.Fprintf(w, "Platform: %s", report.Platform)
+	if report.VMType != "" {
+		fmt.Fprintf(w, ", VM type: %s", report.VMType)
+	}
+	if report.MountType != "" {
+		fmt.Fprintf(w, ", mount type: %s", report.MountType)
+	}
+	fmt.Fprintf(w, "\n\n")
+	writer := tabwriter.NewWriter(w, 0, 4, 4, ' ', 0)
+	fmt.Fprintf(writer, "BENCHMARK\tOPERATION\tMiB/s\tSECONDS\n")
+	for _, result := range report.Results {
+		if result.Error != "" {
+			fmt.Fprintf(writer, "%s\t%s\t-\t-\tfailed: %s\n", result.Benchmark, result.Operation, result.Error)
+			continue
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%.1f\t%.2f\n", result.Benchmark, result.Operation, result.MiBPerSecond, result.Seconds)
+	}
+	return writer.Flush()
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"bytes"
+	"runtime"
+	"testing"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/benchmark"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBenchmarkBackend(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the settings are ignored with WSL")
+	}
+	vmType, mountType := parseBenchmarkBackend([]byte(`{"experimental":{"virtualMachine":{"type":"vz","mount":{"type":"virtiofs"}}}}`))
+	assert.Equal(t, "vz", vmType)
+	assert.Equal(t, "virtiofs", mountType)
+}
+
+func TestWriteBenchmarkReport(t *testing.T) {
+	report := &benchmark.Report{
+		Platform:  "darwin/arm64",
+		VMType:    "vz",
+		MountType: "virtiofs",
+		Results: []benchmark.Result{
+			{Benchmark: benchmark.VMDisk, Operation: "write", Bytes: 1 << 28, Seconds: 0.5, MiBPerSecond: 512},
+			{Benchmark: benchmark.Network, Operation: "upload", Error: "port 4000 of the VM wasn't forwarded"},
+		},
+	}
+	var buf bytes.Buffer
+	require.NoError(t, writeBenchmarkReport(&buf, report))
+	assert.Equal(t, `Platform: darwin/arm64, VM type: vz, mount type: virtiofs
+
+BENCHMARK    OPERATION    MiB/s    SECONDS
+vm-disk      write        512.0    0.50
+network      upload       -        -    failed: port 4000 of the VM wasn't forwarded
+`, buf.String())
+}
@@ -0,0 +1,201 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/apicache"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/client"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/config"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/logs"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/snapshot"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// The completion functions query the application, or the cache of its
+// answers; they offer nothing when it can't be reached, rather than failing.
+
+// registerSettingCompletions completes the values of the settings flags of the
+// command: the values allowed by the settings schema, or the Kubernetes
+// versions the application can run. It must be called before any flag that
+// isn't a setting is added.
+func registerSettingCompletions(cmd *cobra.Command) {
+	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+		if flag.Value.Type() != "string" {
+			return
+		}
+		name := flag.Name
+		_ = cmd.RegisterFlagCompletionFunc(name, func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+			return completeSettingValue(name)
+		})
+	})
+}
+
+func completeSettingValue(flagName string) ([]string, cobra.ShellCompDirective) {
+	connectionInfo, err := config.GetConnectionInfo(true)
+	if err != nil || connectionInfo == nil {
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+	cache, err := newAPICache(connectionInfo)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+	if flagName == "kubernetes.version" || flagName == "kubernetes-version" {
+		body, err := cache.Get(apicache.KubernetesVersions)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return kubernetesVersionCompletions(body), cobra.ShellCompDirectiveNoFileComp
+	}
+	body, err := cache.Get(apicache.SettingsSchema)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+	values := settingEnum(body, flagName)
+	if values == nil {
+		// Paths and free-form values.
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+	return values, cobra.ShellCompDirectiveNoFileComp
+}
+
+// kubernetesVersionCompletions returns the versions in the answer to the
+// Kubernetes versions request, described by their channels.
+func kubernetesVersionCompletions(body []byte) []string {
+	var versions []kubernetesVersion
+	if err := json.Unmarshal(body, &versions); err != nil {
+		return nil
+	}
+	completions := make([]string, 0, len(versions))
+	for _, version := range versions {
+		completion := version.Version
+		if len(version.Channels) > 0 {
+			completion += "\t" + strings.Join(version.Channels, ",")
+		}
+		completions = append(completions, completion)
+	}
+	return completions
+}
+
+// settingEnum returns the values allowed for the setting of the flag, such as
+// "container-engine.name", by the settings schema; nil if any value is.
+func settingEnum(schemaBody []byte, flagName string) []string {
+	type schemaNode struct {
+		Properties map[string]json.RawMessage `json:"properties"`
+		Enum       []any                      `json:"enum"`
+	}
+	var node schemaNode
+	if err := json.Unmarshal(schemaBody, &node); err != nil {
+		return nil
+	}
+	for _, part := range strings.Split(flagName, ".") {
+		property, ok := node.Properties[kebabToCamel(part)]
+		if !ok {
+			return nil
+		}
+		node = schemaNode{}
+		if err := json.Unmarshal(property, &node); err != nil {
+			return nil
+		}
+	}
+	if len(node.Enum) == 0 {
+		return nil
+	}
+	values := make([]string, 0, len(node.Enum))
+	for _, value := range node.Enum {
+		values = append(values, fmt.Sprint(value))
+	}
+	return values
+}
+
+// kebabToCamel returns the name of a setting for the part of a flag name,
+// such as "virtualMachine" for "virtual-machine".
+func kebabToCamel(name string) string {
+	words := strings.Split(name, "-")
+	for i := 1; i < len(words); i++ {
+		if words[i] != "" {
+			words[i] = strings.ToUpper(words[i][:1]) + words[i][1:]
+		}
+	}
+	return strings.Join(words, "")
+}
+
+// completeLogComponents completes the component of the logs as the only
+// argument.
+func completeLogComponents(_ *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	appPaths, err := paths.GetPaths()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	components, err := logs.Components(appPaths.Logs)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return components, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeSnapshotNames completes the name of a snapshot as the only argument.
+func completeSnapshotNames(_ *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	manager, err := snapshot.NewManager()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	snapshots, err := manager.List(false)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	completions := make([]string, 0, len(snapshots))
+	for _, entry := range snapshots {
+		completion := entry.Name
+		if entry.Description != "" {
+			completion += "\t" + strings.SplitN(entry.Description, "\n", 2)[0]
+		}
+		completions = append(completions, completion)
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeExtensionIDs completes the ID of an installed extension as the only
+// argument.
+func completeExtensionIDs(_ *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	connectionInfo, err := config.GetConnectionInfo(true)
+	if err != nil || connectionInfo == nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	rdClient := client.NewRDClient(connectionInfo)
+	body, err := client.ProcessRequestForUtility(rdClient.DoRequest("GET", client.VersionCommand("", "extensions")))
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return extensionCompletions(body), cobra.ShellCompDirectiveNoFileComp
+}
+
+// extensionCompletions returns the IDs in the list of installed extensions,
+// described by their version.
+func extensionCompletions(body []byte) []string {
+	var extensions map[string]struct {
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal(body, &extensions); err != nil {
+		return nil
+	}
+	completions := make([]string, 0, len(extensions))
+	for id, info := range extensions {
+		completions = append(completions, fmt.Sprintf("%s\t%s", id, info.Version))
+	}
+	sort.Strings(completions)
+	return completions
+}
@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSettingEnum(t *testing.T) {
+	schema := []byte(`{"type": "object", "properties": {
+		"containerEngine": {"type": "object", "properties": {
+			"name": {"type": "string", "enum": ["containerd", "docker", "moby"]}}},
+		"experimental": {"type": "object", "properties": {
+			"virtualMachine": {"type": "object", "properties": {
+				"mount": {"type": "object", "properties": {
+					"type": {"type": "string", "enum": ["reverse-sshfs", "9p", "virtiofs"]}}}}}}},
+		"kubernetes": {"type": "object", "properties": {
+			"port": {"type": "integer"}}}}}`)
+	assert.Equal(t, []string{"containerd", "docker", "moby"}, settingEnum(schema, "container-engine.name"))
+	assert.Equal(t, []string{"reverse-sshfs", "9p", "virtiofs"}, settingEnum(schema, "experimental.virtual-machine.mount.type"))
+	assert.Nil(t, settingEnum(schema, "kubernetes.port"))
+	assert.Nil(t, settingEnum(schema, "container-engine"))
+	assert.Nil(t, settingEnum(schema, "missing.setting"))
+}
+
+func TestKubernetesVersionCompletions(t *testing.T) {
+	body := []byte(`[{"version": "1.29.1", "channels": ["latest", "v1.29"]}, {"version": "1.28.6"}]`)
+	assert.Equal(t, []string{"1.29.1\tlatest,v1.29", "1.28.6"}, kubernetesVersionCompletions(body))
+}
+
+func TestExtensionCompletions(t *testing.T) {
+	body := []byte(`{"docker/logs-explorer-extension": {"version": "0.2.2"}, "ashleyr/epinio": {"version": "latest"}}`)
+	assert.Equal(t, []string{"ashleyr/epinio\tlatest", "docker/logs-explorer-extension\t0.2.2"}, extensionCompletions(body))
+}
@@ -0,0 +1,17 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect the connection settings of rdctl",
+	Long: `Inspect the settings rdctl uses to connect to Rancher Desktop, which come from,
+in order of precedence: the command-line flags, the RDCTL_* environment
+variables, the selected profile, and the config file.`,
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+}
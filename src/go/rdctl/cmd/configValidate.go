@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/checks"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/client"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/config"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/output"
+	"github.com/spf13/cobra"
+)
+
+var configValidateSettings struct {
+	Output string
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check that rdctl can connect to Rancher Desktop",
+	Long: `Check that the config file can be read, that the connection settings are
+complete and valid, and that the application accepts them. The command fails
+if any check fails.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		formatter, err := output.NewFormatter(configValidateSettings.Output, tableFormat, output.JSON)
+		if err != nil {
+			return err
+		}
+		cmd.SilenceUsage = true
+		results := validateConfig()
+		if formatter.Format != tableFormat {
+			err = formatter.Write(os.Stdout, results)
+		} else {
+			err = checks.WriteTable(os.Stdout, results)
+		}
+		if err != nil {
+			return err
+		}
+		return checks.Err(results)
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configValidateCmd)
+	output.AddFlag(configValidateCmd.Flags(), &configValidateSettings.Output, tableFormat, output.JSON)
+	markReadOnly(configValidateCmd)
+}
+
+func validateConfig() []checks.Check {
+	results := config.CheckConfigFile()
+	settingsCheck := checks.Check{Name: "connection settings", Status: checks.OK}
+	serverCheck := checks.Check{Name: "server", Status: checks.Skipped, Message: "no valid connection settings"}
+	connectionInfo, err := config.GetConnectionInfo(false)
+	if err != nil {
+		settingsCheck.Status = checks.Failed
+		settingsCheck.Message = err.Error()
+		return append(results, settingsCheck, serverCheck)
+	}
+	settingsCheck.Message = fmt.Sprintf("connecting to %s as %s", connectionInfo.Address(), connectionInfo.User)
+	if connectionInfo.TLS {
+		settingsCheck.Message += " with TLS"
+	}
+	rdClient := client.NewRDClient(connectionInfo)
+	_, err = client.ProcessRequestForUtility(rdClient.DoRequest("GET", client.VersionCommand("", "about")))
+	switch {
+	case err == nil:
+		serverCheck.Status = checks.OK
+		serverCheck.Message = "the application accepted the credentials"
+	case errors.Is(err, client.ErrConnectionRefused):
+		serverCheck.Status = checks.Failed
+		serverCheck.Message = fmt.Sprintf("nothing listens on %s; is Rancher Desktop running?", connectionInfo.Address())
+	default:
+		serverCheck.Status = checks.Failed
+		serverCheck.Message = err.Error()
+	}
+	return append(results, settingsCheck, serverCheck)
+}
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/config"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/output"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/redact"
+	"github.com/spf13/cobra"
+)
+
+var configViewSettings struct {
+	ShowSecrets bool
+	Output      string
+}
+
+// effectiveConfig is the connection info rdctl uses, and where it comes from.
+type effectiveConfig struct {
+	ConfigPath string `json:"configPath"`
+	Profile    string `json:"profile,omitempty"`
+	User       string `json:"user"`
+	Password   string `json:"password"`
+	Host       string `json:"host"`
+	Port       int    `json:"port"`
+	Socket     string `json:"socket,omitempty"`
+	TLS        bool   `json:"tls,omitempty"`
+	CACert     string `json:"caCert,omitempty"`
+	ClientCert string `json:"clientCert,omitempty"`
+	ClientKey  string `json:"clientKey,omitempty"`
+	// Timeout and RetryBackoff are durations, e.g. "10s".
+	Timeout      config.Duration `json:"timeout,omitempty"`
+	Retries      int             `json:"retries,omitempty"`
+	RetryBackoff config.Duration `json:"retryBackoff,omitempty"`
+}
+
+var configViewCmd = &cobra.Command{
+	Use:   "view",
+	Short: "Show the effective connection settings",
+	Long: `Show the connection settings rdctl uses, after merging the config file, the
+selected profile, the RDCTL_* environment variables, and the command-line flags.
+The password is redacted unless --show-secrets is given.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		formatter, err := output.NewFormatter(configViewSettings.Output, tableFormat, output.JSON)
+		if err != nil {
+			return err
+		}
+		cmd.SilenceUsage = true
+		if configViewSettings.ShowSecrets && readOnlyMode() {
+			return readOnlyError("--show-secrets")
+		}
+		connectionInfo, err := config.GetConnectionInfo(false)
+		if err != nil {
+			return fmt.Errorf("failed to get connection info: %w", err)
+		}
+		effective := effectiveConfig{
+			ConfigPath:   config.ConfigPath(),
+			Profile:      config.ProfileName(),
+			User:         connectionInfo.User,
+			Password:     redact.Redacted,
+			Host:         connectionInfo.Host,
+			Port:         connectionInfo.Port,
+			Socket:       connectionInfo.Socket,
+			TLS:          connectionInfo.TLS,
+			CACert:       connectionInfo.CACert,
+			ClientCert:   connectionInfo.ClientCert,
+			ClientKey:    connectionInfo.ClientKey,
+			Timeout:      connectionInfo.Timeout,
+			Retries:      connectionInfo.Retries,
+			RetryBackoff: connectionInfo.RetryBackoff,
+		}
+		if configViewSettings.ShowSecrets {
+			effective.Password = connectionInfo.Password
+		}
+		if formatter.Format != tableFormat {
+			return formatter.Write(os.Stdout, effective)
+		}
+		writer := tabwriter.NewWriter(os.Stdout, 0, 4, 4, ' ', 0)
+		fmt.Fprintf(writer, "CONFIG FILE\t%s\n", effective.ConfigPath)
+		if effective.Profile != "" {
+			fmt.Fprintf(writer, "PROFILE\t%s\n", effective.Profile)
+		}
+		fmt.Fprintf(writer, "USER\t%s\n", effective.User)
+		fmt.Fprintf(writer, "PASSWORD\t%s\n", effective.Password)
+		fmt.Fprintf(writer, "HOST\t%s\n", effective.Host)
+		fmt.Fprintf(writer, "PORT\t%d\n", effective.Port)
+		if effective.Socket != "" {
+			fmt.Fprintf(writer, "SOCKET\t%s\n", effective.Socket)
+		}
+		if effective.TLS {
+			fmt.Fprintf(writer, "TLS\t%t\n", effective.TLS)
+		}
+		for _, setting := range []struct{ name, path string }{
+			{"CA CERT", effective.CACert},
+			{"CLIENT CERT", effective.ClientCert},
+			{"CLIENT KEY", effective.ClientKey},
+		} {
+			if setting.path != "" {
+				fmt.Fprintf(writer, "%s\t%s\n", setting.name, setting.path)
+			}
+		}
+		if effective.Timeout != 0 {
+			fmt.Fprintf(writer, "TIMEOUT\t%s\n", time.Duration(effective.Timeout))
+		}
+		if effective.Retries != 0 {
+			fmt.Fprintf(writer, "RETRIES\t%d\n", effective.Retries)
+		}
+		if effective.RetryBackoff != 0 {
+			fmt.Fprintf(writer, "RETRY BACKOFF\t%s\n", time.Duration(effective.RetryBackoff))
+		}
+		return writer.Flush()
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configViewCmd)
+	configViewCmd.Flags().BoolVar(&configViewSettings.ShowSecrets, "show-secrets", false, "show the password instead of redacting it")
+	output.AddFlag(configViewCmd.Flags(), &configViewSettings.Output, tableFormat, output.JSON)
+	markReadOnly(configViewCmd)
+}
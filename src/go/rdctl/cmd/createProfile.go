@@ -106,7 +106,8 @@ func createProfile() (string, error) {
 			return "", fmt.Errorf("failed to get connection info: %w", err)
 		}
 		rdClient := client.NewRDClient(connectionInfo)
-		response, err := rdClient.DoRequest("GET", client.VersionCommand("", "settings"))
+		// Profiles must contain the actual values, not redacted ones.
+		response, err := rdClient.DoRequest("GET", client.VersionCommand("", "settings")+"?"+client.ShowSecretsQuery)
 		output, err = client.ProcessRequestForUtility(response, err)
 	}
 	if err != nil {
@@ -0,0 +1,237 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/client"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/config"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/dashboard"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/output"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/vm"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+const clearScreen = "\x1b[H\x1b[2J"
+
+// ctrlC is the key read for Ctrl-C while the terminal is in raw mode, which
+// doesn't turn it into an interrupt.
+const ctrlC = 0x03
+
+var dashboardSettings struct {
+	TUI      bool
+	Interval time.Duration
+	Output   string
+}
+
+var dashboardCmd = &cobra.Command{
+	Use:   "dashboard",
+	Short: "Show the state of Rancher Desktop",
+	Long: `Shows the backend state, Kubernetes and container engine settings, the resource
+limits and actual usage (load average and memory) of the VM, forwarded ports,
+and recent events of the running Rancher Desktop application.
+
+With --tui, the dashboard is shown as an interactive terminal UI that refreshes
+periodically, and offers quick actions:
+
+  r   restart the backend (and with it, Kubernetes)
+  s   open a shell in the VM (see 'rdctl shell')
+  q   quit
+
+Without --tui, the --output flag selects JSON or a JSONPath or Go template
+applied to it instead of the text display, for example:
+
+  rdctl dashboard --output jsonpath='{.backendState.vmState}'
+`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		formatter, err := output.NewFormatter(dashboardSettings.Output, tableFormat, output.JSON)
+		if err != nil {
+			return err
+		}
+		if dashboardSettings.TUI && formatter.Format != tableFormat {
+			return errors.New("--output can't be used with --tui")
+		}
+		cmd.SilenceUsage = true
+		if !dashboardSettings.TUI {
+			// Only the quick actions of the terminal UI need to change anything.
+			config.UseViewerCredentials()
+		}
+		appPaths, err := paths.GetPaths()
+		if err != nil {
+			return fmt.Errorf("failed to get paths: %w", err)
+		}
+		if dashboardSettings.TUI {
+			// The terminal UI outlives restarts of the application, which
+			// change the password.
+			watcher, err := config.WatchConnectionInfo()
+			if err != nil {
+				return fmt.Errorf("failed to get connection info: %w", err)
+			}
+			defer watcher.Close()
+			board := dashboard.NewDashboard(client.NewWatchingRDClient(watcher), appPaths)
+			board.UseColor = output.ColorEnabled(os.Stdout)
+			board.VM = dashboardVM(appPaths)
+			return runDashboardTUI(cmd, board)
+		}
+		connectionInfo, err := config.GetConnectionInfo(false)
+		if err != nil {
+			return fmt.Errorf("failed to get connection info: %w", err)
+		}
+		board := dashboard.NewDashboard(client.NewRDClient(connectionInfo), appPaths)
+		board.UseColor = output.ColorEnabled(os.Stdout)
+		board.VM = dashboardVM(appPaths)
+		if formatter.Format != tableFormat {
+			return formatter.Write(os.Stdout, board.Refresh())
+		}
+		return board.Render(os.Stdout, board.Refresh(), 0)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(dashboardCmd)
+	// The quick actions of the terminal UI are disabled in read-only mode.
+	markReadOnly(dashboardCmd)
+	dashboardCmd.Flags().BoolVar(&dashboardSettings.TUI, "tui", false, "show an interactive, self-refreshing terminal UI")
+	dashboardCmd.Flags().DurationVar(&dashboardSettings.Interval, "interval", 2*time.Second, "refresh interval for the terminal UI")
+	output.AddFlag(dashboardCmd.Flags(), &dashboardSettings.Output, tableFormat, output.JSON)
+}
+
+// dashboardVM returns the runner used to measure the resource usage of the VM,
+// or nil if the VM can't be reached; the usage is then not shown.
+func dashboardVM(appPaths paths.Paths) vm.Runner {
+	runner, err := vm.New(appPaths)
+	if err != nil {
+		return nil
+	}
+	return runner
+}
+
+func runDashboardTUI(cmd *cobra.Command, board *dashboard.Dashboard) error {
+	stdinFd, stdoutFd := int(os.Stdin.Fd()), int(os.Stdout.Fd())
+	if !term.IsTerminal(stdinFd) || !term.IsTerminal(stdoutFd) {
+		return errors.New("--tui requires an interactive terminal")
+	}
+	if dashboardSettings.Interval <= 0 {
+		return fmt.Errorf("invalid refresh interval %s", dashboardSettings.Interval)
+	}
+	inputState, err := term.MakeRaw(stdinFd)
+	if err != nil {
+		return fmt.Errorf("failed to set up terminal input: %w", err)
+	}
+	defer func() { _ = term.Restore(stdinFd, inputState) }()
+	out := crlfWriter{os.Stdout}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	// The key reader waits for an acknowledgement after every key, so that it
+	// doesn't steal input from an interactive shell started by a quick action.
+	keys := make(chan byte)
+	keysAck := make(chan struct{})
+	go readDashboardKeys(keys, keysAck)
+	actionResults := make(chan string)
+	ticker := time.NewTicker(dashboardSettings.Interval)
+	defer ticker.Stop()
+
+	status := board.Refresh()
+	for {
+		width, _, err := term.GetSize(stdoutFd)
+		if err != nil {
+			width = 0
+		}
+		fmt.Fprint(out, clearScreen)
+		if err := board.Render(out, status, width); err != nil {
+			return err
+		}
+		if readOnlyMode() {
+			fmt.Fprint(out, "\n[q] quit (read-only mode)\n")
+		} else {
+			fmt.Fprint(out, "\n[r] restart backend  [s] open shell  [q] quit\n")
+		}
+
+		select {
+		case <-ctx.Done():
+			fmt.Fprint(out, clearScreen)
+			return nil
+		case <-ticker.C:
+			status = board.Refresh()
+		case result := <-actionResults:
+			board.AddEvent("%s", result)
+		case key, ok := <-keys:
+			if !ok {
+				return nil
+			}
+			switch key {
+			case 'q', 'Q', ctrlC:
+				fmt.Fprint(out, clearScreen)
+				return nil
+			case 'r', 'R':
+				if readOnlyMode() {
+					board.AddEvent("can't restart the backend in read-only mode")
+					break
+				}
+				board.AddEvent("restarting the backend")
+				go func() {
+					if err := board.RestartBackend(); err != nil {
+						actionResults <- fmt.Sprintf("failed to restart the backend: %s", err)
+					} else {
+						actionResults <- "backend restart initiated"
+					}
+				}()
+			case 's', 'S':
+				if readOnlyMode() {
+					board.AddEvent("can't open a shell in read-only mode")
+					break
+				}
+				if status.Error != nil || status.BackendState.VMState != "STARTED" {
+					board.AddEvent("can't open a shell: the backend is not running")
+					break
+				}
+				_ = term.Restore(stdinFd, inputState)
+				fmt.Print(clearScreen)
+				if err := doShellCommand(cmd, shellOptions{}); err != nil {
+					board.AddEvent("shell exited: %s", err)
+				}
+				if inputState, err = term.MakeRaw(stdinFd); err != nil {
+					return fmt.Errorf("failed to set up terminal input: %w", err)
+				}
+				status = board.Refresh()
+			}
+			keysAck <- struct{}{}
+		}
+	}
+}
+
+// crlfWriter writes to a terminal in raw mode, which no longer moves to the
+// start of the line on a newline.
+type crlfWriter struct {
+	io.Writer
+}
+
+func (w crlfWriter) Write(p []byte) (int, error) {
+	if _, err := w.Writer.Write(bytes.ReplaceAll(p, []byte("\n"), []byte("\r\n"))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func readDashboardKeys(keys chan<- byte, keysAck <-chan struct{}) {
+	buf := make([]byte, 1)
+	for {
+		if _, err := os.Stdin.Read(buf); err != nil {
+			close(keys)
+			return
+		}
+		keys <- buf[0]
+		<-keysAck
+	}
+}
@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/checks"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/client"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/config"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/devcontainer"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/output"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/tools"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/vm"
+	"github.com/spf13/cobra"
+)
+
+var devcontainerDoctorSettings struct {
+	Workspace string
+	Output    string
+}
+
+var devcontainerCmd = &cobra.Command{
+	Use:   "devcontainer",
+	Short: "Support editor integrations built on dev containers",
+	Long: `Support editor integrations built on dev containers, such as the VS Code Dev
+Containers extension and JetBrains Gateway.
+
+These integrations need the moby container engine. They find it through docker:
+  - On macOS and Linux, the engine listens on ~/.rd/docker.sock, and the docker
+    context named "` + devcontainer.ContextName + `" uses it. When Rancher Desktop has
+    administrative access, /var/run/docker.sock also points to it.
+  - On Windows, the engine listens on the default named pipe,
+    npipe:////./pipe/docker_engine.
+These names do not change across releases, so they can be used in editor
+settings.`,
+}
+
+var devcontainerDoctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check that dev containers can use Rancher Desktop",
+	Long: `Check everything that dev containers need: the container engine, the docker CLI
+and context, and that the workspace is shared with the VM with the same owner.
+Warnings point at settings that break some integrations; the command fails if
+any check fails.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		formatter, err := output.NewFormatter(devcontainerDoctorSettings.Output, tableFormat, output.JSON)
+		if err != nil {
+			return err
+		}
+		cmd.SilenceUsage = true
+		doctor, err := newDevcontainerDoctor(devcontainerDoctorSettings.Workspace)
+		if err != nil {
+			return err
+		}
+		results := doctor.Run()
+		if formatter.Format != tableFormat {
+			err = formatter.Write(os.Stdout, results)
+		} else {
+			err = checks.WriteTable(os.Stdout, results)
+		}
+		if err != nil {
+			return err
+		}
+		return checks.Err(results)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(devcontainerCmd)
+	devcontainerCmd.AddCommand(devcontainerDoctorCmd)
+	markReadOnly(devcontainerDoctorCmd)
+	devcontainerDoctorCmd.Flags().StringVar(&devcontainerDoctorSettings.Workspace, "workspace", "", "directory opened in the dev container (default: the current directory)")
+	output.AddFlag(devcontainerDoctorCmd.Flags(), &devcontainerDoctorSettings.Output, tableFormat, output.JSON)
+}
+
+// newDevcontainerDoctor gathers what the checks need from the application
+// and the host.
+func newDevcontainerDoctor(workspace string) (*devcontainer.Doctor, error) {
+	if workspace == "" {
+		var err error
+		if workspace, err = os.Getwd(); err != nil {
+			return nil, fmt.Errorf("failed to get the current directory: %w", err)
+		}
+	}
+	connectionInfo, err := config.GetConnectionInfo(false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection info: %w", err)
+	}
+	engineName, err := getContainerEngineName(client.NewRDClient(connectionInfo))
+	if err != nil {
+		return nil, err
+	}
+	appPaths, err := paths.GetPaths()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get paths: %w", err)
+	}
+	doctor := &devcontainer.Doctor{
+		EngineName:    engineName,
+		DockerHost:    devcontainer.DockerHost(appPaths),
+		DockerHostEnv: os.Getenv("DOCKER_HOST"),
+		Workspace:     workspace,
+		UID:           os.Getuid(),
+	}
+	if dockerPath, err := tools.Find(appPaths, "docker"); err == nil {
+		doctor.Docker = func(args ...string) ([]byte, error) {
+			var stderr bytes.Buffer
+			cmd := exec.Command(dockerPath, args...)
+			cmd.Stderr = &stderr
+			output, err := cmd.Output()
+			if err != nil && stderr.Len() > 0 {
+				return nil, fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+			}
+			return output, err
+		}
+	}
+	// On Windows, dev containers translate paths of the host to /mnt paths
+	// of WSL themselves.
+	if runtime.GOOS != "windows" {
+		if doctor.VM, err = vm.New(appPaths); err != nil {
+			return nil, err
+		}
+	}
+	return doctor, nil
+}
+
+// getContainerEngineName returns the container engine in the settings.
+func getContainerEngineName(rdClient client.RDClient) (string, error) {
+	body, err := client.ProcessRequestForUtility(rdClient.DoRequest("GET", client.VersionCommand("", "settings")))
+	if err != nil {
+		return "", err
+	}
+	var settings struct {
+		ContainerEngine struct {
+			Name string `json:"name"`
+		} `json:"containerEngine"`
+	}
+	if err := json.Unmarshal(body, &settings); err != nil {
+		return "", fmt.Errorf("failed to unmarshal settings: %w", err)
+	}
+	return settings.ContainerEngine.Name, nil
+}
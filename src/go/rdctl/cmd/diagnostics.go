@@ -0,0 +1,17 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var diagnosticsCmd = &cobra.Command{
+	Use:   "diagnostics",
+	Short: "Run the diagnostic checks of Rancher Desktop",
+	Long: `Run the diagnostic checks of the running Rancher Desktop application, as shown on
+its Diagnostics page. See "rdctl doctor" for the checks that don't need the
+application to be running.`,
+}
+
+func init() {
+	rootCmd.AddCommand(diagnosticsCmd)
+}
@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/checks"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/client"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/config"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/output"
+	"github.com/spf13/cobra"
+)
+
+var diagnosticsRunSettings struct {
+	Categories []string
+	Output     string
+}
+
+// diagnosticResults is the result of running the checks, as returned by the
+// API.
+type diagnosticResults struct {
+	LastUpdate string             `json:"last_update"`
+	Checks     []diagnosticResult `json:"checks"`
+}
+
+type diagnosticResult struct {
+	ID            string `json:"id"`
+	Category      string `json:"category"`
+	Description   string `json:"description"`
+	Documentation string `json:"documentation,omitempty"`
+	Passed        bool   `json:"passed"`
+	// Mute is whether the user asked not to be notified of the failures.
+	Mute  bool `json:"mute"`
+	Fixes []struct {
+		Description string `json:"description"`
+	} `json:"fixes"`
+}
+
+var diagnosticsRunCmd = &cobra.Command{
+	Use:   "run [--category CATEGORY,...]",
+	Short: "Run the diagnostic checks now",
+	Long: `Run the diagnostic checks of the application now, rather than waiting for it to
+run them, and show their results. With --category, only the checks of the given
+categories are run, e.g. --category networking,kubernetes; the names of the
+categories are matched ignoring case, spaces and dashes.
+
+The exit status is 0 if no check failed, 3 if some checks failed and others
+passed, and 1 otherwise; failures of muted checks are shown as warnings. Use
+--output json for the results as the API returns them.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		formatter, err := output.NewFormatter(diagnosticsRunSettings.Output, tableFormat, output.JSON)
+		if err != nil {
+			return err
+		}
+		cmd.SilenceUsage = true
+		connectionInfo, err := config.GetConnectionInfo(false)
+		if err != nil {
+			return fmt.Errorf("failed to get connection info: %w", err)
+		}
+		rdClient := client.NewRDClient(connectionInfo)
+		categories, err := resolveDiagnosticCategories(rdClient, diagnosticsRunSettings.Categories)
+		if err != nil {
+			return err
+		}
+		results, err := runDiagnostics(rdClient, categories)
+		if err != nil {
+			return err
+		}
+		if formatter.Format != tableFormat {
+			return formatter.Write(os.Stdout, results)
+		}
+		if len(results.Checks) == 0 {
+			fmt.Println("No diagnostic checks apply to this host.")
+			return nil
+		}
+		report := diagnosticChecks(results)
+		if err := checks.WriteTable(os.Stdout, report); err != nil {
+			return err
+		}
+		return checks.Err(report)
+	},
+}
+
+func init() {
+	diagnosticsCmd.AddCommand(diagnosticsRunCmd)
+	diagnosticsRunCmd.Flags().StringSliceVar(&diagnosticsRunSettings.Categories, "category", nil, "only run the checks of this category (can be repeated)")
+	output.AddFlag(diagnosticsRunCmd.Flags(), &diagnosticsRunSettings.Output, tableFormat, output.JSON)
+}
+
+// normalizeCategory makes e.g. "container-engine" match "Container Engine".
+func normalizeCategory(category string) string {
+	return strings.NewReplacer(" ", "", "-", "", "_", "").Replace(strings.ToLower(category))
+}
+
+// resolveDiagnosticCategories returns the names of the categories the
+// application knows for the names given on the command line.
+func resolveDiagnosticCategories(rdClient client.RDClient, names []string) ([]string, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	body, err := client.ProcessRequestForUtility(rdClient.DoRequest("GET", client.VersionCommand("", "diagnostic_categories")))
+	if err != nil {
+		return nil, err
+	}
+	var known []string
+	if err := json.Unmarshal(body, &known); err != nil {
+		return nil, fmt.Errorf("failed to parse the diagnostic categories: %w", err)
+	}
+	var categories, unknown []string
+	for _, name := range names {
+		found := false
+		for _, category := range known {
+			if normalizeCategory(name) == normalizeCategory(category) {
+				categories = append(categories, category)
+				found = true
+				break
+			}
+		}
+		if !found {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("unknown diagnostic categories: %s; the categories are %s",
+			strings.Join(unknown, ", "), strings.Join(known, ", "))
+	}
+	return categories, nil
+}
+
+// runDiagnostics asks the application to run the checks of the categories, or
+// all checks.
+func runDiagnostics(rdClient client.RDClient, categories []string) (*diagnosticResults, error) {
+	command := client.VersionCommand("", "diagnostic_checks")
+	if len(categories) > 0 {
+		command += "?" + url.Values{"category": categories}.Encode()
+	}
+	body, err := client.ProcessRequestForUtility(rdClient.DoRequest("POST", command))
+	if err != nil {
+		return nil, err
+	}
+	results := &diagnosticResults{}
+	if err := json.Unmarshal(body, results); err != nil {
+		return nil, fmt.Errorf("failed to parse the diagnostic results: %w", err)
+	}
+	return results, nil
+}
+
+// diagnosticChecks returns the results as checks; the message of a failed
+// check suggests its fixes.
+func diagnosticChecks(results *diagnosticResults) []checks.Check {
+	report := make([]checks.Check, 0, len(results.Checks))
+	for _, result := range results.Checks {
+		check := checks.Check{Name: result.ID, Status: checks.OK, Message: result.Description}
+		if !result.Passed {
+			check.Status = checks.Failed
+			if result.Mute {
+				check.Status = checks.Warning
+			}
+			for _, fix := range result.Fixes {
+				check.Message += " Fix: " + fix.Description
+			}
+		}
+		report = append(report, check)
+	}
+	return report
+}
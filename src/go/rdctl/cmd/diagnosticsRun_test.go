@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/checks"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDiagnosticsClient serves the diagnostic categories, and records the
+// requests to run the checks.
+type fakeDiagnosticsClient struct {
+	runs []string
+}
+
+func (c *fakeDiagnosticsClient) DoRequest(method string, command string) (*http.Response, error) {
+	respond := func(body string) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body))}, nil
+	}
+	switch {
+	case method == "GET" && command == client.VersionCommand("", "diagnostic_categories"):
+		return respond(`["Container Engine", "Kubernetes", "Networking"]`)
+	case method == "POST" && strings.HasPrefix(command, client.VersionCommand("", "diagnostic_checks")):
+		c.runs = append(c.runs, command)
+		return respond(`{"last_update": "2024-01-01T00:00:00.000Z", "checks": [
+			{"id": "CONNECTED_TO_INTERNET", "category": "Networking", "description": "The application cannot reach the internet.",
+			 "passed": false, "mute": false, "fixes": [{"description": "Check the proxy settings."}]}
+		]}`)
+	}
+	return nil, errors.New("unexpected request")
+}
+
+func (c *fakeDiagnosticsClient) DoRequestWithPayload(method string, command string, payload io.Reader) (*http.Response, error) {
+	return nil, errors.New("unexpected request")
+}
+
+func (c *fakeDiagnosticsClient) GetBackendState() (client.BackendState, error) {
+	return client.BackendState{}, errors.New("unexpected request")
+}
+
+func (c *fakeDiagnosticsClient) UpdateBackendState(state client.BackendState) error {
+	return errors.New("unexpected request")
+}
+
+func TestResolveDiagnosticCategories(t *testing.T) {
+	rdClient := &fakeDiagnosticsClient{}
+	categories, err := resolveDiagnosticCategories(rdClient, []string{"networking", "container-engine"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Networking", "Container Engine"}, categories)
+
+	_, err = resolveDiagnosticCategories(rdClient, []string{"kubernetes", "dns"})
+	assert.EqualError(t, err, "unknown diagnostic categories: dns; the categories are Container Engine, Kubernetes, Networking")
+}
+
+func TestRunDiagnostics(t *testing.T) {
+	rdClient := &fakeDiagnosticsClient{}
+	results, err := runDiagnostics(rdClient, []string{"Networking", "Container Engine"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"v1/diagnostic_checks?category=Networking&category=Container+Engine"}, rdClient.runs)
+	assert.Equal(t, []checks.Check{{
+		Name:    "CONNECTED_TO_INTERNET",
+		Status:  checks.Failed,
+		Message: "The application cannot reach the internet. Fix: Check the proxy settings.",
+	}}, diagnosticChecks(results))
+
+	results.Checks[0].Mute = true
+	assert.Equal(t, checks.Warning, diagnosticChecks(results)[0].Status)
+}
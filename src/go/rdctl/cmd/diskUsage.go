@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/output"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/vm"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/vmdisk"
+	"github.com/spf13/cobra"
+)
+
+var diskUsageSettings struct {
+	Output string
+}
+
+var diskUsageCmd = &cobra.Command{
+	Use:   "disk-usage",
+	Short: "Show the disk usage of the VM",
+	Long: `Show the space the disk images of the VM use on the host and, while the VM is
+running, the usage of its file systems and when they were last trimmed (see the
+experimental.virtualMachine.trimInterval setting). Space freed in the VM is only
+released to the host once the file systems are trimmed; see also
+'rdctl vm compact-disk'.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		formatter, err := output.NewFormatter(diskUsageSettings.Output, tableFormat, output.JSON)
+		if err != nil {
+			return err
+		}
+		cmd.SilenceUsage = true
+		appPaths, err := paths.GetPaths()
+		if err != nil {
+			return fmt.Errorf("failed to get paths: %w", err)
+		}
+		runner, err := vm.New(appPaths)
+		if err != nil {
+			return err
+		}
+		usage, err := vmdisk.GetUsage(appPaths, runner)
+		if err != nil {
+			return err
+		}
+		if formatter.Format != tableFormat {
+			return formatter.Write(os.Stdout, usage)
+		}
+		writer := tabwriter.NewWriter(os.Stdout, 0, 4, 4, ' ', 0)
+		fmt.Fprintf(writer, "DISK IMAGE\tSIZE\n")
+		for _, image := range usage.Images {
+			fmt.Fprintf(writer, "%s\t%s\n", image.Path, formatUsage(image.Size))
+		}
+		if usage.Running {
+			fmt.Fprintf(writer, "\nFILE SYSTEM\tMOUNT\tSIZE\tUSED\tAVAILABLE\n")
+			for _, fileSystem := range usage.FileSystems {
+				fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n", fileSystem.Device, fileSystem.Mount,
+					formatUsage(fileSystem.Size), formatUsage(fileSystem.Used), formatUsage(fileSystem.Available))
+			}
+		}
+		if err := writer.Flush(); err != nil {
+			return err
+		}
+		switch {
+		case !usage.Running:
+			output.Infof("The VM is not running; start Rancher Desktop to see the usage of its file systems.")
+		case usage.LastTrim == nil:
+			output.Infof("The file systems of the VM have not been trimmed periodically yet.")
+		default:
+			output.Infof("Last trimmed %s, releasing %s.", usage.LastTrim.Time.Format(time.RFC3339), formatUsage(usage.LastTrim.Bytes))
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(diskUsageCmd)
+	markReadOnly(diskUsageCmd)
+	output.AddFlag(diskUsageCmd.Flags(), &diskUsageSettings.Output, tableFormat, output.JSON)
+}
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	dockerconfig "github.com/docker/docker/cli/config"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/checks"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/client"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/config"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/doctor"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/output"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+	"github.com/spf13/cobra"
+)
+
+var doctorSettings struct {
+	Fix    bool
+	Output string
+}
+
+var doctorCmd = &cobra.Command{
+	Use:     "doctor",
+	Aliases: []string{"diagnose"},
+	Short:   "Check that the host can run Rancher Desktop",
+	Long: `Check that the host can run Rancher Desktop, and suggest how to fix the problems
+found:
+
+  WSL              WSL is installed and up to date (Windows)
+  virtualization   the virtualization extensions of the CPU can be used
+  disk space       there is enough space for the data of the application
+  kubernetes port  the port of the Kubernetes API is free
+  tool symlinks    the tools in ~/.rd/bin, and the docker CLI plugins linked to
+                   them, are valid (macOS and Linux)
+  PATH             ~/.rd/bin is in the PATH (macOS and Linux)
+  docker context   the docker context isn't the one of Docker Desktop
+  lock files       no lock was left behind by a failed snapshot operation
+
+Unlike the diagnostics of the application, the checks don't need it to be
+running, so they help when it fails to start. The exit status is 0 if no check
+failed, 3 if some checks failed and others passed, and 1 otherwise.
+
+With --fix, the problems that can be fixed safely are fixed before checking
+again: the broken symlinks of the tools are recreated, the docker context of
+Rancher Desktop is selected in the configuration of the docker CLI, and stale
+locks are removed.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		formatter, err := output.NewFormatter(doctorSettings.Output, tableFormat, output.JSON)
+		if err != nil {
+			return err
+		}
+		if doctorSettings.Fix && readOnlyMode() {
+			return readOnlyError("--fix")
+		}
+		cmd.SilenceUsage = true
+		appPaths, err := paths.GetPaths()
+		if err != nil {
+			return fmt.Errorf("failed to get paths: %w", err)
+		}
+		results, fixes := doctor.New(appPaths, appRunning(), dockerconfig.Dir()).Run(doctorSettings.Fix)
+		for _, fix := range fixes {
+			for _, change := range fix.Changes {
+				output.Infof("Fixed %s: %s.", fix.Check, change)
+			}
+			if fix.Err != nil {
+				output.Infof("Failed to fix %s: %s.", fix.Check, fix.Err)
+			}
+		}
+		if formatter.Format != tableFormat {
+			err = formatter.Write(os.Stdout, results)
+		} else {
+			err = checks.WriteTable(os.Stdout, results)
+		}
+		if err != nil {
+			return err
+		}
+		return checks.Err(results)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+	markReadOnly(doctorCmd)
+	doctorCmd.Flags().BoolVar(&doctorSettings.Fix, "fix", false, "fix the problems that can be fixed safely")
+	output.AddFlag(doctorCmd.Flags(), &doctorSettings.Output, tableFormat, output.JSON)
+}
+
+// appRunning returns whether the application answers; the connection info
+// is left behind when it quits.
+func appRunning() bool {
+	connectionInfo, err := config.GetConnectionInfo(true)
+	if err != nil || connectionInfo == nil {
+		return false
+	}
+	_, err = client.NewRDClient(connectionInfo).GetBackendState()
+	return err == nil
+}
@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"time"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/client"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/config"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/events"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/output"
+	"github.com/spf13/cobra"
+)
+
+// maxEventsReconnectBackoff caps the wait between attempts to reconnect with
+// --follow.
+const maxEventsReconnectBackoff = 10 * time.Second
+
+var eventsSettings struct {
+	Follow bool
+	Types  []string
+}
+
+// eventStreamer opens the stream of events; it exists for testing.
+type eventStreamer interface {
+	DoStreamingRequest(ctx context.Context, command string) (*http.Response, error)
+}
+
+var eventsCmd = &cobra.Command{
+	Use:   "events",
+	Short: "Print the events of the running Rancher Desktop application",
+	Long: `Print the events of the running Rancher Desktop application as they happen, one
+JSON object per line, until it quits or Ctrl-C is pressed. The types of events
+are:
+
+  settings-changed        the settings were changed
+  backend-state-changed   the backend moved to another state, e.g. STARTED
+  backend-lock-changed    the backend was locked or unlocked, e.g. for a snapshot
+  image-operation         an image operation, such as a pull, finished
+  diagnostics-updated     a diagnostic check was run
+  oom-killed              the VM killed a process, e.g. of a container, for
+                          running out of memory
+  resource-pressure       the cpu, memory or io pressure of the VM became high,
+                          or is over
+
+With --follow, keep waiting for the application to restart instead of exiting
+when it quits.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		if !eventsSettings.Follow {
+			connectionInfo, err := config.GetConnectionInfo(false)
+			if err != nil {
+				return fmt.Errorf("failed to get connection info: %w", err)
+			}
+			return streamEvents(ctx, client.NewRDClient(connectionInfo), eventsSettings.Types, os.Stdout)
+		}
+		// The application writes new credentials when it restarts.
+		watcher, err := config.WatchConnectionInfo()
+		if err != nil {
+			return fmt.Errorf("failed to get connection info: %w", err)
+		}
+		defer watcher.Close()
+		return followEvents(ctx, client.NewWatchingRDClient(watcher), eventsSettings.Types, os.Stdout, time.Second)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(eventsCmd)
+	markReadOnly(eventsCmd)
+	eventsCmd.Flags().BoolVarP(&eventsSettings.Follow, "follow", "f", false, "keep running when the application quits, and print its events once it restarts")
+	eventsCmd.Flags().StringSliceVar(&eventsSettings.Types, "type", nil, "only print the events of this type (can be repeated)")
+}
+
+// streamEvents writes the events of the given types, or all events if none
+// are given, until the stream ends or the context is done.
+func streamEvents(ctx context.Context, streamer eventStreamer, types []string, w io.Writer) error {
+	response, err := streamer.DoStreamingRequest(ctx, client.VersionCommand("", "events"))
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		// Reuse the handling of error responses of the other commands.
+		_, err := client.ProcessRequestForUtility(response, nil)
+		if err == nil {
+			err = fmt.Errorf("unexpected response status %s", response.Status)
+		}
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	err = events.Read(response.Body, func(event events.Event) error {
+		if len(types) > 0 && !slices.Contains(types, event.Type) {
+			return nil
+		}
+		return encoder.Encode(event)
+	})
+	if ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
+
+// followEvents streams the events, reconnecting whenever the stream ends,
+// until the context is done.
+func followEvents(ctx context.Context, streamer eventStreamer, types []string, w io.Writer, backoff time.Duration) error {
+	wait := backoff
+	for {
+		start := time.Now()
+		err := streamEvents(ctx, streamer, types, w)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			output.Infof("Not connected to Rancher Desktop (%s); retrying.", err)
+		}
+		if time.Since(start) > maxEventsReconnectBackoff {
+			// The stream was up for a while; reconnect quickly.
+			wait = backoff
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(wait):
+		}
+		wait = min(2*wait, maxEventsReconnectBackoff)
+	}
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeEventStreamer serves each of its streams once, then fails.
+type fakeEventStreamer struct {
+	streams []string
+	cancel  context.CancelFunc
+}
+
+func (s *fakeEventStreamer) DoStreamingRequest(ctx context.Context, command string) (*http.Response, error) {
+	if len(s.streams) == 0 {
+		if s.cancel != nil {
+			s.cancel()
+		}
+		return nil, errors.New("connection refused")
+	}
+	body := s.streams[0]
+	s.streams = s.streams[1:]
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body))}, nil
+}
+
+const testEventStream = ": subscribed\n\n" +
+	`data: {"type":"backend-state-changed","time":"t1","data":{"state":"STARTING"}}` + "\n\n" +
+	`data: {"type":"settings-changed","time":"t2","data":{}}` + "\n\n"
+
+func TestStreamEvents(t *testing.T) {
+	t.Run("all events", func(t *testing.T) {
+		var output bytes.Buffer
+		require.NoError(t, streamEvents(context.Background(), &fakeEventStreamer{streams: []string{testEventStream}}, nil, &output))
+		assert.Equal(t,
+			`{"type":"backend-state-changed","time":"t1","data":{"state":"STARTING"}}`+"\n"+
+				`{"type":"settings-changed","time":"t2","data":{}}`+"\n",
+			output.String())
+	})
+	t.Run("filtered by type", func(t *testing.T) {
+		var output bytes.Buffer
+		require.NoError(t, streamEvents(context.Background(), &fakeEventStreamer{streams: []string{testEventStream}}, []string{"settings-changed"}, &output))
+		assert.Equal(t, `{"type":"settings-changed","time":"t2","data":{}}`+"\n", output.String())
+	})
+	t.Run("not running", func(t *testing.T) {
+		err := streamEvents(context.Background(), &fakeEventStreamer{}, nil, io.Discard)
+		assert.ErrorContains(t, err, "connection refused")
+	})
+}
+
+func TestFollowEvents(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	streamer := &fakeEventStreamer{streams: []string{testEventStream, testEventStream}, cancel: cancel}
+	var output bytes.Buffer
+	require.NoError(t, followEvents(ctx, streamer, []string{"settings-changed"}, &output, time.Millisecond))
+	assert.Equal(t, strings.Repeat(`{"type":"settings-changed","time":"t2","data":{}}`+"\n", 2), output.String())
+}
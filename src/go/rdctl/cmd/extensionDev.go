@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/client"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/config"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/extension"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/vm"
+	"github.com/spf13/cobra"
+)
+
+var extensionDevSettings struct {
+	Image string
+	Watch bool
+}
+
+var extensionDevCmd = &cobra.Command{
+	Use:   "dev <directory>",
+	Short: "Build and install an extension from its source directory",
+	Long: `Build the image of an extension from its source directory, which holds its
+Dockerfile, and install it, replacing the version installed before. The image
+is built in the VM with the selected container engine, so it doesn't need to
+be pushed to a registry.
+
+With --watch, the extension is rebuilt and reinstalled whenever files in the
+directory change, until the command is interrupted. The .git directory is
+ignored.
+
+The image is named after the directory, e.g. rd-dev/my-extension:` + extension.DevTag + `, unless
+--image is given. If the extensions allowed by the deployment profile are
+restricted, the image must be allowed to be installed.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		return developExtension(args[0])
+	},
+}
+
+func init() {
+	extensionCmd.AddCommand(extensionDevCmd)
+	extensionDevCmd.Flags().StringVar(&extensionDevSettings.Image, "image", "", "image to build, with its tag (default: named after the directory)")
+	extensionDevCmd.Flags().BoolVar(&extensionDevSettings.Watch, "watch", false, "rebuild and reinstall the extension when files in the directory change")
+}
+
+func developExtension(dir string) error {
+	if info, err := os.Stat(dir); err != nil {
+		return err
+	} else if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+	image := extensionDevSettings.Image
+	if image == "" {
+		image = extension.DefaultImage(dir)
+	}
+	connectionInfo, err := config.GetConnectionInfo(false)
+	if err != nil {
+		return fmt.Errorf("failed to get connection info: %w", err)
+	}
+	rdClient := client.NewRDClient(connectionInfo)
+	engineName, err := getContainerEngineName(rdClient)
+	if err != nil {
+		return err
+	}
+	appPaths, err := paths.GetPaths()
+	if err != nil {
+		return fmt.Errorf("failed to get paths: %w", err)
+	}
+	runner, err := vm.New(appPaths)
+	if err != nil {
+		return err
+	}
+	install := func() error {
+		fmt.Fprintf(os.Stderr, "Building %s from %s...\n", image, dir)
+		if err := extension.Build(runner, engineName, dir, image, os.Stderr); err != nil {
+			return err
+		}
+		return reinstallExtension(rdClient, image)
+	}
+	if !extensionDevSettings.Watch {
+		return install()
+	}
+	if err := install(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	fmt.Fprintf(os.Stderr, "Watching %s for changes; press Ctrl-C to stop.\n", dir)
+	return extension.Watch(ctx, dir, 500*time.Millisecond, func() {
+		// Keep watching after errors, which the next change may fix.
+		if err := install(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	})
+}
+
+// reinstallExtension uninstalls the image, in case an earlier build of it is
+// installed, and installs it again.
+func reinstallExtension(rdClient client.RDClient, image string) error {
+	for _, action := range []string{"uninstall", "install"} {
+		endpoint := fmt.Sprintf("/%s/extensions/%s?id=%s", client.ApiVersion, action, image)
+		result, errorPacket, err := client.ProcessRequestForAPI(rdClient.DoRequest("POST", endpoint))
+		if errorPacket != nil || err != nil {
+			return displayAPICallResult(result, errorPacket, err)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Installed %s.\n", image)
+	return nil
+}
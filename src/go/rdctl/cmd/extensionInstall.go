@@ -19,6 +19,8 @@ package cmd
 
 import (
 	"fmt"
+	"net/url"
+
 	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/client"
 	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/config"
 	"github.com/spf13/cobra"
@@ -30,7 +32,8 @@ var installCmd = &cobra.Command{
 	Short: "Install an RDX extension",
 	Long: `rdctl extension install [--force] <image-id>
 --force: avoid any interactivity.
-The <image-id> is an image reference, e.g. splatform/epinio-docker-desktop:latest (the tag is optional).`,
+The <image-id> is an image reference, e.g. splatform/epinio-docker-desktop:latest (the tag is optional).
+Pin the extension to a digest with <image>@sha256:<digest>; without a tag or a digest, the highest version is installed.`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		cmd.SilenceUsage = true
@@ -49,9 +52,7 @@ func installExtension(args []string) error {
 	}
 	rdClient := client.NewRDClient(connectionInfo)
 	imageID := args[0]
-	endpoint := fmt.Sprintf("/%s/extensions/install?id=%s", client.ApiVersion, imageID)
-	// https://stackoverflow.com/questions/20847357/golang-http-client-always-escaped-the-url
-	// Looks like http.NewRequest(method, url) escapes the URL
+	endpoint := fmt.Sprintf("/%s/extensions/install?id=%s", client.ApiVersion, url.QueryEscape(imageID))
 
 	result, errorPacket, err := client.ProcessRequestForAPI(rdClient.DoRequest("POST", endpoint))
 	if errorPacket != nil || err != nil {
@@ -21,11 +21,15 @@ package cmd
 import (
 	"encoding/json"
 	"fmt"
+	"io"
+	"os"
 	"sort"
 	"strings"
+	"text/tabwriter"
 
 	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/client"
 	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/config"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/output"
 	"github.com/spf13/cobra"
 )
 
@@ -34,49 +38,94 @@ var listCmd = &cobra.Command{
 	Use:     "list",
 	Aliases: []string{"ls"},
 	Short:   "List currently installed images",
-	Long:    `List currently installed images.`,
-	Args:    cobra.NoArgs,
+	Long: `List currently installed images, with the title and the vendor of each.
+Use --output json for all their metadata and labels.`,
+	Args: cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		formatter, err := output.NewFormatter(extensionListOutputFormat, tableFormat, output.JSON)
+		if err != nil {
+			return err
+		}
 		cmd.SilenceUsage = true
-		return listExtensions()
+		return listExtensions(formatter)
 	},
 }
 
+var extensionListOutputFormat string
+
 func init() {
 	extensionCmd.AddCommand(listCmd)
+	markReadOnly(listCmd)
+	output.AddFlag(listCmd.Flags(), &extensionListOutputFormat, tableFormat, output.JSON)
+}
+
+// installedExtension describes an installed extension, as listed by the API.
+type installedExtension struct {
+	// Version is the tag, or the digest the extension is pinned to.
+	Version  string            `json:"version"`
+	Metadata map[string]any    `json:"metadata"`
+	Labels   map[string]string `json:"labels"`
+}
+
+// extensionReference returns the image reference of the given version of an
+// extension.
+func extensionReference(id, version string) string {
+	if strings.HasPrefix(version, "sha256:") {
+		return fmt.Sprintf("%s@%s", id, version)
+	}
+	return fmt.Sprintf("%s:%s", id, version)
 }
 
-func listExtensions() error {
+// getInstalledExtensions returns the installed extensions, by ID.
+func getInstalledExtensions(rdClient client.RDClient) (map[string]installedExtension, error) {
+	result, err := client.ProcessRequestForUtility(rdClient.DoRequest("GET", client.VersionCommand("", "extensions")))
+	if err != nil {
+		return nil, err
+	}
+	extensions := map[string]installedExtension{}
+	if err := json.Unmarshal(result, &extensions); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal extension list API response: %w", err)
+	}
+	return extensions, nil
+}
+
+func listExtensions(formatter *output.Formatter) error {
 	connectionInfo, err := config.GetConnectionInfo(false)
 	if err != nil {
 		return fmt.Errorf("failed to get connection info: %w", err)
 	}
 	rdClient := client.NewRDClient(connectionInfo)
-	endpoint := fmt.Sprintf("/%s/extensions", client.ApiVersion)
-	result, errorPacket, err := client.ProcessRequestForAPI(rdClient.DoRequest("GET", endpoint))
-	if errorPacket != nil || err != nil {
-		return displayAPICallResult([]byte{}, errorPacket, err)
-	}
-	extensionList := map[string]struct {
-		Version string `json:"version"`
-	}{}
-	err = json.Unmarshal(result, &extensionList)
+	extensions, err := getInstalledExtensions(rdClient)
 	if err != nil {
-		return fmt.Errorf("failed to unmarshal extension list API response: %w", err)
+		return err
 	}
-	if len(extensionList) == 0 {
+	if formatter.Format != tableFormat {
+		return formatter.Write(os.Stdout, extensions)
+	}
+	if len(extensions) == 0 {
 		fmt.Println("No extensions are installed.")
 		return nil
 	}
-	extensionIDs := make([]string, 0, len(extensionList))
-	for id, info := range extensionList {
-		extensionIDs = append(extensionIDs, fmt.Sprintf("%s:%s", id, info.Version))
+	return writeExtensionTable(os.Stdout, extensions)
+}
+
+// writeExtensionTable writes the image reference, the title and the vendor of
+// each extension, sorted by ID.
+func writeExtensionTable(w io.Writer, extensions map[string]installedExtension) error {
+	ids := make([]string, 0, len(extensions))
+	for id := range extensions {
+		ids = append(ids, id)
 	}
-	sort.Slice(extensionIDs, func(i, j int) bool { return strings.ToLower(extensionIDs[i]) < strings.ToLower(extensionIDs[j]) })
+	sort.Slice(ids, func(i, j int) bool { return strings.ToLower(ids[i]) < strings.ToLower(ids[j]) })
 
-	fmt.Print("Extension IDs\n\n")
-	for _, extensionID := range extensionIDs {
-		fmt.Println(extensionID)
+	writer := tabwriter.NewWriter(w, 0, 4, 4, ' ', 0)
+	fmt.Fprintf(writer, "EXTENSION\tTITLE\tVENDOR\n")
+	for _, id := range ids {
+		extension := extensions[id]
+		fmt.Fprintf(writer, "%s\t%s\t%s\n",
+			extensionReference(id, extension.Version),
+			orDash(extension.Labels["org.opencontainers.image.title"]),
+			orDash(extension.Labels["org.opencontainers.image.vendor"]))
 	}
-	return nil
+	return writer.Flush()
 }
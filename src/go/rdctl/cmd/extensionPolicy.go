@@ -0,0 +1,17 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var extensionPolicyCmd = &cobra.Command{
+	Use:   "policy",
+	Short: "Inspect the policy for installing extensions",
+	Long: `Inspect the policy for installing extensions: administrators can restrict the
+extensions that may be installed to an allow list of images, organizations, and
+registries, set with application.extensions.allowed in a deployment profile.`,
+}
+
+func init() {
+	extensionCmd.AddCommand(extensionPolicyCmd)
+}
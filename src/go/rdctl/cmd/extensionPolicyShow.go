@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/client"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/config"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/output"
+	"github.com/spf13/cobra"
+)
+
+var extensionPolicyShowSettings struct {
+	Output string
+}
+
+// extensionPolicy is the allow list for installing extensions.
+type extensionPolicy struct {
+	Enabled bool `json:"enabled"`
+	// Locked is set when a locked deployment profile sets the policy.
+	Locked  bool     `json:"locked"`
+	Allowed []string `json:"allowed"`
+}
+
+var extensionPolicyShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show which extensions may be installed",
+	Long: `Show the allow list of extensions, if it is enabled: the images, organizations
+(ending with "/"), and registries from which extensions may be installed.
+Attempts to install other extensions are denied, and recorded in audit.log in
+the logs directory.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		formatter, err := output.NewFormatter(extensionPolicyShowSettings.Output, tableFormat, output.JSON)
+		if err != nil {
+			return err
+		}
+		cmd.SilenceUsage = true
+		policy, err := getExtensionPolicy()
+		if err != nil {
+			return err
+		}
+		if formatter.Format != tableFormat {
+			return formatter.Write(os.Stdout, policy)
+		}
+		source := ""
+		if policy.Locked {
+			source = " by a locked deployment profile"
+		}
+		if !policy.Enabled {
+			fmt.Printf("The extension allow list is disabled%s: any extension may be installed.\n", source)
+			return nil
+		}
+		if len(policy.Allowed) == 0 {
+			fmt.Printf("The extension allow list is enabled%s, and empty: no extension may be installed.\n", source)
+			return nil
+		}
+		fmt.Printf("The extension allow list is enabled%s; only extensions matching these patterns may be installed:\n\n", source)
+		for _, pattern := range policy.Allowed {
+			fmt.Println(pattern)
+		}
+		return nil
+	},
+}
+
+func init() {
+	extensionPolicyCmd.AddCommand(extensionPolicyShowCmd)
+	output.AddFlag(extensionPolicyShowCmd.Flags(), &extensionPolicyShowSettings.Output, tableFormat, output.JSON)
+	markReadOnly(extensionPolicyShowCmd)
+}
+
+// extensionsAllowedSettings mirrors application.extensions.allowed in the
+// settings, and in the locked settings, where the locked fields are true.
+type extensionsAllowedSettings[E, L any] struct {
+	Application struct {
+		Extensions struct {
+			Allowed struct {
+				Enabled E `json:"enabled"`
+				List    L `json:"list"`
+			} `json:"allowed"`
+		} `json:"extensions"`
+	} `json:"application"`
+}
+
+func getExtensionPolicy() (extensionPolicy, error) {
+	var policy extensionPolicy
+	connectionInfo, err := config.GetConnectionInfo(false)
+	if err != nil {
+		return policy, fmt.Errorf("failed to get connection info: %w", err)
+	}
+	rdClient := client.NewRDClient(connectionInfo)
+	result, err := client.ProcessRequestForUtility(rdClient.DoRequest("GET", client.VersionCommand("", "settings")))
+	if err != nil {
+		return policy, err
+	}
+	var settings extensionsAllowedSettings[bool, []string]
+	if err := json.Unmarshal(result, &settings); err != nil {
+		return policy, fmt.Errorf("failed to unmarshal settings: %w", err)
+	}
+	policy.Enabled = settings.Application.Extensions.Allowed.Enabled
+	policy.Allowed = settings.Application.Extensions.Allowed.List
+	if policy.Allowed == nil {
+		policy.Allowed = []string{}
+	}
+
+	result, err = client.ProcessRequestForUtility(rdClient.DoRequest("GET", client.VersionCommand("", "settings/locked")))
+	if err != nil {
+		return policy, err
+	}
+	var locked extensionsAllowedSettings[bool, bool]
+	if err := json.Unmarshal(result, &locked); err != nil {
+		return policy, fmt.Errorf("failed to unmarshal locked settings: %w", err)
+	}
+	policy.Locked = locked.Application.Extensions.Allowed.Enabled || locked.Application.Extensions.Allowed.List
+	return policy, nil
+}
@@ -20,6 +20,7 @@ package cmd
 
 import (
 	"fmt"
+	"net/url"
 
 	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/client"
 	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/config"
@@ -31,7 +32,8 @@ var uninstallCmd = &cobra.Command{
 	Short: "Uninstall an RDX extension",
 	Long: `rdctl extension uninstall <image-id>
 The <image-id> is an image reference, e.g. splatform/epinio-docker-desktop:latest (the tag is optional).`,
-	Args: cobra.ExactArgs(1),
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeExtensionIDs,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		cmd.SilenceUsage = true
 		return uninstallExtension(args)
@@ -49,7 +51,7 @@ func uninstallExtension(args []string) error {
 	}
 	rdClient := client.NewRDClient(connectionInfo)
 	imageID := args[0]
-	endpoint := fmt.Sprintf("/%s/extensions/uninstall?id=%s", client.ApiVersion, imageID)
+	endpoint := fmt.Sprintf("/%s/extensions/uninstall?id=%s", client.ApiVersion, url.QueryEscape(imageID))
 	result, errorPacket, err := client.ProcessRequestForAPI(rdClient.DoRequest("POST", endpoint))
 	if errorPacket != nil || err != nil {
 		return displayAPICallResult(result, errorPacket, err)
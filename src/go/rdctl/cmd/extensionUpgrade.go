@@ -0,0 +1,126 @@
+/*
+Copyright © 2023 SUSE LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cmd implements the rdctl commands
+
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/client"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+var upgradeCmd = &cobra.Command{
+	Use:   "upgrade",
+	Short: "Upgrade an installed RDX extension",
+	Long: `rdctl extension upgrade <image-id>
+The <image-id> is the image reference of an installed extension, e.g. splatform/epinio-docker-desktop.
+Without a tag or a digest, the extension is upgraded to its highest version;
+with one, e.g. splatform/epinio-docker-desktop:1.8.0 or splatform/epinio-docker-desktop@sha256:<digest>,
+the extension is changed to that version, even if it is older than the installed one.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeExtensionIDs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		connectionInfo, err := config.GetConnectionInfo(false)
+		if err != nil {
+			return fmt.Errorf("failed to get connection info: %w", err)
+		}
+		result, err := upgradeExtension(client.NewRDClient(connectionInfo), args[0])
+		if err != nil {
+			return err
+		}
+		if result.From == result.To {
+			fmt.Printf("Extension %s is already at %s\n", result.ID, result.To)
+		} else {
+			fmt.Printf("Upgraded extension %s from %s to %s\n", result.ID, result.From, result.To)
+		}
+		return nil
+	},
+}
+
+func init() {
+	extensionCmd.AddCommand(upgradeCmd)
+}
+
+// extensionReferenceRE splits an image reference into the ID of the extension
+// and its tag or digest; a tag before a digest is ignored, as the digest wins.
+var extensionReferenceRE = regexp.MustCompile(`^(.*?)(?::([\w][\w.-]{0,127}))?(?:@(sha256:[0-9a-f]{64}))?$`)
+
+// splitExtensionReference returns the ID of the extension in an image
+// reference, and its digest or tag if it has any.
+func splitExtensionReference(reference string) (id, version string) {
+	match := extensionReferenceRE.FindStringSubmatch(reference)
+	if match == nil {
+		return reference, ""
+	}
+	if match[3] != "" {
+		return match[1], match[3]
+	}
+	return match[1], match[2]
+}
+
+// extensionUpgrade is the outcome of upgrading an extension.
+type extensionUpgrade struct {
+	ID   string
+	From string
+	To   string
+}
+
+// upgradeExtension installs the version of an installed extension given by
+// its reference, or its highest version if the reference has none; the
+// backend replaces the installed version.
+func upgradeExtension(rdClient client.RDClient, reference string) (extensionUpgrade, error) {
+	id, version := splitExtensionReference(reference)
+	extensions, err := getInstalledExtensions(rdClient)
+	if err != nil {
+		return extensionUpgrade{}, err
+	}
+	installed, ok := extensions[id]
+	if !ok {
+		return extensionUpgrade{}, fmt.Errorf("extension %s is not installed; install it with `rdctl extension install %s`", id, reference)
+	}
+	result := extensionUpgrade{ID: id, From: installed.Version, To: version}
+	if version == installed.Version {
+		return result, nil
+	}
+
+	endpoint := client.VersionCommand("", fmt.Sprintf("extensions/install?id=%s", url.QueryEscape(reference)))
+	body, errorPacket, err := client.ProcessRequestForAPI(rdClient.DoRequest("POST", endpoint))
+	if err != nil {
+		return result, err
+	}
+	if errorPacket != nil {
+		return result, fmt.Errorf("failed to install %s: %s: %s", reference, *errorPacket.Message, body)
+	}
+
+	// Without a version, the backend picked it; find out which one.
+	extensions, err = getInstalledExtensions(rdClient)
+	if err != nil {
+		return result, err
+	}
+	installed, ok = extensions[id]
+	if !ok {
+		return result, fmt.Errorf("extension %s is no longer installed after the upgrade", id)
+	}
+	result.To = installed.Version
+	return result, nil
+}
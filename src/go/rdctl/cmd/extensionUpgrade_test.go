@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDigest = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+// fakeExtensionClient serves the list of installed extensions, and installs
+// the extensions it is asked to.
+type fakeExtensionClient struct {
+	// installed maps the ID of each installed extension to its version.
+	installed map[string]string
+	// newest is the version installed when none is requested.
+	newest string
+	// installs records the install requests.
+	installs []string
+}
+
+func (c *fakeExtensionClient) DoRequest(method string, command string) (*http.Response, error) {
+	respond := func(body string) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body))}, nil
+	}
+	switch {
+	case method == "GET" && command == client.VersionCommand("", "extensions"):
+		var entries []string
+		for id, version := range c.installed {
+			entries = append(entries, `"`+id+`": {"version": "`+version+`"}`)
+		}
+		return respond("{" + strings.Join(entries, ",") + "}")
+	case method == "POST" && strings.HasPrefix(command, client.VersionCommand("", "extensions/install?")):
+		c.installs = append(c.installs, command)
+		query, err := url.ParseQuery(command[strings.Index(command, "?")+1:])
+		if err != nil {
+			return nil, err
+		}
+		id, version := splitExtensionReference(query.Get("id"))
+		if version == "" {
+			version = c.newest
+		}
+		c.installed[id] = version
+		return &http.Response{StatusCode: http.StatusCreated, Body: io.NopCloser(strings.NewReader(""))}, nil
+	}
+	return nil, errors.New("unexpected request")
+}
+
+func (c *fakeExtensionClient) DoRequestWithPayload(method string, command string, payload io.Reader) (*http.Response, error) {
+	return nil, errors.New("unexpected request")
+}
+
+func (c *fakeExtensionClient) GetBackendState() (client.BackendState, error) {
+	return client.BackendState{}, errors.New("unexpected request")
+}
+
+func (c *fakeExtensionClient) UpdateBackendState(state client.BackendState) error {
+	return errors.New("unexpected request")
+}
+
+func TestSplitExtensionReference(t *testing.T) {
+	testCases := []struct {
+		reference string
+		id        string
+		version   string
+	}{
+		{"rd/extension", "rd/extension", ""},
+		{"rd/extension:1.0", "rd/extension", "1.0"},
+		{"rd/extension@" + testDigest, "rd/extension", testDigest},
+		{"rd/extension:1.0@" + testDigest, "rd/extension", testDigest},
+		{"registry.test:5000/rd/extension", "registry.test:5000/rd/extension", ""},
+		{"registry.test:5000/rd/extension:v2", "registry.test:5000/rd/extension", "v2"},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.reference, func(t *testing.T) {
+			id, version := splitExtensionReference(testCase.reference)
+			assert.Equal(t, testCase.id, id)
+			assert.Equal(t, testCase.version, version)
+		})
+	}
+}
+
+func TestUpgradeExtension(t *testing.T) {
+	t.Run("upgrades to the newest version", func(t *testing.T) {
+		rdClient := &fakeExtensionClient{installed: map[string]string{"rd/extension": "0.0.1"}, newest: "0.0.2"}
+		result, err := upgradeExtension(rdClient, "rd/extension")
+		require.NoError(t, err)
+		assert.Equal(t, extensionUpgrade{ID: "rd/extension", From: "0.0.1", To: "0.0.2"}, result)
+		assert.Equal(t, []string{client.VersionCommand("", "extensions/install?id=rd%2Fextension")}, rdClient.installs)
+	})
+	t.Run("pins to a digest", func(t *testing.T) {
+		rdClient := &fakeExtensionClient{installed: map[string]string{"rd/extension": "0.0.1"}}
+		result, err := upgradeExtension(rdClient, "rd/extension@"+testDigest)
+		require.NoError(t, err)
+		assert.Equal(t, extensionUpgrade{ID: "rd/extension", From: "0.0.1", To: testDigest}, result)
+		assert.Equal(t, testDigest, rdClient.installed["rd/extension"])
+	})
+	t.Run("skips the installed version", func(t *testing.T) {
+		rdClient := &fakeExtensionClient{installed: map[string]string{"rd/extension": "0.0.2"}}
+		result, err := upgradeExtension(rdClient, "rd/extension:0.0.2")
+		require.NoError(t, err)
+		assert.Equal(t, extensionUpgrade{ID: "rd/extension", From: "0.0.2", To: "0.0.2"}, result)
+		assert.Empty(t, rdClient.installs)
+	})
+	t.Run("rejects extensions that aren't installed", func(t *testing.T) {
+		rdClient := &fakeExtensionClient{installed: map[string]string{}}
+		_, err := upgradeExtension(rdClient, "rd/extension")
+		assert.ErrorContains(t, err, "extension rd/extension is not installed")
+		assert.Empty(t, rdClient.installs)
+	})
+}
+
+func TestWriteExtensionTable(t *testing.T) {
+	var buf bytes.Buffer
+	err := writeExtensionTable(&buf, map[string]installedExtension{
+		"rd/pinned": {Version: testDigest},
+		"rd/extension": {Version: "1.0", Labels: map[string]string{
+			"org.opencontainers.image.title":  "Extension",
+			"org.opencontainers.image.vendor": "Rancher",
+		}},
+	})
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	require.Len(t, lines, 3)
+	assert.Equal(t, []string{"EXTENSION", "TITLE", "VENDOR"}, strings.Fields(lines[0]))
+	assert.Equal(t, []string{"rd/extension:1.0", "Extension", "Rancher"}, strings.Fields(lines[1]))
+	assert.Equal(t, []string{"rd/pinned@" + testDigest, "-", "-"}, strings.Fields(lines[2]))
+}
@@ -18,15 +18,20 @@ package cmd
 
 import (
 	"fmt"
+	"os"
 
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/exitcode"
 	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/factoryreset"
 	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
 	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/shutdown"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/steps"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/vm"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
-var removeKubernetesCache bool
+var factoryResetScope factoryreset.Scope
+var factoryResetFailFast bool
 
 // Note that this command supports a `--remove-kubernetes-cache` flag,
 // but the server takes an optional flag meaning the opposite (as per issues
@@ -37,7 +42,26 @@ var factoryResetCmd = &cobra.Command{
 	Use:   "factory-reset",
 	Short: "Clear all the Rancher Desktop state and shut it down.",
 	Long: `Clear all the Rancher Desktop state and shut it down.
-Use the --remove-kubernetes-cache=BOOLEAN flag to also remove the cached Kubernetes images.`,
+Use the --remove-kubernetes-cache=BOOLEAN flag to also remove the cached Kubernetes images.
+
+Parts of the state can be kept, to fix a problem without losing the rest:
+
+  --keep-images            keep the images of the container engine
+  --keep-kubernetes-data   keep the Kubernetes datastore and volumes
+  --keep-settings          keep the settings, the autostart configuration, and
+                           the PATH changes in the shell profiles
+  --only-cache             only remove the cache (downloaded Kubernetes
+                           versions and other files that are fetched again)
+
+The images and the Kubernetes data are both stored on the disk of the VM,
+which is kept with either flag; when only one of them is given, the other data
+is deleted from it first, which requires Rancher Desktop to be running.
+
+The reset consists of several independent steps. By default, all of them are
+attempted even if some fail, and a summary is printed at the end; use
+--fail-fast to stop at the first failure instead. If Rancher Desktop can't be
+shut down, no data is deleted. The exit status is 0 if every step succeeded,
+3 if only some of them did, and 1 otherwise.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if err := cobra.NoArgs(cmd, args); err != nil {
 			return err
@@ -45,22 +69,55 @@ Use the --remove-kubernetes-cache=BOOLEAN flag to also remove the cached Kuberne
 		if commonShutdownSettings.Verbose {
 			logrus.SetLevel(logrus.TraceLevel)
 		}
+		if err := factoryResetScope.Validate(); err != nil {
+			return exitcode.WithCode(err, exitcode.InvalidInput)
+		}
 		cmd.SilenceUsage = true
 		commonShutdownSettings.WaitForShutdown = false
-		_, err := doShutdown(&commonShutdownSettings, shutdown.FactoryReset)
-		if err != nil {
-			return err
-		}
 		paths, err := paths.GetPaths()
 		if err != nil {
 			return fmt.Errorf("failed to get paths: %w", err)
 		}
-		return factoryreset.DeleteData(paths, removeKubernetesCache)
+		runner := &steps.Runner{FailFast: factoryResetFailFast}
+		if factoryResetScope.KeepImages != factoryResetScope.KeepKubernetesData {
+			// Only the data that isn't kept is deleted from the disk of the
+			// VM, so the reset stops if that fails.
+			runner.RunRequired("delete data from the VM", func() error {
+				machine, err := vm.New(paths)
+				if err != nil {
+					return err
+				}
+				return factoryreset.RemoveVMData(machine, factoryResetScope)
+			})
+		}
+		// Deleting the data of a running application could leave it in an
+		// inconsistent state, so nothing else is attempted if it doesn't stop.
+		runner.RunRequired("shut down Rancher Desktop", func() error {
+			// A factory reset deletes the VM while shutting it down.
+			initiatingCommand := shutdown.FactoryReset
+			if factoryResetScope.KeepVM() {
+				initiatingCommand = shutdown.Shutdown
+			}
+			_, err := doShutdown(&commonShutdownSettings, initiatingCommand)
+			return err
+		})
+		if err := factoryreset.DeleteData(paths, factoryResetScope, runner); err != nil {
+			// The report already includes the errors.
+			cmd.SilenceErrors = true
+			_ = runner.WriteReport(os.Stderr, cmd.Name())
+			return err
+		}
+		return nil
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(factoryResetCmd)
-	factoryResetCmd.Flags().BoolVar(&removeKubernetesCache, "remove-kubernetes-cache", false, "If specified, also removes the cached Kubernetes images.")
+	factoryResetCmd.Flags().BoolVar(&factoryResetScope.RemoveKubernetesCache, "remove-kubernetes-cache", false, "If specified, also removes the cached Kubernetes images.")
+	factoryResetCmd.Flags().BoolVar(&factoryResetScope.KeepImages, "keep-images", false, "Keep the images of the container engine.")
+	factoryResetCmd.Flags().BoolVar(&factoryResetScope.KeepKubernetesData, "keep-kubernetes-data", false, "Keep the Kubernetes datastore and volumes.")
+	factoryResetCmd.Flags().BoolVar(&factoryResetScope.KeepSettings, "keep-settings", false, "Keep the settings.")
+	factoryResetCmd.Flags().BoolVar(&factoryResetScope.OnlyCache, "only-cache", false, "Only remove the cache.")
 	factoryResetCmd.Flags().BoolVar(&commonShutdownSettings.Verbose, "verbose", false, "Be verbose")
+	factoryResetCmd.Flags().BoolVar(&factoryResetFailFast, "fail-fast", false, "Stop at the first step that fails, instead of attempting all of them.")
 }
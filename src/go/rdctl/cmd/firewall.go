@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/firewall"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/output"
+	"github.com/spf13/cobra"
+)
+
+var firewallListSettings struct {
+	Output string
+}
+
+var firewallCmd = &cobra.Command{
+	Use:   "firewall",
+	Short: "Manage the firewall rules of Rancher Desktop",
+	Long: `Manage the Windows Defender Firewall rules of Rancher Desktop.
+
+When experimental.virtualMachine.firewallRules is enabled, the privileged
+service allows inbound connections to ports containers publish on all
+interfaces, and removes the rules when the ports are unpublished or Rancher
+Desktop stops.`,
+}
+
+var firewallListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List the firewall rules owned by Rancher Desktop",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		formatter, err := output.NewFormatter(firewallListSettings.Output, tableFormat, output.JSON)
+		if err != nil {
+			return err
+		}
+		cmd.SilenceUsage = true
+		rules, err := firewall.List()
+		if err != nil {
+			return err
+		}
+		if formatter.Format != tableFormat {
+			return formatter.Write(os.Stdout, rules)
+		}
+		if len(rules) == 0 {
+			output.Infof("No firewall rules present.")
+			return nil
+		}
+		writer := tabwriter.NewWriter(os.Stdout, 0, 4, 4, ' ', 0)
+		fmt.Fprintf(writer, "NAME\tPORT\tPROTOCOL\n")
+		for _, rule := range rules {
+			fmt.Fprintf(writer, "%s\t%s\t%s\n", rule.Name, rule.Port, rule.Protocol)
+		}
+		return writer.Flush()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(firewallCmd)
+	firewallCmd.AddCommand(firewallListCmd)
+	markReadOnly(firewallListCmd)
+	output.AddFlag(firewallListCmd.Flags(), &firewallListSettings.Output, tableFormat, output.JSON)
+}
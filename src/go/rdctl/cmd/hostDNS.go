@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/hostdns"
+	"github.com/spf13/cobra"
+)
+
+var hostDNSCmd = &cobra.Command{
+	Hidden: true,
+	Use:    "host-dns",
+	Short:  "Print the DNS configuration of the host",
+	Long: `Print the DNS servers and domains of the host as JSON, as configured in
+systemd-resolved or NetworkManager; only supported on Linux.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		config, err := hostdns.Detect()
+		if err != nil {
+			return err
+		}
+		if err := json.NewEncoder(os.Stdout).Encode(config); err != nil {
+			return fmt.Errorf("failed to output the DNS configuration: %w", err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(hostDNSCmd)
+	markReadOnly(hostDNSCmd)
+}
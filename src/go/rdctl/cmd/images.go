@@ -0,0 +1,18 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var imagesCmd = &cobra.Command{
+	Use:   "images",
+	Short: "Manage the images of the container engine, and the keys decrypting them",
+	Long: `Manage the images of the container engine: those of moby, or those of the
+namespace of containerd shown in the Images page (the images.namespace
+setting), unless --namespace is given. The commands run docker or nerdctl,
+whichever the container engine uses, so scripts work with either engine.`,
+}
+
+func init() {
+	rootCmd.AddCommand(imagesCmd)
+}
@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/imagelayers"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/output"
+	"github.com/spf13/cobra"
+)
+
+// maxStepWidth limits the width of the build steps in the table.
+const maxStepWidth = 60
+
+var imagesAnalyzeSettings struct {
+	MinStepSizeInMiB int64
+	Output           string
+}
+
+var imagesAnalyzeCmd = &cobra.Command{
+	Use:   "analyze",
+	Short: "Analyze how the images share their layers, and suggest how to use less disk",
+	Long: `Analyze how the images of the container engine share their layers. Each distinct
+layer is stored once, so images built on the same base image share its layers;
+images built on different versions of a base, e.g. node:18 pulled at different
+times, don't share them, and rebuilding them on a single version frees the space
+of the others. Build steps adding large layers, which are often worth slimming
+down, are listed too.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		formatter, err := output.NewFormatter(imagesAnalyzeSettings.Output, tableFormat, output.JSON)
+		if err != nil {
+			return err
+		}
+		if imagesAnalyzeSettings.MinStepSizeInMiB < 0 {
+			return fmt.Errorf("invalid step size %d: must not be negative", imagesAnalyzeSettings.MinStepSizeInMiB)
+		}
+		cmd.SilenceUsage = true
+		engine, err := newImagesEngine("")
+		if err != nil {
+			return err
+		}
+		progress := output.StartProgress("Inspecting the images")
+		images, err := imagelayers.List(engine.capture)
+		progress.Stop()
+		if err != nil {
+			return err
+		}
+		analysis := imagelayers.Analyze(images, imagesAnalyzeSettings.MinStepSizeInMiB*1024*1024)
+		if formatter.Format != tableFormat {
+			return formatter.Write(os.Stdout, analysis)
+		}
+		return writeImagesAnalysis(os.Stdout, analysis, imagesAnalyzeSettings.MinStepSizeInMiB)
+	},
+}
+
+func init() {
+	imagesCmd.AddCommand(imagesAnalyzeCmd)
+	markReadOnly(imagesAnalyzeCmd)
+	imagesAnalyzeCmd.Flags().Int64Var(&imagesAnalyzeSettings.MinStepSizeInMiB, "min-step-size", 100, "list the build steps adding layers of at least this many MiB")
+	output.AddFlag(imagesAnalyzeCmd.Flags(), &imagesAnalyzeSettings.Output, tableFormat, output.JSON)
+}
+
+func writeImagesAnalysis(w io.Writer, analysis *imagelayers.Analysis, minStepSizeInMiB int64) error {
+	if analysis.Images == 0 {
+		fmt.Fprintln(w, "There are no images.")
+		return nil
+	}
+	fmt.Fprintf(w, "Images: %d, of %s in total counting the shared layers once for each image\n",
+		analysis.Images, formatUsage(analysis.ApparentSize))
+	fmt.Fprintf(w, "Layers: %d, of which %d are shared; the images use %d layers in total\n",
+		analysis.Layers, analysis.SharedLayers, analysis.LayerReferences)
+
+	writer := tabwriter.NewWriter(w, 0, 4, 4, ' ', 0)
+	if len(analysis.FragmentedBases) > 0 {
+		fmt.Fprintf(writer, "\nBASE IMAGE\tSIZE\tIMAGES BUILT ON IT\n")
+		for _, group := range analysis.FragmentedBases {
+			for _, base := range group.Bases {
+				fmt.Fprintf(writer, "%s\t%s\t%d\n", base.Name, formatUsage(base.Size), len(base.Images))
+			}
+		}
+	}
+	if len(analysis.LargeSteps) > 0 {
+		fmt.Fprintf(writer, "\nSIZE\tIMAGES\tBUILD STEP\n")
+		for _, step := range analysis.LargeSteps {
+			fmt.Fprintf(writer, "%s\t%d\t%s\n", formatUsage(step.Size), len(step.Images), truncateStep(step.CreatedBy))
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+
+	var suggestions []string
+	for _, group := range analysis.FragmentedBases {
+		first, others := group.Bases[0], 0
+		for _, base := range group.Bases[1:] {
+			others += len(base.Images)
+		}
+		suggestions = append(suggestions, fmt.Sprintf(
+			"%d images are built on %d versions of %s; rebuilding the %d built on other versions on %s could free up to %s.",
+			others+len(first.Images), len(group.Bases), group.Repository, others, first.Name, formatUsage(group.Savings)))
+	}
+	if len(analysis.LargeSteps) > 0 {
+		suggestions = append(suggestions, fmt.Sprintf(
+			"The build steps above add layers of at least %dMi; consider removing caches and build dependencies in the same step, or multi-stage builds.",
+			minStepSizeInMiB))
+	}
+	if len(suggestions) == 0 {
+		return nil
+	}
+	fmt.Fprintf(w, "\nSuggestions:\n")
+	for _, suggestion := range suggestions {
+		fmt.Fprintf(w, "  - %s\n", suggestion)
+	}
+	return nil
+}
+
+// truncateStep shortens a build step for the table, dropping the shell
+// prefix of the steps of Dockerfiles.
+func truncateStep(step string) string {
+	step = strings.Join(strings.Fields(step), " ")
+	step = strings.TrimPrefix(step, "/bin/sh -c ")
+	step = strings.TrimPrefix(step, "#(nop) ")
+	if len(step) > maxStepWidth {
+		step = step[:maxStepWidth-3] + "..."
+	}
+	return step
+}
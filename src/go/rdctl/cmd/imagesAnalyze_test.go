@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/imagelayers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteImagesAnalysis(t *testing.T) {
+	const mebibyte = 1024 * 1024
+	analysis := &imagelayers.Analysis{
+		Images:          3,
+		ApparentSize:    1500 * mebibyte,
+		Layers:          5,
+		SharedLayers:    1,
+		LayerReferences: 6,
+		FragmentedBases: []imagelayers.BaseGroup{{
+			Repository: "node",
+			Bases: []imagelayers.Base{
+				{Name: "node:18", Size: 300 * mebibyte, Images: []string{"app1", "app2"}},
+				{Name: "node@sha256:old", Size: 290 * mebibyte, Images: []string{"app3"}},
+			},
+			Savings: 290 * mebibyte,
+		}},
+		LargeSteps: []imagelayers.LargeStep{
+			{CreatedBy: "/bin/sh -c apt-get update && apt-get install -y chromium fonts-liberation libnss3 xvfb", Size: 600 * mebibyte, Images: []string{"app3"}},
+		},
+	}
+	var output bytes.Buffer
+	require.NoError(t, writeImagesAnalysis(&output, analysis, 100))
+	assert.Equal(t, `Images: 3, of 1.5Gi in total counting the shared layers once for each image
+Layers: 5, of which 1 are shared; the images use 6 layers in total
+
+BASE IMAGE         SIZE       IMAGES BUILT ON IT
+node:18            300.0Mi    2
+node@sha256:old    290.0Mi    1
+
+SIZE       IMAGES    BUILD STEP
+600.0Mi    1         apt-get update && apt-get install -y chromium fonts-liber...
+
+Suggestions:
+  - 3 images are built on 2 versions of node; rebuilding the 1 built on other versions on node:18 could free up to 290.0Mi.
+  - The build steps above add layers of at least 100Mi; consider removing caches and build dependencies in the same step, or multi-stage builds.
+`, output.String())
+
+	output.Reset()
+	require.NoError(t, writeImagesAnalysis(&output, &imagelayers.Analysis{}, 100))
+	assert.Equal(t, "There are no images.\n", output.String())
+}
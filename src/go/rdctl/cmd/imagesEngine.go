@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/client"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/config"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/exitcode"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/tools"
+)
+
+// imagesEngine runs the command line tool of the container engine of the
+// running application: docker for moby, or nerdctl in a namespace for
+// containerd.
+type imagesEngine struct {
+	name string
+	path string
+	// args are the arguments passed before those of each command, e.g. the
+	// namespace of nerdctl.
+	args []string
+}
+
+// imagesEngineSettings are the settings choosing the tool of the container
+// engine.
+type imagesEngineSettings struct {
+	ContainerEngine struct {
+		Name string `json:"name"`
+	} `json:"containerEngine"`
+	Images struct {
+		Namespace string `json:"namespace"`
+	} `json:"images"`
+}
+
+// newImagesEngine returns the tool of the container engine of the running
+// application, in the given namespace for nerdctl, or in the namespace of the
+// Images page if it is empty.
+func newImagesEngine(namespace string) (*imagesEngine, error) {
+	connectionInfo, err := config.GetConnectionInfo(false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection info: %w", err)
+	}
+	rdClient := client.NewRDClient(connectionInfo)
+	body, err := client.ProcessRequestForUtility(rdClient.DoRequest("GET", client.VersionCommand("", "settings")))
+	if err != nil {
+		return nil, err
+	}
+	var settings imagesEngineSettings
+	if err := json.Unmarshal(body, &settings); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal settings: %w", err)
+	}
+	name, args, err := imagesEngineCommand(settings, namespace)
+	if err != nil {
+		return nil, err
+	}
+	appPaths, err := paths.GetPaths()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get paths: %w", err)
+	}
+	path, err := tools.Find(appPaths, name)
+	if err != nil {
+		return nil, err
+	}
+	return &imagesEngine{name: name, path: path, args: args}, nil
+}
+
+// imagesEngineCommand returns the name of the tool of the container engine,
+// and the arguments it needs for every command.
+func imagesEngineCommand(settings imagesEngineSettings, namespace string) (string, []string, error) {
+	if settings.ContainerEngine.Name == "moby" {
+		if namespace != "" {
+			return "", nil, exitcode.WithCode(errors.New("--namespace is only supported with the containerd engine; moby has no namespaces"), exitcode.InvalidInput)
+		}
+		return "docker", nil, nil
+	}
+	if namespace == "" {
+		namespace = settings.Images.Namespace
+	}
+	return "nerdctl", []string{"--namespace", namespace}, nil
+}
+
+// capture runs the tool with the arguments, and returns its standard output.
+// It is an imagelayers.CLI.
+func (e *imagesEngine) capture(args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(e.path, append(e.args, args...)...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s %s failed: %w: %s", e.name, args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
+
+// run runs the tool with the arguments, connected to the standard streams of
+// rdctl. When the tool fails, the returned error has its exit code.
+func (e *imagesEngine) run(args ...string) error {
+	cmd := exec.Command(e.path, append(e.args, args...)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
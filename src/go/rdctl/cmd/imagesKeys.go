@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/client"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/config"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/exitcode"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/imgcrypt"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/output"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/vm"
+	"github.com/spf13/cobra"
+)
+
+var imagesKeysSettings struct {
+	Name   string
+	Output string
+}
+
+var imagesKeysCmd = &cobra.Command{
+	Use:   "keys",
+	Short: "Manage the keys decrypting encrypted images",
+	Long: `Manage the private keys containerd uses to decrypt the layers of encrypted images
+(OCI images encrypted with imgcrypt, e.g. by 'nerdctl image encrypt'). Images
+are decrypted when they are pulled with nerdctl or by Kubernetes, with any of
+the keys; moby doesn't support encrypted images.
+
+The keys are stored in the VM, in ` + imgcrypt.KeysDir + `, and are
+kept when Rancher Desktop restarts, but not across a factory reset.`,
+}
+
+var imagesKeysAddCmd = &cobra.Command{
+	Use:   "add <file>",
+	Short: "Add a private key to decrypt images with",
+	Long: `Add the private key in the file, or in the standard input for "-", to decrypt
+images with. The key is named after the file unless --name is given; a key of
+the same name is replaced.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := imagesKeysSettings.Name
+		if name == "" {
+			if args[0] == "-" {
+				return exitcode.WithCode(errors.New("--name is required to add a key from the standard input"), exitcode.InvalidInput)
+			}
+			name = filepath.Base(args[0])
+		}
+		if err := imgcrypt.ValidateName(name); err != nil {
+			return exitcode.WithCode(err, exitcode.InvalidInput)
+		}
+		cmd.SilenceUsage = true
+		contents, err := readKeyFile(args[0])
+		if err != nil {
+			return err
+		}
+		if err := imgcrypt.ValidateKey(contents); err != nil {
+			return exitcode.WithCode(fmt.Errorf("invalid key %s: %w", args[0], err), exitcode.InvalidInput)
+		}
+		runner, err := newImagesVM()
+		if err != nil {
+			return err
+		}
+		if err := imgcrypt.Add(runner, name, contents); err != nil {
+			return err
+		}
+		output.Infof("Added key %q.", name)
+		return nil
+	},
+}
+
+var imagesKeysListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List the keys decrypting images",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		formatter, err := output.NewFormatter(imagesKeysSettings.Output, tableFormat, output.JSON)
+		if err != nil {
+			return err
+		}
+		cmd.SilenceUsage = true
+		runner, err := newImagesVM()
+		if err != nil {
+			return err
+		}
+		keys, err := imgcrypt.List(runner)
+		if err != nil {
+			return err
+		}
+		if formatter.Format != tableFormat {
+			return formatter.Write(os.Stdout, keys)
+		}
+		if len(keys) == 0 {
+			output.Infof("No keys present.")
+			return nil
+		}
+		writer := tabwriter.NewWriter(os.Stdout, 0, 4, 4, ' ', 0)
+		fmt.Fprintf(writer, "NAME\tSIZE\n")
+		for _, key := range keys {
+			fmt.Fprintf(writer, "%s\t%d\n", key.Name, key.Size)
+		}
+		return writer.Flush()
+	},
+}
+
+var imagesKeysRemoveCmd = &cobra.Command{
+	Use:     "remove <name>",
+	Aliases: []string{"rm"},
+	Short:   "Remove a key decrypting images",
+	Long: `Remove the key. The images it decrypted keep working, but pulling them again
+fails unless another key decrypts them.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		runner, err := newImagesVM()
+		if err != nil {
+			return err
+		}
+		if err := imgcrypt.Remove(runner, args[0]); err != nil {
+			if errors.Is(err, imgcrypt.ErrKeyNotFound) {
+				return exitcode.WithCode(err, exitcode.InvalidInput)
+			}
+			return err
+		}
+		output.Infof("Removed key %q.", args[0])
+		return nil
+	},
+}
+
+func init() {
+	imagesCmd.AddCommand(imagesKeysCmd)
+	imagesKeysCmd.AddCommand(imagesKeysAddCmd)
+	imagesKeysCmd.AddCommand(imagesKeysListCmd)
+	markReadOnly(imagesKeysListCmd)
+	imagesKeysCmd.AddCommand(imagesKeysRemoveCmd)
+	imagesKeysAddCmd.Flags().StringVar(&imagesKeysSettings.Name, "name", "", "name of the key; defaults to the name of the file")
+	output.AddFlag(imagesKeysListCmd.Flags(), &imagesKeysSettings.Output, tableFormat, output.JSON)
+}
+
+func readKeyFile(path string) ([]byte, error) {
+	if path == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, exitcode.WithCode(fmt.Errorf("failed to read key: %w", err), exitcode.InvalidInput)
+	}
+	return contents, nil
+}
+
+// newImagesVM checks that the backend is running, and returns a runner for
+// commands in the VM; it warns that moby doesn't decrypt images.
+func newImagesVM() (vm.Runner, error) {
+	connectionInfo, err := config.GetConnectionInfo(false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection info: %w", err)
+	}
+	rdClient := client.NewRDClient(connectionInfo)
+	state, err := rdClient.GetBackendState()
+	if err != nil {
+		return nil, err
+	}
+	if state.VMState != "STARTED" {
+		return nil, exitcode.WithCode(fmt.Errorf("the backend is not running (state %s)", state.VMState), exitcode.NotRunning)
+	}
+	body, err := client.ProcessRequestForUtility(rdClient.DoRequest("GET", client.VersionCommand("", "settings")))
+	if err != nil {
+		return nil, err
+	}
+	var settings struct {
+		ContainerEngine struct {
+			Name string `json:"name"`
+		} `json:"containerEngine"`
+	}
+	if err := json.Unmarshal(body, &settings); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal settings: %w", err)
+	}
+	if settings.ContainerEngine.Name == "moby" {
+		output.Infof("The container engine is moby, which doesn't decrypt images; switch to containerd to pull encrypted images.")
+	}
+	appPaths, err := paths.GetPaths()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get paths: %w", err)
+	}
+	return vm.New(appPaths)
+}
@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"errors"
+	"os/exec"
+
+	"github.com/spf13/cobra"
+)
+
+// imagesManageSettings are the flags of the commands managing the images;
+// each command only registers those it uses.
+var imagesManageSettings struct {
+	Namespace string
+	All       bool
+	Quiet     bool
+	Force     bool
+	Platform  string
+}
+
+const imagesPassthroughHelp = `
+Arguments after "--" are passed on unchanged to docker or nerdctl.`
+
+var imagesListCmd = &cobra.Command{
+	Use:     "list [-- <engine arguments>...]",
+	Aliases: []string{"ls"},
+	Short:   "List the images of the container engine",
+	Long: `List the images of the container engine, with docker for moby or nerdctl for
+containerd.` + imagesPassthroughHelp,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runImagesEngine(cmd, imagesListArgs(imagesManageSettings.All, imagesManageSettings.Quiet, args))
+	},
+}
+
+var imagesPullCmd = &cobra.Command{
+	Use:   "pull <image> [-- <engine arguments>...]",
+	Short: "Pull an image into the container engine",
+	Long: `Pull an image into the container engine, with docker for moby or nerdctl for
+containerd.` + imagesPassthroughHelp,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runImagesEngine(cmd, imagesPullArgs(imagesManageSettings.Platform, imagesManageSettings.Quiet, args))
+	},
+}
+
+var imagesRemoveCmd = &cobra.Command{
+	Use:     "remove <image>... [-- <engine arguments>...]",
+	Aliases: []string{"rm"},
+	Short:   "Remove images from the container engine",
+	Long: `Remove images from the container engine, with docker for moby or nerdctl for
+containerd.` + imagesPassthroughHelp,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runImagesEngine(cmd, imagesRemoveArgs(imagesManageSettings.Force, args))
+	},
+}
+
+var imagesPruneCmd = &cobra.Command{
+	Use:   "prune [-- <engine arguments>...]",
+	Short: "Remove the unused images of the container engine",
+	Long: `Remove the dangling images of the container engine, or with --all every image
+no container uses, with docker for moby or nerdctl for containerd.` + imagesPassthroughHelp,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runImagesEngine(cmd, imagesPruneArgs(imagesManageSettings.All, imagesManageSettings.Force, args))
+	},
+}
+
+func init() {
+	imagesCmd.AddCommand(imagesListCmd, imagesPullCmd, imagesRemoveCmd, imagesPruneCmd)
+	markReadOnly(imagesListCmd)
+	for _, cmd := range []*cobra.Command{imagesListCmd, imagesPullCmd, imagesRemoveCmd, imagesPruneCmd} {
+		cmd.Flags().StringVar(&imagesManageSettings.Namespace, "namespace", "", "containerd namespace of the images; defaults to the images.namespace setting")
+	}
+	imagesListCmd.Flags().BoolVarP(&imagesManageSettings.All, "all", "a", false, "list the intermediate images too")
+	imagesListCmd.Flags().BoolVarP(&imagesManageSettings.Quiet, "quiet", "q", false, "only list the image IDs")
+	imagesPullCmd.Flags().StringVar(&imagesManageSettings.Platform, "platform", "", "pull the image for this platform, e.g. linux/arm64")
+	imagesPullCmd.Flags().BoolVarP(&imagesManageSettings.Quiet, "quiet", "q", false, "don't show the progress")
+	imagesRemoveCmd.Flags().BoolVarP(&imagesManageSettings.Force, "force", "f", false, "remove the images even if containers use them")
+	imagesPruneCmd.Flags().BoolVarP(&imagesManageSettings.All, "all", "a", false, "remove every image no container uses, not only the dangling ones")
+	imagesPruneCmd.Flags().BoolVarP(&imagesManageSettings.Force, "force", "f", false, "don't ask for confirmation")
+}
+
+// runImagesEngine runs the tool of the container engine with the arguments.
+// The tool reports its own errors, so rdctl only passes on its exit code.
+func runImagesEngine(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	engine, err := newImagesEngine(imagesManageSettings.Namespace)
+	if err != nil {
+		return err
+	}
+	err = engine.run(args...)
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		cmd.SilenceErrors = true
+	}
+	return err
+}
+
+// imagesListArgs returns the arguments of docker or nerdctl listing the images.
+func imagesListArgs(all, quiet bool, extra []string) []string {
+	args := []string{"images"}
+	if all {
+		args = append(args, "--all")
+	}
+	if quiet {
+		args = append(args, "--quiet")
+	}
+	return append(args, extra...)
+}
+
+// imagesPullArgs returns the arguments of docker or nerdctl pulling an image.
+func imagesPullArgs(platform string, quiet bool, extra []string) []string {
+	args := []string{"pull"}
+	if platform != "" {
+		args = append(args, "--platform", platform)
+	}
+	if quiet {
+		args = append(args, "--quiet")
+	}
+	return append(args, extra...)
+}
+
+// imagesRemoveArgs returns the arguments of docker or nerdctl removing images.
+func imagesRemoveArgs(force bool, extra []string) []string {
+	args := []string{"rmi"}
+	if force {
+		args = append(args, "--force")
+	}
+	return append(args, extra...)
+}
+
+// imagesPruneArgs returns the arguments of docker or nerdctl removing the
+// unused images.
+func imagesPruneArgs(all, force bool, extra []string) []string {
+	args := []string{"image", "prune"}
+	if all {
+		args = append(args, "--all")
+	}
+	if force {
+		args = append(args, "--force")
+	}
+	return append(args, extra...)
+}
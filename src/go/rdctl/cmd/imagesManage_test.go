@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/exitcode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImagesEngineCommand(t *testing.T) {
+	var containerd, moby imagesEngineSettings
+	containerd.ContainerEngine.Name = "containerd"
+	containerd.Images.Namespace = "k8s.io"
+	moby.ContainerEngine.Name = "moby"
+	moby.Images.Namespace = "k8s.io"
+
+	t.Run("uses the namespace of the Images page", func(t *testing.T) {
+		name, args, err := imagesEngineCommand(containerd, "")
+		require.NoError(t, err)
+		assert.Equal(t, "nerdctl", name)
+		assert.Equal(t, []string{"--namespace", "k8s.io"}, args)
+	})
+	t.Run("uses the given namespace", func(t *testing.T) {
+		name, args, err := imagesEngineCommand(containerd, "buildkit")
+		require.NoError(t, err)
+		assert.Equal(t, "nerdctl", name)
+		assert.Equal(t, []string{"--namespace", "buildkit"}, args)
+	})
+	t.Run("uses docker for moby", func(t *testing.T) {
+		name, args, err := imagesEngineCommand(moby, "")
+		require.NoError(t, err)
+		assert.Equal(t, "docker", name)
+		assert.Empty(t, args)
+	})
+	t.Run("rejects a namespace for moby", func(t *testing.T) {
+		_, _, err := imagesEngineCommand(moby, "buildkit")
+		assert.ErrorContains(t, err, "--namespace is only supported with the containerd engine")
+		assert.Equal(t, exitcode.InvalidInput, exitcode.FromError(err))
+	})
+}
+
+func TestImagesArgs(t *testing.T) {
+	assert.Equal(t, []string{"images"}, imagesListArgs(false, false, nil))
+	assert.Equal(t, []string{"images", "--all", "--quiet", "--digests"}, imagesListArgs(true, true, []string{"--digests"}))
+	assert.Equal(t, []string{"pull", "alpine"}, imagesPullArgs("", false, []string{"alpine"}))
+	assert.Equal(t, []string{"pull", "--platform", "linux/arm64", "--quiet", "alpine"}, imagesPullArgs("linux/arm64", true, []string{"alpine"}))
+	assert.Equal(t, []string{"rmi", "alpine", "busybox"}, imagesRemoveArgs(false, []string{"alpine", "busybox"}))
+	assert.Equal(t, []string{"rmi", "--force", "alpine"}, imagesRemoveArgs(true, []string{"alpine"}))
+	assert.Equal(t, []string{"image", "prune"}, imagesPruneArgs(false, false, nil))
+	assert.Equal(t, []string{"image", "prune", "--all", "--force"}, imagesPruneArgs(true, true, nil))
+}
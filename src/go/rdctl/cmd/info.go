@@ -0,0 +1,197 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"text/tabwriter"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/client"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/directories"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/output"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/tools"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/vm"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/vmdisk"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/vmswap"
+	"github.com/spf13/cobra"
+)
+
+var infoSettings struct {
+	Output    string
+	Resources bool
+	Swap      bool
+}
+
+// infoTools are the tools whose resolution is reported by `rdctl info --resources`.
+var infoTools = []string{"kubectl", "docker", "nerdctl", "helm", "limactl"}
+
+type resourceDirInfo struct {
+	Path   string `json:"path"`
+	Exists bool   `json:"exists"`
+}
+
+type infoResult struct {
+	Version       string            `json:"version"`
+	OS            string            `json:"os"`
+	Arch          string            `json:"arch"`
+	Architectures []string          `json:"architectures"`
+	ResourcesPath string            `json:"resourcesPath"`
+	ResourceDirs  []resourceDirInfo `json:"resourceDirs,omitempty"`
+	// Tools maps the names of the tools to their paths; tools that could not
+	// be found have an empty path.
+	Tools map[string]string `json:"tools,omitempty"`
+	Swap  *swapInfo         `json:"swap,omitempty"`
+}
+
+// swapInfo is the swap space of the VM, reported by `rdctl info --swap`.
+type swapInfo struct {
+	Running bool `json:"running"`
+	// Devices are only known while the VM is running.
+	Devices []vmswap.Device `json:"devices,omitempty"`
+}
+
+var infoCmd = &cobra.Command{
+	Use:   "info",
+	Short: "Show information about the rdctl installation",
+	Long: `Show information about the rdctl installation: its version, the platform and
+architectures it runs on, and where the application resources are.
+
+With --resources, also show the resource directories that are searched, most
+preferred first, and where the bundled tools were found. On Macs running rdctl
+under Rosetta, binaries for the machine architecture are preferred.
+
+With --swap, also show the swap space of the VM while it is running (see the
+experimental.virtualMachine.swap setting).`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		formatter, err := output.NewFormatter(infoSettings.Output, tableFormat, output.JSON)
+		if err != nil {
+			return err
+		}
+		cmd.SilenceUsage = true
+		result, err := getInfo(infoSettings.Resources, infoSettings.Swap)
+		if err != nil {
+			return err
+		}
+		if formatter.Format != tableFormat {
+			return formatter.Write(os.Stdout, result)
+		}
+		writer := tabwriter.NewWriter(os.Stdout, 0, 4, 4, ' ', 0)
+		fmt.Fprintf(writer, "Version:\t%s\n", result.Version)
+		fmt.Fprintf(writer, "Platform:\t%s/%s\n", result.OS, result.Arch)
+		fmt.Fprintf(writer, "Architectures:\t%v\n", result.Architectures)
+		fmt.Fprintf(writer, "Resources:\t%s\n", result.ResourcesPath)
+		if infoSettings.Resources {
+			fmt.Fprintf(writer, "\nRESOURCE DIRECTORY\tEXISTS\n")
+			for _, dir := range result.ResourceDirs {
+				fmt.Fprintf(writer, "%s\t%t\n", dir.Path, dir.Exists)
+			}
+			fmt.Fprintf(writer, "\nTOOL\tPATH\n")
+			for _, name := range infoTools {
+				if toolPath, ok := result.Tools[name]; ok {
+					if toolPath == "" {
+						toolPath = "not found"
+					}
+					fmt.Fprintf(writer, "%s\t%s\n", name, toolPath)
+				}
+			}
+		}
+		if result.Swap != nil && len(result.Swap.Devices) > 0 {
+			fmt.Fprintf(writer, "\nSWAP\tTYPE\tSIZE\tUSED\tPRIORITY\n")
+			for _, device := range result.Swap.Devices {
+				fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%d\n", device.Name, device.Type,
+					formatUsage(device.Size), formatUsage(device.Used), device.Priority)
+			}
+		}
+		if err := writer.Flush(); err != nil {
+			return err
+		}
+		if result.Swap != nil && !result.Swap.Running {
+			output.Infof("The VM is not running; start Rancher Desktop to see its swap space.")
+		} else if result.Swap != nil && len(result.Swap.Devices) == 0 {
+			output.Infof("The VM has no swap space.")
+		}
+		return nil
+	},
+}
+
+func getInfo(withResources, withSwap bool) (*infoResult, error) {
+	appPaths, err := paths.GetPaths()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get paths: %w", err)
+	}
+	result := &infoResult{
+		Version:       client.Version,
+		OS:            runtime.GOOS,
+		Arch:          runtime.GOARCH,
+		Architectures: paths.Architectures(),
+		ResourcesPath: appPaths.Resources,
+	}
+	if withSwap {
+		if result.Swap, err = getSwapInfo(appPaths); err != nil {
+			return nil, err
+		}
+	}
+	if !withResources {
+		return result, nil
+	}
+	for _, dir := range appPaths.ResourceDirs("bin") {
+		_, err := os.Stat(dir)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("failed to check %q: %w", dir, err)
+		}
+		result.ResourceDirs = append(result.ResourceDirs, resourceDirInfo{Path: dir, Exists: err == nil})
+	}
+	result.Tools = map[string]string{}
+	for _, name := range infoTools {
+		if name == "limactl" && runtime.GOOS == "windows" {
+			continue
+		}
+		// Failing to find a tool is what is being reported, not an error.
+		result.Tools[name], _ = findTool(appPaths, name)
+	}
+	return result, nil
+}
+
+// getSwapInfo returns the swap space of the VM, if it is running.
+func getSwapInfo(appPaths paths.Paths) (*swapInfo, error) {
+	running, err := vmdisk.Running()
+	if err != nil || !running {
+		return &swapInfo{}, err
+	}
+	runner, err := vm.New(appPaths)
+	if err != nil {
+		return nil, err
+	}
+	devices, err := vmswap.Get(runner)
+	if err != nil {
+		return nil, err
+	}
+	return &swapInfo{Running: true, Devices: devices}, nil
+}
+
+// findTool returns the path to the named tool; limactl is shipped apart from
+// the other tools.
+func findTool(appPaths paths.Paths, name string) (string, error) {
+	if name != "limactl" {
+		return tools.Find(appPaths, name)
+	}
+	limactlPath, err := directories.GetLimactlPath()
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(limactlPath); err != nil {
+		return "", err
+	}
+	return limactlPath, nil
+}
+
+func init() {
+	rootCmd.AddCommand(infoCmd)
+	markReadOnly(infoCmd)
+	infoCmd.Flags().BoolVar(&infoSettings.Resources, "resources", false, "show the resource directories and the tools found in them")
+	infoCmd.Flags().BoolVar(&infoSettings.Swap, "swap", false, "show the swap space of the VM")
+	output.AddFlag(infoCmd.Flags(), &infoSettings.Output, tableFormat, output.JSON)
+}
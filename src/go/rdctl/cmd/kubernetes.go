@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/client"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/config"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/kubectl"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/vm"
+	"github.com/spf13/cobra"
+)
+
+var kubernetesCmd = &cobra.Command{
+	Use:     "kubernetes",
+	Aliases: []string{"k8s"},
+	Short:   "Manage the Rancher Desktop Kubernetes cluster",
+}
+
+func init() {
+	rootCmd.AddCommand(kubernetesCmd)
+}
+
+// newKubectl returns a runner for the kubectl shipped with Rancher Desktop.
+func newKubectl() (*kubectl.Kubectl, paths.Paths, error) {
+	appPaths, err := paths.GetPaths()
+	if err != nil {
+		return nil, appPaths, fmt.Errorf("failed to get paths: %w", err)
+	}
+	runner, err := kubectl.New(appPaths)
+	if err != nil {
+		return nil, appPaths, fmt.Errorf("failed to find kubectl: %w", err)
+	}
+	return runner, appPaths, nil
+}
+
+// newKubernetesVM checks that the backend is running with Kubernetes enabled,
+// and returns a runner for commands in the VM.
+func newKubernetesVM() (*vm.VM, error) {
+	connectionInfo, err := config.GetConnectionInfo(false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection info: %w", err)
+	}
+	rdClient := client.NewRDClient(connectionInfo)
+	state, err := rdClient.GetBackendState()
+	if err != nil {
+		return nil, err
+	}
+	if state.VMState != "STARTED" {
+		return nil, fmt.Errorf("the backend is not running (state %s)", state.VMState)
+	}
+	body, err := client.ProcessRequestForUtility(rdClient.DoRequest("GET", client.VersionCommand("", "settings")))
+	if err != nil {
+		return nil, err
+	}
+	var settings struct {
+		Kubernetes struct {
+			Enabled bool `json:"enabled"`
+		} `json:"kubernetes"`
+	}
+	if err := json.Unmarshal(body, &settings); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal settings: %w", err)
+	}
+	if !settings.Kubernetes.Enabled {
+		return nil, errors.New("this command requires Kubernetes to be enabled")
+	}
+	appPaths, err := paths.GetPaths()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get paths: %w", err)
+	}
+	return vm.New(appPaths)
+}
@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/k3s"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/output"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/vm"
+	"github.com/spf13/cobra"
+)
+
+var kubernetesBackupSettings struct {
+	Keep   int
+	Output string
+}
+
+var kubernetesBackupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Manage backups of the Kubernetes datastore",
+	Long: `Backups hold the state of the Kubernetes cluster (all resources, but not the
+contents of volumes or images), and can be used to recover from a broken
+cluster without a full reset; see 'rdctl kubernetes restore'.
+
+Backups are stored on the host, in the kubernetes-backups directory in the
+application data directory.`,
+}
+
+var kubernetesBackupCreateCmd = &cobra.Command{
+	Use:   "create [name]",
+	Short: "Back up the Kubernetes datastore",
+	Long: `Backs up the Kubernetes datastore under the given name, which defaults to the
+current date and time. Kubernetes is stopped briefly while the backup is taken;
+workloads keep running.
+
+After the backup is taken, the oldest backups are deleted so that at most
+--keep remain.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		machine, err := newKubernetesVM()
+		if err != nil {
+			return err
+		}
+		manager, err := newBackupManager(machine)
+		if err != nil {
+			return err
+		}
+		name := manager.DefaultBackupName()
+		if len(args) > 0 {
+			name = args[0]
+		}
+		progress := output.StartProgress(fmt.Sprintf("Backing up Kubernetes to %q", name))
+		_, err = manager.Create(name)
+		progress.Stop()
+		if err != nil {
+			return err
+		}
+		output.Infof("Created backup %q.", name)
+		deleted, err := manager.Prune(kubernetesBackupSettings.Keep)
+		for _, oldName := range deleted {
+			output.Infof("Deleted old backup %q.", oldName)
+		}
+		return err
+	},
+}
+
+var kubernetesBackupListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List backups of the Kubernetes datastore",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		formatter, err := output.NewFormatter(kubernetesBackupSettings.Output, tableFormat, output.JSON)
+		if err != nil {
+			return err
+		}
+		cmd.SilenceUsage = true
+		manager, err := newBackupManager(nil)
+		if err != nil {
+			return err
+		}
+		backups, err := manager.List()
+		if err != nil {
+			return err
+		}
+		if formatter.Format != tableFormat {
+			return formatter.Write(os.Stdout, backups)
+		}
+		if len(backups) == 0 {
+			output.Infof("No backups present.")
+			return nil
+		}
+		writer := tabwriter.NewWriter(os.Stdout, 0, 4, 4, ' ', 0)
+		fmt.Fprintf(writer, "NAME\tCREATED\tKUBERNETES VERSION\n")
+		for _, backup := range backups {
+			fmt.Fprintf(writer, "%s\t%s\t%s\n", backup.Name, backup.Created.Format(time.RFC1123), backup.Version)
+		}
+		return writer.Flush()
+	},
+}
+
+var kubernetesBackupDeleteCmd = &cobra.Command{
+	Use:   "delete <name>",
+	Short: "Delete a backup of the Kubernetes datastore",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		manager, err := newBackupManager(nil)
+		if err != nil {
+			return err
+		}
+		return manager.Delete(args[0])
+	},
+}
+
+func init() {
+	kubernetesCmd.AddCommand(kubernetesBackupCmd)
+	kubernetesBackupCmd.AddCommand(kubernetesBackupCreateCmd)
+	kubernetesBackupCmd.AddCommand(kubernetesBackupListCmd)
+	markReadOnly(kubernetesBackupListCmd)
+	kubernetesBackupCmd.AddCommand(kubernetesBackupDeleteCmd)
+	kubernetesBackupCreateCmd.Flags().IntVar(&kubernetesBackupSettings.Keep, "keep", 5, "number of backups to keep; 0 keeps all backups")
+	output.AddFlag(kubernetesBackupListCmd.Flags(), &kubernetesBackupSettings.Output, tableFormat, output.JSON)
+}
+
+// newBackupManager returns a manager for the backups; machine may be nil for
+// operations that don't access the VM.
+func newBackupManager(machine vm.Runner) (*k3s.BackupManager, error) {
+	appPaths, err := paths.GetPaths()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get paths: %w", err)
+	}
+	return k3s.NewBackupManager(k3s.BackupDir(appPaths), machine), nil
+}
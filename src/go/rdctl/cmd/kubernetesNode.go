@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/k3s"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/output"
+	"github.com/spf13/cobra"
+)
+
+var kubernetesNodeCmd = &cobra.Command{
+	Use:   "node",
+	Short: "Manage extra nodes of the Rancher Desktop cluster",
+	Long: `Extra nodes are K3s agents running as containers of the container engine in the
+VM, the same way k3d and kind run nodes. They join the Rancher Desktop cluster,
+so multi-node scheduling, pod disruption budgets, and rolling updates can be
+tested locally.
+
+All nodes share the CPUs and memory of the VM; extra nodes don't add capacity.
+Pod networking across nodes needs flannel, which is enabled by default.`,
+}
+
+var kubernetesNodeAddSettings struct {
+	Timeout time.Duration
+}
+
+var kubernetesNodeAddCmd = &cobra.Command{
+	Use:   "add [name]",
+	Short: "Add a node to the cluster",
+	Long: `Adds an agent node running the same K3s version as the cluster, and waits for
+it to be ready. Without a name, the first unused name of the form agent-N is used.`,
+	Example: `  rdctl kubernetes node add
+  kubectl get nodes`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) > 0 {
+			if err := k3s.ValidateNodeName(args[0]); err != nil {
+				return err
+			}
+		}
+		cmd.SilenceUsage = true
+		machine, err := newKubernetesVM()
+		if err != nil {
+			return err
+		}
+		var name string
+		if len(args) > 0 {
+			name = args[0]
+		} else {
+			nodes, err := k3s.ListAgents(machine)
+			if err != nil {
+				return err
+			}
+			name = k3s.NextAgentName(nodes)
+		}
+		progress := output.StartProgress(fmt.Sprintf("Adding node %q", name))
+		err = k3s.AddAgent(machine, name)
+		if err == nil && kubernetesNodeAddSettings.Timeout > 0 {
+			err = k3s.WaitForNode(machine, name, kubernetesNodeAddSettings.Timeout)
+		}
+		progress.Stop()
+		if err != nil {
+			return err
+		}
+		output.Infof("Node %q added.", name)
+		return nil
+	},
+}
+
+var kubernetesNodeRemoveCmd = &cobra.Command{
+	Use:     "remove <name>...",
+	Aliases: []string{"rm"},
+	Short:   "Remove nodes added with 'rdctl kubernetes node add'",
+	Long: `Drains the nodes, removes them from the cluster, and deletes their containers.
+The node running the K3s server can't be removed.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		machine, err := newKubernetesVM()
+		if err != nil {
+			return err
+		}
+		for _, name := range args {
+			progress := output.StartProgress(fmt.Sprintf("Removing node %q", name))
+			err := k3s.RemoveAgent(machine, name)
+			progress.Stop()
+			if err != nil {
+				return err
+			}
+			output.Infof("Node %q removed.", name)
+		}
+		return nil
+	},
+}
+
+var kubernetesNodeListOutputFormat string
+
+var kubernetesNodeListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List nodes added with 'rdctl kubernetes node add'",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		formatter, err := output.NewFormatter(kubernetesNodeListOutputFormat, tableFormat, output.JSON)
+		if err != nil {
+			return err
+		}
+		cmd.SilenceUsage = true
+		machine, err := newKubernetesVM()
+		if err != nil {
+			return err
+		}
+		nodes, err := k3s.ListAgents(machine)
+		if err != nil {
+			return err
+		}
+		if formatter.Format != tableFormat {
+			return formatter.Write(os.Stdout, nodes)
+		}
+		if len(nodes) == 0 {
+			output.Infof("No extra nodes present.")
+			return nil
+		}
+		writer := tabwriter.NewWriter(os.Stdout, 0, 4, 4, ' ', 0)
+		fmt.Fprintf(writer, "NAME\tSTATUS\n")
+		for _, node := range nodes {
+			fmt.Fprintf(writer, "%s\t%s\n", node.Name, node.Status)
+		}
+		return writer.Flush()
+	},
+}
+
+func init() {
+	kubernetesCmd.AddCommand(kubernetesNodeCmd)
+	kubernetesNodeCmd.AddCommand(kubernetesNodeAddCmd)
+	kubernetesNodeCmd.AddCommand(kubernetesNodeRemoveCmd)
+	kubernetesNodeCmd.AddCommand(kubernetesNodeListCmd)
+	markReadOnly(kubernetesNodeListCmd)
+	kubernetesNodeAddCmd.Flags().DurationVar(&kubernetesNodeAddSettings.Timeout, "timeout", 3*time.Minute, "how long to wait for the node to be ready; 0 to not wait")
+	output.AddFlag(kubernetesNodeListCmd.Flags(), &kubernetesNodeListOutputFormat, tableFormat, output.JSON)
+}
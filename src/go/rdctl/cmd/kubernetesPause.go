@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/k3s"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/output"
+	"github.com/spf13/cobra"
+)
+
+var kubernetesPauseCmd = &cobra.Command{
+	Use:   "pause",
+	Short: "Stop Kubernetes and its workloads, keeping the cluster state",
+	Long: `Stops K3s and the containers of all pods to free the CPU and memory they use,
+while the container engine keeps running. The cluster state is kept, so
+'rdctl kubernetes resume' brings back all workloads; pods are restarted, so
+data that isn't stored in volumes is lost.
+
+Changing settings that restart Kubernetes, or restarting Rancher Desktop,
+also resumes the cluster.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		machine, err := newKubernetesVM()
+		if err != nil {
+			return err
+		}
+		progress := output.StartProgress("Pausing Kubernetes")
+		err = k3s.Pause(machine)
+		progress.Stop()
+		if err != nil {
+			return err
+		}
+		output.Infof("Kubernetes is paused; run 'rdctl kubernetes resume' to resume it.")
+		return nil
+	},
+}
+
+var kubernetesResumeCmd = &cobra.Command{
+	Use:   "resume",
+	Short: "Start Kubernetes again after 'rdctl kubernetes pause'",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		machine, err := newKubernetesVM()
+		if err != nil {
+			return err
+		}
+		if err := k3s.Resume(machine); err != nil {
+			return err
+		}
+		output.Infof("Kubernetes is starting; workloads will be restarted shortly.")
+		return nil
+	},
+}
+
+func init() {
+	kubernetesCmd.AddCommand(kubernetesPauseCmd)
+	kubernetesCmd.AddCommand(kubernetesResumeCmd)
+}
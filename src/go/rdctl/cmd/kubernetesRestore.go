@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/output"
+	"github.com/spf13/cobra"
+)
+
+var kubernetesRestoreSettings struct {
+	Force bool
+}
+
+var kubernetesRestoreCmd = &cobra.Command{
+	Use:   "restore <name>",
+	Short: "Restore the Kubernetes datastore from a backup",
+	Long: `Replaces the state of the Kubernetes cluster with a backup made by
+'rdctl kubernetes backup create'. Resources created after the backup was taken
+are removed, and their workloads stopped.
+
+The backup must have been taken with the Kubernetes version that is currently
+installed, unless --force is given.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		machine, err := newKubernetesVM()
+		if err != nil {
+			return err
+		}
+		manager, err := newBackupManager(machine)
+		if err != nil {
+			return err
+		}
+		progress := output.StartProgress(fmt.Sprintf("Restoring Kubernetes from %q", args[0]))
+		err = manager.Restore(args[0], kubernetesRestoreSettings.Force)
+		progress.Stop()
+		if err != nil {
+			return err
+		}
+		output.Infof("Restored backup %q; Kubernetes is starting.", args[0])
+		return nil
+	},
+}
+
+func init() {
+	kubernetesCmd.AddCommand(kubernetesRestoreCmd)
+	kubernetesRestoreCmd.Flags().BoolVar(&kubernetesRestoreSettings.Force, "force", false, "restore a backup taken with a different Kubernetes version")
+}
@@ -0,0 +1,21 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var kubernetesVclusterCmd = &cobra.Command{
+	Use:   "vcluster",
+	Short: "Manage virtual clusters running other Kubernetes versions",
+	Long: `Virtual clusters are additional, lightweight K3s clusters that run as workloads
+inside the Rancher Desktop cluster. Each one runs its own Kubernetes version and
+gets its own kubeconfig context (vcluster-NAME), so compatibility with other
+versions can be tested without resetting the main cluster.
+
+Virtual clusters need Kubernetes to be enabled and running, and are removed when
+Kubernetes is reset.`,
+}
+
+func init() {
+	kubernetesCmd.AddCommand(kubernetesVclusterCmd)
+}
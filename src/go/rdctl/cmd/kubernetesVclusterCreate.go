@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/output"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/vcluster"
+	"github.com/spf13/cobra"
+)
+
+var kubernetesVclusterCreateSettings struct {
+	Version string
+	Timeout time.Duration
+}
+
+var kubernetesVclusterCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Create a virtual cluster",
+	Long: `Creates a virtual cluster running the given Kubernetes version, waits for it to
+be ready, and adds the context vcluster-<name> to the kubeconfig.
+
+The version can be a full version (1.27.4), a minor version (1.27), which
+selects its latest patch release, or a release channel such as "stable".`,
+	Example: `  rdctl kubernetes vcluster create --version 1.27 old
+  kubectl --context vcluster-old get nodes`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := vcluster.ValidateName(args[0]); err != nil {
+			return err
+		}
+		cmd.SilenceUsage = true
+		runner, appPaths, err := newKubectl()
+		if err != nil {
+			return err
+		}
+		version, err := vcluster.ResolveVersion(appPaths.Cache, kubernetesVclusterCreateSettings.Version)
+		if err != nil {
+			return err
+		}
+		manager := vcluster.NewManager(runner)
+		manager.Timeout = kubernetesVclusterCreateSettings.Timeout
+		progress := output.StartProgress(fmt.Sprintf("Creating virtual cluster %q with Kubernetes %s", args[0], version))
+		result, err := manager.Create(args[0], version)
+		progress.Stop()
+		if err != nil {
+			return err
+		}
+		output.Infof("Virtual cluster %q is ready; use it with: kubectl --context %s", result.Name, result.Context)
+		return nil
+	},
+}
+
+func init() {
+	kubernetesVclusterCmd.AddCommand(kubernetesVclusterCreateCmd)
+	kubernetesVclusterCreateCmd.Flags().StringVar(&kubernetesVclusterCreateSettings.Version, "version", "stable", "Kubernetes version to run")
+	kubernetesVclusterCreateCmd.Flags().DurationVar(&kubernetesVclusterCreateSettings.Timeout, "timeout", 5*time.Minute, "how long to wait for the virtual cluster to be ready")
+}
@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/vcluster"
+	"github.com/spf13/cobra"
+)
+
+var kubernetesVclusterDeleteCmd = &cobra.Command{
+	Use:   "delete <name>",
+	Short: "Delete a virtual cluster and its kubeconfig context",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		runner, _, err := newKubectl()
+		if err != nil {
+			return err
+		}
+		if err := vcluster.NewManager(runner).Delete(args[0]); err != nil {
+			return fmt.Errorf("failed to delete virtual cluster %q: %w", args[0], err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	kubernetesVclusterCmd.AddCommand(kubernetesVclusterDeleteCmd)
+}
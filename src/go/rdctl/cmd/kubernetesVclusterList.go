@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/output"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/vcluster"
+	"github.com/spf13/cobra"
+)
+
+var kubernetesVclusterListOutputFormat string
+
+var kubernetesVclusterListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List virtual clusters",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		formatter, err := output.NewFormatter(kubernetesVclusterListOutputFormat, tableFormat, output.JSON)
+		if err != nil {
+			return err
+		}
+		cmd.SilenceUsage = true
+		runner, _, err := newKubectl()
+		if err != nil {
+			return err
+		}
+		vclusters, err := vcluster.NewManager(runner).List()
+		if err != nil {
+			return err
+		}
+		if formatter.Format != tableFormat {
+			return formatter.Write(os.Stdout, vclusters)
+		}
+		if len(vclusters) == 0 {
+			output.Infof("No virtual clusters present.")
+			return nil
+		}
+		writer := tabwriter.NewWriter(os.Stdout, 0, 4, 4, ' ', 0)
+		fmt.Fprintf(writer, "NAME\tVERSION\tREADY\tCONTEXT\n")
+		for _, vc := range vclusters {
+			fmt.Fprintf(writer, "%s\t%s\t%t\t%s\n", vc.Name, vc.Version, vc.Ready, vc.Context)
+		}
+		return writer.Flush()
+	},
+}
+
+func init() {
+	kubernetesVclusterCmd.AddCommand(kubernetesVclusterListCmd)
+	markReadOnly(kubernetesVclusterListCmd)
+	output.AddFlag(kubernetesVclusterListCmd.Flags(), &kubernetesVclusterListOutputFormat, tableFormat, output.JSON)
+}
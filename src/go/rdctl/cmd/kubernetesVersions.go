@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/apicache"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/config"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/output"
+	"github.com/spf13/cobra"
+)
+
+var kubernetesVersionsOutputFormat string
+
+// kubernetesVersion is an entry of the list of Kubernetes versions.
+type kubernetesVersion struct {
+	Version  string   `json:"version"`
+	Channels []string `json:"channels"`
+}
+
+var kubernetesVersionsCmd = &cobra.Command{
+	Use:   "versions",
+	Short: "List the Kubernetes versions that can be selected",
+	Long: `List the Kubernetes versions that can be selected with
+'rdctl set --kubernetes.version', with the release channels that include them.
+The list rarely changes, so it is cached on disk.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		formatter, err := output.NewFormatter(kubernetesVersionsOutputFormat, tableFormat, output.JSON)
+		if err != nil {
+			return err
+		}
+		cmd.SilenceUsage = true
+		connectionInfo, err := config.GetConnectionInfo(false)
+		if err != nil {
+			return fmt.Errorf("failed to get connection info: %w", err)
+		}
+		cache, err := newAPICache(connectionInfo)
+		if err != nil {
+			return err
+		}
+		body, err := cache.Get(apicache.KubernetesVersions)
+		if err != nil {
+			return err
+		}
+		var versions []kubernetesVersion
+		if err := json.Unmarshal(body, &versions); err != nil {
+			return fmt.Errorf("failed to unmarshal Kubernetes versions: %w", err)
+		}
+		if formatter.Format != tableFormat {
+			return formatter.Write(os.Stdout, versions)
+		}
+		writer := tabwriter.NewWriter(os.Stdout, 0, 4, 4, ' ', 0)
+		fmt.Fprintf(writer, "VERSION\tCHANNELS\n")
+		for _, version := range versions {
+			fmt.Fprintf(writer, "%s\t%s\n", version.Version, strings.Join(version.Channels, ","))
+		}
+		return writer.Flush()
+	},
+}
+
+func init() {
+	kubernetesCmd.AddCommand(kubernetesVersionsCmd)
+	markReadOnly(kubernetesVersionsCmd)
+	output.AddFlag(kubernetesVersionsCmd.Flags(), &kubernetesVersionsOutputFormat, tableFormat, output.JSON)
+}
@@ -18,40 +18,67 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+
 	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/client"
 	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/config"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/output"
 	"github.com/spf13/cobra"
 )
 
+var listSettingsOutputFormat string
+var listSettingsShowSecrets bool
+
 // listSettingsCmd represents the listSettings command
 var listSettingsCmd = &cobra.Command{
 	Use:   "list-settings",
 	Short: "Lists the current settings.",
-	Long:  `Lists the current settings in JSON format.`,
+	Long: `Lists the current settings in JSON format. Secrets, such as the proxy password,
+are redacted unless --show-secrets is given.
+
+Use --output jsonpath=TEMPLATE or --output go-template=TEMPLATE to extract
+individual fields, e.g. --output jsonpath='{.kubernetes.version}'.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if err := cobra.NoArgs(cmd, args); err != nil {
 			return err
 		}
+		formatter, err := output.NewFormatter(listSettingsOutputFormat, output.JSON)
+		if err != nil {
+			return err
+		}
 		cmd.SilenceUsage = true
-		result, err := getListSettings()
+		if listSettingsShowSecrets && readOnlyMode() {
+			return readOnlyError("--show-secrets")
+		}
+		result, err := getListSettings(listSettingsShowSecrets)
 		if err != nil {
 			return err
 		}
-		fmt.Println(string(result))
-		return nil
+		if !formatter.IsTemplate() {
+			fmt.Println(string(result))
+			return nil
+		}
+		return formatter.Write(os.Stdout, result)
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(listSettingsCmd)
+	markReadOnly(listSettingsCmd)
+	output.AddFlag(listSettingsCmd.Flags(), &listSettingsOutputFormat, output.JSON)
+	listSettingsCmd.Flags().BoolVar(&listSettingsShowSecrets, "show-secrets", false, "show secrets instead of redacting them")
 }
 
-func getListSettings() ([]byte, error) {
+func getListSettings(showSecrets bool) ([]byte, error) {
 	connectionInfo, err := config.GetConnectionInfo(false)
 	if err != nil {
 		return []byte{}, fmt.Errorf("failed to get connection info: %w", err)
 	}
 	rdClient := client.NewRDClient(connectionInfo)
-	response, err := rdClient.DoRequest("GET", client.VersionCommand("", "settings"))
+	command := client.VersionCommand("", "settings")
+	if showSecrets {
+		command += "?" + client.ShowSecretsQuery
+	}
+	response, err := rdClient.DoRequest("GET", command)
 	return client.ProcessRequestForUtility(response, err)
 }
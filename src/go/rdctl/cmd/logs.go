@@ -0,0 +1,190 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"time"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/exitcode"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/logs"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/output"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+	"github.com/spf13/cobra"
+)
+
+// logsFollowInterval is how often --follow checks the files for new lines.
+const logsFollowInterval = 500 * time.Millisecond
+
+var logsSettings struct {
+	Follow bool
+	Since  string
+	Tail   int
+	All    bool
+	Zip    string
+}
+
+var logsCmd = &cobra.Command{
+	Use:   "logs [COMPONENT]",
+	Short: "Print the logs of Rancher Desktop",
+	Long: `Print the logs of a component of Rancher Desktop, from the logs directory of
+the application. The components are:
+
+  main   the main process of the application
+  k3s    Kubernetes
+  lima   the VM, on macOS and Linux
+  wsl    the WSL distributions, on Windows
+
+and the topics of the other log files, such as "images" for images.log.
+Without a component, list the components with logs.
+
+With --all, print the logs of all the components; with --all --zip FILE,
+write all the files of the logs directory to a zip archive instead, to attach
+to bug reports.`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeLogComponents,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		since, err := parseLogsSince(logsSettings.Since, time.Now())
+		if err != nil {
+			return exitcode.WithCode(err, exitcode.InvalidInput)
+		}
+		if err := validateLogsSettings(cmd, args); err != nil {
+			return exitcode.WithCode(err, exitcode.InvalidInput)
+		}
+		cmd.SilenceUsage = true
+		appPaths, err := paths.GetPaths()
+		if err != nil {
+			return fmt.Errorf("failed to get paths: %w", err)
+		}
+		if logsSettings.Zip != "" {
+			return zipLogs(logsSettings.Zip, appPaths.Logs)
+		}
+		if len(args) == 0 && !logsSettings.All {
+			components, err := logs.Components(appPaths.Logs)
+			if err != nil {
+				return err
+			}
+			for _, component := range components {
+				fmt.Fprintln(cmd.OutOrStdout(), component)
+			}
+			return nil
+		}
+		files, err := logFiles(appPaths.Logs, args)
+		if err != nil {
+			return err
+		}
+		offsets, err := writeLogs(cmd.OutOrStdout(), files, logs.Options{Since: since, Tail: logsSettings.Tail})
+		if err != nil || !logsSettings.Follow {
+			return err
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		return logs.Follow(ctx, cmd.OutOrStdout(), offsets, logsFollowInterval)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(logsCmd)
+	markReadOnly(logsCmd)
+	logsCmd.Flags().BoolVarP(&logsSettings.Follow, "follow", "f", false, "keep printing the lines written to the logs")
+	logsCmd.Flags().StringVar(&logsSettings.Since, "since", "", "only print the lines since a time (e.g. 2024-01-02T15:04:05Z) or for a duration (e.g. 10m)")
+	logsCmd.Flags().IntVar(&logsSettings.Tail, "tail", -1, "only print the last lines of each file (-1 for all)")
+	logsCmd.Flags().BoolVar(&logsSettings.All, "all", false, "print the logs of all the components")
+	logsCmd.Flags().StringVar(&logsSettings.Zip, "zip", "", "with --all, write all the logs to this zip archive instead")
+}
+
+func validateLogsSettings(cmd *cobra.Command, args []string) error {
+	switch {
+	case logsSettings.All && len(args) > 0:
+		return errors.New("--all can't be used with a component")
+	case logsSettings.Zip != "" && !logsSettings.All:
+		return errors.New("--zip can only be used with --all")
+	case logsSettings.Zip != "" && (logsSettings.Follow || cmd.Flags().Changed("since") || cmd.Flags().Changed("tail")):
+		return errors.New("--zip can't be used with --follow, --since, or --tail")
+	case len(args) == 0 && !logsSettings.All && (logsSettings.Follow || cmd.Flags().Changed("since") || cmd.Flags().Changed("tail")):
+		return errors.New("--follow, --since, and --tail need a component or --all")
+	}
+	return nil
+}
+
+// parseLogsSince parses the value of --since, which is either a time or a
+// duration before now.
+func parseLogsSince(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if duration, err := time.ParseDuration(value); err == nil {
+		if duration < 0 {
+			return time.Time{}, fmt.Errorf("invalid --since %q: the duration must not be negative", value)
+		}
+		return now.Add(-duration), nil
+	}
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid --since %q: must be a time such as 2024-01-02T15:04:05Z or a duration such as 10m", value)
+}
+
+// logFiles returns the log files of the component given as argument, or of
+// all the components for --all.
+func logFiles(logsDir string, args []string) ([]string, error) {
+	if len(args) > 0 {
+		files, err := logs.Files(logsDir, args[0])
+		if errors.Is(err, logs.ErrUnknownComponent) {
+			return nil, exitcode.WithCode(err, exitcode.InvalidInput)
+		}
+		return files, err
+	}
+	files, err := filepath.Glob(filepath.Join(logsDir, "*.log"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("there are no logs in %s", logsDir)
+	}
+	return files, nil
+}
+
+// writeLogs writes the selected lines of the files, under a header naming
+// each file if there is more than one, and returns the offsets to follow the
+// files from.
+func writeLogs(w io.Writer, files []string, options logs.Options) (map[string]int64, error) {
+	offsets := make(map[string]int64, len(files))
+	for i, file := range files {
+		if len(files) > 1 {
+			if i > 0 {
+				fmt.Fprintln(w)
+			}
+			fmt.Fprintf(w, "==> %s <==\n", file)
+		}
+		offset, err := logs.Write(w, file, options)
+		if err != nil {
+			return nil, err
+		}
+		offsets[file] = offset
+	}
+	return offsets, nil
+}
+
+func zipLogs(zipPath, logsDir string) error {
+	f, err := os.Create(zipPath)
+	if err != nil {
+		return err
+	}
+	if err := logs.WriteZip(f, logsDir); err != nil {
+		f.Close()
+		_ = os.Remove(zipPath)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	output.Infof("Wrote the logs of %s to %s", logsDir, zipPath)
+	return nil
+}
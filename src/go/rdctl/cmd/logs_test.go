@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/logs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLogsSince(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	for value, expected := range map[string]time.Time{
+		"":                     {},
+		"10m":                  now.Add(-10 * time.Minute),
+		"2024-01-01T00:00:00Z": time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		"2024-01-01":           time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local),
+	} {
+		actual, err := parseLogsSince(value, now)
+		require.NoError(t, err, value)
+		assert.True(t, expected.Equal(actual), "%s: %s", value, actual)
+	}
+	for _, value := range []string{"-10m", "yesterday"} {
+		_, err := parseLogsSince(value, now)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestWriteLogs(t *testing.T) {
+	dir := t.TempDir()
+	main, k3s := filepath.Join(dir, "background.log"), filepath.Join(dir, "k3s.log")
+	require.NoError(t, os.WriteFile(main, []byte("one\ntwo\n"), 0o644))
+	require.NoError(t, os.WriteFile(k3s, []byte("three\n"), 0o644))
+
+	var output bytes.Buffer
+	offsets, err := writeLogs(&output, []string{main, k3s}, logs.Options{Tail: 1})
+	require.NoError(t, err)
+	assert.Equal(t, "==> "+main+" <==\ntwo\n\n==> "+k3s+" <==\nthree\n", output.String())
+	assert.Equal(t, map[string]int64{main: 8, k3s: 6}, offsets)
+
+	output.Reset()
+	_, err = writeLogs(&output, []string{main}, logs.Options{Tail: -1})
+	require.NoError(t, err)
+	assert.Equal(t, "one\ntwo\n", output.String())
+}
@@ -28,4 +28,5 @@ var pathsCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(pathsCmd)
+	markReadOnly(pathsCmd)
 }
@@ -0,0 +1,321 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/client"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/config"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/options/generated"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/output"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/portforward"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/vm"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/vmdisk"
+	"github.com/spf13/cobra"
+)
+
+var portForwardSettings struct {
+	Reverse bool
+	UDP     bool
+	Delete  bool
+	DryRun  bool
+	Output  string
+}
+
+// reversePortForward is an entry of the reversePortForwards setting.
+type reversePortForward struct {
+	GuestPort int `json:"guestPort"`
+	HostPort  int `json:"hostPort"`
+}
+
+var portForwardCmd = &cobra.Command{
+	Use:   "port-forward VM_PORT[:HOST_PORT] | --reverse [[GUEST_PORT:]HOST_PORT...]",
+	Short: "Forward ports between the host and the VM",
+	Long: `Forward a port of the host to a port of the VM, to reach services listening only
+inside the VM, such as on its loopback address, while debugging them. The port
+of the host, VM_PORT unless HOST_PORT is given, listens on 127.0.0.1 until
+Ctrl-C is pressed; with --udp, UDP is forwarded instead of TCP. Each connection,
+or each UDP peer, is relayed by nc in the VM.
+
+With --reverse, make ports of the host available in the VM and to containers,
+so that they can reach services on the host, such as a debugger or a database,
+on a stable port.
+
+Without ports, list the ports that are forwarded. With ports, forward each one
+(GUEST_PORT:HOST_PORT, or PORT if both are the same); with --delete, stop
+forwarding the given guest ports.
+
+The ports are stored in the experimental.virtualMachine.reversePortForwards
+setting; changing them restarts the backend. With --dry-run, the change to the
+setting is shown without applying it.`,
+	Example: `  rdctl port-forward 8080
+  rdctl port-forward 53:5353 --udp
+  rdctl port-forward --reverse 5432
+  rdctl port-forward --reverse 9229:9230
+  rdctl port-forward --reverse --delete 9229`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !portForwardSettings.Reverse {
+			return runPortForward(cmd, args)
+		}
+		if portForwardSettings.UDP {
+			return errors.New("--udp is not supported with --reverse")
+		}
+		formatter, err := output.NewFormatter(portForwardSettings.Output, tableFormat, output.JSON)
+		if err != nil {
+			return err
+		}
+		if portForwardSettings.Delete && len(args) == 0 {
+			return errors.New("--delete requires the guest ports to stop forwarding")
+		}
+		forwards := make([]reversePortForward, 0, len(args))
+		for _, arg := range args {
+			forward, err := parseReversePortForward(arg)
+			if err != nil {
+				return err
+			}
+			forwards = append(forwards, forward)
+		}
+		cmd.SilenceUsage = true
+		connectionInfo, err := config.GetConnectionInfo(false)
+		if err != nil {
+			return fmt.Errorf("failed to get connection info: %w", err)
+		}
+		rdClient := client.NewRDClient(connectionInfo)
+		current, err := getReversePortForwards(rdClient)
+		if err != nil {
+			return err
+		}
+		if len(args) == 0 {
+			return listReversePortForwards(formatter, current)
+		}
+		if !portForwardSettings.DryRun && readOnlyMode() {
+			return readOnlyError("changing forwarded ports")
+		}
+		var updated []reversePortForward
+		if portForwardSettings.Delete {
+			updated = deleteReversePortForwards(current, forwards)
+		} else {
+			updated = addReversePortForwards(current, forwards)
+		}
+		jsonBuffer, err := reversePortForwardsPayload(updated)
+		if err != nil {
+			return err
+		}
+		if portForwardSettings.DryRun {
+			preview, err := previewSettings(rdClient, jsonBuffer, false)
+			if err != nil {
+				return err
+			}
+			return writeSettingsPreview(os.Stdout, formatter, preview)
+		}
+		return setReversePortForwards(rdClient, jsonBuffer)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(portForwardCmd)
+	portForwardCmd.Flags().BoolVar(&portForwardSettings.Reverse, "reverse", false, "forward ports of the host to the VM")
+	portForwardCmd.Flags().BoolVar(&portForwardSettings.UDP, "udp", false, "forward a UDP port instead of a TCP one")
+	portForwardCmd.Flags().BoolVar(&portForwardSettings.Delete, "delete", false, "stop forwarding the given guest ports")
+	portForwardCmd.Flags().BoolVar(&portForwardSettings.DryRun, "dry-run", false, "show the change to the settings, without applying it")
+	output.AddFlag(portForwardCmd.Flags(), &portForwardSettings.Output, tableFormat, output.JSON)
+	// Listing the ports is allowed; changing them is checked when running.
+	markReadOnly(portForwardCmd)
+}
+
+// runPortForward forwards a port of the host to a port of the VM until
+// interrupted.
+func runPortForward(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return errors.New("expected one VM_PORT[:HOST_PORT] to forward")
+	}
+	for _, flag := range []string{"delete", "dry-run", "output"} {
+		if cmd.Flags().Changed(flag) {
+			return fmt.Errorf("--%s requires --reverse", flag)
+		}
+	}
+	// The syntax is that of the reverse forwards, with the port of the VM first.
+	forward, err := parseReversePortForward(args[0])
+	if err != nil {
+		return err
+	}
+	cmd.SilenceUsage = true
+	running, err := vmdisk.Running()
+	if err != nil {
+		return err
+	}
+	if !running {
+		return errors.New("the VM is not running")
+	}
+	appPaths, err := paths.GetPaths()
+	if err != nil {
+		return fmt.Errorf("failed to get paths: %w", err)
+	}
+	runner, err := vm.New(appPaths)
+	if err != nil {
+		return err
+	}
+	forwarder := portforward.Forwarder{
+		VM:        runner,
+		GuestPort: forward.GuestPort,
+		Logf: func(format string, args ...any) {
+			fmt.Fprintf(os.Stderr, format+"\n", args...)
+		},
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	address := net.JoinHostPort("127.0.0.1", strconv.Itoa(forward.HostPort))
+	if portForwardSettings.UDP {
+		conn, err := net.ListenPacket("udp", address)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Forwarding UDP from %s to port %d of the VM; press Ctrl-C to stop.\n", address, forward.GuestPort)
+		return forwarder.ServeUDP(ctx, conn)
+	}
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Forwarding from %s to port %d of the VM; press Ctrl-C to stop.\n", address, forward.GuestPort)
+	return forwarder.ServeTCP(ctx, listener)
+}
+
+// parseReversePortForward parses GUEST_PORT:HOST_PORT or PORT.
+func parseReversePortForward(value string) (reversePortForward, error) {
+	guest, host, found := strings.Cut(value, ":")
+	if !found {
+		host = guest
+	}
+	guestPort, err := parsePort(guest)
+	if err != nil {
+		return reversePortForward{}, fmt.Errorf("invalid port forward %q: %w", value, err)
+	}
+	hostPort, err := parsePort(host)
+	if err != nil {
+		return reversePortForward{}, fmt.Errorf("invalid port forward %q: %w", value, err)
+	}
+	return reversePortForward{GuestPort: guestPort, HostPort: hostPort}, nil
+}
+
+func parsePort(value string) (int, error) {
+	port, err := strconv.Atoi(value)
+	if err != nil || port < 1 || port > 65535 {
+		return 0, fmt.Errorf("%q is not a port number", value)
+	}
+	return port, nil
+}
+
+func (f reversePortForward) String() string {
+	if f.GuestPort == f.HostPort {
+		return strconv.Itoa(f.GuestPort)
+	}
+	return fmt.Sprintf("%d:%d", f.GuestPort, f.HostPort)
+}
+
+// addReversePortForwards returns the forwards with the additions; an addition
+// replaces any forward of the same guest port.
+func addReversePortForwards(forwards, additions []reversePortForward) []reversePortForward {
+	result := forwards
+	for _, addition := range additions {
+		result = append(deleteReversePortForwards(result, []reversePortForward{addition}), addition)
+	}
+	return result
+}
+
+// deleteReversePortForwards returns the forwards, without those of the guest
+// ports of the deletions.
+func deleteReversePortForwards(forwards, deletions []reversePortForward) []reversePortForward {
+	result := []reversePortForward{}
+	for _, forward := range forwards {
+		deleted := false
+		for _, deletion := range deletions {
+			deleted = deleted || forward.GuestPort == deletion.GuestPort
+		}
+		if !deleted {
+			result = append(result, forward)
+		}
+	}
+	return result
+}
+
+func getReversePortForwards(rdClient client.RDClient) ([]reversePortForward, error) {
+	body, err := client.ProcessRequestForUtility(rdClient.DoRequest("GET", client.VersionCommand("", "settings")))
+	if err != nil {
+		return nil, err
+	}
+	var settings struct {
+		Experimental struct {
+			VirtualMachine struct {
+				ReversePortForwards []string `json:"reversePortForwards"`
+			} `json:"virtualMachine"`
+		} `json:"experimental"`
+	}
+	if err := json.Unmarshal(body, &settings); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal settings: %w", err)
+	}
+	forwards := []reversePortForward{}
+	for _, value := range settings.Experimental.VirtualMachine.ReversePortForwards {
+		forward, err := parseReversePortForward(value)
+		if err != nil {
+			return nil, err
+		}
+		forwards = append(forwards, forward)
+	}
+	return forwards, nil
+}
+
+// reversePortForwardsPayload returns the partial settings document setting
+// the forwards.
+func reversePortForwardsPayload(forwards []reversePortForward) ([]byte, error) {
+	values := make([]string, 0, len(forwards))
+	for _, forward := range forwards {
+		values = append(values, forward.String())
+	}
+	payload := map[string]any{
+		"version": options.CURRENT_SETTINGS_VERSION,
+		"experimental": map[string]any{
+			"virtualMachine": map[string]any{"reversePortForwards": values},
+		},
+	}
+	return json.Marshal(payload)
+}
+
+func setReversePortForwards(rdClient client.RDClient, jsonBuffer []byte) error {
+	response, err := rdClient.DoRequestWithPayload("PUT", client.VersionCommand("", "settings"), bytes.NewBuffer(jsonBuffer))
+	result, err := client.ProcessRequestForUtility(response, err)
+	if err != nil {
+		return err
+	}
+	invalidateCachedSettings()
+	if len(result) > 0 {
+		fmt.Printf("Status: %s.\n", string(result))
+	}
+	return nil
+}
+
+func listReversePortForwards(formatter *output.Formatter, forwards []reversePortForward) error {
+	if formatter.Format != tableFormat {
+		return formatter.Write(os.Stdout, forwards)
+	}
+	if len(forwards) == 0 {
+		output.Infof("No ports are forwarded.")
+		return nil
+	}
+	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 4, ' ', 0)
+	fmt.Fprintf(writer, "GUEST PORT\tHOST PORT\n")
+	for _, forward := range forwards {
+		fmt.Fprintf(writer, "%d\t%d\n", forward.GuestPort, forward.HostPort)
+	}
+	return writer.Flush()
+}
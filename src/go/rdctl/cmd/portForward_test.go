@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseReversePortForward(t *testing.T) {
+	testCases := map[string]reversePortForward{
+		"5432":      {GuestPort: 5432, HostPort: 5432},
+		"9229:9230": {GuestPort: 9229, HostPort: 9230},
+	}
+	for input, expected := range testCases {
+		t.Run(input, func(t *testing.T) {
+			actual, err := parseReversePortForward(input)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, actual)
+			assert.Equal(t, input, actual.String())
+		})
+	}
+	for _, input := range []string{"", "0", "65536", "db", "1:", ":1", "1:2:3"} {
+		t.Run(input, func(t *testing.T) {
+			_, err := parseReversePortForward(input)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestUpdateReversePortForwards(t *testing.T) {
+	current := []reversePortForward{{GuestPort: 5432, HostPort: 5432}, {GuestPort: 9229, HostPort: 9229}}
+
+	t.Run("add replaces forwards of the same guest port", func(t *testing.T) {
+		actual := addReversePortForwards(current, []reversePortForward{{GuestPort: 9229, HostPort: 9230}, {GuestPort: 6379, HostPort: 6379}})
+		assert.Equal(t, []reversePortForward{
+			{GuestPort: 5432, HostPort: 5432},
+			{GuestPort: 9229, HostPort: 9230},
+			{GuestPort: 6379, HostPort: 6379},
+		}, actual)
+	})
+	t.Run("delete matches guest ports", func(t *testing.T) {
+		actual := deleteReversePortForwards(current, []reversePortForward{{GuestPort: 5432, HostPort: 1}})
+		assert.Equal(t, []reversePortForward{{GuestPort: 9229, HostPort: 9229}}, actual)
+	})
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/output"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/privilegedservice"
+	"github.com/spf13/cobra"
+)
+
+const privilegedServiceTextFormat = "text"
+
+var privilegedServiceStatusOutput string
+
+var privilegedServiceCmd = &cobra.Command{
+	Use:   "privileged-service",
+	Short: "Manage the Rancher Desktop Privileged Service",
+	Long: `Manage the Rancher Desktop Privileged Service, which performs actions requiring
+administrative privileges (such as forwarding ports on all interfaces) on behalf
+of Rancher Desktop.
+
+These commands are useful to repair a broken installation, or for unattended
+deployments. The install and uninstall commands must be run as administrator.`,
+}
+
+var privilegedServiceInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Install and start the privileged service (requires administrator)",
+	Long: `Install the privileged service shipped with this Rancher Desktop installation,
+replacing any existing registration, and start it.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		appPaths, err := paths.GetPaths()
+		if err != nil {
+			return fmt.Errorf("failed to get paths: %w", err)
+		}
+		if err := privilegedservice.Install(appPaths); err != nil {
+			return err
+		}
+		output.Infof("The privileged service has been installed.")
+		return nil
+	},
+}
+
+var privilegedServiceUninstallCmd = &cobra.Command{
+	Use:   "uninstall",
+	Short: "Stop and uninstall the privileged service (requires administrator)",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		appPaths, err := paths.GetPaths()
+		if err != nil {
+			return fmt.Errorf("failed to get paths: %w", err)
+		}
+		if err := privilegedservice.Uninstall(appPaths); err != nil {
+			return err
+		}
+		output.Infof("The privileged service has been uninstalled.")
+		return nil
+	},
+}
+
+var privilegedServiceStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show whether the privileged service is installed and running",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		formatter, err := output.NewFormatter(privilegedServiceStatusOutput, privilegedServiceTextFormat, output.JSON)
+		if err != nil {
+			return err
+		}
+		cmd.SilenceUsage = true
+		status, err := privilegedservice.GetStatus()
+		if err != nil {
+			return err
+		}
+		if formatter.Format != privilegedServiceTextFormat {
+			return formatter.Write(os.Stdout, status)
+		}
+		if !status.Installed {
+			fmt.Println("The privileged service is not installed.")
+			return nil
+		}
+		fmt.Printf("State:      %s\n", status.State)
+		fmt.Printf("Start type: %s\n", status.StartType)
+		fmt.Printf("Executable: %s\n", status.Executable)
+		return nil
+	},
+}
+
+func init() {
+	privilegedServiceCmd.AddCommand(privilegedServiceInstallCmd)
+	privilegedServiceCmd.AddCommand(privilegedServiceUninstallCmd)
+	privilegedServiceCmd.AddCommand(privilegedServiceStatusCmd)
+	markReadOnly(privilegedServiceStatusCmd)
+	output.AddFlag(privilegedServiceStatusCmd.Flags(), &privilegedServiceStatusOutput, privilegedServiceTextFormat, output.JSON)
+	rootCmd.AddCommand(privilegedServiceCmd)
+}
@@ -0,0 +1,16 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var profileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "Manage deployment profiles",
+	Long: `Manage deployment profiles, which administrators use to set the default and
+locked settings of Rancher Desktop; see also "rdctl create-profile".`,
+}
+
+func init() {
+	rootCmd.AddCommand(profileCmd)
+}
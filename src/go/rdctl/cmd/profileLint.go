@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"text/tabwriter"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/deploymentprofile"
+	options "github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/options/generated"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/output"
+	"github.com/spf13/cobra"
+)
+
+var profileLintSettings struct {
+	Output string
+}
+
+var profileLintCmd = &cobra.Command{
+	Use:   "lint FILE",
+	Short: "Check a deployment profile for invalid and unknown fields",
+	Long: `Check a deployment profile in JSON format, or in plist format on macOS, the way
+Rancher Desktop checks it when it starts.
+
+Fields with invalid values are errors: they are dropped from a defaults profile,
+and prevent Rancher Desktop from starting with a locked profile. Unknown fields
+are warnings, as they are ignored. The command fails if there are any errors.
+
+The running application reports the problems of the installed profiles in the
+DEPLOYMENT_PROFILES diagnostic.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		formatter, err := output.NewFormatter(profileLintSettings.Output, tableFormat, output.JSON)
+		if err != nil {
+			return err
+		}
+		cmd.SilenceUsage = true
+		profile, err := deploymentprofile.Read(args[0])
+		if err != nil {
+			return err
+		}
+		problems := deploymentprofile.Lint(profile, reflect.TypeOf(options.ServerSettingsForJSON{}))
+		if formatter.Format != tableFormat {
+			err = formatter.Write(os.Stdout, problems)
+		} else {
+			err = writeProfileProblems(problems)
+		}
+		if err != nil {
+			return err
+		}
+		errorCount := 0
+		for _, problem := range problems {
+			if problem.Severity == deploymentprofile.Error {
+				errorCount++
+			}
+		}
+		if errorCount > 0 {
+			return fmt.Errorf("%s: found %d errors", args[0], errorCount)
+		}
+		return nil
+	},
+}
+
+func init() {
+	profileCmd.AddCommand(profileLintCmd)
+	output.AddFlag(profileLintCmd.Flags(), &profileLintSettings.Output, tableFormat, output.JSON)
+	markReadOnly(profileLintCmd)
+}
+
+func writeProfileProblems(problems []deploymentprofile.Problem) error {
+	if len(problems) == 0 {
+		output.Infof("No problems found.")
+		return nil
+	}
+	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 4, ' ', 0)
+	fmt.Fprintf(writer, "SEVERITY\tFIELD\tMESSAGE\n")
+	for _, problem := range problems {
+		fmt.Fprintf(writer, "%s\t%s\t%s\n", problem.Severity, problem.Field, problem.Message)
+	}
+	return writer.Flush()
+}
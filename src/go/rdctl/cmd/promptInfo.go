@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/apicache"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/client"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/config"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/output"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/promptinfo"
+	"github.com/spf13/cobra"
+)
+
+const (
+	promptInfoShortFormat    = "short"
+	promptInfoKeyValueFormat = "kv"
+	// promptInfoTimeout limits the time to wait for the main process when the
+	// cached status is too old, so a busy application can't stall the prompt.
+	promptInfoTimeout = 250 * time.Millisecond
+)
+
+var promptInfoSettings struct {
+	Output string
+	MaxAge time.Duration
+}
+
+var promptInfoCmd = &cobra.Command{
+	Use:   "prompt-info",
+	Short: "Print a compact status line for shell prompts",
+	Long: `Prints the container engine, whether Kubernetes is enabled, and the current
+kubeconfig context in a compact form suitable for embedding in shell prompts.
+
+The status is served from a cache that is refreshed at most every --max-age,
+so this command returns quickly and never waits long for Rancher Desktop.
+Once Rancher Desktop has quit, it is reported as not running.
+
+Output formats:
+
+  short   ENGINE [k8s] [ctx:CONTEXT], e.g. "moby k8s ctx:rancher-desktop";
+          nothing is printed if Rancher Desktop is not running.
+  kv      A single line of KEY=VALUE pairs, always in this order:
+          running=true|false engine=NAME kubernetes=on|off context=NAME
+          Values never contain spaces.
+  json    {"running": BOOL, "engine": STRING, "kubernetes": BOOL, "context": STRING}
+
+The JSON fields can also be used with --output jsonpath=... or go-template=...
+
+Unless given invalid flags, this command always exits with status 0, so it
+can't break a prompt.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		formatter, err := output.NewFormatter(promptInfoSettings.Output, promptInfoShortFormat, promptInfoKeyValueFormat, output.JSON)
+		if err != nil {
+			return err
+		}
+		cmd.SilenceUsage = true
+		info := promptinfo.Get(promptInfoCache())
+		switch formatter.Format {
+		case promptInfoShortFormat:
+			return info.WriteShort(os.Stdout)
+		case promptInfoKeyValueFormat:
+			return info.WriteKeyValue(os.Stdout)
+		}
+		return formatter.Write(os.Stdout, info)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(promptInfoCmd)
+	markReadOnly(promptInfoCmd)
+	output.AddFlag(promptInfoCmd.Flags(), &promptInfoSettings.Output, promptInfoShortFormat, promptInfoKeyValueFormat, output.JSON)
+	promptInfoCmd.Flags().DurationVar(&promptInfoSettings.MaxAge, "max-age", 30*time.Second, "how long cached information is used before it is refreshed")
+}
+
+// promptInfoCache returns the cache to get the status from, or nil if
+// Rancher Desktop is not running.
+func promptInfoCache() *apicache.Cache {
+	config.UseViewerCredentials()
+	endpoint, err := config.GetEndpoint(true)
+	if err != nil || endpoint == "" {
+		return nil
+	}
+	cache, err := newEndpointAPICache(endpoint, &lazyRequester{})
+	if err != nil {
+		return nil
+	}
+	cache.MaxAge = promptInfoSettings.MaxAge
+	cache.Timeout = promptInfoTimeout
+	return cache
+}
+
+// lazyRequester gets the connection info only once a request is sent:
+// getting the password may run a credential helper, which fresh cache entries
+// shouldn't wait for.
+type lazyRequester struct {
+	once   sync.Once
+	client *client.RDClientImpl
+	err    error
+}
+
+func (r *lazyRequester) DoRequestWithHeaders(ctx context.Context, method string, command string, headers http.Header) (*http.Response, error) {
+	r.once.Do(func() {
+		connectionInfo, err := config.GetConnectionInfo(true)
+		switch {
+		case err != nil:
+			r.err = err
+		case connectionInfo == nil:
+			// Without the config file, Rancher Desktop is not running.
+			r.err = client.ErrConnectionRefused
+		default:
+			r.client = client.NewRDClient(connectionInfo)
+		}
+	})
+	if r.err != nil {
+		return nil, r.err
+	}
+	return r.client.DoRequestWithHeaders(ctx, method, command, headers)
+}
+
+// newAPICache returns the cache of rarely-changing API responses of the
+// application the connection info is for.
+func newAPICache(connectionInfo *config.ConnectionInfo) (*apicache.Cache, error) {
+	return newEndpointAPICache(connectionInfo.Endpoint(), client.NewRDClient(connectionInfo))
+}
+
+func newEndpointAPICache(endpoint string, requester apicache.Requester) (*apicache.Cache, error) {
+	appPaths, err := paths.GetPaths()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get paths: %w", err)
+	}
+	return apicache.NewCache(apicache.EndpointDir(apicache.Dir(appPaths), endpoint), requester), nil
+}
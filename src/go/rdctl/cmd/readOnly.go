@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+// readOnlyEnvVar enables read-only mode when set to a true value. Unlike the
+// --read-only flag, it can't be overridden on the command line, so
+// administrators can enforce read-only mode in the environment of users who
+// should only inspect the state of Rancher Desktop. Values that aren't
+// booleans also enable it.
+const readOnlyEnvVar = "RDCTL_READ_ONLY"
+
+// readOnlyAnnotation marks commands that don't change any state, and can
+// therefore be run in read-only mode. Commands without it are blocked.
+const readOnlyAnnotation = "rdctl/read-only"
+
+var readOnlyFlag bool
+
+func addReadOnlyFlag(cmd *cobra.Command) {
+	cmd.PersistentFlags().BoolVar(&readOnlyFlag, "read-only", false,
+		fmt.Sprintf("refuse to run commands that change any state (also enabled by setting %s)", readOnlyEnvVar))
+}
+
+// markReadOnly allows the commands to be run in read-only mode.
+func markReadOnly(cmds ...*cobra.Command) {
+	for _, cmd := range cmds {
+		if cmd.Annotations == nil {
+			cmd.Annotations = map[string]string{}
+		}
+		cmd.Annotations[readOnlyAnnotation] = "true"
+	}
+}
+
+// readOnlyMode returns whether commands changing any state are blocked.
+func readOnlyMode() bool {
+	if value, ok := os.LookupEnv(readOnlyEnvVar); ok && value != "" {
+		enabled, err := strconv.ParseBool(value)
+		return enabled || err != nil
+	}
+	return readOnlyFlag
+}
+
+func readOnlyError(action string) error {
+	return fmt.Errorf("%s is not allowed in read-only mode", action)
+}
+
+// checkReadOnly returns an error if the command is blocked in read-only mode.
+func checkReadOnly(cmd *cobra.Command) error {
+	if !readOnlyMode() || !cmd.Runnable() || cmd.Annotations[readOnlyAnnotation] == "true" {
+		return nil
+	}
+	// The commands added by cobra only print help and completion scripts.
+	for c := cmd; c != nil; c = c.Parent() {
+		switch c.Name() {
+		case "help", "completion", cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd:
+			return nil
+		}
+	}
+	return readOnlyError(fmt.Sprintf("'%s'", cmd.CommandPath()))
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadOnlyMode(t *testing.T) {
+	for value, expected := range map[string]bool{"": false, "0": false, "false": false, "1": true, "true": true, "yes": true} {
+		t.Run(value, func(t *testing.T) {
+			t.Setenv(readOnlyEnvVar, value)
+			readOnlyFlag = false
+			assert.Equal(t, expected, readOnlyMode())
+		})
+	}
+	t.Run("the environment can't be overridden", func(t *testing.T) {
+		t.Setenv(readOnlyEnvVar, "true")
+		readOnlyFlag = false
+		assert.True(t, readOnlyMode())
+	})
+}
+
+func TestCheckReadOnly(t *testing.T) {
+	run := func(*cobra.Command, []string) {}
+	root := &cobra.Command{Use: "rdctl"}
+	parent := &cobra.Command{Use: "parent"}
+	mutating := &cobra.Command{Use: "mutating", Run: run}
+	inspecting := &cobra.Command{Use: "inspecting", Run: run}
+	completion := &cobra.Command{Use: "completion", Run: run}
+	markReadOnly(inspecting)
+	root.AddCommand(parent, completion)
+	parent.AddCommand(mutating, inspecting)
+
+	t.Setenv(readOnlyEnvVar, "")
+	readOnlyFlag = false
+	assert.NoError(t, checkReadOnly(mutating))
+
+	readOnlyFlag = true
+	t.Cleanup(func() { readOnlyFlag = false })
+	assert.EqualError(t, checkReadOnly(mutating), "'rdctl parent mutating' is not allowed in read-only mode")
+	assert.NoError(t, checkReadOnly(inspecting))
+	assert.NoError(t, checkReadOnly(parent), "commands that only show help are allowed")
+	assert.NoError(t, checkReadOnly(completion))
+}
@@ -18,27 +18,122 @@ limitations under the License.
 package cmd
 
 import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
 	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/config"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/exitcode"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/output"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/profile"
 	"github.com/spf13/cobra"
-	"os"
 )
 
+var profileCLI bool
+
+// errorFormat is the value of the global `--output` flag, for the commands
+// that don't have their own.
+var errorFormat string
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "rdctl",
 	Short: "A CLI for Rancher Desktop",
-	Long:  `The eventual goal of this CLI is to enable any UI-based operation to be done from the command-line as well.`,
+	Long: `The eventual goal of this CLI is to enable any UI-based operation to be done from the command-line as well.
+
+The exit code tells how a command failed:
+  1  failure             any other error
+  2  not-running         Rancher Desktop, or its backend, isn't running
+  3  partial-success     some steps of the command failed
+  4  connection-refused  nothing answers at the address of the API
+  5  auth-failure        the API didn't accept the credentials
+  6  invalid-input       the arguments, flags or settings were rejected
+
+With --output json, errors are written to standard error as
+{"code": "<name of the exit code>", "message": "..."} instead of text.`,
+	// Execute reports the errors, in the format chosen by the command.
+	SilenceErrors: true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		output.Configure()
+		if jsonErrors(cmd) {
+			cmd.SilenceUsage = true
+		} else if errorFormat != "text" && cmd.Flags().Lookup("output") == cmd.Root().PersistentFlags().Lookup("output") {
+			cmd.SilenceUsage = true
+			return exitcode.WithCode(fmt.Errorf("invalid output format %q: must be text or json", errorFormat), exitcode.InvalidInput)
+		}
+		if profileCLI {
+			profile.Enable()
+			http.DefaultClient.Transport = profile.Transport(http.DefaultTransport)
+		}
+		if err := checkReadOnly(cmd); err != nil {
+			cmd.SilenceUsage = true
+			return err
+		}
+		if readOnlyMode() {
+			config.UseViewerCredentials()
+		}
+		return nil
+	},
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
-		os.Exit(1)
+	cmd, err := rootCmd.ExecuteC()
+	if profile.Enabled() {
+		_ = profile.Write(os.Stderr)
+	}
+	if err != nil {
+		// Commands that report their errors themselves silence them.
+		if asJSON := jsonErrors(cmd); asJSON || cmd == rootCmd || !cmd.SilenceErrors {
+			writeError(os.Stderr, err, asJSON)
+		}
+		os.Exit(exitcode.FromError(err))
+	}
+}
+
+// jsonErrors returns whether the errors of the command are reported as JSON,
+// i.e. whether its `--output` flag, or the global one, is "json".
+func jsonErrors(cmd *cobra.Command) bool {
+	if cmd == nil {
+		return false
+	}
+	flag := cmd.Flags().Lookup("output")
+	if flag == nil {
+		flag = cmd.Root().PersistentFlags().Lookup("output")
+	}
+	return flag != nil && flag.Value.String() == output.JSON
+}
+
+// errorReport is an error as reported with `--output json`.
+type errorReport struct {
+	// Code is the stable name of the exit code, e.g. "not-running".
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// writeError reports the error returned by a command.
+func writeError(w io.Writer, err error, asJSON bool) {
+	if !asJSON {
+		fmt.Fprintln(w, "Error:", err.Error())
+		return
 	}
+	report, _ := json.Marshal(errorReport{Code: exitcode.Name(exitcode.FromError(err)), Message: err.Error()})
+	fmt.Fprintln(w, string(report))
 }
 
 func init() {
+	output.AddGlobalFlags(rootCmd.PersistentFlags())
+	rootCmd.PersistentFlags().StringVarP(&errorFormat, "output", "o", "text",
+		"format of the errors of commands without their own --output flag: text|json")
+	rootCmd.SetFlagErrorFunc(func(_ *cobra.Command, err error) error {
+		return exitcode.WithCode(err, exitcode.InvalidInput)
+	})
+	addReadOnlyFlag(rootCmd)
+	rootCmd.PersistentFlags().BoolVar(&profileCLI, "profile-cli", false,
+		"print where the command spent its time (configuration, paths, API requests, subprocesses) to standard error")
 	if len(os.Args) > 1 {
 		mainCommand := os.Args[1]
 		if mainCommand == "-h" || mainCommand == "help" || mainCommand == "--help" {
@@ -46,7 +141,7 @@ func init() {
 				mainCommand = os.Args[2]
 			}
 		}
-		if mainCommand == "shell" || mainCommand == "version" || mainCommand == "completion" {
+		if mainCommand == "shell" || mainCommand == "completion" {
 			return
 		}
 	}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/client"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/config"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/output"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/settingsschema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteError(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected string
+	}{
+		{errors.New("failed"), `{"code":"failure","message":"failed"}`},
+		{config.ErrMainProcessNotRunning, `{"code":"not-running","message":"Rancher Desktop is not running"}`},
+		{client.ErrConnectionRefused, `{"code":"connection-refused","message":"connection refused"}`},
+		{fmt.Errorf("401 Unauthorized: %w", client.ErrAuthFailure), `{"code":"auth-failure","message":"401 Unauthorized: user/password not accepted"}`},
+		{settingsProblemsError([]settingsschema.Problem{{Field: "colour", Message: "unknown setting"}}), `{"code":"invalid-input","message":"invalid settings:\n  colour: unknown setting"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.err.Error(), func(t *testing.T) {
+			var buf bytes.Buffer
+			writeError(&buf, tt.err, true)
+			assert.Equal(t, tt.expected+"\n", buf.String())
+		})
+	}
+	t.Run("text", func(t *testing.T) {
+		var buf bytes.Buffer
+		writeError(&buf, client.ErrConnectionRefused, false)
+		assert.Equal(t, "Error: connection refused\n", buf.String())
+	})
+}
+
+func TestJSONErrors(t *testing.T) {
+	t.Cleanup(func() {
+		errorFormat = "text"
+		statusSettings.Output = tableFormat
+	})
+	assert.False(t, jsonErrors(startCmd))
+	errorFormat = output.JSON
+	assert.True(t, jsonErrors(startCmd))
+	// Commands with their own --output flag use it.
+	assert.False(t, jsonErrors(statusCmd))
+	statusSettings.Output = output.JSON
+	assert.True(t, jsonErrors(statusCmd))
+}
@@ -19,23 +19,85 @@ package cmd
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"runtime"
+	"strings"
 
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/apicache"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/checks"
 	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/client"
 	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/config"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/exitcode"
 	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/options/generated"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/output"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/servicemesh"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/settingsschema"
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
 )
 
+var setSettings struct {
+	NoRestart bool
+	FromFile  string
+	DryRun    bool
+	Preset    string
+	Force     bool
+	Output    string
+}
+
 // setCmd represents the set command
 var setCmd = &cobra.Command{
 	Use:   "set",
 	Short: "Update selected fields in the Rancher Desktop UI and restart the backend.",
-	Long:  `Update selected fields in the Rancher Desktop UI and restart the backend.`,
+	Long: `Update selected fields in the Rancher Desktop UI and restart the backend.
+
+With --no-restart, changes that require restarting the backend are saved, and
+applied on its next restart, so that several changes cause a single restart.
+"rdctl settings pending" lists them.
+
+With --from-file, the settings are read from a YAML or JSON document, or from
+standard input if the file is "-", and merged into the current settings; the
+document only needs the settings to change, e.g.:
+
+  containerEngine:
+    name: moby
+  kubernetes:
+    enabled: false
+
+Flags given with --from-file override the settings in the document. The
+document may give the version of its settings, in which case it is migrated to
+the current version; it defaults to the current version.
+
+With --preset service-mesh, the settings are changed to meet what service
+meshes such as Istio and Linkerd need for development: at least 4 CPUs and 8 GB
+of memory for the VM, higher inotify limits, Kubernetes enabled, and Traefik
+disabled so that the ingress gateway of the mesh can use ports 80 and 443.
+Settings that already meet these are kept, and a report of each requirement is
+shown; flags and --from-file override the preset.
+
+The memory and CPUs of the VM are limited to 80% of the memory of the host and
+to its CPUs, as reported by "rdctl api /v1/host_resources"; --force accepts
+more memory, up to what the host has, and the status warns about it.
+
+With --dry-run, the settings that would change are listed, with whether
+applying them restarts the backend, without changing anything; --output sets
+the format of the list.
+
+The settings are checked against the schema of the settings ("rdctl settings
+schema") before being sent, and all the unknown settings and invalid values are
+reported.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if err := cobra.NoArgs(cmd, args); err != nil {
 			return err
 		}
+		if !setSettings.DryRun && readOnlyMode() {
+			return readOnlyError("changing settings")
+		}
 		return doSetCommand(cmd)
 	},
 }
@@ -43,9 +105,26 @@ var setCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(setCmd)
 	options.UpdateCommonStartAndSetCommands(setCmd)
+	registerSettingCompletions(setCmd)
+	setCmd.Flags().BoolVar(&setSettings.NoRestart, "no-restart", false, "save changes that require a restart, and apply them on the next restart")
+	setCmd.Flags().StringVar(&setSettings.FromFile, "from-file", "", `read the settings to change from a YAML or JSON file, or standard input with "-"`)
+	setCmd.Flags().BoolVar(&setSettings.DryRun, "dry-run", false, "show the settings that would change, without changing them")
+	setCmd.Flags().BoolVar(&setSettings.Force, "force", false, "accept more memory for the VM than the limit of the host")
+	setCmd.Flags().StringVar(&setSettings.Preset, "preset", "", fmt.Sprintf("change the settings to meet the needs of a use case: %s", servicemesh.Name))
+	_ = setCmd.RegisterFlagCompletionFunc("preset", cobra.FixedCompletions([]string{servicemesh.Name}, cobra.ShellCompDirectiveNoFileComp))
+	output.AddFlag(setCmd.Flags(), &setSettings.Output, tableFormat, output.JSON)
+	// Previewing changes is allowed; changing settings is checked when running.
+	markReadOnly(setCmd)
 }
 
 func doSetCommand(cmd *cobra.Command) error {
+	formatter, err := output.NewFormatter(setSettings.Output, tableFormat, output.JSON)
+	if err != nil {
+		return err
+	}
+	if setSettings.Preset != "" && setSettings.Preset != servicemesh.Name {
+		return fmt.Errorf("invalid preset %q; the presets are: %s", setSettings.Preset, servicemesh.Name)
+	}
 	connectionInfo, err := config.GetConnectionInfo(false)
 	if err != nil {
 		return fmt.Errorf("failed to get connection info: %w", err)
@@ -56,7 +135,7 @@ func doSetCommand(cmd *cobra.Command) error {
 	if err != nil {
 		cmd.SilenceUsage = true
 		return err
-	} else if changedSettings == nil {
+	} else if changedSettings == nil && setSettings.FromFile == "" && setSettings.Preset == "" {
 		return fmt.Errorf("%s command: no settings to change were given", cmd.Name())
 	}
 	cmd.SilenceUsage = true
@@ -64,12 +143,55 @@ func doSetCommand(cmd *cobra.Command) error {
 	if err != nil {
 		return err
 	}
+	if setSettings.FromFile != "" {
+		if jsonBuffer, err = mergeSettingsFile(setSettings.FromFile, os.Stdin, jsonBuffer); err != nil {
+			return err
+		}
+	}
+	var report []checks.Check
+	if setSettings.Preset != "" {
+		if jsonBuffer, report, err = applyServiceMeshPreset(rdClient, jsonBuffer); err != nil {
+			return err
+		}
+	}
+	if err := validateSettings(connectionInfo, jsonBuffer); err != nil {
+		return err
+	}
+	if report != nil {
+		// Keep standard output for the preview in other formats.
+		reportWriter := io.Writer(os.Stdout)
+		if formatter.Format != tableFormat {
+			reportWriter = os.Stderr
+		}
+		if err := checks.WriteTable(reportWriter, report); err != nil {
+			return err
+		}
+	}
+	if setSettings.DryRun {
+		preview, err := previewSettings(rdClient, jsonBuffer, setSettings.Force)
+		if err != nil {
+			return err
+		}
+		return writeSettingsPreview(os.Stdout, formatter, preview)
+	}
 
-	response, err := rdClient.DoRequestWithPayload("PUT", client.VersionCommand("", "settings"), bytes.NewBuffer(jsonBuffer))
+	endpoint := client.VersionCommand("", "settings")
+	query := url.Values{}
+	if setSettings.NoRestart {
+		query.Set("restart", "false")
+	}
+	if setSettings.Force {
+		query.Set("force", "true")
+	}
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+	response, err := rdClient.DoRequestWithPayload("PUT", endpoint, bytes.NewBuffer(jsonBuffer))
 	result, err := client.ProcessRequestForUtility(response, err)
 	if err != nil {
 		return err
 	}
+	invalidateCachedSettings()
 	if len(result) > 0 {
 		fmt.Printf("Status: %s.\n", string(result))
 	} else {
@@ -77,3 +199,135 @@ func doSetCommand(cmd *cobra.Command) error {
 	}
 	return nil
 }
+
+// validateSettings checks the settings against the settings schema, so that
+// all the mistakes are reported precisely. Settings for an older version are
+// left to the application, which migrates them, and so are the settings when
+// the schema can't be fetched: the application checks them again anyway.
+func validateSettings(connectionInfo *config.ConnectionInfo, payload []byte) error {
+	var settings map[string]any
+	if err := json.Unmarshal(payload, &settings); err != nil {
+		return err
+	}
+	if version, ok := settings["version"]; ok && fmt.Sprint(version) != fmt.Sprint(options.CURRENT_SETTINGS_VERSION) {
+		return nil
+	}
+	cache, err := newAPICache(connectionInfo)
+	if err != nil {
+		return nil
+	}
+	body, err := cache.Get(apicache.SettingsSchema)
+	if err != nil {
+		return nil
+	}
+	var schema settingsschema.Schema
+	if err := json.Unmarshal(body, &schema); err != nil {
+		return nil
+	}
+	return settingsProblemsError(settingsschema.Validate(&schema, settings, runtime.GOOS))
+}
+
+// settingsProblemsError returns an error listing the problems, or nil if
+// there are none.
+func settingsProblemsError(problems []settingsschema.Problem) error {
+	if len(problems) == 0 {
+		return nil
+	}
+	var message strings.Builder
+	message.WriteString("invalid settings:")
+	for _, problem := range problems {
+		fmt.Fprintf(&message, "\n  %s", problem)
+	}
+	return exitcode.WithCode(errors.New(message.String()), exitcode.InvalidInput)
+}
+
+// mergeSettingsFile returns the settings of the file, or of stdin if the file
+// is "-", overridden by the settings in flagSettings, as JSON.
+func mergeSettingsFile(file string, stdin io.Reader, flagSettings []byte) ([]byte, error) {
+	var contents []byte
+	var err error
+	if file == "-" {
+		contents, err = io.ReadAll(stdin)
+	} else {
+		contents, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read settings: %w", err)
+	}
+	var settings map[string]any
+	// YAML is a superset of JSON, so this reads both.
+	if err := yaml.Unmarshal(contents, &settings); err != nil {
+		return nil, fmt.Errorf("failed to parse settings from %s: %w", file, err)
+	}
+	if len(settings) == 0 {
+		return nil, fmt.Errorf("no settings to change were given in %s", file)
+	}
+	var overrides map[string]any
+	if err := json.Unmarshal(flagSettings, &overrides); err != nil {
+		return nil, err
+	}
+	if version, ok := settings["version"]; ok && overrides != nil && fmt.Sprint(version) != fmt.Sprint(overrides["version"]) {
+		return nil, fmt.Errorf("the settings in %s are for version %v, so they can't be combined with flags for version %v", file, version, overrides["version"])
+	}
+	mergeSettings(settings, overrides)
+	if _, ok := settings["version"]; !ok {
+		settings["version"] = options.CURRENT_SETTINGS_VERSION
+	}
+	result, err := json.Marshal(settings)
+	if err != nil {
+		return nil, fmt.Errorf("invalid settings in %s: %w", file, err)
+	}
+	return result, nil
+}
+
+// applyServiceMeshPreset returns the settings of the service mesh preset for
+// the current settings, overridden by overrides, as JSON, and the report on
+// each requirement of the preset.
+func applyServiceMeshPreset(rdClient client.RDClient, overrides []byte) ([]byte, []checks.Check, error) {
+	body, err := client.ProcessRequestForUtility(rdClient.DoRequest("GET", client.VersionCommand("", "settings")))
+	if err != nil {
+		return nil, nil, err
+	}
+	var current servicemesh.Settings
+	if err := json.Unmarshal(body, &current); err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal settings: %w", err)
+	}
+	settings, report := servicemesh.Apply(current, servicemesh.Host{GOOS: runtime.GOOS, CPUs: runtime.NumCPU()})
+	var overrideSettings map[string]any
+	if err := json.Unmarshal(overrides, &overrideSettings); err != nil {
+		return nil, nil, err
+	}
+	if version, ok := overrideSettings["version"]; ok && fmt.Sprint(version) != fmt.Sprint(options.CURRENT_SETTINGS_VERSION) {
+		return nil, nil, fmt.Errorf("settings for version %v can't be combined with a preset", version)
+	}
+	mergeSettings(settings, overrideSettings)
+	settings["version"] = options.CURRENT_SETTINGS_VERSION
+	result, err := json.Marshal(settings)
+	if err != nil {
+		return nil, nil, err
+	}
+	return result, report, nil
+}
+
+// mergeSettings recursively copies the settings of overrides into settings.
+func mergeSettings(settings, overrides map[string]any) {
+	for key, value := range overrides {
+		existing, existingIsMap := settings[key].(map[string]any)
+		override, overrideIsMap := value.(map[string]any)
+		if existingIsMap && overrideIsMap {
+			mergeSettings(existing, override)
+		} else {
+			settings[key] = value
+		}
+	}
+}
+
+// invalidateCachedSettings makes sure commands using the API cache, such as
+// prompt-info, don't show the settings from before a change.
+func invalidateCachedSettings() {
+	appPaths, err := paths.GetPaths()
+	if err != nil {
+		return
+	}
+	_ = apicache.InvalidateAll(apicache.Dir(appPaths), apicache.Settings)
+}
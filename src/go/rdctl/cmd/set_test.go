@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/options/generated"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/settingsschema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeSettingsFile(t *testing.T) {
+	version := options.CURRENT_SETTINGS_VERSION
+	flags := fmt.Sprintf(`{"version":%d,"kubernetes":{"version":"1.29.1"}}`, version)
+	document := `
+containerEngine:
+  name: moby
+kubernetes:
+  enabled: true
+  version: 1.27.3
+`
+	t.Run("from a file", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "settings.yaml")
+		require.NoError(t, os.WriteFile(file, []byte(document), 0o644))
+		result, err := mergeSettingsFile(file, nil, []byte("null"))
+		require.NoError(t, err)
+		expected := fmt.Sprintf(`{"containerEngine":{"name":"moby"},"kubernetes":{"enabled":true,"version":"1.27.3"},"version":%d}`, version)
+		assert.JSONEq(t, expected, string(result))
+	})
+	t.Run("flags override the file", func(t *testing.T) {
+		result, err := mergeSettingsFile("-", strings.NewReader(document), []byte(flags))
+		require.NoError(t, err)
+		expected := fmt.Sprintf(`{"containerEngine":{"name":"moby"},"kubernetes":{"enabled":true,"version":"1.29.1"},"version":%d}`, version)
+		assert.JSONEq(t, expected, string(result))
+	})
+	t.Run("JSON with an older version", func(t *testing.T) {
+		result, err := mergeSettingsFile("-", strings.NewReader(`{"version": 1, "kubernetes": {"enabled": false}}`), []byte("null"))
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"version":1,"kubernetes":{"enabled":false}}`, string(result))
+		_, err = mergeSettingsFile("-", strings.NewReader(`{"version": 1}`), []byte(flags))
+		assert.ErrorContains(t, err, "are for version 1")
+	})
+	t.Run("the same version as the flags", func(t *testing.T) {
+		input := fmt.Sprintf("version: %d\n", version)
+		_, err := mergeSettingsFile("-", strings.NewReader(input), []byte(flags))
+		assert.NoError(t, err)
+	})
+	t.Run("invalid documents", func(t *testing.T) {
+		_, err := mergeSettingsFile("-", strings.NewReader(""), []byte("null"))
+		assert.ErrorContains(t, err, "no settings to change")
+		_, err = mergeSettingsFile("-", strings.NewReader("- a\n- b\n"), []byte("null"))
+		assert.ErrorContains(t, err, "failed to parse settings")
+		_, err = mergeSettingsFile(filepath.Join(t.TempDir(), "missing.yaml"), nil, []byte("null"))
+		assert.ErrorContains(t, err, "failed to read settings")
+	})
+}
+
+func TestSettingsProblemsError(t *testing.T) {
+	assert.NoError(t, settingsProblemsError(nil))
+	err := settingsProblemsError([]settingsschema.Problem{
+		{Field: "colour", Message: "unknown setting"},
+		{Field: "kubernetes.port", Message: "must be at least 1"},
+	})
+	assert.EqualError(t, err, "invalid settings:\n  colour: unknown setting\n  kubernetes.port: must be at least 1")
+}
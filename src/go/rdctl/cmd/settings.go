@@ -0,0 +1,16 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var settingsCmd = &cobra.Command{
+	Use:   "settings",
+	Short: "Inspect the settings of Rancher Desktop",
+	Long: `Inspect the settings of Rancher Desktop; use "rdctl list-settings" to list them,
+and "rdctl set" to change them.`,
+}
+
+func init() {
+	rootCmd.AddCommand(settingsCmd)
+}
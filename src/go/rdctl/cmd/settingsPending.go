@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/client"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/config"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/output"
+	"github.com/spf13/cobra"
+)
+
+var settingsPendingSettings struct {
+	Output string
+}
+
+// pendingSetting is a saved setting the backend applies on its next restart.
+type pendingSetting struct {
+	Name     string `json:"name"`
+	Current  any    `json:"current"`
+	Desired  any    `json:"desired"`
+	Severity string `json:"severity"`
+}
+
+var settingsPendingCmd = &cobra.Command{
+	Use:   "pending",
+	Short: "List the settings that are applied on the next restart of the backend",
+	Long: `List the saved settings that differ from those the backend is running with,
+such as the ones changed with "rdctl set --no-restart". They are applied on the
+next restart of the backend; a severity of "reset" means that applying them
+deletes the existing workloads.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		formatter, err := output.NewFormatter(settingsPendingSettings.Output, tableFormat, output.JSON)
+		if err != nil {
+			return err
+		}
+		cmd.SilenceUsage = true
+		connectionInfo, err := config.GetConnectionInfo(false)
+		if err != nil {
+			return fmt.Errorf("failed to get connection info: %w", err)
+		}
+		rdClient := client.NewRDClient(connectionInfo)
+		pending, err := getPendingSettings(rdClient)
+		if err != nil {
+			return err
+		}
+		if formatter.Format != tableFormat {
+			return formatter.Write(os.Stdout, pending)
+		}
+		if len(pending) == 0 {
+			output.Infof("No settings are pending a restart.")
+			return nil
+		}
+		writer := tabwriter.NewWriter(os.Stdout, 0, 4, 4, ' ', 0)
+		fmt.Fprintf(writer, "SETTING\tCURRENT\tDESIRED\tSEVERITY\n")
+		for _, setting := range pending {
+			fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", setting.Name, formatSettingValue(setting.Current), formatSettingValue(setting.Desired), setting.Severity)
+		}
+		return writer.Flush()
+	},
+}
+
+func init() {
+	settingsCmd.AddCommand(settingsPendingCmd)
+	output.AddFlag(settingsPendingCmd.Flags(), &settingsPendingSettings.Output, tableFormat, output.JSON)
+	markReadOnly(settingsPendingCmd)
+}
+
+// getPendingSettings returns the pending settings, sorted by name.
+func getPendingSettings(rdClient client.RDClient) ([]pendingSetting, error) {
+	body, err := client.ProcessRequestForUtility(rdClient.DoRequest("GET", client.VersionCommand("", "settings/pending")))
+	if err != nil {
+		return nil, err
+	}
+	var reasons map[string]pendingSetting
+	if err := json.Unmarshal(body, &reasons); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal pending settings: %w", err)
+	}
+	pending := make([]pendingSetting, 0, len(reasons))
+	for name, reason := range reasons {
+		reason.Name = name
+		pending = append(pending, reason)
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].Name < pending[j].Name
+	})
+	return pending, nil
+}
+
+// formatSettingValue formats a setting value as compact JSON, except for
+// strings, which are shown as is.
+func formatSettingValue(value any) string {
+	if text, ok := value.(string); ok {
+		return text
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(encoded)
+}
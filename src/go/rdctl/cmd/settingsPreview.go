@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/client"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/output"
+)
+
+// settingChange is a setting that a change to the settings would modify.
+type settingChange struct {
+	Name    string `json:"name"`
+	Current any    `json:"current"`
+	New     any    `json:"new"`
+	// Restart is whether the backend must restart to apply the change;
+	// Severity is "reset" if doing so deletes the existing workloads.
+	Restart  bool   `json:"restart"`
+	Severity string `json:"severity,omitempty"`
+}
+
+// settingsPreview describes what a change to the settings would do.
+type settingsPreview struct {
+	Changes []settingChange `json:"changes"`
+	Restart bool            `json:"restart"`
+}
+
+// previewSettings returns how the payload, a partial settings document, would
+// change the settings, without applying it; force accepts resources of the VM
+// over the limits of the host.
+func previewSettings(rdClient client.RDClient, payload []byte, force bool) (settingsPreview, error) {
+	body, err := client.ProcessRequestForUtility(rdClient.DoRequest("GET", client.VersionCommand("", "settings")))
+	if err != nil {
+		return settingsPreview{}, err
+	}
+	var current, changes map[string]any
+	if err := json.Unmarshal(body, &current); err != nil {
+		return settingsPreview{}, fmt.Errorf("failed to unmarshal settings: %w", err)
+	}
+	if err := json.Unmarshal(payload, &changes); err != nil {
+		return settingsPreview{}, err
+	}
+	delete(changes, "version")
+	// The proposal also validates the changes.
+	command := client.VersionCommand("", "propose_settings")
+	if force {
+		command += "?force=true"
+	}
+	response, err := rdClient.DoRequestWithPayload("PUT", command, bytes.NewBuffer(payload))
+	body, err = client.ProcessRequestForUtility(response, err)
+	if err != nil {
+		return settingsPreview{}, err
+	}
+	var reasons map[string]pendingSetting
+	if err := json.Unmarshal(body, &reasons); err != nil {
+		return settingsPreview{}, fmt.Errorf("failed to unmarshal the restart reasons: %w", err)
+	}
+	preview := settingsPreview{Changes: diffSettings("", current, changes)}
+	for i, change := range preview.Changes {
+		if reason, ok := reasons[change.Name]; ok {
+			preview.Changes[i].Restart = true
+			preview.Changes[i].Severity = reason.Severity
+			preview.Restart = true
+		}
+	}
+	return preview, nil
+}
+
+// diffSettings returns the settings in changes whose value differs from the
+// one in current, sorted by name.
+func diffSettings(prefix string, current, changes map[string]any) []settingChange {
+	result := []settingChange{}
+	for key, value := range changes {
+		name := prefix + key
+		currentValue, exists := current[key]
+		nestedCurrent, currentIsMap := currentValue.(map[string]any)
+		nestedChanges, changesIsMap := value.(map[string]any)
+		if currentIsMap && changesIsMap {
+			result = append(result, diffSettings(name+".", nestedCurrent, nestedChanges)...)
+		} else if !exists || !reflect.DeepEqual(currentValue, value) {
+			result = append(result, settingChange{Name: name, Current: currentValue, New: value})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// writeSettingsPreview writes the preview in the format of the formatter, or
+// as a table.
+func writeSettingsPreview(w io.Writer, formatter *output.Formatter, preview settingsPreview) error {
+	if formatter.Format != tableFormat {
+		return formatter.Write(w, preview)
+	}
+	if len(preview.Changes) == 0 {
+		output.Infof("No settings would change.")
+		return nil
+	}
+	writer := tabwriter.NewWriter(w, 0, 4, 4, ' ', 0)
+	fmt.Fprintf(writer, "SETTING\tCURRENT\tNEW\tRESTART\n")
+	for _, change := range preview.Changes {
+		restart := "no"
+		if change.Severity != "" {
+			restart = change.Severity
+		} else if change.Restart {
+			restart = "yes"
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", change.Name, formatSettingValue(change.Current), formatSettingValue(change.New), restart)
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	if preview.Restart {
+		output.Infof("Applying these changes restarts the backend.")
+	} else {
+		output.Infof("Applying these changes doesn't restart the backend.")
+	}
+	return nil
+}
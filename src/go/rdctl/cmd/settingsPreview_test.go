@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSettingsClient serves the settings, and the restart reasons of any
+// proposal.
+type fakeSettingsClient struct {
+	settings string
+	reasons  string
+	proposed string
+}
+
+func (c *fakeSettingsClient) respond(body string) (*http.Response, error) {
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body))}, nil
+}
+
+func (c *fakeSettingsClient) DoRequest(method string, command string) (*http.Response, error) {
+	if method == "GET" && command == client.VersionCommand("", "settings") {
+		return c.respond(c.settings)
+	}
+	return nil, errors.New("unexpected request")
+}
+
+func (c *fakeSettingsClient) DoRequestWithPayload(method string, command string, payload io.Reader) (*http.Response, error) {
+	if method != "PUT" || command != client.VersionCommand("", "propose_settings") {
+		return nil, errors.New("unexpected request")
+	}
+	body, err := io.ReadAll(payload)
+	if err != nil {
+		return nil, err
+	}
+	c.proposed = string(body)
+	return c.respond(c.reasons)
+}
+
+func (c *fakeSettingsClient) GetBackendState() (client.BackendState, error) {
+	return client.BackendState{}, errors.New("unexpected request")
+}
+
+func (c *fakeSettingsClient) UpdateBackendState(state client.BackendState) error {
+	return errors.New("unexpected request")
+}
+
+func TestPreviewSettings(t *testing.T) {
+	rdClient := &fakeSettingsClient{
+		settings: `{"version": 10, "containerEngine": {"name": "moby"}, "kubernetes": {"enabled": true, "version": "1.27.3", "port": 6443}, "application": {"autoStart": false}}`,
+		reasons:  `{"containerEngine.name": {"current": "moby", "desired": "containerd", "severity": "reset"}}`,
+	}
+	payload := `{"version": 10, "containerEngine": {"name": "containerd"}, "kubernetes": {"enabled": true, "port": 6444}, "application": {"autoStart": true}}`
+	preview, err := previewSettings(rdClient, []byte(payload), false)
+	require.NoError(t, err)
+	assert.Equal(t, payload, rdClient.proposed)
+	assert.True(t, preview.Restart)
+	assert.Equal(t, []settingChange{
+		{Name: "application.autoStart", Current: false, New: true},
+		{Name: "containerEngine.name", Current: "moby", New: "containerd", Restart: true, Severity: "reset"},
+		{Name: "kubernetes.port", Current: float64(6443), New: float64(6444)},
+	}, preview.Changes)
+
+	rdClient.reasons = "{}"
+	preview, err = previewSettings(rdClient, []byte(`{"version": 10, "kubernetes": {"version": "1.27.3"}}`), false)
+	require.NoError(t, err)
+	assert.False(t, preview.Restart)
+	assert.Empty(t, preview.Changes)
+}
+
+func TestDiffSettings(t *testing.T) {
+	current := map[string]any{
+		"list":   []any{"a", "b"},
+		"nested": map[string]any{"value": "x"},
+	}
+	changes := map[string]any{
+		"list":   []any{"a"},
+		"nested": map[string]any{"value": "x", "added": true},
+		"new":    map[string]any{"value": 1},
+	}
+	assert.Equal(t, []settingChange{
+		{Name: "list", Current: []any{"a", "b"}, New: []any{"a"}},
+		{Name: "nested.added", New: true},
+		{Name: "new", New: map[string]any{"value": 1}},
+	}, diffSettings("", current, changes))
+}
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/apicache"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+var settingsSchemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the JSON schema of the settings",
+	Long: `Print the JSON schema of the settings, as accepted by 'rdctl set' and the API.
+The schema only changes when Rancher Desktop is upgraded, so it is cached on
+disk.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		connectionInfo, err := config.GetConnectionInfo(false)
+		if err != nil {
+			return fmt.Errorf("failed to get connection info: %w", err)
+		}
+		cache, err := newAPICache(connectionInfo)
+		if err != nil {
+			return err
+		}
+		body, err := cache.Get(apicache.SettingsSchema)
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(body)
+		return err
+	},
+}
+
+func init() {
+	settingsCmd.AddCommand(settingsSchemaCmd)
+	markReadOnly(settingsSchemaCmd)
+}
@@ -18,15 +18,21 @@ package cmd
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"regexp"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/directories"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/exitcode"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/guestcmd"
 	p "github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/vm"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"golang.org/x/text/encoding/unicode"
@@ -44,14 +50,55 @@ var shellCmd = &cobra.Command{
 -- Runs 'ls -CF' from /tmp on the VM
 > rdctl shell bash -c "cd .. ; pwd"
 -- Usual way of running multiple statements on a single call
+> rdctl shell --workdir /src --env GOFLAGS=-mod=mod -- go test ./...
+-- Runs 'go test' from /src on the VM, with GOFLAGS set
+> rdctl shell --user root --no-tty -- apk add jq
+-- Runs 'apk add jq' as root, without a terminal even when run in one
+
+The arguments are passed to the command as they are, even if they contain
+spaces or special characters; run a shell, as above, to use pipes, globs or
+variables of the VM.
+
+The flags of rdctl shell come before the command, and end at the first argument
+that isn't one of them, or at "--":
+  --workdir <dir>   run the command in this directory of the VM
+  --env KEY=VALUE   set an environment variable of the command; with only KEY,
+                    the value of the variable on the host is passed on, if set
+  -u, --user <user> run the command, or the shell, as this user of the VM; the
+                    default user is root on Windows, and that of the host on
+                    macOS and Linux
+  -t, --tty         run the command in a terminal, even if rdctl doesn't run in
+                    one
+  -T, --no-tty      don't run the command in a terminal, even if rdctl runs in
+                    one
+
+The standard input and output are passed through unchanged, so binary data can
+be piped through the command, and rdctl exits with the exit code of the command.
+
+On macOS and Linux, the SSH agent of the host is forwarded to the shell when
+the experimental.virtual-machine.ssh-agent-forwarding setting is enabled (see
+'rdctl set'), so that git can use the SSH keys of the host without copying them
+into the VM.
 `,
 	DisableFlagParsing: true,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		// Do manual flag parsing looking to see if we should give help instead.
-		if len(args) > 0 && (args[0] == "-h" || args[0] == "--help") {
+		// The flags are parsed by hand, so that those of the command aren't
+		// taken for those of rdctl.
+		options, err := parseShellArgs(args)
+		if err != nil {
+			return exitcode.WithCode(err, exitcode.InvalidInput)
+		}
+		if options.help {
 			return cmd.Help()
 		}
-		return doShellCommand(cmd, args)
+		err = doShellCommand(cmd, options)
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			// The command reported its own errors; only its exit code is
+			// passed on.
+			cmd.SilenceErrors = true
+		}
+		return err
 	},
 }
 
@@ -59,40 +106,118 @@ func init() {
 	rootCmd.AddCommand(shellCmd)
 }
 
-func doShellCommand(cmd *cobra.Command, args []string) error {
+// shellOptions are the parsed arguments of rdctl shell.
+type shellOptions struct {
+	help  bool
+	noTTY bool
+	// command is the command to run in the VM, with its environment,
+	// directory, user and terminal; it runs a shell if it has no arguments.
+	command guestcmd.Command
+}
+
+// parseShellArgs parses the flags of rdctl shell, up to the first argument
+// that isn't one, or "--"; the rest of the arguments are the command.
+func parseShellArgs(args []string) (shellOptions, error) {
+	var options shellOptions
+	for len(args) > 0 {
+		arg := args[0]
+		if arg == "--" {
+			args = args[1:]
+			break
+		}
+		if !strings.HasPrefix(arg, "-") {
+			break
+		}
+		args = args[1:]
+		name, value, hasValue := strings.Cut(arg, "=")
+		switch name {
+		case "-h", "--help":
+			options.help = true
+			return options, nil
+		case "-t", "--tty":
+			options.command.TTY = true
+		case "-T", "--no-tty":
+			options.noTTY = true
+		case "--workdir", "--env", "-u", "--user":
+			if !hasValue {
+				if len(args) == 0 {
+					return options, fmt.Errorf("flag needs an argument: %s", name)
+				}
+				value, args = args[0], args[1:]
+			}
+			if name == "--workdir" {
+				options.command.Dir = value
+			} else if name == "-u" || name == "--user" {
+				options.command.User = value
+			} else if strings.Contains(value, "=") {
+				options.command.Env = append(options.command.Env, value)
+			} else if hostValue, ok := os.LookupEnv(value); ok {
+				options.command.Env = append(options.command.Env, value+"="+hostValue)
+			}
+		default:
+			return options, fmt.Errorf("unknown flag: %s", name)
+		}
+	}
+	options.command.Args = args
+	if options.command.TTY && options.noTTY {
+		return options, errors.New("--tty and --no-tty can't be used together")
+	}
+	if len(args) == 0 && (options.command.Dir != "" || len(options.command.Env) > 0 || options.command.TTY) {
+		return options, errors.New("--workdir, --env and --tty need a command to run")
+	}
+	return options, nil
+}
+
+func doShellCommand(cmd *cobra.Command, options shellOptions) error {
 	cmd.SilenceUsage = true
-	var commandName string
+	// Commands run as root on Windows, and as the user of the host otherwise.
+	options.command.Sudo = runtime.GOOS != "windows"
+	var args []string
+	if len(options.command.Args) > 0 {
+		var err error
+		if args, err = options.command.Argv(); err != nil {
+			return exitcode.WithCode(err, exitcode.InvalidInput)
+		}
+	} else if options.command.User != "" {
+		args = []string{"su", "-l", options.command.User}
+		if options.command.Sudo {
+			args = append([]string{"sudo"}, args...)
+		}
+	}
+	paths, err := p.GetPaths()
+	if err != nil {
+		return err
+	}
+	runner, err := vm.New(paths)
+	if err != nil {
+		return err
+	}
 	if runtime.GOOS == "windows" {
-		commandName = "wsl"
-		distroName := "rancher-desktop"
-		if !checkWSLIsRunning(distroName) {
+		if !checkWSLIsRunning(vm.DistroName) {
 			// No further output wanted, so just exit with the desired status.
 			os.Exit(1)
 		}
-		args = append([]string{
-			"--distribution", distroName,
-			"--exec", "/usr/local/bin/wsl-exec"},
-			args...)
 	} else {
-		paths, err := p.GetPaths()
-		if err != nil {
-			return err
-		}
-		if err = directories.SetupLimaHome(paths.AppHome); err != nil {
-			return err
-		}
-		commandName, err = directories.GetLimactlPath()
+		limactl, err := directories.GetLimactlPath()
 		if err != nil {
 			return err
 		}
-		if !checkLimaIsRunning(commandName) {
+		if !checkLimaIsRunning(limactl) {
 			// No further output wanted, so just exit with the desired status.
 			os.Exit(1)
 		}
-		args = append([]string{"shell", "0"}, args...)
 	}
-	shellCommand := exec.Command(commandName, args...)
+	// The arguments are quoted for limactl, so that they reach the command
+	// as they are, as with WSL.
+	shellCommand := runner.Command(args...)
 	shellCommand.Stdin = os.Stdin
+	if options.noTTY {
+		// A terminal is only allocated when the standard input is one; a pipe
+		// copying it hides it. The copy may be blocked reading the input
+		// after the command exits.
+		shellCommand.Stdin = struct{ io.Reader }{os.Stdin}
+		shellCommand.WaitDelay = time.Second
+	}
 	shellCommand.Stdout = os.Stdout
 	shellCommand.Stderr = os.Stderr
 	return shellCommand.Run()
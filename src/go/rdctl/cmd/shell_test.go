@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/guestcmd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseShellArgs(t *testing.T) {
+	t.Setenv("RDCTL_TEST_HOST_VAR", "from host")
+	testCases := []struct {
+		name    string
+		args    []string
+		command guestcmd.Command
+	}{
+		{"no arguments", nil, guestcmd.Command{}},
+		{"command", []string{"ls", "-CF", "/tmp"}, guestcmd.Command{Args: []string{"ls", "-CF", "/tmp"}}},
+		{"command after --", []string{"--", "ls", "--help"}, guestcmd.Command{Args: []string{"ls", "--help"}}},
+		{"flags of the command", []string{"go", "--workdir", "/src"}, guestcmd.Command{Args: []string{"go", "--workdir", "/src"}}},
+		{
+			"workdir and env",
+			[]string{"--workdir", "/src", "--env=A=1", "--env", "B=x y", "--", "make"},
+			guestcmd.Command{Args: []string{"make"}, Env: []string{"A=1", "B=x y"}, Dir: "/src"},
+		},
+		{
+			"env from the host",
+			[]string{"--env", "RDCTL_TEST_HOST_VAR", "--env", "RDCTL_TEST_UNSET_VAR", "env"},
+			guestcmd.Command{Args: []string{"env"}, Env: []string{"RDCTL_TEST_HOST_VAR=from host"}},
+		},
+		{"user", []string{"--user", "root", "id"}, guestcmd.Command{Args: []string{"id"}, User: "root"}},
+		{"user of the shell", []string{"-u=root"}, guestcmd.Command{Args: []string{}, User: "root"}},
+		{"tty", []string{"-t", "--", "top"}, guestcmd.Command{Args: []string{"top"}, TTY: true}},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			options, err := parseShellArgs(testCase.args)
+			require.NoError(t, err)
+			assert.False(t, options.help)
+			assert.Equal(t, testCase.command.Dir, options.command.Dir)
+			assert.Equal(t, testCase.command.Env, options.command.Env)
+			assert.Equal(t, testCase.command.Args, options.command.Args)
+			assert.Equal(t, testCase.command.User, options.command.User)
+			assert.Equal(t, testCase.command.TTY, options.command.TTY)
+			assert.False(t, options.noTTY)
+		})
+	}
+
+	t.Run("help", func(t *testing.T) {
+		options, err := parseShellArgs([]string{"--help"})
+		require.NoError(t, err)
+		assert.True(t, options.help)
+	})
+	t.Run("help of the command", func(t *testing.T) {
+		options, err := parseShellArgs([]string{"ls", "--help"})
+		require.NoError(t, err)
+		assert.False(t, options.help)
+	})
+	t.Run("no tty", func(t *testing.T) {
+		options, err := parseShellArgs([]string{"--no-tty", "cat"})
+		require.NoError(t, err)
+		assert.True(t, options.noTTY)
+		assert.False(t, options.command.TTY)
+	})
+	errorCases := []struct {
+		args  []string
+		error string
+	}{
+		{[]string{"--privileged", "id"}, "unknown flag: --privileged"},
+		{[]string{"--workdir"}, "flag needs an argument: --workdir"},
+		{[]string{"--user"}, "flag needs an argument: --user"},
+		{[]string{"--workdir", "/src"}, "--workdir, --env and --tty need a command"},
+		{[]string{"--env", "A=1", "--"}, "--workdir, --env and --tty need a command"},
+		{[]string{"--tty"}, "--workdir, --env and --tty need a command"},
+		{[]string{"-t", "-T", "id"}, "--tty and --no-tty can't be used together"},
+	}
+	for _, testCase := range errorCases {
+		t.Run(testCase.error, func(t *testing.T) {
+			_, err := parseShellArgs(testCase.args)
+			assert.ErrorContains(t, err, testCase.error)
+		})
+	}
+}
@@ -17,11 +17,14 @@ limitations under the License.
 package cmd
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/client"
 	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/config"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
 	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/shutdown"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/vm"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
@@ -29,6 +32,11 @@ import (
 type shutdownSettingsStruct struct {
 	Verbose         bool
 	WaitForShutdown bool
+	// Force kills the VM and the application without asking them to stop.
+	Force bool
+	// DrainTimeout is how long, in seconds, the running containers are given
+	// to stop before shutting down; they aren't stopped first if it is 0.
+	DrainTimeout int
 }
 
 var commonShutdownSettings shutdownSettingsStruct
@@ -37,11 +45,22 @@ var commonShutdownSettings shutdownSettingsStruct
 var shutdownCmd = &cobra.Command{
 	Use:   "shutdown",
 	Short: "Shuts down the running Rancher Desktop application",
-	Long:  `Shuts down the running Rancher Desktop application.`,
+	Long: `Shuts down the running Rancher Desktop application.
+
+Use --drain-timeout=SECONDS to first stop the running containers, giving each
+of them up to SECONDS to exit before it is killed. Use --force to kill the VM
+(or the WSL distributions) and the application immediately, for instance when
+they no longer respond.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if err := cobra.NoArgs(cmd, args); err != nil {
 			return err
 		}
+		if commonShutdownSettings.Force && commonShutdownSettings.DrainTimeout != 0 {
+			return errors.New("--force and --drain-timeout can't be used together")
+		}
+		if commonShutdownSettings.DrainTimeout < 0 {
+			return errors.New("--drain-timeout must not be negative")
+		}
 		if commonShutdownSettings.Verbose {
 			logrus.SetLevel(logrus.TraceLevel)
 		}
@@ -61,16 +80,44 @@ func init() {
 	rootCmd.AddCommand(shutdownCmd)
 	shutdownCmd.Flags().BoolVar(&commonShutdownSettings.Verbose, "verbose", false, "be verbose")
 	shutdownCmd.Flags().BoolVar(&commonShutdownSettings.WaitForShutdown, "wait", true, "wait for shutdown to be confirmed")
+	shutdownCmd.Flags().BoolVar(&commonShutdownSettings.Force, "force", false, "kill the VM and the application immediately")
+	shutdownCmd.Flags().IntVar(&commonShutdownSettings.DrainTimeout, "drain-timeout", 0, "stop the running containers first, waiting up to this many seconds for each")
 }
 
 func doShutdown(shutdownSettings *shutdownSettingsStruct, initiatingCommand shutdown.InitiatingCommand) ([]byte, error) {
 	var output []byte
 	connectionInfo, err := config.GetConnectionInfo(true)
-	if err == nil && connectionInfo != nil {
+	if err == nil && connectionInfo != nil && !shutdownSettings.Force {
 		rdClient := client.NewRDClient(connectionInfo)
+		if shutdownSettings.DrainTimeout > 0 {
+			if err := drainContainers(rdClient, shutdownSettings.DrainTimeout); err != nil {
+				logrus.Errorf("Ignoring error trying to stop the running containers: %s", err)
+			}
+		}
 		request, err := rdClient.DoRequest("PUT", client.VersionCommand("", "shutdown"))
 		output, _ = client.ProcessRequestForUtility(request, err)
 	}
-	err = shutdown.FinishShutdown(shutdownSettings.WaitForShutdown, initiatingCommand)
+	// Commands using the API cache, such as prompt-info, shouldn't report
+	// the application as running.
+	invalidateCachedSettings()
+	err = shutdown.FinishShutdown(shutdownSettings.WaitForShutdown, shutdownSettings.Force, initiatingCommand)
 	return output, err
 }
+
+// drainContainers stops the running containers of the engine in use.
+func drainContainers(rdClient client.RDClient, timeoutSeconds int) error {
+	engineName, err := getContainerEngineName(rdClient)
+	if err != nil {
+		return err
+	}
+	appPaths, err := paths.GetPaths()
+	if err != nil {
+		return fmt.Errorf("failed to get paths: %w", err)
+	}
+	runner, err := vm.New(appPaths)
+	if err != nil {
+		return err
+	}
+	logrus.Infof("Stopping the running containers, waiting up to %d seconds for each", timeoutSeconds)
+	return shutdown.DrainContainers(runner, engineName, timeoutSeconds)
+}
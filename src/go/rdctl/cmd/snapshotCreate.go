@@ -11,6 +11,7 @@ import (
 	"runtime"
 	"syscall"
 
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/output"
 	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/runner"
 	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/snapshot"
 	"github.com/sirupsen/logrus"
@@ -71,11 +72,18 @@ func createSnapshot(args []string) error {
 	defer stop()
 	stopAfterFunc := context.AfterFunc(ctx, func() {
 		if !outputJsonFormat {
-			fmt.Println("Cancelling snapshot creation...")
+			output.Infof("Cancelling snapshot creation...")
 		}
 	})
 	defer stopAfterFunc()
+	var progress *output.Progress
+	if !outputJsonFormat {
+		progress = output.StartProgress(fmt.Sprintf("Creating snapshot %q", name))
+	}
 	_, err = manager.Create(ctx, name, snapshotDescription)
+	if progress != nil {
+		progress.Stop()
+	}
 	if err != nil && !errors.Is(err, runner.ErrContextDone) {
 		return fmt.Errorf("failed to create snapshot: %w", err)
 	}
@@ -9,6 +9,7 @@ import (
 	"text/tabwriter"
 	"time"
 
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/output"
 	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/snapshot"
 	"github.com/spf13/cobra"
 )
@@ -36,17 +37,34 @@ var snapshotListCmd = &cobra.Command{
 	Short:   "List snapshots",
 	Args:    cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if outputJsonFormat {
+			if cmd.Flags().Changed("output") && snapshotListOutputFormat != output.JSON {
+				return fmt.Errorf(`can't specify both "--json" and "--output %s"`, snapshotListOutputFormat)
+			}
+			snapshotListOutputFormat = output.JSON
+		}
+		formatter, err := output.NewFormatter(snapshotListOutputFormat, tableFormat, output.JSON)
+		if err != nil {
+			return err
+		}
+		outputJsonFormat = formatter.Format == output.JSON
 		cmd.SilenceUsage = true
-		return exitWithJsonOrErrorCondition(listSnapshot())
+		return exitWithJsonOrErrorCondition(listSnapshot(formatter))
 	},
 }
 
+const tableFormat = "table"
+
+var snapshotListOutputFormat string
+
 func init() {
 	snapshotCmd.AddCommand(snapshotListCmd)
+	markReadOnly(snapshotListCmd)
 	snapshotListCmd.Flags().BoolVar(&outputJsonFormat, "json", false, "output json format")
+	output.AddFlag(snapshotListCmd.Flags(), &snapshotListOutputFormat, tableFormat, output.JSON)
 }
 
-func listSnapshot() error {
+func listSnapshot(formatter *output.Formatter) error {
 	manager, err := snapshot.NewManager()
 	if err != nil {
 		return fmt.Errorf("failed to create snapshot manager: %w", err)
@@ -56,6 +74,17 @@ func listSnapshot() error {
 		return fmt.Errorf("failed to list snapshots: %w", err)
 	}
 	sort.Sort(SortableSnapshots(snapshots))
+	for i := range snapshots {
+		if snapshots[i].Size, err = manager.Size(snapshots[i]); err != nil {
+			return err
+		}
+	}
+	if formatter.IsTemplate() {
+		for i := range snapshots {
+			snapshots[i].ID = ""
+		}
+		return formatter.Write(os.Stdout, snapshots)
+	}
 	if outputJsonFormat {
 		return jsonOutput(snapshots)
 	}
@@ -76,15 +105,15 @@ func jsonOutput(snapshots []snapshot.Snapshot) error {
 
 func tabularOutput(snapshots []snapshot.Snapshot) error {
 	if len(snapshots) == 0 {
-		fmt.Fprintln(os.Stderr, "No snapshots present.")
+		output.Infof("No snapshots present.")
 		return nil
 	}
 	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 4, ' ', 0)
-	fmt.Fprintf(writer, "NAME\tCREATED\tDESCRIPTION\n")
+	fmt.Fprintf(writer, "NAME\tCREATED\tSIZE\tDESCRIPTION\n")
 	for _, aSnapshot := range snapshots {
 		prettyCreated := aSnapshot.Created.Format(time.RFC1123)
 		desc := truncateAtNewlineOrMaxRunes(aSnapshot.Description, 63)
-		fmt.Fprintf(writer, "%s\t%s\t%s\n", aSnapshot.Name, prettyCreated, desc)
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", aSnapshot.Name, prettyCreated, formatUsage(aSnapshot.Size), desc)
 	}
 	writer.Flush()
 	return nil
@@ -7,15 +7,17 @@ import (
 	"os/signal"
 	"syscall"
 
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/output"
 	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/runner"
 	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/snapshot"
 	"github.com/spf13/cobra"
 )
 
 var snapshotRestoreCmd = &cobra.Command{
-	Use:   "restore <id>",
-	Short: "Restore a snapshot",
-	Args:  cobra.ExactArgs(1),
+	Use:               "restore <id>",
+	Short:             "Restore a snapshot",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeSnapshotNames,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		cmd.SilenceUsage = true
 		return exitWithJsonOrErrorCondition(restoreSnapshot(cmd, args))
@@ -40,11 +42,18 @@ func restoreSnapshot(cmd *cobra.Command, args []string) error {
 	defer stop()
 	stopAfterFunc := context.AfterFunc(ctx, func() {
 		if !outputJsonFormat {
-			fmt.Println("Cancelling snapshot restoration...")
+			output.Infof("Cancelling snapshot restoration...")
 		}
 	})
 	defer stopAfterFunc()
+	var progress *output.Progress
+	if !outputJsonFormat {
+		progress = output.StartProgress(fmt.Sprintf("Restoring snapshot %q", args[0]))
+	}
 	err = manager.Restore(ctx, args[0])
+	if progress != nil {
+		progress.Stop()
+	}
 	if err != nil && !errors.Is(err, runner.ErrContextDone) {
 		return fmt.Errorf("failed to restore snapshot %q: %w", args[0], err)
 	}
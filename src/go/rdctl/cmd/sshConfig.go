@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/sshconfig"
+	"github.com/spf13/cobra"
+)
+
+var sshConfigSettings struct {
+	Host  string
+	Proxy bool
+}
+
+var sshConfigCmd = &cobra.Command{
+	Use:   "ssh-config",
+	Short: "Print an ssh_config stanza for connecting to the VM",
+	Long: `Print an ssh_config stanza for connecting to the Rancher Desktop VM with ssh,
+and tools built on it, such as VS Code Remote-SSH and rsync. For example:
+
+> rdctl ssh-config >> ~/.ssh/config
+> ssh rancher-desktop
+
+The stanza connects through rdctl, so it keeps working when the VM is
+restarted. On Windows, printing the stanza creates a key for connecting as root,
+and installs sshd in the WSL distribution if needed, which requires Rancher
+Desktop to be running and the VM to have network access; it is therefore not
+allowed in read-only mode.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		appPaths, err := paths.GetPaths()
+		if err != nil {
+			return fmt.Errorf("failed to get paths: %w", err)
+		}
+		if sshConfigSettings.Proxy {
+			// Connecting gives a shell in the VM, like 'rdctl shell'.
+			if readOnlyMode() {
+				return readOnlyError("connecting to the VM")
+			}
+			return sshconfig.Proxy(appPaths, os.Stdin, os.Stdout)
+		}
+		// On Windows, generating the stanza creates a key and installs sshd
+		// in the VM.
+		if runtime.GOOS == "windows" && readOnlyMode() {
+			return readOnlyError("installing sshd in the VM")
+		}
+		rdctlPath, err := os.Executable()
+		if err != nil {
+			return fmt.Errorf("failed to get path to rdctl: %w", err)
+		}
+		if resolved, err := filepath.EvalSymlinks(rdctlPath); err == nil {
+			rdctlPath = resolved
+		}
+		stanza, err := sshconfig.Generate(appPaths, sshConfigSettings.Host, rdctlPath)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Print(stanza)
+		return err
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(sshConfigCmd)
+	sshConfigCmd.Flags().StringVar(&sshConfigSettings.Host, "host", sshconfig.DefaultHost, "name of the host in the stanza")
+	sshConfigCmd.Flags().BoolVar(&sshConfigSettings.Proxy, "proxy", false, "connect standard input and output to the ssh server of the VM (used as ProxyCommand)")
+	_ = sshConfigCmd.Flags().MarkHidden("proxy")
+	markReadOnly(sshConfigCmd)
+}
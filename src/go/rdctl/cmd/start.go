@@ -22,7 +22,10 @@ import (
 	"os/exec"
 	"runtime"
 	"strings"
+	"time"
 
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/client"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/config"
 	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/options/generated"
 	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/utils"
 	"github.com/sirupsen/logrus"
@@ -35,30 +38,50 @@ var startCmd = &cobra.Command{
 	Short: "Start up Rancher Desktop, or update its settings.",
 	Long: `Starts up Rancher Desktop with the specified settings.
 If it's running, behaves the same as 'rdctl set ...'.
+
+With --wait, the command returns once the container engine is running, and
+Kubernetes too if it is enabled, or fails if the backend reports an error or
+isn't ready within --timeout.
 `,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if err := cobra.NoArgs(cmd, args); err != nil {
 			return err
 		}
-		return doStartOrSetCommand(cmd)
+		if cmd.Flags().Changed("timeout") && !startWait {
+			return fmt.Errorf("--timeout requires --wait")
+		}
+		if err := doStartOrSetCommand(cmd); err != nil {
+			return err
+		}
+		if !startWait {
+			return nil
+		}
+		return waitForBackend(getBackendState, containerEngineReady, startTimeout, time.Second, 10*time.Second)
 	},
 }
 
 var applicationPath string
 var noModalDialogs bool
+var safeMode bool
+var startWait bool
+var startTimeout time.Duration
 
 func init() {
 	rootCmd.AddCommand(startCmd)
 	options.UpdateCommonStartAndSetCommands(startCmd)
+	registerSettingCompletions(startCmd)
 	startCmd.Flags().StringVarP(&applicationPath, "path", "p", "", "path to main executable")
 	startCmd.Flags().BoolVarP(&noModalDialogs, "no-modal-dialogs", "", false, "avoid displaying dialog boxes")
+	startCmd.Flags().BoolVar(&startWait, "wait", false, "wait until the container engine, and Kubernetes if enabled, is ready")
+	startCmd.Flags().DurationVar(&startTimeout, "timeout", 10*time.Minute, "how long to wait with --wait")
+	startCmd.Flags().BoolVar(&safeMode, "safe-mode", false, "start with the default settings, without provisioning scripts or extensions, keeping the current settings and data for the next start")
 }
 
 /**
  * If Rancher Desktop is currently running, treat this like a `set` command, and pass all the args to that.
  */
 func doStartOrSetCommand(cmd *cobra.Command) error {
-	_, err := getListSettings()
+	_, err := getListSettings(false)
 	if err == nil {
 		// Unavoidable race condition here.
 		// There's no system-wide mutex that will let us guarantee that if rancher desktop is running when
@@ -68,6 +91,9 @@ func doStartOrSetCommand(cmd *cobra.Command) error {
 			// `--path | -p` is not a valid option for `rdctl set...`
 			return fmt.Errorf("--path %q specified but Rancher Desktop is already running", applicationPath)
 		}
+		if safeMode {
+			return fmt.Errorf("--safe-mode specified but Rancher Desktop is already running; shut it down first")
+		}
 		return doSetCommand(cmd)
 	}
 	cmd.SilenceUsage = true
@@ -88,6 +114,9 @@ func doStartCommand(cmd *cobra.Command) error {
 	if noModalDialogs {
 		commandLineArgs = append(commandLineArgs, "--no-modal-dialogs")
 	}
+	if safeMode {
+		commandLineArgs = append(commandLineArgs, "--safe-mode")
+	}
 	return launchApp(applicationPath, commandLineArgs)
 }
 
@@ -114,3 +143,48 @@ func launchApp(applicationPath string, commandLineArgs []string) error {
 	cmd.Stderr = os.Stderr
 	return cmd.Start()
 }
+
+// getBackendState returns the state of the backend. The connection info is
+// read every time, as the application writes it once its API is up.
+func getBackendState() (client.BackendState, error) {
+	connectionInfo, err := config.GetConnectionInfo(false)
+	if err != nil {
+		return client.BackendState{}, fmt.Errorf("failed to get connection info: %w", err)
+	}
+	return client.NewRDClient(connectionInfo).GetBackendState()
+}
+
+// waitForBackend polls the state of the backend, at intervals doubling from
+// interval up to maxInterval, until the condition holds. Errors are taken to
+// mean that the application isn't up yet.
+func waitForBackend(getState func() (client.BackendState, error), ready backendCondition, timeout, interval, maxInterval time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	var lastErr error
+	lastState := ""
+	for {
+		state, err := getState()
+		if err != nil {
+			lastErr = err
+		} else {
+			if ok, err := ready(state); err != nil {
+				return err
+			} else if ok {
+				return nil
+			}
+			if description := state.Description(); description != lastState {
+				logrus.Infof("Waiting for the backend (state %s)...", description)
+				lastState = description
+			}
+			lastErr = nil
+		}
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			if lastErr != nil {
+				return fmt.Errorf("timed out waiting for the backend to be ready: %w", lastErr)
+			}
+			return fmt.Errorf("timed out waiting for the backend to be ready (state %s)", lastState)
+		}
+		time.Sleep(min(interval, remaining))
+		interval = min(2*interval, maxInterval)
+	}
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/client"
+	"github.com/stretchr/testify/assert"
+)
+
+// backendStates returns a function returning the given states in turn, and
+// then the last one; nil means the application isn't up yet.
+func backendStates(states ...any) func() (client.BackendState, error) {
+	return func() (client.BackendState, error) {
+		state := states[0]
+		if len(states) > 1 {
+			states = states[1:]
+		}
+		if state == nil {
+			return client.BackendState{}, client.ErrConnectionRefused
+		}
+		return client.BackendState{VMState: state.(string)}, nil
+	}
+}
+
+func TestWaitForBackend(t *testing.T) {
+	const interval, maxInterval = time.Millisecond, 4 * time.Millisecond
+	t.Run("kubernetes ready", func(t *testing.T) {
+		getState := backendStates(nil, nil, "STOPPED", "STARTING", "STARTED")
+		assert.NoError(t, waitForBackend(getState, containerEngineReady, time.Second, interval, maxInterval))
+	})
+	t.Run("kubernetes disabled", func(t *testing.T) {
+		getState := backendStates("STARTING", "DISABLED")
+		assert.NoError(t, waitForBackend(getState, containerEngineReady, time.Second, interval, maxInterval))
+	})
+	t.Run("error", func(t *testing.T) {
+		getState := backendStates("STARTING", "ERROR")
+		assert.ErrorContains(t, waitForBackend(getState, containerEngineReady, time.Second, interval, maxInterval), "failed to start")
+	})
+	t.Run("timeout", func(t *testing.T) {
+		err := waitForBackend(backendStates("STARTING"), containerEngineReady, 20*time.Millisecond, interval, maxInterval)
+		assert.ErrorContains(t, err, "timed out waiting for the backend to be ready (state STARTING)")
+		err = waitForBackend(backendStates(nil), containerEngineReady, 20*time.Millisecond, interval, maxInterval)
+		assert.ErrorIs(t, err, client.ErrConnectionRefused)
+	})
+}
@@ -0,0 +1,250 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/client"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/config"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/dashboard"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/exitcode"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/output"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+	"github.com/spf13/cobra"
+)
+
+var statusSettings struct {
+	Output string
+}
+
+type kubernetesStatus struct {
+	Enabled bool   `json:"enabled"`
+	Version string `json:"version,omitempty"`
+	// Ready is whether the Kubernetes API can be used; it is only ready once
+	// the backend has started.
+	Ready bool `json:"ready"`
+}
+
+// bootFailureReport is the name of the report that the application writes to
+// the logs directory when the backend fails to start.
+const bootFailureReport = "boot-failure.json"
+
+// statusLogLines is the number of lines shown from each log of the report.
+const statusLogLines = 10
+
+type bootFailureLog struct {
+	Path  string   `json:"path"`
+	Lines []string `json:"lines"`
+}
+
+// bootFailure describes why the backend failed to start: the step of the
+// start sequence that failed, and the end of the logs of the VM.
+type bootFailure struct {
+	Time    string           `json:"time"`
+	Backend string           `json:"backend"`
+	Step    string           `json:"step,omitempty"`
+	Error   string           `json:"error"`
+	Logs    []bootFailureLog `json:"logs"`
+	// Report is the path of the report.
+	Report string `json:"report"`
+}
+
+type statusResult struct {
+	// Running is whether the application could be reached.
+	Running         bool              `json:"running"`
+	VMState         string            `json:"vmState,omitempty"`
+	SubState        string            `json:"subState,omitempty"`
+	Locked          bool              `json:"locked"`
+	ContainerEngine string            `json:"containerEngine,omitempty"`
+	Kubernetes      *kubernetesStatus `json:"kubernetes,omitempty"`
+	Ports           []dashboard.Port  `json:"ports"`
+	PortsError      string            `json:"portsError,omitempty"`
+	BootFailure     *bootFailure      `json:"bootFailure,omitempty"`
+	Error           string            `json:"error,omitempty"`
+}
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the state of the backend",
+	Long: `Show the state of the backend of the running Rancher Desktop application: the
+state of the VM and, while it starts, the step it is at, the container engine, the version and readiness of Kubernetes,
+and the ports published by containers. If the backend failed to start, the step
+that failed and the end of the logs of the VM are shown too.
+
+The exit status is 0 if the backend is running, 2 if it is stopped or the
+application isn't running, and 1 if the backend failed or the state couldn't be
+determined. Use --output json, or a JSONPath or Go template, for scripting:
+
+  rdctl status --output jsonpath='{.kubernetes.ready}'`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		formatter, err := output.NewFormatter(statusSettings.Output, tableFormat, output.JSON)
+		if err != nil {
+			return err
+		}
+		cmd.SilenceUsage = true
+		result := getStatus()
+		if formatter.Format != tableFormat {
+			if err := formatter.Write(os.Stdout, result); err != nil {
+				return err
+			}
+		} else if err := writeStatus(result); err != nil {
+			return err
+		}
+		return statusError(result)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(statusCmd)
+	markReadOnly(statusCmd)
+	output.AddFlag(statusCmd.Flags(), &statusSettings.Output, tableFormat, output.JSON)
+}
+
+// getStatus queries the application; failing to reach it means it isn't
+// running.
+func getStatus() statusResult {
+	connectionInfo, err := config.GetConnectionInfo(true)
+	if err != nil || connectionInfo == nil {
+		return statusResult{Ports: []dashboard.Port{}}
+	}
+	appPaths, err := paths.GetPaths()
+	if err != nil {
+		return statusResult{Ports: []dashboard.Port{}, Error: fmt.Sprintf("failed to get paths: %s", err)}
+	}
+	board := dashboard.NewDashboard(client.NewRDClient(connectionInfo), appPaths)
+	result := newStatusResult(board.Refresh())
+	if result.VMState == "ERROR" {
+		if result.BootFailure, err = readBootFailure(appPaths.Logs); err != nil {
+			result.Error = err.Error()
+		}
+	}
+	return result
+}
+
+// readBootFailure returns the report of the last failure to start, if any.
+func readBootFailure(logsDir string) (*bootFailure, error) {
+	report := filepath.Join(logsDir, bootFailureReport)
+	contents, err := os.ReadFile(report)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read the report of the failure to start: %w", err)
+	}
+	result := &bootFailure{}
+	if err := json.Unmarshal(contents, result); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", report, err)
+	}
+	result.Report = report
+	return result, nil
+}
+
+func newStatusResult(status *dashboard.Status) statusResult {
+	result := statusResult{Ports: []dashboard.Port{}}
+	if status.Error != nil {
+		// Without an answer to the first request, the application isn't
+		// running; it's in trouble if only later ones fail.
+		if status.BackendState.VMState != "" {
+			result.Running = true
+			result.Error = status.Error.Error()
+		}
+		return result
+	}
+	result.Running = true
+	result.VMState = status.BackendState.VMState
+	result.SubState = status.BackendState.SubState
+	result.Locked = status.BackendState.Locked
+	if status.Settings != nil {
+		result.ContainerEngine = status.Settings.ContainerEngine.Name
+		k8s := status.Settings.Kubernetes
+		result.Kubernetes = &kubernetesStatus{Enabled: k8s.Enabled}
+		if k8s.Enabled {
+			result.Kubernetes.Version = k8s.Version
+			result.Kubernetes.Ready = result.VMState == "STARTED"
+		}
+	}
+	if status.Ports != nil {
+		result.Ports = status.Ports
+	}
+	if status.PortsError != nil {
+		result.PortsError = status.PortsError.Error()
+	}
+	return result
+}
+
+// statusError returns the error setting the exit status for the state.
+func statusError(result statusResult) error {
+	switch {
+	case !result.Running:
+		return config.ErrMainProcessNotRunning
+	case result.Error != "":
+		return errors.New(result.Error)
+	case result.VMState == "STOPPED":
+		return exitcode.WithCode(errors.New("the backend is stopped"), exitcode.NotRunning)
+	case result.VMState == "ERROR":
+		return errors.New("the backend failed to start")
+	}
+	return nil
+}
+
+func writeStatus(result statusResult) error {
+	if !result.Running {
+		// The exit status is enough; the error explains it.
+		return nil
+	}
+	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 4, ' ', 0)
+	locked := ""
+	if result.Locked {
+		locked = " (locked)"
+	}
+	state := client.BackendState{VMState: result.VMState, SubState: result.SubState}
+	fmt.Fprintf(writer, "Backend:\t%s%s\n", state.Description(), locked)
+	if result.ContainerEngine != "" {
+		fmt.Fprintf(writer, "Container engine:\t%s\n", result.ContainerEngine)
+	}
+	if k8s := result.Kubernetes; k8s != nil {
+		switch {
+		case !k8s.Enabled:
+			fmt.Fprintf(writer, "Kubernetes:\tdisabled\n")
+		case k8s.Ready:
+			fmt.Fprintf(writer, "Kubernetes:\t%s (ready)\n", k8s.Version)
+		default:
+			fmt.Fprintf(writer, "Kubernetes:\t%s (not ready)\n", k8s.Version)
+		}
+	}
+	if result.PortsError != "" {
+		fmt.Fprintf(writer, "Ports:\tunknown: %s\n", result.PortsError)
+	} else if len(result.Ports) > 0 {
+		fmt.Fprintf(writer, "\nNAME\tPORTS\n")
+		for _, port := range result.Ports {
+			fmt.Fprintf(writer, "%s\t%s\n", port.Name, port.Ports)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	if failure := result.BootFailure; failure != nil {
+		writeBootFailure(failure)
+	}
+	return nil
+}
+
+func writeBootFailure(failure *bootFailure) {
+	fmt.Printf("\nThe backend failed to start at %s", failure.Time)
+	if failure.Step != "" {
+		fmt.Printf(", while %q", failure.Step)
+	}
+	fmt.Printf(":\n  %s\n", failure.Error)
+	for _, log := range failure.Logs {
+		lines := log.Lines[max(len(log.Lines)-statusLogLines, 0):]
+		fmt.Printf("\nEnd of %s:\n", log.Path)
+		for _, line := range lines {
+			fmt.Printf("  %s\n", line)
+		}
+	}
+	fmt.Printf("\nThe full report is in %s.\n", failure.Report)
+}
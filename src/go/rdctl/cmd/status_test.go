@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/client"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/dashboard"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/exitcode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewStatusResult(t *testing.T) {
+	settings := &dashboard.Settings{}
+	settings.ContainerEngine.Name = "moby"
+	settings.Kubernetes.Enabled = true
+	settings.Kubernetes.Version = "1.29.1"
+
+	t.Run("started", func(t *testing.T) {
+		ports := []dashboard.Port{{Name: "web", Ports: "0.0.0.0:8080->80/tcp"}}
+		result := newStatusResult(&dashboard.Status{
+			BackendState: client.BackendState{VMState: "STARTED"},
+			Settings:     settings,
+			Ports:        ports,
+		})
+		assert.Equal(t, statusResult{
+			Running:         true,
+			VMState:         "STARTED",
+			ContainerEngine: "moby",
+			Kubernetes:      &kubernetesStatus{Enabled: true, Version: "1.29.1", Ready: true},
+			Ports:           ports,
+		}, result)
+		assert.NoError(t, statusError(result))
+	})
+	t.Run("starting", func(t *testing.T) {
+		result := newStatusResult(&dashboard.Status{
+			BackendState: client.BackendState{VMState: "STARTING", SubState: "engine-starting", Locked: true},
+			Settings:     settings,
+		})
+		assert.Equal(t, "engine-starting", result.SubState)
+		assert.False(t, result.Kubernetes.Ready)
+		assert.True(t, result.Locked)
+		assert.NoError(t, statusError(result))
+	})
+	t.Run("stopped", func(t *testing.T) {
+		result := newStatusResult(&dashboard.Status{
+			BackendState: client.BackendState{VMState: "STOPPED"},
+			Settings:     settings,
+		})
+		assert.Equal(t, exitcode.NotRunning, exitcode.FromError(statusError(result)))
+	})
+	t.Run("failed", func(t *testing.T) {
+		result := newStatusResult(&dashboard.Status{
+			BackendState: client.BackendState{VMState: "ERROR"},
+			Settings:     settings,
+		})
+		assert.Equal(t, exitcode.Failure, exitcode.FromError(statusError(result)))
+	})
+	t.Run("application not running", func(t *testing.T) {
+		result := newStatusResult(&dashboard.Status{Error: errors.New("connection refused")})
+		assert.False(t, result.Running)
+		assert.Equal(t, exitcode.NotRunning, exitcode.FromError(statusError(result)))
+	})
+	t.Run("settings unavailable", func(t *testing.T) {
+		result := newStatusResult(&dashboard.Status{
+			BackendState: client.BackendState{VMState: "STARTED"},
+			Error:        errors.New("failed to unmarshal settings"),
+		})
+		assert.True(t, result.Running)
+		assert.EqualError(t, statusError(result), "failed to unmarshal settings")
+	})
+}
+
+func TestReadBootFailure(t *testing.T) {
+	dir := t.TempDir()
+	failure, err := readBootFailure(dir)
+	require.NoError(t, err)
+	assert.Nil(t, failure)
+
+	report := filepath.Join(dir, bootFailureReport)
+	contents := `{"time": "2024-05-01T10:00:00.000Z", "backend": "lima", "step": "Starting virtual machine",
+		"error": "exit status 1", "logs": [{"path": "/logs/serial.log", "lines": ["kernel panic"]}]}`
+	require.NoError(t, os.WriteFile(report, []byte(contents), 0o600))
+	failure, err = readBootFailure(dir)
+	require.NoError(t, err)
+	assert.Equal(t, &bootFailure{
+		Time:    "2024-05-01T10:00:00.000Z",
+		Backend: "lima",
+		Step:    "Starting virtual machine",
+		Error:   "exit status 1",
+		Logs:    []bootFailureLog{{Path: "/logs/serial.log", Lines: []string{"kernel panic"}}},
+		Report:  report,
+	}, failure)
+
+	require.NoError(t, os.WriteFile(report, []byte("{"), 0o600))
+	_, err = readBootFailure(dir)
+	assert.ErrorContains(t, err, "failed to parse")
+}
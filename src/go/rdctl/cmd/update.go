@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/client"
+	"github.com/spf13/cobra"
+)
+
+// updatePollInterval is how often the progress of a download is polled.
+const updatePollInterval = time.Second
+
+var updateSettings struct {
+	Channel string
+	Output  string
+}
+
+// updateStatus is the state of the updater, as returned by the API.
+type updateStatus struct {
+	// Configured is whether this installation can be updated.
+	Configured bool `json:"configured"`
+	// Channel is the release channel of the checks; empty for any release.
+	Channel     string `json:"channel"`
+	Available   bool   `json:"available"`
+	Downloading bool   `json:"downloading"`
+	Downloaded  bool   `json:"downloaded"`
+	// Version is the latest release found by the last check.
+	Version     string `json:"version,omitempty"`
+	ReleaseDate string `json:"releaseDate,omitempty"`
+	// UnsupportedUpdateAvailable is whether a newer release exists, which
+	// isn't supported on this host.
+	UnsupportedUpdateAvailable bool `json:"unsupportedUpdateAvailable,omitempty"`
+	Progress                   *struct {
+		Percent        float64 `json:"percent"`
+		Transferred    int64   `json:"transferred"`
+		Total          int64   `json:"total"`
+		BytesPerSecond int64   `json:"bytesPerSecond"`
+	} `json:"progress,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+var updateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Update Rancher Desktop",
+	Long: `Check for, download and install updates of the running Rancher Desktop
+application, as it does on its own, without using its window; this is meant for
+hosts managed without the GUI, e.g. kiosks or CI runners.
+
+Use --channel to pick the release channel, e.g. --channel beta; the channel is
+kept for the later checks of the application, and an empty channel means any
+release.`,
+}
+
+func init() {
+	rootCmd.AddCommand(updateCmd)
+}
+
+// addUpdateChannelFlag registers the --channel flag of the update commands.
+func addUpdateChannelFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&updateSettings.Channel, "channel", "", "the release channel to check for updates (default: the channel of the last check)")
+}
+
+// updateRequest sends a request to the updater; the errors it reports, e.g.
+// that no update is available, are returned as errors.
+func updateRequest(rdClient client.RDClient, method, command string) ([]byte, error) {
+	result, errorPacket, err := client.ProcessRequestForAPI(rdClient.DoRequest(method, client.VersionCommand("", command)))
+	if err != nil {
+		// Report that the application isn't running as the other commands do.
+		return client.ProcessRequestForUtility(nil, err)
+	}
+	if errorPacket != nil {
+		if message := strings.TrimSpace(string(result)); message != "" {
+			return nil, errors.New(message)
+		}
+		return nil, errors.New(*errorPacket.Message)
+	}
+	return result, nil
+}
+
+func updateStatusRequest(rdClient client.RDClient, method, command string) (*updateStatus, error) {
+	body, err := updateRequest(rdClient, method, command)
+	if err != nil {
+		return nil, err
+	}
+	status := &updateStatus{}
+	if err := json.Unmarshal(body, status); err != nil {
+		return nil, fmt.Errorf("failed to parse the state of the updater: %w", err)
+	}
+	return status, nil
+}
+
+func getUpdateStatus(rdClient client.RDClient) (*updateStatus, error) {
+	return updateStatusRequest(rdClient, "GET", "update")
+}
+
+// checkForUpdates asks the application to check for updates in the channel;
+// the error of a failed check is returned.
+func checkForUpdates(rdClient client.RDClient, channel string) (*updateStatus, error) {
+	status, err := updateStatusRequest(rdClient, "POST", "update/check?"+url.Values{"channel": {channel}}.Encode())
+	if err != nil {
+		return nil, err
+	}
+	if status.Error != "" {
+		return status, fmt.Errorf("failed to check for updates: %s", status.Error)
+	}
+	return status, nil
+}
+
+// findUpdate checks for updates, unless an update is being downloaded, or
+// has been downloaded and no other channel is given; it returns the state of
+// the updater.
+func findUpdate(cmd *cobra.Command, rdClient client.RDClient) (*updateStatus, error) {
+	status, err := getUpdateStatus(rdClient)
+	if err != nil {
+		return nil, err
+	}
+	channelChanged := cmd.Flags().Changed("channel") && updateSettings.Channel != status.Channel
+	if status.Downloading || (status.Downloaded && !channelChanged) {
+		return status, nil
+	}
+	channel := status.Channel
+	if cmd.Flags().Changed("channel") {
+		channel = updateSettings.Channel
+	}
+	return checkForUpdates(rdClient, channel)
+}
+
+// waitForDownload polls the state of the updater until the update has been
+// downloaded; report is called with the progress of the download.
+func waitForDownload(ctx context.Context, rdClient client.RDClient, interval time.Duration, report func(status *updateStatus)) (*updateStatus, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		status, err := getUpdateStatus(rdClient)
+		switch {
+		case err != nil:
+			return nil, err
+		case status.Downloaded:
+			return status, nil
+		case status.Error != "":
+			return nil, fmt.Errorf("failed to download the update: %s", status.Error)
+		case !status.Downloading:
+			return nil, errors.New("the download of the update stopped")
+		}
+		report(status)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// downloadMessage describes the download of the update.
+func downloadMessage(status *updateStatus) string {
+	message := fmt.Sprintf("Downloading Rancher Desktop %s", status.Version)
+	if status.Progress != nil {
+		message += fmt.Sprintf(" (%d%%)", int(status.Progress.Percent))
+	}
+	return message
+}
+
+// releaseName describes the update, e.g. "1.12.0 (released 2024-01-10)".
+func releaseName(status *updateStatus) string {
+	if status.ReleaseDate == "" {
+		return status.Version
+	}
+	return fmt.Sprintf("%s (released %s)", status.Version, status.ReleaseDate)
+}
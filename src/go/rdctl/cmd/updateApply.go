@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/client"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+var updateApplyCmd = &cobra.Command{
+	Use:   "apply [--channel CHANNEL]",
+	Short: "Install the update of Rancher Desktop",
+	Long: `Download the available update, as "rdctl update download" does, unless it has
+been downloaded already, and install it. Rancher Desktop quits to install the
+update, and restarts once it is installed.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		connectionInfo, err := config.GetConnectionInfo(false)
+		if err != nil {
+			return fmt.Errorf("failed to get connection info: %w", err)
+		}
+		rdClient := client.NewRDClient(connectionInfo)
+		status, err := downloadAvailableUpdate(cmd, rdClient)
+		if err != nil {
+			return err
+		}
+		if !status.Available {
+			fmt.Println("Rancher Desktop is up to date.")
+			return nil
+		}
+		result, err := updateRequest(rdClient, "POST", "update/apply")
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Rancher Desktop %s: %s\n", status.Version, result)
+		return nil
+	},
+}
+
+func init() {
+	updateCmd.AddCommand(updateApplyCmd)
+	addUpdateChannelFlag(updateApplyCmd)
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/client"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/config"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/output"
+	"github.com/spf13/cobra"
+)
+
+var updateCheckCmd = &cobra.Command{
+	Use:   "check [--channel CHANNEL]",
+	Short: "Check for updates of Rancher Desktop",
+	Long: `Ask the running Rancher Desktop application to check its release channel for
+updates now. As with the checks it runs on its own, an available update starts
+downloading if automatic updates are enabled.
+
+Use --output json, or a JSONPath or Go template, for scripting:
+
+  rdctl update check --output jsonpath='{.available}'`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		formatter, err := output.NewFormatter(updateSettings.Output, tableFormat, output.JSON)
+		if err != nil {
+			return err
+		}
+		cmd.SilenceUsage = true
+		connectionInfo, err := config.GetConnectionInfo(false)
+		if err != nil {
+			return fmt.Errorf("failed to get connection info: %w", err)
+		}
+		rdClient := client.NewRDClient(connectionInfo)
+		status, err := getUpdateStatus(rdClient)
+		if err != nil {
+			return err
+		}
+		// The application doesn't check again while it downloads the update.
+		if !status.Downloading {
+			channel := status.Channel
+			if cmd.Flags().Changed("channel") {
+				channel = updateSettings.Channel
+			}
+			status, err = checkForUpdates(rdClient, channel)
+			if status == nil {
+				return err
+			}
+		}
+		// The state is written even if the check failed, for its error.
+		if formatter.Format != tableFormat {
+			if writeErr := formatter.Write(os.Stdout, status); writeErr != nil {
+				return writeErr
+			}
+			return err
+		}
+		if err != nil {
+			return err
+		}
+		return writeUpdateStatus(status)
+	},
+}
+
+func init() {
+	updateCmd.AddCommand(updateCheckCmd)
+	addUpdateChannelFlag(updateCheckCmd)
+	output.AddFlag(updateCheckCmd.Flags(), &updateSettings.Output, tableFormat, output.JSON)
+}
+
+func writeUpdateStatus(status *updateStatus) error {
+	var err error
+	switch {
+	case !status.Available:
+		_, err = fmt.Println("Rancher Desktop is up to date.")
+	case status.Downloaded:
+		_, err = fmt.Printf("Rancher Desktop %s has been downloaded; run `rdctl update apply` to install it.\n", releaseName(status))
+	case status.Downloading:
+		_, err = fmt.Printf("Rancher Desktop %s is being downloaded.\n", releaseName(status))
+	default:
+		_, err = fmt.Printf("Rancher Desktop %s is available; run `rdctl update download` to download it.\n", releaseName(status))
+	}
+	if err == nil && status.UnsupportedUpdateAvailable {
+		_, err = fmt.Println("A newer release exists, but isn't supported on this host.")
+	}
+	return err
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/client"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/config"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/output"
+	"github.com/spf13/cobra"
+)
+
+var updateDownloadCmd = &cobra.Command{
+	Use:   "download [--channel CHANNEL]",
+	Short: "Download the update of Rancher Desktop",
+	Long: `Check for updates, unless the update has been downloaded already, and download
+the available update, showing the progress until the download finishes.
+Interrupting the command doesn't stop the download; the application keeps
+downloading the update.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		connectionInfo, err := config.GetConnectionInfo(false)
+		if err != nil {
+			return fmt.Errorf("failed to get connection info: %w", err)
+		}
+		status, err := downloadAvailableUpdate(cmd, client.NewRDClient(connectionInfo))
+		if err != nil {
+			return err
+		}
+		if !status.Available {
+			fmt.Println("Rancher Desktop is up to date.")
+			return nil
+		}
+		return writeUpdateStatus(status)
+	},
+}
+
+func init() {
+	updateCmd.AddCommand(updateDownloadCmd)
+	addUpdateChannelFlag(updateDownloadCmd)
+}
+
+// downloadAvailableUpdate finds the update, and downloads it if there is
+// one; it returns the state of the updater once the update is downloaded.
+func downloadAvailableUpdate(cmd *cobra.Command, rdClient client.RDClient) (*updateStatus, error) {
+	status, err := findUpdate(cmd, rdClient)
+	if err != nil || !status.Available || status.Downloaded {
+		return status, err
+	}
+	if !status.Downloading {
+		status, err = updateStatusRequest(rdClient, "POST", "update/download")
+		if err != nil || status.Downloaded {
+			return status, err
+		}
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	progress := output.StartProgress(downloadMessage(status))
+	status, err = waitForDownload(ctx, rdClient, updatePollInterval, func(status *updateStatus) {
+		progress.SetMessage(downloadMessage(status))
+	})
+	progress.Stop()
+	if errors.Is(err, context.Canceled) {
+		return nil, errors.New("stopped waiting; the application keeps downloading the update")
+	}
+	return status, err
+}
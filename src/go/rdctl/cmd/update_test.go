@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/client"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeUpdateClient serves the given states of the updater in turn, and
+// records the other requests.
+type fakeUpdateClient struct {
+	states   []string
+	requests []string
+}
+
+func (c *fakeUpdateClient) DoRequest(method string, command string) (*http.Response, error) {
+	respond := func(status int, body string) (*http.Response, error) {
+		return &http.Response{StatusCode: status, Status: http.StatusText(status), Body: io.NopCloser(strings.NewReader(body))}, nil
+	}
+	if method == "GET" && command == client.VersionCommand("", "update") {
+		state := c.states[0]
+		if len(c.states) > 1 {
+			c.states = c.states[1:]
+		}
+		return respond(http.StatusOK, state)
+	}
+	c.requests = append(c.requests, method+" "+command)
+	switch command {
+	case client.VersionCommand("", "update/check?channel=beta"):
+		return respond(http.StatusOK, `{"configured": true, "channel": "beta", "available": true, "version": "1.13.0-beta.1"}`)
+	case client.VersionCommand("", "update/download"):
+		return respond(http.StatusConflict, "No update is available")
+	}
+	return nil, errors.New("unexpected request")
+}
+
+func (c *fakeUpdateClient) DoRequestWithPayload(method string, command string, payload io.Reader) (*http.Response, error) {
+	return nil, errors.New("unexpected request")
+}
+
+func (c *fakeUpdateClient) GetBackendState() (client.BackendState, error) {
+	return client.BackendState{}, errors.New("unexpected request")
+}
+
+func (c *fakeUpdateClient) UpdateBackendState(state client.BackendState) error {
+	return errors.New("unexpected request")
+}
+
+func TestUpdateRequestError(t *testing.T) {
+	rdClient := &fakeUpdateClient{}
+	_, err := updateRequest(rdClient, "POST", "update/download")
+	assert.EqualError(t, err, "No update is available")
+}
+
+func TestFindUpdate(t *testing.T) {
+	newCommand := func() *cobra.Command {
+		cmd := &cobra.Command{}
+		addUpdateChannelFlag(cmd)
+		return cmd
+	}
+	downloaded := `{"configured": true, "channel": "", "available": true, "downloaded": true, "version": "1.12.0"}`
+
+	// The downloaded update isn't checked again.
+	rdClient := &fakeUpdateClient{states: []string{downloaded}}
+	status, err := findUpdate(newCommand(), rdClient)
+	require.NoError(t, err)
+	assert.Equal(t, "1.12.0", status.Version)
+	assert.Empty(t, rdClient.requests)
+
+	// Unless another channel is given.
+	cmd := newCommand()
+	require.NoError(t, cmd.Flags().Set("channel", "beta"))
+	status, err = findUpdate(cmd, rdClient)
+	require.NoError(t, err)
+	assert.Equal(t, "1.13.0-beta.1", status.Version)
+	assert.Equal(t, []string{"POST v1/update/check?channel=beta"}, rdClient.requests)
+}
+
+func TestWaitForDownload(t *testing.T) {
+	rdClient := &fakeUpdateClient{states: []string{
+		`{"available": true, "downloading": true, "version": "1.12.0", "progress": {"percent": 12.5}}`,
+		`{"available": true, "downloading": true, "version": "1.12.0", "progress": {"percent": 87.5}}`,
+		`{"available": true, "downloaded": true, "version": "1.12.0"}`,
+	}}
+	var messages []string
+	status, err := waitForDownload(context.Background(), rdClient, time.Millisecond, func(status *updateStatus) {
+		messages = append(messages, downloadMessage(status))
+	})
+	require.NoError(t, err)
+	assert.True(t, status.Downloaded)
+	assert.Equal(t, []string{"Downloading Rancher Desktop 1.12.0 (12%)", "Downloading Rancher Desktop 1.12.0 (87%)"}, messages)
+
+	rdClient = &fakeUpdateClient{states: []string{`{"available": true, "error": "net::ERR_CONNECTION_RESET"}`}}
+	_, err = waitForDownload(context.Background(), rdClient, time.Millisecond, func(*updateStatus) {})
+	assert.EqualError(t, err, "failed to download the update: net::ERR_CONNECTION_RESET")
+}
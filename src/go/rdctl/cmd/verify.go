@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/checks"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/client"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/config"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/devcontainer"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/exitcode"
+	options "github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/options/generated"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/output"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/testcontainers"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/vm"
+	"github.com/spf13/cobra"
+)
+
+var verifySettings struct {
+	Testcontainers bool
+	ApplyPreset    bool
+	Env            bool
+	FailFast       bool
+	Output         string
+}
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Check that tools can use Rancher Desktop",
+	Long: `Check that tools can use Rancher Desktop.
+
+With --testcontainers, check what Testcontainers libraries need: the moby
+container engine, a DOCKER_HOST pointing at it, and the socket and host
+address overrides. --apply-preset selects the moby engine, and --env prints the
+environment variables to set, for example:
+  eval "$(rdctl verify --testcontainers --env)"
+
+All checks are run even if some fail, unless --fail-fast is given; a failure
+to apply the preset is reported, and the checks are still run. The exit status
+is 0 if no check failed, 3 if some checks failed and others passed, and 1
+otherwise.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !verifySettings.Testcontainers {
+			return errors.New("nothing to verify; specify --testcontainers")
+		}
+		formatter, err := output.NewFormatter(verifySettings.Output, tableFormat, output.JSON)
+		if err != nil {
+			return err
+		}
+		cmd.SilenceUsage = true
+		connectionInfo, err := config.GetConnectionInfo(false)
+		if err != nil {
+			return fmt.Errorf("failed to get connection info: %w", err)
+		}
+		rdClient := client.NewRDClient(connectionInfo)
+		var applyErr error
+		if verifySettings.ApplyPreset {
+			if applyErr = applyTestcontainersPreset(rdClient); applyErr != nil {
+				if verifySettings.FailFast || verifySettings.Env {
+					return applyErr
+				}
+				fmt.Fprintf(os.Stderr, "Failed to apply the Testcontainers preset: %s\n", applyErr)
+			}
+		}
+		verifier, err := newTestcontainersVerifier(rdClient)
+		if err != nil {
+			return err
+		}
+		verifier.FailFast = verifySettings.FailFast
+		if verifySettings.Env {
+			environment := verifier.Environment()
+			if formatter.Format != tableFormat {
+				return formatter.Write(os.Stdout, environment)
+			}
+			for _, variable := range environment {
+				if runtime.GOOS == "windows" {
+					fmt.Printf("$Env:%s = \"%s\"\n", variable.Name, variable.Value)
+				} else {
+					fmt.Printf("export %s=%s\n", variable.Name, variable.Value)
+				}
+			}
+			return nil
+		}
+		results := verifier.Run()
+		if formatter.Format != tableFormat {
+			err = formatter.Write(os.Stdout, results)
+		} else {
+			err = checks.WriteTable(os.Stdout, results)
+		}
+		if err != nil {
+			return err
+		}
+		if err := checks.Err(results); err != nil {
+			return err
+		}
+		if applyErr != nil {
+			// The checks passed, so only applying the preset failed.
+			return exitcode.WithCode(applyErr, exitcode.PartialSuccess)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(verifyCmd)
+	verifyCmd.Flags().BoolVar(&verifySettings.Testcontainers, "testcontainers", false, "check the configuration for Testcontainers")
+	verifyCmd.Flags().BoolVar(&verifySettings.ApplyPreset, "apply-preset", false, "change the settings Testcontainers needs before checking")
+	verifyCmd.Flags().BoolVar(&verifySettings.Env, "env", false, "print the environment variables Testcontainers needs instead of checking")
+	verifyCmd.Flags().BoolVar(&verifySettings.FailFast, "fail-fast", false, "stop at the first failure, instead of running all checks")
+	output.AddFlag(verifyCmd.Flags(), &verifySettings.Output, tableFormat, output.JSON)
+}
+
+// applyTestcontainersPreset changes the settings Testcontainers needs.
+func applyTestcontainersPreset(rdClient client.RDClient) error {
+	payload := map[string]any{"version": options.CURRENT_SETTINGS_VERSION}
+	for key, value := range testcontainers.Preset {
+		payload[key] = value
+	}
+	jsonBuffer, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	response, err := rdClient.DoRequestWithPayload("PUT", client.VersionCommand("", "settings"), bytes.NewBuffer(jsonBuffer))
+	result, err := client.ProcessRequestForUtility(response, err)
+	if err != nil {
+		return err
+	}
+	invalidateCachedSettings()
+	if len(result) > 0 {
+		fmt.Fprintf(os.Stderr, "Status: %s.\n", string(result))
+	}
+	return nil
+}
+
+// newTestcontainersVerifier gathers what the checks need from the application
+// and the host.
+func newTestcontainersVerifier(rdClient client.RDClient) (*testcontainers.Verifier, error) {
+	engineName, err := getContainerEngineName(rdClient)
+	if err != nil {
+		return nil, err
+	}
+	appPaths, err := paths.GetPaths()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get paths: %w", err)
+	}
+	verifier := &testcontainers.Verifier{
+		EngineName: engineName,
+		DockerHost: devcontainer.DockerHost(appPaths),
+		Getenv:     os.Getenv,
+	}
+	// On Windows, docker uses the named pipe of Rancher Desktop by default,
+	// and published ports are reachable on localhost.
+	if runtime.GOOS != "windows" {
+		verifier.DefaultDockerHost = "unix://" + testcontainers.VMDockerSocket
+		if verifier.VM, err = vm.New(appPaths); err != nil {
+			return nil, err
+		}
+	}
+	return verifier, nil
+}
@@ -17,22 +17,228 @@ limitations under the License.
 package cmd
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/apicache"
 	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/client"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/config"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/output"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/version"
 	"github.com/spf13/cobra"
 )
 
+// upgradeCheckTimeout limits the time to ask the release channel for updates.
+const upgradeCheckTimeout = 30 * time.Second
+
+// versionCacheMaxAge is how long the version of the application is cached.
+const versionCacheMaxAge = 30 * time.Second
+
+var versionSettings struct {
+	JSON   bool
+	Output string
+	Check  bool
+}
+
+// versionInfo is the output of `rdctl version --output json`.
+type versionInfo struct {
+	version.Info
+	// ClientVersion and APIVersion are the versions of the API client, and
+	// of the API it uses.
+	ClientVersion string `json:"clientVersion"`
+	APIVersion    string `json:"apiVersion"`
+	// Backend describes the running application.
+	Backend backendVersion `json:"backend"`
+	// Skew is whether rdctl and the application come from different releases.
+	Skew bool `json:"skew"`
+	// Upgrade is the result of --check.
+	Upgrade *upgradeInfo `json:"upgrade,omitempty"`
+}
+
+type backendVersion struct {
+	// Running is whether the application could be reached.
+	Running bool   `json:"running"`
+	Version string `json:"version,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+type upgradeInfo struct {
+	// Current is the version checked for updates: the one of the
+	// application if it is running, or else the one of rdctl.
+	Current string `json:"current"`
+	*version.Upgrade
+	Error string `json:"error,omitempty"`
+}
+
 // showVersionCmd represents the showVersion command
 var showVersionCmd = &cobra.Command{
 	Use:   "version",
 	Short: "Shows the CLI version.",
-	Long:  `Shows the CLI version.`,
+	Long: `Shows the CLI version, and the build it comes from. If Rancher Desktop is
+running, its version is shown too, with a warning if rdctl comes from another
+release, e.g. because another rdctl comes first in the PATH.
+
+With --check, the release channel of Rancher Desktop is asked whether a newer
+release is available for this host, as the application does when it checks for
+updates.
+
+Use --output json, or a JSONPath or Go template, for scripting; --json is the
+same as --output json:
+
+  rdctl version --check --output jsonpath='{.upgrade.available}'`,
+	Args: cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		_, err := fmt.Printf("rdctl client version: %s, targeting server version: %s\n", client.Version, client.ApiVersion)
+		if versionSettings.JSON && !cmd.Flags().Changed("output") {
+			versionSettings.Output = output.JSON
+		}
+		formatter, err := output.NewFormatter(versionSettings.Output, tableFormat, output.JSON)
+		if err != nil {
+			return err
+		}
+		cmd.SilenceUsage = true
+		result := versionInfo{
+			Info:          version.Get(),
+			ClientVersion: client.Version,
+			APIVersion:    client.ApiVersion,
+			Backend:       getBackendVersion(),
+		}
+		result.Skew = isSkewed(result.Version, result.Backend.Version)
+		if versionSettings.Check {
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer stop()
+			ctx, cancel := context.WithTimeout(ctx, upgradeCheckTimeout)
+			defer cancel()
+			result.Upgrade = checkUpgrade(ctx, version.UpgradeURL, result)
+		}
+		if result.Skew {
+			fmt.Fprintln(os.Stderr, output.Colorize(os.Stderr, output.Yellow, fmt.Sprintf(
+				"Warning: rdctl %s doesn't come from the same release as Rancher Desktop %s; use the rdctl installed with the application.",
+				result.Version, result.Backend.Version)))
+		}
+		if formatter.Format != tableFormat {
+			err = formatter.Write(os.Stdout, result)
+		} else {
+			err = writeVersion(result)
+		}
+		if err == nil && result.Upgrade != nil && result.Upgrade.Error != "" {
+			err = errors.New(result.Upgrade.Error)
+		}
 		return err
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(showVersionCmd)
+	output.AddFlag(showVersionCmd.Flags(), &versionSettings.Output, tableFormat, output.JSON)
+	showVersionCmd.Flags().BoolVar(&versionSettings.JSON, "json", false, "write the version information as JSON")
+	showVersionCmd.Flags().BoolVar(&versionSettings.Check, "check", false, "check the release channel for a newer release")
+	markReadOnly(showVersionCmd)
+}
+
+// getBackendVersion asks the application for its version, through the cache
+// of API responses; failing to reach it means it isn't running.
+func getBackendVersion() backendVersion {
+	connectionInfo, err := config.GetConnectionInfo(true)
+	if err != nil {
+		return backendVersion{Error: err.Error()}
+	} else if connectionInfo == nil {
+		return backendVersion{}
+	}
+	cache, err := newAPICache(connectionInfo)
+	if err != nil {
+		return backendVersion{Error: err.Error()}
+	}
+	// The application restarts when it is updated, so its version is only
+	// cached briefly, as the status of prompt-info is.
+	cache.MaxAge = versionCacheMaxAge
+	body, err := cache.Get(apicache.Version)
+	switch {
+	case client.IsConnectionRefused(err):
+		return backendVersion{}
+	case errors.Is(err, apicache.ErrNotFound):
+		return backendVersion{Running: true, Error: "the application is too old to report its version"}
+	case err != nil:
+		return backendVersion{Running: true, Error: err.Error()}
+	}
+	var result backendVersion
+	if err := json.Unmarshal(body, &result); err != nil {
+		return backendVersion{Running: true, Error: fmt.Sprintf("failed to parse the version: %s", err)}
+	}
+	result.Running = true
+	return result
+}
+
+// isSkewed returns whether rdctl and the application come from different
+// releases; development builds and unknown versions aren't compared.
+func isSkewed(clientVersion, backendVersion string) bool {
+	result, err := version.Compare(clientVersion, backendVersion)
+	return err == nil && result != 0
+}
+
+// checkUpgrade checks the release channel for a release newer than the
+// application, or than rdctl if the application isn't running.
+func checkUpgrade(ctx context.Context, url string, info versionInfo) *upgradeInfo {
+	result := &upgradeInfo{Current: info.Backend.Version}
+	if result.Current == "" {
+		result.Current = info.Version
+	}
+	upgrade, err := version.CheckUpgrade(ctx, url, result.Current)
+	if err != nil {
+		result.Error = fmt.Sprintf("failed to check for updates: %s", err)
+	}
+	result.Upgrade = upgrade
+	return result
+}
+
+func writeVersion(info versionInfo) error {
+	if _, err := fmt.Printf("rdctl client version: %s, targeting server version: %s\n", info.ClientVersion, info.APIVersion); err != nil {
+		return err
+	}
+	build := fmt.Sprintf("build: %s", info.Version)
+	if info.Commit != "" {
+		build += fmt.Sprintf(", commit %s", info.Commit)
+	}
+	if info.BuildDate != "" {
+		build += fmt.Sprintf(", built %s", info.BuildDate)
+	}
+	if _, err := fmt.Printf("%s (%s, %s)\n", build, info.GoVersion, info.Platform); err != nil {
+		return err
+	}
+	backend := "not running"
+	switch {
+	case info.Backend.Error != "":
+		backend = fmt.Sprintf("unknown (%s)", info.Backend.Error)
+	case info.Backend.Version != "":
+		backend = info.Backend.Version
+	}
+	if _, err := fmt.Printf("Rancher Desktop version: %s\n", backend); err != nil {
+		return err
+	}
+	return writeUpgrade(info.Upgrade)
+}
+
+func writeUpgrade(upgrade *upgradeInfo) error {
+	var err error
+	switch {
+	case upgrade == nil || upgrade.Error != "":
+		// The error sets the exit status.
+		return nil
+	case upgrade.Available:
+		released := ""
+		if upgrade.Latest.ReleaseDate != "" {
+			released = fmt.Sprintf(" (released %s)", upgrade.Latest.ReleaseDate)
+		}
+		_, err = fmt.Printf("Update available: %s%s; the current version is %s\n", upgrade.Latest.Name, released, upgrade.Current)
+	default:
+		_, err = fmt.Printf("%s is the latest release.\n", upgrade.Current)
+	}
+	if err == nil && upgrade.UnsupportedAvailable {
+		_, err = fmt.Println("A newer release exists, but isn't supported on this host.")
+	}
+	return err
 }
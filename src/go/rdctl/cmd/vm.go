@@ -0,0 +1,16 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var vmCmd = &cobra.Command{
+	Use:   "vm",
+	Short: "Manage the virtual machine",
+	Long: `Manage the virtual machine running the container engine and Kubernetes: the
+Lima VM on macOS and Linux, or the WSL distributions on Windows.`,
+}
+
+func init() {
+	rootCmd.AddCommand(vmCmd)
+}
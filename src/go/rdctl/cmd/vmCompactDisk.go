@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/output"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/vm"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/vmdisk"
+	"github.com/spf13/cobra"
+)
+
+var vmCompactDiskSettings struct {
+	Output string
+}
+
+var vmCompactDiskCmd = &cobra.Command{
+	Use:   "compact-disk",
+	Short: "Reclaim the space of the disks of the VM",
+	Long: `Reclaim the host disk space that the VM no longer uses; its disks otherwise
+only ever grow.
+
+While Rancher Desktop is running, the file systems of the VM are trimmed, which
+releases freed blocks to the disk images where the platform supports it. Once
+Rancher Desktop is shut down, the disk images themselves are compacted; on
+Windows, this shuts down WSL, stopping all WSL distributions, so that it
+releases the disk images, and prompts for administrative access.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		formatter, err := output.NewFormatter(vmCompactDiskSettings.Output, tableFormat, output.JSON)
+		if err != nil {
+			return err
+		}
+		cmd.SilenceUsage = true
+		appPaths, err := paths.GetPaths()
+		if err != nil {
+			return fmt.Errorf("failed to get paths: %w", err)
+		}
+		runner, err := vm.New(appPaths)
+		if err != nil {
+			return err
+		}
+		result, err := vmdisk.Compact(appPaths, runner)
+		if err != nil {
+			return err
+		}
+		if formatter.Format != tableFormat {
+			return formatter.Write(os.Stdout, result)
+		}
+		writer := tabwriter.NewWriter(os.Stdout, 0, 4, 4, ' ', 0)
+		fmt.Fprintf(writer, "DISK\tBEFORE\tAFTER\tRECLAIMED\n")
+		for _, disk := range result.Disks {
+			fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", disk.Path, formatUsage(disk.Before), formatUsage(disk.After), formatUsage(max(disk.Reclaimed(), 0)))
+		}
+		if err := writer.Flush(); err != nil {
+			return err
+		}
+		if result.Trimmed {
+			output.Infof("Trimmed the file systems of the running VM; shut down Rancher Desktop and run this again to compact the disk images.")
+		}
+		return nil
+	},
+}
+
+func init() {
+	vmCmd.AddCommand(vmCompactDiskCmd)
+	output.AddFlag(vmCompactDiskCmd.Flags(), &vmCompactDiskSettings.Output, tableFormat, output.JSON)
+}
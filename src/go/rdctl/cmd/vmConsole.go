@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/console"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var vmConsoleSettings struct {
+	Display bool
+}
+
+var vmConsoleCmd = &cobra.Command{
+	Use:   "console",
+	Short: "Attach to the serial console of the VM",
+	Long: `Attach to the serial console of the VM, to debug a guest that doesn't boot far
+enough to open a shell; press Ctrl-] to detach. When the VM has no interactive
+serial console, as with the VZ emulation, its output is shown until Ctrl-C is
+pressed.
+
+Use --display to open the graphical console in a VNC viewer instead; it requires
+the VM to be started with a VNC display, by setting video.display to vnc in the
+Lima override file.
+
+The consoles of the WSL distributions on Windows aren't accessible; run
+"wsl --debug-shell" from an administrator prompt instead.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if runtime.GOOS == "windows" {
+			return errors.New(`the VM console is not supported on Windows; run "wsl --debug-shell" from an administrator prompt instead`)
+		}
+		cmd.SilenceUsage = true
+		appPaths, err := paths.GetPaths()
+		if err != nil {
+			return fmt.Errorf("failed to get paths: %w", err)
+		}
+		endpoints, err := console.Find(filepath.Join(appPaths.Lima, "0"))
+		if err != nil {
+			return err
+		}
+		if vmConsoleSettings.Display {
+			return openVMDisplay(endpoints)
+		}
+		if endpoints.Socket != "" {
+			return attachVMConsole(endpoints.Socket)
+		}
+		if endpoints.Log == "" {
+			return errors.New("the VM has no serial console")
+		}
+		fmt.Fprintln(os.Stderr, "The VM has no interactive serial console; showing its output, press Ctrl-C to stop.")
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		return console.Follow(ctx, endpoints.Log, os.Stdout, 500*time.Millisecond)
+	},
+}
+
+func init() {
+	vmCmd.AddCommand(vmConsoleCmd)
+	vmConsoleCmd.Flags().BoolVar(&vmConsoleSettings.Display, "display", false, "open the graphical console in a VNC viewer")
+}
+
+func attachVMConsole(socket string) error {
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		return fmt.Errorf("failed to connect to the serial console: %w", err)
+	}
+	defer conn.Close()
+	stdinFd := int(os.Stdin.Fd())
+	if term.IsTerminal(stdinFd) {
+		inputState, err := term.MakeRaw(stdinFd)
+		if err != nil {
+			return fmt.Errorf("failed to set up terminal input: %w", err)
+		}
+		defer func() { _ = term.Restore(stdinFd, inputState) }()
+	}
+	fmt.Fprintln(os.Stderr, "Connected to the serial console of the VM; press Ctrl-] to detach.")
+	// Guests usually only print a prompt after a key press.
+	if _, err := conn.Write([]byte("\r")); err != nil {
+		return err
+	}
+	return console.Attach(conn, os.Stdin, os.Stdout)
+}
+
+func openVMDisplay(endpoints console.Endpoints) error {
+	url, err := endpoints.VNCURL()
+	if err != nil {
+		return fmt.Errorf("%w; set video.display to vnc in the Lima override file and restart Rancher Desktop", err)
+	}
+	opener := "xdg-open"
+	if runtime.GOOS == "darwin" {
+		opener = "open"
+	}
+	if err := exec.Command(opener, url).Run(); err != nil {
+		return fmt.Errorf("failed to open %s; connect to it with a VNC viewer: %w", url, err)
+	}
+	return nil
+}
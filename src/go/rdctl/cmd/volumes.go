@@ -0,0 +1,18 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var volumesCmd = &cobra.Command{
+	Use:   "volumes",
+	Short: "Inspect Kubernetes persistent volumes",
+	Long: `Persistent volumes created by the local-path provisioner are stored in the VM,
+in /var/lib/rancher/k3s/storage unless the kubernetes.storage.path setting
+names another directory (for example on a dedicated mount), and use space on
+that disk whatever their requested capacity.`,
+}
+
+func init() {
+	rootCmd.AddCommand(volumesCmd)
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/k3s"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/output"
+	"github.com/spf13/cobra"
+)
+
+var volumesListOutputFormat string
+
+var volumesListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List persistent volumes with their backing paths and sizes",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		formatter, err := output.NewFormatter(volumesListOutputFormat, tableFormat, output.JSON)
+		if err != nil {
+			return err
+		}
+		cmd.SilenceUsage = true
+		machine, err := newKubernetesVM()
+		if err != nil {
+			return err
+		}
+		volumes, err := k3s.ListVolumes(machine)
+		if err != nil {
+			return err
+		}
+		if formatter.Format != tableFormat {
+			return formatter.Write(os.Stdout, volumes)
+		}
+		if len(volumes) == 0 {
+			output.Infof("No persistent volumes present.")
+			return nil
+		}
+		writer := tabwriter.NewWriter(os.Stdout, 0, 4, 4, ' ', 0)
+		fmt.Fprintf(writer, "NAMESPACE\tCLAIM\tCAPACITY\tUSED\tPATH\n")
+		for _, volume := range volumes {
+			fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n",
+				orDash(volume.Namespace), orDash(volume.Claim), orDash(volume.Capacity), formatUsage(volume.Used), orDash(volume.Path))
+		}
+		return writer.Flush()
+	},
+}
+
+func init() {
+	volumesCmd.AddCommand(volumesListCmd)
+	markReadOnly(volumesListCmd)
+	output.AddFlag(volumesListCmd.Flags(), &volumesListOutputFormat, tableFormat, output.JSON)
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+// formatUsage formats a size in bytes with binary units, like the capacity
+// of volumes; negative sizes are unknown.
+func formatUsage(size int64) string {
+	if size < 0 {
+		return "-"
+	}
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d", size)
+	}
+	value, suffix := float64(size), ""
+	for _, suffix = range []string{"Ki", "Mi", "Gi", "Ti"} {
+		value /= unit
+		if value < unit {
+			break
+		}
+	}
+	return fmt.Sprintf("%.1f%s", value, suffix)
+}
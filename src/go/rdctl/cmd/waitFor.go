@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/client"
+	"github.com/spf13/cobra"
+)
+
+var waitForSettings struct {
+	Timeout time.Duration
+}
+
+// backendCondition returns whether a condition holds for the state of the
+// backend, or an error if it can't hold without the user doing something.
+type backendCondition func(state client.BackendState) (bool, error)
+
+// backendConditions are the conditions `rdctl wait-for` accepts.
+var backendConditions = map[string]backendCondition{
+	"api":              apiReady,
+	"container-engine": containerEngineReady,
+	"kubernetes":       kubernetesReady,
+}
+
+var waitForCmd = &cobra.Command{
+	Use:   "wait-for CONDITION...",
+	Short: "Wait until the backend is ready",
+	Long: `Wait until all the conditions hold, or fail once --timeout is over. The
+conditions are:
+
+  api               the application answers requests
+  container-engine  the container engine is running
+  kubernetes        Kubernetes is running
+
+The command fails right away if a condition can no longer hold, such as when the
+backend failed to start, or Kubernetes is disabled.
+
+  rdctl wait-for kubernetes --timeout 120s`,
+	Args:      cobra.MinimumNArgs(1),
+	ValidArgs: backendConditionNames(),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ready, err := combineConditions(args)
+		if err != nil {
+			return err
+		}
+		cmd.SilenceUsage = true
+		return waitForBackend(getBackendState, ready, waitForSettings.Timeout, time.Second, 10*time.Second)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(waitForCmd)
+	markReadOnly(waitForCmd)
+	waitForCmd.Flags().DurationVar(&waitForSettings.Timeout, "timeout", 10*time.Minute, "how long to wait")
+}
+
+func backendConditionNames() []string {
+	names := make([]string, 0, len(backendConditions))
+	for name := range backendConditions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// combineConditions returns the condition holding when all the named ones do.
+func combineConditions(names []string) (backendCondition, error) {
+	var conditions []backendCondition
+	for _, name := range names {
+		condition, ok := backendConditions[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown condition %q; accepted conditions: %s", name, strings.Join(backendConditionNames(), ", "))
+		}
+		conditions = append(conditions, condition)
+	}
+	return func(state client.BackendState) (bool, error) {
+		for _, condition := range conditions {
+			if ok, err := condition(state); !ok || err != nil {
+				return false, err
+			}
+		}
+		return true, nil
+	}, nil
+}
+
+// apiReady holds as soon as the application returns the state.
+func apiReady(client.BackendState) (bool, error) {
+	return true, nil
+}
+
+// containerEngineReady holds once the backend has started, with or without
+// Kubernetes.
+func containerEngineReady(state client.BackendState) (bool, error) {
+	switch state.VMState {
+	// DISABLED means the engine is running without Kubernetes.
+	case "STARTED", "DISABLED":
+		return true, nil
+	case "ERROR":
+		return false, errors.New("the backend failed to start; see the logs of Rancher Desktop")
+	}
+	return false, nil
+}
+
+// kubernetesReady holds once the backend has started with Kubernetes.
+func kubernetesReady(state client.BackendState) (bool, error) {
+	if state.VMState == "DISABLED" {
+		return false, errors.New("kubernetes is disabled")
+	}
+	ready, err := containerEngineReady(state)
+	return ready && state.VMState == "STARTED", err
+}
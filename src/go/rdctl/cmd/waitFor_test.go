@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCombineConditions(t *testing.T) {
+	check := func(names []string, vmState string) (bool, error) {
+		ready, err := combineConditions(names)
+		require.NoError(t, err)
+		return ready(client.BackendState{VMState: vmState})
+	}
+	t.Run("api", func(t *testing.T) {
+		ok, err := check([]string{"api"}, "STOPPED")
+		assert.NoError(t, err)
+		assert.True(t, ok)
+	})
+	t.Run("container engine", func(t *testing.T) {
+		ok, err := check([]string{"container-engine"}, "STARTING")
+		assert.NoError(t, err)
+		assert.False(t, ok)
+		ok, err = check([]string{"container-engine"}, "DISABLED")
+		assert.NoError(t, err)
+		assert.True(t, ok)
+	})
+	t.Run("kubernetes", func(t *testing.T) {
+		ok, err := check([]string{"api", "kubernetes"}, "STARTED")
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		_, err = check([]string{"kubernetes"}, "DISABLED")
+		assert.EqualError(t, err, "kubernetes is disabled")
+		_, err = check([]string{"api", "kubernetes"}, "ERROR")
+		assert.ErrorContains(t, err, "failed to start")
+	})
+	t.Run("unknown condition", func(t *testing.T) {
+		_, err := combineConditions([]string{"api", "vm"})
+		assert.EqualError(t, err, `unknown condition "vm"; accepted conditions: api, container-engine, kubernetes`)
+	})
+}
+
+func TestWaitForKubernetes(t *testing.T) {
+	const interval, maxInterval = time.Millisecond, 4 * time.Millisecond
+	getState := backendStates(nil, "STARTING", "STARTED")
+	assert.NoError(t, waitForBackend(getState, kubernetesReady, time.Second, interval, maxInterval))
+	getState = backendStates("STARTING", "DISABLED")
+	assert.EqualError(t, waitForBackend(getState, kubernetesReady, time.Second, interval, maxInterval), "kubernetes is disabled")
+}
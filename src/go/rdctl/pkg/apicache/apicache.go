@@ -0,0 +1,236 @@
+// Package apicache keeps copies of rarely-changing API responses (such as the
+// settings schema and the list of Kubernetes versions) on disk, so that rdctl
+// invocations from shell prompts and scripts don't need to query the main
+// process every time. Entries are revalidated using ETags once they expire.
+// A stale entry is used for a while if the main process is slow to respond,
+// but never once it has quit.
+package apicache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/client"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultMaxAge is how long an entry is used without revalidating it.
+	DefaultMaxAge = 5 * time.Minute
+	// DefaultMaxStale is how old an entry may be to be used when the main
+	// process doesn't respond in time.
+	DefaultMaxStale = 30 * time.Minute
+)
+
+// Commands whose responses are suitable for caching.
+var (
+	Settings           = client.VersionCommand("", "settings")
+	SettingsSchema     = client.VersionCommand("", "settings/schema")
+	KubernetesVersions = client.VersionCommand("", "kubernetes_versions")
+	Version            = client.VersionCommand("", "version")
+)
+
+// ErrNotFound is returned when the main process doesn't know the command,
+// e.g. because it is older than rdctl.
+var ErrNotFound = errors.New("not found")
+
+// Dir returns the directory rdctl keeps its cache in.
+func Dir(appPaths paths.Paths) string {
+	return filepath.Join(appPaths.Cache, "rdctl")
+}
+
+// EndpointDir returns the directory, in the cache directory dir, of the
+// responses of the endpoint (see config.ConnectionInfo.Endpoint), so that the
+// responses of different instances or users are kept apart.
+func EndpointDir(dir, endpoint string) string {
+	sum := sha256.Sum256([]byte(endpoint))
+	return filepath.Join(dir, hex.EncodeToString(sum[:8]))
+}
+
+// InvalidateAll removes the cached responses for the command of all the
+// endpoints in the cache directory dir.
+func InvalidateAll(dir, command string) error {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to read cache directory: %w", err)
+	}
+	var errs []error
+	for _, entry := range entries {
+		if entry.IsDir() {
+			errs = append(errs, NewCache(filepath.Join(dir, entry.Name()), nil).Invalidate(command))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Requester is implemented by clients that can send conditional requests.
+type Requester interface {
+	DoRequestWithHeaders(ctx context.Context, method string, command string, headers http.Header) (*http.Response, error)
+}
+
+type entry struct {
+	ETag    string    `json:"etag"`
+	Fetched time.Time `json:"fetched"`
+	Body    []byte    `json:"body"`
+}
+
+// Cache is a directory of cached API responses. It is safe for concurrent
+// use, both within a process and across processes: entries are replaced
+// atomically, so readers always see either the old or the new copy.
+type Cache struct {
+	// MaxAge is how long an entry is used before it is revalidated.
+	MaxAge time.Duration
+	// MaxStale is how old an entry may be to be used when revalidating it
+	// fails for any reason other than the main process not running.
+	MaxStale time.Duration
+	// Timeout limits the time to wait for the main process; zero means no
+	// limit beyond the one of the connection settings.
+	Timeout   time.Duration
+	dir       string
+	requester Requester
+	mutex     sync.Mutex
+	now       func() time.Time
+}
+
+func NewCache(dir string, requester Requester) *Cache {
+	return &Cache{
+		MaxAge:    DefaultMaxAge,
+		MaxStale:  DefaultMaxStale,
+		dir:       dir,
+		requester: requester,
+		now:       time.Now,
+	}
+}
+
+// Get returns the response body for a GET request of the command, from the
+// cache if possible.
+func (c *Cache) Get(command string) ([]byte, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	cached, err := c.read(command)
+	if err != nil {
+		return nil, err
+	}
+	if cached != nil && c.now().Sub(cached.Fetched) < c.MaxAge {
+		return cached.Body, nil
+	}
+	headers := http.Header{}
+	if cached != nil && cached.ETag != "" {
+		headers.Set("If-None-Match", cached.ETag)
+	}
+	ctx := context.Background()
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+	response, err := c.requester.DoRequestWithHeaders(ctx, "GET", command, headers)
+	if err != nil {
+		if client.IsConnectionRefused(err) {
+			// The main process has quit; the entry describes its last run.
+			_ = os.Remove(c.path(command))
+			return nil, client.ErrConnectionRefused
+		}
+		if cached != nil && c.now().Sub(cached.Fetched) < c.MaxStale {
+			// Better to show slightly outdated information than none at all.
+			return cached.Body, nil
+		}
+		return nil, err
+	}
+	if response.StatusCode == http.StatusNotModified && cached != nil {
+		response.Body.Close()
+		cached.Fetched = c.now()
+		c.save(command, cached)
+		return cached.Body, nil
+	}
+	if response.StatusCode == http.StatusNotFound {
+		response.Body.Close()
+		return nil, fmt.Errorf("%s: %w", response.Status, ErrNotFound)
+	}
+	body, err := client.ProcessRequestForUtility(response, nil)
+	if err != nil {
+		return nil, err
+	}
+	etag := response.Header.Get("ETag")
+	if etag == "" {
+		sum := sha256.Sum256(body)
+		etag = fmt.Sprintf(`"%s"`, hex.EncodeToString(sum[:]))
+	}
+	c.save(command, &entry{ETag: etag, Fetched: c.now(), Body: body})
+	return body, nil
+}
+
+// save writes the entry; failing to do so only costs a request next time.
+func (c *Cache) save(command string, cached *entry) {
+	if err := c.write(command, cached); err != nil {
+		logrus.Debugf("Failed to update the API cache: %s", err)
+	}
+}
+
+// Invalidate removes the cached response for the command, if any.
+func (c *Cache) Invalidate(command string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	err := os.Remove(c.path(command))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove cached %s: %w", command, err)
+	}
+	return nil
+}
+
+func (c *Cache) path(command string) string {
+	name := strings.NewReplacer("/", "_", "?", "_", "&", "_", "=", "_").Replace(strings.Trim(command, "/"))
+	return filepath.Join(c.dir, name+".json")
+}
+
+// read returns the cached entry for the command, or nil if there is none.
+// Corrupt entries are treated as missing.
+func (c *Cache) read(command string) (*entry, error) {
+	contents, err := os.ReadFile(c.path(command))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read cached %s: %w", command, err)
+	}
+	var cached entry
+	if err := json.Unmarshal(contents, &cached); err != nil {
+		return nil, nil
+	}
+	return &cached, nil
+}
+
+func (c *Cache) write(command string, cached *entry) error {
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	file, err := os.CreateTemp(c.dir, ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create cache file: %w", err)
+	}
+	defer os.Remove(file.Name())
+	if err := json.NewEncoder(file).Encode(cached); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write cached %s: %w", command, err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write cached %s: %w", command, err)
+	}
+	if err := os.Rename(file.Name(), c.path(command)); err != nil {
+		return fmt.Errorf("failed to save cached %s: %w", command, err)
+	}
+	return nil
+}
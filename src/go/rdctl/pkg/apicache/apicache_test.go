@@ -0,0 +1,183 @@
+package apicache
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/client"
+)
+
+type fakeRequester struct {
+	body string
+	etag string
+	err  error
+	// status is the status of the responses, if not 200.
+	status int
+	// block makes requests wait until their context is done.
+	block    bool
+	requests []http.Header
+}
+
+// connectionRefusedError returns the error of connecting to a port on which
+// nothing listens.
+func connectionRefusedError(t *testing.T) error {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+	_, err = http.Get("http://" + address)
+	if err == nil {
+		t.Fatal("expected the connection to be refused")
+	}
+	return err
+}
+
+func (f *fakeRequester) DoRequestWithHeaders(ctx context.Context, method string, command string, headers http.Header) (*http.Response, error) {
+	f.requests = append(f.requests, headers)
+	if f.err != nil {
+		return nil, f.err
+	}
+	if f.block {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	response := &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(f.body))}
+	if f.status != 0 {
+		response.StatusCode = f.status
+		response.Status = http.StatusText(f.status)
+	}
+	if f.etag != "" {
+		response.Header.Set("ETag", f.etag)
+		if headers.Get("If-None-Match") == f.etag {
+			response.StatusCode = http.StatusNotModified
+			response.Body = io.NopCloser(strings.NewReader(""))
+		}
+	}
+	return response, nil
+}
+
+func TestCache(t *testing.T) {
+	now := time.Now()
+	requester := &fakeRequester{body: "hello", etag: `"1"`}
+	cache := NewCache(t.TempDir(), requester)
+	cache.now = func() time.Time { return now }
+
+	get := func(expected string) {
+		t.Helper()
+		body, err := cache.Get(SettingsSchema)
+		if err != nil {
+			t.Fatalf("failed to get: %s", err)
+		}
+		if string(body) != expected {
+			t.Errorf("expected %q, got %q", expected, body)
+		}
+	}
+
+	get("hello")
+	get("hello")
+	if len(requester.requests) != 1 {
+		t.Fatalf("expected a fresh entry to be used without a request, got %d requests", len(requester.requests))
+	}
+
+	now = now.Add(2 * cache.MaxAge)
+	get("hello")
+	if len(requester.requests) != 2 || requester.requests[1].Get("If-None-Match") != `"1"` {
+		t.Fatalf("expected a conditional request, got %v", requester.requests)
+	}
+
+	now = now.Add(2 * cache.MaxAge)
+	requester.body, requester.etag = "changed", `"2"`
+	get("changed")
+
+	now = now.Add(2 * cache.MaxAge)
+	requester.err = errors.New("connection reset")
+	get("changed")
+
+	now = now.Add(2 * cache.MaxStale)
+	if _, err := cache.Get(SettingsSchema); err == nil {
+		t.Error("expected an entry older than MaxStale not to be used")
+	}
+
+	if err := cache.Invalidate(SettingsSchema); err != nil {
+		t.Fatalf("failed to invalidate: %s", err)
+	}
+	if _, err := cache.Get(SettingsSchema); err == nil {
+		t.Error("expected an error without a cached entry or a connection")
+	}
+}
+
+func TestCacheWithoutETag(t *testing.T) {
+	requester := &fakeRequester{body: "hello"}
+	cache := NewCache(t.TempDir(), requester)
+	cache.MaxAge = 0
+	for i := 0; i < 2; i++ {
+		if _, err := cache.Get(KubernetesVersions); err != nil {
+			t.Fatalf("failed to get: %s", err)
+		}
+	}
+	if etag := requester.requests[1].Get("If-None-Match"); !strings.HasPrefix(etag, `"`) {
+		t.Errorf("expected a computed ETag to be sent, got %q", etag)
+	}
+}
+
+func TestCacheAfterQuit(t *testing.T) {
+	requester := &fakeRequester{body: "hello"}
+	cache := NewCache(t.TempDir(), requester)
+	if _, err := cache.Get(Settings); err != nil {
+		t.Fatalf("failed to get: %s", err)
+	}
+	cache.MaxAge = 0
+	requester.err = connectionRefusedError(t)
+	if _, err := cache.Get(Settings); !errors.Is(err, client.ErrConnectionRefused) {
+		t.Fatalf("expected the entry not to be used once the main process quit, got %v", err)
+	}
+	requester.err = errors.New("connection reset")
+	if _, err := cache.Get(Settings); err == nil {
+		t.Error("expected the entry to be removed")
+	}
+}
+
+func TestCacheTimeout(t *testing.T) {
+	requester := &fakeRequester{block: true}
+	cache := NewCache(t.TempDir(), requester)
+	cache.Timeout = 10 * time.Millisecond
+	if _, err := cache.Get(Settings); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the request to time out, got %v", err)
+	}
+}
+
+func TestCacheNotFound(t *testing.T) {
+	cache := NewCache(t.TempDir(), &fakeRequester{status: http.StatusNotFound})
+	if _, err := cache.Get(Version); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestInvalidateAll(t *testing.T) {
+	dir := t.TempDir()
+	var caches []*Cache
+	for _, endpoint := range []string{"user@127.0.0.1:6107", "user@127.0.0.1:6108"} {
+		requester := &fakeRequester{body: endpoint}
+		cache := NewCache(EndpointDir(dir, endpoint), requester)
+		if body, err := cache.Get(Settings); err != nil || string(body) != endpoint {
+			t.Fatalf("expected %q, got %q, %v", endpoint, body, err)
+		}
+		caches = append(caches, cache)
+	}
+	if err := InvalidateAll(dir, Settings); err != nil {
+		t.Fatal(err)
+	}
+	for _, cache := range caches {
+		if cached, err := cache.read(Settings); err != nil || cached != nil {
+			t.Errorf("expected no cached settings, got %v, %v", cached, err)
+		}
+	}
+}
@@ -0,0 +1,253 @@
+// Package benchmark measures the throughput of the disk of the VM, of the
+// directories shared from the host, which containers bind-mount, and of the
+// network between the host and the VM, which published ports of containers
+// go through. The results are in MiB/s whatever the size, so that reports
+// for different mount types and backends can be compared.
+package benchmark
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/vm"
+)
+
+// The names of the benchmarks.
+const (
+	VMDisk    = "vm-disk"
+	BindMount = "bind-mount"
+	Network   = "network"
+)
+
+// Names are the names of all the benchmarks, in the order they run.
+var Names = []string{VMDisk, BindMount, Network}
+
+// VMDiskDir is the directory of the VM the disk benchmark writes to; it is
+// on the disk holding the images and containers.
+const VMDiskDir = "/var/lib"
+
+const mebibyte = 1024 * 1024
+
+// Report is the result of a run of the benchmarks.
+type Report struct {
+	// Platform is the OS and architecture of the host, e.g. "darwin/arm64".
+	Platform string `json:"platform"`
+	// VMType and MountType are the settings of the backend, if known.
+	VMType    string   `json:"vmType,omitempty"`
+	MountType string   `json:"mountType,omitempty"`
+	Results   []Result `json:"results"`
+}
+
+// NewReport returns an empty report for this host.
+func NewReport() *Report {
+	return &Report{Platform: runtime.GOOS + "/" + runtime.GOARCH, Results: []Result{}}
+}
+
+// Failed returns the number of results that are errors.
+func (r *Report) Failed() int {
+	failed := 0
+	for _, result := range r.Results {
+		if result.Error != "" {
+			failed++
+		}
+	}
+	return failed
+}
+
+// Result is the measurement of one operation of a benchmark.
+type Result struct {
+	Benchmark string `json:"benchmark"`
+	// Operation is "write" or "read" for the disks, and "upload" (from the
+	// host) or "download" (to the host) for the network.
+	Operation    string  `json:"operation"`
+	Bytes        int64   `json:"bytes"`
+	Seconds      float64 `json:"seconds"`
+	MiBPerSecond float64 `json:"mibPerSecond"`
+	// Error is set instead of the measurement if the operation failed.
+	Error string `json:"error,omitempty"`
+}
+
+func newResult(benchmark, operation string, bytes int64, seconds float64) Result {
+	result := Result{Benchmark: benchmark, Operation: operation, Bytes: bytes, Seconds: seconds}
+	if seconds > 0 {
+		result.MiBPerSecond = math.Round(float64(bytes)/mebibyte/seconds*10) / 10
+	}
+	return result
+}
+
+func failedResults(benchmark string, err error, operations ...string) []Result {
+	results := make([]Result, len(operations))
+	for i, operation := range operations {
+		results[i] = Result{Benchmark: benchmark, Operation: operation, Error: err.Error()}
+	}
+	return results
+}
+
+// diskScript writes a file of $2 MiB in the directory $1, and reads it back
+// once the page cache is dropped, so that the reads come from the disk of the
+// VM (or, for shared directories, from the host).
+const diskScript = `set -e
+file="$1/rd-benchmark-$$"
+trap 'rm -f "$file"' EXIT
+dd if=/dev/zero of="$file" bs=1M count="$2" conv=fsync 2>&1
+sync
+echo 3 > /proc/sys/vm/drop_caches
+dd if="$file" of=/dev/null bs=1M 2>&1`
+
+// ddStatsRE matches the statistics dd writes, in the formats of both busybox
+// ("... bytes (256.0MB) copied, 0.5 seconds, ...") and GNU coreutils.
+var ddStatsRE = regexp.MustCompile(`(?m)^(\d+) bytes .*copied, ([0-9.]+) s`)
+
+// Disk measures writing and reading a file of the given size in the
+// directory of the VM.
+func Disk(runner vm.Runner, benchmark, dir string, size int64) []Result {
+	count := strconv.FormatInt(max(size/mebibyte, 1), 10)
+	output, err := runner.RootOutput("sh", "-c", diskScript, "sh", dir, count)
+	if err != nil {
+		return failedResults(benchmark, err, "write", "read")
+	}
+	stats := ddStatsRE.FindAllStringSubmatch(string(output), -1)
+	if len(stats) != 2 {
+		return failedResults(benchmark, fmt.Errorf("unexpected output of dd: %q", strings.TrimSpace(string(output))), "write", "read")
+	}
+	results := make([]Result, 2)
+	for i, operation := range []string{"write", "read"} {
+		bytes, _ := strconv.ParseInt(stats[i][1], 10, 64)
+		seconds, _ := strconv.ParseFloat(stats[i][2], 64)
+		results[i] = newResult(benchmark, operation, bytes, seconds)
+	}
+	return results
+}
+
+// GuestPath returns the path in the VM of a directory of the host, which
+// must be shared with the VM.
+func GuestPath(runner vm.Runner, hostPath string) (string, error) {
+	if runtime.GOOS != "windows" {
+		// Lima mounts the directories at the same path.
+		return hostPath, nil
+	}
+	output, err := runner.RootOutput("wslpath", "-u", hostPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to get the path of %s in the VM: %w", hostPath, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// The scripts listening on port $1 in the VM for the network benchmark.
+const (
+	uploadScript   = `exec nc -l -p "$1" > /dev/null`
+	downloadScript = `head -c "$2" /dev/zero | nc -l -p "$1"`
+)
+
+// connectTimeout limits the time to wait for the port of the VM to be
+// forwarded to the host.
+var connectTimeout = 30 * time.Second
+
+// NetworkThroughput measures sending and receiving the given number of bytes
+// through a port of the VM forwarded to localhost on the host, as published
+// ports of containers are.
+func NetworkThroughput(runner vm.Runner, size int64) []Result {
+	var results []Result
+	for _, operation := range []string{"upload", "download"} {
+		bytes, seconds, err := transfer(runner, operation, size)
+		if err != nil {
+			results = append(results, failedResults(Network, err, operation)...)
+		} else {
+			results = append(results, newResult(Network, operation, bytes, seconds))
+		}
+	}
+	return results
+}
+
+func transfer(runner vm.Runner, operation string, size int64) (int64, float64, error) {
+	port, err := freePort()
+	if err != nil {
+		return 0, 0, err
+	}
+	script := uploadScript
+	if operation == "download" {
+		script = downloadScript
+	}
+	listener := make(chan error, 1)
+	go func() {
+		listener <- runner.RootStream(nil, nil, "sh", "-c", script, "sh", strconv.Itoa(port), strconv.FormatInt(size, 10))
+	}()
+	conn, err := connect(port, listener)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer conn.Close()
+	start := time.Now()
+	if operation == "upload" {
+		if _, err := io.CopyN(conn, zeros{}, size); err != nil {
+			return 0, 0, fmt.Errorf("failed to send data to the VM: %w", err)
+		}
+		if tcpConn, ok := conn.(*net.TCPConn); ok {
+			_ = tcpConn.CloseWrite()
+		}
+		// The data is only all received once the listener exits.
+		if err := <-listener; err != nil {
+			return 0, 0, fmt.Errorf("failed to receive data in the VM: %w", err)
+		}
+		return size, time.Since(start).Seconds(), nil
+	}
+	received, err := io.Copy(io.Discard, io.LimitReader(conn, size))
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to receive data from the VM: %w", err)
+	}
+	if received < size {
+		return 0, 0, fmt.Errorf("received %d bytes from the VM instead of %d", received, size)
+	}
+	// The listener exits, possibly failing, once the connection is closed.
+	return received, time.Since(start).Seconds(), nil
+}
+
+// connect connects to the port on localhost, retrying until the VM listens
+// on it and it is forwarded, or the listener fails.
+func connect(port int, listener <-chan error) (net.Conn, error) {
+	address := net.JoinHostPort("127.0.0.1", strconv.Itoa(port))
+	deadline := time.Now().Add(connectTimeout)
+	for {
+		conn, err := net.DialTimeout("tcp", address, time.Second)
+		if err == nil {
+			return conn, nil
+		}
+		select {
+		case err := <-listener:
+			if err == nil {
+				err = errors.New("it exited")
+			}
+			return nil, fmt.Errorf("failed to listen on port %d in the VM: %w", port, err)
+		case <-time.After(250 * time.Millisecond):
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("port %d of the VM wasn't forwarded to the host within %s", port, connectTimeout)
+		}
+	}
+}
+
+// freePort returns a port that nothing listens on on the host.
+func freePort() (int, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, fmt.Errorf("failed to find a free port: %w", err)
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port, nil
+}
+
+// zeros is an endless stream of zero bytes.
+type zeros struct{}
+
+func (zeros) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
@@ -0,0 +1,99 @@
+package benchmark
+
+import (
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeVM answers the commands of the benchmarks without a VM; the network
+// benchmark listens on the host instead.
+type fakeVM struct {
+	t          *testing.T
+	diskOutput string
+	err        error
+	received   int64
+}
+
+func (v *fakeVM) RootOutput(args ...string) ([]byte, error) {
+	return []byte(v.diskOutput), v.err
+}
+
+func (v *fakeVM) RootStream(_ io.Reader, _ io.Writer, args ...string) error {
+	if v.err != nil {
+		return v.err
+	}
+	require.Len(v.t, args, 6)
+	listener, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", args[4]))
+	if err != nil {
+		return err
+	}
+	defer listener.Close()
+	conn, err := listener.Accept()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if args[2] == uploadScript {
+		v.received, err = io.Copy(io.Discard, conn)
+		return err
+	}
+	size, _ := strconv.ParseInt(args[5], 10, 64)
+	_, err = io.CopyN(conn, zeros{}, size)
+	return err
+}
+
+func TestDisk(t *testing.T) {
+	t.Run("busybox", func(t *testing.T) {
+		runner := &fakeVM{t: t, diskOutput: `256+0 records in
+256+0 records out
+268435456 bytes (256.0MB) copied, 0.500000 seconds, 512.0MB/s
+256+0 records in
+256+0 records out
+268435456 bytes (256.0MB) copied, 0.250000 seconds, 1.0GB/s
+`}
+		assert.Equal(t, []Result{
+			{Benchmark: VMDisk, Operation: "write", Bytes: 268435456, Seconds: 0.5, MiBPerSecond: 512},
+			{Benchmark: VMDisk, Operation: "read", Bytes: 268435456, Seconds: 0.25, MiBPerSecond: 1024},
+		}, Disk(runner, VMDisk, VMDiskDir, 256*mebibyte))
+	})
+	t.Run("coreutils", func(t *testing.T) {
+		runner := &fakeVM{t: t, diskOutput: `1048576 bytes (1.0 MB, 1.0 MiB) copied, 0.3 s, 3.5 MB/s
+1048576 bytes (1.0 MB, 1.0 MiB) copied, 0.1 s, 10.5 MB/s
+`}
+		results := Disk(runner, BindMount, "/Users/me/.rd-benchmark", mebibyte)
+		assert.Equal(t, 3.3, results[0].MiBPerSecond)
+		assert.Equal(t, 10.0, results[1].MiBPerSecond)
+	})
+	t.Run("failure", func(t *testing.T) {
+		runner := &fakeVM{t: t, err: errors.New("dd: can't open file")}
+		assert.Equal(t, []Result{
+			{Benchmark: BindMount, Operation: "write", Error: "dd: can't open file"},
+			{Benchmark: BindMount, Operation: "read", Error: "dd: can't open file"},
+		}, Disk(runner, BindMount, "/missing", mebibyte))
+	})
+}
+
+func TestNetworkThroughput(t *testing.T) {
+	runner := &fakeVM{t: t}
+	results := NetworkThroughput(runner, 4*mebibyte)
+	require.Len(t, results, 2)
+	for i, operation := range []string{"upload", "download"} {
+		assert.Equal(t, operation, results[i].Operation)
+		assert.Empty(t, results[i].Error)
+		assert.Equal(t, int64(4*mebibyte), results[i].Bytes)
+		assert.Positive(t, results[i].Seconds)
+	}
+	assert.Equal(t, int64(4*mebibyte), runner.received)
+}
+
+func TestReportFailed(t *testing.T) {
+	report := NewReport()
+	report.Results = append(report.Results, Result{Benchmark: VMDisk, Operation: "write"}, Result{Benchmark: Network, Operation: "upload", Error: "timeout"})
+	assert.Equal(t, 1, report.Failed())
+}
@@ -0,0 +1,95 @@
+// Package checks holds the results of diagnostic commands, such as
+// `rdctl devcontainer doctor` and `rdctl verify`, which run a series of checks
+// and report on each of them.
+package checks
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/exitcode"
+)
+
+// Status is the outcome of a check.
+type Status string
+
+const (
+	OK      Status = "ok"
+	Warning Status = "warning"
+	Failed  Status = "failed"
+	Skipped Status = "skipped"
+)
+
+// Check is the outcome of a single check, and how to fix any problem found.
+type Check struct {
+	Name    string `json:"name"`
+	Status  Status `json:"status"`
+	Message string `json:"message"`
+}
+
+// Err returns an error if any of the checks failed. Its exit code is
+// exitcode.PartialSuccess if some other checks passed, and exitcode.Failure
+// otherwise.
+func Err(checks []Check) error {
+	for _, check := range checks {
+		if check.Status == Failed {
+			return &Error{Checks: checks}
+		}
+	}
+	return nil
+}
+
+// Error is returned by Err when at least one check failed.
+type Error struct {
+	Checks []Check
+}
+
+func (e *Error) Error() string {
+	var failures []string
+	for _, check := range e.Checks {
+		if check.Status == Failed {
+			failures = append(failures, check.Name)
+		}
+	}
+	return fmt.Sprintf("failed checks: %s", strings.Join(failures, ", "))
+}
+
+// ExitCode implements exitcode.Coder.
+func (e *Error) ExitCode() int {
+	for _, check := range e.Checks {
+		if check.Status == OK || check.Status == Warning {
+			return exitcode.PartialSuccess
+		}
+	}
+	return exitcode.Failure
+}
+
+// WriteTable writes the checks as a table.
+func WriteTable(w io.Writer, checks []Check) error {
+	writer := tabwriter.NewWriter(w, 0, 4, 4, ' ', 0)
+	fmt.Fprintf(writer, "CHECK\tSTATUS\tMESSAGE\n")
+	for _, check := range checks {
+		fmt.Fprintf(writer, "%s\t%s\t%s\n", check.Name, check.Status, check.Message)
+	}
+	return writer.Flush()
+}
+
+// SameDockerEndpoint returns whether two docker endpoints are the same,
+// following symlinks to unix sockets (such as /var/run/docker.sock).
+func SameDockerEndpoint(a, b string) bool {
+	if a == b {
+		return true
+	}
+	pathA, okA := strings.CutPrefix(a, "unix://")
+	pathB, okB := strings.CutPrefix(b, "unix://")
+	if !okA || !okB {
+		return false
+	}
+	resolvedA, errA := filepath.EvalSymlinks(pathA)
+	resolvedB, errB := filepath.EvalSymlinks(pathB)
+	return errors.Join(errA, errB) == nil && resolvedA == resolvedB
+}
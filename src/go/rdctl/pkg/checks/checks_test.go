@@ -0,0 +1,31 @@
+package checks
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/exitcode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErr(t *testing.T) {
+	assert.NoError(t, Err([]Check{{Name: "a", Status: OK}, {Name: "b", Status: Warning}}))
+	assert.EqualError(t, Err([]Check{{Name: "a", Status: Failed}, {Name: "b", Status: OK}, {Name: "c", Status: Failed}}), "failed checks: a, c")
+	assert.Equal(t, exitcode.PartialSuccess, exitcode.FromError(Err([]Check{{Name: "a", Status: Failed}, {Name: "b", Status: Warning}})))
+	assert.Equal(t, exitcode.Failure, exitcode.FromError(Err([]Check{{Name: "a", Status: Failed}, {Name: "b", Status: Skipped}})))
+}
+
+func TestSameDockerEndpoint(t *testing.T) {
+	dir := t.TempDir()
+	socket := filepath.Join(dir, "docker.sock")
+	link := filepath.Join(dir, "link.sock")
+	require.NoError(t, os.WriteFile(socket, nil, 0o600))
+	if err := os.Symlink(socket, link); err != nil {
+		t.Skipf("failed to create symlink: %s", err)
+	}
+	assert.True(t, SameDockerEndpoint("unix://"+link, "unix://"+socket))
+	assert.False(t, SameDockerEndpoint("unix://"+filepath.Join(dir, "other.sock"), "unix://"+socket))
+	assert.False(t, SameDockerEndpoint("tcp://127.0.0.1:2375", "unix://"+socket))
+}
@@ -2,13 +2,19 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/config"
 	"io"
+	"net"
 	"net/http"
 	"strings"
+	"time"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/config"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/exitcode"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/profile"
 )
 
 const (
@@ -16,11 +22,42 @@ const (
 	ApiVersion = "v1"
 )
 
-var ErrConnectionRefused = errors.New("connection refused")
+var (
+	// ErrConnectionRefused means nothing listens for requests, i.e. the
+	// application isn't running.
+	ErrConnectionRefused = exitcode.WithCode(errors.New("connection refused"), exitcode.ConnectionRefused)
+	// ErrAuthFailure means the application didn't accept the credentials.
+	ErrAuthFailure = exitcode.WithCode(errors.New("user/password not accepted"), exitcode.AuthFailure)
+)
+
+// IsConnectionRefused returns whether the error means that nothing listens
+// for requests, i.e. the application isn't running.
+func IsConnectionRefused(err error) bool {
+	return errors.Is(handleConnectionRefused(err), ErrConnectionRefused)
+}
+
+const (
+	// defaultRetryBackoff is the time to wait before the first retry if the
+	// connection info doesn't set one.
+	defaultRetryBackoff = 500 * time.Millisecond
+	// maxRetryBackoff limits the doubling of the time between retries.
+	maxRetryBackoff = 5 * time.Second
+)
 
 type BackendState struct {
 	VMState string `json:"vmState"`
-	Locked  bool   `json:"locked"`
+	// SubState is the step the backend is at while VMState is STARTING, such
+	// as "vm-booting" or "k8s-waiting".
+	SubState string `json:"subState,omitempty"`
+	Locked   bool   `json:"locked"`
+}
+
+// Description returns the state of the VM, followed by the sub-state if any.
+func (s BackendState) Description() string {
+	if s.SubState == "" {
+		return s.VMState
+	}
+	return fmt.Sprintf("%s (%s)", s.VMState, s.SubState)
 }
 
 // APIError - type for representing errors from API calls.
@@ -48,6 +85,8 @@ func validateBackendState(state BackendState) error {
 
 type RDClientImpl struct {
 	connectionInfo *config.ConnectionInfo
+	// watcher, if set, provides the connection info instead.
+	watcher *config.Watcher
 }
 
 func NewRDClient(connectionInfo *config.ConnectionInfo) *RDClientImpl {
@@ -56,41 +95,143 @@ func NewRDClient(connectionInfo *config.ConnectionInfo) *RDClientImpl {
 	}
 }
 
-func (client *RDClientImpl) makeURL(host string, port int, command string) string {
+// NewWatchingRDClient returns a client for long-running commands, which keeps
+// working after the application restarts with new credentials.
+func NewWatchingRDClient(watcher *config.Watcher) *RDClientImpl {
+	return &RDClientImpl{
+		watcher: watcher,
+	}
+}
+
+func (client *RDClientImpl) getConnectionInfo() *config.ConnectionInfo {
+	if client.watcher != nil {
+		return client.watcher.ConnectionInfo()
+	}
+	return client.connectionInfo
+}
+
+func (client *RDClientImpl) makeURL(scheme, host string, port int, command string) string {
 	if strings.HasPrefix(command, "/") {
-		return fmt.Sprintf("http://%s:%d%s", host, port, command)
+		return fmt.Sprintf("%s://%s:%d%s", scheme, host, port, command)
 	}
-	return fmt.Sprintf("http://%s:%d/%s", host, port, command)
+	return fmt.Sprintf("%s://%s:%d/%s", scheme, host, port, command)
 }
 
 func (client *RDClientImpl) DoRequest(method string, command string) (*http.Response, error) {
-	req, err := client.getRequestObject(method, command)
+	return client.send(context.Background(), method, command, nil, "text/plain", nil, false)
+}
+
+func (client *RDClientImpl) DoRequestWithPayload(method string, command string, payload io.Reader) (*http.Response, error) {
+	// Read the payload up front, so that it can be sent again on retries.
+	body, err := io.ReadAll(payload)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to read request payload: %w", err)
 	}
-	return http.DefaultClient.Do(req)
+	return client.send(context.Background(), method, command, body, "application/json", nil, false)
 }
 
-func (client *RDClientImpl) DoRequestWithPayload(method string, command string, payload io.Reader) (*http.Response, error) {
-	url := client.makeURL(client.connectionInfo.Host, client.connectionInfo.Port, command)
-	req, err := http.NewRequest(method, url, payload)
+// send sends a request, retrying as many times as the connection info allows
+// while the server refuses the connection, e.g. because the application is
+// still starting. As the server never got the request then, this is safe for
+// requests that change things too. Cancelling the context stops the retries.
+// Streamed responses are read for as long as the server sends them, without
+// the timeout of the connection info.
+func (client *RDClientImpl) send(ctx context.Context, method, command string, body []byte, contentType string, headers http.Header, stream bool) (*http.Response, error) {
+	connectionInfo := client.getConnectionInfo()
+	httpClient, err := client.httpClient(connectionInfo, stream)
 	if err != nil {
 		return nil, err
 	}
-	req.SetBasicAuth(client.connectionInfo.User, client.connectionInfo.Password)
-	req.Header.Add("Content-Type", "application/json")
-	req.Close = true
-	return http.DefaultClient.Do(req)
+	backoff := time.Duration(connectionInfo.RetryBackoff)
+	if backoff <= 0 {
+		backoff = defaultRetryBackoff
+	}
+	for attempt := 0; ; attempt++ {
+		req, err := client.getRequestObject(ctx, connectionInfo, method, command, body, contentType)
+		if err != nil {
+			return nil, err
+		}
+		for name, values := range headers {
+			for _, value := range values {
+				req.Header.Add(name, value)
+			}
+		}
+		response, err := httpClient.Do(req)
+		if attempt >= connectionInfo.Retries || !IsConnectionRefused(err) {
+			return response, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, maxRetryBackoff)
+		// The application writes new credentials when it restarts.
+		next := client.getConnectionInfo()
+		if next != connectionInfo {
+			if httpClient, err = client.httpClient(next, stream); err != nil {
+				return nil, err
+			}
+		}
+		connectionInfo = next
+	}
 }
 
-func (client *RDClientImpl) getRequestObject(method string, command string) (*http.Request, error) {
-	url := client.makeURL(client.connectionInfo.Host, client.connectionInfo.Port, command)
-	req, err := http.NewRequest(method, url, nil)
+// httpClient returns the client to send requests with, connecting to the
+// socket of the connection info if it has one, and using its TLS settings.
+// Clients for streamed responses have no timeout.
+func (client *RDClientImpl) httpClient(connectionInfo *config.ConnectionInfo, stream bool) (*http.Client, error) {
+	// Keep the transport of the default client, which `--profile` instruments.
+	transport := http.DefaultClient.Transport
+	if connectionInfo.Socket != "" || connectionInfo.TLS {
+		custom := &http.Transport{}
+		if socket := connectionInfo.Socket; socket != "" {
+			custom.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return dialSocket(ctx, socket)
+			}
+		}
+		if connectionInfo.TLS {
+			tlsConfig, err := connectionInfo.TLSConfig()
+			if err != nil {
+				return nil, err
+			}
+			custom.TLSClientConfig = tlsConfig
+		}
+		transport = custom
+		if profile.Enabled() {
+			transport = profile.Transport(transport)
+		}
+	}
+	timeout := time.Duration(connectionInfo.Timeout)
+	if stream {
+		timeout = 0
+	}
+	return &http.Client{Transport: transport, Timeout: timeout}, nil
+}
+
+func (client *RDClientImpl) getRequestObject(ctx context.Context, connectionInfo *config.ConnectionInfo, method, command string, body []byte, contentType string) (*http.Request, error) {
+	scheme := "http"
+	if connectionInfo.TLS {
+		scheme = "https"
+	}
+	var url string
+	if connectionInfo.Socket != "" {
+		// The host and port are ignored, as the transport dials the socket;
+		// the certificate of the server must be valid for localhost.
+		url = client.makeURL(scheme, "localhost", 80, command)
+	} else {
+		url = client.makeURL(scheme, connectionInfo.Host, connectionInfo.Port, command)
+	}
+	var payload io.Reader
+	if body != nil {
+		payload = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, payload)
 	if err != nil {
 		return nil, err
 	}
-	req.SetBasicAuth(client.connectionInfo.User, client.connectionInfo.Password)
-	req.Header.Add("Content-Type", "text/plain")
+	req.SetBasicAuth(connectionInfo.User, connectionInfo.Password)
+	req.Header.Add("Content-Type", contentType)
 	req.Close = true
 	return req, nil
 }
@@ -122,3 +263,17 @@ func (client *RDClientImpl) UpdateBackendState(state BackendState) error {
 	}
 	return nil
 }
+
+// DoRequestWithHeaders sends a request without a payload, adding the given
+// headers; this is used for conditional requests. The request is abandoned
+// when the context is done.
+func (client *RDClientImpl) DoRequestWithHeaders(ctx context.Context, method string, command string, headers http.Header) (*http.Response, error) {
+	return client.send(ctx, method, command, nil, "text/plain", headers, false)
+}
+
+// DoStreamingRequest sends a GET request for a response that the server keeps
+// streaming, such as server-sent events; it is read until the context is done
+// or the server closes it.
+func (client *RDClientImpl) DoStreamingRequest(ctx context.Context, command string) (*http.Response, error) {
+	return client.send(ctx, "GET", command, nil, "text/plain", http.Header{"Accept": {"text/event-stream"}}, true)
+}
@@ -0,0 +1,164 @@
+package client
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// unusedPort returns a port on which nothing listens, for now.
+func unusedPort(t *testing.T) int {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := listener.Addr().(*net.TCPAddr).Port
+	require.NoError(t, listener.Close())
+	return port
+}
+
+func TestRetries(t *testing.T) {
+	t.Run("gives up", func(t *testing.T) {
+		rdClient := NewRDClient(&config.ConnectionInfo{
+			Host:         "127.0.0.1",
+			Port:         unusedPort(t),
+			Retries:      2,
+			RetryBackoff: config.Duration(10 * time.Millisecond),
+		})
+		start := time.Now()
+		_, err := ProcessRequestForUtility(rdClient.DoRequest("GET", VersionCommand("", "about")))
+		assert.ErrorIs(t, err, ErrConnectionRefused)
+		// Waits 10ms, then 20ms.
+		assert.GreaterOrEqual(t, time.Since(start), 30*time.Millisecond)
+	})
+	t.Run("waits for the server", func(t *testing.T) {
+		port := unusedPort(t)
+		bodies := make(chan string, 1)
+		server := &http.Server{
+			Addr: net.JoinHostPort("127.0.0.1", strconv.Itoa(port)),
+			Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				bodies <- string(body)
+				_, _ = w.Write([]byte("ok"))
+			}),
+		}
+		t.Cleanup(func() { _ = server.Close() })
+		// Start the server after the first attempts failed.
+		time.AfterFunc(100*time.Millisecond, func() { _ = server.ListenAndServe() })
+		rdClient := NewRDClient(&config.ConnectionInfo{
+			Host:         "127.0.0.1",
+			Port:         port,
+			Retries:      10,
+			RetryBackoff: config.Duration(20 * time.Millisecond),
+		})
+		result, err := ProcessRequestForUtility(rdClient.DoRequestWithPayload("PUT", VersionCommand("", "settings"), strings.NewReader(`{"version": 10}`)))
+		require.NoError(t, err)
+		assert.Equal(t, "ok", string(result))
+		assert.Equal(t, `{"version": 10}`, <-bodies)
+	})
+}
+
+func TestSocket(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the application listens on a named pipe on Windows")
+	}
+	socket := filepath.Join(t.TempDir(), "rd-engine.sock")
+	rdClient := NewRDClient(&config.ConnectionInfo{User: "user", Password: "secret", Socket: socket})
+	t.Run("not listening", func(t *testing.T) {
+		_, err := ProcessRequestForUtility(rdClient.DoRequest("GET", VersionCommand("", "about")))
+		assert.ErrorIs(t, err, ErrConnectionRefused)
+	})
+	t.Run("listening", func(t *testing.T) {
+		listener, err := net.Listen("unix", socket)
+		require.NoError(t, err)
+		server := &http.Server{
+			Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				user, password, _ := r.BasicAuth()
+				_, _ = w.Write([]byte(r.URL.Path + " " + user + ":" + password))
+			}),
+		}
+		t.Cleanup(func() { _ = server.Close() })
+		go func() { _ = server.Serve(listener) }()
+		result, err := ProcessRequestForUtility(rdClient.DoRequest("GET", VersionCommand("", "about")))
+		require.NoError(t, err)
+		assert.Equal(t, "/v1/about user:secret", string(result))
+	})
+}
+
+// writePEM writes the PEM block to a new file, and returns its path.
+func writePEM(t *testing.T, name, blockType string, bytes []byte) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: bytes}), 0o600))
+	return path
+}
+
+func TestTLS(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	port, err := strconv.Atoi(serverURL.Port())
+	require.NoError(t, err)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "rdctl"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	certificate, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyBytes, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	connectionInfo := &config.ConnectionInfo{
+		Host:       "127.0.0.1",
+		Port:       port,
+		TLS:        true,
+		CACert:     writePEM(t, "ca.pem", "CERTIFICATE", server.Certificate().Raw),
+		ClientCert: writePEM(t, "client.pem", "CERTIFICATE", certificate),
+		ClientKey:  writePEM(t, "client-key.pem", "EC PRIVATE KEY", keyBytes),
+	}
+	t.Run("mutual TLS", func(t *testing.T) {
+		result, err := ProcessRequestForUtility(NewRDClient(connectionInfo).DoRequest("GET", VersionCommand("", "about")))
+		require.NoError(t, err)
+		assert.Equal(t, "rdctl", string(result))
+	})
+	t.Run("untrusted server", func(t *testing.T) {
+		untrusted := *connectionInfo
+		untrusted.CACert = ""
+		_, err := ProcessRequestForUtility(NewRDClient(&untrusted).DoRequest("GET", VersionCommand("", "about")))
+		assert.ErrorContains(t, err, "certificate")
+	})
+}
+
+func TestBackendStateDescription(t *testing.T) {
+	assert.Equal(t, "STARTED", BackendState{VMState: "STARTED"}.Description())
+	assert.Equal(t, "STARTING (k8s-waiting)", BackendState{VMState: "STARTING", SubState: "k8s-waiting"}.Description())
+}
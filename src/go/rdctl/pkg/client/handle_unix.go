@@ -3,13 +3,23 @@
 package client
 
 import (
+	"context"
 	"errors"
+	"net"
+
 	"golang.org/x/sys/unix"
 )
 
 func handleConnectionRefused(err error) error {
-	if errors.Is(err, unix.ECONNREFUSED) {
+	// A missing socket means that the application isn't running either.
+	if errors.Is(err, unix.ECONNREFUSED) || errors.Is(err, unix.ENOENT) {
 		return ErrConnectionRefused
 	}
 	return err
 }
+
+// dialSocket connects to the Unix socket.
+func dialSocket(ctx context.Context, path string) (net.Conn, error) {
+	var dialer net.Dialer
+	return dialer.DialContext(ctx, "unix", path)
+}
@@ -1,13 +1,29 @@
 package client
 
 import (
+	"context"
 	"errors"
+	"net"
+	"strings"
+
+	"github.com/Microsoft/go-winio"
 	"golang.org/x/sys/windows"
 )
 
 func handleConnectionRefused(err error) error {
-	if errors.Is(err, windows.WSAECONNREFUSED) {
+	// A missing named pipe means that the application isn't running either.
+	if errors.Is(err, windows.WSAECONNREFUSED) || errors.Is(err, windows.ERROR_FILE_NOT_FOUND) {
 		return ErrConnectionRefused
 	}
 	return err
 }
+
+// dialSocket connects to the named pipe, or to the Unix socket if the path
+// isn't that of a named pipe.
+func dialSocket(ctx context.Context, path string) (net.Conn, error) {
+	if strings.HasPrefix(path, `\\.\pipe\`) {
+		return winio.DialPipeContext(ctx, path)
+	}
+	var dialer net.Dialer
+	return dialer.DialContext(ctx, "unix", path)
+}
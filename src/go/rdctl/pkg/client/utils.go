@@ -1,12 +1,19 @@
 package client
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/exitcode"
 )
 
+// ShowSecretsQuery asks `GET /settings` not to redact secrets; only admin
+// credentials can see them.
+const ShowSecretsQuery = "showSecrets=true"
+
 func VersionCommand(version string, command string) string {
 	if version == "" {
 		version = ApiVersion
@@ -57,7 +64,7 @@ func ProcessRequestForUtility(response *http.Response, err error) ([]byte, error
 			// Prefer the error message in the body written by the command-server, not the one from the http server.
 			break
 		case 401:
-			return nil, fmt.Errorf("%s: user/password not accepted", response.Status)
+			return nil, fmt.Errorf("%s: %w", response.Status, ErrAuthFailure)
 		case 413:
 			return nil, fmt.Errorf("%s", response.Status)
 		case 500:
@@ -76,7 +83,8 @@ func ProcessRequestForUtility(response *http.Response, err error) ([]byte, error
 		}
 		return nil, err
 	} else if statusMessage != "" {
-		return nil, fmt.Errorf("%s", string(body))
+		// The command server rejected the request as invalid.
+		return nil, exitcode.WithCode(errors.New(string(body)), exitcode.InvalidInput)
 	}
 	return body, nil
 }
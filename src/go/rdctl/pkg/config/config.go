@@ -19,7 +19,8 @@ package config
 
 import (
 	"bytes"
-	"encoding/json"
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"fmt"
 	"log"
@@ -27,24 +28,171 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/checks"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/exitcode"
 	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/profile"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
+// ErrMainProcessNotRunning means Rancher Desktop isn't running, as it hasn't
+// written the config file it writes when it starts.
+var ErrMainProcessNotRunning = exitcode.WithCode(errors.New("Rancher Desktop is not running"), exitcode.NotRunning)
+
 // ConnectionInfo stores the parameters needed to connect to an HTTP server
 type ConnectionInfo struct {
 	User     string
 	Password string
 	Host     string
 	Port     int
+	// Socket is the path of a Unix socket, or the name of a named pipe on
+	// Windows, to connect to instead of Host and Port.
+	Socket string
+	// TLS makes requests use HTTPS. CACert is the certificate of the
+	// authority that signed the certificate of the server, if the system
+	// doesn't trust it; ClientCert and ClientKey authenticate rdctl to the
+	// server. All are PEM files; relative paths in config files are relative
+	// to the directory of the file.
+	TLS        bool
+	CACert     string `yaml:"caCert"`
+	ClientCert string `yaml:"clientCert"`
+	ClientKey  string `yaml:"clientKey"`
+	// Timeout limits the time of each request; zero means no limit.
+	Timeout Duration
+	// Retries is the number of times to retry a request when the server
+	// refuses the connection, e.g. because the application is still starting.
+	Retries int
+	// RetryBackoff is the time to wait before the first retry, which doubles
+	// for each further retry.
+	RetryBackoff Duration `yaml:"retryBackoff"`
+}
+
+// namedPipePrefix starts the names of named pipes on Windows.
+const namedPipePrefix = `\\.\pipe\`
+
+// Address describes where the connection goes, for messages.
+func (c *ConnectionInfo) Address() string {
+	if c.Socket != "" {
+		return c.Socket
+	}
+	return fmt.Sprintf("%s:%d", c.Host, c.Port)
+}
+
+// Endpoint identifies the server and the user the connection is for, e.g. to
+// keep the responses of different servers apart.
+func (c *ConnectionInfo) Endpoint() string {
+	return c.User + "@" + c.Address()
 }
 
+// resolvePaths makes the paths of the certificates of a config file in the
+// directory relative to it.
+func (c *ConnectionInfo) resolvePaths(dir string) {
+	for _, path := range []*string{&c.CACert, &c.ClientCert, &c.ClientKey} {
+		if *path != "" && !filepath.IsAbs(*path) {
+			*path = filepath.Join(dir, *path)
+		}
+	}
+}
+
+// TLSConfig returns the configuration of TLS connections to the server, with
+// the certificates of the connection info.
+func (c *ConnectionInfo) TLSConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if c.CACert != "" {
+		pem, err := os.ReadFile(c.CACert)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA certificate: %w", err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("failed to read CA certificate %q: no certificates found", c.CACert)
+		}
+	}
+	if c.ClientCert != "" {
+		certificate, err := tls.LoadX509KeyPair(c.ClientCert, c.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+	return tlsConfig, nil
+}
+
+// Duration is a time.Duration given as a string in config files, e.g. "5s".
+type Duration time.Duration
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (d *Duration) UnmarshalText(text []byte) error {
+	duration, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(duration)
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// configFile is the format of the config file (rd-engine.json) written by
+// the application. Users may also write it as YAML or TOML; field names are
+// matched case-insensitively in JSON and TOML, and are lowercase in YAML.
+type configFile struct {
+	ConnectionInfo `yaml:",inline"`
+	// CredentialStore names the docker credential helper (e.g. "osxkeychain")
+	// with which the application stored the passwords in the keychain of the
+	// OS; the file then only has the passwords that could not be stored.
+	CredentialStore string `yaml:"credentialStore"`
+	// Viewer holds credentials that can only be used for GET requests.
+	Viewer *struct {
+		User     string
+		Password string
+	}
+	// Profiles are named sets of settings, selected with --profile. As the
+	// application rewrites rd-engine.json, they belong in a config file of
+	// the user's, given with --config-path or RDCTL_CONFIG_PATH.
+	Profiles map[string]profileSettings
+}
+
+// profileSettings is a named profile of the config file. Its settings override
+// those of the config file at ConfigPath if set, or else the top-level ones.
+type profileSettings struct {
+	// ConfigPath is the config file of an instance of the application, such
+	// as the rd-engine.json of an instance running in a WSL distribution;
+	// reading it picks up the credentials the instance generates at startup.
+	ConfigPath     string `yaml:"configPath"`
+	ConnectionInfo `yaml:",inline"`
+}
+
+// Environment variables overriding the settings of the config file; the
+// command-line flags take precedence over them. The password in particular is
+// better passed in the environment, as command lines are visible to all users.
+const (
+	userEnvVar       = "RDCTL_USER"
+	passwordEnvVar   = "RDCTL_PASSWORD"
+	hostEnvVar       = "RDCTL_HOST"
+	portEnvVar       = "RDCTL_PORT"
+	socketEnvVar     = "RDCTL_SOCKET"
+	configPathEnvVar = "RDCTL_CONFIG_PATH"
+	profileEnvVar    = "RDCTL_PROFILE"
+)
+
 var (
 	connectionSettings ConnectionInfo
+	// globalFlags tells which of connectionSettings were given on the command
+	// line, for the settings where zero is a meaningful value.
+	globalFlags *pflag.FlagSet
+	useViewer   bool
 
-	configPath string
+	configPath  string
+	profileName string
 	// DefaultConfigPath - used to differentiate not being able to find a user-specified config file from the default
 	DefaultConfigPath string
 )
@@ -66,62 +214,324 @@ func DefineGlobalFlags(rootCmd *cobra.Command) {
 		configDir = appPaths.AppHome
 	}
 	DefaultConfigPath = filepath.Join(configDir, "rd-engine.json")
-	rootCmd.PersistentFlags().StringVar(&configPath, "config-path", "", fmt.Sprintf("config file (default %s)", DefaultConfigPath))
-	rootCmd.PersistentFlags().StringVar(&connectionSettings.User, "user", "", "overrides the user setting in the config file")
-	rootCmd.PersistentFlags().StringVar(&connectionSettings.Host, "host", "", "default is 127.0.0.1; most useful for WSL")
-	rootCmd.PersistentFlags().IntVar(&connectionSettings.Port, "port", 0, "overrides the port setting in the config file")
-	rootCmd.PersistentFlags().StringVar(&connectionSettings.Password, "password", "", "overrides the password setting in the config file")
+	rootCmd.PersistentFlags().StringVar(&configPath, "config-path", "", fmt.Sprintf("config file, in JSON, YAML, or TOML; overrides %s (default %s)", configPathEnvVar, DefaultConfigPath))
+	rootCmd.PersistentFlags().StringVar(&profileName, "profile", "", fmt.Sprintf("profile of the config file to use; overrides %s", profileEnvVar))
+	rootCmd.PersistentFlags().StringVar(&connectionSettings.User, "user", "", fmt.Sprintf("overrides %s and the user setting in the config file", userEnvVar))
+	rootCmd.PersistentFlags().StringVar(&connectionSettings.Host, "host", "", fmt.Sprintf("overrides %s; default is 127.0.0.1; most useful for WSL", hostEnvVar))
+	rootCmd.PersistentFlags().IntVar(&connectionSettings.Port, "port", 0, fmt.Sprintf("overrides %s and the port setting in the config file", portEnvVar))
+	rootCmd.PersistentFlags().StringVar(&connectionSettings.Socket, "socket", "", fmt.Sprintf("Unix socket, or named pipe on Windows, to connect to instead of the host and port; overrides %s and the socket setting in the config file", socketEnvVar))
+	rootCmd.PersistentFlags().BoolVar(&connectionSettings.TLS, "tls", false, "connect with HTTPS; overrides the tls setting in the config file")
+	rootCmd.PersistentFlags().StringVar(&connectionSettings.CACert, "cacert", "", "PEM file of the certificate authority of the server, for --tls; overrides the caCert setting in the config file")
+	rootCmd.PersistentFlags().StringVar(&connectionSettings.ClientCert, "client-cert", "", "PEM file of the client certificate, for --tls; overrides the clientCert setting in the config file")
+	rootCmd.PersistentFlags().StringVar(&connectionSettings.ClientKey, "client-key", "", "PEM file of the key of the client certificate, for --tls; overrides the clientKey setting in the config file")
+	rootCmd.PersistentFlags().DurationVar((*time.Duration)(&connectionSettings.Timeout), "timeout", 0, "time limit of each request to the application, e.g. 10s; overrides the timeout setting in the config file (default no limit)")
+	rootCmd.PersistentFlags().IntVar(&connectionSettings.Retries, "retries", 0, "number of times to retry requests while the application refuses connections, e.g. while it starts; overrides the retries setting in the config file")
+	rootCmd.PersistentFlags().StringVar(&connectionSettings.Password, "password", "", fmt.Sprintf("overrides %s and the password setting in the config file; prefer %s, as command lines are visible to other users", passwordEnvVar, passwordEnvVar))
+	globalFlags = rootCmd.PersistentFlags()
+}
+
+// UseViewerCredentials makes GetConnectionInfo return the credentials of the
+// viewer role, which can't change anything, if the config file has them.
+// Credentials given on the command line still take precedence.
+func UseViewerCredentials() {
+	useViewer = true
 }
 
 // GetConnectionInfo returns the connection details of the application API server.
+// Each setting comes from, in order of precedence: the command-line flags, the
+// RDCTL_* environment variables, the selected profile, the config file, and
+// the defaults.
 // As a special case this function may return a nil *ConnectionInfo and nil error
 // when the config file has not been specified explicitly, the default config file
 // does not exist, and the mayBeMissing parameter is true.
 func GetConnectionInfo(mayBeMissing bool) (*ConnectionInfo, error) {
-	var settings ConnectionInfo
+	defer profile.Start(profile.Config, "connection info")()
+	settings, _, err := loadConnectionInfo(mayBeMissing)
+	return settings, err
+}
 
-	if configPath == "" {
-		configPath = DefaultConfigPath
+// GetEndpoint returns the Endpoint of the connection info GetConnectionInfo
+// would return, or "" if it would return nil. Unlike GetConnectionInfo, it
+// doesn't get the password from the keychain, which may take a while.
+func GetEndpoint(mayBeMissing bool) (string, error) {
+	settings, _, err := resolveConnectionInfo(mayBeMissing, false)
+	if err != nil || settings == nil {
+		return "", err
+	}
+	return settings.Endpoint(), nil
+}
+
+// loadConnectionInfo implements GetConnectionInfo, and also returns the paths
+// of the config files the settings depend on, whether they exist or not.
+func loadConnectionInfo(mayBeMissing bool) (*ConnectionInfo, []string, error) {
+	return resolveConnectionInfo(mayBeMissing, true)
+}
+
+// resolveConnectionInfo implements loadConnectionInfo; without withPassword,
+// a password kept in the keychain is left empty.
+func resolveConnectionInfo(mayBeMissing, withPassword bool) (*ConnectionInfo, []string, error) {
+	envSettings, err := connectionInfoFromEnv()
+	if err != nil {
+		return nil, nil, err
 	}
-	content, readFileError := os.ReadFile(configPath)
+	resolveSelection()
+	sources := []string{configPath}
+	file, readFileError := readConfigFile(configPath)
 	if readFileError != nil {
 		// It is ok if the default config path doesn't exist; the user may have specified the required settings on the commandline.
-		// But it is an error if the file specified via --config-path can not be read.
-		if configPath != DefaultConfigPath || !errors.Is(readFileError, os.ErrNotExist) {
-			return nil, readFileError
+		// But it is an error if the file specified via --config-path or RDCTL_CONFIG_PATH can not be read,
+		// or if it must hold the selected profile.
+		if configPath != DefaultConfigPath || !errors.Is(readFileError, os.ErrNotExist) || profileName != "" {
+			return nil, nil, readFileError
+		}
+	}
+	settings := file.credentials()
+	credentialStore, keychainServerURL := file.CredentialStore, file.keychainServerURL()
+	if profileName != "" {
+		selected, ok := file.Profiles[profileName]
+		if !ok {
+			return nil, nil, fmt.Errorf("profile %q not found in config file %q", profileName, configPath)
+		}
+		if selected.ConfigPath != "" {
+			sources = append(sources, selected.ConfigPath)
+			instanceFile, err := readConfigFile(selected.ConfigPath)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to read the config file of profile %q: %w", profileName, err)
+			}
+			settings = instanceFile.credentials()
+			credentialStore, keychainServerURL = instanceFile.CredentialStore, instanceFile.keychainServerURL()
 		}
-	} else if err := json.Unmarshal(content, &settings); err != nil {
-		return nil, fmt.Errorf("error parsing config file %q: %w", configPath, err)
+		settings.override(selected.ConnectionInfo)
 	}
 
-	// CLI options override file settings
-	if connectionSettings.Host != "" {
-		settings.Host = connectionSettings.Host
+	// Environment variables override file settings, and CLI options override both
+	settings.override(envSettings)
+	settings.override(connectionSettings)
+	// --timeout 0 and --retries 0 turn off the limit and the retries, and
+	// --tls=false turns off TLS.
+	if flagChanged("tls") {
+		settings.TLS = connectionSettings.TLS
 	}
-	if settings.Host == "" {
-		settings.Host = "127.0.0.1"
+	if flagChanged("timeout") {
+		settings.Timeout = connectionSettings.Timeout
 	}
-	if connectionSettings.User != "" {
-		settings.User = connectionSettings.User
+	if flagChanged("retries") {
+		settings.Retries = connectionSettings.Retries
 	}
-	if connectionSettings.Password != "" {
-		settings.Password = connectionSettings.Password
+	if settings.Host == "" {
+		settings.Host = "127.0.0.1"
 	}
-	if connectionSettings.Port != 0 {
-		settings.Port = connectionSettings.Port
+	hasPassword := settings.Password != "" || (credentialStore != "" && !withPassword)
+	if settings.Password == "" && credentialStore != "" && withPassword {
+		if settings.Password, err = keychainPassword(credentialStore, keychainServerURL); err != nil {
+			return nil, nil, err
+		}
+		hasPassword = settings.Password != ""
 	}
-	if settings.Port == 0 || settings.User == "" || settings.Password == "" {
+	if (settings.Port == 0 && settings.Socket == "") || settings.User == "" || !hasPassword {
 		// Missing the default config file may or may not be considered an error
 		if readFileError != nil {
 			if mayBeMissing {
-				readFileError = nil
+				return nil, sources, nil
 			}
-			return nil, readFileError
+			// The application writes the default config file when it starts.
+			return nil, nil, fmt.Errorf("%w: %w", ErrMainProcessNotRunning, readFileError)
 		}
-		return nil, errors.New("insufficient connection settings (missing one or more of: port, user, and password)")
+		return nil, nil, invalidInput(errors.New("insufficient connection settings (missing one or more of: port or socket, user, and password)"))
+	}
+	if settings.Socket == "" && (settings.Port < 0 || settings.Port > 65535) {
+		return nil, nil, invalidInput(fmt.Errorf("invalid port %d: must be a port number", settings.Port))
+	}
+	if settings.Timeout < 0 || settings.Retries < 0 || settings.RetryBackoff < 0 {
+		return nil, nil, invalidInput(errors.New("invalid timeout, retries, or retryBackoff: must not be negative"))
 	}
+	if (settings.ClientCert == "") != (settings.ClientKey == "") {
+		return nil, nil, invalidInput(errors.New("invalid TLS settings: the client certificate and its key must be given together"))
+	}
+
+	return &settings, sources, nil
+}
+
+func invalidInput(err error) error {
+	return exitcode.WithCode(err, exitcode.InvalidInput)
+}
+
+// flagChanged returns whether the global flag was given on the command line.
+func flagChanged(name string) bool {
+	return globalFlags != nil && globalFlags.Changed(name)
+}
+
+// resolveSelection sets the config file and the profile from the environment,
+// if they weren't given on the command line.
+func resolveSelection() {
+	if configPath == "" {
+		configPath = os.Getenv(configPathEnvVar)
+	}
+	if configPath == "" {
+		configPath = DefaultConfigPath
+	}
+	if profileName == "" {
+		profileName = os.Getenv(profileEnvVar)
+	}
+}
+
+// ConfigPath returns the path of the config file GetConnectionInfo reads.
+func ConfigPath() string {
+	resolveSelection()
+	return configPath
+}
+
+// ProfileName returns the name of the selected profile, if any.
+func ProfileName() string {
+	resolveSelection()
+	return profileName
+}
+
+// CheckConfigFile checks that the config file, and that of the selected
+// profile, can be read, and that the environment variables are valid.
+func CheckConfigFile() []checks.Check {
+	resolveSelection()
+	results := []checks.Check{}
+	check := checks.Check{Name: "config file", Status: checks.OK}
+	file, err := readConfigFile(configPath)
+	switch {
+	case err == nil:
+		check.Message = fmt.Sprintf("read %s", configPath)
+	case configPath == DefaultConfigPath && profileName == "" && errors.Is(err, os.ErrNotExist):
+		check.Status = checks.Warning
+		check.Message = fmt.Sprintf("%s doesn't exist; the application writes it when it starts", configPath)
+	default:
+		check.Status = checks.Failed
+		check.Message = err.Error()
+	}
+	results = append(results, check)
+	if profileName != "" && err == nil {
+		check = checks.Check{Name: "profile", Status: checks.OK, Message: fmt.Sprintf("using profile %q", profileName)}
+		if selected, ok := file.Profiles[profileName]; !ok {
+			check.Status = checks.Failed
+			check.Message = fmt.Sprintf("profile %q not found in config file %q", profileName, configPath)
+		} else if selected.ConfigPath != "" {
+			if _, err := readConfigFile(selected.ConfigPath); err != nil {
+				check.Status = checks.Failed
+				check.Message = fmt.Sprintf("failed to read the config file of profile %q: %s", profileName, err)
+			} else {
+				check.Message = fmt.Sprintf("using profile %q, based on %s", profileName, selected.ConfigPath)
+			}
+		}
+		results = append(results, check)
+	}
+	check = checks.Check{Name: "environment", Status: checks.OK, Message: "the RDCTL_* variables are valid"}
+	if _, err := connectionInfoFromEnv(); err != nil {
+		check.Status = checks.Failed
+		check.Message = err.Error()
+	}
+	return append(results, check)
+}
+
+// readConfigFile reads a config file in any of the supported formats.
+func readConfigFile(path string) (configFile, error) {
+	var file configFile
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return file, err
+	}
+	if err := unmarshalConfig(detectFormat(path, content), content, &file); err != nil {
+		return file, fmt.Errorf("error parsing config file %q: %w", path, err)
+	}
+	dir := filepath.Dir(path)
+	file.ConnectionInfo.resolvePaths(dir)
+	for name, selected := range file.Profiles {
+		selected.ConnectionInfo.resolvePaths(dir)
+		file.Profiles[name] = selected
+	}
+	return file, nil
+}
+
+// credentials returns the connection settings of the config file, with the
+// viewer credentials if they were requested.
+func (f *configFile) credentials() ConnectionInfo {
+	settings := f.ConnectionInfo
+	// Older versions of the application don't write viewer credentials.
+	if useViewer && f.Viewer != nil && f.Viewer.User != "" {
+		settings.User, settings.Password = f.Viewer.User, f.Viewer.Password
+	}
+	// Named pipes can't be reached from outside Windows, e.g. by rdctl in a
+	// WSL distribution reading the config file of the application; it then
+	// uses the port.
+	if runtime.GOOS != "windows" && strings.HasPrefix(settings.Socket, namedPipePrefix) {
+		settings.Socket = ""
+	}
+	return settings
+}
+
+// keychainServerURL returns the key in the keychain of the password that
+// credentials() returns, for when the file leaves it out.
+func (f *configFile) keychainServerURL() string {
+	if useViewer && f.Viewer != nil && f.Viewer.User != "" {
+		return apiViewerCredentialsServerURL
+	}
+	return apiCredentialsServerURL
+}
+
+// connectionInfoFromEnv returns the settings given by the RDCTL_*
+// environment variables.
+func connectionInfoFromEnv() (ConnectionInfo, error) {
+	settings := ConnectionInfo{
+		User:     os.Getenv(userEnvVar),
+		Password: os.Getenv(passwordEnvVar),
+		Host:     os.Getenv(hostEnvVar),
+		Socket:   os.Getenv(socketEnvVar),
+	}
+	if port := os.Getenv(portEnvVar); port != "" {
+		var err error
+		if settings.Port, err = strconv.Atoi(port); err != nil || settings.Port <= 0 || settings.Port > 65535 {
+			return ConnectionInfo{}, fmt.Errorf("invalid %s %q: must be a port number", portEnvVar, port)
+		}
+	}
+	return settings, nil
+}
 
-	return &settings, nil
+// override replaces the settings that are set in overrides. Giving the host
+// or port but no socket means connecting over TCP, so the socket is dropped.
+func (c *ConnectionInfo) override(overrides ConnectionInfo) {
+	if overrides.Socket != "" {
+		c.Socket = overrides.Socket
+	} else if overrides.Host != "" || overrides.Port != 0 {
+		c.Socket = ""
+	}
+	if overrides.User != "" {
+		c.User = overrides.User
+	}
+	if overrides.Password != "" {
+		c.Password = overrides.Password
+	}
+	if overrides.Host != "" {
+		c.Host = overrides.Host
+	}
+	if overrides.Port != 0 {
+		c.Port = overrides.Port
+	}
+	if overrides.TLS {
+		c.TLS = true
+	}
+	if overrides.CACert != "" {
+		c.CACert = overrides.CACert
+	}
+	if overrides.ClientCert != "" {
+		c.ClientCert = overrides.ClientCert
+	}
+	if overrides.ClientKey != "" {
+		c.ClientKey = overrides.ClientKey
+	}
+	if overrides.Timeout != 0 {
+		c.Timeout = overrides.Timeout
+	}
+	if overrides.Retries != 0 {
+		c.Retries = overrides.Retries
+	}
+	if overrides.RetryBackoff != 0 {
+		c.RetryBackoff = overrides.RetryBackoff
+	}
 }
 
 // determines if we are running in a wsl linux distro
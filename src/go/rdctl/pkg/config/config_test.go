@@ -0,0 +1,465 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/checks"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetConnectionInfoViewer(t *testing.T) {
+	writeConfig := func(t *testing.T, contents string) {
+		configPath = filepath.Join(t.TempDir(), "rd-engine.json")
+		require.NoError(t, os.WriteFile(configPath, []byte(contents), 0o600))
+		t.Cleanup(func() {
+			configPath = ""
+			useViewer = false
+		})
+	}
+
+	t.Run("admin credentials by default", func(t *testing.T) {
+		writeConfig(t, `{"user": "user", "password": "secret", "port": 6107, "viewer": {"user": "viewer", "password": "peek"}}`)
+		info, err := GetConnectionInfo(false)
+		require.NoError(t, err)
+		assert.Equal(t, ConnectionInfo{User: "user", Password: "secret", Host: "127.0.0.1", Port: 6107}, *info)
+	})
+	t.Run("viewer credentials", func(t *testing.T) {
+		writeConfig(t, `{"user": "user", "password": "secret", "port": 6107, "viewer": {"user": "viewer", "password": "peek"}}`)
+		UseViewerCredentials()
+		info, err := GetConnectionInfo(false)
+		require.NoError(t, err)
+		assert.Equal(t, ConnectionInfo{User: "viewer", Password: "peek", Host: "127.0.0.1", Port: 6107}, *info)
+	})
+	t.Run("no viewer credentials in the config file", func(t *testing.T) {
+		writeConfig(t, `{"user": "user", "password": "secret", "port": 6107}`)
+		UseViewerCredentials()
+		info, err := GetConnectionInfo(false)
+		require.NoError(t, err)
+		assert.Equal(t, "user", info.User)
+	})
+}
+
+func TestGetConnectionInfoFormats(t *testing.T) {
+	expected := ConnectionInfo{User: "user", Password: "secret", Host: "127.0.0.1", Port: 6107}
+	for name, contents := range map[string]string{
+		"rd-engine.json": `{"user": "user", "password": "secret", "port": 6107}`,
+		"rd-engine.yaml": "user: user\npassword: secret\nport: 6107\n",
+		"rd-engine.yml":  "# comment\nuser: user\npassword: secret\nport: 6107\n",
+		"rd-engine.toml": "user = \"user\"\npassword = \"secret\"\nport = 6107\n",
+		"sniffed-yaml":   "---\nuser: user\npassword: secret\nport: 6107\n",
+		"sniffed-toml":   "user = \"user\"\npassword = \"secret\"\nport = 6107\n",
+		"sniffed-json":   `{"user": "user", "password": "secret", "port": 6107}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			configPath = filepath.Join(t.TempDir(), name)
+			require.NoError(t, os.WriteFile(configPath, []byte(contents), 0o600))
+			t.Cleanup(func() { configPath = "" })
+			info, err := GetConnectionInfo(false)
+			require.NoError(t, err)
+			assert.Equal(t, expected, *info)
+		})
+	}
+	t.Run("viewer credentials in YAML", func(t *testing.T) {
+		configPath = filepath.Join(t.TempDir(), "rd-engine.yaml")
+		require.NoError(t, os.WriteFile(configPath, []byte("user: user\npassword: secret\nport: 6107\nviewer:\n  user: viewer\n  password: peek\n"), 0o600))
+		UseViewerCredentials()
+		t.Cleanup(func() {
+			configPath = ""
+			useViewer = false
+		})
+		info, err := GetConnectionInfo(false)
+		require.NoError(t, err)
+		assert.Equal(t, "viewer", info.User)
+	})
+	t.Run("retries", func(t *testing.T) {
+		expected := expected
+		expected.Timeout = Duration(10 * time.Second)
+		expected.Retries = 3
+		expected.RetryBackoff = Duration(250 * time.Millisecond)
+		for name, contents := range map[string]string{
+			"rd-engine.json": `{"user": "user", "password": "secret", "port": 6107, "timeout": "10s", "retries": 3, "retryBackoff": "250ms"}`,
+			"rd-engine.yaml": "user: user\npassword: secret\nport: 6107\ntimeout: 10s\nretries: 3\nretryBackoff: 250ms\n",
+			"rd-engine.toml": "user = \"user\"\npassword = \"secret\"\nport = 6107\ntimeout = \"10s\"\nretries = 3\nretryBackoff = \"250ms\"\n",
+		} {
+			configPath = filepath.Join(t.TempDir(), name)
+			require.NoError(t, os.WriteFile(configPath, []byte(contents), 0o600))
+			info, err := GetConnectionInfo(false)
+			require.NoError(t, err, name)
+			assert.Equal(t, expected, *info, name)
+		}
+		configPath = filepath.Join(t.TempDir(), "rd-engine.json")
+		t.Cleanup(func() { configPath = "" })
+		require.NoError(t, os.WriteFile(configPath, []byte(`{"user": "user", "password": "secret", "port": 6107, "timeout": "soon"}`), 0o600))
+		_, err := GetConnectionInfo(false)
+		assert.ErrorContains(t, err, "error parsing config file")
+	})
+	t.Run("invalid YAML", func(t *testing.T) {
+		configPath = filepath.Join(t.TempDir(), "rd-engine.yaml")
+		require.NoError(t, os.WriteFile(configPath, []byte("port: [\n"), 0o600))
+		t.Cleanup(func() { configPath = "" })
+		_, err := GetConnectionInfo(false)
+		assert.ErrorContains(t, err, "error parsing config file")
+	})
+}
+
+func TestGetConnectionInfoPrecedence(t *testing.T) {
+	writeConfig := func(t *testing.T) string {
+		path := filepath.Join(t.TempDir(), "rd-engine.json")
+		require.NoError(t, os.WriteFile(path, []byte(`{"user": "file-user", "password": "file-secret", "port": 6107}`), 0o600))
+		t.Cleanup(func() {
+			configPath = ""
+			connectionSettings = ConnectionInfo{}
+		})
+		return path
+	}
+
+	t.Run("environment overrides the config file", func(t *testing.T) {
+		configPath = writeConfig(t)
+		t.Setenv("RDCTL_USER", "env-user")
+		t.Setenv("RDCTL_PASSWORD", "env-secret")
+		t.Setenv("RDCTL_HOST", "192.168.1.2")
+		t.Setenv("RDCTL_PORT", "6108")
+		info, err := GetConnectionInfo(false)
+		require.NoError(t, err)
+		assert.Equal(t, ConnectionInfo{User: "env-user", Password: "env-secret", Host: "192.168.1.2", Port: 6108}, *info)
+	})
+	t.Run("flags override the environment", func(t *testing.T) {
+		configPath = writeConfig(t)
+		t.Setenv("RDCTL_USER", "env-user")
+		t.Setenv("RDCTL_PORT", "6108")
+		connectionSettings = ConnectionInfo{User: "flag-user"}
+		info, err := GetConnectionInfo(false)
+		require.NoError(t, err)
+		assert.Equal(t, ConnectionInfo{User: "flag-user", Password: "file-secret", Host: "127.0.0.1", Port: 6108}, *info)
+	})
+	t.Run("flags set retries and the timeout to zero", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "rd-engine.json")
+		require.NoError(t, os.WriteFile(path, []byte(`{"user": "user", "password": "secret", "port": 6107, "timeout": "10s", "retries": 3}`), 0o600))
+		rootCmd := &cobra.Command{Use: "rdctl"}
+		DefineGlobalFlags(rootCmd)
+		configPath = path
+		t.Cleanup(func() {
+			configPath = ""
+			connectionSettings = ConnectionInfo{}
+			globalFlags = nil
+		})
+		require.NoError(t, rootCmd.ParseFlags([]string{"--retries", "0", "--timeout", "0"}))
+		info, err := GetConnectionInfo(false)
+		require.NoError(t, err)
+		assert.Equal(t, ConnectionInfo{User: "user", Password: "secret", Host: "127.0.0.1", Port: 6107}, *info)
+	})
+	t.Run("config path from the environment", func(t *testing.T) {
+		t.Setenv("RDCTL_CONFIG_PATH", writeConfig(t))
+		info, err := GetConnectionInfo(false)
+		require.NoError(t, err)
+		assert.Equal(t, "file-user", info.User)
+	})
+	t.Run("missing config file from the environment", func(t *testing.T) {
+		writeConfig(t)
+		t.Setenv("RDCTL_CONFIG_PATH", filepath.Join(t.TempDir(), "missing.json"))
+		_, err := GetConnectionInfo(true)
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+	t.Run("invalid port", func(t *testing.T) {
+		configPath = writeConfig(t)
+		t.Setenv("RDCTL_PORT", "http")
+		_, err := GetConnectionInfo(false)
+		assert.EqualError(t, err, `invalid RDCTL_PORT "http": must be a port number`)
+	})
+}
+
+func TestGetConnectionInfoSocket(t *testing.T) {
+	writeConfig := func(t *testing.T, contents string) {
+		configPath = filepath.Join(t.TempDir(), "rd-engine.json")
+		require.NoError(t, os.WriteFile(configPath, []byte(contents), 0o600))
+		t.Cleanup(func() {
+			configPath = ""
+			connectionSettings = ConnectionInfo{}
+		})
+	}
+
+	t.Run("socket from the config file", func(t *testing.T) {
+		writeConfig(t, `{"user": "user", "password": "secret", "port": 6107, "socket": "/run/rd-engine.sock"}`)
+		info, err := GetConnectionInfo(false)
+		require.NoError(t, err)
+		assert.Equal(t, "/run/rd-engine.sock", info.Socket)
+		assert.Equal(t, "/run/rd-engine.sock", info.Address())
+	})
+	t.Run("socket without a port", func(t *testing.T) {
+		writeConfig(t, `{"user": "user", "password": "secret"}`)
+		t.Setenv("RDCTL_SOCKET", "/run/rd-engine.sock")
+		info, err := GetConnectionInfo(false)
+		require.NoError(t, err)
+		assert.Equal(t, ConnectionInfo{User: "user", Password: "secret", Host: "127.0.0.1", Socket: "/run/rd-engine.sock"}, *info)
+	})
+	t.Run("port from the environment means TCP", func(t *testing.T) {
+		writeConfig(t, `{"user": "user", "password": "secret", "port": 6107, "socket": "/run/rd-engine.sock"}`)
+		t.Setenv("RDCTL_PORT", "6108")
+		info, err := GetConnectionInfo(false)
+		require.NoError(t, err)
+		assert.Empty(t, info.Socket)
+		assert.Equal(t, "127.0.0.1:6108", info.Address())
+	})
+	t.Run("host flag means TCP", func(t *testing.T) {
+		writeConfig(t, `{"user": "user", "password": "secret", "port": 6107, "socket": "/run/rd-engine.sock"}`)
+		connectionSettings = ConnectionInfo{Host: "192.168.1.2"}
+		info, err := GetConnectionInfo(false)
+		require.NoError(t, err)
+		assert.Empty(t, info.Socket)
+	})
+	t.Run("named pipe", func(t *testing.T) {
+		writeConfig(t, `{"user": "user", "password": "secret", "port": 6107, "socket": "\\\\.\\pipe\\rancher-desktop-api-user"}`)
+		info, err := GetConnectionInfo(false)
+		require.NoError(t, err)
+		if runtime.GOOS == "windows" {
+			assert.Equal(t, `\\.\pipe\rancher-desktop-api-user`, info.Socket)
+		} else {
+			assert.Empty(t, info.Socket, "named pipes can only be used on Windows")
+		}
+	})
+}
+
+func TestGetConnectionInfoTLS(t *testing.T) {
+	writeConfig := func(t *testing.T, contents string) string {
+		dir := t.TempDir()
+		configPath = filepath.Join(dir, "rd-engine.yaml")
+		require.NoError(t, os.WriteFile(configPath, []byte(contents), 0o600))
+		t.Cleanup(func() {
+			configPath = ""
+			connectionSettings = ConnectionInfo{}
+			globalFlags = nil
+		})
+		return dir
+	}
+
+	t.Run("paths relative to the config file", func(t *testing.T) {
+		dir := writeConfig(t, "user: user\npassword: secret\nport: 6107\ntls: true\ncaCert: ca.pem\nclientCert: certs/client.pem\nclientKey: "+filepath.Join(os.TempDir(), "key.pem")+"\n")
+		info, err := GetConnectionInfo(false)
+		require.NoError(t, err)
+		assert.True(t, info.TLS)
+		assert.Equal(t, filepath.Join(dir, "ca.pem"), info.CACert)
+		assert.Equal(t, filepath.Join(dir, "certs", "client.pem"), info.ClientCert)
+		assert.Equal(t, filepath.Join(os.TempDir(), "key.pem"), info.ClientKey)
+	})
+	t.Run("flags", func(t *testing.T) {
+		rootCmd := &cobra.Command{Use: "rdctl"}
+		DefineGlobalFlags(rootCmd)
+		writeConfig(t, "user: user\npassword: secret\nport: 6107\ntls: true\ncaCert: ca.pem\n")
+		require.NoError(t, rootCmd.ParseFlags([]string{"--tls=false", "--cacert", "other.pem"}))
+		info, err := GetConnectionInfo(false)
+		require.NoError(t, err)
+		assert.False(t, info.TLS)
+		assert.Equal(t, "other.pem", info.CACert)
+	})
+	t.Run("client certificate without its key", func(t *testing.T) {
+		writeConfig(t, "user: user\npassword: secret\nport: 6107\ntls: true\nclientCert: client.pem\n")
+		_, err := GetConnectionInfo(false)
+		assert.ErrorContains(t, err, "the client certificate and its key must be given together")
+	})
+	t.Run("invalid CA certificate", func(t *testing.T) {
+		dir := writeConfig(t, "user: user\npassword: secret\nport: 6107\ntls: true\ncaCert: ca.pem\n")
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "ca.pem"), []byte("not a certificate"), 0o600))
+		info, err := GetConnectionInfo(false)
+		require.NoError(t, err)
+		_, err = info.TLSConfig()
+		assert.ErrorContains(t, err, "no certificates found")
+	})
+}
+
+func TestGetConnectionInfoProfiles(t *testing.T) {
+	dir := t.TempDir()
+	instancePath := filepath.Join(dir, "rd-engine.json")
+	require.NoError(t, os.WriteFile(instancePath, []byte(`{"user": "wsl-user", "password": "wsl-secret", "port": 6107}`), 0o600))
+	path := filepath.Join(dir, "rdctl.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`user: local-user
+password: local-secret
+port: 6107
+profiles:
+  remote:
+    host: 192.168.1.2
+    port: 6108
+  wsl:
+    configPath: `+instancePath+`
+    host: 172.20.0.1
+`), 0o600))
+	setup := func(t *testing.T) {
+		configPath = path
+		t.Cleanup(func() {
+			configPath = ""
+			profileName = ""
+		})
+	}
+
+	t.Run("no profile", func(t *testing.T) {
+		setup(t)
+		info, err := GetConnectionInfo(false)
+		require.NoError(t, err)
+		assert.Equal(t, ConnectionInfo{User: "local-user", Password: "local-secret", Host: "127.0.0.1", Port: 6107}, *info)
+	})
+	t.Run("profile overrides the config file", func(t *testing.T) {
+		setup(t)
+		profileName = "remote"
+		info, err := GetConnectionInfo(false)
+		require.NoError(t, err)
+		assert.Equal(t, ConnectionInfo{User: "local-user", Password: "local-secret", Host: "192.168.1.2", Port: 6108}, *info)
+	})
+	t.Run("profile from the environment with its own config file", func(t *testing.T) {
+		setup(t)
+		t.Setenv("RDCTL_PROFILE", "wsl")
+		info, err := GetConnectionInfo(false)
+		require.NoError(t, err)
+		assert.Equal(t, ConnectionInfo{User: "wsl-user", Password: "wsl-secret", Host: "172.20.0.1", Port: 6107}, *info)
+	})
+	t.Run("unknown profile", func(t *testing.T) {
+		setup(t)
+		profileName = "work"
+		_, err := GetConnectionInfo(false)
+		assert.EqualError(t, err, fmt.Sprintf("profile %q not found in config file %q", "work", path))
+	})
+}
+
+func TestGetConnectionInfoKeychain(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake credential helper is a shell script")
+	}
+	dir := t.TempDir()
+	// The fake credential helper only knows the API credentials.
+	helper := `#!/bin/sh
+read -r url
+case "$1 $url" in
+	"get rancher-desktop-api")
+		echo '{"ServerURL": "rancher-desktop-api", "Username": "user", "Secret": "keychain-secret"}'
+		;;
+	"get rancher-desktop-api-viewer")
+		echo '{"ServerURL": "rancher-desktop-api-viewer", "Username": "viewer", "Secret": "viewer-secret"}'
+		;;
+	*)
+		echo "credentials not found in native keychain"
+		exit 1
+		;;
+esac
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "docker-credential-fake"), []byte(helper), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "docker-credential-hanging"), []byte("#!/bin/sh\nexec sleep 60\n"), 0o755))
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	configPath = filepath.Join(dir, "rd-engine.json")
+	t.Cleanup(func() { configPath = "" })
+
+	t.Run("password from the keychain", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(configPath, []byte(`{"user": "user", "port": 6107, "credentialStore": "fake"}`), 0o600))
+		info, err := GetConnectionInfo(false)
+		require.NoError(t, err)
+		assert.Equal(t, ConnectionInfo{User: "user", Password: "keychain-secret", Host: "127.0.0.1", Port: 6107}, *info)
+	})
+	t.Run("the environment takes precedence", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(configPath, []byte(`{"user": "user", "port": 6107, "credentialStore": "missing"}`), 0o600))
+		t.Setenv("RDCTL_PASSWORD", "env-secret")
+		info, err := GetConnectionInfo(false)
+		require.NoError(t, err)
+		assert.Equal(t, "env-secret", info.Password)
+	})
+	t.Run("missing credential helper", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(configPath, []byte(`{"user": "user", "port": 6107, "credentialStore": "missing"}`), 0o600))
+		_, err := GetConnectionInfo(false)
+		assert.ErrorContains(t, err, "set RDCTL_PASSWORD instead")
+	})
+	t.Run("viewer password from the keychain", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(configPath, []byte(`{"user": "user", "port": 6107, "credentialStore": "fake", "viewer": {"user": "viewer"}}`), 0o600))
+		UseViewerCredentials()
+		t.Cleanup(func() { useViewer = false })
+		info, err := GetConnectionInfo(false)
+		require.NoError(t, err)
+		assert.Equal(t, ConnectionInfo{User: "viewer", Password: "viewer-secret", Host: "127.0.0.1", Port: 6107}, *info)
+	})
+	t.Run("credential helper not answering", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(configPath, []byte(`{"user": "user", "port": 6107, "credentialStore": "hanging"}`), 0o600))
+		original := keychainTimeout
+		keychainTimeout = 100 * time.Millisecond
+		t.Cleanup(func() { keychainTimeout = original })
+		start := time.Now()
+		_, err := GetConnectionInfo(false)
+		assert.ErrorContains(t, err, "no answer after 100ms")
+		assert.Less(t, time.Since(start), 10*time.Second)
+	})
+	t.Run("the endpoint doesn't need the password", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(configPath, []byte(`{"user": "user", "port": 6107, "credentialStore": "hanging"}`), 0o600))
+		endpoint, err := GetEndpoint(false)
+		require.NoError(t, err)
+		assert.Equal(t, "user@127.0.0.1:6107", endpoint)
+	})
+}
+
+func TestCheckConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rdctl.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("port: 6107\nprofiles:\n  remote:\n    host: 192.168.1.2\n"), 0o600))
+	setup := func(t *testing.T) {
+		configPath = path
+		t.Cleanup(func() {
+			configPath = ""
+			profileName = ""
+		})
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		setup(t)
+		profileName = "remote"
+		assert.Equal(t, []checks.Check{
+			{Name: "config file", Status: checks.OK, Message: "read " + path},
+			{Name: "profile", Status: checks.OK, Message: `using profile "remote"`},
+			{Name: "environment", Status: checks.OK, Message: "the RDCTL_* variables are valid"},
+		}, CheckConfigFile())
+	})
+	t.Run("invalid", func(t *testing.T) {
+		setup(t)
+		profileName = "work"
+		t.Setenv("RDCTL_PORT", "http")
+		results := CheckConfigFile()
+		require.Len(t, results, 3)
+		assert.Equal(t, checks.Failed, results[1].Status)
+		assert.Equal(t, checks.Check{Name: "environment", Status: checks.Failed, Message: `invalid RDCTL_PORT "http": must be a port number`}, results[2])
+	})
+}
+
+func TestWatchConnectionInfo(t *testing.T) {
+	writeConfig := func(t *testing.T, path, password string) {
+		content := fmt.Sprintf(`{"user": "user", "password": %q, "port": 6107}`, password)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+	path := filepath.Join(t.TempDir(), "rd-engine.json")
+	writeConfig(t, path, "first")
+	configPath = path
+	t.Cleanup(func() { configPath = "" })
+
+	watcher, err := WatchConnectionInfo()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = watcher.Close() })
+	assert.Equal(t, "first", watcher.ConnectionInfo().Password)
+
+	t.Run("rewritten", func(t *testing.T) {
+		writeConfig(t, path, "second")
+		assert.Eventually(t, func() bool {
+			return watcher.ConnectionInfo().Password == "second"
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+	t.Run("replaced", func(t *testing.T) {
+		replacement := path + ".tmp"
+		writeConfig(t, replacement, "third")
+		require.NoError(t, os.Rename(replacement, path))
+		assert.Eventually(t, func() bool {
+			return watcher.ConnectionInfo().Password == "third"
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+	t.Run("invalid", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(path, []byte(`{"user": `), 0o600))
+		writeConfig(t, filepath.Join(filepath.Dir(path), "other.json"), "other")
+		time.Sleep(100 * time.Millisecond)
+		assert.Equal(t, "third", watcher.ConnectionInfo().Password)
+	})
+}
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// configFormat is the format of a config file.
+type configFormat string
+
+const (
+	formatJSON configFormat = "json"
+	formatYAML configFormat = "yaml"
+	formatTOML configFormat = "toml"
+)
+
+// detectFormat returns the format of a config file, from its extension, or
+// from its first significant line if the extension isn't known.
+func detectFormat(path string, content []byte) configFormat {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return formatJSON
+	case ".yaml", ".yml":
+		return formatYAML
+	case ".toml":
+		return formatTOML
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "{") {
+			return formatJSON
+		}
+		// TOML has `key = value` pairs and [tables]; YAML has `key: value`
+		// pairs and documents starting with `---`.
+		equals, colon := strings.Index(line, "="), strings.Index(line, ":")
+		if strings.HasPrefix(line, "[") || (equals >= 0 && (colon < 0 || equals < colon)) {
+			return formatTOML
+		}
+		return formatYAML
+	}
+	return formatJSON
+}
+
+// unmarshalConfig parses the content of a config file in the given format.
+func unmarshalConfig(format configFormat, content []byte, file *configFile) error {
+	switch format {
+	case formatYAML:
+		return yaml.Unmarshal(content, file)
+	case formatTOML:
+		return toml.Unmarshal(content, file)
+	default:
+		return json.Unmarshal(content, file)
+	}
+}
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"time"
+
+	"github.com/docker/docker-credential-helpers/client"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+)
+
+// The keys under which the application stores the API credentials, and those
+// of the viewer role, in the keychain; they must match API_CREDENTIALS_SERVER_URL
+// and API_VIEWER_CREDENTIALS_SERVER_URL in main/commandServer/apiCredentials.ts.
+const (
+	apiCredentialsServerURL       = "rancher-desktop-api"
+	apiViewerCredentialsServerURL = "rancher-desktop-api-viewer"
+)
+
+// keychainTimeout bounds the wait for the credential helper, which may hang
+// waiting for the keychain to be unlocked.
+var keychainTimeout = 10 * time.Second
+
+// keychainPassword returns the API password the application stored in the
+// keychain of the OS under serverURL with the docker credential helper named
+// store (e.g. "osxkeychain"), instead of writing it to the config file.
+func keychainPassword(store, serverURL string) (string, error) {
+	helper, err := findCredentialHelper("docker-credential-" + store)
+	if err != nil {
+		return "", fmt.Errorf("failed to get the password from the keychain: %w; set %s instead", err, passwordEnvVar)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), keychainTimeout)
+	defer cancel()
+	program := func(args ...string) client.Program {
+		cmd := exec.CommandContext(ctx, helper, args...)
+		cmd.Stderr = os.Stderr
+		return &helperProgram{cmd: cmd}
+	}
+	credentials, err := client.Get(program, serverURL)
+	if ctx.Err() != nil {
+		err = fmt.Errorf("no answer after %s", keychainTimeout)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get the password from the keychain with %s: %w; set %s instead", helper, err, passwordEnvVar)
+	}
+	return credentials.Secret, nil
+}
+
+// helperProgram is a client.Program running the credential helper with a
+// deadline, which client.NewShellProgramFunc doesn't support.
+type helperProgram struct {
+	cmd *exec.Cmd
+}
+
+func (p *helperProgram) Output() ([]byte, error) {
+	return p.cmd.Output()
+}
+
+func (p *helperProgram) Input(in io.Reader) {
+	p.cmd.Stdin = in
+}
+
+// findCredentialHelper returns the path of the named credential helper: the
+// one shipped with the application, or else the one in the PATH. In a WSL
+// distribution, that is the Windows executable, run through interop.
+func findCredentialHelper(name string) (string, error) {
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	if appPaths, err := paths.GetPaths(); err == nil {
+		if helper, err := appPaths.FindResource("bin", name); err == nil {
+			return helper, nil
+		}
+	}
+	helper, err := exec.LookPath(name)
+	if err != nil && runtime.GOOS == "linux" && isWSLDistro() {
+		return exec.LookPath(name + ".exe")
+	}
+	return helper, err
+}
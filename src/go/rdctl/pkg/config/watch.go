@@ -0,0 +1,93 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Watcher keeps the connection info of long-running commands up to date: the
+// application writes a new password to its config file whenever it restarts,
+// after which requests with the old one fail with 401 Unauthorized.
+type Watcher struct {
+	mutex   sync.Mutex
+	info    *ConnectionInfo
+	watcher *fsnotify.Watcher
+	// files are the absolute paths of the config files the settings depend on.
+	files map[string]bool
+}
+
+// WatchConnectionInfo returns a watcher holding the connection info returned
+// by GetConnectionInfo, which it reads again whenever one of the config files
+// it comes from changes, until it is closed.
+func WatchConnectionInfo() (*Watcher, error) {
+	info, sources, err := loadConnectionInfo(false)
+	if err != nil {
+		return nil, err
+	}
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch the config file: %w", err)
+	}
+	w := &Watcher{info: info, watcher: fsWatcher, files: map[string]bool{}}
+	for _, source := range sources {
+		path, err := filepath.Abs(source)
+		if err != nil {
+			_ = fsWatcher.Close()
+			return nil, err
+		}
+		w.files[path] = true
+		// Watch the directory, as the file may be replaced rather than
+		// rewritten, which would end a watch on the file itself.
+		if err := fsWatcher.Add(filepath.Dir(path)); err != nil {
+			_ = fsWatcher.Close()
+			return nil, fmt.Errorf("failed to watch config file %q: %w", source, err)
+		}
+	}
+	go w.run()
+	return w, nil
+}
+
+// ConnectionInfo returns the latest valid connection info.
+func (w *Watcher) ConnectionInfo() *ConnectionInfo {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.info
+}
+
+// Close stops watching the config files.
+func (w *Watcher) Close() error {
+	return w.watcher.Close()
+}
+
+func (w *Watcher) run() {
+	for {
+		select {
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if w.files[event.Name] && event.Has(fsnotify.Write|fsnotify.Create) {
+				w.reload()
+			}
+		case _, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+		}
+	}
+}
+
+func (w *Watcher) reload() {
+	info, _, err := loadConnectionInfo(false)
+	if err != nil {
+		// The file may be partially written; a later event will pick up the
+		// complete one.
+		return
+	}
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.info = info
+}
@@ -0,0 +1,146 @@
+// Package console gives access to the serial and graphical consoles of the
+// Lima VM, for debugging guests that don't boot far enough to be reached by
+// ssh.
+package console
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EscapeByte detaches from the serial console; it is Ctrl-], as in telnet.
+const EscapeByte = 0x1d
+
+// Endpoints are the consoles of a Lima instance, as found in its directory.
+type Endpoints struct {
+	// Socket is the socket of the interactive serial console, if any; only
+	// QEMU instances have one.
+	Socket string
+	// Log is the file the output of the serial console is logged to, if any.
+	Log string
+	// VNCDisplay is the address of the VNC server of the graphical console,
+	// if the instance has one, as "host:display".
+	VNCDisplay string
+	// VNCPassword is the password of the VNC server.
+	VNCPassword string
+}
+
+// Find returns the consoles of the Lima instance in the directory; QEMU and
+// VZ instances use different file names.
+func Find(instanceDir string) (Endpoints, error) {
+	var result Endpoints
+	for _, name := range []string{"serial.sock", "serialv.sock"} {
+		path := filepath.Join(instanceDir, name)
+		if info, err := os.Stat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+			result.Socket = path
+			break
+		}
+	}
+	for _, name := range []string{"serial.log", "serialv.log"} {
+		path := filepath.Join(instanceDir, name)
+		if _, err := os.Stat(path); err == nil {
+			result.Log = path
+			break
+		}
+	}
+	if display, err := os.ReadFile(filepath.Join(instanceDir, "vncdisplay")); err == nil {
+		result.VNCDisplay = strings.TrimSpace(string(display))
+		if password, err := os.ReadFile(filepath.Join(instanceDir, "vncpassword")); err == nil {
+			result.VNCPassword = strings.TrimSpace(string(password))
+		}
+	}
+	if result.Socket == "" && result.Log == "" && result.VNCDisplay == "" {
+		return result, fmt.Errorf("no console found in %s; has the VM been started?", instanceDir)
+	}
+	return result, nil
+}
+
+// VNCURL returns the vnc:// URL of the graphical console.
+func (e Endpoints) VNCURL() (string, error) {
+	if e.VNCDisplay == "" {
+		return "", errors.New("the VM has no VNC display")
+	}
+	host, display, ok := strings.Cut(e.VNCDisplay, ":")
+	number, err := strconv.Atoi(display)
+	if !ok || err != nil || number < 0 {
+		return "", fmt.Errorf("invalid VNC display %q", e.VNCDisplay)
+	}
+	if host == "" {
+		host = "127.0.0.1"
+	}
+	// VNC displays are numbered from port 5900.
+	address := net.JoinHostPort(host, strconv.Itoa(5900+number))
+	if e.VNCPassword != "" {
+		return fmt.Sprintf("vnc://:%s@%s", e.VNCPassword, address), nil
+	}
+	return "vnc://" + address, nil
+}
+
+// Attach connects the terminal to the serial console until the console is
+// closed or EscapeByte is read from stdin.
+func Attach(conn io.ReadWriter, stdin io.Reader, stdout io.Writer) error {
+	done := make(chan error, 2)
+	go func() {
+		_, err := io.Copy(stdout, conn)
+		done <- err
+	}()
+	go func() {
+		buf := make([]byte, 1024)
+		for {
+			n, err := stdin.Read(buf)
+			if n > 0 {
+				data := buf[:n]
+				escape := false
+				if i := strings.IndexByte(string(data), EscapeByte); i >= 0 {
+					data, escape = data[:i], true
+				}
+				if _, err := conn.Write(data); err != nil {
+					done <- err
+					return
+				}
+				if escape {
+					done <- nil
+					return
+				}
+			}
+			if err != nil {
+				if errors.Is(err, io.EOF) {
+					err = nil
+				}
+				done <- err
+				return
+			}
+		}
+	}()
+	return <-done
+}
+
+// Follow writes the contents of the log file, and then what is appended to
+// it, until the context is done.
+func Follow(ctx context.Context, path string, w io.Writer, interval time.Duration) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := io.Copy(w, file); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
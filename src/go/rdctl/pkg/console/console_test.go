@@ -0,0 +1,110 @@
+package console
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// safeBuffer is a bytes.Buffer that can be written and read concurrently.
+type safeBuffer struct {
+	mutex  sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (b *safeBuffer) Write(p []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buffer.Write(p)
+}
+
+func (b *safeBuffer) String() string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buffer.String()
+}
+
+func TestFind(t *testing.T) {
+	t.Run("no console", func(t *testing.T) {
+		_, err := Find(t.TempDir())
+		assert.ErrorContains(t, err, "no console found")
+	})
+	t.Run("VZ log and VNC display", func(t *testing.T) {
+		dir := t.TempDir()
+		for name, contents := range map[string]string{
+			"serialv.log": "booting\n",
+			"vncdisplay":  "127.0.0.1:1\n",
+			"vncpassword": "secret\n",
+		} {
+			require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(contents), 0o600))
+		}
+		endpoints, err := Find(dir)
+		require.NoError(t, err)
+		assert.Equal(t, Endpoints{
+			Log:         filepath.Join(dir, "serialv.log"),
+			VNCDisplay:  "127.0.0.1:1",
+			VNCPassword: "secret",
+		}, endpoints)
+		url, err := endpoints.VNCURL()
+		require.NoError(t, err)
+		assert.Equal(t, "vnc://:secret@127.0.0.1:5901", url)
+	})
+}
+
+func TestVNCURL(t *testing.T) {
+	url, err := Endpoints{VNCDisplay: ":0"}.VNCURL()
+	require.NoError(t, err)
+	assert.Equal(t, "vnc://127.0.0.1:5900", url)
+	_, err = Endpoints{}.VNCURL()
+	assert.ErrorContains(t, err, "no VNC display")
+	_, err = Endpoints{VNCDisplay: "localhost"}.VNCURL()
+	assert.ErrorContains(t, err, "invalid VNC display")
+}
+
+func TestAttach(t *testing.T) {
+	host, guest := net.Pipe()
+	defer host.Close()
+	received := make(chan string)
+	go func() {
+		_, _ = guest.Write([]byte("login: "))
+		buf := make([]byte, 100)
+		n, _ := guest.Read(buf)
+		received <- string(buf[:n])
+		_, _ = io.Copy(io.Discard, guest)
+	}()
+	var stdout safeBuffer
+	stdin := strings.NewReader("root\n\x1dignored")
+	require.NoError(t, Attach(host, stdin, &stdout))
+	assert.Equal(t, "root\n", <-received)
+	assert.Eventually(t, func() bool { return stdout.String() == "login: " }, time.Second, 10*time.Millisecond)
+}
+
+func TestFollow(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "serial.log")
+	require.NoError(t, os.WriteFile(path, []byte("first\n"), 0o600))
+	ctx, cancel := context.WithCancel(context.Background())
+	var output safeBuffer
+	done := make(chan error)
+	go func() {
+		done <- Follow(ctx, path, &output, 10*time.Millisecond)
+	}()
+	assert.Eventually(t, func() bool { return output.String() == "first\n" }, time.Second, 10*time.Millisecond)
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	require.NoError(t, err)
+	_, err = file.WriteString("second\n")
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+	assert.Eventually(t, func() bool { return output.String() == "first\nsecond\n" }, time.Second, 10*time.Millisecond)
+	cancel()
+	assert.NoError(t, <-done)
+}
@@ -0,0 +1,219 @@
+// Package dashboard collects the state shown by `rdctl dashboard` and renders
+// it as plain text, either once or repeatedly for the interactive mode.
+package dashboard
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/client"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/vm"
+)
+
+// maxEvents is the number of recent events kept for display.
+const maxEvents = 8
+
+// Settings is the subset of the application settings shown on the dashboard.
+type Settings struct {
+	ContainerEngine struct {
+		Name string `json:"name"`
+	} `json:"containerEngine"`
+	Kubernetes struct {
+		Enabled bool   `json:"enabled"`
+		Version string `json:"version"`
+		Port    int    `json:"port"`
+	} `json:"kubernetes"`
+	VirtualMachine struct {
+		MemoryInGB int `json:"memoryInGB"`
+		NumberCPUs int `json:"numberCPUs"`
+	} `json:"virtualMachine"`
+}
+
+// Status is a snapshot of everything the dashboard displays.
+type Status struct {
+	Time         time.Time
+	BackendState client.BackendState
+	Settings     *Settings
+	Ports        []Port
+	// PortsError is set if the forwarded ports could not be determined.
+	PortsError error
+	// Usage is the resource usage of the VM, if it is running and the
+	// dashboard has a VM to query.
+	Usage *Usage
+	// UsageError is set if the resource usage could not be determined.
+	UsageError error
+	// Error is set if the main process could not be queried at all.
+	Error error
+}
+
+// MarshalJSON converts the status to JSON for the structured output formats;
+// errors are converted to their messages.
+func (s *Status) MarshalJSON() ([]byte, error) {
+	errorMessage := func(err error) string {
+		if err == nil {
+			return ""
+		}
+		return err.Error()
+	}
+	return json.Marshal(struct {
+		Time         time.Time           `json:"time"`
+		BackendState client.BackendState `json:"backendState"`
+		Settings     *Settings           `json:"settings,omitempty"`
+		Ports        []Port              `json:"ports,omitempty"`
+		PortsError   string              `json:"portsError,omitempty"`
+		Usage        *Usage              `json:"usage,omitempty"`
+		UsageError   string              `json:"usageError,omitempty"`
+		Error        string              `json:"error,omitempty"`
+	}{
+		Time:         s.Time,
+		BackendState: s.BackendState,
+		Settings:     s.Settings,
+		Ports:        s.Ports,
+		PortsError:   errorMessage(s.PortsError),
+		Usage:        s.Usage,
+		UsageError:   errorMessage(s.UsageError),
+		Error:        errorMessage(s.Error),
+	})
+}
+
+// Event is a change noticed between two refreshes of the dashboard, or an
+// action taken from it.
+type Event struct {
+	Time    time.Time
+	Message string
+}
+
+// Dashboard keeps track of the state of Rancher Desktop across refreshes.
+type Dashboard struct {
+	// UseColor enables highlighting of the backend state when rendering.
+	UseColor bool
+	// VM, if set, is queried for the resource usage of the running VM.
+	VM       vm.Runner
+	rdClient client.RDClient
+	appPaths paths.Paths
+	last     *Status
+	events   []Event
+}
+
+func NewDashboard(rdClient client.RDClient, appPaths paths.Paths) *Dashboard {
+	return &Dashboard{
+		rdClient: rdClient,
+		appPaths: appPaths,
+	}
+}
+
+// Refresh queries the current state and records any changes since the last
+// refresh as events.
+func (d *Dashboard) Refresh() *Status {
+	status := &Status{Time: time.Now()}
+	status.BackendState, status.Error = d.rdClient.GetBackendState()
+	if status.Error == nil {
+		status.Settings, status.Error = d.getSettings()
+	}
+	if status.Error == nil && status.BackendState.VMState == "STARTED" {
+		status.Ports, status.PortsError = getPorts(d.appPaths, status.Settings)
+		if d.VM != nil {
+			status.Usage, status.UsageError = d.getUsage()
+		}
+	}
+	d.recordChanges(status)
+	d.last = status
+	return status
+}
+
+// Events returns the recent events, oldest first.
+func (d *Dashboard) Events() []Event {
+	return d.events
+}
+
+// AddEvent records an event to be shown on the dashboard.
+func (d *Dashboard) AddEvent(format string, args ...any) {
+	d.events = append(d.events, Event{Time: time.Now(), Message: fmt.Sprintf(format, args...)})
+	if len(d.events) > maxEvents {
+		d.events = d.events[len(d.events)-maxEvents:]
+	}
+}
+
+func (d *Dashboard) getSettings() (*Settings, error) {
+	body, err := client.ProcessRequestForUtility(d.rdClient.DoRequest("GET", client.VersionCommand("", "settings")))
+	if err != nil {
+		return nil, err
+	}
+	var settings Settings
+	if err := json.Unmarshal(body, &settings); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal settings: %w", err)
+	}
+	return &settings, nil
+}
+
+func (d *Dashboard) recordChanges(status *Status) {
+	if d.last == nil {
+		return
+	}
+	if (d.last.Error == nil) != (status.Error == nil) {
+		if status.Error != nil {
+			d.AddEvent("lost connection to Rancher Desktop: %s", status.Error)
+		} else {
+			d.AddEvent("connected to Rancher Desktop")
+		}
+	}
+	if status.Error != nil || d.last.Error != nil {
+		return
+	}
+	if d.last.BackendState.VMState != status.BackendState.VMState {
+		d.AddEvent("backend state changed from %s to %s", d.last.BackendState.VMState, status.BackendState.VMState)
+	}
+	if d.last.BackendState.Locked != status.BackendState.Locked {
+		if status.BackendState.Locked {
+			d.AddEvent("backend locked")
+		} else {
+			d.AddEvent("backend unlocked")
+		}
+	}
+	lastK8s, k8s := d.last.Settings.Kubernetes, status.Settings.Kubernetes
+	if lastK8s.Enabled != k8s.Enabled {
+		if k8s.Enabled {
+			d.AddEvent("Kubernetes enabled")
+		} else {
+			d.AddEvent("Kubernetes disabled")
+		}
+	} else if lastK8s.Version != k8s.Version {
+		d.AddEvent("Kubernetes version changed from %s to %s", lastK8s.Version, k8s.Version)
+	}
+	if d.last.Settings.ContainerEngine.Name != status.Settings.ContainerEngine.Name {
+		d.AddEvent("container engine changed from %s to %s", d.last.Settings.ContainerEngine.Name, status.Settings.ContainerEngine.Name)
+	}
+}
+
+// RestartBackend stops the backend and starts it again, which also restarts
+// Kubernetes. It blocks until the backend has started to come back up.
+func (d *Dashboard) RestartBackend() error {
+	state, err := d.rdClient.GetBackendState()
+	if err != nil {
+		return err
+	}
+	if state.Locked {
+		return errors.New("the backend is locked")
+	}
+	if err := d.rdClient.UpdateBackendState(client.BackendState{VMState: "STOPPED"}); err != nil {
+		return fmt.Errorf("failed to stop backend: %w", err)
+	}
+	const interval = time.Second
+	for i := 0; i < 120; i++ {
+		state, err = d.rdClient.GetBackendState()
+		if err != nil {
+			return fmt.Errorf("failed to poll backend state: %w", err)
+		}
+		if state.VMState == "STOPPED" {
+			if err := d.rdClient.UpdateBackendState(client.BackendState{VMState: "STARTED"}); err != nil {
+				return fmt.Errorf("failed to start backend: %w", err)
+			}
+			return nil
+		}
+		time.Sleep(interval)
+	}
+	return errors.New("timed out waiting for the backend to stop")
+}
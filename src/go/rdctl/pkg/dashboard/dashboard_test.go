@@ -0,0 +1,143 @@
+package dashboard
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/client"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/output"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeClient struct {
+	state    client.BackendState
+	settings string
+}
+
+func (c *fakeClient) DoRequest(method string, command string) (*http.Response, error) {
+	return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(c.settings))}, nil
+}
+
+func (c *fakeClient) DoRequestWithPayload(method string, command string, payload io.Reader) (*http.Response, error) {
+	return c.DoRequest(method, command)
+}
+
+func (c *fakeClient) GetBackendState() (client.BackendState, error) {
+	return c.state, nil
+}
+
+func (c *fakeClient) UpdateBackendState(state client.BackendState) error {
+	c.state = state
+	return nil
+}
+
+func settingsJSON(k8sEnabled bool, k8sVersion string) string {
+	return fmt.Sprintf(`{"containerEngine":{"name":"containerd"},"kubernetes":{"enabled":%t,"version":%q,"port":6443}}`, k8sEnabled, k8sVersion)
+}
+
+func TestRefreshRecordsChanges(t *testing.T) {
+	fake := &fakeClient{
+		state:    client.BackendState{VMState: "STARTING"},
+		settings: settingsJSON(true, "1.27.3"),
+	}
+	board := NewDashboard(fake, paths.Paths{})
+
+	status := board.Refresh()
+	assert.NoError(t, status.Error)
+	assert.Empty(t, board.Events(), "the first refresh should not generate events")
+
+	fake.state.VMState = "STARTED"
+	fake.settings = settingsJSON(true, "1.28.1")
+	board.Refresh()
+	var messages []string
+	for _, event := range board.Events() {
+		messages = append(messages, event.Message)
+	}
+	assert.Equal(t, []string{
+		"backend state changed from STARTING to STARTED",
+		"Kubernetes version changed from 1.27.3 to 1.28.1",
+	}, messages)
+}
+
+func TestEventsAreCapped(t *testing.T) {
+	board := NewDashboard(&fakeClient{}, paths.Paths{})
+	for i := 0; i < maxEvents+3; i++ {
+		board.AddEvent("event %d", i)
+	}
+	events := board.Events()
+	assert.Len(t, events, maxEvents)
+	assert.Equal(t, fmt.Sprintf("event %d", maxEvents+2), events[len(events)-1].Message)
+}
+
+func TestRender(t *testing.T) {
+	fake := &fakeClient{
+		state:    client.BackendState{VMState: "STOPPED"},
+		settings: settingsJSON(false, ""),
+	}
+	board := NewDashboard(fake, paths.Paths{})
+	var buf bytes.Buffer
+	assert.NoError(t, board.Render(&buf, board.Refresh(), 40))
+	for _, line := range strings.Split(buf.String(), "\n") {
+		assert.LessOrEqual(t, len([]rune(line)), 40)
+	}
+	assert.Contains(t, buf.String(), "STOPPED")
+	assert.Contains(t, buf.String(), "disabled")
+}
+
+type fakeVM struct {
+	output string
+}
+
+func (f fakeVM) RootOutput(args ...string) ([]byte, error) {
+	return []byte(f.output), nil
+}
+
+func (f fakeVM) RootStream(stdin io.Reader, stdout io.Writer, args ...string) error {
+	return nil
+}
+
+func TestParseUsage(t *testing.T) {
+	usage, err := parseUsage([]byte("0.42 0.30 0.20 1/123 456\nMemTotal:        4194304 kB\nMemFree:          102400 kB\nMemAvailable:    3145728 kB\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, &Usage{LoadAverage: 0.42, MemoryUsed: 1 << 30, MemoryTotal: 4 << 30}, usage)
+
+	_, err = parseUsage([]byte("0.42 0.30 0.20 1/123 456\n"))
+	assert.Error(t, err)
+}
+
+func TestRenderUsage(t *testing.T) {
+	fake := &fakeClient{
+		state:    client.BackendState{VMState: "STOPPED"},
+		settings: `{"virtualMachine":{"memoryInGB":4,"numberCPUs":2}}`,
+	}
+	board := NewDashboard(fake, paths.Paths{})
+	board.VM = fakeVM{output: "1.50 0.30 0.20 1/123 456\nMemTotal: 4194304 kB\nMemAvailable: 3145728 kB\n"}
+	status := board.Refresh()
+	assert.Nil(t, status.Usage, "usage should only be measured while the VM is running")
+
+	status.Usage, status.UsageError = board.getUsage()
+	var buf bytes.Buffer
+	assert.NoError(t, board.Render(&buf, status, 0))
+	assert.Regexp(t, `VM limits:\s+2 CPUs, 4 GB memory`, buf.String())
+	assert.Regexp(t, `VM usage:\s+load 1\.50, 1\.0 of 4\.0 GiB memory used`, buf.String())
+}
+
+func TestStatusJSON(t *testing.T) {
+	fake := &fakeClient{
+		state:    client.BackendState{VMState: "STOPPED"},
+		settings: settingsJSON(true, "1.28.1"),
+	}
+	status := NewDashboard(fake, paths.Paths{}).Refresh()
+	status.PortsError = errors.New("no ports")
+	formatter, err := output.NewFormatter("jsonpath={.backendState.vmState} {.settings.kubernetes.version} {.portsError}")
+	assert.NoError(t, err)
+	var buf bytes.Buffer
+	assert.NoError(t, formatter.Write(&buf, status))
+	assert.Equal(t, "STOPPED 1.28.1 no ports\n", buf.String())
+}
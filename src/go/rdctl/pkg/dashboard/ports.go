@@ -0,0 +1,50 @@
+package dashboard
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/tools"
+)
+
+// Port describes a port forwarded from the host into Rancher Desktop.
+type Port struct {
+	// The name of the container or service that owns the port.
+	Name string `json:"name"`
+	// A description of the forwarded ports, e.g. "0.0.0.0:8080->80/tcp".
+	Ports string `json:"ports"`
+}
+
+// getPorts returns the ports published by running containers, plus the
+// Kubernetes API port if Kubernetes is enabled.
+func getPorts(appPaths paths.Paths, settings *Settings) ([]Port, error) {
+	var ports []Port
+	if settings.Kubernetes.Enabled && settings.Kubernetes.Port != 0 {
+		ports = append(ports, Port{Name: "Kubernetes API", Ports: fmt.Sprintf("127.0.0.1:%d", settings.Kubernetes.Port)})
+	}
+	cliName := "nerdctl"
+	if settings.ContainerEngine.Name == "moby" {
+		cliName = "docker"
+	}
+	cliPath, err := tools.Find(appPaths, cliName)
+	if err != nil {
+		return ports, err
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(cliPath, "ps", "--format", "{{.Names}}\t{{.Ports}}")
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return ports, fmt.Errorf("failed to run %s ps: %w: %s", cliName, err, strings.TrimSpace(stderr.String()))
+	}
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		name, published, _ := strings.Cut(strings.TrimSpace(line), "\t")
+		if published != "" {
+			ports = append(ports, Port{Name: name, Ports: published})
+		}
+	}
+	return ports, nil
+}
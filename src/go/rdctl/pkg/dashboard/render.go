@@ -0,0 +1,102 @@
+package dashboard
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/output"
+)
+
+// Render writes a textual representation of the status and recent events to
+// the writer. If width is positive, lines are truncated to that many runes.
+func (d *Dashboard) Render(w io.Writer, status *Status, width int) error {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "Rancher Desktop dashboard (updated %s)\n\n", status.Time.Format(time.TimeOnly))
+
+	writer := tabwriter.NewWriter(&buf, 0, 4, 2, ' ', 0)
+	if status.Error != nil {
+		fmt.Fprintf(writer, "Backend:\tunavailable (%s)\n", status.Error)
+	} else {
+		state := status.BackendState.VMState
+		if d.UseColor {
+			state = stateColor(state).Wrap(state)
+		}
+		if status.BackendState.Locked {
+			state += " (locked)"
+		}
+		fmt.Fprintf(writer, "Backend:\t%s\n", state)
+		settings := status.Settings
+		if settings.Kubernetes.Enabled {
+			fmt.Fprintf(writer, "Kubernetes:\tv%s\n", settings.Kubernetes.Version)
+		} else {
+			fmt.Fprintf(writer, "Kubernetes:\tdisabled\n")
+		}
+		fmt.Fprintf(writer, "Container engine:\t%s\n", settings.ContainerEngine.Name)
+		fmt.Fprintf(writer, "VM limits:\t%d CPUs, %d GB memory\n", settings.VirtualMachine.NumberCPUs, settings.VirtualMachine.MemoryInGB)
+		switch {
+		case status.UsageError != nil:
+			fmt.Fprintf(writer, "VM usage:\tunavailable (%s)\n", status.UsageError)
+		case status.Usage != nil:
+			fmt.Fprintf(writer, "VM usage:\tload %.2f, %.1f of %.1f GiB memory used\n",
+				status.Usage.LoadAverage, gib(status.Usage.MemoryUsed), gib(status.Usage.MemoryTotal))
+		}
+	}
+	writer.Flush()
+
+	fmt.Fprintf(&buf, "\nForwarded ports:\n")
+	writer = tabwriter.NewWriter(&buf, 0, 4, 2, ' ', 0)
+	switch {
+	case status.PortsError != nil:
+		fmt.Fprintf(writer, "  unavailable: %s\n", status.PortsError)
+	case len(status.Ports) == 0:
+		fmt.Fprintf(writer, "  none\n")
+	}
+	for _, port := range status.Ports {
+		fmt.Fprintf(writer, "  %s\t%s\n", port.Name, port.Ports)
+	}
+	writer.Flush()
+
+	fmt.Fprintf(&buf, "\nRecent events:\n")
+	if len(d.events) == 0 {
+		fmt.Fprintf(&buf, "  none\n")
+	}
+	for _, event := range d.events {
+		fmt.Fprintf(&buf, "  %s  %s\n", event.Time.Format(time.TimeOnly), event.Message)
+	}
+
+	for _, line := range strings.SplitAfter(buf.String(), "\n") {
+		if _, err := io.WriteString(w, truncate(line, width)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func gib(bytes uint64) float64 {
+	return float64(bytes) / (1024 * 1024 * 1024)
+}
+
+func stateColor(state string) output.Color {
+	switch state {
+	case "STARTED":
+		return output.Green
+	case "ERROR":
+		return output.Red
+	}
+	return output.Yellow
+}
+
+// truncate shortens a line (keeping any trailing newline) to at most width runes.
+func truncate(line string, width int) string {
+	text, hasNewline := strings.CutSuffix(line, "\n")
+	if runes := []rune(text); width > 0 && len(runes) > width {
+		text = string(runes[:width])
+	}
+	if hasNewline {
+		return text + "\n"
+	}
+	return text
+}
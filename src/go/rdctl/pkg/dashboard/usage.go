@@ -0,0 +1,77 @@
+package dashboard
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Usage is the resource usage measured inside the VM.
+type Usage struct {
+	// LoadAverage is the one-minute load average.
+	LoadAverage float64 `json:"loadAverage"`
+	// MemoryUsed and MemoryTotal are in bytes; memory that the kernel can
+	// reclaim (caches and buffers) does not count as used.
+	MemoryUsed  uint64 `json:"memoryUsed"`
+	MemoryTotal uint64 `json:"memoryTotal"`
+}
+
+// getUsage reads the load average and memory usage of the VM.
+func (d *Dashboard) getUsage() (*Usage, error) {
+	output, err := d.VM.RootOutput("cat", "/proc/loadavg", "/proc/meminfo")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read resource usage: %w", err)
+	}
+	return parseUsage(output)
+}
+
+// parseUsage parses the concatenated contents of /proc/loadavg and
+// /proc/meminfo.
+func parseUsage(output []byte) (*Usage, error) {
+	var usage Usage
+	var memAvailable uint64
+	haveLoad, haveTotal, haveAvailable := false, false, false
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if !haveLoad {
+			load, err := strconv.ParseFloat(fields[0], 64)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse load average %q: %w", fields[0], err)
+			}
+			usage.LoadAverage = load
+			haveLoad = true
+			continue
+		}
+		if len(fields) < 2 || (fields[0] != "MemTotal:" && fields[0] != "MemAvailable:") {
+			continue
+		}
+		kib, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s %q: %w", strings.TrimSuffix(fields[0], ":"), fields[1], err)
+		}
+		if fields[0] == "MemTotal:" {
+			usage.MemoryTotal = kib * 1024
+			haveTotal = true
+		} else {
+			memAvailable = kib * 1024
+			haveAvailable = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if !haveLoad || !haveTotal || !haveAvailable {
+		return nil, errors.New("incomplete resource usage information")
+	}
+	if memAvailable < usage.MemoryTotal {
+		usage.MemoryUsed = usage.MemoryTotal - memAvailable
+	}
+	return &usage, nil
+}
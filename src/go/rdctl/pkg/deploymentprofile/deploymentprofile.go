@@ -0,0 +1,151 @@
+// Package deploymentprofile checks deployment profiles the way Rancher Desktop
+// loads them, so that administrators can find mistakes before deploying them.
+package deploymentprofile
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Severity tells whether a problem makes Rancher Desktop drop a field.
+type Severity string
+
+const (
+	// Error is for a field with an invalid value, which is dropped from a
+	// defaults profile, and prevents startup with a locked profile.
+	Error Severity = "error"
+	// Warning is for an unknown field, which is ignored.
+	Warning Severity = "warning"
+)
+
+// Problem is a problem found in a deployment profile.
+type Problem struct {
+	Severity Severity `json:"severity"`
+	// Field is the dotted path of the field, e.g. "kubernetes.enabled".
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Read reads a deployment profile from a JSON file, or from a plist file on
+// macOS.
+func Read(path string) (map[string]any, error) {
+	var content []byte
+	var err error
+	if strings.EqualFold(filepath.Ext(path), ".plist") {
+		content, err = exec.Command("plutil", "-convert", "json", "-r", "-o", "-", path).Output()
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert plist file %q: %w", path, err)
+		}
+	} else {
+		content, err = os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var profile map[string]any
+	if err := json.Unmarshal(content, &profile); err != nil {
+		return nil, fmt.Errorf("failed to parse %q: %w", path, err)
+	}
+	return profile, nil
+}
+
+// Lint checks the profile against the schema, a struct type like
+// options.ServerSettingsForJSON, and returns the problems sorted by field.
+func Lint(profile map[string]any, schema reflect.Type) []Problem {
+	problems := []Problem{}
+	if _, ok := profile["version"]; !ok {
+		problems = append(problems, Problem{
+			Severity: Error,
+			Field:    "version",
+			Message:  "no version specified; the profile is rejected",
+		})
+	}
+	problems = lintObject(problems, profile, schema, "")
+	sort.SliceStable(problems, func(i, j int) bool {
+		return problems[i].Field < problems[j].Field
+	})
+	return problems
+}
+
+func lintObject(problems []Problem, object map[string]any, schema reflect.Type, path string) []Problem {
+	fields := map[string]reflect.Type{}
+	for i := 0; i < schema.NumField(); i++ {
+		field := schema.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		fields[name] = field.Type
+	}
+	for key, value := range object {
+		fieldPath := key
+		if path != "" {
+			fieldPath = path + "." + key
+		}
+		fieldType, ok := fields[key]
+		if !ok {
+			problems = append(problems, Problem{
+				Severity: Warning,
+				Field:    fieldPath,
+				Message:  "not a known setting; it is ignored",
+			})
+			continue
+		}
+		problems = lintValue(problems, value, fieldType, fieldPath)
+	}
+	return problems
+}
+
+func lintValue(problems []Problem, value any, schema reflect.Type, path string) []Problem {
+	if schema.Kind() == reflect.Ptr {
+		schema = schema.Elem()
+	}
+	var expected string
+	valid := false
+	switch schema.Kind() {
+	case reflect.Struct:
+		if object, ok := value.(map[string]any); ok {
+			return lintObject(problems, object, schema, path)
+		}
+		expected = "object"
+	case reflect.Map:
+		// User-defined objects, such as WSL.integrations, have arbitrary keys.
+		_, valid = value.(map[string]any)
+		expected = "object"
+	case reflect.Slice:
+		expected = "array"
+		if values, ok := value.([]any); ok {
+			valid = true
+			for _, element := range values {
+				if _, ok := element.(string); !ok {
+					valid = false
+					expected = "array of strings"
+				}
+			}
+		}
+	case reflect.String:
+		_, valid = value.(string)
+		expected = "string"
+	case reflect.Bool:
+		_, valid = value.(bool)
+		expected = "boolean"
+	case reflect.Int:
+		number, ok := value.(float64)
+		valid = ok && number == float64(int64(number))
+		expected = "integer"
+	default:
+		return problems
+	}
+	if valid {
+		return problems
+	}
+	actual, _ := json.Marshal(value)
+	return append(problems, Problem{
+		Severity: Error,
+		Field:    path,
+		Message:  fmt.Sprintf("expecting value of type %s, got %s; the field is dropped", expected, actual),
+	})
+}
@@ -0,0 +1,83 @@
+package deploymentprofile
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// settings mirrors the shape of options.ServerSettingsForJSON.
+type settings struct {
+	Version     *int `json:"version,omitempty"`
+	Application struct {
+		AdminAccess *bool `json:"adminAccess,omitempty"`
+	} `json:"application"`
+	ContainerEngine struct {
+		AllowedImages struct {
+			Patterns *[]string `json:"patterns,omitempty"`
+		} `json:"allowedImages"`
+		Name *string `json:"name,omitempty"`
+	} `json:"containerEngine"`
+	Kubernetes struct {
+		Port *int `json:"port,omitempty"`
+	} `json:"kubernetes"`
+	WSL struct {
+		Integrations map[string]interface{} `json:"integrations"`
+	} `json:"WSL"`
+}
+
+func lint(t *testing.T, profile string) []Problem {
+	var parsed map[string]any
+	require.NoError(t, json.Unmarshal([]byte(profile), &parsed))
+	return Lint(parsed, reflect.TypeOf(settings{}))
+}
+
+func TestLint(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		problems := lint(t, `{
+			"version": 10,
+			"application": {"adminAccess": false},
+			"containerEngine": {"name": "moby", "allowedImages": {"patterns": ["busybox"]}},
+			"kubernetes": {"port": 6443},
+			"WSL": {"integrations": {"Ubuntu": true}}
+		}`)
+		assert.Empty(t, problems)
+	})
+	t.Run("invalid", func(t *testing.T) {
+		problems := lint(t, `{
+			"application": {"adminAccess": "yes", "colour": "blue"},
+			"containerEngine": {"name": 5, "allowedImages": {"patterns": [1]}},
+			"kubernetes": {"port": 6443.5},
+			"WSL": {"integrations": true},
+			"extra": {}
+		}`)
+		assert.Equal(t, []Problem{
+			{Severity: Error, Field: "WSL.integrations", Message: "expecting value of type object, got true; the field is dropped"},
+			{Severity: Error, Field: "application.adminAccess", Message: `expecting value of type boolean, got "yes"; the field is dropped`},
+			{Severity: Warning, Field: "application.colour", Message: "not a known setting; it is ignored"},
+			{Severity: Error, Field: "containerEngine.allowedImages.patterns", Message: "expecting value of type array of strings, got [1]; the field is dropped"},
+			{Severity: Error, Field: "containerEngine.name", Message: "expecting value of type string, got 5; the field is dropped"},
+			{Severity: Warning, Field: "extra", Message: "not a known setting; it is ignored"},
+			{Severity: Error, Field: "kubernetes.port", Message: "expecting value of type integer, got 6443.5; the field is dropped"},
+			{Severity: Error, Field: "version", Message: "no version specified; the profile is rejected"},
+		}, problems)
+	})
+}
+
+func TestRead(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "defaults.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"version": 10}`), 0o644))
+	profile, err := Read(path)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"version": float64(10)}, profile)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"version": `), 0o644))
+	_, err = Read(path)
+	assert.ErrorContains(t, err, "failed to parse")
+}
@@ -0,0 +1,152 @@
+// Package devcontainer checks that the Rancher Desktop container engine can be
+// used by editor integrations built on dev containers, such as the VS Code Dev
+// Containers extension and JetBrains Gateway.
+package devcontainer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/checks"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/vm"
+)
+
+// ContextName is the name of the docker context that Rancher Desktop creates
+// on macOS and Linux; it stays the same across releases.
+const ContextName = "rancher-desktop"
+
+// DefaultContextName is the docker context the CLI uses when none is set; it
+// uses the default docker endpoint.
+const DefaultContextName = "default"
+
+// Doctor checks everything that dev containers need.
+type Doctor struct {
+	// EngineName is the container engine in the settings.
+	EngineName string
+	// DockerHost is the endpoint of the Rancher Desktop docker engine.
+	DockerHost string
+	// DockerHostEnv is the value of DOCKER_HOST, which overrides the context.
+	DockerHostEnv string
+	// Docker runs the docker CLI and returns its output; it is nil if the
+	// docker CLI was not found.
+	Docker func(args ...string) ([]byte, error)
+	// VM runs commands in the VM; it is nil if workspaces can't be checked
+	// there.
+	VM vm.Runner
+	// Workspace is the directory on the host opened in the dev container.
+	Workspace string
+	// UID is the user ID on the host.
+	UID int
+}
+
+// Run runs all the checks.
+func (d *Doctor) Run() []checks.Check {
+	results := []checks.Check{d.checkEngine()}
+	results = append(results, d.checkDocker()...)
+	return append(results, d.checkWorkspace()...)
+}
+
+func (d *Doctor) checkEngine() checks.Check {
+	check := checks.Check{Name: "container engine"}
+	if d.EngineName == "moby" {
+		check.Status = checks.OK
+		check.Message = "the moby engine is selected"
+	} else {
+		check.Status = checks.Failed
+		check.Message = fmt.Sprintf("dev containers require the moby engine, not %s; run 'rdctl set --container-engine.name moby'", d.EngineName)
+	}
+	return check
+}
+
+func (d *Doctor) checkDocker() []checks.Check {
+	cli := checks.Check{Name: "docker CLI"}
+	if d.Docker == nil {
+		cli.Status = checks.Failed
+		cli.Message = "docker was not found among the tools shipped with Rancher Desktop"
+		return []checks.Check{cli, {Name: "docker context", Status: checks.Skipped}, {Name: "docker engine", Status: checks.Skipped}}
+	}
+	cli.Status = checks.OK
+	cli.Message = "docker was found"
+	return []checks.Check{cli, d.checkContext(), d.checkEngineReachable()}
+}
+
+func (d *Doctor) checkContext() checks.Check {
+	check := checks.Check{Name: "docker context"}
+	if d.DockerHostEnv != "" {
+		if checks.SameDockerEndpoint(d.DockerHostEnv, d.DockerHost) {
+			check.Status = checks.OK
+			check.Message = fmt.Sprintf("DOCKER_HOST is %s", d.DockerHostEnv)
+		} else {
+			check.Status = checks.Warning
+			check.Message = fmt.Sprintf("DOCKER_HOST (%s) overrides the docker context; editors started without it may use another engine", d.DockerHostEnv)
+		}
+		return check
+	}
+	name, err := d.Docker("context", "show")
+	if err != nil {
+		check.Status = checks.Failed
+		check.Message = fmt.Sprintf("failed to get the current docker context: %s", err)
+		return check
+	}
+	contextName := strings.TrimSpace(string(name))
+	host, err := d.Docker("context", "inspect", contextName, "--format", "{{.Endpoints.docker.Host}}")
+	if err != nil {
+		check.Status = checks.Failed
+		check.Message = fmt.Sprintf("failed to inspect the docker context %q: %s", contextName, err)
+		return check
+	}
+	endpoint := strings.TrimSpace(string(host))
+	if checks.SameDockerEndpoint(endpoint, d.DockerHost) {
+		check.Status = checks.OK
+		check.Message = fmt.Sprintf("the current context %q uses %s", contextName, endpoint)
+	} else {
+		check.Status = checks.Warning
+		check.Message = fmt.Sprintf("the current context %q uses %s, not %s; run 'docker context use %s'", contextName, endpoint, d.DockerHost, SuggestedContext)
+	}
+	return check
+}
+
+func (d *Doctor) checkEngineReachable() checks.Check {
+	check := checks.Check{Name: "docker engine"}
+	version, err := d.Docker("version", "--format", "{{.Server.Version}}")
+	if err != nil {
+		check.Status = checks.Failed
+		check.Message = fmt.Sprintf("failed to connect to the docker engine: %s", err)
+	} else {
+		check.Status = checks.OK
+		check.Message = fmt.Sprintf("docker engine %s is reachable", strings.TrimSpace(string(version)))
+	}
+	return check
+}
+
+func (d *Doctor) checkWorkspace() []checks.Check {
+	mount := checks.Check{Name: "workspace mount"}
+	users := checks.Check{Name: "user mapping", Status: checks.Skipped}
+	if d.VM == nil {
+		mount.Status = checks.Skipped
+		mount.Message = "the drives of the host are available in the VM under /mnt"
+		return []checks.Check{mount, users}
+	}
+	output, err := d.VM.RootOutput("stat", "-c", "%u", d.Workspace)
+	if err != nil {
+		mount.Status = checks.Failed
+		mount.Message = fmt.Sprintf("%s is not shared with the VM, so it can't be mounted into containers; open a workspace under your home directory", d.Workspace)
+		return []checks.Check{mount, users}
+	}
+	mount.Status = checks.OK
+	mount.Message = fmt.Sprintf("%s is shared with the VM", d.Workspace)
+	owner, err := strconv.Atoi(strings.TrimSpace(string(output)))
+	if err != nil {
+		users.Status = checks.Failed
+		users.Message = fmt.Sprintf("failed to get the owner of %s in the VM: %q", d.Workspace, output)
+	} else if owner == d.UID {
+		users.Status = checks.OK
+		users.Message = fmt.Sprintf("files in the workspace are owned by uid %d in the VM, as on the host", owner)
+	} else {
+		users.Status = checks.Warning
+		users.Message = fmt.Sprintf("files in the workspace are owned by uid %d in the VM, but by uid %d on the host; "+
+			`keep "updateRemoteUserUID" enabled in devcontainer.json`, owner, d.UID)
+	}
+	return []checks.Check{mount, users}
+}
@@ -0,0 +1,105 @@
+package devcontainer
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/checks"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeVM struct {
+	output string
+	err    error
+}
+
+func (f fakeVM) RootOutput(args ...string) ([]byte, error) {
+	return []byte(f.output), f.err
+}
+
+func (f fakeVM) RootStream(stdin io.Reader, stdout io.Writer, args ...string) error {
+	return errors.New("not implemented")
+}
+
+// fakeDocker returns a docker CLI whose output is looked up by arguments.
+func fakeDocker(outputs map[string]string) func(args ...string) ([]byte, error) {
+	return func(args ...string) ([]byte, error) {
+		if output, ok := outputs[strings.Join(args, " ")]; ok {
+			return []byte(output), nil
+		}
+		return nil, errors.New("unexpected command")
+	}
+}
+
+func statuses(results []checks.Check) map[string]checks.Status {
+	result := map[string]checks.Status{}
+	for _, check := range results {
+		result[check.Name] = check.Status
+	}
+	return result
+}
+
+func TestDoctor(t *testing.T) {
+	host := "unix:///home/user/.rd/docker.sock"
+	healthy := func() Doctor {
+		return Doctor{
+			EngineName: "moby",
+			DockerHost: host,
+			Docker: fakeDocker(map[string]string{
+				"context show": "rancher-desktop\n",
+				"context inspect rancher-desktop --format {{.Endpoints.docker.Host}}": host + "\n",
+				"version --format {{.Server.Version}}":                                "24.0.7\n",
+			}),
+			VM:        fakeVM{output: "501\n"},
+			Workspace: "/home/user/project",
+			UID:       501,
+		}
+	}
+
+	t.Run("healthy", func(t *testing.T) {
+		doctor := healthy()
+		results := doctor.Run()
+		assert.Equal(t, map[string]checks.Status{
+			"container engine": checks.OK,
+			"docker CLI":       checks.OK,
+			"docker context":   checks.OK,
+			"docker engine":    checks.OK,
+			"workspace mount":  checks.OK,
+			"user mapping":     checks.OK,
+		}, statuses(results))
+		assert.NoError(t, checks.Err(results))
+	})
+	t.Run("containerd", func(t *testing.T) {
+		doctor := healthy()
+		doctor.EngineName = "containerd"
+		results := doctor.Run()
+		assert.Equal(t, checks.Failed, statuses(results)["container engine"])
+		assert.EqualError(t, checks.Err(results), "failed checks: container engine")
+	})
+	t.Run("other context", func(t *testing.T) {
+		doctor := healthy()
+		doctor.DockerHostEnv = "tcp://127.0.0.1:2375"
+		assert.Equal(t, checks.Warning, statuses(doctor.Run())["docker context"])
+	})
+	t.Run("missing docker", func(t *testing.T) {
+		doctor := healthy()
+		doctor.Docker = nil
+		result := statuses(doctor.Run())
+		assert.Equal(t, checks.Failed, result["docker CLI"])
+		assert.Equal(t, checks.Skipped, result["docker engine"])
+	})
+	t.Run("unshared workspace", func(t *testing.T) {
+		doctor := healthy()
+		doctor.VM = fakeVM{err: errors.New("no such file or directory")}
+		result := statuses(doctor.Run())
+		assert.Equal(t, checks.Failed, result["workspace mount"])
+		assert.Equal(t, checks.Skipped, result["user mapping"])
+	})
+	t.Run("different owner", func(t *testing.T) {
+		doctor := healthy()
+		doctor.VM = fakeVM{output: "1000\n"}
+		assert.Equal(t, checks.Warning, statuses(doctor.Run())["user mapping"])
+	})
+}
@@ -0,0 +1,19 @@
+//go:build unix
+
+package devcontainer
+
+import (
+	"path/filepath"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+)
+
+// SuggestedContext is the docker context using the Rancher Desktop engine.
+const SuggestedContext = ContextName
+
+// DockerHost returns the endpoint of the Rancher Desktop docker engine, which
+// the docker context named ContextName uses. The socket is ~/.rd/docker.sock,
+// whether or not /var/run/docker.sock is also set up.
+func DockerHost(appPaths paths.Paths) string {
+	return "unix://" + filepath.Join(appPaths.AltAppHome, "docker.sock")
+}
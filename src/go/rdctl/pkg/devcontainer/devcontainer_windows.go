@@ -0,0 +1,13 @@
+package devcontainer
+
+import "github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+
+// SuggestedContext is the docker context using the Rancher Desktop engine,
+// which listens on the default named pipe.
+const SuggestedContext = DefaultContextName
+
+// DockerHost returns the endpoint of the Rancher Desktop docker engine, which
+// is the default docker endpoint on Windows, so no context is needed.
+func DockerHost(appPaths paths.Paths) string {
+	return "npipe:////./pipe/docker_engine"
+}
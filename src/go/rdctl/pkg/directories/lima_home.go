@@ -17,6 +17,7 @@ limitations under the License.
 package directories
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -63,13 +64,19 @@ func GetLimactlPath() (string, error) {
 	if err != nil {
 		return "", err
 	}
+	limaDir := path.Join(path.Dir(path.Dir(execPath)), "lima")
+	if _, err := os.Stat(limaDir); errors.Is(err, os.ErrNotExist) {
+		// rdctl may be in an architecture-specific directory (e.g.
+		// resources/darwin/arm64/bin), with lima shared by all architectures.
+		limaDir = path.Join(path.Dir(path.Dir(path.Dir(execPath))), "lima")
+	}
 	if runtime.GOOS == "darwin" {
 		majorVersion, err := getOSMajorVersion()
 		if err == nil && majorVersion >= 22 {
 			// https://en.wikipedia.org/wiki/MacOS_version_history: maps darwin versions to macOS release version numbers and names
 			// macOS 13 | Ventura | 22
-			return path.Join(path.Dir(path.Dir(execPath)), "lima", "bin", "limactl.ventura"), nil
+			return path.Join(limaDir, "bin", "limactl.ventura"), nil
 		}
 	}
-	return path.Join(path.Dir(path.Dir(execPath)), "lima", "bin", "limactl"), nil
+	return path.Join(limaDir, "bin", "limactl"), nil
 }
@@ -0,0 +1,517 @@
+// Package doctor checks that the host can run Rancher Desktop, and fixes the
+// problems that can be fixed safely. Unlike the diagnostics of the
+// application, the checks only look at the host, so they work when the
+// application isn't running, or fails to start.
+//
+// Each check is a Checker; those that can fix the problems they report are
+// Fixers too. New checks are added to DefaultCheckers.
+package doctor
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/checks"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/devcontainer"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+)
+
+// The thresholds of the space available for the data of the application.
+const (
+	minFreeSpace         = 2 << 30
+	recommendedFreeSpace = 10 << 30
+)
+
+// defaultKubernetesPort is the default of the kubernetes.port setting.
+const defaultKubernetesPort = 6443
+
+// staleLockAge is the age after which the backend lock is deemed left behind
+// by a snapshot operation that failed; the operations take minutes.
+const staleLockAge = time.Hour
+
+// backendLockName is the lock of the snapshot operations, in the application
+// home; see pkg/lock.
+const backendLockName = "backend.lock"
+
+// Checker is a check of the host, which may report several results, such as
+// the tool symlinks and the PATH.
+type Checker interface {
+	Check(d *Doctor) []checks.Check
+}
+
+// Fixer is a Checker that can fix the problems it reports. Fixes run
+// unattended, so they must be safe: they only recreate or reset what Rancher
+// Desktop manages, and never remove data.
+type Fixer interface {
+	Checker
+	// Fix fixes the problems Check reports, and describes the changes made.
+	Fix(d *Doctor) ([]string, error)
+}
+
+// CheckFunc is a Checker without a fix.
+type CheckFunc func(d *Doctor) []checks.Check
+
+func (f CheckFunc) Check(d *Doctor) []checks.Check {
+	return f(d)
+}
+
+// fixer is a Fixer made of a check and its fix.
+type fixer struct {
+	check CheckFunc
+	fix   func(d *Doctor) ([]string, error)
+}
+
+func (f fixer) Check(d *Doctor) []checks.Check {
+	return f.check(d)
+}
+
+func (f fixer) Fix(d *Doctor) ([]string, error) {
+	return f.fix(d)
+}
+
+// single adapts a check with a single result.
+func single(check func(d *Doctor) checks.Check) CheckFunc {
+	return func(d *Doctor) []checks.Check {
+		return []checks.Check{check(d)}
+	}
+}
+
+// DefaultCheckers returns the checks Run runs, in order.
+func DefaultCheckers() []Checker {
+	return []Checker{
+		CheckFunc((*Doctor).platformChecks),
+		single((*Doctor).checkDiskSpace),
+		single((*Doctor).checkKubernetesPort),
+		fixer{CheckFunc((*Doctor).checkIntegration), (*Doctor).fixIntegration},
+		fixer{single((*Doctor).checkDockerContext), (*Doctor).fixDockerContext},
+		fixer{single((*Doctor).checkLockFiles), (*Doctor).fixLockFiles},
+	}
+}
+
+// Fix is the outcome of fixing the problems of a check.
+type Fix struct {
+	// Check is the name of the first result of the check.
+	Check   string
+	Changes []string
+	Err     error
+}
+
+// Doctor checks the host; the functions it calls exist for testing.
+type Doctor struct {
+	Paths paths.Paths
+	// Checkers are the checks to run.
+	Checkers []Checker
+	// AppRunning is whether the application is running, and so may be
+	// listening on the port of Kubernetes itself.
+	AppRunning bool
+	// DockerConfigDir is the directory holding the docker CLI configuration
+	// and contexts.
+	DockerConfigDir string
+	Getenv          func(key string) string
+	// Command runs a command of the host and returns its output.
+	Command func(name string, args ...string) ([]byte, error)
+	// FreeSpace returns the bytes available on the file system of the
+	// directory.
+	FreeSpace func(dir string) (uint64, error)
+	// Listen listens on the TCP address, to check that it is free.
+	Listen func(address string) (net.Listener, error)
+}
+
+// New returns a Doctor checking this host.
+func New(appPaths paths.Paths, appRunning bool, dockerConfigDir string) *Doctor {
+	return &Doctor{
+		Paths:           appPaths,
+		Checkers:        DefaultCheckers(),
+		AppRunning:      appRunning,
+		DockerConfigDir: dockerConfigDir,
+		Getenv:          os.Getenv,
+		Command:         runCommand,
+		FreeSpace:       freeSpace,
+		Listen: func(address string) (net.Listener, error) {
+			return net.Listen("tcp", address)
+		},
+	}
+}
+
+// Run runs the checks. With fix, the Fixers fix the problems they report,
+// and check again; the fixes that changed something or failed are returned.
+func (d *Doctor) Run(fix bool) ([]checks.Check, []Fix) {
+	var results []checks.Check
+	var fixes []Fix
+	for _, checker := range d.Checkers {
+		checkerResults := checker.Check(d)
+		if fixer, ok := checker.(Fixer); ok && fix && hasProblems(checkerResults) {
+			changes, err := fixer.Fix(d)
+			if len(changes) > 0 || err != nil {
+				fixes = append(fixes, Fix{Check: checkerResults[0].Name, Changes: changes, Err: err})
+				checkerResults = checker.Check(d)
+			}
+		}
+		results = append(results, checkerResults...)
+	}
+	return results, fixes
+}
+
+func hasProblems(results []checks.Check) bool {
+	return slices.ContainsFunc(results, func(check checks.Check) bool {
+		return check.Status == checks.Warning || check.Status == checks.Failed
+	})
+}
+
+func (d *Doctor) checkDiskSpace() checks.Check {
+	check := checks.Check{Name: "disk space"}
+	// The directory doesn't exist before the first start.
+	dir := d.Paths.AppHome
+	for {
+		if _, err := os.Stat(dir); err == nil || filepath.Dir(dir) == dir {
+			break
+		}
+		dir = filepath.Dir(dir)
+	}
+	free, err := d.FreeSpace(dir)
+	switch {
+	case err != nil:
+		check.Status = checks.Failed
+		check.Message = fmt.Sprintf("failed to get the space available in %s: %s", dir, err)
+	case free < minFreeSpace:
+		check.Status = checks.Failed
+		check.Message = fmt.Sprintf("only %s is available in %s; free some space for the VM and the images", formatSize(free), dir)
+	case free < recommendedFreeSpace:
+		check.Status = checks.Warning
+		check.Message = fmt.Sprintf("only %s is available in %s; the VM and the images may soon need more", formatSize(free), dir)
+	default:
+		check.Status = checks.OK
+		check.Message = fmt.Sprintf("%s is available in %s", formatSize(free), dir)
+	}
+	return check
+}
+
+// kubernetesSettings returns the Kubernetes settings the application saved,
+// or the defaults.
+func (d *Doctor) kubernetesSettings() (enabled bool, port int) {
+	settings := struct {
+		Kubernetes struct {
+			Enabled *bool `json:"enabled"`
+			Port    int   `json:"port"`
+		} `json:"kubernetes"`
+	}{}
+	if contents, err := os.ReadFile(filepath.Join(d.Paths.Config, "settings.json")); err == nil {
+		_ = json.Unmarshal(contents, &settings)
+	}
+	enabled, port = true, defaultKubernetesPort
+	if settings.Kubernetes.Enabled != nil {
+		enabled = *settings.Kubernetes.Enabled
+	}
+	if settings.Kubernetes.Port != 0 {
+		port = settings.Kubernetes.Port
+	}
+	return enabled, port
+}
+
+func (d *Doctor) checkKubernetesPort() checks.Check {
+	check := checks.Check{Name: "kubernetes port"}
+	enabled, port := d.kubernetesSettings()
+	if !enabled {
+		check.Status = checks.Skipped
+		check.Message = "Kubernetes is disabled"
+		return check
+	}
+	listener, err := d.Listen(net.JoinHostPort("127.0.0.1", strconv.Itoa(port)))
+	switch {
+	case err == nil:
+		listener.Close()
+		check.Status = checks.OK
+		check.Message = fmt.Sprintf("port %d is free", port)
+	case d.AppRunning:
+		check.Status = checks.OK
+		check.Message = fmt.Sprintf("port %d is in use, presumably by Kubernetes as Rancher Desktop is running", port)
+	default:
+		check.Status = checks.Failed
+		check.Message = fmt.Sprintf("port %d is in use by another program; stop it, or run 'rdctl set --kubernetes.port PORT' to use another port", port)
+	}
+	return check
+}
+
+// checkIntegration checks that the tools in ~/.rd/bin, and the docker CLI
+// plugins linked to them, point to the tools of the application.
+func (d *Doctor) checkIntegration() []checks.Check {
+	links := checks.Check{Name: "tool symlinks"}
+	path := checks.Check{Name: "PATH"}
+	if d.Paths.Integration == "" {
+		// The installer puts the tools in the PATH.
+		links.Status, path.Status = checks.Skipped, checks.Skipped
+		return []checks.Check{links, path}
+	}
+	entries, err := os.ReadDir(d.Paths.Integration)
+	if errors.Is(err, os.ErrNotExist) {
+		links.Status = checks.Warning
+		links.Message = fmt.Sprintf("%s doesn't exist; Rancher Desktop creates it when it starts", d.Paths.Integration)
+		path.Status = checks.Skipped
+		return []checks.Check{links, path}
+	} else if err != nil {
+		links.Status = checks.Failed
+		links.Message = fmt.Sprintf("failed to read %s: %s", d.Paths.Integration, err)
+	} else {
+		links.Status = checks.OK
+		links.Message = fmt.Sprintf("the %d tools in %s are valid", len(entries), d.Paths.Integration)
+		var broken []string
+		for _, entry := range entries {
+			if _, err := os.Stat(filepath.Join(d.Paths.Integration, entry.Name())); err != nil {
+				broken = append(broken, entry.Name())
+			}
+		}
+		broken = append(broken, d.brokenCLIPlugins()...)
+		if len(broken) > 0 {
+			links.Status = checks.Failed
+			links.Message = fmt.Sprintf("broken symlinks: %s; restart Rancher Desktop to recreate them, or reinstall it if it was moved", strings.Join(broken, ", "))
+		}
+	}
+	if slices.Contains(filepath.SplitList(d.Getenv("PATH")), d.Paths.Integration) {
+		path.Status = checks.OK
+		path.Message = fmt.Sprintf("%s is in the PATH", d.Paths.Integration)
+	} else {
+		path.Status = checks.Warning
+		path.Message = fmt.Sprintf("%s is not in the PATH; start a new shell, or run 'rdctl set --application.path-management-strategy rcfiles'", d.Paths.Integration)
+	}
+	return []checks.Check{links, path}
+}
+
+// brokenCLIPlugins returns the docker CLI plugins linked to ~/.rd/bin whose
+// target is missing.
+func (d *Doctor) brokenCLIPlugins() []string {
+	pluginsDir := filepath.Join(d.DockerConfigDir, "cli-plugins")
+	entries, err := os.ReadDir(pluginsDir)
+	if err != nil {
+		return nil
+	}
+	var broken []string
+	for _, entry := range entries {
+		plugin := filepath.Join(pluginsDir, entry.Name())
+		target, err := os.Readlink(plugin)
+		if err != nil || filepath.Dir(target) != d.Paths.Integration {
+			continue
+		}
+		if _, err := os.Stat(plugin); err != nil {
+			broken = append(broken, plugin)
+		}
+	}
+	return broken
+}
+
+// fixIntegration recreates the broken symlinks of ~/.rd/bin to the tools of
+// this installation, removes those to tools it doesn't have, and then removes
+// the docker CLI plugins linked to removed tools.
+func (d *Doctor) fixIntegration() ([]string, error) {
+	if d.Paths.Integration == "" {
+		return nil, nil
+	}
+	entries, err := os.ReadDir(d.Paths.Integration)
+	if err != nil {
+		// Rancher Desktop creates the directory when it starts.
+		return nil, nil
+	}
+	var changes []string
+	for _, entry := range entries {
+		link := filepath.Join(d.Paths.Integration, entry.Name())
+		if _, err := os.Stat(link); err == nil {
+			continue
+		}
+		if err := os.Remove(link); err != nil {
+			return changes, err
+		}
+		target, err := d.Paths.FindResource("bin", entry.Name())
+		if err != nil {
+			changes = append(changes, fmt.Sprintf("removed %s, which isn't a tool of this installation", link))
+			continue
+		}
+		if err := os.Symlink(target, link); err != nil {
+			return changes, err
+		}
+		changes = append(changes, fmt.Sprintf("linked %s to %s", link, target))
+	}
+	for _, plugin := range d.brokenCLIPlugins() {
+		if err := os.Remove(plugin); err != nil {
+			return changes, err
+		}
+		changes = append(changes, fmt.Sprintf("removed the docker CLI plugin %s", plugin))
+	}
+	return changes, nil
+}
+
+// dockerContext is the part of the metadata of a docker context the check
+// needs.
+type dockerContext struct {
+	Name      string `json:"Name"`
+	Endpoints struct {
+		Docker struct {
+			Host string `json:"Host"`
+		} `json:"docker"`
+	} `json:"Endpoints"`
+}
+
+// dockerContexts returns the docker contexts, other than the default one.
+func (d *Doctor) dockerContexts() []dockerContext {
+	var contexts []dockerContext
+	metaFiles, _ := filepath.Glob(filepath.Join(d.DockerConfigDir, "contexts", "meta", "*", "meta.json"))
+	for _, metaFile := range metaFiles {
+		var context dockerContext
+		contents, err := os.ReadFile(metaFile)
+		if err != nil || json.Unmarshal(contents, &context) != nil {
+			continue
+		}
+		contexts = append(contexts, context)
+	}
+	return contexts
+}
+
+// currentDockerContext returns the docker context the CLI uses.
+func (d *Doctor) currentDockerContext() string {
+	current := d.Getenv("DOCKER_CONTEXT")
+	if current == "" {
+		config := struct {
+			CurrentContext string `json:"currentContext"`
+		}{}
+		if contents, err := os.ReadFile(filepath.Join(d.DockerConfigDir, "config.json")); err == nil {
+			_ = json.Unmarshal(contents, &config)
+		}
+		current = config.CurrentContext
+	}
+	if current == "" {
+		current = devcontainer.DefaultContextName
+	}
+	return current
+}
+
+func (d *Doctor) checkDockerContext() checks.Check {
+	check := checks.Check{Name: "docker context"}
+	current := d.currentDockerContext()
+	var desktopContexts []string
+	for _, context := range d.dockerContexts() {
+		if isDockerDesktopContext(context) {
+			desktopContexts = append(desktopContexts, context.Name)
+		}
+	}
+	slices.Sort(desktopContexts)
+	switch {
+	case d.Getenv("DOCKER_HOST") != "":
+		check.Status = checks.OK
+		check.Message = fmt.Sprintf("DOCKER_HOST (%s) overrides the docker context", d.Getenv("DOCKER_HOST"))
+	case slices.Contains(desktopContexts, current):
+		check.Status = checks.Warning
+		check.Message = fmt.Sprintf("the current docker context %q is the one of Docker Desktop; run 'docker context use %s'", current, devcontainer.SuggestedContext)
+	case len(desktopContexts) > 0:
+		check.Status = checks.OK
+		check.Message = fmt.Sprintf("the current docker context is %q; the contexts of Docker Desktop (%s) are not used", current, strings.Join(desktopContexts, ", "))
+	default:
+		check.Status = checks.OK
+		check.Message = fmt.Sprintf("the current docker context is %q", current)
+	}
+	return check
+}
+
+// fixDockerContext switches the docker CLI to the context of Rancher Desktop
+// in its configuration; DOCKER_CONTEXT can't be changed for the shell.
+func (d *Doctor) fixDockerContext() ([]string, error) {
+	if context := d.Getenv("DOCKER_CONTEXT"); context != "" {
+		return nil, fmt.Errorf("DOCKER_CONTEXT is set to %q; run 'unset DOCKER_CONTEXT'", context)
+	}
+	if devcontainer.SuggestedContext != devcontainer.DefaultContextName && !slices.ContainsFunc(d.dockerContexts(), func(context dockerContext) bool {
+		return context.Name == devcontainer.SuggestedContext
+	}) {
+		return nil, fmt.Errorf("the %s docker context doesn't exist; start Rancher Desktop to create it", devcontainer.SuggestedContext)
+	}
+	configPath := filepath.Join(d.DockerConfigDir, "config.json")
+	contents, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, err
+	}
+	// Keep the other settings, of which the CLI has many.
+	config := map[string]any{}
+	if err := json.Unmarshal(contents, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", configPath, err)
+	}
+	if devcontainer.SuggestedContext == devcontainer.DefaultContextName {
+		delete(config, "currentContext")
+	} else {
+		config["currentContext"] = devcontainer.SuggestedContext
+	}
+	// The docker CLI indents its configuration with tabs.
+	contents, err = json.MarshalIndent(config, "", "\t")
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(configPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(configPath, contents, info.Mode().Perm()); err != nil {
+		return nil, err
+	}
+	return []string{fmt.Sprintf("switched the docker context to %q in %s", devcontainer.SuggestedContext, configPath)}, nil
+}
+
+// checkLockFiles checks for the lock of the snapshot operations left behind
+// by an operation that failed, which blocks the others.
+func (d *Doctor) checkLockFiles() checks.Check {
+	check := checks.Check{Name: "lock files", Status: checks.OK, Message: "no locks are held"}
+	info, err := os.Stat(filepath.Join(d.Paths.AppHome, backendLockName))
+	switch {
+	case err != nil:
+	case time.Since(info.ModTime()) > staleLockAge:
+		check.Status = checks.Warning
+		check.Message = fmt.Sprintf("the lock of the snapshot operations is held since %s; if no operation is running, run 'rdctl snapshot unlock'",
+			info.ModTime().Format(time.RFC3339))
+	default:
+		check.Message = "a snapshot operation is running"
+	}
+	return check
+}
+
+// fixLockFiles removes the stale locks; a lock held by an operation that
+// might still be running is kept.
+func (d *Doctor) fixLockFiles() ([]string, error) {
+	lockPath := filepath.Join(d.Paths.AppHome, backendLockName)
+	info, err := os.Stat(lockPath)
+	if err != nil || time.Since(info.ModTime()) <= staleLockAge {
+		return nil, nil
+	}
+	if err := os.Remove(lockPath); err != nil {
+		return nil, err
+	}
+	return []string{fmt.Sprintf("removed the stale lock %s", lockPath)}, nil
+}
+
+// isDockerDesktopContext returns whether the context is one Docker Desktop
+// creates.
+func isDockerDesktopContext(context dockerContext) bool {
+	if strings.HasPrefix(context.Name, "desktop-") {
+		return true
+	}
+	host := context.Endpoints.Docker.Host
+	return strings.Contains(host, "/.docker/run/docker.sock") || strings.Contains(host, "dockerDesktop")
+}
+
+func runCommand(name string, args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command(name, args...)
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil && stderr.Len() > 0 {
+		return output, fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return output, err
+}
+
+func formatSize(size uint64) string {
+	return fmt.Sprintf("%.1fGiB", float64(size)/(1<<30))
+}
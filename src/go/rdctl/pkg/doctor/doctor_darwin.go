@@ -0,0 +1,25 @@
+package doctor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/checks"
+)
+
+func (d *Doctor) platformChecks() []checks.Check {
+	check := checks.Check{Name: "virtualization"}
+	output, err := d.Command("sysctl", "-n", "kern.hv_support")
+	switch {
+	case err != nil:
+		check.Status = checks.Failed
+		check.Message = fmt.Sprintf("failed to check the support of the Hypervisor framework: %s", err)
+	case strings.TrimSpace(string(output)) != "1":
+		check.Status = checks.Failed
+		check.Message = "the Hypervisor framework is not supported; Rancher Desktop can't run in a VM without nested virtualization"
+	default:
+		check.Status = checks.OK
+		check.Message = "the Hypervisor framework is supported"
+	}
+	return []checks.Check{check}
+}
@@ -0,0 +1,33 @@
+package doctor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/checks"
+)
+
+// kvmDevice is the device QEMU needs to run the VM with hardware acceleration.
+var kvmDevice = "/dev/kvm"
+
+func (d *Doctor) platformChecks() []checks.Check {
+	check := checks.Check{Name: "virtualization"}
+	f, err := os.OpenFile(kvmDevice, os.O_RDWR, 0)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		check.Status = checks.Failed
+		check.Message = fmt.Sprintf("%s doesn't exist; enable virtualization in the firmware settings, and load the kvm_intel or kvm_amd module", kvmDevice)
+	case errors.Is(err, os.ErrPermission):
+		check.Status = checks.Failed
+		check.Message = fmt.Sprintf("%s can't be opened; run 'sudo usermod -a -G kvm \"$USER\"', and log in again", kvmDevice)
+	case err != nil:
+		check.Status = checks.Failed
+		check.Message = fmt.Sprintf("failed to open %s: %s", kvmDevice, err)
+	default:
+		f.Close()
+		check.Status = checks.OK
+		check.Message = fmt.Sprintf("%s is available", kvmDevice)
+	}
+	return []checks.Check{check}
+}
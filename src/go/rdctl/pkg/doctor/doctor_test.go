@@ -0,0 +1,285 @@
+package doctor
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/checks"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/devcontainer"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDoctor(t *testing.T, env map[string]string) *Doctor {
+	dir := t.TempDir()
+	return &Doctor{
+		Paths: paths.Paths{
+			AppHome:     filepath.Join(dir, "app", "rancher-desktop"),
+			Config:      filepath.Join(dir, "config"),
+			Integration: filepath.Join(dir, ".rd", "bin"),
+			Resources:   filepath.Join(dir, "app", "resources"),
+		},
+		DockerConfigDir: filepath.Join(dir, ".docker"),
+		Getenv:          func(key string) string { return env[key] },
+		FreeSpace: func(dir string) (uint64, error) {
+			return 50 << 30, nil
+		},
+		Listen: func(address string) (net.Listener, error) {
+			return nil, errors.New("address already in use")
+		},
+	}
+}
+
+func writeFile(t *testing.T, path, contents string) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(contents), 0o644))
+}
+
+func TestCheckDiskSpace(t *testing.T) {
+	d := newTestDoctor(t, nil)
+	var checkedDir string
+	for _, testCase := range []struct {
+		free   uint64
+		err    error
+		status checks.Status
+	}{
+		{free: 50 << 30, status: checks.OK},
+		{free: 5 << 30, status: checks.Warning},
+		{free: 1 << 30, status: checks.Failed},
+		{err: errors.New("no such device"), status: checks.Failed},
+	} {
+		d.FreeSpace = func(dir string) (uint64, error) {
+			checkedDir = dir
+			return testCase.free, testCase.err
+		}
+		assert.Equal(t, testCase.status, d.checkDiskSpace().Status, testCase)
+	}
+	// The application home doesn't exist yet.
+	assert.Equal(t, filepath.Dir(filepath.Dir(d.Paths.AppHome)), checkedDir)
+}
+
+func TestCheckKubernetesPort(t *testing.T) {
+	d := newTestDoctor(t, nil)
+	check := d.checkKubernetesPort()
+	assert.Equal(t, checks.Failed, check.Status)
+	assert.Contains(t, check.Message, "port 6443 is in use by another program")
+
+	d.AppRunning = true
+	assert.Equal(t, checks.OK, d.checkKubernetesPort().Status)
+
+	var address string
+	d.Listen = func(a string) (net.Listener, error) {
+		address = a
+		return net.Listen("tcp", "127.0.0.1:0")
+	}
+	writeFile(t, filepath.Join(d.Paths.Config, "settings.json"), `{"kubernetes": {"port": 6444}}`)
+	assert.Equal(t, checks.Check{Name: "kubernetes port", Status: checks.OK, Message: "port 6444 is free"}, d.checkKubernetesPort())
+	assert.Equal(t, "127.0.0.1:6444", address)
+
+	writeFile(t, filepath.Join(d.Paths.Config, "settings.json"), `{"kubernetes": {"enabled": false}}`)
+	assert.Equal(t, checks.Skipped, d.checkKubernetesPort().Status)
+}
+
+func TestCheckIntegration(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows doesn't use symlinks for the tools")
+	}
+	d := newTestDoctor(t, nil)
+	results := d.checkIntegration()
+	assert.Equal(t, []checks.Status{checks.Warning, checks.Skipped}, []checks.Status{results[0].Status, results[1].Status})
+
+	resources := t.TempDir()
+	writeFile(t, filepath.Join(resources, "docker"), "")
+	writeFile(t, filepath.Join(resources, "docker-compose"), "")
+	require.NoError(t, os.MkdirAll(d.Paths.Integration, 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Join(d.DockerConfigDir, "cli-plugins"), 0o755))
+	for _, name := range []string{"docker", "docker-compose"} {
+		require.NoError(t, os.Symlink(filepath.Join(resources, name), filepath.Join(d.Paths.Integration, name)))
+	}
+	require.NoError(t, os.Symlink(filepath.Join(d.Paths.Integration, "docker-compose"), filepath.Join(d.DockerConfigDir, "cli-plugins", "docker-compose")))
+	require.NoError(t, os.Symlink(filepath.Join(d.Paths.Integration, "docker-buildx"), filepath.Join(d.DockerConfigDir, "cli-plugins", "docker-buildx")))
+	// Plugins installed otherwise aren't checked.
+	require.NoError(t, os.Symlink("/missing/docker-scan", filepath.Join(d.DockerConfigDir, "cli-plugins", "docker-scan")))
+
+	results = d.checkIntegration()
+	assert.Equal(t, checks.Failed, results[0].Status)
+	assert.Equal(t, "broken symlinks: "+filepath.Join(d.DockerConfigDir, "cli-plugins", "docker-buildx")+
+		"; restart Rancher Desktop to recreate them, or reinstall it if it was moved", results[0].Message)
+	assert.Equal(t, checks.Warning, results[1].Status)
+
+	require.NoError(t, os.Remove(filepath.Join(d.DockerConfigDir, "cli-plugins", "docker-buildx")))
+	d.Getenv = func(key string) string {
+		return "/usr/bin" + string(filepath.ListSeparator) + d.Paths.Integration
+	}
+	results = d.checkIntegration()
+	assert.Equal(t, checks.OK, results[0].Status, results[0].Message)
+	assert.Equal(t, checks.OK, results[1].Status, results[1].Message)
+
+	require.NoError(t, os.Remove(filepath.Join(resources, "docker")))
+	results = d.checkIntegration()
+	assert.Contains(t, results[0].Message, "broken symlinks: docker;")
+}
+
+func TestCheckDockerContext(t *testing.T) {
+	d := newTestDoctor(t, nil)
+	assert.Equal(t, checks.Check{Name: "docker context", Status: checks.OK, Message: `the current docker context is "default"`}, d.checkDockerContext())
+
+	writeFile(t, filepath.Join(d.DockerConfigDir, "contexts", "meta", "a", "meta.json"),
+		`{"Name": "desktop-linux", "Endpoints": {"docker": {"Host": "unix:///home/me/.docker/run/docker.sock"}}}`)
+	writeFile(t, filepath.Join(d.DockerConfigDir, "contexts", "meta", "b", "meta.json"),
+		`{"Name": "rancher-desktop", "Endpoints": {"docker": {"Host": "unix:///home/me/.rd/docker.sock"}}}`)
+	writeFile(t, filepath.Join(d.DockerConfigDir, "config.json"), `{"currentContext": "rancher-desktop"}`)
+	check := d.checkDockerContext()
+	assert.Equal(t, checks.OK, check.Status)
+	assert.Equal(t, `the current docker context is "rancher-desktop"; the contexts of Docker Desktop (desktop-linux) are not used`, check.Message)
+
+	writeFile(t, filepath.Join(d.DockerConfigDir, "config.json"), `{"currentContext": "desktop-linux"}`)
+	check = d.checkDockerContext()
+	assert.Equal(t, checks.Warning, check.Status)
+	assert.Contains(t, check.Message, `the current docker context "desktop-linux" is the one of Docker Desktop`)
+
+	d.Getenv = func(key string) string {
+		return map[string]string{"DOCKER_CONTEXT": "rancher-desktop"}[key]
+	}
+	assert.Equal(t, checks.OK, d.checkDockerContext().Status)
+}
+
+// countingFixer reports a problem until it is fixed.
+type countingFixer struct {
+	fixed bool
+	fixes int
+}
+
+func (f *countingFixer) Check(d *Doctor) []checks.Check {
+	if f.fixed {
+		return []checks.Check{{Name: "fixable", Status: checks.OK}}
+	}
+	return []checks.Check{{Name: "fixable", Status: checks.Failed}}
+}
+
+func (f *countingFixer) Fix(d *Doctor) ([]string, error) {
+	f.fixes++
+	f.fixed = true
+	return []string{"fixed it"}, nil
+}
+
+func TestRun(t *testing.T) {
+	d := newTestDoctor(t, nil)
+	fixable := &countingFixer{}
+	d.Checkers = []Checker{
+		single(func(d *Doctor) checks.Check { return checks.Check{Name: "fine", Status: checks.OK} }),
+		fixable,
+		fixer{
+			check: single(func(d *Doctor) checks.Check { return checks.Check{Name: "unfixable", Status: checks.Warning} }),
+			fix:   func(d *Doctor) ([]string, error) { return nil, errors.New("cannot fix") },
+		},
+	}
+	results, fixes := d.Run(false)
+	assert.Equal(t, []checks.Status{checks.OK, checks.Failed, checks.Warning},
+		[]checks.Status{results[0].Status, results[1].Status, results[2].Status})
+	assert.Empty(t, fixes)
+	assert.Equal(t, 0, fixable.fixes)
+
+	results, fixes = d.Run(true)
+	assert.Equal(t, []checks.Status{checks.OK, checks.OK, checks.Warning},
+		[]checks.Status{results[0].Status, results[1].Status, results[2].Status})
+	assert.Equal(t, []Fix{
+		{Check: "fixable", Changes: []string{"fixed it"}},
+		{Check: "unfixable", Err: errors.New("cannot fix")},
+	}, fixes)
+
+	// Checks without problems aren't fixed.
+	_, fixes = d.Run(true)
+	assert.Equal(t, 1, fixable.fixes)
+	assert.Len(t, fixes, 1)
+}
+
+func TestFixIntegration(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows doesn't use symlinks for the tools")
+	}
+	d := newTestDoctor(t, nil)
+	binDir := filepath.Join(d.Paths.Resources, paths.PlatformDir(), "bin")
+	writeFile(t, filepath.Join(binDir, "docker"), "")
+	require.NoError(t, os.MkdirAll(d.Paths.Integration, 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Join(d.DockerConfigDir, "cli-plugins"), 0o755))
+	links := map[string]string{
+		filepath.Join(d.Paths.Integration, "docker"):                     "/moved/docker",
+		filepath.Join(d.Paths.Integration, "docker-old"):                 "/moved/docker-old",
+		filepath.Join(d.DockerConfigDir, "cli-plugins", "docker-old"):    filepath.Join(d.Paths.Integration, "docker-old"),
+		filepath.Join(d.DockerConfigDir, "cli-plugins", "docker-buildx"): "/usr/libexec/docker-buildx",
+	}
+	for link, target := range links {
+		require.NoError(t, os.Symlink(target, link))
+	}
+
+	changes, err := d.fixIntegration()
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"linked " + filepath.Join(d.Paths.Integration, "docker") + " to " + filepath.Join(binDir, "docker"),
+		"removed " + filepath.Join(d.Paths.Integration, "docker-old") + ", which isn't a tool of this installation",
+		"removed the docker CLI plugin " + filepath.Join(d.DockerConfigDir, "cli-plugins", "docker-old"),
+	}, changes)
+	assert.Equal(t, checks.OK, d.checkIntegration()[0].Status)
+	// Plugins installed otherwise are kept.
+	_, err = os.Lstat(filepath.Join(d.DockerConfigDir, "cli-plugins", "docker-buildx"))
+	assert.NoError(t, err)
+
+	changes, err = d.fixIntegration()
+	assert.NoError(t, err)
+	assert.Empty(t, changes)
+}
+
+func TestFixDockerContext(t *testing.T) {
+	d := newTestDoctor(t, nil)
+	writeFile(t, filepath.Join(d.DockerConfigDir, "contexts", "meta", "a", "meta.json"),
+		`{"Name": "desktop-linux", "Endpoints": {"docker": {"Host": "unix:///home/me/.docker/run/docker.sock"}}}`)
+	writeFile(t, filepath.Join(d.DockerConfigDir, "config.json"), `{"auths": {}, "currentContext": "desktop-linux"}`)
+	if devcontainer.SuggestedContext != devcontainer.DefaultContextName {
+		_, err := d.fixDockerContext()
+		assert.ErrorContains(t, err, "docker context doesn't exist")
+		writeFile(t, filepath.Join(d.DockerConfigDir, "contexts", "meta", "b", "meta.json"),
+			`{"Name": "rancher-desktop", "Endpoints": {"docker": {"Host": "unix:///home/me/.rd/docker.sock"}}}`)
+	}
+
+	changes, err := d.fixDockerContext()
+	require.NoError(t, err)
+	assert.Len(t, changes, 1)
+	assert.Equal(t, checks.OK, d.checkDockerContext().Status)
+	contents, err := os.ReadFile(filepath.Join(d.DockerConfigDir, "config.json"))
+	require.NoError(t, err)
+	assert.Contains(t, string(contents), `"auths": {}`)
+
+	d.Getenv = func(key string) string {
+		return map[string]string{"DOCKER_CONTEXT": "desktop-linux"}[key]
+	}
+	_, err = d.fixDockerContext()
+	assert.ErrorContains(t, err, "run 'unset DOCKER_CONTEXT'")
+}
+
+func TestLockFiles(t *testing.T) {
+	d := newTestDoctor(t, nil)
+	assert.Equal(t, checks.Check{Name: "lock files", Status: checks.OK, Message: "no locks are held"}, d.checkLockFiles())
+
+	lockPath := filepath.Join(d.Paths.AppHome, backendLockName)
+	writeFile(t, lockPath, `{"action": "snapshot create"}`)
+	assert.Equal(t, checks.Check{Name: "lock files", Status: checks.OK, Message: "a snapshot operation is running"}, d.checkLockFiles())
+	changes, err := d.fixLockFiles()
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+	assert.FileExists(t, lockPath)
+
+	old := time.Now().Add(-2 * staleLockAge)
+	require.NoError(t, os.Chtimes(lockPath, old, old))
+	assert.Equal(t, checks.Warning, d.checkLockFiles().Status)
+	changes, err = d.fixLockFiles()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"removed the stale lock " + lockPath}, changes)
+	assert.NoFileExists(t, lockPath)
+}
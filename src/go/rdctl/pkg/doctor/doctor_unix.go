@@ -0,0 +1,15 @@
+//go:build unix
+
+package doctor
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+func freeSpace(dir string) (uint64, error) {
+	var stat unix.Statfs_t
+	if err := unix.Statfs(dir, &stat); err != nil {
+		return 0, err
+	}
+	return uint64(stat.Bavail) * uint64(stat.Bsize), nil
+}
@@ -0,0 +1,91 @@
+package doctor
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/checks"
+	"golang.org/x/sys/windows"
+	"golang.org/x/text/encoding/unicode"
+)
+
+func (d *Doctor) platformChecks() []checks.Check {
+	return []checks.Check{d.checkWSL(), d.checkVirtualization()}
+}
+
+// wslOutput runs wsl.exe, which writes UTF-16.
+func (d *Doctor) wslOutput(args ...string) (string, error) {
+	output, err := d.Command("wsl.exe", args...)
+	if bytes.IndexByte(output, 0) >= 0 {
+		decoder := unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM).NewDecoder()
+		if decoded, decodeErr := decoder.Bytes(output); decodeErr == nil {
+			output = decoded
+		}
+	}
+	return strings.ReplaceAll(string(output), "\r", ""), err
+}
+
+func (d *Doctor) checkWSL() checks.Check {
+	check := checks.Check{Name: "WSL"}
+	output, err := d.wslOutput("--version")
+	if err == nil {
+		check.Status = checks.OK
+		check.Message = "WSL is installed"
+		if version, _, _ := strings.Cut(output, "\n"); version != "" {
+			check.Message = fmt.Sprintf("WSL is installed (%s)", strings.TrimSpace(version))
+		}
+		return check
+	}
+	// Versions of WSL older than the one from the Microsoft Store don't
+	// have --version.
+	if _, err := d.wslOutput("--status"); err == nil {
+		check.Status = checks.Warning
+		check.Message = "WSL is an old version; run 'wsl --update' to update it"
+		return check
+	}
+	check.Status = checks.Failed
+	check.Message = "WSL is not installed; run 'wsl --install --no-distribution' as an administrator, and restart the computer"
+	return check
+}
+
+// virtualizationScript prints whether a hypervisor is running, and whether
+// the virtualization extensions of the CPU are enabled in the firmware; the
+// latter is only reported while no hypervisor is running.
+const virtualizationScript = `(Get-CimInstance Win32_ComputerSystem).HypervisorPresent; ` +
+	`(Get-CimInstance Win32_Processor | Select-Object -First 1).VirtualizationFirmwareEnabled`
+
+func (d *Doctor) checkVirtualization() checks.Check {
+	check := checks.Check{Name: "virtualization"}
+	output, err := d.Command("powershell.exe", "-NoProfile", "-NonInteractive", "-Command", virtualizationScript)
+	if err != nil {
+		check.Status = checks.Warning
+		check.Message = fmt.Sprintf("failed to check virtualization: %s", err)
+		return check
+	}
+	values := strings.Fields(string(output))
+	switch {
+	case len(values) > 0 && strings.EqualFold(values[0], "True"):
+		check.Status = checks.OK
+		check.Message = "the hypervisor is running"
+	case len(values) > 1 && strings.EqualFold(values[1], "True"):
+		check.Status = checks.Failed
+		check.Message = "the hypervisor is not running; enable the Virtual Machine Platform Windows feature, and restart the computer"
+	default:
+		check.Status = checks.Failed
+		check.Message = "virtualization is disabled; enable it (Intel VT-x or AMD-V) in the firmware settings of the computer"
+	}
+	return check
+}
+
+func freeSpace(dir string) (uint64, error) {
+	path, err := windows.UTF16PtrFromString(dir)
+	if err != nil {
+		return 0, err
+	}
+	var available, total, free uint64
+	if err := windows.GetDiskFreeSpaceEx(path, &available, &total, &free); err != nil {
+		return 0, err
+	}
+	return available, nil
+}
@@ -0,0 +1,51 @@
+// Package events reads the events that the application streams from
+// `GET /v1/events` in the server-sent events format.
+package events
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Event is an event of the application.
+type Event struct {
+	// Type is e.g. "settings-changed" or "backend-state-changed".
+	Type string          `json:"type"`
+	Time string          `json:"time"`
+	Data json.RawMessage `json:"data"`
+}
+
+// Read calls handle with each event read from the stream, until the stream
+// ends or handle returns an error. Comments, which the server sends to keep
+// the connection open, are skipped.
+func Read(r io.Reader, handle func(Event) error) error {
+	scanner := bufio.NewScanner(r)
+	// Events are small, but settings may grow them.
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	var data []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if len(data) == 0 {
+				continue
+			}
+			var event Event
+			if err := json.Unmarshal([]byte(strings.Join(data, "\n")), &event); err != nil {
+				return fmt.Errorf("failed to parse event: %w", err)
+			}
+			data = nil
+			if err := handle(event); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, ":"):
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+		// The type is also in the data, so "event:" lines are not needed.
+	}
+	return scanner.Err()
+}
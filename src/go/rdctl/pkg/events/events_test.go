@@ -0,0 +1,44 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRead(t *testing.T) {
+	stream := ": subscribed\n\n" +
+		"event: backend-state-changed\n" +
+		`data: {"type":"backend-state-changed","time":"2024-05-01T10:00:00.000Z","data":{"state":"STARTED"}}` + "\n\n" +
+		": keepalive\n\n" +
+		"event: settings-changed\n" +
+		`data: {"type":"settings-changed","time":"2024-05-01T10:00:01.000Z","data":{}}` + "\n\n"
+	var received []Event
+	require.NoError(t, Read(strings.NewReader(stream), func(event Event) error {
+		received = append(received, event)
+		return nil
+	}))
+	assert.Equal(t, []Event{
+		{Type: "backend-state-changed", Time: "2024-05-01T10:00:00.000Z", Data: json.RawMessage(`{"state":"STARTED"}`)},
+		{Type: "settings-changed", Time: "2024-05-01T10:00:01.000Z", Data: json.RawMessage(`{}`)},
+	}, received)
+
+	t.Run("handler errors stop reading", func(t *testing.T) {
+		stop := errors.New("stop")
+		count := 0
+		err := Read(strings.NewReader(stream), func(event Event) error {
+			count++
+			return stop
+		})
+		assert.ErrorIs(t, err, stop)
+		assert.Equal(t, 1, count)
+	})
+	t.Run("invalid data", func(t *testing.T) {
+		err := Read(strings.NewReader("data: {\n\n"), func(event Event) error { return nil })
+		assert.ErrorContains(t, err, "failed to parse event")
+	})
+}
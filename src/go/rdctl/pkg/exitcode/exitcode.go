@@ -0,0 +1,87 @@
+// Package exitcode defines the process exit codes that rdctl commands use,
+// so that scripts can tell apart the different ways a command can fail.
+package exitcode
+
+import "errors"
+
+const (
+	// Success means the command did everything it was asked to do.
+	Success = 0
+	// Failure means the command failed; this is also used for any error
+	// that doesn't specify a more precise exit code.
+	Failure = 1
+	// NotRunning means the command found Rancher Desktop, or its backend,
+	// not running.
+	NotRunning = 2
+	// PartialSuccess means a command consisting of several independent steps
+	// completed some of them, but at least one step failed or was skipped.
+	PartialSuccess = 3
+	// ConnectionRefused means nothing answered at the address of the API,
+	// e.g. because the application quit without removing its config file.
+	ConnectionRefused = 4
+	// AuthFailure means the API didn't accept the credentials.
+	AuthFailure = 5
+	// InvalidInput means the arguments, flags or settings given to the
+	// command were rejected.
+	InvalidInput = 6
+)
+
+// names are the stable names of the exit codes, reported with errors in the
+// JSON output so that tools don't depend on the messages.
+var names = map[int]string{
+	Success:           "success",
+	Failure:           "failure",
+	NotRunning:        "not-running",
+	PartialSuccess:    "partial-success",
+	ConnectionRefused: "connection-refused",
+	AuthFailure:       "auth-failure",
+	InvalidInput:      "invalid-input",
+}
+
+// Name returns the stable name of the exit code, such as "not-running".
+func Name(code int) string {
+	if name, ok := names[code]; ok {
+		return name
+	}
+	return names[Failure]
+}
+
+// Coder is implemented by errors that carry their own exit code.
+type Coder interface {
+	error
+	ExitCode() int
+}
+
+// FromError returns the exit code to use for the error returned by a command.
+func FromError(err error) int {
+	if err == nil {
+		return Success
+	}
+	var coder Coder
+	if errors.As(err, &coder) {
+		return coder.ExitCode()
+	}
+	return Failure
+}
+
+// WithCode returns an error that wraps err, but has the given exit code.
+func WithCode(err error, code int) error {
+	return &codedError{err: err, code: code}
+}
+
+type codedError struct {
+	err  error
+	code int
+}
+
+func (e *codedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *codedError) Unwrap() error {
+	return e.err
+}
+
+func (e *codedError) ExitCode() int {
+	return e.code
+}
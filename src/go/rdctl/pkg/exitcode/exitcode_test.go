@@ -0,0 +1,23 @@
+package exitcode
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromError(t *testing.T) {
+	assert.Equal(t, Success, FromError(nil))
+	assert.Equal(t, Failure, FromError(errors.New("failed")))
+	coded := WithCode(errors.New("denied"), AuthFailure)
+	assert.Equal(t, AuthFailure, FromError(coded))
+	assert.Equal(t, AuthFailure, FromError(fmt.Errorf("401 Unauthorized: %w", coded)))
+}
+
+func TestName(t *testing.T) {
+	assert.Equal(t, "not-running", Name(NotRunning))
+	assert.Equal(t, "invalid-input", Name(InvalidInput))
+	assert.Equal(t, "failure", Name(42))
+}
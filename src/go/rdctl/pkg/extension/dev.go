@@ -0,0 +1,202 @@
+// Package extension supports developing extensions: it builds the image of an
+// extension from its source directory in the VM, so that it can be installed
+// without being pushed to a registry, and watches the directory for changes.
+package extension
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/vm"
+)
+
+// Namespace is the containerd namespace of the extension images, in which the
+// application looks for them.
+const Namespace = "rancher-desktop-extensions"
+
+// DevTag is the tag of the images built from source directories.
+const DevTag = "dev"
+
+// buildScript builds the image "$2" with the container engine "$1", from the
+// build context read from standard input as a tar archive. The context is
+// extracted first, as not all engines can read it from standard input.
+const buildScript = `
+set -o errexit
+if [ "$1" = moby ]; then
+  CLI=docker
+else
+  CLI="nerdctl --namespace ` + Namespace + `"
+fi
+context="$(mktemp -d)"
+trap 'rm -rf "$context"' EXIT
+tar -x -C "$context"
+$CLI build --tag "$2" "$context" 2>&1
+`
+
+// ignored are the names of the files and directories left out of the build
+// context.
+var ignored = map[string]bool{".git": true}
+
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9._-]+`)
+
+// DefaultImage returns the image to build from the directory when none is
+// given: the name of the directory, with the dev tag.
+func DefaultImage(dir string) string {
+	name := strings.ToLower(filepath.Base(filepath.Clean(dir)))
+	name = strings.Trim(invalidNameChars.ReplaceAllString(name, "-"), "-._")
+	if name == "" {
+		name = "extension"
+	}
+	return fmt.Sprintf("rd-dev/%s:%s", name, DevTag)
+}
+
+// Build builds the image from the directory, which holds the Dockerfile of
+// the extension, with the given container engine ("moby" or "containerd").
+// The output of the build goes to the writer.
+func Build(runner vm.Runner, engine, dir, image string, output io.Writer) error {
+	reader, writer := io.Pipe()
+	go func() {
+		_ = writer.CloseWithError(writeContext(writer, dir))
+	}()
+	err := runner.RootStream(reader, output, "sh", "-c", buildScript, "sh", engine, image)
+	// Stop writing the context if the build failed before reading all of it.
+	_ = reader.Close()
+	if err != nil {
+		return fmt.Errorf("failed to build image %s from %s: %w", image, dir, err)
+	}
+	return nil
+}
+
+// writeContext writes the files of the directory as a tar archive.
+func writeContext(w io.Writer, dir string) error {
+	archive := tar.NewWriter(w)
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == dir {
+			return nil
+		}
+		if ignored[entry.Name()] {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		link := ""
+		if info.Mode()&fs.ModeSymlink != 0 {
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		}
+		header, err := tar.FileInfoHeader(info, filepath.ToSlash(link))
+		if err != nil {
+			return err
+		}
+		relative, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(relative)
+		if err := archive.WriteHeader(header); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		_, err = io.Copy(archive, file)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to archive %s: %w", dir, err)
+	}
+	return archive.Close()
+}
+
+// Watch calls onChange whenever files in the directory change, once the
+// changes have settled for the given delay, until the context is done.
+// Changes made while onChange runs lead to a further call.
+func Watch(ctx context.Context, dir string, delay time.Duration, onChange func()) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to watch %s: %w", dir, err)
+	}
+	defer watcher.Close()
+	// The watches are not recursive, so each directory is watched.
+	addDirs := func(root string) error {
+		return filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil || !entry.IsDir() {
+				return err
+			}
+			if path != root && ignored[entry.Name()] {
+				return filepath.SkipDir
+			}
+			return watcher.Add(path)
+		})
+	}
+	if err := addDirs(dir); err != nil {
+		return fmt.Errorf("failed to watch %s: %w", dir, err)
+	}
+	timer := time.NewTimer(delay)
+	timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if isIgnored(dir, event.Name) {
+				continue
+			}
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					// The directory may be removed again before it is watched.
+					_ = addDirs(event.Name)
+				}
+			}
+			timer.Reset(delay)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			return fmt.Errorf("failed to watch %s: %w", dir, err)
+		case <-timer.C:
+			onChange()
+		}
+	}
+}
+
+// isIgnored returns whether the path is in an ignored directory, or is
+// ignored itself.
+func isIgnored(dir, path string) bool {
+	relative, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	for _, part := range strings.Split(filepath.ToSlash(relative), "/") {
+		if ignored[part] {
+			return true
+		}
+	}
+	return false
+}
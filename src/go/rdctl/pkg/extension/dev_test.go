@@ -0,0 +1,118 @@
+package extension
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeVM records the files of the build context it is sent.
+type fakeVM struct {
+	args  []string
+	files []string
+	err   error
+}
+
+func (v *fakeVM) RootOutput(args ...string) ([]byte, error) {
+	return nil, errors.New("unexpected command")
+}
+
+func (v *fakeVM) RootStream(stdin io.Reader, stdout io.Writer, args ...string) error {
+	v.args = args
+	archive := tar.NewReader(stdin)
+	for {
+		header, err := archive.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		v.files = append(v.files, header.Name)
+	}
+	return v.err
+}
+
+func writeExtension(t *testing.T) string {
+	dir := filepath.Join(t.TempDir(), "My Extension")
+	for name, contents := range map[string]string{
+		"Dockerfile":    "FROM scratch\n",
+		"metadata.json": "{}\n",
+		"ui/index.html": "<html></html>\n",
+		".git/HEAD":     "ref: refs/heads/main\n",
+	} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(contents), 0o644))
+	}
+	return dir
+}
+
+func TestDefaultImage(t *testing.T) {
+	assert.Equal(t, "rd-dev/my-extension:dev", DefaultImage(filepath.Join("src", "My Extension")+string(filepath.Separator)))
+	assert.Equal(t, "rd-dev/extension:dev", DefaultImage("___"))
+}
+
+func TestBuild(t *testing.T) {
+	dir := writeExtension(t)
+	t.Run("sends the build context", func(t *testing.T) {
+		runner := &fakeVM{}
+		require.NoError(t, Build(runner, "containerd", dir, "rd-dev/my-extension:dev", io.Discard))
+		assert.Equal(t, []string{"sh", "-c", buildScript, "sh", "containerd", "rd-dev/my-extension:dev"}, runner.args)
+		sort.Strings(runner.files)
+		assert.Equal(t, []string{"Dockerfile", "metadata.json", "ui", "ui/index.html"}, runner.files)
+	})
+	t.Run("build failure", func(t *testing.T) {
+		runner := &fakeVM{err: errors.New("exit status 1")}
+		err := Build(runner, "moby", dir, "rd-dev/my-extension:dev", io.Discard)
+		assert.ErrorContains(t, err, "failed to build image rd-dev/my-extension:dev")
+	})
+}
+
+func TestWatch(t *testing.T) {
+	dir := writeExtension(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes := make(chan struct{}, 10)
+	done := make(chan error)
+	go func() {
+		done <- Watch(ctx, dir, 50*time.Millisecond, func() { changes <- struct{}{} })
+	}()
+	// Give the watcher time to start.
+	time.Sleep(100 * time.Millisecond)
+
+	// Changes in the .git directory are ignored.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".git", "HEAD"), []byte("ref: refs/heads/other\n"), 0o644))
+	// A burst of changes, in new directories too, leads to a single call.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Dockerfile"), []byte("FROM alpine\n"), 0o644))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "backend"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ui", "index.html"), []byte("<html>2</html>\n"), 0o644))
+	select {
+	case <-changes:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no change reported")
+	}
+	select {
+	case <-changes:
+		t.Fatal("changes reported more than once")
+	case <-time.After(200 * time.Millisecond):
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "backend", "main.go"), []byte("package main\n"), 0o644))
+	select {
+	case <-changes:
+	case <-time.After(5 * time.Second):
+		t.Fatal("change in a new directory not reported")
+	}
+
+	cancel()
+	assert.NoError(t, <-done)
+}
@@ -25,78 +25,78 @@ import (
 	"path"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
 	"syscall"
 
 	dockerconfig "github.com/docker/docker/cli/config"
 	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/directories"
 	p "github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/steps"
 	"github.com/sirupsen/logrus"
 )
 
-func addAppHomeWithoutSnapshots(appHome string) []string {
-	haveSnapshots := false
-	if snapshots, err := os.ReadDir(filepath.Join(appHome, "snapshots")); err == nil {
-		haveSnapshots = len(snapshots) > 0
+// DeleteData deletes the data of the installation within the scope.
+func DeleteData(paths p.Paths, scope Scope, runner *steps.Runner) error {
+	if scope.OnlyCache {
+		runner.Run("remove the cache", func() error {
+			return os.RemoveAll(paths.Cache)
+		})
+		return runner.Err()
 	}
-	if !haveSnapshots {
-		return []string{appHome}
-	}
-	appHomeMembers, err := os.ReadDir(appHome)
-	if err != nil {
-		logrus.Errorf("failed to read contents of dir %s: %s", appHome, err)
-		return []string{appHome}
-	}
-	pathList := make([]string, 0, len(appHomeMembers))
-	for _, entry := range appHomeMembers {
-		if filepath.Base(entry.Name()) != "snapshots" {
-			pathList = append(pathList, filepath.Join(appHome, entry.Name()))
-		}
-	}
-	return pathList
+	return deleteData(paths, scope, runner)
 }
 
-// Most of the errors in this function are reported, but we continue to try to delete things,
-// because there isn't really a dependency graph here.
+// The steps here don't really depend on each other, so unless the runner is in
+// fail-fast mode, a failure in one step doesn't stop the others from running.
 // For example, if we can't delete the Lima VM, that doesn't mean we can't remove docker files
 // or pull the path settings out of the shell profile files.
-func deleteUnixLikeData(paths p.Paths, pathList []string) error {
-	if err := deleteLimaVM(); err != nil {
-		logrus.Errorf("Error trying to delete the Lima VM: %s\n", err)
+func deleteUnixLikeData(paths p.Paths, pathList []string, scope Scope, runner *steps.Runner) error {
+	if !scope.KeepVM() {
+		runner.Run("delete the Lima VM", deleteLimaVM)
 	}
-	for _, currentPath := range pathList {
-		if err := os.RemoveAll(currentPath); err != nil {
-			logrus.Errorf("Error trying to remove %s: %s", currentPath, err)
+	keep := scope.keptPaths([]string{paths.Lima}, []string{paths.Config})
+	pathList = append(slices.DeleteFunc(pathList, func(path string) bool {
+		return slices.Contains(keep, path)
+	}), appHomeEntries(paths.AppHome, keep)...)
+	runner.Run("remove application data", func() error {
+		var errs []error
+		for _, currentPath := range pathList {
+			if err := os.RemoveAll(currentPath); err != nil {
+				errs = append(errs, fmt.Errorf("failed to remove %s: %w", currentPath, err))
+			}
 		}
+		return errors.Join(errs...)
+	})
+	runner.Run("clear the docker context", clearDockerContext)
+	runner.Run("remove docker CLI plugins", func() error {
+		return removeDockerCliPlugins(paths.AltAppHome)
+	})
+	if scope.KeepSettings {
+		return runner.Err()
 	}
-	if err := clearDockerContext(); err != nil {
-		logrus.Errorf("Error trying to clear the docker context %s", err)
-	}
-	if err := removeDockerCliPlugins(paths.AltAppHome); err != nil {
-		logrus.Errorf("Error trying to remove docker plugins %s", err)
-	}
-
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		// If we can't get home directory, none of the below code is valid
-		logrus.Errorf("Error trying to get home dir: %s", err)
-		return nil
-	}
-	rawPaths := []string{
-		".bashrc",
-		".bash_profile",
-		".bash_login",
-		".profile",
-		".zshrc",
-		".cshrc",
-		".tcshrc",
-	}
-	for i, s := range rawPaths {
-		rawPaths[i] = path.Join(homeDir, s)
-	}
-	rawPaths = append(rawPaths, path.Join(homeDir, ".config", "fish", "config.fish"))
-
-	return removePathManagement(rawPaths)
+	runner.Run("remove path management from shell profiles", func() error {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			// If we can't get home directory, none of the below code is valid
+			return fmt.Errorf("failed to get home dir: %w", err)
+		}
+		rawPaths := []string{
+			".bashrc",
+			".bash_profile",
+			".bash_login",
+			".profile",
+			".zshrc",
+			".cshrc",
+			".tcshrc",
+		}
+		for i, s := range rawPaths {
+			rawPaths[i] = path.Join(homeDir, s)
+		}
+		rawPaths = append(rawPaths, path.Join(homeDir, ".config", "fish", "config.fish"))
+		return removePathManagement(rawPaths)
+	})
+	return runner.Err()
 }
 
 func deleteLimaVM() error {
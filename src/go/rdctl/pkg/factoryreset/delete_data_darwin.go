@@ -6,12 +6,15 @@ import (
 
 	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/autostart"
 	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/steps"
 	"github.com/sirupsen/logrus"
 )
 
-func DeleteData(paths paths.Paths, removeKubernetesCache bool) error {
-	if err := autostart.EnsureAutostart(false); err != nil {
-		logrus.Errorf("Failed to remove autostart configuration: %s", err)
+func deleteData(paths paths.Paths, scope Scope, runner *steps.Runner) error {
+	if !scope.KeepSettings {
+		runner.Run("remove the autostart configuration", func() error {
+			return autostart.EnsureAutostart(false)
+		})
 	}
 
 	pathList := []string{
@@ -20,8 +23,6 @@ func DeleteData(paths paths.Paths, removeKubernetesCache bool) error {
 		paths.Logs,
 		paths.ExtensionRoot,
 	}
-	appHomeDirs := addAppHomeWithoutSnapshots(paths.AppHome)
-	pathList = append(pathList, appHomeDirs...)
 
 	// Get path that electron-updater stores cache data in. Technically this
 	// is the wrong directory to use for cache data, but it is set by electron-updater.
@@ -33,10 +34,10 @@ func DeleteData(paths paths.Paths, removeKubernetesCache bool) error {
 		pathList = append(pathList, filepath.Join(configDir, "Caches", "rancher-desktop-updater"))
 	}
 
-	if removeKubernetesCache {
+	if scope.RemoveKubernetesCache {
 		pathList = append(pathList, paths.Cache)
 	} else {
 		pathList = append(pathList, filepath.Join(paths.Cache, "updater-longhorn.json"))
 	}
-	return deleteUnixLikeData(paths, pathList)
+	return deleteUnixLikeData(paths, pathList, scope, runner)
 }
@@ -6,12 +6,15 @@ import (
 
 	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/autostart"
 	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/steps"
 	"github.com/sirupsen/logrus"
 )
 
-func DeleteData(paths paths.Paths, removeKubernetesCache bool) error {
-	if err := autostart.EnsureAutostart(false); err != nil {
-		logrus.Errorf("Failed to remove autostart configuration: %s", err)
+func deleteData(paths paths.Paths, scope Scope, runner *steps.Runner) error {
+	if !scope.KeepSettings {
+		runner.Run("remove the autostart configuration", func() error {
+			return autostart.EnsureAutostart(false)
+		})
 	}
 
 	homeDir, err := os.UserHomeDir()
@@ -35,12 +38,10 @@ func DeleteData(paths paths.Paths, removeKubernetesCache bool) error {
 		pathList = append(pathList, filepath.Join(configPath, "Rancher Desktop"))
 	}
 
-	if removeKubernetesCache {
+	if scope.RemoveKubernetesCache {
 		pathList = append(pathList, paths.Cache)
 	} else {
 		pathList = append(pathList, filepath.Join(paths.Cache, "updater-longhorn.json"))
 	}
-	appHomeDirs := addAppHomeWithoutSnapshots(paths.AppHome)
-	pathList = append(pathList, appHomeDirs...)
-	return deleteUnixLikeData(paths, pathList)
+	return deleteUnixLikeData(paths, pathList, scope, runner)
 }
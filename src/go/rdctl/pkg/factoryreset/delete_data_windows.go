@@ -1,27 +1,37 @@
 package factoryreset
 
 import (
+	"path/filepath"
+
 	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/autostart"
 	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/steps"
 	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/wsl"
 	"github.com/sirupsen/logrus"
 )
 
-func DeleteData(paths paths.Paths, removeKubernetesCache bool) error {
-	if err := autostart.EnsureAutostart(false); err != nil {
-		logrus.Errorf("Failed to remove autostart configuration: %s", err)
-	}
-	w := wsl.WSLImpl{}
-	if err := w.UnregisterDistros(); err != nil {
-		logrus.Errorf("could not unregister WSL: %s", err)
-		return err
-	}
-	if err := deleteWindowsData(!removeKubernetesCache, "rancher-desktop"); err != nil {
-		logrus.Errorf("could not delete data: %s", err)
-		return err
+func deleteData(paths paths.Paths, scope Scope, runner *steps.Runner) error {
+	if !scope.KeepSettings {
+		runner.Run("remove the autostart configuration", func() error {
+			return autostart.EnsureAutostart(false)
+		})
 	}
-	if err := clearDockerContext(); err != nil {
-		logrus.Errorf("could not clear docker context: %s", err)
+	// The data of distributions still registered must not be deleted.
+	runner.RunRequired("unregister the WSL distributions", func() error {
+		w := wsl.WSLImpl{}
+		if scope.KeepVM() {
+			// The data distribution holds the images and Kubernetes data.
+			return w.UnregisterDistrosExcept(wsl.DataDistroName)
+		}
+		return w.UnregisterDistros()
+	})
+	keep := scope.keptPaths([]string{paths.WslDistroData},
+		[]string{filepath.Join(paths.Config, "settings.json"), filepath.Join(paths.Config, "provisioning")})
+	runner.Run("delete application data", func() error {
+		return deleteWindowsData(!scope.RemoveKubernetesCache, keep, "rancher-desktop")
+	})
+	runner.Run("clear the docker context", clearDockerContext)
+	if err := runner.Err(); err != nil {
 		return err
 	}
 	logrus.Infoln("successfully cleared data.")
@@ -28,7 +28,7 @@ func KillRancherDesktop() error {
 	return fmt.Errorf("internal error: KillRancherDesktop shouldn't be called")
 }
 
-func deleteWindowsData(_ bool, _ string) error {
+func deleteWindowsData(_ bool, _ []string, _ string) error {
 	return fmt.Errorf("internal error: deleteWindowsData shouldn't be called")
 }
 
@@ -24,8 +24,8 @@ import (
 	"io"
 	"os"
 	"os/exec"
-	"path"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 	"unsafe"
@@ -187,8 +187,8 @@ func KillRancherDesktop() error {
 	return nil
 }
 
-func deleteWindowsData(keepSystemImages bool, appName string) error {
-	dirs, err := getDirectoriesToDelete(keepSystemImages, appName)
+func deleteWindowsData(keepSystemImages bool, keep []string, appName string) error {
+	dirs, err := getDirectoriesToDelete(keepSystemImages, keep, appName)
 	if err != nil {
 		return err
 	}
@@ -201,7 +201,9 @@ func deleteWindowsData(keepSystemImages bool, appName string) error {
 	return nil
 }
 
-func getDirectoriesToDelete(keepSystemImages bool, appName string) ([]string, error) {
+// getDirectoriesToDelete returns the directories, and files, to delete; the
+// paths in keep must be entries of %LOCALAPPDATA%\rancher-desktop.
+func getDirectoriesToDelete(keepSystemImages bool, keep []string, appName string) ([]string, error) {
 	// Ordered from least important to most, so that if delete fails we
 	// still keep some useful data.
 	localAppData, err := directories.GetLocalAppDataDirectory()
@@ -251,6 +253,8 @@ func getDirectoriesToDelete(keepSystemImages bool, appName string) ([]string, er
 				}
 			}
 			deleteLocalRDAppData = false
+		} else if slices.Contains(keep, filepath.Join(localRDAppData, fileName)) {
+			deleteLocalRDAppData = false
 		} else {
 			dirs = append(dirs, filepath.Join(localRDAppData, fileName))
 		}
@@ -260,9 +264,9 @@ func getDirectoriesToDelete(keepSystemImages bool, appName string) ([]string, er
 	}
 	roamingAppData, err := directories.GetRoamingAppDataDirectory()
 	if err == nil {
-		dirs = append(dirs, path.Join(roamingAppData, appName))
+		dirs = append(dirs, filepath.Join(roamingAppData, appName))
 		// Electron stores some files in AppData\Roaming\Rancher Desktop
-		dirs = append(dirs, path.Join(roamingAppData, "Rancher Desktop"))
+		dirs = append(dirs, filepath.Join(roamingAppData, "Rancher Desktop"))
 	} else {
 		logrus.Errorf("Could not get AppData (roaming) folder: %s\n", err)
 	}
@@ -0,0 +1,134 @@
+package factoryreset
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/vm"
+	"github.com/sirupsen/logrus"
+)
+
+// removeImagesScript deletes the images and containers of both container
+// engines from the disk of the VM.
+const removeImagesScript = `
+set -o errexit
+for service in k3s docker buildkitd containerd; do
+  rc-service --ifexists --ifstarted "$service" stop
+done
+rm -rf /var/lib/rancher/k3s/agent/containerd /var/lib/docker /var/lib/nerdctl /var/lib/buildkit
+`
+
+// removeKubernetesDataScript deletes the datastore, volumes, and other state
+// of Kubernetes from the disk of the VM, keeping the images of containerd,
+// which are stored among them.
+const removeKubernetesDataScript = `
+set -o errexit
+rc-service --ifexists --ifstarted k3s stop
+mkdir -p /var/lib/rancher/k3s/agent
+find /var/lib/rancher/k3s -mindepth 1 -maxdepth 1 ! -name agent -exec rm -rf {} +
+find /var/lib/rancher/k3s/agent -mindepth 1 -maxdepth 1 ! -name containerd -exec rm -rf {} +
+rm -rf /var/lib/kubelet
+`
+
+// Scope selects the parts of the installation a factory reset deletes; the
+// zero value deletes everything but the cached Kubernetes images.
+type Scope struct {
+	// RemoveKubernetesCache also deletes the cached Kubernetes images.
+	RemoveKubernetesCache bool
+	// KeepImages keeps the images of the container engine, and
+	// KeepKubernetesData the datastore and volumes of Kubernetes. Both are
+	// stored on the disk of the VM, which is kept with either of them; the
+	// data that isn't kept is deleted from it while the VM is running.
+	KeepImages         bool
+	KeepKubernetesData bool
+	// KeepSettings keeps the settings, and what they change on the host:
+	// the autostart configuration and the PATH in the shell profiles.
+	KeepSettings bool
+	// OnlyCache deletes the cache, and nothing else.
+	OnlyCache bool
+}
+
+// Validate checks that the options of the scope don't contradict each other.
+func (s Scope) Validate() error {
+	if s.OnlyCache && (s.KeepImages || s.KeepKubernetesData || s.KeepSettings) {
+		return errors.New("--only-cache keeps everything but the cache, and can't be combined with the --keep flags")
+	}
+	return nil
+}
+
+// KeepVM returns whether the disk of the VM is kept.
+func (s Scope) KeepVM() bool {
+	return s.OnlyCache || s.KeepImages || s.KeepKubernetesData
+}
+
+// vmScript returns the script deleting the data the scope doesn't keep from
+// the disk of the VM, if it is kept; it is empty if nothing is deleted.
+func (s Scope) vmScript() string {
+	switch {
+	case s.OnlyCache || s.KeepImages == s.KeepKubernetesData:
+		return ""
+	case s.KeepImages:
+		return removeKubernetesDataScript
+	default:
+		return removeImagesScript
+	}
+}
+
+// RemoveVMData deletes the data the scope doesn't keep from the disk of the
+// VM, which must be running; it does nothing unless the scope keeps only
+// some of the data of the VM.
+func RemoveVMData(runner vm.Runner, scope Scope) error {
+	script := scope.vmScript()
+	if script == "" {
+		return nil
+	}
+	if _, err := runner.RootOutput("sh", "-c", script); err != nil {
+		return fmt.Errorf("failed to delete data from the VM, which must be running to keep only some of its data: %w", err)
+	}
+	return nil
+}
+
+// keptPaths returns the paths the scope keeps among those of the installation.
+func (s Scope) keptPaths(vmPaths, settingsPaths []string) []string {
+	var paths []string
+	if s.KeepVM() {
+		paths = append(paths, vmPaths...)
+	}
+	if s.KeepSettings {
+		paths = append(paths, settingsPaths...)
+	}
+	return slices.DeleteFunc(paths, func(path string) bool { return path == "" })
+}
+
+// appHomeEntries returns the paths to delete for the application home: the
+// directory itself, or its entries when some of them are kept, which are
+// snapshots, if there are any, and the paths in keep.
+func appHomeEntries(appHome string, keep []string) []string {
+	haveSnapshots := false
+	if snapshots, err := os.ReadDir(filepath.Join(appHome, "snapshots")); err == nil {
+		haveSnapshots = len(snapshots) > 0
+	}
+	keepEntries := slices.ContainsFunc(keep, func(path string) bool {
+		return filepath.Dir(path) == appHome
+	})
+	if !haveSnapshots && !keepEntries {
+		return []string{appHome}
+	}
+	appHomeMembers, err := os.ReadDir(appHome)
+	if err != nil {
+		logrus.Errorf("failed to read contents of dir %s: %s", appHome, err)
+		return []string{appHome}
+	}
+	pathList := make([]string, 0, len(appHomeMembers))
+	for _, entry := range appHomeMembers {
+		entryPath := filepath.Join(appHome, entry.Name())
+		if (entry.Name() == "snapshots" && haveSnapshots) || slices.Contains(keep, entryPath) {
+			continue
+		}
+		pathList = append(pathList, entryPath)
+	}
+	return pathList
+}
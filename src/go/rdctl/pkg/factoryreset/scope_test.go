@@ -0,0 +1,93 @@
+package factoryreset
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeVM struct {
+	commands [][]string
+	err      error
+}
+
+func (v *fakeVM) RootOutput(args ...string) ([]byte, error) {
+	v.commands = append(v.commands, args)
+	return nil, v.err
+}
+
+func (v *fakeVM) RootStream(stdin io.Reader, stdout io.Writer, args ...string) error {
+	return errors.New("not implemented")
+}
+
+func TestScopeValidate(t *testing.T) {
+	assert.NoError(t, Scope{}.Validate())
+	assert.NoError(t, Scope{KeepImages: true, KeepKubernetesData: true, KeepSettings: true}.Validate())
+	assert.NoError(t, Scope{OnlyCache: true, RemoveKubernetesCache: true}.Validate())
+	assert.ErrorContains(t, Scope{OnlyCache: true, KeepSettings: true}.Validate(), "can't be combined")
+}
+
+func TestRemoveVMData(t *testing.T) {
+	for _, testCase := range []struct {
+		scope  Scope
+		script string
+	}{
+		{scope: Scope{}},
+		{scope: Scope{KeepImages: true, KeepKubernetesData: true}},
+		{scope: Scope{KeepImages: true}, script: removeKubernetesDataScript},
+		{scope: Scope{KeepKubernetesData: true}, script: removeImagesScript},
+	} {
+		v := &fakeVM{}
+		require.NoError(t, RemoveVMData(v, testCase.scope))
+		if testCase.script == "" {
+			assert.Empty(t, v.commands, testCase.scope)
+		} else {
+			assert.Equal(t, [][]string{{"sh", "-c", testCase.script}}, v.commands, testCase.scope)
+		}
+	}
+
+	v := &fakeVM{err: errors.New("instance is not running")}
+	err := RemoveVMData(v, Scope{KeepImages: true})
+	assert.ErrorContains(t, err, "must be running to keep only some of its data: instance is not running")
+}
+
+func TestKeptPaths(t *testing.T) {
+	vmPaths, settingsPaths := []string{"/lima", ""}, []string{"/config"}
+	assert.Empty(t, Scope{}.keptPaths(vmPaths, settingsPaths))
+	assert.Equal(t, []string{"/lima"}, Scope{KeepKubernetesData: true}.keptPaths(vmPaths, settingsPaths))
+	assert.Equal(t, []string{"/lima", "/config"}, Scope{KeepImages: true, KeepSettings: true}.keptPaths(vmPaths, settingsPaths))
+}
+
+func TestAppHomeEntries(t *testing.T) {
+	appHome := t.TempDir()
+	for _, dir := range []string{"lima", "snapshots", "credential-server"} {
+		require.NoError(t, os.Mkdir(filepath.Join(appHome, dir), 0o755))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(appHome, "rd-engine.json"), nil, 0o644))
+	// Empty snapshot directories are deleted with the rest.
+	assert.Equal(t, []string{appHome}, appHomeEntries(appHome, nil))
+	assert.Equal(t, []string{appHome}, appHomeEntries(appHome, []string{"/elsewhere/config"}))
+
+	lima := filepath.Join(appHome, "lima")
+	assert.ElementsMatch(t, []string{
+		filepath.Join(appHome, "credential-server"),
+		filepath.Join(appHome, "rd-engine.json"),
+		filepath.Join(appHome, "snapshots"),
+	}, appHomeEntries(appHome, []string{lima}))
+
+	require.NoError(t, os.Mkdir(filepath.Join(appHome, "snapshots", "abc"), 0o755))
+	assert.ElementsMatch(t, []string{
+		filepath.Join(appHome, "credential-server"),
+		filepath.Join(appHome, "lima"),
+		filepath.Join(appHome, "rd-engine.json"),
+	}, appHomeEntries(appHome, nil))
+	assert.ElementsMatch(t, []string{
+		filepath.Join(appHome, "credential-server"),
+		filepath.Join(appHome, "rd-engine.json"),
+	}, appHomeEntries(appHome, []string{lima}))
+}
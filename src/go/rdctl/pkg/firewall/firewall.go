@@ -0,0 +1,43 @@
+// Package firewall lists the Windows Defender Firewall rules that the
+// privileged service creates for ports containers publish on all interfaces.
+package firewall
+
+import (
+	"bufio"
+	"strings"
+)
+
+// RulePrefix is the prefix of the names of the rules owned by Rancher Desktop;
+// it must match the one of the privileged service.
+const RulePrefix = "Rancher Desktop port "
+
+// Rule is a firewall rule allowing inbound connections to a published port.
+type Rule struct {
+	Name     string `json:"name"`
+	Port     string `json:"port"`
+	Protocol string `json:"protocol"`
+}
+
+// parseRules extracts the rules owned by Rancher Desktop from the output of
+// `netsh advfirewall firewall show rule`. Only the values are matched, as the
+// labels are localized.
+func parseRules(output string) []Rule {
+	var rules []Rule
+	seen := map[string]bool{}
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		_, value, found := strings.Cut(scanner.Text(), ":")
+		if !found {
+			continue
+		}
+		name := strings.TrimSpace(value)
+		portProto, ok := strings.CutPrefix(name, RulePrefix)
+		if !ok || seen[name] {
+			continue
+		}
+		seen[name] = true
+		port, proto, _ := strings.Cut(portProto, "/")
+		rules = append(rules, Rule{Name: name, Port: port, Protocol: proto})
+	}
+	return rules
+}
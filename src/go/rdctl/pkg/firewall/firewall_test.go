@@ -0,0 +1,42 @@
+package firewall
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRules(t *testing.T) {
+	output := `
+Rule Name:                            Rancher Desktop port 8080/tcp
+----------------------------------------------------------------------
+Enabled:                              Yes
+Direction:                            In
+Profiles:                             Domain,Private,Public
+Grouping:
+LocalIP:                              Any
+RemoteIP:                             Any
+Protocol:                             TCP
+LocalPort:                            8080
+RemotePort:                           Any
+Edge traversal:                       No
+Action:                               Allow
+
+Rule Name:                            Core Networking - Teredo (UDP-In)
+----------------------------------------------------------------------
+Enabled:                              Yes
+
+Rule Name:                            Rancher Desktop port 53/udp
+----------------------------------------------------------------------
+Enabled:                              Yes
+
+Rule Name:                            Rancher Desktop port 8080/tcp
+----------------------------------------------------------------------
+Enabled:                              Yes
+Ok.
+`
+	assert.Equal(t, []Rule{
+		{Name: "Rancher Desktop port 8080/tcp", Port: "8080", Protocol: "tcp"},
+		{Name: "Rancher Desktop port 53/udp", Port: "53", Protocol: "udp"},
+	}, parseRules(output))
+}
@@ -0,0 +1,11 @@
+//go:build unix
+
+package firewall
+
+import "errors"
+
+// List returns the firewall rules owned by Rancher Desktop; they only exist
+// on Windows.
+func List() ([]Rule, error) {
+	return nil, errors.New("firewall rules are only managed on Windows")
+}
@@ -0,0 +1,15 @@
+package firewall
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// List returns the firewall rules owned by Rancher Desktop.
+func List() ([]Rule, error) {
+	output, err := exec.Command("netsh", "advfirewall", "firewall", "show", "rule", "name=all", "dir=in").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list firewall rules: %w", err)
+	}
+	return parseRules(string(output)), nil
+}
@@ -0,0 +1,101 @@
+// Package guestcmd builds the commands run in the VM. WSL runs the arguments
+// of a command as they are, but limactl joins them with spaces into a line
+// for the shell in the VM, which splits arguments containing spaces and
+// expands special characters; the arguments must be quoted for it.
+package guestcmd
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// safe matches the arguments that don't need quoting for a POSIX shell.
+var safe = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// envName matches the names of environment variables.
+var envName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// chdirScript changes to the directory "$1" and runs the rest of the
+// arguments, so that the directory is never parsed by the shell.
+const chdirScript = `cd "$1" && shift && exec "$@"`
+
+// userScript runs the rest of the arguments as they are, for su, which only
+// takes a command line.
+const userScript = `exec "$@"`
+
+// ttyScript runs the command line "$1" in a pseudo-terminal, and exits with its
+// status, which not every version of script passes on.
+const ttyScript = `status=$(mktemp) || exit
+script -q -c "$1; echo \$? > $status" /dev/null
+code=$(cat "$status")
+rm -f "$status"
+exit "${code:-1}"`
+
+// Quote returns the argument quoted for a POSIX shell.
+func Quote(arg string) string {
+	if safe.MatchString(arg) {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// Join returns the command line running the arguments in a POSIX shell.
+func Join(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = Quote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// Command is a command to run in the VM.
+type Command struct {
+	// Args are the command and its arguments.
+	Args []string
+	// Env holds the variables set for the command, as "NAME=value".
+	Env []string
+	// Dir is the directory the command runs in, if not empty.
+	Dir string
+	// User runs the command as this user, if not empty, with su; the command
+	// must then be started as root, or Sudo set.
+	User string
+	// Sudo runs su with sudo, for the default users that aren't root.
+	Sudo bool
+	// TTY runs the command in a pseudo-terminal, even if it isn't started in
+	// one.
+	TTY bool
+}
+
+// Argv returns the arguments running the command with its environment,
+// directory, user and terminal; they are passed as arguments to env, sh and
+// su, rather than parsed by a shell, so they can hold any character.
+func (c Command) Argv() ([]string, error) {
+	if len(c.Args) == 0 {
+		return nil, errors.New("no command given")
+	}
+	args := c.Args
+	if len(c.Env) > 0 {
+		for _, variable := range c.Env {
+			name, _, ok := strings.Cut(variable, "=")
+			if !ok || !envName.MatchString(name) {
+				return nil, fmt.Errorf("invalid environment variable %q", variable)
+			}
+		}
+		args = append(append([]string{"env"}, c.Env...), args...)
+	}
+	if c.Dir != "" {
+		args = append([]string{"sh", "-c", chdirScript, "sh", c.Dir}, args...)
+	}
+	if c.User != "" {
+		args = append([]string{"su", "-s", "/bin/sh", c.User, "-c", userScript, "sh"}, args...)
+		if c.Sudo {
+			args = append([]string{"sudo"}, args...)
+		}
+	}
+	if c.TTY {
+		args = []string{"sh", "-c", ttyScript, "sh", Join(args)}
+	}
+	return args, nil
+}
@@ -0,0 +1,104 @@
+package guestcmd
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuote(t *testing.T) {
+	for arg, expected := range map[string]string{
+		"ls":                   "ls",
+		"/tmp/project-1.2":     "/tmp/project-1.2",
+		"":                     "''",
+		"My Project":           "'My Project'",
+		"it's":                 `'it'\''s'`,
+		"$HOME; rm -rf / `id`": "'$HOME; rm -rf / `id`'",
+		"*.txt":                "'*.txt'",
+	} {
+		assert.Equal(t, expected, Quote(arg), "quoting %q", arg)
+	}
+}
+
+func TestJoin(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no POSIX shell")
+	}
+	args := []string{"printf", "[%s]", "My Project", "it's", "$HOME", "a\nb", "", "\"quoted\""}
+	output, err := exec.Command("sh", "-c", Join(args)).Output()
+	require.NoError(t, err)
+	assert.Equal(t, "[My Project][it's][$HOME][a\nb][][\"quoted\"]", string(output))
+}
+
+func TestCommandArgv(t *testing.T) {
+	t.Run("arguments only", func(t *testing.T) {
+		args, err := Command{Args: []string{"ls", "-l"}}.Argv()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"ls", "-l"}, args)
+	})
+	t.Run("environment and directory", func(t *testing.T) {
+		args, err := Command{
+			Args: []string{"make", "build"},
+			Env:  []string{"GOOS=linux", "NAME=My Project"},
+			Dir:  "/src/My Project",
+		}.Argv()
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			"sh", "-c", chdirScript, "sh", "/src/My Project",
+			"env", "GOOS=linux", "NAME=My Project", "make", "build",
+		}, args)
+	})
+	t.Run("invalid environment", func(t *testing.T) {
+		for _, variable := range []string{"NAME", "=value", "A B=c", "1A=b"} {
+			_, err := Command{Args: []string{"true"}, Env: []string{variable}}.Argv()
+			assert.ErrorContains(t, err, "invalid environment variable", variable)
+		}
+	})
+	t.Run("user", func(t *testing.T) {
+		args, err := Command{Args: []string{"id", "-u"}, Dir: "/tmp", User: "root"}.Argv()
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			"su", "-s", "/bin/sh", "root", "-c", userScript, "sh",
+			"sh", "-c", chdirScript, "sh", "/tmp", "id", "-u",
+		}, args)
+	})
+	t.Run("terminal", func(t *testing.T) {
+		if _, err := exec.LookPath("script"); err != nil || runtime.GOOS != "linux" {
+			t.Skip("no script(1) to allocate a terminal with")
+		}
+		args, err := Command{Args: []string{"sh", "-c", "test -t 0 && test -t 1 && exit 3"}, TTY: true}.Argv()
+		require.NoError(t, err)
+		cmd := exec.Command("sh", "-c", Join(args))
+		cmd.Stdin = strings.NewReader("")
+		err = cmd.Run()
+		var exitErr *exec.ExitError
+		require.ErrorAs(t, err, &exitErr)
+		assert.Equal(t, 3, exitErr.ExitCode())
+	})
+	t.Run("no command", func(t *testing.T) {
+		_, err := Command{Dir: "/tmp"}.Argv()
+		assert.Error(t, err)
+	})
+	t.Run("runs in a shell", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("no POSIX shell")
+		}
+		dir := filepath.Join(t.TempDir(), "My 'Project'")
+		require.NoError(t, os.Mkdir(dir, 0o755))
+		args, err := Command{
+			Args: []string{"sh", "-c", `printf '%s %s' "$(basename "$PWD")" "$VALUE"`},
+			Env:  []string{"VALUE=a 'b' $c"},
+			Dir:  dir,
+		}.Argv()
+		require.NoError(t, err)
+		output, err := exec.Command("sh", "-c", Join(args)).Output()
+		require.NoError(t, err)
+		assert.Equal(t, "My 'Project' a 'b' $c", string(output))
+	})
+}
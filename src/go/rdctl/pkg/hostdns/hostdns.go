@@ -0,0 +1,191 @@
+// Package hostdns reads the DNS configuration of a Linux host from
+// systemd-resolved or NetworkManager, over D-Bus, so that the VM can use the
+// same servers and domains. On hosts running systemd-resolved, /etc/resolv.conf
+// only points to its local stub resolver, which the VM can't reach, and lacks
+// the domains routed to the servers of specific links, such as VPNs.
+package hostdns
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+)
+
+const (
+	SourceResolved       = "systemd-resolved"
+	SourceNetworkManager = "NetworkManager"
+)
+
+// Domain is a DNS domain of the host.
+type Domain struct {
+	Name string `json:"name"`
+	// RouteOnly domains only select the servers for the names in them; they
+	// aren't search domains.
+	RouteOnly bool `json:"routeOnly"`
+	// Servers are the servers for names in the domain; if empty, the default
+	// servers are used.
+	Servers []string `json:"servers,omitempty"`
+}
+
+// Config is the DNS configuration of the host.
+type Config struct {
+	// Source is SourceResolved, SourceNetworkManager, or the path of the
+	// resolv.conf file the configuration was read from.
+	Source  string   `json:"source"`
+	Servers []string `json:"servers"`
+	Domains []Domain `json:"domains"`
+}
+
+// SearchDomains returns the names of the domains that aren't route-only.
+func (c Config) SearchDomains() []string {
+	result := []string{}
+	for _, domain := range c.Domains {
+		if !domain.RouteOnly {
+			result = append(result, domain.Name)
+		}
+	}
+	return result
+}
+
+// busctlProperty is a property, as printed by `busctl --json=short`.
+type busctlProperty struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// parseResolved parses the DNS (a(iiay)) and Domains (a(isb)) properties of
+// the systemd-resolved manager. Each entry holds the index of its link, or 0
+// for the global configuration.
+func parseResolved(dnsProperty, domainsProperty []byte) (Config, error) {
+	var dns, domains busctlProperty
+	if err := json.Unmarshal(dnsProperty, &dns); err != nil {
+		return Config{}, fmt.Errorf("failed to parse DNS servers of systemd-resolved: %w", err)
+	}
+	if err := json.Unmarshal(domainsProperty, &domains); err != nil {
+		return Config{}, fmt.Errorf("failed to parse domains of systemd-resolved: %w", err)
+	}
+	var servers [][3]any
+	if err := json.Unmarshal(dns.Data, &servers); err != nil {
+		return Config{}, fmt.Errorf("unexpected DNS servers of systemd-resolved: %w", err)
+	}
+	config := Config{Source: SourceResolved, Servers: []string{}, Domains: []Domain{}}
+	linkServers := map[int][]string{}
+	for _, server := range servers {
+		link, _ := server[0].(float64)
+		address, err := parseAddress(server[2])
+		if err != nil {
+			return Config{}, err
+		}
+		linkServers[int(link)] = append(linkServers[int(link)], address)
+		config.Servers = appendUnique(config.Servers, address)
+	}
+	var entries [][3]any
+	if err := json.Unmarshal(domains.Data, &entries); err != nil {
+		return Config{}, fmt.Errorf("unexpected domains of systemd-resolved: %w", err)
+	}
+	for _, entry := range entries {
+		link, _ := entry[0].(float64)
+		name, _ := entry[1].(string)
+		routeOnly, _ := entry[2].(bool)
+		domain := Domain{Name: name, RouteOnly: routeOnly}
+		if link != 0 {
+			domain.Servers = linkServers[int(link)]
+		}
+		config.Domains = append(config.Domains, domain)
+	}
+	return config, nil
+}
+
+// parseAddress parses an address given as an array of bytes.
+func parseAddress(value any) (string, error) {
+	bytes, ok := value.([]any)
+	if !ok || (len(bytes) != net.IPv4len && len(bytes) != net.IPv6len) {
+		return "", fmt.Errorf("invalid DNS server address %v", value)
+	}
+	ip := make(net.IP, len(bytes))
+	for i, b := range bytes {
+		n, ok := b.(float64)
+		if !ok {
+			return "", fmt.Errorf("invalid DNS server address %v", value)
+		}
+		ip[i] = byte(n)
+	}
+	return ip.String(), nil
+}
+
+// parseNetworkManager parses the Configuration (aa{sv}) property of the
+// NetworkManager DNS manager, which lists the servers and domains of each
+// connection; domains starting with "~" are route-only.
+func parseNetworkManager(property []byte) (Config, error) {
+	var configuration busctlProperty
+	if err := json.Unmarshal(property, &configuration); err != nil {
+		return Config{}, fmt.Errorf("failed to parse the DNS configuration of NetworkManager: %w", err)
+	}
+	var connections []struct {
+		Nameservers struct {
+			Data []string `json:"data"`
+		} `json:"nameservers"`
+		Domains struct {
+			Data []string `json:"data"`
+		} `json:"domains"`
+	}
+	if err := json.Unmarshal(configuration.Data, &connections); err != nil {
+		return Config{}, fmt.Errorf("unexpected DNS configuration of NetworkManager: %w", err)
+	}
+	config := Config{Source: SourceNetworkManager, Servers: []string{}, Domains: []Domain{}}
+	for _, connection := range connections {
+		for _, server := range connection.Nameservers.Data {
+			config.Servers = appendUnique(config.Servers, server)
+		}
+		for _, name := range connection.Domains.Data {
+			domain := Domain{Name: strings.TrimPrefix(name, "~"), RouteOnly: strings.HasPrefix(name, "~")}
+			if domain.Name == "" || domain.Name == "." {
+				// "~." only makes the connection the default route.
+				continue
+			}
+			if len(connection.Nameservers.Data) > 0 {
+				domain.Servers = connection.Nameservers.Data
+			}
+			config.Domains = append(config.Domains, domain)
+		}
+	}
+	return config, nil
+}
+
+// parseResolvConf parses a resolv.conf file, leaving out the servers on
+// loopback addresses, such as the stub resolver of systemd-resolved.
+func parseResolvConf(path string, contents []byte) Config {
+	config := Config{Source: path, Servers: []string{}, Domains: []Domain{}}
+	scanner := bufio.NewScanner(bytes.NewReader(contents))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") || strings.HasPrefix(fields[0], ";") {
+			continue
+		}
+		switch fields[0] {
+		case "nameserver":
+			if ip := net.ParseIP(fields[1]); ip != nil && !ip.IsLoopback() {
+				config.Servers = appendUnique(config.Servers, ip.String())
+			}
+		case "search", "domain":
+			// The last search or domain line wins.
+			config.Domains = []Domain{}
+			for _, name := range fields[1:] {
+				config.Domains = append(config.Domains, Domain{Name: name})
+			}
+		}
+	}
+	return config
+}
+
+func appendUnique(values []string, value string) []string {
+	for _, existing := range values {
+		if existing == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
@@ -0,0 +1,81 @@
+package hostdns
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// resolvConfPaths are read when neither systemd-resolved nor NetworkManager
+// is running; the first one lists the servers systemd-resolved uses, rather
+// than its stub resolver.
+var resolvConfPaths = []string{"/run/systemd/resolve/resolv.conf", "/etc/resolv.conf"}
+
+// busctl runs busctl, which prints D-Bus properties as JSON.
+var busctl = func(args ...string) ([]byte, error) {
+	output, err := exec.Command("busctl", append([]string{"--system", "--json=short"}, args...)...).Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return nil, fmt.Errorf("busctl %v: %w: %s", args, err, exitErr.Stderr)
+	}
+	return output, err
+}
+
+// Detect returns the DNS configuration of the host, from systemd-resolved,
+// NetworkManager, or resolv.conf, in that order.
+func Detect() (Config, error) {
+	var errs []error
+	if config, err := detectResolved(); err == nil {
+		return config, nil
+	} else {
+		errs = append(errs, err)
+	}
+	if config, err := detectNetworkManager(); err == nil {
+		return config, nil
+	} else {
+		errs = append(errs, err)
+	}
+	for _, path := range resolvConfPaths {
+		contents, err := os.ReadFile(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if config := parseResolvConf(path, contents); len(config.Servers) > 0 {
+			return config, nil
+		}
+		errs = append(errs, fmt.Errorf("no DNS servers in %s", path))
+	}
+	return Config{}, fmt.Errorf("failed to detect the DNS configuration: %w", errors.Join(errs...))
+}
+
+func detectResolved() (Config, error) {
+	const service, object, iface = "org.freedesktop.resolve1", "/org/freedesktop/resolve1", "org.freedesktop.resolve1.Manager"
+	dns, err := busctl("get-property", service, object, iface, "DNS")
+	if err != nil {
+		return Config{}, err
+	}
+	domains, err := busctl("get-property", service, object, iface, "Domains")
+	if err != nil {
+		return Config{}, err
+	}
+	config, err := parseResolved(dns, domains)
+	if err == nil && len(config.Servers) == 0 {
+		return Config{}, errors.New("systemd-resolved has no DNS servers")
+	}
+	return config, err
+}
+
+func detectNetworkManager() (Config, error) {
+	property, err := busctl("get-property", "org.freedesktop.NetworkManager", "/org/freedesktop/NetworkManager/DnsManager",
+		"org.freedesktop.NetworkManager.DnsManager", "Configuration")
+	if err != nil {
+		return Config{}, err
+	}
+	config, err := parseNetworkManager(property)
+	if err == nil && len(config.Servers) == 0 {
+		return Config{}, errors.New("NetworkManager has no DNS servers")
+	}
+	return config, err
+}
@@ -0,0 +1,52 @@
+package hostdns
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetect(t *testing.T) {
+	originalBusctl, originalPaths := busctl, resolvConfPaths
+	t.Cleanup(func() {
+		busctl, resolvConfPaths = originalBusctl, originalPaths
+	})
+	stub := filepath.Join(t.TempDir(), "resolv.conf")
+	require.NoError(t, os.WriteFile(stub, []byte("nameserver 127.0.0.53\n"), 0o644))
+	resolvConf := filepath.Join(t.TempDir(), "resolv.conf")
+	require.NoError(t, os.WriteFile(resolvConf, []byte("nameserver 192.168.1.1\nsearch home.lan\n"), 0o644))
+	resolvConfPaths = []string{filepath.Join(t.TempDir(), "missing"), stub, resolvConf}
+
+	t.Run("falls back to NetworkManager", func(t *testing.T) {
+		busctl = func(args ...string) ([]byte, error) {
+			if args[1] == "org.freedesktop.NetworkManager" {
+				return []byte(`{"type":"aa{sv}","data":[{"nameservers":{"type":"as","data":["192.168.1.1"]}}]}`), nil
+			}
+			return nil, errors.New("service not found")
+		}
+		config, err := Detect()
+		require.NoError(t, err)
+		assert.Equal(t, SourceNetworkManager, config.Source)
+	})
+	t.Run("falls back to resolv.conf without stub servers", func(t *testing.T) {
+		busctl = func(args ...string) ([]byte, error) {
+			return nil, errors.New("service not found")
+		}
+		config, err := Detect()
+		require.NoError(t, err)
+		assert.Equal(t, Config{
+			Source:  resolvConf,
+			Servers: []string{"192.168.1.1"},
+			Domains: []Domain{{Name: "home.lan"}},
+		}, config)
+	})
+	t.Run("fails without servers", func(t *testing.T) {
+		resolvConfPaths = []string{stub}
+		_, err := Detect()
+		assert.ErrorContains(t, err, "no DNS servers in "+stub)
+	})
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+
+package hostdns
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// Detect returns the DNS configuration of the host, which is only supported
+// on Linux.
+func Detect() (Config, error) {
+	return Config{}, fmt.Errorf("detecting the DNS configuration is not supported on %s", runtime.GOOS)
+}
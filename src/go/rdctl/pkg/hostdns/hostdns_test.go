@@ -0,0 +1,56 @@
+package hostdns
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseResolved(t *testing.T) {
+	dns := `{"type":"a(iiay)","data":[[0,2,[1,1,1,1]],[3,2,[10,0,0,1]],[3,10,[253,0,0,0,0,0,0,0,0,0,0,0,0,0,0,1]]]}`
+	domains := `{"type":"a(isb)","data":[[0,"example.com",false],[3,"corp.example",true],[3,"lab.example",false]]}`
+	config, err := parseResolved([]byte(dns), []byte(domains))
+	require.NoError(t, err)
+	assert.Equal(t, Config{
+		Source:  SourceResolved,
+		Servers: []string{"1.1.1.1", "10.0.0.1", "fd00::1"},
+		Domains: []Domain{
+			{Name: "example.com"},
+			{Name: "corp.example", RouteOnly: true, Servers: []string{"10.0.0.1", "fd00::1"}},
+			{Name: "lab.example", Servers: []string{"10.0.0.1", "fd00::1"}},
+		},
+	}, config)
+	assert.Equal(t, []string{"example.com", "lab.example"}, config.SearchDomains())
+
+	t.Run("invalid address", func(t *testing.T) {
+		_, err := parseResolved([]byte(`{"type":"a(iiay)","data":[[0,2,[1,1,1]]]}`), []byte(`{"type":"a(isb)","data":[]}`))
+		assert.ErrorContains(t, err, "invalid DNS server address")
+	})
+}
+
+func TestParseNetworkManager(t *testing.T) {
+	property := `{"type":"aa{sv}","data":[` +
+		`{"nameservers":{"type":"as","data":["192.168.1.1"]},"domains":{"type":"as","data":["home.lan"]},"interface":{"type":"s","data":"wlan0"}},` +
+		`{"nameservers":{"type":"as","data":["10.8.0.1","192.168.1.1"]},"domains":{"type":"as","data":["~corp.example","~."]},"interface":{"type":"s","data":"tun0"}}` +
+		`]}`
+	config, err := parseNetworkManager([]byte(property))
+	require.NoError(t, err)
+	assert.Equal(t, Config{
+		Source:  SourceNetworkManager,
+		Servers: []string{"192.168.1.1", "10.8.0.1"},
+		Domains: []Domain{
+			{Name: "home.lan", Servers: []string{"192.168.1.1"}},
+			{Name: "corp.example", RouteOnly: true, Servers: []string{"10.8.0.1", "192.168.1.1"}},
+		},
+	}, config)
+}
+
+func TestParseResolvConf(t *testing.T) {
+	contents := "# comment\nnameserver 127.0.0.53\nnameserver 192.168.1.1\nsearch old.example\nsearch a.example b.example\noptions edns0\n"
+	assert.Equal(t, Config{
+		Source:  "/etc/resolv.conf",
+		Servers: []string{"192.168.1.1"},
+		Domains: []Domain{{Name: "a.example"}, {Name: "b.example"}},
+	}, parseResolvConf("/etc/resolv.conf", []byte(contents)))
+}
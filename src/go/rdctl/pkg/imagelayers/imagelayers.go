@@ -0,0 +1,326 @@
+// Package imagelayers analyzes how the images of the local image store share
+// their layers, to explain where its disk space goes. The store keeps each
+// distinct layer once, so images built on the same base image share its
+// layers; images built on slightly different versions of a base, such as a
+// node:18 image pulled at different times, don't, and large build steps are
+// often worth slimming down.
+package imagelayers
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// CLI runs the docker or nerdctl command line tool with the arguments, and
+// returns its standard output; it exists so that callers can be tested
+// without a container engine.
+type CLI func(args ...string) ([]byte, error)
+
+// Image is an image of the local store.
+type Image struct {
+	ID string `json:"id"`
+	// Names are the tags of the image or, for images that are no longer
+	// tagged, the repositories they were pulled from.
+	Names []string `json:"names"`
+	// Size is the size of all the layers of the image, shared or not.
+	Size int64 `json:"size"`
+	// Layers are the digests of the contents of the layers, from the bottom.
+	Layers []string `json:"-"`
+	// Steps are the build steps of the image, from the last one.
+	Steps []Step `json:"-"`
+}
+
+// Name returns the first name of the image, or its ID for images without
+// names.
+func (i *Image) Name() string {
+	if len(i.Names) > 0 {
+		return i.Names[0]
+	}
+	return shortID(i.ID)
+}
+
+// Repository returns the repository of the image, e.g. "node" for "node:18",
+// or "" for images without names.
+func (i *Image) Repository() string {
+	if len(i.Names) == 0 {
+		return ""
+	}
+	name, _, _ := strings.Cut(i.Names[0], "@")
+	// The tag follows the last colon, unless it is the port of the registry.
+	if colon := strings.LastIndex(name, ":"); colon > strings.LastIndex(name, "/") {
+		name = name[:colon]
+	}
+	return name
+}
+
+// Step is a build step of an image.
+type Step struct {
+	CreatedBy string
+	// Size is the size of the layer the step added, if any.
+	Size int64
+}
+
+func shortID(id string) string {
+	id = strings.TrimPrefix(id, "sha256:")
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
+
+// inspectedImage is the part of the output of "image inspect" describing an
+// image, which nerdctl gives in the format of docker.
+type inspectedImage struct {
+	ID          string   `json:"Id"`
+	RepoTags    []string `json:"RepoTags"`
+	RepoDigests []string `json:"RepoDigests"`
+	Size        int64    `json:"Size"`
+	RootFS      struct {
+		Layers []string `json:"Layers"`
+	} `json:"RootFS"`
+}
+
+// historyStep is a line of the output of "history --format '{{json .}}'";
+// the size is in bytes with --human=false.
+type historyStep struct {
+	CreatedBy string `json:"CreatedBy"`
+	Size      string `json:"Size"`
+}
+
+// List returns the images of the store, with their layers and build steps.
+func List(cli CLI) ([]Image, error) {
+	output, err := cli("image", "ls", "--quiet", "--no-trunc")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the images: %w", err)
+	}
+	var ids []string
+	for _, id := range strings.Fields(string(output)) {
+		if !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return []Image{}, nil
+	}
+	output, err = cli(append([]string{"image", "inspect"}, ids...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect the images: %w", err)
+	}
+	var inspected []inspectedImage
+	if err := json.Unmarshal(output, &inspected); err != nil {
+		return nil, fmt.Errorf("failed to parse the images: %w", err)
+	}
+	images := make([]Image, 0, len(inspected))
+	for _, image := range inspected {
+		names := image.RepoTags
+		if len(names) == 0 {
+			names = image.RepoDigests
+		}
+		steps, err := history(cli, image.ID)
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, Image{
+			ID:     image.ID,
+			Names:  slices.DeleteFunc(slices.Clone(names), func(name string) bool { return strings.HasPrefix(name, "<none>") }),
+			Size:   image.Size,
+			Layers: image.RootFS.Layers,
+			Steps:  steps,
+		})
+	}
+	return images, nil
+}
+
+func history(cli CLI, id string) ([]Step, error) {
+	output, err := cli("history", "--no-trunc", "--human=false", "--format", "{{json .}}", id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the history of image %s: %w", shortID(id), err)
+	}
+	var steps []Step
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		if line == "" {
+			continue
+		}
+		var step historyStep
+		if err := json.Unmarshal([]byte(line), &step); err != nil {
+			return nil, fmt.Errorf("failed to parse the history of image %s: %w", shortID(id), err)
+		}
+		// Steps that didn't add a layer have no size ("0B" or "0").
+		size, _ := strconv.ParseInt(step.Size, 10, 64)
+		steps = append(steps, Step{CreatedBy: step.CreatedBy, Size: size})
+	}
+	return steps, nil
+}
+
+// Analysis is the result of analyzing the images of the store.
+type Analysis struct {
+	Images int `json:"images"`
+	// ApparentSize is the sum of the sizes of the images, which counts the
+	// shared layers once for each image using them.
+	ApparentSize int64 `json:"apparentSize"`
+	// Layers is the number of distinct layers, and SharedLayers the number
+	// of them used by more than one image.
+	Layers       int `json:"layers"`
+	SharedLayers int `json:"sharedLayers"`
+	// LayerReferences is the number of layers of all the images; the
+	// layers stored once instead of for each image are the difference with
+	// Layers.
+	LayerReferences int `json:"layerReferences"`
+	// FragmentedBases are the repositories of which several versions are
+	// used as the base of the images.
+	FragmentedBases []BaseGroup `json:"fragmentedBases"`
+	// LargeSteps are the build steps adding large layers.
+	LargeSteps []LargeStep `json:"largeSteps"`
+}
+
+// BaseGroup is a repository of which several versions are the bases of the
+// images; the images don't share the layers of the different versions.
+type BaseGroup struct {
+	Repository string `json:"repository"`
+	// Bases are the versions of the repository, from the one most images
+	// are built on.
+	Bases []Base `json:"bases"`
+	// Savings is the most space rebuilding all the images on the first base
+	// could free: the size of the other bases, some of whose layers may be
+	// shared.
+	Savings int64 `json:"savings"`
+}
+
+// Base is an image other images are built on.
+type Base struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+	// Images are the names of the images built on it.
+	Images []string `json:"images"`
+}
+
+// LargeStep is a build step adding a large layer, possibly to several images
+// sharing it.
+type LargeStep struct {
+	CreatedBy string   `json:"createdBy"`
+	Size      int64    `json:"size"`
+	Images    []string `json:"images"`
+}
+
+// Analyze analyzes the layers of the images, reporting the build steps
+// adding layers of at least minStepSize bytes.
+func Analyze(images []Image, minStepSize int64) *Analysis {
+	analysis := &Analysis{Images: len(images)}
+	layerUsers := map[string]int{}
+	for _, image := range images {
+		analysis.ApparentSize += image.Size
+		analysis.LayerReferences += len(image.Layers)
+		for _, layer := range image.Layers {
+			layerUsers[layer]++
+		}
+	}
+	analysis.Layers = len(layerUsers)
+	for _, users := range layerUsers {
+		if users > 1 {
+			analysis.SharedLayers++
+		}
+	}
+	analysis.FragmentedBases = fragmentedBases(images)
+	analysis.LargeSteps = largeSteps(images, minStepSize)
+	return analysis
+}
+
+// baseOf returns the image with a name the image is built on: the one with
+// the most layers, all of them at the bottom of the image.
+func baseOf(image *Image, images []Image) *Image {
+	var base *Image
+	for i := range images {
+		candidate := &images[i]
+		if candidate.ID == image.ID || len(candidate.Names) == 0 || len(candidate.Layers) == 0 {
+			continue
+		}
+		if len(candidate.Layers) >= len(image.Layers) || !slices.Equal(candidate.Layers, image.Layers[:len(candidate.Layers)]) {
+			continue
+		}
+		if base == nil || len(candidate.Layers) > len(base.Layers) {
+			base = candidate
+		}
+	}
+	return base
+}
+
+func fragmentedBases(images []Image) []BaseGroup {
+	bases := map[string]*Base{}
+	var repositories []string
+	baseRepositories := map[string][]string{}
+	for i := range images {
+		base := baseOf(&images[i], images)
+		if base == nil {
+			continue
+		}
+		if _, ok := bases[base.ID]; !ok {
+			bases[base.ID] = &Base{ID: base.ID, Name: base.Name(), Size: base.Size, Images: []string{}}
+			repository := base.Repository()
+			if _, ok := baseRepositories[repository]; !ok {
+				repositories = append(repositories, repository)
+			}
+			baseRepositories[repository] = append(baseRepositories[repository], base.ID)
+		}
+		bases[base.ID].Images = append(bases[base.ID].Images, images[i].Name())
+	}
+	groups := []BaseGroup{}
+	for _, repository := range repositories {
+		ids := baseRepositories[repository]
+		if len(ids) < 2 {
+			continue
+		}
+		group := BaseGroup{Repository: repository}
+		for _, id := range ids {
+			group.Bases = append(group.Bases, *bases[id])
+		}
+		slices.SortStableFunc(group.Bases, func(a, b Base) int {
+			return len(b.Images) - len(a.Images)
+		})
+		for _, base := range group.Bases[1:] {
+			group.Savings += base.Size
+		}
+		groups = append(groups, group)
+	}
+	slices.SortStableFunc(groups, func(a, b BaseGroup) int {
+		return cmp.Compare(b.Savings, a.Savings)
+	})
+	return groups
+}
+
+func largeSteps(images []Image, minStepSize int64) []LargeStep {
+	type key struct {
+		createdBy string
+		size      int64
+	}
+	var keys []key
+	steps := map[key]*LargeStep{}
+	for _, image := range images {
+		for _, step := range image.Steps {
+			if step.Size < minStepSize {
+				continue
+			}
+			// Images built on the same base share the steps of the base.
+			k := key{step.CreatedBy, step.Size}
+			if _, ok := steps[k]; !ok {
+				keys = append(keys, k)
+				steps[k] = &LargeStep{CreatedBy: step.CreatedBy, Size: step.Size}
+			}
+			if name := image.Name(); !slices.Contains(steps[k].Images, name) {
+				steps[k].Images = append(steps[k].Images, name)
+			}
+		}
+	}
+	result := make([]LargeStep, 0, len(keys))
+	for _, k := range keys {
+		result = append(result, *steps[k])
+	}
+	slices.SortStableFunc(result, func(a, b LargeStep) int {
+		return cmp.Compare(b.Size, a.Size)
+	})
+	return result
+}
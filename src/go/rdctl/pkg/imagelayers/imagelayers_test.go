@@ -0,0 +1,107 @@
+package imagelayers
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const mebibyte = 1024 * 1024
+
+func TestList(t *testing.T) {
+	cli := func(args ...string) ([]byte, error) {
+		switch strings.Join(args[:2], " ") {
+		case "image ls":
+			return []byte("sha256:aaa\nsha256:bbb\nsha256:aaa\n"), nil
+		case "image inspect":
+			assert.Equal(t, []string{"sha256:aaa", "sha256:bbb"}, args[2:])
+			return []byte(`[
+				{"Id": "sha256:aaa", "RepoTags": ["node:18"], "Size": 300, "RootFS": {"Layers": ["l1", "l2"]}},
+				{"Id": "sha256:bbb", "RepoTags": [], "RepoDigests": ["node@sha256:old"], "Size": 290, "RootFS": {"Layers": ["l1", "l3"]}}
+			]`), nil
+		case "history --no-trunc":
+			if args[len(args)-1] == "sha256:bbb" {
+				return []byte(`{"CreatedBy": "/bin/sh -c #(nop) CMD [\"node\"]", "Size": "0"}
+{"CreatedBy": "/bin/sh -c apt-get install -y python3", "Size": "250"}
+`), nil
+			}
+			return []byte(`{"CreatedBy": "ADD file:abc in /", "Size": "300"}`), nil
+		}
+		return nil, errors.New("unexpected command")
+	}
+	images, err := List(cli)
+	require.NoError(t, err)
+	assert.Equal(t, []Image{
+		{ID: "sha256:aaa", Names: []string{"node:18"}, Size: 300, Layers: []string{"l1", "l2"}, Steps: []Step{{CreatedBy: "ADD file:abc in /", Size: 300}}},
+		{ID: "sha256:bbb", Names: []string{"node@sha256:old"}, Size: 290, Layers: []string{"l1", "l3"}, Steps: []Step{
+			{CreatedBy: `/bin/sh -c #(nop) CMD ["node"]`},
+			{CreatedBy: "/bin/sh -c apt-get install -y python3", Size: 250},
+		}},
+	}, images)
+}
+
+func TestListFailure(t *testing.T) {
+	_, err := List(func(args ...string) ([]byte, error) {
+		return nil, errors.New("cannot connect to the daemon")
+	})
+	assert.ErrorContains(t, err, "failed to list the images: cannot connect to the daemon")
+}
+
+func TestRepository(t *testing.T) {
+	for name, expected := range map[string]string{
+		"node:18":                        "node",
+		"node@sha256:abc":                "node",
+		"registry.local:5000/app":        "registry.local:5000/app",
+		"registry.local:5000/app:1.0":    "registry.local:5000/app",
+		"ghcr.io/rancher/app:1.0@sha256": "ghcr.io/rancher/app",
+	} {
+		image := Image{Names: []string{name}}
+		assert.Equal(t, expected, image.Repository(), name)
+	}
+	assert.Empty(t, (&Image{ID: "sha256:abc"}).Repository())
+}
+
+func TestAnalyze(t *testing.T) {
+	images := []Image{
+		{ID: "node-new", Names: []string{"node:18"}, Size: 300 * mebibyte, Layers: []string{"os", "node-new"}},
+		{ID: "node-old", Names: []string{"node@sha256:old"}, Size: 290 * mebibyte, Layers: []string{"os", "node-old"}},
+		{ID: "alpine", Names: []string{"alpine:3"}, Size: 8 * mebibyte, Layers: []string{"alpine"}},
+		{ID: "app1", Names: []string{"app1:latest"}, Size: 310 * mebibyte, Layers: []string{"os", "node-new", "app1"}, Steps: []Step{
+			{CreatedBy: "COPY . /app", Size: 10 * mebibyte},
+			{CreatedBy: "ADD file:node in /", Size: 200 * mebibyte},
+		}},
+		{ID: "app2", Names: []string{"app2:latest"}, Size: 310 * mebibyte, Layers: []string{"os", "node-new", "app2"}, Steps: []Step{
+			{CreatedBy: "ADD file:node in /", Size: 200 * mebibyte},
+		}},
+		{ID: "app3", Names: []string{"app3:latest"}, Size: 890 * mebibyte, Layers: []string{"os", "node-old", "app3"}, Steps: []Step{
+			{CreatedBy: "RUN apt-get install -y chromium", Size: 600 * mebibyte},
+		}},
+		{ID: "tool", Names: []string{"tool:1"}, Size: 9 * mebibyte, Layers: []string{"alpine", "tool"}},
+	}
+	analysis := Analyze(images, 100*mebibyte)
+	assert.Equal(t, 7, analysis.Images)
+	assert.Equal(t, int64(2117*mebibyte), analysis.ApparentSize)
+	assert.Equal(t, 8, analysis.Layers)
+	assert.Equal(t, 4, analysis.SharedLayers)
+	assert.Equal(t, 16, analysis.LayerReferences)
+	assert.Equal(t, []BaseGroup{{
+		Repository: "node",
+		Bases: []Base{
+			{ID: "node-new", Name: "node:18", Size: 300 * mebibyte, Images: []string{"app1:latest", "app2:latest"}},
+			{ID: "node-old", Name: "node@sha256:old", Size: 290 * mebibyte, Images: []string{"app3:latest"}},
+		},
+		Savings: 290 * mebibyte,
+	}}, analysis.FragmentedBases)
+	assert.Equal(t, []LargeStep{
+		{CreatedBy: "RUN apt-get install -y chromium", Size: 600 * mebibyte, Images: []string{"app3:latest"}},
+		{CreatedBy: "ADD file:node in /", Size: 200 * mebibyte, Images: []string{"app1:latest", "app2:latest"}},
+	}, analysis.LargeSteps)
+}
+
+func TestAnalyzeNoImages(t *testing.T) {
+	analysis := Analyze(nil, mebibyte)
+	assert.Equal(t, &Analysis{FragmentedBases: []BaseGroup{}, LargeSteps: []LargeStep{}}, analysis)
+}
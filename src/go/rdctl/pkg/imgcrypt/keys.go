@@ -0,0 +1,147 @@
+// Package imgcrypt manages the private keys containerd uses to decrypt the
+// layers of encrypted images (see github.com/containerd/imgcrypt). The keys
+// are kept in the VM, on the data disk, where the ctd-decoder stream processor
+// configured in /etc/containerd/config.toml reads them when pulling images.
+package imgcrypt
+
+import (
+	"bytes"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/vm"
+)
+
+// KeysDir is the directory of the keys in the VM; it must match the
+// arguments of ctd-decoder in k3s-containerd-config.toml.
+const KeysDir = "/var/lib/rancher-desktop/ocicrypt/keys"
+
+// maxKeySize rejects files that can't be keys, such as images passed by
+// mistake.
+const maxKeySize = 1 << 20
+
+// addScript writes the key read from standard input, readable by root only.
+const addScript = `
+set -o errexit
+umask 077
+mkdir -p "$1"
+cat > "$1/.$2.tmp"
+mv -f "$1/.$2.tmp" "$1/$2"
+`
+
+// listScript prints the size and name of each key.
+const listScript = `
+[ -d "$1" ] || exit 0
+cd "$1"
+for key in *; do
+  [ -f "$key" ] && wc -c < "$key" | tr -d ' \n' && echo " $key"
+done
+exit 0
+`
+
+var keyNamePattern = regexp.MustCompile(`^[A-Za-z0-9][-_.A-Za-z0-9]*$`)
+
+// ErrKeyNotFound is returned when a key does not exist.
+var ErrKeyNotFound = errors.New("key not found")
+
+// Key is a decryption key in the VM.
+type Key struct {
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+}
+
+// ValidateName checks that the name can be the name of a key file.
+func ValidateName(name string) error {
+	if !keyNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid key name %q: only letters, digits, '-', '_', and '.' are allowed", name)
+	}
+	return nil
+}
+
+// ValidateKey rejects contents that can't decrypt images: empty or large
+// files, and PEM files without a private key, such as the public key or the
+// certificate used to encrypt the images. Keys in other formats (DER, JWK,
+// or GPG) are left to ctd-decoder.
+func ValidateKey(contents []byte) error {
+	if len(bytes.TrimSpace(contents)) == 0 {
+		return errors.New("the key is empty")
+	}
+	if len(contents) > maxKeySize {
+		return fmt.Errorf("the key is larger than %d bytes", maxKeySize)
+	}
+	var types []string
+	for rest := contents; ; {
+		var block *pem.Block
+		if block, rest = pem.Decode(rest); block == nil {
+			break
+		}
+		if strings.HasSuffix(block.Type, "PRIVATE KEY") {
+			return nil
+		}
+		types = append(types, block.Type)
+	}
+	if len(types) > 0 {
+		return fmt.Errorf("the file holds no private key, only %s; add the private key matching the recipient the images are encrypted for", strings.Join(types, ", "))
+	}
+	return nil
+}
+
+// Add adds the key, replacing any key of the same name.
+func Add(runner vm.Runner, name string, contents []byte) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+	if err := ValidateKey(contents); err != nil {
+		return err
+	}
+	if err := runner.RootStream(bytes.NewReader(contents), nil, "sh", "-c", addScript, "sh", KeysDir, name); err != nil {
+		return fmt.Errorf("failed to add key %q: %w", name, err)
+	}
+	return nil
+}
+
+// List returns the keys, sorted by name.
+func List(runner vm.Runner) ([]Key, error) {
+	output, err := runner.RootOutput("sh", "-c", listScript, "sh", KeysDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the keys: %w", err)
+	}
+	keys := []Key{}
+	for _, line := range strings.Split(string(output), "\n") {
+		size, name, ok := strings.Cut(line, " ")
+		if !ok {
+			continue
+		}
+		if size, err := strconv.ParseInt(size, 10, 64); err == nil {
+			keys = append(keys, Key{Name: name, Size: size})
+		}
+	}
+	slices.SortFunc(keys, func(a, b Key) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return keys, nil
+}
+
+// Remove removes the key; images it decrypted keep working, but pulling them
+// again fails.
+func Remove(runner vm.Runner, name string) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+	keys, err := List(runner)
+	if err != nil {
+		return err
+	}
+	if !slices.ContainsFunc(keys, func(key Key) bool { return key.Name == name }) {
+		return fmt.Errorf("%w: %s", ErrKeyNotFound, name)
+	}
+	if _, err := runner.RootOutput("rm", "-f", KeysDir+"/"+name); err != nil {
+		return fmt.Errorf("failed to remove key %q: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,264 @@
+package k3s
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/vm"
+)
+
+// Rancher Desktop runs K3s with its default datastore, SQLite (through
+// kine), rather than embedded etcd, so `k3s etcd-snapshot` can't be used.
+// Instead, a backup is an archive of the datastore, together with the token
+// and certificates needed to decrypt the bootstrap data stored in it, taken
+// while K3s is stopped so that the database is consistent.
+
+// backupScript writes the archive to standard output.
+const backupScript = `
+set -o errexit
+cd /var/lib/rancher/k3s
+tar -cz $(ls -d server/db server/token server/cred server/tls 2>/dev/null)
+`
+
+// restoreScript replaces the datastore with the archive read from standard
+// input.
+const restoreScript = `
+set -o errexit
+cd /var/lib/rancher/k3s
+rm -rf server/db
+tar -xz
+`
+
+var backupNamePattern = regexp.MustCompile(`^[A-Za-z0-9][-_.A-Za-z0-9]*$`)
+
+// ErrBackupNotFound is returned when a backup does not exist.
+var ErrBackupNotFound = errors.New("backup not found")
+
+// Backup describes a backup of the K3s datastore.
+type Backup struct {
+	Name    string    `json:"name"`
+	Created time.Time `json:"created"`
+	// The K3s version that wrote the datastore, e.g. "v1.27.4+k3s1".
+	Version string `json:"kubernetesVersion"`
+}
+
+// BackupManager keeps backups of the K3s datastore on the host.
+type BackupManager struct {
+	// Directory holding the backups; each one is stored as NAME.tar.gz,
+	// with its metadata in NAME.json.
+	Dir    string
+	runner vm.Runner
+	now    func() time.Time
+}
+
+// BackupDir returns the default directory for backups.
+func BackupDir(appPaths paths.Paths) string {
+	return filepath.Join(appPaths.AppHome, "kubernetes-backups")
+}
+
+func NewBackupManager(dir string, runner vm.Runner) *BackupManager {
+	return &BackupManager{
+		Dir:    dir,
+		runner: runner,
+		now:    time.Now,
+	}
+}
+
+func (m *BackupManager) archivePath(name string) string {
+	return filepath.Join(m.Dir, name+".tar.gz")
+}
+
+func (m *BackupManager) metadataPath(name string) string {
+	return filepath.Join(m.Dir, name+".json")
+}
+
+// DefaultBackupName returns a name based on the current time.
+func (m *BackupManager) DefaultBackupName() string {
+	return m.now().Format("20060102-150405")
+}
+
+// installedVersion returns the version of K3s installed in the VM.
+func installedVersion(runner vm.Runner) (string, error) {
+	output, err := runner.RootOutput("k3s", "--version")
+	if err != nil {
+		return "", fmt.Errorf("failed to get the K3s version: %w", err)
+	}
+	// The output starts with "k3s version v1.27.4+k3s1 (40d7c2b0)".
+	fields := strings.Fields(string(output))
+	if len(fields) < 3 || fields[1] != "version" {
+		return "", fmt.Errorf("failed to get the K3s version: unexpected output %q", strings.TrimSpace(string(output)))
+	}
+	return fields[2], nil
+}
+
+// withK3sStopped runs the function while the K3s service is stopped, and
+// starts it again afterwards if it was running before.
+func (m *BackupManager) withK3sStopped(fn func() error) (err error) {
+	running, err := Running(m.runner)
+	if err != nil {
+		return err
+	}
+	if running {
+		if _, err := m.runner.RootOutput("rc-service", "k3s", "stop"); err != nil {
+			return fmt.Errorf("failed to stop K3s: %w", err)
+		}
+		defer func() {
+			if _, startErr := m.runner.RootOutput("rc-service", "k3s", "start"); startErr != nil {
+				err = errors.Join(err, fmt.Errorf("failed to start K3s: %w", startErr))
+			}
+		}()
+	}
+	return fn()
+}
+
+// Create backs up the datastore under the given name. Kubernetes is briefly
+// stopped while the backup is taken; workloads keep running.
+func (m *BackupManager) Create(name string) (*Backup, error) {
+	if !backupNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("invalid backup name %q: only letters, digits, '-', '_', and '.' are allowed", name)
+	}
+	if _, err := os.Stat(m.metadataPath(name)); err == nil {
+		return nil, fmt.Errorf("backup %q already exists", name)
+	}
+	version, err := installedVersion(m.runner)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(m.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	file, err := os.CreateTemp(m.Dir, name+".*.tmp")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create backup file: %w", err)
+	}
+	defer os.Remove(file.Name())
+	err = m.withK3sStopped(func() error {
+		return m.runner.RootStream(nil, file, "sh", "-c", backupScript)
+	})
+	if closeErr := file.Close(); err == nil && closeErr != nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to back up the datastore: %w", err)
+	}
+	if err := os.Rename(file.Name(), m.archivePath(name)); err != nil {
+		return nil, fmt.Errorf("failed to save backup: %w", err)
+	}
+	backup := Backup{Name: name, Created: m.now(), Version: version}
+	metadata, err := json.MarshalIndent(backup, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(m.metadataPath(name), metadata, 0o644); err != nil {
+		return nil, fmt.Errorf("failed to save backup metadata: %w", err)
+	}
+	return &backup, nil
+}
+
+// List returns the backups, oldest first.
+func (m *BackupManager) List() ([]Backup, error) {
+	entries, err := os.ReadDir(m.Dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return []Backup{}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read backup directory: %w", err)
+	}
+	backups := []Backup{}
+	for _, entry := range entries {
+		name, found := strings.CutSuffix(entry.Name(), ".json")
+		if !found || entry.IsDir() {
+			continue
+		}
+		backup, err := m.get(name)
+		if err != nil {
+			return nil, err
+		}
+		backups = append(backups, *backup)
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].Created.Before(backups[j].Created) })
+	return backups, nil
+}
+
+func (m *BackupManager) get(name string) (*Backup, error) {
+	data, err := os.ReadFile(m.metadataPath(name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrBackupNotFound, name)
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read backup %q: %w", name, err)
+	}
+	var backup Backup
+	if err := json.Unmarshal(data, &backup); err != nil {
+		return nil, fmt.Errorf("failed to parse backup %q: %w", name, err)
+	}
+	backup.Name = name
+	return &backup, nil
+}
+
+// Delete removes the backup.
+func (m *BackupManager) Delete(name string) error {
+	if _, err := m.get(name); err != nil {
+		return err
+	}
+	// Remove the metadata first, so a partially deleted backup isn't listed.
+	if err := os.Remove(m.metadataPath(name)); err != nil {
+		return fmt.Errorf("failed to delete backup %q: %w", name, err)
+	}
+	if err := os.Remove(m.archivePath(name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete backup %q: %w", name, err)
+	}
+	return nil
+}
+
+// Prune deletes the oldest backups so that at most keep remain, returning
+// the names of the deleted backups. A keep of 0 or less keeps all backups.
+func (m *BackupManager) Prune(keep int) ([]string, error) {
+	backups, err := m.List()
+	if err != nil || keep <= 0 || len(backups) <= keep {
+		return nil, err
+	}
+	var deleted []string
+	for _, backup := range backups[:len(backups)-keep] {
+		if err := m.Delete(backup.Name); err != nil {
+			return deleted, err
+		}
+		deleted = append(deleted, backup.Name)
+	}
+	return deleted, nil
+}
+
+// Restore replaces the datastore with the backup. Unless force is set, the
+// backup must have been taken with the installed K3s version, as K3s doesn't
+// support downgrading the datastore.
+func (m *BackupManager) Restore(name string, force bool) error {
+	backup, err := m.get(name)
+	if err != nil {
+		return err
+	}
+	version, err := installedVersion(m.runner)
+	if err != nil {
+		return err
+	}
+	if version != backup.Version && !force {
+		return fmt.Errorf("backup %q was taken with Kubernetes %s, but %s is installed; use --force to restore it anyway", name, backup.Version, version)
+	}
+	file, err := os.Open(m.archivePath(name))
+	if err != nil {
+		return fmt.Errorf("failed to open backup %q: %w", name, err)
+	}
+	defer file.Close()
+	err = m.withK3sStopped(func() error {
+		return m.runner.RootStream(file, nil, "sh", "-c", restoreScript)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to restore backup %q: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package k3s
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestBackupManager(t *testing.T, runner *shellVM) *BackupManager {
+	manager := NewBackupManager(filepath.Join(t.TempDir(), "backups"), runner)
+	now := time.Date(2023, 8, 1, 12, 0, 0, 0, time.UTC)
+	manager.now = func() time.Time {
+		now = now.Add(time.Minute)
+		return now
+	}
+	return manager
+}
+
+// newBackupVM returns a fake VM with a datastore, which K3s only allows to
+// be archived or replaced while it is stopped.
+func newBackupVM(t *testing.T, k3sStatus string) *shellVM {
+	runner := newShellVM(t, k3sStatus)
+	runner.stoppedForStreams = true
+	runner.writeFile("/var/lib/rancher/k3s/server/db/state.db", "first")
+	runner.writeFile("/var/lib/rancher/k3s/server/token", "token")
+	runner.writeFile("/var/lib/rancher/k3s/server/tls/server-ca.crt", "certificate")
+	return runner
+}
+
+func TestBackupRestore(t *testing.T) {
+	runner := newBackupVM(t, "started")
+	manager := newTestBackupManager(t, runner)
+
+	backup, err := manager.Create("first")
+	require.NoError(t, err)
+	assert.Equal(t, "v1.27.4+k3s1", backup.Version)
+	assert.Equal(t, "started", runner.k3sStatus(), "K3s should be started again")
+	_, err = manager.Create("first")
+	assert.Error(t, err, "backup names must be unique")
+	_, err = manager.Create("../escape")
+	assert.Error(t, err)
+
+	runner.writeFile("/var/lib/rancher/k3s/server/db/state.db", "second")
+	_, err = manager.Create("second")
+	require.NoError(t, err)
+
+	backups, err := manager.List()
+	require.NoError(t, err)
+	require.Len(t, backups, 2)
+	assert.Equal(t, "first", backups[0].Name)
+	assert.Equal(t, "second", backups[1].Name)
+
+	runner.writeFile("/var/lib/rancher/k3s/server/db/state.db-wal", "journal")
+	runner.writeFile("/var/lib/rancher/k3s/server/tls/server-ca.crt", "other certificate")
+	require.NoError(t, manager.Restore("first", false))
+	assert.Equal(t, "first", runner.readFile("/var/lib/rancher/k3s/server/db/state.db"))
+	assert.NoFileExists(t, runner.path("/var/lib/rancher/k3s/server/db/state.db-wal"), "the datastore should be replaced, not merged")
+	assert.Equal(t, "certificate", runner.readFile("/var/lib/rancher/k3s/server/tls/server-ca.crt"))
+	assert.Equal(t, "started", runner.k3sStatus())
+	assert.ErrorIs(t, manager.Restore("missing", false), ErrBackupNotFound)
+}
+
+func TestBackupWhilePaused(t *testing.T) {
+	runner := newBackupVM(t, "stopped")
+	manager := newTestBackupManager(t, runner)
+	_, err := manager.Create("paused")
+	require.NoError(t, err)
+	assert.Equal(t, "stopped", runner.k3sStatus(), "a paused cluster should stay paused")
+}
+
+func TestRestoreVersionMismatch(t *testing.T) {
+	runner := newBackupVM(t, "started")
+	manager := newTestBackupManager(t, runner)
+	_, err := manager.Create("old")
+	require.NoError(t, err)
+	metadata := `{"name": "old", "created": "2023-01-01T00:00:00Z", "kubernetesVersion": "v1.26.7+k3s1"}`
+	require.NoError(t, os.WriteFile(filepath.Join(manager.Dir, "old.json"), []byte(metadata), 0o644))
+	runner.writeFile("/var/lib/rancher/k3s/server/db/state.db", "current")
+
+	assert.ErrorContains(t, manager.Restore("old", false), "--force")
+	assert.Equal(t, "current", runner.readFile("/var/lib/rancher/k3s/server/db/state.db"))
+	require.NoError(t, manager.Restore("old", true))
+	assert.Equal(t, "first", runner.readFile("/var/lib/rancher/k3s/server/db/state.db"))
+}
+
+func TestPrune(t *testing.T) {
+	manager := newTestBackupManager(t, newBackupVM(t, "started"))
+	for _, name := range []string{"a", "b", "c", "d"} {
+		_, err := manager.Create(name)
+		require.NoError(t, err)
+	}
+	deleted, err := manager.Prune(2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, deleted)
+	backups, err := manager.List()
+	require.NoError(t, err)
+	require.Len(t, backups, 2)
+	assert.Equal(t, "c", backups[0].Name)
+	assert.NoFileExists(t, filepath.Join(manager.Dir, "a.tar.gz"))
+
+	deleted, err = manager.Prune(0)
+	assert.NoError(t, err)
+	assert.Empty(t, deleted)
+}
@@ -0,0 +1,176 @@
+package k3s
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/vcluster"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/vm"
+)
+
+// Extra nodes are K3s agents running as privileged containers of the
+// container engine in the VM (the same way k3d and kind run nodes), and join
+// the cluster through the K3s server in the VM. They share the resources of
+// the VM; they are meant for testing scheduling, not for adding capacity.
+
+// agentContainerPrefix is prepended to the node name to get the name of the
+// container running the agent.
+const agentContainerPrefix = "rd-agent-"
+
+// engineSetup sets $CLI to the command managing the agent containers, which
+// depends on the container engine K3s was started with.
+const engineSetup = `
+set -o errexit
+ENGINE=containerd
+if [ -f /etc/conf.d/k3s ]; then
+  . /etc/conf.d/k3s
+fi
+if [ "$ENGINE" = moby ]; then
+  CLI=docker
+else
+  CLI="nerdctl --namespace default"
+fi
+`
+
+// listAgentsScript writes the name and status of all containers.
+const listAgentsScript = engineSetup + `
+$CLI ps --all --format '{{.Names}}	{{.Status}}'
+`
+
+// addAgentScript starts an agent container named "$1" from the image "$2".
+// The agent registers with the address of the server node, which is
+// included in the server certificate.
+const addAgentScript = engineSetup + `
+SERVER_IP="$(k3s kubectl get nodes --selector node-role.kubernetes.io/control-plane \
+  --output 'jsonpath={.items[0].status.addresses[?(@.type=="InternalIP")].address}')"
+TOKEN="$(cat /var/lib/rancher/k3s/server/node-token)"
+$CLI run --detach --privileged --restart unless-stopped \
+  --name "` + agentContainerPrefix + `$1" --hostname "$1" \
+  --tmpfs /run --tmpfs /var/run \
+  --env K3S_URL="https://${SERVER_IP}:${PORT:-6443}" --env K3S_TOKEN="$TOKEN" \
+  "$2" agent >/dev/null
+`
+
+// removeAgentScript removes the node "$1" from the cluster, evicting its
+// pods first, and then removes its container.
+const removeAgentScript = engineSetup + `
+k3s kubectl drain "$1" --ignore-daemonsets --delete-emptydir-data --force --timeout 60s >/dev/null 2>&1 || true
+k3s kubectl delete node "$1" --ignore-not-found >/dev/null
+$CLI rm --force "` + agentContainerPrefix + `$1" >/dev/null
+`
+
+var nodeNamePattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]{0,61}[a-z0-9])?$`)
+
+// ErrNodeNotFound is returned when an agent node does not exist.
+var ErrNodeNotFound = errors.New("node not found")
+
+// AgentNode describes an extra node added with AddAgent.
+type AgentNode struct {
+	Name string `json:"name"`
+	// Status of the container running the node, as reported by the
+	// container engine (e.g. "Up 2 minutes").
+	Status string `json:"status"`
+}
+
+// ValidateNodeName checks that the name can be used for a node and its
+// container.
+func ValidateNodeName(name string) error {
+	if !nodeNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid node name %q: must be at most 63 lowercase letters, digits, and dashes, and must start and end with a letter or digit", name)
+	}
+	return nil
+}
+
+// ListAgents returns the extra nodes, sorted by name.
+func ListAgents(runner vm.Runner) ([]AgentNode, error) {
+	output, err := runner.RootOutput("sh", "-c", listAgentsScript)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list agent containers: %w", err)
+	}
+	nodes := []AgentNode{}
+	for _, line := range strings.Split(string(output), "\n") {
+		name, status, _ := strings.Cut(strings.TrimSpace(line), "\t")
+		if nodeName, found := strings.CutPrefix(name, agentContainerPrefix); found {
+			nodes = append(nodes, AgentNode{Name: nodeName, Status: status})
+		}
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Name < nodes[j].Name })
+	return nodes, nil
+}
+
+// NextAgentName returns the first name of the form agent-N that is not used
+// by any of the nodes.
+func NextAgentName(nodes []AgentNode) string {
+	used := make(map[string]bool, len(nodes))
+	for _, node := range nodes {
+		used[node.Name] = true
+	}
+	for i := 1; ; i++ {
+		if name := fmt.Sprintf("agent-%d", i); !used[name] {
+			return name
+		}
+	}
+}
+
+// AddAgent starts a K3s agent container that joins the cluster as the node
+// with the given name. The agent runs the same K3s version as the server.
+func AddAgent(runner vm.Runner, name string) error {
+	if err := ValidateNodeName(name); err != nil {
+		return err
+	}
+	nodes, err := ListAgents(runner)
+	if err != nil {
+		return err
+	}
+	for _, node := range nodes {
+		if node.Name == name {
+			return fmt.Errorf("node %q already exists", name)
+		}
+	}
+	version, err := installedVersion(runner)
+	if err != nil {
+		return err
+	}
+	if _, err := runner.RootOutput("sh", "-c", addAgentScript, "sh", name, vcluster.Image(version)); err != nil {
+		return fmt.Errorf("failed to start agent %q: %w", name, err)
+	}
+	return nil
+}
+
+// WaitForNode waits until the node has joined the cluster and is ready.
+func WaitForNode(runner vm.Runner, name string, timeout time.Duration) error {
+	const interval = 2 * time.Second
+	deadline := time.Now().Add(timeout)
+	for {
+		output, err := runner.RootOutput("k3s", "kubectl", "get", "node", name, "--ignore-not-found",
+			"--output", `jsonpath={.status.conditions[?(@.type=="Ready")].status}`)
+		if err == nil && strings.TrimSpace(string(output)) == "True" {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out waiting for node %q to become ready", name)
+		}
+		time.Sleep(interval)
+	}
+}
+
+// RemoveAgent removes the node from the cluster and deletes its container.
+func RemoveAgent(runner vm.Runner, name string) error {
+	nodes, err := ListAgents(runner)
+	if err != nil {
+		return err
+	}
+	for _, node := range nodes {
+		if node.Name == name {
+			if _, err := runner.RootOutput("sh", "-c", removeAgentScript, "sh", name); err != nil {
+				return fmt.Errorf("failed to remove node %q: %w", name, err)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %q", ErrNodeNotFound, name)
+}
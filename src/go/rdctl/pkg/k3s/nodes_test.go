@@ -0,0 +1,42 @@
+package k3s
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAgents(t *testing.T) {
+	runner := newShellVM(t, "started")
+	runner.writeState("containers", "buildkitd\n")
+	runner.writeFile("/etc/conf.d/k3s", "ENGINE=moby\nPORT=6444\n")
+	runner.writeFile("/var/lib/rancher/k3s/server/node-token", "secret-token\n")
+
+	nodes, err := ListAgents(runner)
+	require.NoError(t, err)
+	assert.Empty(t, nodes)
+	assert.Equal(t, "agent-1", NextAgentName(nodes))
+
+	require.NoError(t, AddAgent(runner, "agent-1"))
+	require.NoError(t, AddAgent(runner, "worker"))
+	assert.Equal(t, []string{"buildkitd", "rd-agent-agent-1", "rd-agent-worker"}, runner.readState("containers"))
+	assert.Equal(t, []string{
+		"K3S_URL=https://192.168.5.15:6444", "K3S_TOKEN=secret-token",
+		"K3S_URL=https://192.168.5.15:6444", "K3S_TOKEN=secret-token",
+	}, runner.readState("env"), "the agents should join the server with its token")
+	assert.Contains(t, runner.log()[len(runner.log())-1], "docker run ", "the engine should be read from the K3s configuration")
+	assert.Contains(t, runner.log()[len(runner.log())-1], " rancher/k3s:v1.27.4-k3s1 agent", "agents should run the K3s version of the server")
+	assert.Error(t, AddAgent(runner, "worker"), "node names must be unique")
+	assert.Error(t, AddAgent(runner, "Not_Valid"))
+
+	nodes, err = ListAgents(runner)
+	require.NoError(t, err)
+	assert.Equal(t, []AgentNode{{Name: "agent-1", Status: "Up 1 second"}, {Name: "worker", Status: "Up 1 second"}}, nodes)
+	assert.Equal(t, "agent-2", NextAgentName(nodes))
+
+	require.NoError(t, RemoveAgent(runner, "agent-1"))
+	assert.Contains(t, runner.log(), "k3s kubectl delete node agent-1 --ignore-not-found")
+	assert.ErrorIs(t, RemoveAgent(runner, "agent-1"), ErrNodeNotFound)
+	assert.Equal(t, []string{"buildkitd", "rd-agent-worker"}, runner.readState("containers"))
+}
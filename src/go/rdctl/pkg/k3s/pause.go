@@ -0,0 +1,90 @@
+// Package k3s manages the K3s service in the Rancher Desktop VM.
+package k3s
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/vm"
+)
+
+// ErrAlreadyPaused and ErrNotPaused are returned when the cluster is already
+// in the requested state.
+var (
+	ErrAlreadyPaused = errors.New("the cluster is already paused")
+	ErrNotPaused     = errors.New("the cluster is not paused")
+)
+
+// pauseScript stops K3s, and then the containers of all pods, which K3s
+// leaves running when it stops. The datastore and the pod definitions are
+// kept, so the kubelet recreates the pods when K3s starts again. The container
+// engine is read from the K3s service configuration.
+const pauseScript = `
+set -o errexit
+ENGINE=containerd
+if [ -f /etc/conf.d/k3s ]; then
+  . /etc/conf.d/k3s
+fi
+rc-service --ifstarted k3s stop
+if [ "$ENGINE" = moby ]; then
+  ids="$(docker ps --quiet --filter label=io.kubernetes.pod.name)"
+  if [ -n "$ids" ]; then
+    docker stop $ids >/dev/null
+  fi
+else
+  ids="$(nerdctl --namespace k8s.io ps --quiet)"
+  if [ -n "$ids" ]; then
+    nerdctl --namespace k8s.io stop $ids >/dev/null
+  fi
+fi
+`
+
+// Running returns whether the K3s service is started.
+func Running(runner vm.Runner) (bool, error) {
+	// rc-service exits with an error if the service is stopped, so the status
+	// is read from the output instead.
+	output, err := runner.RootOutput("sh", "-c", "rc-service k3s status 2>&1 || true")
+	if err != nil {
+		return false, fmt.Errorf("failed to get the K3s service status: %w", err)
+	}
+	_, status, found := strings.Cut(string(output), "status:")
+	if !found {
+		return false, fmt.Errorf("failed to get the K3s service status: unexpected output %q", strings.TrimSpace(string(output)))
+	}
+	// Any state other than stopped (e.g. "starting", or "crashed" while
+	// waiting to be respawned) is treated as running.
+	return strings.TrimSpace(status) != "stopped", nil
+}
+
+// Pause stops K3s and all Kubernetes workloads to free their CPU and memory,
+// while keeping the container engine running.
+func Pause(runner vm.Runner) error {
+	running, err := Running(runner)
+	if err != nil {
+		return err
+	}
+	if !running {
+		return ErrAlreadyPaused
+	}
+	if _, err := runner.RootOutput("sh", "-c", pauseScript); err != nil {
+		return fmt.Errorf("failed to pause Kubernetes: %w", err)
+	}
+	return nil
+}
+
+// Resume starts K3s again after Pause; the workloads are then restarted by
+// the kubelet.
+func Resume(runner vm.Runner) error {
+	running, err := Running(runner)
+	if err != nil {
+		return err
+	}
+	if running {
+		return ErrNotPaused
+	}
+	if _, err := runner.RootOutput("rc-service", "k3s", "start"); err != nil {
+		return fmt.Errorf("failed to resume Kubernetes: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package k3s
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPauseResume(t *testing.T) {
+	stopCommands := map[string]string{
+		"containerd": "nerdctl --namespace k8s.io stop pod-a pod-b",
+		"moby":       "docker stop pod-a pod-b",
+	}
+	for engine, stopCommand := range stopCommands {
+		t.Run(engine, func(t *testing.T) {
+			runner := newShellVM(t, "started")
+			runner.writeFile("/etc/conf.d/k3s", "ENGINE="+engine+"\n")
+			runner.writeState("pods", "pod-a\npod-b\n")
+
+			assert.ErrorIs(t, Resume(runner), ErrNotPaused)
+			require.NoError(t, Pause(runner))
+			assert.Equal(t, "stopped", runner.k3sStatus())
+			assert.Equal(t, []string{"pod-a", "pod-b"}, runner.readState("stopped"), "the containers of the pods should be stopped")
+			assert.Contains(t, runner.log(), stopCommand, "the engine should be read from the K3s configuration")
+
+			assert.ErrorIs(t, Pause(runner), ErrAlreadyPaused)
+			require.NoError(t, Resume(runner))
+			assert.Equal(t, "started", runner.k3sStatus())
+		})
+	}
+}
+
+func TestPauseWithoutPods(t *testing.T) {
+	runner := newShellVM(t, "started")
+	require.NoError(t, Pause(runner))
+	assert.Equal(t, "stopped", runner.k3sStatus())
+	assert.Empty(t, runner.readState("stopped"))
+}
+
+func TestRunning(t *testing.T) {
+	for status, expected := range map[string]bool{"started": true, "starting": true, "crashed": true, "stopped": false} {
+		running, err := Running(newShellVM(t, status))
+		assert.NoError(t, err)
+		assert.Equal(t, expected, running, status)
+	}
+}
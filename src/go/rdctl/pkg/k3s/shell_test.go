@@ -0,0 +1,225 @@
+package k3s
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// The scripts that manage K3s are tested by running them with the local
+// shell against a fake VM: a temporary directory standing in for the file
+// system of the VM, and stubs of the commands that can't be run outside of
+// it. The stubs keep their state in files under $STATE, and append every
+// invocation to $STATE/log.
+
+// rcServiceStub implements `rc-service [--ifstarted] k3s start|stop|status`.
+const rcServiceStub = `#!/bin/sh
+echo "rc-service $*" >> "$STATE/log"
+status="$(cat "$STATE/k3s")"
+if [ "$1" = --ifstarted ]; then
+  shift
+  [ "$status" = started ] || exit 0
+fi
+case "$2" in
+  start) echo started > "$STATE/k3s" ;;
+  stop) echo stopped > "$STATE/k3s" ;;
+  status)
+    echo " * status: $status"
+    [ "$status" = started ]
+    ;;
+esac
+`
+
+// k3sStub implements `k3s --version` and the kubectl commands used by the
+// scripts.
+const k3sStub = `#!/bin/sh
+echo "k3s $*" >> "$STATE/log"
+case "$1 $2 $3" in
+  "--version  ")
+    echo "k3s version v1.27.4+k3s1 (40d7c2b0)"
+    echo "go version go1.20.6"
+    ;;
+  "kubectl get nodes") echo 192.168.5.15 ;;
+  "kubectl get persistentvolumes") cat "$STATE/volumes" ;;
+esac
+`
+
+// containerCLIStub implements the docker and nerdctl commands used by the
+// scripts; the containers of pods are listed in $STATE/pods, and the other
+// containers in $STATE/containers.
+const containerCLIStub = `#!/bin/sh
+echo "$(basename "$0") $*" >> "$STATE/log"
+if [ "$1" = --namespace ]; then
+  shift 2
+fi
+command="$1"
+shift
+case "$command" in
+  ps)
+    if [ "$1" = --quiet ]; then
+      cat "$STATE/pods"
+    else
+      while read -r name; do
+        printf '%s\tUp 1 second\n' "$name"
+      done < "$STATE/containers"
+    fi
+    ;;
+  stop)
+    printf '%s\n' "$@" >> "$STATE/stopped"
+    : > "$STATE/pods"
+    ;;
+  run)
+    while [ $# -gt 0 ]; do
+      case "$1" in
+        --name) echo "$2" >> "$STATE/containers"; shift ;;
+        --env) echo "$2" >> "$STATE/env"; shift ;;
+      esac
+      shift
+    done
+    ;;
+  rm)
+    grep -v -x -e "$2" "$STATE/containers" > "$STATE/containers.new" || true
+    mv "$STATE/containers.new" "$STATE/containers"
+    ;;
+esac
+`
+
+// shellVM is a vm.Runner running commands in a fake VM.
+type shellVM struct {
+	t *testing.T
+	// root stands in for the root directory of the VM, for the paths the
+	// scripts use.
+	root  string
+	bin   string
+	state string
+	// stoppedForStreams requires K3s to be stopped when a command is run
+	// with RootStream, as is the case for backups and restores.
+	stoppedForStreams bool
+}
+
+// newShellVM creates a fake VM in which K3s has the given status.
+func newShellVM(t *testing.T, k3sStatus string) *shellVM {
+	if runtime.GOOS == "windows" {
+		t.Skip("the scripts run in the Linux VM")
+	}
+	dir := t.TempDir()
+	v := &shellVM{
+		t:     t,
+		root:  filepath.Join(dir, "root"),
+		bin:   filepath.Join(dir, "bin"),
+		state: filepath.Join(dir, "state"),
+	}
+	for _, dir := range []string{v.bin, v.state, v.path("/etc/conf.d"), v.path("/var/lib/rancher/k3s/server")} {
+		require.NoError(t, os.MkdirAll(dir, 0o755))
+	}
+	stubs := map[string]string{
+		"rc-service": rcServiceStub,
+		"k3s":        k3sStub,
+		"docker":     containerCLIStub,
+		"nerdctl":    containerCLIStub,
+	}
+	for name, contents := range stubs {
+		require.NoError(t, os.WriteFile(filepath.Join(v.bin, name), []byte(contents), 0o755))
+	}
+	for _, name := range []string{"log", "pods", "stopped", "containers", "env", "volumes"} {
+		v.writeState(name, "")
+	}
+	v.writeState("k3s", k3sStatus+"\n")
+	return v
+}
+
+// path returns the path on the host standing in for the path in the VM.
+func (v *shellVM) path(vmPath string) string {
+	return filepath.Join(v.root, filepath.FromSlash(vmPath))
+}
+
+func (v *shellVM) writeFile(vmPath, contents string) {
+	require.NoError(v.t, os.MkdirAll(filepath.Dir(v.path(vmPath)), 0o755))
+	require.NoError(v.t, os.WriteFile(v.path(vmPath), []byte(contents), 0o644))
+}
+
+func (v *shellVM) readFile(vmPath string) string {
+	contents, err := os.ReadFile(v.path(vmPath))
+	require.NoError(v.t, err)
+	return string(contents)
+}
+
+func (v *shellVM) writeState(name, contents string) {
+	require.NoError(v.t, os.WriteFile(filepath.Join(v.state, name), []byte(contents), 0o644))
+}
+
+// readState returns the lines of a state file of the stubs.
+func (v *shellVM) readState(name string) []string {
+	contents, err := os.ReadFile(filepath.Join(v.state, name))
+	require.NoError(v.t, err)
+	return strings.Fields(strings.ReplaceAll(string(contents), "\t", " "))
+}
+
+func (v *shellVM) k3sStatus() string {
+	return strings.Join(v.readState("k3s"), "")
+}
+
+// log returns the commands run by the stubs.
+func (v *shellVM) log() []string {
+	contents, err := os.ReadFile(filepath.Join(v.state, "log"))
+	require.NoError(v.t, err)
+	return strings.Split(strings.TrimSpace(string(contents)), "\n")
+}
+
+// command prepares to run the command, with the paths of the VM in its
+// arguments replaced by the ones standing in for them.
+func (v *shellVM) command(args ...string) *exec.Cmd {
+	replacer := strings.NewReplacer(
+		"/etc/conf.d/", v.path("/etc/conf.d")+"/",
+		"/var/lib/rancher/", v.path("/var/lib/rancher")+"/",
+	)
+	for i := range args {
+		args[i] = replacer.Replace(args[i])
+	}
+	name := args[0]
+	if _, err := os.Stat(filepath.Join(v.bin, name)); err == nil {
+		name = filepath.Join(v.bin, name)
+	}
+	cmd := exec.Command(name, args[1:]...)
+	cmd.Env = append(os.Environ(),
+		"PATH="+v.bin+string(os.PathListSeparator)+os.Getenv("PATH"),
+		"STATE="+v.state)
+	return cmd
+}
+
+func (v *shellVM) RootOutput(args ...string) ([]byte, error) {
+	var stdout bytes.Buffer
+	if err := v.run(nil, &stdout, args...); err != nil {
+		return nil, err
+	}
+	// Report the paths as they are in the VM.
+	return bytes.ReplaceAll(stdout.Bytes(), []byte(v.root), nil), nil
+}
+
+func (v *shellVM) RootStream(stdin io.Reader, stdout io.Writer, args ...string) error {
+	if v.stoppedForStreams && v.k3sStatus() != "stopped" {
+		return errors.New("K3s must be stopped")
+	}
+	return v.run(stdin, stdout, args...)
+}
+
+func (v *shellVM) run(stdin io.Reader, stdout io.Writer, args ...string) error {
+	var stderr bytes.Buffer
+	cmd := v.command(append([]string{}, args...)...)
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
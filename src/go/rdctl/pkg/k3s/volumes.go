@@ -0,0 +1,139 @@
+package k3s
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/vm"
+)
+
+// Volume describes a persistent volume of the cluster.
+type Volume struct {
+	Name         string `json:"name"`
+	Namespace    string `json:"namespace,omitempty"`
+	Claim        string `json:"claim,omitempty"`
+	StorageClass string `json:"storageClass,omitempty"`
+	// Capacity is the requested size, e.g. "2Gi"; the local-path provisioner
+	// does not enforce it.
+	Capacity string `json:"capacity,omitempty"`
+	// Path is the directory in the VM backing the volume, if any.
+	Path string `json:"path,omitempty"`
+	// Used is the disk space used by the volume in bytes, or -1 if unknown.
+	Used int64 `json:"usedBytes"`
+}
+
+// persistentVolumeList is the part of the Kubernetes PersistentVolumeList
+// needed to describe volumes.
+type persistentVolumeList struct {
+	Items []struct {
+		Metadata struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+		Spec struct {
+			Capacity struct {
+				Storage string `json:"storage"`
+			} `json:"capacity"`
+			ClaimRef *struct {
+				Namespace string `json:"namespace"`
+				Name      string `json:"name"`
+			} `json:"claimRef"`
+			StorageClassName string `json:"storageClassName"`
+			// The local-path provisioner creates hostPath volumes, or local
+			// volumes when requested through the volumeType annotation.
+			HostPath *struct {
+				Path string `json:"path"`
+			} `json:"hostPath"`
+			Local *struct {
+				Path string `json:"path"`
+			} `json:"local"`
+		} `json:"spec"`
+	} `json:"items"`
+}
+
+// duScript writes the disk usage in KiB of each directory given as an
+// argument, or "-" if it can't be determined.
+const duScript = `
+for dir in "$@"; do
+  du -sk "$dir" 2>/dev/null || printf -- '-\t%s\n' "$dir"
+done
+`
+
+// ListVolumes returns the persistent volumes with their backing directories
+// and disk usage, sorted by namespace and claim.
+func ListVolumes(runner vm.Runner) ([]Volume, error) {
+	data, err := runner.RootOutput("k3s", "kubectl", "get", "persistentvolumes", "--output", "json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list persistent volumes: %w", err)
+	}
+	var list persistentVolumeList
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse persistent volumes: %w", err)
+	}
+	volumes := make([]Volume, 0, len(list.Items))
+	var dirs []string
+	for _, item := range list.Items {
+		volume := Volume{
+			Name:         item.Metadata.Name,
+			StorageClass: item.Spec.StorageClassName,
+			Capacity:     item.Spec.Capacity.Storage,
+			Used:         -1,
+		}
+		if item.Spec.ClaimRef != nil {
+			volume.Namespace = item.Spec.ClaimRef.Namespace
+			volume.Claim = item.Spec.ClaimRef.Name
+		}
+		if item.Spec.HostPath != nil {
+			volume.Path = item.Spec.HostPath.Path
+		} else if item.Spec.Local != nil {
+			volume.Path = item.Spec.Local.Path
+		}
+		if volume.Path != "" {
+			dirs = append(dirs, volume.Path)
+		}
+		volumes = append(volumes, volume)
+	}
+	if len(dirs) > 0 {
+		usage, err := diskUsage(runner, dirs)
+		if err != nil {
+			return nil, err
+		}
+		for i := range volumes {
+			if used, ok := usage[volumes[i].Path]; ok {
+				volumes[i].Used = used
+			}
+		}
+	}
+	sort.Slice(volumes, func(i, j int) bool {
+		if volumes[i].Namespace != volumes[j].Namespace {
+			return volumes[i].Namespace < volumes[j].Namespace
+		}
+		if volumes[i].Claim != volumes[j].Claim {
+			return volumes[i].Claim < volumes[j].Claim
+		}
+		return volumes[i].Name < volumes[j].Name
+	})
+	return volumes, nil
+}
+
+// diskUsage returns the disk usage in bytes of the directories in the VM that
+// could be determined.
+func diskUsage(runner vm.Runner, dirs []string) (map[string]int64, error) {
+	output, err := runner.RootOutput(append([]string{"sh", "-c", duScript, "sh"}, dirs...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get volume disk usage: %w", err)
+	}
+	usage := make(map[string]int64, len(dirs))
+	for _, line := range strings.Split(string(output), "\n") {
+		size, dir, found := strings.Cut(line, "\t")
+		if !found {
+			continue
+		}
+		if kib, err := strconv.ParseInt(size, 10, 64); err == nil {
+			usage[dir] = kib * 1024
+		}
+	}
+	return usage, nil
+}
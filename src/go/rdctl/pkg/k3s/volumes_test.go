@@ -0,0 +1,49 @@
+package k3s
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListVolumes(t *testing.T) {
+	runner := newShellVM(t, "started")
+	runner.writeFile("/var/lib/rancher/k3s/storage/pvc-2_web_data/data", strings.Repeat("x", 64*1024))
+	runner.writeState("volumes", `{"items": [
+		{
+			"metadata": {"name": "pvc-2"},
+			"spec": {
+				"capacity": {"storage": "2Gi"},
+				"claimRef": {"namespace": "web", "name": "data"},
+				"storageClassName": "local-path",
+				"hostPath": {"path": "/var/lib/rancher/k3s/storage/pvc-2_web_data"}
+			}
+		},
+		{
+			"metadata": {"name": "pvc-1"},
+			"spec": {
+				"capacity": {"storage": "1Gi"},
+				"claimRef": {"namespace": "db", "name": "data"},
+				"storageClassName": "local-path",
+				"local": {"path": "/missing"}
+			}
+		},
+		{
+			"metadata": {"name": "nfs"},
+			"spec": {"capacity": {"storage": "10Gi"}, "nfs": {"server": "example.com", "path": "/"}}
+		}
+	]}`)
+
+	volumes, err := ListVolumes(runner)
+	require.NoError(t, err)
+	require.Len(t, volumes, 3)
+	assert.GreaterOrEqual(t, volumes[2].Used, int64(64*1024), "the disk usage of the volume should be measured")
+	volumes[2].Used = 0
+	assert.Equal(t, []Volume{
+		{Name: "nfs", Capacity: "10Gi", Used: -1},
+		{Name: "pvc-1", Namespace: "db", Claim: "data", StorageClass: "local-path", Capacity: "1Gi", Path: "/missing", Used: -1},
+		{Name: "pvc-2", Namespace: "web", Claim: "data", StorageClass: "local-path", Capacity: "2Gi", Path: "/var/lib/rancher/k3s/storage/pvc-2_web_data", Used: 0},
+	}, volumes)
+}
@@ -0,0 +1,61 @@
+// Package kubectl runs the kubectl binary shipped with Rancher Desktop.
+package kubectl
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/profile"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/tools"
+)
+
+// Context is the name of the kubeconfig context for the Rancher Desktop cluster.
+const Context = "rancher-desktop"
+
+// Runner runs kubectl commands; it exists so that callers can be tested
+// without a cluster.
+type Runner interface {
+	// Output runs kubectl with the given arguments and returns its standard
+	// output. The error includes anything kubectl wrote to standard error.
+	Output(stdin io.Reader, args ...string) ([]byte, error)
+}
+
+// Kubectl is a Runner that executes the kubectl binary.
+type Kubectl struct {
+	Path string
+}
+
+// New locates the kubectl shipped with Rancher Desktop.
+func New(appPaths paths.Paths) (*Kubectl, error) {
+	kubectlPath, err := tools.Find(appPaths, "kubectl")
+	if err != nil {
+		return nil, err
+	}
+	return &Kubectl{Path: kubectlPath}, nil
+}
+
+func (k *Kubectl) Output(stdin io.Reader, args ...string) ([]byte, error) {
+	// Only the subcommand is mentioned, as the remaining arguments may
+	// contain credentials.
+	subcommand := ""
+	if len(args) > 0 {
+		subcommand = " " + args[0]
+	}
+	defer profile.Start(profile.Process, "kubectl"+subcommand)()
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(k.Path, args...)
+	cmd.Stdin = stdin
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return nil, fmt.Errorf("kubectl%s failed: %w: %s", subcommand, err, message)
+		}
+		return nil, fmt.Errorf("kubectl%s failed: %w", subcommand, err)
+	}
+	return stdout.Bytes(), nil
+}
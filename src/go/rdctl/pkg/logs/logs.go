@@ -0,0 +1,302 @@
+// Package logs finds, tails, and bundles the log files Rancher Desktop writes
+// to its logs directory. The application writes a file per topic, such as
+// background.log for the main process; the VM writes k3s.log there too, as
+// the directory is shared with it, and the logs of Lima are linked into it.
+package logs
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/redact"
+)
+
+// ErrUnknownComponent is returned for a component without any log files.
+var ErrUnknownComponent = errors.New("unknown component")
+
+// components maps the names of the components that aren't topics of the
+// application to the patterns of their log files.
+var components = map[string][]string{
+	"main": {"background.log"},
+	"k3s":  {"k3s.log"},
+	"lima": {"lima.log", "lima.*.log"},
+	"wsl":  {"wsl.log", "wsl-*.log"},
+}
+
+// Components returns the names of the components with log files in the
+// directory: the known components, and the topics of the other log files.
+func Components(logsDir string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(logsDir, "*.log"))
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	claimed := map[string]bool{}
+	for name := range components {
+		matches, err := Files(logsDir, name)
+		if err != nil && !errors.Is(err, ErrUnknownComponent) {
+			return nil, err
+		}
+		for _, match := range matches {
+			claimed[match] = true
+		}
+		if len(matches) > 0 || platformComponent(name) {
+			names = append(names, name)
+		}
+	}
+	for _, file := range files {
+		if !claimed[file] {
+			names = append(names, strings.TrimSuffix(filepath.Base(file), ".log"))
+		}
+	}
+	slices.Sort(names)
+	return names, nil
+}
+
+// platformComponent returns whether the component exists on this platform,
+// whether or not it has written logs yet.
+func platformComponent(name string) bool {
+	switch name {
+	case "lima":
+		return runtime.GOOS != "windows"
+	case "wsl":
+		return runtime.GOOS == "windows"
+	}
+	return true
+}
+
+// Files returns the paths of the log files of the component, which is either
+// a known component or the topic of a log file.
+func Files(logsDir, component string) ([]string, error) {
+	patterns, ok := components[component]
+	if !ok {
+		if component == "" || strings.ContainsAny(component, `/\*?[`) {
+			return nil, fmt.Errorf("%w %q", ErrUnknownComponent, component)
+		}
+		patterns = []string{component + ".log"}
+	}
+	var files []string
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(filepath.Join(logsDir, pattern))
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, matches...)
+	}
+	if len(files) == 0 {
+		if !ok {
+			return nil, fmt.Errorf("%w %q: there is no %s.log in %s", ErrUnknownComponent, component, component, logsDir)
+		}
+		return nil, fmt.Errorf("%w %q: it hasn't written any logs to %s", ErrUnknownComponent, component, logsDir)
+	}
+	slices.Sort(files)
+	return slices.Compact(files), nil
+}
+
+// timestampRE matches the timestamps of the lines of the logs: the
+// application and Lima write RFC 3339 timestamps, and k3s writes them with a
+// space instead of the "T" and without the time zone.
+var timestampRE = regexp.MustCompile(`\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:?\d{2})?`)
+
+// lineTime returns the time of the first timestamp in the line, if any; the
+// timestamps without a time zone are in local time.
+func lineTime(line string) (time.Time, bool) {
+	match := timestampRE.FindStringSubmatch(line)
+	if match == nil {
+		return time.Time{}, false
+	}
+	value := strings.Replace(match[0], " ", "T", 1)
+	layout, location := "2006-01-02T15:04:05.999999999", time.Local
+	if match[2] != "" {
+		layout, location = "2006-01-02T15:04:05.999999999Z07:00", time.UTC
+		if !strings.Contains(match[2], ":") && match[2] != "Z" {
+			layout = "2006-01-02T15:04:05.999999999Z0700"
+		}
+	}
+	t, err := time.ParseInLocation(layout, value, location)
+	return t, err == nil
+}
+
+// Options select the lines of a log file to write.
+type Options struct {
+	// Since drops the lines older than it, unless it is zero. Lines without
+	// a timestamp, such as the rest of a stack trace, go with the line
+	// before them.
+	Since time.Time
+	// Tail limits the lines to the last ones, unless it is negative.
+	Tail int
+}
+
+// Write writes the complete lines of the log file selected by the options,
+// and returns the offset following the last one, to follow the file from.
+func Write(w io.Writer, file string, options Options) (int64, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	var lines []string
+	var offset int64
+	keep := options.Since.IsZero()
+	reader := bufio.NewReader(f)
+	for {
+		line, err := reader.ReadString('\n')
+		// An incomplete last line is left for Follow, once it is complete.
+		if err == nil {
+			if t, ok := lineTime(line); ok && !options.Since.IsZero() {
+				keep = !t.Before(options.Since)
+			}
+			if keep {
+				lines = append(lines, line)
+				if options.Tail >= 0 && len(lines) > options.Tail {
+					lines = lines[1:]
+				}
+			}
+			offset += int64(len(line))
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return 0, fmt.Errorf("failed to read %s: %w", file, err)
+		}
+	}
+	for _, line := range lines {
+		if _, err := io.WriteString(w, line); err != nil {
+			return 0, err
+		}
+	}
+	return offset, nil
+}
+
+// Follow writes the lines appended to the log files from their offsets on,
+// until the context is done; with more than one file, a header names the file
+// the lines are from, as tail does. It starts a file from the beginning again
+// if it is truncated or replaced, as rotating the logs does.
+func Follow(ctx context.Context, w io.Writer, offsets map[string]int64, interval time.Duration) error {
+	files := make([]string, 0, len(offsets))
+	for file := range offsets {
+		files = append(files, file)
+	}
+	slices.Sort(files)
+	last := ""
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		for _, file := range files {
+			data, offset, err := readFrom(file, offsets[file])
+			if err != nil {
+				return err
+			}
+			offsets[file] = offset
+			if len(data) == 0 {
+				continue
+			}
+			if len(files) > 1 && file != last {
+				fmt.Fprintf(w, "\n==> %s <==\n", file)
+				last = file
+			}
+			if _, err := w.Write(data); err != nil {
+				return err
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// readFrom returns the complete lines of the file from the offset, and the
+// offset to continue from.
+func readFrom(file string, offset int64) ([]byte, int64, error) {
+	f, err := os.Open(file)
+	if errors.Is(err, os.ErrNotExist) {
+		// The file is being rotated.
+		return nil, 0, nil
+	} else if err != nil {
+		return nil, offset, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, offset, err
+	}
+	if info.Size() < offset {
+		offset = 0
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, offset, err
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, offset, fmt.Errorf("failed to read %s: %w", file, err)
+	}
+	// Leave the last line for later until it is complete.
+	data = data[:bytes.LastIndexByte(data, '\n')+1]
+	return data, offset + int64(len(data)), nil
+}
+
+// WriteZip writes a zip archive of all the files of the logs directory, for
+// attaching to bug reports. The logs linked into the directory are included;
+// the secrets in them are redacted, as the application does for its own logs.
+func WriteZip(w io.Writer, logsDir string) error {
+	archive := zip.NewWriter(w)
+	err := filepath.WalkDir(logsDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		// Stat the file itself, not the link to it.
+		info, err := os.Stat(path)
+		if errors.Is(err, os.ErrNotExist) {
+			// A dangling link, or a file removed by rotating the logs.
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		name, err := filepath.Rel(logsDir, path)
+		if err != nil {
+			return err
+		}
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(name)
+		header.Method = zip.Deflate
+		writer, err := archive.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = redact.Copy(writer, f)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to archive %s: %w", logsDir, err)
+	}
+	return archive.Close()
+}
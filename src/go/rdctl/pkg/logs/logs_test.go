@@ -0,0 +1,223 @@
+package logs
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/redact"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeLogFiles(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, contents := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(contents), 0o644))
+	}
+	return dir
+}
+
+func TestFiles(t *testing.T) {
+	dir := writeLogFiles(t, map[string]string{
+		"background.log":      "",
+		"lima.log":            "",
+		"lima.ha.stderr.log":  "",
+		"lima.serial.log":     "",
+		"images.log":          "",
+		"boot-failure.json":   "",
+		"wsl-helper.rd.log":   "",
+		"wsl.log":             "",
+		"networking-ca.log":   "",
+		"k3s.log":             "",
+		"diagnostics.log.old": "",
+	})
+	t.Run("components", func(t *testing.T) {
+		files, err := Files(dir, "lima")
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			filepath.Join(dir, "lima.ha.stderr.log"),
+			filepath.Join(dir, "lima.log"),
+			filepath.Join(dir, "lima.serial.log"),
+		}, files)
+		files, err = Files(dir, "main")
+		require.NoError(t, err)
+		assert.Equal(t, []string{filepath.Join(dir, "background.log")}, files)
+	})
+	t.Run("topics", func(t *testing.T) {
+		files, err := Files(dir, "networking-ca")
+		require.NoError(t, err)
+		assert.Equal(t, []string{filepath.Join(dir, "networking-ca.log")}, files)
+	})
+	t.Run("unknown", func(t *testing.T) {
+		for _, component := range []string{"missing", "../k3s", "*", "diagnostics"} {
+			_, err := Files(dir, component)
+			assert.ErrorIs(t, err, ErrUnknownComponent, component)
+		}
+	})
+	t.Run("list", func(t *testing.T) {
+		components, err := Components(dir)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"images", "k3s", "lima", "main", "networking-ca", "wsl"}, components)
+	})
+}
+
+func TestComponentsWithoutLogs(t *testing.T) {
+	components, err := Components(t.TempDir())
+	require.NoError(t, err)
+	if runtime.GOOS == "windows" {
+		assert.Equal(t, []string{"k3s", "main", "wsl"}, components)
+	} else {
+		assert.Equal(t, []string{"k3s", "lima", "main"}, components)
+	}
+}
+
+func TestLineTime(t *testing.T) {
+	for line, expected := range map[string]time.Time{
+		"2024-01-02T03:04:05.678Z: Starting":                    time.Date(2024, 1, 2, 3, 4, 5, 678000000, time.UTC),
+		`{"level":"info","time":"2024-01-02T04:04:05+01:00"}`:   time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		`time="2024-01-02T03:04:05Z" level=info msg="Starting"`: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		"2024-01-02 03:04:05 k3s starting":                      time.Date(2024, 1, 2, 3, 4, 5, 0, time.Local),
+	} {
+		actual, ok := lineTime(line)
+		if assert.True(t, ok, line) {
+			assert.True(t, expected.Equal(actual), "%s: %s", line, actual)
+		}
+	}
+	_, ok := lineTime("    at Object.<anonymous> (main.js:1:1)")
+	assert.False(t, ok)
+}
+
+func TestWrite(t *testing.T) {
+	dir := writeLogFiles(t, map[string]string{"background.log": `2024-01-02T03:00:00.000Z: first
+2024-01-02T03:10:00.000Z: second
+Error: failed
+    at main.js:1:1
+2024-01-02T03:20:00.000Z: third
+2024-01-02T03:30:00.000Z: incomplete`})
+	file := filepath.Join(dir, "background.log")
+	const completeLength = 130
+	testCases := []struct {
+		name     string
+		options  Options
+		expected string
+	}{
+		{
+			name:    "all",
+			options: Options{Tail: -1},
+			expected: `2024-01-02T03:00:00.000Z: first
+2024-01-02T03:10:00.000Z: second
+Error: failed
+    at main.js:1:1
+2024-01-02T03:20:00.000Z: third
+`,
+		},
+		{
+			name:     "tail",
+			options:  Options{Tail: 2},
+			expected: "    at main.js:1:1\n2024-01-02T03:20:00.000Z: third\n",
+		},
+		{
+			name:    "since",
+			options: Options{Since: time.Date(2024, 1, 2, 3, 5, 0, 0, time.UTC), Tail: -1},
+			expected: `2024-01-02T03:10:00.000Z: second
+Error: failed
+    at main.js:1:1
+2024-01-02T03:20:00.000Z: third
+`,
+		},
+		{
+			name:     "since and tail",
+			options:  Options{Since: time.Date(2024, 1, 2, 3, 5, 0, 0, time.UTC), Tail: 0},
+			expected: "",
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			var output bytes.Buffer
+			offset, err := Write(&output, file, testCase.options)
+			require.NoError(t, err)
+			assert.Equal(t, testCase.expected, output.String())
+			assert.Equal(t, int64(completeLength), offset)
+		})
+	}
+}
+
+func TestFollow(t *testing.T) {
+	dir := writeLogFiles(t, map[string]string{"k3s.log": "old\n", "lima.log": ""})
+	k3s, lima := filepath.Join(dir, "k3s.log"), filepath.Join(dir, "lima.log")
+	appendTo := func(file, text string) {
+		f, err := os.OpenFile(file, os.O_APPEND|os.O_WRONLY, 0)
+		require.NoError(t, err)
+		_, err = f.WriteString(text)
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	reader, writer := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		done <- Follow(ctx, writer, map[string]int64{k3s: 4, lima: 0}, 10*time.Millisecond)
+		writer.Close()
+	}()
+	appendTo(k3s, "new\npart")
+	expect := func(expected string) {
+		actual := make([]byte, len(expected))
+		_, err := io.ReadFull(reader, actual)
+		require.NoError(t, err)
+		assert.Equal(t, expected, string(actual))
+	}
+	expect("\n==> " + k3s + " <==\nnew\n")
+	appendTo(k3s, "ial\n")
+	expect("partial\n")
+	// Rotating the logs starts the file again.
+	require.NoError(t, os.WriteFile(k3s, []byte("rotated\n"), 0o644))
+	expect("rotated\n")
+	appendTo(lima, "started\n")
+	expect("\n==> " + lima + " <==\nstarted\n")
+	cancel()
+	rest, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Empty(t, strings.TrimSpace(string(rest)))
+	assert.NoError(t, <-done)
+}
+
+func TestWriteZip(t *testing.T) {
+	dir := writeLogFiles(t, map[string]string{"background.log": "main\n", "k3s.log": "k3s\nK3S_TOKEN=K10abc\n"})
+	expected := map[string]string{
+		"background.log":          "main\n",
+		"k3s.log":                 "k3s\nK3S_TOKEN=" + redact.Redacted + "\n",
+		"previous/background.log": "previous\n",
+	}
+	if runtime.GOOS != "windows" {
+		// The logs of Lima are linked into the directory.
+		limaDir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(limaDir, "serial.log"), []byte("serial\n"), 0o644))
+		require.NoError(t, os.Symlink(filepath.Join(limaDir, "serial.log"), filepath.Join(dir, "lima.serial.log")))
+		require.NoError(t, os.Symlink(filepath.Join(limaDir, "missing.log"), filepath.Join(dir, "lima.missing.log")))
+		expected["lima.serial.log"] = "serial\n"
+	}
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "previous"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "previous", "background.log"), []byte("previous\n"), 0o644))
+
+	var archive bytes.Buffer
+	require.NoError(t, WriteZip(&archive, dir))
+	reader, err := zip.NewReader(bytes.NewReader(archive.Bytes()), int64(archive.Len()))
+	require.NoError(t, err)
+	contents := map[string]string{}
+	for _, file := range reader.File {
+		f, err := file.Open()
+		require.NoError(t, err)
+		data, err := io.ReadAll(f)
+		require.NoError(t, err)
+		contents[file.Name] = string(data)
+	}
+	assert.Equal(t, expected, contents)
+}
@@ -0,0 +1,247 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// jsonPathTemplate is a parsed kubectl-style JSONPath template, such as
+// `{.kubernetes.version}` or `name={.items[*].name}`. Text outside of braces
+// is written verbatim; each expression inside braces is evaluated against the
+// data and its results are written separated by spaces.
+//
+// Supported expressions are quoted string literals (e.g. `{"\n"}`) and paths
+// made from `.field`, `['field']`, `[index]`, `[*]` and `.*` segments,
+// optionally starting with `$`.
+type jsonPathTemplate struct {
+	parts []jsonPathPart
+}
+
+type jsonPathPart struct {
+	// Literal text; only used if path is nil.
+	text string
+	path []jsonPathSegment
+}
+
+type jsonPathSegment struct {
+	// A field name; only used if neither index nor wildcard are set.
+	field    string
+	index    *int
+	wildcard bool
+}
+
+func parseJSONPath(template string) (*jsonPathTemplate, error) {
+	result := &jsonPathTemplate{}
+	rest := template
+	for rest != "" {
+		start := strings.Index(rest, "{")
+		if start < 0 {
+			result.parts = append(result.parts, jsonPathPart{text: rest})
+			break
+		}
+		if start > 0 {
+			result.parts = append(result.parts, jsonPathPart{text: rest[:start]})
+		}
+		end := matchingBracket(rest[start:], '{', '}')
+		if end < 0 {
+			return nil, fmt.Errorf("unclosed expression in JSONPath template %q", template)
+		}
+		expression := strings.TrimSpace(rest[start+1 : start+end])
+		rest = rest[start+end+1:]
+		if strings.HasPrefix(expression, `"`) {
+			text, err := strconv.Unquote(expression)
+			if err != nil {
+				return nil, fmt.Errorf("invalid string literal %s in JSONPath template: %w", expression, err)
+			}
+			result.parts = append(result.parts, jsonPathPart{text: text})
+			continue
+		}
+		path, err := parseJSONPathExpression(expression)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JSONPath expression %q: %w", expression, err)
+		}
+		result.parts = append(result.parts, jsonPathPart{path: path})
+	}
+	return result, nil
+}
+
+func parseJSONPathExpression(expression string) ([]jsonPathSegment, error) {
+	path := []jsonPathSegment{}
+	rest := strings.TrimPrefix(expression, "$")
+	if rest == "" {
+		return path, nil
+	}
+	for rest != "" {
+		switch {
+		case strings.HasPrefix(rest, ".."):
+			return nil, fmt.Errorf("recursive descent is not supported")
+		case strings.HasPrefix(rest, "."):
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			field := rest[:end]
+			rest = rest[end:]
+			switch field {
+			case "":
+				if rest != "" {
+					return nil, fmt.Errorf("empty field name")
+				}
+			case "*":
+				path = append(path, jsonPathSegment{wildcard: true})
+			default:
+				path = append(path, jsonPathSegment{field: field})
+			}
+		case strings.HasPrefix(rest, "["):
+			end := matchingBracket(rest, '[', ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed bracket")
+			}
+			subscript := strings.TrimSpace(rest[1:end])
+			rest = rest[end+1:]
+			switch {
+			case subscript == "*":
+				path = append(path, jsonPathSegment{wildcard: true})
+			case strings.HasPrefix(subscript, "'") && strings.HasSuffix(subscript, "'") && len(subscript) >= 2:
+				path = append(path, jsonPathSegment{field: subscript[1 : len(subscript)-1]})
+			case strings.HasPrefix(subscript, `"`):
+				field, err := strconv.Unquote(subscript)
+				if err != nil {
+					return nil, fmt.Errorf("invalid subscript %s: %w", subscript, err)
+				}
+				path = append(path, jsonPathSegment{field: field})
+			default:
+				index, err := strconv.Atoi(subscript)
+				if err != nil {
+					return nil, fmt.Errorf("invalid subscript %q", subscript)
+				}
+				path = append(path, jsonPathSegment{index: &index})
+			}
+		default:
+			return nil, fmt.Errorf("expected '.' or '[' at %q", rest)
+		}
+	}
+	return path, nil
+}
+
+// matchingBracket returns the index of the bracket closing the one s starts
+// with, skipping nested brackets and quoted strings, such as the field names in
+// `{.labels['a}b']}`; it returns -1 if the bracket isn't closed.
+func matchingBracket(s string, open, close byte) int {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			// Only double-quoted strings have escapes, as in strconv.Unquote.
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == open:
+			depth++
+		case c == close:
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// evaluate returns all the values that the path selects from the data.
+func evaluateJSONPath(path []jsonPathSegment, data any) ([]any, error) {
+	current := []any{data}
+	for _, segment := range path {
+		var next []any
+		for _, value := range current {
+			switch typed := value.(type) {
+			case map[string]any:
+				if segment.wildcard {
+					keys := make([]string, 0, len(typed))
+					for key := range typed {
+						keys = append(keys, key)
+					}
+					sort.Strings(keys)
+					for _, key := range keys {
+						next = append(next, typed[key])
+					}
+				} else if segment.index != nil {
+					return nil, fmt.Errorf("can't index an object with [%d]", *segment.index)
+				} else if child, ok := typed[segment.field]; ok {
+					next = append(next, child)
+				} else {
+					return nil, fmt.Errorf("%s is not found", segment.field)
+				}
+			case []any:
+				if segment.wildcard {
+					next = append(next, typed...)
+				} else if segment.index != nil {
+					index := *segment.index
+					if index < 0 {
+						index += len(typed)
+					}
+					if index < 0 || index >= len(typed) {
+						return nil, fmt.Errorf("array index %d is out of bounds", *segment.index)
+					}
+					next = append(next, typed[index])
+				} else {
+					return nil, fmt.Errorf("can't select field %q of an array", segment.field)
+				}
+			default:
+				return nil, fmt.Errorf("can't select from a %T value", value)
+			}
+		}
+		current = next
+	}
+	return current, nil
+}
+
+func (t *jsonPathTemplate) execute(w io.Writer, data any) error {
+	for _, part := range t.parts {
+		if part.path == nil {
+			if _, err := io.WriteString(w, part.text); err != nil {
+				return err
+			}
+			continue
+		}
+		values, err := evaluateJSONPath(part.path, data)
+		if err != nil {
+			return err
+		}
+		strs := make([]string, 0, len(values))
+		for _, value := range values {
+			str, err := formatJSONPathValue(value)
+			if err != nil {
+				return err
+			}
+			strs = append(strs, str)
+		}
+		if _, err := io.WriteString(w, strings.Join(strs, " ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// formatJSONPathValue prints strings without quotes, and everything else as JSON.
+func formatJSONPathValue(value any) (string, error) {
+	if str, ok := value.(string); ok {
+		return str, nil
+	}
+	result, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(result), nil
+}
@@ -0,0 +1,152 @@
+// Package output implements the shared handling of the `--output` flag, so
+// that commands producing structured data can be filtered with JSONPath or Go
+// templates without needing external tools such as jq.
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// JSON outputs the data as JSON.
+	JSON = "json"
+	// YAML outputs the data as YAML.
+	YAML = "yaml"
+	// JSONPathPrefix introduces a kubectl-style JSONPath template.
+	JSONPathPrefix = "jsonpath="
+	// GoTemplatePrefix introduces a Go text/template.
+	GoTemplatePrefix = "go-template="
+)
+
+// Formatter writes structured data in the format selected by the user.
+type Formatter struct {
+	// Format is the name of the output format, without any template.
+	Format     string
+	jsonPath   *jsonPathTemplate
+	goTemplate *template.Template
+}
+
+// AddFlag registers the `--output` flag on the given flag set. The formats
+// are the names of command-specific formats (the first being the default),
+// in addition to the JSONPath and Go template formats that are always
+// available.
+func AddFlag(flags *pflag.FlagSet, target *string, formats ...string) {
+	defaultFormat := ""
+	if len(formats) > 0 {
+		defaultFormat = formats[0]
+	}
+	choices := append(append([]string{}, formats...), JSONPathPrefix+"TEMPLATE", GoTemplatePrefix+"TEMPLATE")
+	flags.StringVarP(target, "output", "o", defaultFormat, fmt.Sprintf("output format: %s", strings.Join(choices, "|")))
+}
+
+// NewFormatter parses an output format specification. Plain format names
+// must be one of the given formats; they are returned as-is in the Format
+// field, and the caller is responsible for handling any of them other than
+// JSON and YAML.
+func NewFormatter(spec string, formats ...string) (*Formatter, error) {
+	switch {
+	case strings.HasPrefix(spec, JSONPathPrefix):
+		jsonPath, err := parseJSONPath(strings.TrimPrefix(spec, JSONPathPrefix))
+		if err != nil {
+			return nil, err
+		}
+		return &Formatter{Format: "jsonpath", jsonPath: jsonPath}, nil
+	case strings.HasPrefix(spec, GoTemplatePrefix):
+		goTemplate, err := template.New("output").Funcs(templateFuncs).Parse(strings.TrimPrefix(spec, GoTemplatePrefix))
+		if err != nil {
+			return nil, fmt.Errorf("invalid Go template: %w", err)
+		}
+		return &Formatter{Format: "go-template", goTemplate: goTemplate}, nil
+	}
+	for _, format := range formats {
+		if spec == format {
+			return &Formatter{Format: spec}, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid output format %q: must be one of %s, %s..., or %s...",
+		spec, strings.Join(formats, ", "), JSONPathPrefix, GoTemplatePrefix)
+}
+
+// IsTemplate returns whether the formatter applies a JSONPath or Go template.
+func (f *Formatter) IsTemplate() bool {
+	return f.jsonPath != nil || f.goTemplate != nil
+}
+
+// Write renders the data, which is either raw JSON (as a []byte or
+// json.RawMessage) or any value that can be marshalled to JSON. Templates
+// are evaluated against the generic JSON representation of the data, so
+// field names are the JSON ones. A trailing newline is always written.
+func (f *Formatter) Write(w io.Writer, data any) error {
+	raw, err := toJSON(data)
+	if err != nil {
+		return err
+	}
+	if f.Format == YAML {
+		var generic any
+		if err := json.Unmarshal(raw, &generic); err != nil {
+			return fmt.Errorf("failed to parse JSON output: %w", err)
+		}
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(generic); err != nil {
+			return fmt.Errorf("failed to format YAML output: %w", err)
+		}
+		return encoder.Close()
+	}
+	if !f.IsTemplate() {
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, raw, "", "  "); err != nil {
+			return fmt.Errorf("failed to format JSON output: %w", err)
+		}
+		buf.WriteString("\n")
+		_, err := w.Write(buf.Bytes())
+		return err
+	}
+	var generic any
+	if err := json.Unmarshal(raw, &generic); err != nil {
+		return fmt.Errorf("failed to parse JSON output: %w", err)
+	}
+	var buf bytes.Buffer
+	if f.jsonPath != nil {
+		err = f.jsonPath.execute(&buf, generic)
+	} else {
+		err = f.goTemplate.Execute(&buf, generic)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to apply %s output format: %w", f.Format, err)
+	}
+	if !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+		buf.WriteString("\n")
+	}
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+func toJSON(data any) ([]byte, error) {
+	switch typed := data.(type) {
+	case []byte:
+		return typed, nil
+	case json.RawMessage:
+		return typed, nil
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert output to JSON: %w", err)
+	}
+	return raw, nil
+}
+
+var templateFuncs = template.FuncMap{
+	"json": func(value any) (string, error) {
+		result, err := json.Marshal(value)
+		return string(result), err
+	},
+}
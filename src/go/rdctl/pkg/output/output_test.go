@@ -0,0 +1,110 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+)
+
+const testSettings = `{
+  "kubernetes": {"version": "1.27.3", "enabled": true, "port": 6443},
+  "containerEngine": {"name": "moby"},
+  "WSL": {"integrations": {"Ubuntu": true, "Debian": false}},
+  "snapshots": [{"name": "first"}, {"name": "second"}],
+  "labels": {"{a}": "braces", "[b]": "brackets"}
+}`
+
+func TestFormatter(t *testing.T) {
+	testCases := []struct {
+		Spec     string
+		Expected string
+	}{
+		{"jsonpath={.kubernetes.version}", "1.27.3\n"},
+		{"jsonpath={.kubernetes.port}", "6443\n"},
+		{"jsonpath={$.kubernetes.enabled}", "true\n"},
+		{"jsonpath={.containerEngine}", `{"name":"moby"}` + "\n"},
+		{"jsonpath={['containerEngine'].name}", "moby\n"},
+		{"jsonpath={.WSL.integrations.*}", "false true\n"},
+		{"jsonpath={.snapshots[*].name}", "first second\n"},
+		{"jsonpath={.snapshots[-1].name}", "second\n"},
+		{`jsonpath=engine={.containerEngine.name}{"\n"}`, "engine=moby\n"},
+		{"jsonpath={.labels['{a}']}", "braces\n"},
+		{`jsonpath={.labels["[b]"]}`, "brackets\n"},
+		{`jsonpath={"}\"{"}{.containerEngine.name}`, "}\"{moby\n"},
+		{"go-template={{.kubernetes.version}}", "1.27.3\n"},
+		{"go-template={{range .snapshots}}{{.name}};{{end}}", "first;second;\n"},
+		{"go-template={{json .containerEngine}}", `{"name":"moby"}` + "\n"},
+		{"json", "{\n  \"containerEngine\": {\n    \"name\": \"moby\"\n  }\n}\n"},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.Spec, func(t *testing.T) {
+			formatter, err := NewFormatter(testCase.Spec, JSON)
+			if err != nil {
+				t.Fatalf("failed to parse format: %s", err)
+			}
+			input := []byte(testSettings)
+			if testCase.Spec == JSON {
+				input = []byte(`{"containerEngine":{"name":"moby"}}`)
+			}
+			var buf bytes.Buffer
+			if err := formatter.Write(&buf, input); err != nil {
+				t.Fatalf("failed to write output: %s", err)
+			}
+			if buf.String() != testCase.Expected {
+				t.Errorf("expected %q, got %q", testCase.Expected, buf.String())
+			}
+		})
+	}
+}
+
+func TestFormatterYAML(t *testing.T) {
+	formatter, err := NewFormatter(YAML, JSON, YAML)
+	if err != nil {
+		t.Fatalf("failed to parse format: %s", err)
+	}
+	var buf bytes.Buffer
+	if err := formatter.Write(&buf, []byte(`{"kubernetes": {"version": "1.27.3", "port": 6443}, "snapshots": [{"name": "first"}]}`)); err != nil {
+		t.Fatalf("failed to write output: %s", err)
+	}
+	expected := "kubernetes:\n  port: 6443\n  version: 1.27.3\nsnapshots:\n  - name: first\n"
+	if buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestFormatterErrors(t *testing.T) {
+	for _, spec := range []string{"yaml", "jsonpath={.a", "jsonpath={..a}", "jsonpath={.a[x]}", "go-template={{.a"} {
+		t.Run(spec, func(t *testing.T) {
+			if _, err := NewFormatter(spec, JSON); err == nil {
+				t.Errorf("expected an error parsing %q", spec)
+			}
+		})
+	}
+	for _, spec := range []string{"jsonpath={.missing}", "jsonpath={.snapshots[5]}", "jsonpath={.kubernetes[0]}"} {
+		t.Run(spec, func(t *testing.T) {
+			formatter, err := NewFormatter(spec, JSON)
+			if err != nil {
+				t.Fatalf("failed to parse format: %s", err)
+			}
+			if err := formatter.Write(&bytes.Buffer{}, []byte(testSettings)); err == nil {
+				t.Errorf("expected an error applying %q", spec)
+			}
+		})
+	}
+}
+
+func TestFormatterStructs(t *testing.T) {
+	data := []struct {
+		Name string `json:"name"`
+	}{{Name: "a"}, {Name: "b"}}
+	formatter, err := NewFormatter("jsonpath={[*].name}")
+	if err != nil {
+		t.Fatalf("failed to parse format: %s", err)
+	}
+	var buf bytes.Buffer
+	if err := formatter.Write(&buf, data); err != nil {
+		t.Fatalf("failed to write output: %s", err)
+	}
+	if buf.String() != "a b\n" {
+		t.Errorf(`expected "a b\n", got %q`, buf.String())
+	}
+}
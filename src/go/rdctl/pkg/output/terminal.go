@@ -0,0 +1,167 @@
+package output
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/pflag"
+	"golang.org/x/term"
+)
+
+// Color is an ANSI color code.
+type Color int
+
+const (
+	Red    Color = 31
+	Green  Color = 32
+	Yellow Color = 33
+)
+
+var globalSettings struct {
+	Quiet   bool
+	NoColor bool
+}
+
+// AddGlobalFlags registers the `--quiet` and `--no-color` flags, which
+// apply to all commands.
+func AddGlobalFlags(flags *pflag.FlagSet) {
+	flags.BoolVarP(&globalSettings.Quiet, "quiet", "q", false, "suppress informational messages and progress indicators")
+	flags.BoolVar(&globalSettings.NoColor, "no-color", false, "disable colored output (also disabled by setting NO_COLOR)")
+}
+
+// Configure applies the global output settings to the logger. It must be
+// called after the flags have been parsed.
+func Configure() {
+	for _, file := range []*os.File{os.Stdout, os.Stderr} {
+		if IsTerminal(file) {
+			// Windows consoles need to be told to interpret escape sequences;
+			// if that isn't supported, don't write any.
+			if err := enableVirtualTerminal(file); err != nil {
+				globalSettings.NoColor = true
+			}
+		}
+	}
+	if globalSettings.Quiet {
+		logrus.SetLevel(logrus.WarnLevel)
+	}
+	logrus.SetFormatter(&logrus.TextFormatter{DisableColors: !ColorEnabled(os.Stderr)})
+}
+
+// Quiet returns whether informational output should be suppressed.
+func Quiet() bool {
+	return globalSettings.Quiet
+}
+
+// IsTerminal returns whether the file is an interactive terminal.
+func IsTerminal(file *os.File) bool {
+	return term.IsTerminal(int(file.Fd()))
+}
+
+// ColorEnabled returns whether colored output should be written to the file:
+// it must be a terminal, and the user must not have disabled colors via
+// `--no-color`, the NO_COLOR environment variable, or TERM=dumb.
+func ColorEnabled(file *os.File) bool {
+	if globalSettings.NoColor || os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	return IsTerminal(file)
+}
+
+// Wrap surrounds the text with the escape sequences for the color.
+func (c Color) Wrap(text string) string {
+	return fmt.Sprintf("\x1b[%dm%s\x1b[0m", c, text)
+}
+
+// Colorize wraps the text in the escape sequences for the given color, if
+// colors are enabled for the file the text will be written to.
+func Colorize(file *os.File, color Color, text string) string {
+	if !ColorEnabled(file) {
+		return text
+	}
+	return color.Wrap(text)
+}
+
+// Infof writes an informational message to standard error, unless running
+// in quiet mode. A trailing newline is added.
+func Infof(format string, args ...any) {
+	if globalSettings.Quiet {
+		return
+	}
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+}
+
+// Progress is an indicator for a long-running operation. On a terminal, it
+// is shown as a spinner that is removed once the operation finishes;
+// otherwise, the message is written once so that logs stay clean.
+type Progress struct {
+	mutex   sync.Mutex
+	message string
+	done    chan struct{}
+	wg      sync.WaitGroup
+}
+
+var spinnerFrames = []string{"|", "/", "-", `\`}
+
+// StartProgress displays a progress indicator on standard error until Stop is
+// called. Nothing is displayed in quiet mode.
+func StartProgress(message string) *Progress {
+	progress := &Progress{message: message, done: make(chan struct{})}
+	if globalSettings.Quiet {
+		return progress
+	}
+	if !IsTerminal(os.Stderr) {
+		fmt.Fprintf(os.Stderr, "%s...\n", message)
+		return progress
+	}
+	progress.wg.Add(1)
+	go func() {
+		defer progress.wg.Done()
+		ticker := time.NewTicker(100 * time.Millisecond)
+		defer ticker.Stop()
+		for frame := 0; ; frame++ {
+			fmt.Fprintf(os.Stderr, "\r%s %s...\x1b[K", spinnerFrames[frame%len(spinnerFrames)], progress.getMessage())
+			select {
+			case <-progress.done:
+				// Erase the spinner line.
+				fmt.Fprint(os.Stderr, "\r\x1b[K")
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return progress
+}
+
+func (p *Progress) getMessage() string {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.message
+}
+
+// SetMessage changes the message of the progress indicator, e.g. to show how
+// much of the operation is done; when not on a terminal, the message is
+// written again if it changed.
+func (p *Progress) SetMessage(message string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if message == p.message {
+		return
+	}
+	p.message = message
+	if !globalSettings.Quiet && !IsTerminal(os.Stderr) {
+		fmt.Fprintf(os.Stderr, "%s...\n", message)
+	}
+}
+
+// Stop removes the progress indicator. It is safe to call more than once.
+func (p *Progress) Stop() {
+	select {
+	case <-p.done:
+	default:
+		close(p.done)
+	}
+	p.wg.Wait()
+}
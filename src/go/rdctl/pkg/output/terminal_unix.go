@@ -0,0 +1,11 @@
+//go:build unix
+
+package output
+
+import "os"
+
+// enableVirtualTerminal does nothing, as Unix terminals always interpret
+// escape sequences.
+func enableVirtualTerminal(file *os.File) error {
+	return nil
+}
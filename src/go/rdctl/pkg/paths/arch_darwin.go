@@ -0,0 +1,18 @@
+package paths
+
+import (
+	"runtime"
+
+	"golang.org/x/sys/unix"
+)
+
+// machineArch returns the architecture of the machine. An amd64 rdctl may be
+// translated by Rosetta on arm64 machines.
+func machineArch() string {
+	if runtime.GOARCH == "amd64" {
+		if translated, err := unix.SysctlUint32("sysctl.proc_translated"); err == nil && translated == 1 {
+			return "arm64"
+		}
+	}
+	return runtime.GOARCH
+}
@@ -0,0 +1,11 @@
+//go:build !darwin
+
+package paths
+
+import "runtime"
+
+// machineArch returns the architecture of the machine, which is assumed to be
+// the one rdctl was built for.
+func machineArch() string {
+	return runtime.GOARCH
+}
@@ -2,7 +2,6 @@ package paths
 
 import (
 	"fmt"
-	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/utils"
 	"os"
 	"path/filepath"
 )
@@ -49,5 +48,5 @@ func getResourcesPath() (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("failed to resolve %q: %w", rdctlSymlinkPath, err)
 	}
-	return utils.GetParentDir(rdctlPath, 3), nil
+	return resourcesDirFor(rdctlPath), nil
 }
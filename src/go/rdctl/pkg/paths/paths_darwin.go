@@ -5,9 +5,12 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/profile"
 )
 
 func GetPaths(getResourcesPathFuncs ...func() (string, error)) (Paths, error) {
+	defer profile.Start(profile.Paths, "resolve")()
 	var getResourcesPathFunc func() (string, error)
 	switch len(getResourcesPathFuncs) {
 	case 0:
@@ -77,4 +77,23 @@ func TestGetPaths(t *testing.T) {
 			t.Errorf("Actual paths does not match expected paths\nActual paths: %#v\nExpected paths: %#v", actualPaths, expectedPaths)
 		}
 	})
+	t.Run("should support long and UNC paths", func(t *testing.T) {
+		for _, localAppData := range []string{
+			`\\?\C:\Users\me\AppData\Local`,
+			`\\server\profiles\me\AppData\Local`,
+		} {
+			t.Setenv("RD_LOGS_DIR", "")
+			t.Setenv("LOCALAPPDATA", localAppData)
+			actualPaths, err := GetPaths(mockGetResourcesPath)
+			if err != nil {
+				t.Fatalf("Unexpected error getting actual paths: %s", err)
+			}
+			if expected := localAppData + `\` + appName; actualPaths.AppHome != expected {
+				t.Errorf("Expected app home %q, got %q", expected, actualPaths.AppHome)
+			}
+			if expected := localAppData + `\` + appName + `\logs`; actualPaths.Logs != expected {
+				t.Errorf("Expected logs %q, got %q", expected, actualPaths.Logs)
+			}
+		}
+	})
 }
@@ -0,0 +1,82 @@
+package paths
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// archNames maps the Go names of architectures to the names of the
+// directories that may hold resources built for them.
+var archNames = map[string][]string{
+	"amd64": {"amd64", "x86_64"},
+	"arm64": {"arm64", "aarch64"},
+}
+
+// PlatformDir returns the name of the directory holding the resources for
+// this platform.
+func PlatformDir() string {
+	if runtime.GOOS == "windows" {
+		return "win32"
+	}
+	return runtime.GOOS
+}
+
+// Architectures returns the architectures whose binaries can run, preferred
+// first: the one of the machine, which differs from the one rdctl was built
+// for when it is translated by Rosetta, followed by the one of rdctl.
+func Architectures() []string {
+	machine := machineArch()
+	if machine == runtime.GOARCH {
+		return []string{machine}
+	}
+	return []string{machine, runtime.GOARCH}
+}
+
+// ResourceDirs returns the directories that may hold the resources of the
+// kind ("bin" or "internal") for this platform, most specific first: the
+// per-architecture directories (e.g. resources/darwin/arm64/bin), then the
+// one shared by all architectures (resources/darwin/bin).
+func (p Paths) ResourceDirs(kind string) []string {
+	platformDir := filepath.Join(p.Resources, PlatformDir())
+	var dirs []string
+	for _, arch := range Architectures() {
+		for _, name := range archNames[arch] {
+			dirs = append(dirs, filepath.Join(platformDir, name, kind))
+		}
+	}
+	return append(dirs, filepath.Join(platformDir, kind))
+}
+
+// FindResource returns the path to the named file in the first of the
+// resource directories of the kind that has it.
+func (p Paths) FindResource(kind, name string) (string, error) {
+	dirs := p.ResourceDirs(kind)
+	for _, dir := range dirs {
+		candidate := filepath.Join(dir, name)
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate, nil
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("failed to check %q: %w", candidate, err)
+		}
+	}
+	return "", fmt.Errorf("could not find %s in %s", name, strings.Join(dirs, ", "))
+}
+
+// resourcesDirFor returns the resources directory of the installation of the
+// rdctl executable, which is in either resources/<platform>/bin or
+// resources/<platform>/<arch>/bin.
+func resourcesDirFor(rdctlPath string) string {
+	dir := filepath.Dir(filepath.Dir(filepath.Clean(rdctlPath)))
+	for _, names := range archNames {
+		for _, name := range names {
+			if filepath.Base(dir) == name {
+				dir = filepath.Dir(dir)
+			}
+		}
+	}
+	return filepath.Dir(dir)
+}
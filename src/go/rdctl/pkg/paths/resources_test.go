@@ -0,0 +1,63 @@
+package paths
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResourceDirs(t *testing.T) {
+	paths := Paths{Resources: fakeResourcesPath}
+	dirs := paths.ResourceDirs("bin")
+	platformDir := filepath.Join(fakeResourcesPath, PlatformDir())
+	if names, ok := archNames[runtime.GOARCH]; ok && machineArch() == runtime.GOARCH {
+		assert.Equal(t, filepath.Join(platformDir, names[0], "bin"), dirs[0])
+	}
+	assert.Equal(t, filepath.Join(platformDir, "bin"), dirs[len(dirs)-1])
+}
+
+func TestFindResource(t *testing.T) {
+	paths := Paths{Resources: t.TempDir()}
+	dirs := paths.ResourceDirs("internal")
+	shared := dirs[len(dirs)-1]
+	require.NoError(t, os.MkdirAll(shared, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(shared, "tool"), nil, 0o644))
+
+	t.Run("falls back to the shared directory", func(t *testing.T) {
+		result, err := paths.FindResource("internal", "tool")
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(shared, "tool"), result)
+	})
+	t.Run("prefers the architecture-specific directory", func(t *testing.T) {
+		if len(dirs) < 2 {
+			t.Skip("no architecture-specific directories on this architecture")
+		}
+		require.NoError(t, os.MkdirAll(dirs[0], 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dirs[0], "tool"), nil, 0o644))
+		result, err := paths.FindResource("internal", "tool")
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(dirs[0], "tool"), result)
+	})
+	t.Run("fails for missing resources", func(t *testing.T) {
+		_, err := paths.FindResource("internal", "missing")
+		assert.ErrorContains(t, err, "could not find missing")
+	})
+}
+
+func TestResourcesDirFor(t *testing.T) {
+	resources := filepath.Join("opt", "rancher-desktop", "resources", "resources")
+	testCases := map[string]string{
+		"shared":                filepath.Join(resources, "darwin", "bin", "rdctl"),
+		"architecture-specific": filepath.Join(resources, "darwin", "arm64", "bin", "rdctl"),
+		"alternate arch name":   filepath.Join(resources, "linux", "x86_64", "bin", "rdctl"),
+	}
+	for name, rdctlPath := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, resources, resourcesDirFor(rdctlPath))
+		})
+	}
+}
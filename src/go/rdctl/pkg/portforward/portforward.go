@@ -0,0 +1,231 @@
+// Package portforward forwards ports of the host to ports of the VM, so that
+// services listening only inside the VM can be reached. Each TCP connection,
+// or each UDP peer, is relayed through nc run in the VM, as rdctl shell runs
+// commands there, which works the same with Lima and WSL.
+package portforward
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// UDPIdleTimeout is how long the relay of a UDP peer is kept without
+// datagrams from it.
+const UDPIdleTimeout = 2 * time.Minute
+
+// Commander runs commands in the VM; *vm.VM is one.
+type Commander interface {
+	Command(args ...string) *exec.Cmd
+}
+
+// Forwarder forwards the connections it accepts to a port of the VM.
+type Forwarder struct {
+	VM Commander
+	// GuestPort is the port of the VM to forward to, on its loopback address.
+	GuestPort int
+	// Logf reports the connections and their errors, if set.
+	Logf func(format string, args ...any)
+}
+
+func (f *Forwarder) logf(format string, args ...any) {
+	if f.Logf != nil {
+		f.Logf(format, args...)
+	}
+}
+
+// guestCommand returns the command relaying the standard input and output
+// to the port of the VM.
+func (f *Forwarder) guestCommand(udp bool) []string {
+	args := []string{"nc"}
+	if udp {
+		args = append(args, "-u")
+	}
+	return append(args, "127.0.0.1", strconv.Itoa(f.GuestPort))
+}
+
+// relay is the nc process relaying the data of a peer.
+type relay struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stderr bytes.Buffer
+	// stopped is set when the relay is killed on purpose.
+	stopped atomic.Bool
+	// unregister stops the relay from being killed when the context is done.
+	unregister func() bool
+}
+
+// start starts the relay of a peer; the relay writes what the port of the VM
+// sends to output, until the context is done.
+func (f *Forwarder) start(ctx context.Context, udp bool, output io.Writer) (*relay, error) {
+	r := &relay{cmd: f.VM.Command(f.guestCommand(udp)...)}
+	r.cmd.Stdout = output
+	r.cmd.Stderr = &r.stderr
+	// limactl runs ssh, which may keep the output open after being killed.
+	r.cmd.WaitDelay = time.Second
+	stdin, err := r.cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	r.stdin = stdin
+	if err := r.cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start the relay in the VM: %w", err)
+	}
+	r.unregister = context.AfterFunc(ctx, r.stop)
+	return r, nil
+}
+
+// stop kills the relay.
+func (r *relay) stop() {
+	r.stopped.Store(true)
+	_ = r.cmd.Process.Kill()
+}
+
+// wait waits for the relay to exit; it returns the error of the relay, with
+// what it reported, unless it was stopped.
+func (r *relay) wait() error {
+	err := r.cmd.Wait()
+	r.unregister()
+	if err == nil || r.stopped.Load() {
+		return nil
+	}
+	if message := strings.TrimSpace(r.stderr.String()); message != "" {
+		return fmt.Errorf("%w: %s", err, message)
+	}
+	return err
+}
+
+// ServeTCP relays the connections accepted by the listener until the
+// context is done; the listener is then closed.
+func (f *Forwarder) ServeTCP(ctx context.Context, listener net.Listener) error {
+	stop := context.AfterFunc(ctx, func() { _ = listener.Close() })
+	defer stop()
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			f.handleTCP(ctx, conn)
+		}()
+	}
+}
+
+func (f *Forwarder) handleTCP(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+	f.logf("Handling connection from %s", conn.RemoteAddr())
+	r, err := f.start(ctx, false, conn)
+	if err != nil {
+		f.logf("Connection from %s: %v", conn.RemoteAddr(), err)
+		return
+	}
+	go func() {
+		_, _ = io.Copy(r.stdin, conn)
+		_ = r.stdin.Close()
+	}()
+	// The relay exits when the port of the VM closes the connection, or
+	// after the peer closes it; the peer may be still reading.
+	if err := r.wait(); err != nil {
+		f.logf("Connection from %s: %v", conn.RemoteAddr(), err)
+	}
+}
+
+// udpPeer is the relay of the datagrams of a UDP peer.
+type udpPeer struct {
+	relay *relay
+	timer *time.Timer
+}
+
+// udpWriter sends what the relay of a peer writes as datagrams to the peer.
+type udpWriter struct {
+	conn net.PacketConn
+	addr net.Addr
+}
+
+func (w udpWriter) Write(p []byte) (int, error) {
+	return w.conn.WriteTo(p, w.addr)
+}
+
+// ServeUDP relays the datagrams received on the connection until the context
+// is done; the connection is then closed. Each peer has its own relay, which
+// is stopped after UDPIdleTimeout without datagrams from the peer. The relay
+// may join datagrams that arrive faster than it sends them.
+func (f *Forwarder) ServeUDP(ctx context.Context, conn net.PacketConn) error {
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	peers := make(map[string]*udpPeer)
+	buffer := make([]byte, 65536)
+	for {
+		n, addr, err := conn.ReadFrom(buffer)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			if errors.Is(err, net.ErrClosed) {
+				return err
+			}
+			f.logf("Failed to receive a datagram: %v", err)
+			continue
+		}
+		mutex.Lock()
+		peer, ok := peers[addr.String()]
+		if !ok {
+			peer, err = f.startUDPPeer(ctx, conn, addr, &wg, func() {
+				mutex.Lock()
+				defer mutex.Unlock()
+				delete(peers, addr.String())
+			})
+			if err != nil {
+				mutex.Unlock()
+				f.logf("Datagram from %s: %v", addr, err)
+				continue
+			}
+			peers[addr.String()] = peer
+		}
+		peer.timer.Reset(UDPIdleTimeout)
+		mutex.Unlock()
+		if _, err := peer.relay.stdin.Write(buffer[:n]); err != nil {
+			f.logf("Datagram from %s: %v", addr, err)
+		}
+	}
+}
+
+// startUDPPeer starts the relay of a UDP peer; done is called once it exits.
+func (f *Forwarder) startUDPPeer(ctx context.Context, conn net.PacketConn, addr net.Addr, wg *sync.WaitGroup, done func()) (*udpPeer, error) {
+	f.logf("Handling datagrams from %s", addr)
+	r, err := f.start(ctx, true, udpWriter{conn: conn, addr: addr})
+	if err != nil {
+		return nil, err
+	}
+	peer := &udpPeer{relay: r, timer: time.AfterFunc(UDPIdleTimeout, r.stop)}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		err := r.wait()
+		peer.timer.Stop()
+		done()
+		if err != nil {
+			f.logf("Datagrams from %s: %v", addr, err)
+		}
+	}()
+	return peer, nil
+}
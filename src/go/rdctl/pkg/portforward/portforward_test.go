@@ -0,0 +1,165 @@
+package portforward
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeVM runs a script on the host instead of the commands of the VM, and
+// records the commands.
+type fakeVM struct {
+	script   string
+	mutex    sync.Mutex
+	commands [][]string
+}
+
+func newFakeVM(t *testing.T, script string) *fakeVM {
+	if runtime.GOOS == "windows" {
+		t.Skip("no POSIX shell")
+	}
+	return &fakeVM{script: script}
+}
+
+func (v *fakeVM) Command(args ...string) *exec.Cmd {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	v.commands = append(v.commands, args)
+	return exec.Command("sh", "-c", v.script)
+}
+
+func (v *fakeVM) commandsRun() [][]string {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	return v.commands
+}
+
+// logRecorder records the messages of the forwarder.
+type logRecorder struct {
+	mutex    sync.Mutex
+	messages []string
+}
+
+func (r *logRecorder) logf(format string, args ...any) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.messages = append(r.messages, fmt.Sprintf(format, args...))
+}
+
+func (r *logRecorder) String() string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return strings.Join(r.messages, "\n")
+}
+
+func TestServeTCP(t *testing.T) {
+	vm := newFakeVM(t, "cat")
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	forwarder := Forwarder{VM: vm, GuestPort: 8080}
+	result := make(chan error)
+	go func() { result <- forwarder.ServeTCP(ctx, listener) }()
+
+	for _, message := range []string{"hello", "world"} {
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		require.NoError(t, err)
+		_, err = conn.Write([]byte(message))
+		require.NoError(t, err)
+		require.NoError(t, conn.(*net.TCPConn).CloseWrite())
+		reply, err := io.ReadAll(conn)
+		require.NoError(t, err)
+		assert.Equal(t, message, string(reply))
+		conn.Close()
+	}
+	assert.Equal(t, [][]string{{"nc", "127.0.0.1", "8080"}, {"nc", "127.0.0.1", "8080"}}, vm.commandsRun())
+
+	// Connections still open are closed when the context is done.
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Write([]byte("open"))
+	require.NoError(t, err)
+	reply := make([]byte, 4)
+	_, err = io.ReadFull(conn, reply)
+	require.NoError(t, err)
+	cancel()
+	select {
+	case err := <-result:
+		assert.NoError(t, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("ServeTCP didn't return")
+	}
+	_, err = conn.Read(reply)
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestServeTCPRelayError(t *testing.T) {
+	vm := newFakeVM(t, "echo 'nc: can'\\''t connect to remote host (127.0.0.1): Connection refused' >&2; exit 1")
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var log logRecorder
+	forwarder := Forwarder{VM: vm, GuestPort: 8080, Logf: log.logf}
+	go func() { _ = forwarder.ServeTCP(ctx, listener) }()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = io.ReadAll(conn)
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return strings.Contains(log.String(), "exit status 1: nc: can't connect to remote host (127.0.0.1): Connection refused")
+	}, 10*time.Second, 10*time.Millisecond, log.String())
+}
+
+func TestServeUDP(t *testing.T) {
+	vm := newFakeVM(t, "cat")
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	forwarder := Forwarder{VM: vm, GuestPort: 53}
+	result := make(chan error)
+	go func() { result <- forwarder.ServeUDP(ctx, conn) }()
+
+	var peers []net.Conn
+	for i := 0; i < 2; i++ {
+		peer, err := net.Dial("udp", conn.LocalAddr().String())
+		require.NoError(t, err)
+		defer peer.Close()
+		peers = append(peers, peer)
+	}
+	for i, peer := range peers {
+		for _, message := range []string{"query", "again"} {
+			message = fmt.Sprintf("%s %d", message, i)
+			_, err := peer.Write([]byte(message))
+			require.NoError(t, err)
+			require.NoError(t, peer.SetReadDeadline(time.Now().Add(10*time.Second)))
+			reply := make([]byte, 100)
+			n, err := peer.Read(reply)
+			require.NoError(t, err)
+			assert.Equal(t, message, string(reply[:n]))
+		}
+	}
+	// Each peer has its own relay.
+	assert.Equal(t, [][]string{{"nc", "-u", "127.0.0.1", "53"}, {"nc", "-u", "127.0.0.1", "53"}}, vm.commandsRun())
+
+	cancel()
+	select {
+	case err := <-result:
+		assert.NoError(t, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("ServeUDP didn't return")
+	}
+}
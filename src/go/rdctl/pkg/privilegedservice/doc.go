@@ -0,0 +1,5 @@
+// Package privilegedservice manages the lifecycle of the Rancher Desktop
+// Privileged Service on Windows. Installation is delegated to the service
+// executable itself, so that it registers itself exactly as the installer
+// would.
+package privilegedservice
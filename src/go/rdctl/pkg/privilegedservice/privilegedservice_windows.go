@@ -0,0 +1,122 @@
+package privilegedservice
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// ServiceName is the name the service is registered under.
+const ServiceName = "RancherDesktopPrivilegedService"
+
+// ErrNotElevated is returned when an operation requires administrative
+// privileges that the current process doesn't have.
+var ErrNotElevated = errors.New("this command must be run as administrator")
+
+// Status describes the state of the service.
+type Status struct {
+	Installed bool   `json:"installed"`
+	State     string `json:"state,omitempty"`
+	StartType string `json:"startType,omitempty"`
+	// Executable is the path of the registered service executable.
+	Executable string `json:"executable,omitempty"`
+}
+
+var stateNames = map[svc.State]string{
+	svc.Stopped:         "stopped",
+	svc.StartPending:    "starting",
+	svc.StopPending:     "stopping",
+	svc.Running:         "running",
+	svc.ContinuePending: "resuming",
+	svc.PausePending:    "pausing",
+	svc.Paused:          "paused",
+}
+
+var startTypeNames = map[uint32]string{
+	mgr.StartAutomatic: "automatic",
+	mgr.StartManual:    "manual",
+	mgr.StartDisabled:  "disabled",
+}
+
+// executablePath returns the path of the service executable shipped with the
+// application.
+func executablePath(appPaths paths.Paths) string {
+	return filepath.Join(appPaths.Resources, "win32", "internal", "privileged-service.exe")
+}
+
+func isElevated() bool {
+	return windows.GetCurrentProcessToken().IsElevated()
+}
+
+// runExecutable runs the service executable with the given subcommand.
+func runExecutable(appPaths paths.Paths, subcommand string) error {
+	if !isElevated() {
+		return ErrNotElevated
+	}
+	cmd := exec.Command(executablePath(appPaths), subcommand)
+	cmd.SysProcAttr = &windows.SysProcAttr{CreationFlags: windows.CREATE_NO_WINDOW}
+	if output, err := cmd.CombinedOutput(); err != nil {
+		if message := strings.TrimSpace(string(output)); message != "" {
+			return fmt.Errorf("failed to %s the privileged service: %w: %s", subcommand, err, message)
+		}
+		return fmt.Errorf("failed to %s the privileged service: %w", subcommand, err)
+	}
+	return nil
+}
+
+// Install registers the service, replacing any existing registration, and
+// starts it.
+func Install(appPaths paths.Paths) error {
+	if err := runExecutable(appPaths, "install"); err != nil {
+		return err
+	}
+	return runExecutable(appPaths, "start")
+}
+
+// Uninstall stops the service and removes its registration.
+func Uninstall(appPaths paths.Paths) error {
+	return runExecutable(appPaths, "uninstall")
+}
+
+// GetStatus returns the state of the service. This does not require
+// administrative privileges.
+func GetStatus() (Status, error) {
+	manager, err := windows.OpenSCManager(nil, nil, windows.SC_MANAGER_CONNECT)
+	if err != nil {
+		return Status{}, fmt.Errorf("failed to connect to the service manager: %w", err)
+	}
+	defer windows.CloseServiceHandle(manager)
+	name, err := windows.UTF16PtrFromString(ServiceName)
+	if err != nil {
+		return Status{}, err
+	}
+	handle, err := windows.OpenService(manager, name, windows.SERVICE_QUERY_STATUS|windows.SERVICE_QUERY_CONFIG)
+	if errors.Is(err, windows.ERROR_SERVICE_DOES_NOT_EXIST) {
+		return Status{Installed: false}, nil
+	} else if err != nil {
+		return Status{}, fmt.Errorf("failed to open the privileged service: %w", err)
+	}
+	service := &mgr.Service{Name: ServiceName, Handle: handle}
+	defer service.Close()
+
+	status := Status{Installed: true}
+	serviceStatus, err := service.Query()
+	if err != nil {
+		return Status{}, fmt.Errorf("failed to query the privileged service: %w", err)
+	}
+	status.State = stateNames[serviceStatus.State]
+	config, err := service.Config()
+	if err != nil {
+		return Status{}, fmt.Errorf("failed to get the configuration of the privileged service: %w", err)
+	}
+	status.StartType = startTypeNames[config.StartType]
+	status.Executable = strings.Trim(config.BinaryPathName, `"`)
+	return status, nil
+}
@@ -0,0 +1,130 @@
+// Package profile records where rdctl spends its time, so that slow commands
+// can be diagnosed with the --profile-cli flag. Recording is disabled by
+// default, in which case it costs next to nothing.
+package profile
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// Operation categories shown in the report.
+const (
+	Config  = "config"
+	Paths   = "paths"
+	HTTP    = "http"
+	Process = "process"
+)
+
+type span struct {
+	category string
+	name     string
+	start    time.Duration
+	duration time.Duration
+}
+
+var (
+	mu       sync.Mutex
+	enabled  bool
+	origin   = time.Now()
+	recorded []span
+)
+
+// Enable starts recording operations.
+func Enable() {
+	mu.Lock()
+	defer mu.Unlock()
+	enabled = true
+}
+
+// Enabled returns whether operations are recorded.
+func Enabled() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return enabled
+}
+
+// Start begins timing an operation; the returned function ends it, e.g.
+//
+//	defer profile.Start(profile.Config, "load")()
+func Start(category, name string) func() {
+	if !Enabled() {
+		return func() {}
+	}
+	start := time.Now()
+	return func() {
+		end := time.Now()
+		mu.Lock()
+		defer mu.Unlock()
+		recorded = append(recorded, span{
+			category: category,
+			name:     name,
+			start:    start.Sub(origin),
+			duration: end.Sub(start),
+		})
+	}
+}
+
+type transport struct {
+	next http.RoundTripper
+}
+
+func (t transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Query strings are left out, as they are not needed to tell requests
+	// apart.
+	defer Start(HTTP, fmt.Sprintf("%s %s", req.Method, req.URL.Path))()
+	return t.next.RoundTrip(req)
+}
+
+// Transport wraps the round tripper, recording the duration of each request
+// until its response headers are received.
+func Transport(next http.RoundTripper) http.RoundTripper {
+	return transport{next: next}
+}
+
+// Write prints the recorded operations in the order they started, followed by
+// the total time spent in each category and the time since the start of the
+// process.
+func Write(w io.Writer) error {
+	mu.Lock()
+	spans := append([]span(nil), recorded...)
+	mu.Unlock()
+	sort.SliceStable(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+
+	writer := tabwriter.NewWriter(w, 0, 4, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(writer, "START\tDURATION\tCATEGORY\t OPERATION")
+	totals := map[string]time.Duration{}
+	var categories []string
+	for _, s := range spans {
+		fmt.Fprintf(writer, "%s\t%s\t%s\t %s\n", formatDuration(s.start), formatDuration(s.duration), s.category, s.name)
+		if _, ok := totals[s.category]; !ok {
+			categories = append(categories, s.category)
+		}
+		totals[s.category] += s.duration
+	}
+	fmt.Fprintln(writer)
+	sort.Strings(categories)
+	for _, category := range categories {
+		fmt.Fprintf(writer, "\t%s\t%s\t total\n", formatDuration(totals[category]), category)
+	}
+	fmt.Fprintf(writer, "\t%s\t\t elapsed\n", formatDuration(time.Since(origin)))
+	return writer.Flush()
+}
+
+func formatDuration(d time.Duration) string {
+	return fmt.Sprintf("%.1fms", float64(d)/float64(time.Millisecond))
+}
+
+// reset discards the recorded operations and disables recording; it is only
+// used by tests.
+func reset() {
+	mu.Lock()
+	defer mu.Unlock()
+	enabled = false
+	recorded = nil
+}
@@ -0,0 +1,50 @@
+package profile
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartDisabled(t *testing.T) {
+	t.Cleanup(reset)
+	Start(Config, "load")()
+	assert.Empty(t, recorded)
+}
+
+func TestWrite(t *testing.T) {
+	t.Cleanup(reset)
+	Enable()
+	Start(Paths, "resolve")()
+	Start(Process, "limactl")()
+	Start(Process, "kubectl")()
+
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf))
+	report := buf.String()
+	assert.Regexp(t, `(?m)paths\s+resolve$`, report)
+	assert.Regexp(t, `(?m)process\s+limactl$`, report)
+	assert.Regexp(t, `(?m)process\s+total$`, report)
+	assert.Regexp(t, `(?m)elapsed$`, report)
+	assert.Less(t, bytes.Index(buf.Bytes(), []byte("limactl")), bytes.Index(buf.Bytes(), []byte("kubectl")))
+}
+
+func TestTransport(t *testing.T) {
+	t.Cleanup(reset)
+	Enable()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	client := &http.Client{Transport: Transport(http.DefaultTransport)}
+	response, err := client.Get(server.URL + "/v1/settings?showSecrets=true")
+	require.NoError(t, err)
+	response.Body.Close()
+
+	require.Len(t, recorded, 1)
+	assert.Equal(t, HTTP, recorded[0].category)
+	assert.Equal(t, "GET /v1/settings", recorded[0].name)
+}
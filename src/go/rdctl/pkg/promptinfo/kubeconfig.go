@@ -0,0 +1,48 @@
+package promptinfo
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// currentKubeContext returns the current context from the kubeconfig files,
+// following the same precedence as kubectl: the first file listed in
+// KUBECONFIG that sets a context wins. To keep this fast, the files are
+// scanned for the top-level key instead of being fully parsed.
+func currentKubeContext() string {
+	var files []string
+	if kubeconfig := os.Getenv("KUBECONFIG"); kubeconfig != "" {
+		files = filepath.SplitList(kubeconfig)
+	} else if homeDir, err := os.UserHomeDir(); err == nil {
+		files = []string{filepath.Join(homeDir, ".kube", "config")}
+	}
+	for _, file := range files {
+		if context := readCurrentContext(file); context != "" {
+			return context
+		}
+	}
+	return ""
+}
+
+func readCurrentContext(file string) string {
+	f, err := os.Open(file)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		value, found := strings.CutPrefix(scanner.Text(), "current-context:")
+		if !found {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if comment := strings.Index(value, " #"); comment >= 0 {
+			value = strings.TrimSpace(value[:comment])
+		}
+		return strings.Trim(value, `"'`)
+	}
+	return ""
+}
@@ -0,0 +1,95 @@
+// Package promptinfo gathers the compact status shown by `rdctl prompt-info`.
+// As it is meant to be run every time a shell prompt is drawn, it relies on
+// the API cache and never waits for the main process to do any real work.
+package promptinfo
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/apicache"
+)
+
+// Info is the status shown in the prompt.
+type Info struct {
+	// Running is false if Rancher Desktop's status could not be determined,
+	// in which case the other fields (except Context) are empty.
+	Running    bool   `json:"running"`
+	Engine     string `json:"engine"`
+	Kubernetes bool   `json:"kubernetes"`
+	// Context is the current kubeconfig context, which need not belong to
+	// Rancher Desktop.
+	Context string `json:"context"`
+}
+
+type settings struct {
+	ContainerEngine struct {
+		Name string `json:"name"`
+	} `json:"containerEngine"`
+	Kubernetes struct {
+		Enabled bool `json:"enabled"`
+	} `json:"kubernetes"`
+}
+
+// Get collects the status. The cache may be nil if Rancher Desktop is known
+// not to be running.
+func Get(cache *apicache.Cache) Info {
+	info := Info{Context: currentKubeContext()}
+	if cache == nil {
+		return info
+	}
+	body, err := cache.Get(apicache.Settings)
+	if err != nil {
+		return info
+	}
+	var current settings
+	if err := json.Unmarshal(body, &current); err != nil {
+		return info
+	}
+	info.Running = true
+	info.Engine = current.ContainerEngine.Name
+	info.Kubernetes = current.Kubernetes.Enabled
+	return info
+}
+
+// WriteShort writes the status in the human-readable prompt format:
+//
+//	ENGINE [k8s] [ctx:CONTEXT]
+//
+// Nothing is written if Rancher Desktop is not running.
+func (info Info) WriteShort(w io.Writer) error {
+	if !info.Running {
+		return nil
+	}
+	fields := []string{info.Engine}
+	if info.Kubernetes {
+		fields = append(fields, "k8s")
+	}
+	if info.Context != "" {
+		fields = append(fields, "ctx:"+info.Context)
+	}
+	_, err := fmt.Fprintln(w, strings.Join(fields, " "))
+	return err
+}
+
+// WriteKeyValue writes the status in the machine-readable format: a single
+// line of space-separated KEY=VALUE pairs, always in the same order:
+//
+//	running=true|false engine=NAME kubernetes=on|off context=NAME
+//
+// Values never contain spaces.
+func (info Info) WriteKeyValue(w io.Writer) error {
+	kubernetes := "off"
+	if info.Kubernetes {
+		kubernetes = "on"
+	}
+	_, err := fmt.Fprintf(w, "running=%t engine=%s kubernetes=%s context=%s\n",
+		info.Running, noSpaces(info.Engine), kubernetes, noSpaces(info.Context))
+	return err
+}
+
+func noSpaces(value string) string {
+	return strings.Join(strings.Fields(value), "_")
+}
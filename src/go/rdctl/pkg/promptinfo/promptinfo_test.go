@@ -0,0 +1,64 @@
+package promptinfo
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFormats(t *testing.T) {
+	testCases := []struct {
+		Info     Info
+		Short    string
+		KeyValue string
+	}{
+		{
+			Info{Running: true, Engine: "moby", Kubernetes: true, Context: "rancher-desktop"},
+			"moby k8s ctx:rancher-desktop\n",
+			"running=true engine=moby kubernetes=on context=rancher-desktop\n",
+		},
+		{
+			Info{Running: true, Engine: "containerd"},
+			"containerd\n",
+			"running=true engine=containerd kubernetes=off context=\n",
+		},
+		{
+			Info{Context: "my context"},
+			"",
+			"running=false engine= kubernetes=off context=my_context\n",
+		},
+	}
+	for _, testCase := range testCases {
+		var short, keyValue bytes.Buffer
+		if err := testCase.Info.WriteShort(&short); err != nil {
+			t.Fatalf("failed to write short format: %s", err)
+		}
+		if err := testCase.Info.WriteKeyValue(&keyValue); err != nil {
+			t.Fatalf("failed to write key-value format: %s", err)
+		}
+		if short.String() != testCase.Short {
+			t.Errorf("expected %q, got %q", testCase.Short, short.String())
+		}
+		if keyValue.String() != testCase.KeyValue {
+			t.Errorf("expected %q, got %q", testCase.KeyValue, keyValue.String())
+		}
+	}
+}
+
+func TestCurrentKubeContext(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first")
+	second := filepath.Join(dir, "second")
+	if err := os.WriteFile(first, []byte("apiVersion: v1\nclusters: []\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	contents := "apiVersion: v1\ncontexts:\n- name: other\ncurrent-context: \"rancher-desktop\" # comment\n"
+	if err := os.WriteFile(second, []byte(contents), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("KUBECONFIG", first+string(os.PathListSeparator)+second)
+	if context := currentKubeContext(); context != "rancher-desktop" {
+		t.Errorf("expected rancher-desktop, got %q", context)
+	}
+}
@@ -0,0 +1,72 @@
+// Package redact removes secrets (passwords, tokens, and registry credentials)
+// from anything rdctl writes out, following the same rules as the application
+// (pkg/rancher-desktop/utils/redact.ts).
+package redact
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"regexp"
+)
+
+// Redacted is the replacement for redacted values.
+const Redacted = "********"
+
+// secretKeySource matches keys whose values are secrets: anything mentioning
+// passwords, secrets, tokens, or credentials, and the auth entries of docker
+// configs.
+const secretKeySource = `[\w.-]*(?:password|passwd|secret|token|credential)[\w.-]*|auth|identitytoken`
+
+var secretKeyRE = regexp.MustCompile(`(?i)^(?:` + secretKeySource + `)$`)
+
+// secretTextREs match the secrets in free-form text; the first group is kept,
+// the second group is the secret, and the third group (if any) is kept too.
+var secretTextREs = []*regexp.Regexp{
+	// KEY=VALUE, KEY: VALUE, and "KEY": "VALUE"
+	regexp.MustCompile(`(?i)(["']?\b(?:` + secretKeySource + `)["']?\s*[:=]\s*["']?)([^"'\s,;&}]+)`),
+	// Authorization headers
+	regexp.MustCompile(`(?i)(\bauthorization:?\s*(?:basic|bearer)\s+)(\S+)`),
+	// Passwords in URLs; the "@" stands in for the lookahead of the original
+	// pattern, which Go doesn't support.
+	regexp.MustCompile(`(?i)(\b[a-z][a-z0-9+.-]*://[^\s:@/]+:)([^\s@/]+)(@)`),
+}
+
+// IsSecretKey returns whether the values of the key are secrets.
+func IsSecretKey(key string) bool {
+	return secretKeyRE.MatchString(key)
+}
+
+// Text replaces the secrets in free-form text, such as log messages.
+func Text(text string) string {
+	for _, re := range secretTextREs {
+		replacement := "${1}" + Redacted
+		if re.NumSubexp() > 2 {
+			replacement += "${3}"
+		}
+		text = re.ReplaceAllString(text, replacement)
+	}
+	return text
+}
+
+// Copy copies the text from src to dst a line at a time, replacing the secrets
+// in each line.
+func Copy(dst io.Writer, src io.Reader) (int64, error) {
+	reader := bufio.NewReader(src)
+	var written int64
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			n, writeErr := io.WriteString(dst, Text(line))
+			written += int64(n)
+			if writeErr != nil {
+				return written, writeErr
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return written, nil
+		} else if err != nil {
+			return written, err
+		}
+	}
+}
@@ -0,0 +1,193 @@
+// Package servicemesh holds the preset of settings that service meshes, such
+// as Istio and Linkerd, need for development, and reports on each of its
+// requirements.
+package servicemesh
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/checks"
+)
+
+// Name is the name of the preset, for `rdctl set --preset`.
+const Name = "service-mesh"
+
+// The resources of the VM that the demo profile of Istio needs; Linkerd needs
+// less.
+const (
+	MinimumCPUs       = 4
+	MinimumMemoryInGB = 8
+)
+
+// Sysctls are the kernel parameters the preset sets: the proxies and the
+// control planes watch many files, which exhausts the default inotify limits.
+// Higher values in the settings are kept.
+var Sysctls = []string{
+	"fs.inotify.max_user_instances=512",
+	"fs.inotify.max_user_watches=524288",
+}
+
+// Settings holds the settings the preset depends on.
+type Settings struct {
+	VirtualMachine struct {
+		MemoryInGB int `json:"memoryInGB"`
+		NumberCPUs int `json:"numberCPUs"`
+	} `json:"virtualMachine"`
+	Kubernetes struct {
+		Enabled bool `json:"enabled"`
+		Options struct {
+			Traefik bool `json:"traefik"`
+		} `json:"options"`
+	} `json:"kubernetes"`
+	Experimental struct {
+		VirtualMachine struct {
+			Sysctls []string `json:"sysctls"`
+		} `json:"virtualMachine"`
+	} `json:"experimental"`
+}
+
+// Host describes the machine running the VM.
+type Host struct {
+	// GOOS is the operating system of the host; on Windows, the resources of
+	// the WSL VM are not settings of Rancher Desktop.
+	GOOS string
+	// CPUs is the number of CPUs of the host.
+	CPUs int
+}
+
+// Apply returns the settings to change so that the current settings meet the
+// requirements of the preset, and a report on each requirement. Settings that
+// already meet them are left alone; the report warns about the requirements
+// that can't be met.
+func Apply(current Settings, host Host) (map[string]any, []checks.Check) {
+	changes := map[string]any{}
+	var report []checks.Check
+	if host.GOOS == "windows" {
+		report = append(report,
+			checks.Check{
+				Name:    "virtualMachine.numberCPUs",
+				Status:  checks.Warning,
+				Message: fmt.Sprintf("the WSL VM has the CPUs set in .wslconfig; service meshes need at least %d", MinimumCPUs),
+			},
+			checks.Check{
+				Name:    "virtualMachine.memoryInGB",
+				Status:  checks.Warning,
+				Message: fmt.Sprintf("the WSL VM has the memory set in .wslconfig; service meshes need at least %d GB", MinimumMemoryInGB),
+			})
+	} else {
+		virtualMachine := map[string]any{}
+		cpus := MinimumCPUs
+		if host.CPUs > 0 && host.CPUs < cpus {
+			cpus = host.CPUs
+		}
+		report = append(report, raise(virtualMachine, "numberCPUs", current.VirtualMachine.NumberCPUs, cpus, MinimumCPUs, "CPUs"))
+		report = append(report, raise(virtualMachine, "memoryInGB", current.VirtualMachine.MemoryInGB, MinimumMemoryInGB, MinimumMemoryInGB, "GB"))
+		if len(virtualMachine) > 0 {
+			changes["virtualMachine"] = virtualMachine
+		}
+	}
+
+	sysctls, sysctlReport := mergeSysctls(current.Experimental.VirtualMachine.Sysctls)
+	report = append(report, sysctlReport...)
+	if sysctls != nil {
+		changes["experimental"] = map[string]any{"virtualMachine": map[string]any{"sysctls": sysctls}}
+	}
+
+	kubernetes := map[string]any{}
+	check := checks.Check{Name: "kubernetes.enabled", Status: checks.OK}
+	if current.Kubernetes.Enabled {
+		check.Message = "Kubernetes is already enabled"
+	} else {
+		kubernetes["enabled"] = true
+		check.Message = "Kubernetes is enabled"
+	}
+	report = append(report, check)
+	// The ingress gateway of the mesh takes the ports Traefik would listen on.
+	check = checks.Check{Name: "kubernetes.options.traefik", Status: checks.OK}
+	if current.Kubernetes.Options.Traefik {
+		kubernetes["options"] = map[string]any{"traefik": false}
+		check.Message = "Traefik is disabled, so the ingress gateway of the mesh can use ports 80 and 443"
+	} else {
+		check.Message = "Traefik is already disabled"
+	}
+	report = append(report, check)
+	if len(kubernetes) > 0 {
+		changes["kubernetes"] = kubernetes
+	}
+	return changes, report
+}
+
+// raise sets the setting named key to target in changes if current is lower,
+// and reports on it; the report warns if target is lower than minimum.
+func raise(changes map[string]any, key string, current, target, minimum int, unit string) checks.Check {
+	check := checks.Check{Name: "virtualMachine." + key, Status: checks.OK}
+	switch {
+	case current >= minimum:
+		check.Message = fmt.Sprintf("already %d %s", current, unit)
+		return check
+	case current >= target:
+		check.Status = checks.Warning
+		check.Message = fmt.Sprintf("%d %s, all the host has; service meshes need at least %d", current, unit, minimum)
+		return check
+	}
+	changes[key] = target
+	if target < minimum {
+		check.Status = checks.Warning
+		check.Message = fmt.Sprintf("raised from %d to %d %s, all the host has; service meshes need at least %d", current, target, unit, minimum)
+	} else {
+		check.Message = fmt.Sprintf("raised from %d to %d %s", current, target, unit)
+	}
+	return check
+}
+
+// mergeSysctls returns the kernel parameters of the settings with those of
+// the preset, or nil if they already have them, and reports on each parameter
+// of the preset.
+func mergeSysctls(current []string) ([]string, []checks.Check) {
+	result := append([]string{}, current...)
+	changed := false
+	var report []checks.Check
+	for _, sysctl := range Sysctls {
+		key, value, _ := strings.Cut(sysctl, "=")
+		check := checks.Check{Name: key, Status: checks.OK}
+		index := -1
+		for i, existing := range result {
+			if existingKey, _, _ := strings.Cut(existing, "="); existingKey == key {
+				index = i
+			}
+		}
+		if index < 0 {
+			result = append(result, sysctl)
+			changed = true
+			check.Message = fmt.Sprintf("set to %s", value)
+			report = append(report, check)
+			continue
+		}
+		_, existingValue, _ := strings.Cut(result[index], "=")
+		if higherOrEqual(existingValue, value) {
+			check.Message = fmt.Sprintf("already %s", existingValue)
+		} else {
+			result[index] = sysctl
+			changed = true
+			check.Message = fmt.Sprintf("raised from %s to %s", existingValue, value)
+		}
+		report = append(report, check)
+	}
+	if !changed {
+		return nil, report
+	}
+	return result, report
+}
+
+// higherOrEqual returns whether the value of a kernel parameter is at least
+// minimum; values that aren't numbers are not.
+func higherOrEqual(value, minimum string) bool {
+	number, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil {
+		return false
+	}
+	minimumNumber, err := strconv.ParseInt(minimum, 10, 64)
+	return err == nil && number >= minimumNumber
+}
@@ -0,0 +1,72 @@
+package servicemesh
+
+import (
+	"testing"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/checks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApply(t *testing.T) {
+	t.Run("raises the settings below the requirements", func(t *testing.T) {
+		var current Settings
+		current.VirtualMachine.NumberCPUs = 2
+		current.VirtualMachine.MemoryInGB = 4
+		current.Kubernetes.Options.Traefik = true
+		current.Experimental.VirtualMachine.Sysctls = []string{"vm.max_map_count=262144", "fs.inotify.max_user_watches=8192"}
+		changes, report := Apply(current, Host{GOOS: "darwin", CPUs: 8})
+		assert.Equal(t, map[string]any{
+			"virtualMachine": map[string]any{"numberCPUs": 4, "memoryInGB": 8},
+			"experimental": map[string]any{"virtualMachine": map[string]any{"sysctls": []string{
+				"vm.max_map_count=262144",
+				"fs.inotify.max_user_watches=524288",
+				"fs.inotify.max_user_instances=512",
+			}}},
+			"kubernetes": map[string]any{"enabled": true, "options": map[string]any{"traefik": false}},
+		}, changes)
+		assert.Equal(t, []checks.Check{
+			{Name: "virtualMachine.numberCPUs", Status: checks.OK, Message: "raised from 2 to 4 CPUs"},
+			{Name: "virtualMachine.memoryInGB", Status: checks.OK, Message: "raised from 4 to 8 GB"},
+			{Name: "fs.inotify.max_user_instances", Status: checks.OK, Message: "set to 512"},
+			{Name: "fs.inotify.max_user_watches", Status: checks.OK, Message: "raised from 8192 to 524288"},
+			{Name: "kubernetes.enabled", Status: checks.OK, Message: "Kubernetes is enabled"},
+			{Name: "kubernetes.options.traefik", Status: checks.OK, Message: "Traefik is disabled, so the ingress gateway of the mesh can use ports 80 and 443"},
+		}, report)
+	})
+	t.Run("keeps the settings meeting the requirements", func(t *testing.T) {
+		var current Settings
+		current.VirtualMachine.NumberCPUs = 6
+		current.VirtualMachine.MemoryInGB = 16
+		current.Kubernetes.Enabled = true
+		current.Experimental.VirtualMachine.Sysctls = []string{"fs.inotify.max_user_instances=1024", "fs.inotify.max_user_watches=524288"}
+		changes, report := Apply(current, Host{GOOS: "linux", CPUs: 8})
+		assert.Empty(t, changes)
+		for _, check := range report {
+			assert.Equal(t, checks.OK, check.Status, check.Name)
+			assert.Contains(t, check.Message, "already", check.Name)
+		}
+	})
+	t.Run("warns about the requirements it can't meet", func(t *testing.T) {
+		var current Settings
+		current.VirtualMachine.NumberCPUs = 2
+		current.VirtualMachine.MemoryInGB = 8
+		changes, report := Apply(current, Host{GOOS: "darwin", CPUs: 3})
+		assert.Equal(t, map[string]any{"numberCPUs": 3}, changes["virtualMachine"])
+		assert.Equal(t, checks.Check{
+			Name:    "virtualMachine.numberCPUs",
+			Status:  checks.Warning,
+			Message: "raised from 2 to 3 CPUs, all the host has; service meshes need at least 4",
+		}, report[0])
+
+		current.VirtualMachine.NumberCPUs = 3
+		changes, report = Apply(current, Host{GOOS: "darwin", CPUs: 3})
+		assert.NotContains(t, changes, "virtualMachine")
+		assert.Equal(t, checks.Warning, report[0].Status)
+	})
+	t.Run("leaves the resources of WSL alone", func(t *testing.T) {
+		changes, report := Apply(Settings{}, Host{GOOS: "windows", CPUs: 2})
+		assert.NotContains(t, changes, "virtualMachine")
+		assert.Equal(t, checks.Warning, report[0].Status)
+		assert.Equal(t, checks.Warning, report[1].Status)
+	})
+}
@@ -0,0 +1,219 @@
+// Package settingsschema checks settings against the JSON schema of the
+// settings that the application serves, so that rdctl can report unknown
+// settings and wrong values precisely before sending them.
+package settingsschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Schema is the part of a JSON schema describing the settings that is
+// checked.
+type Schema struct {
+	Type       string             `json:"type"`
+	Properties map[string]*Schema `json:"properties"`
+	// AdditionalProperties is true, or the schema of the values, for objects
+	// with arbitrary keys.
+	AdditionalProperties json.RawMessage `json:"additionalProperties"`
+	Items                *Schema         `json:"items"`
+	Enum                 []any           `json:"enum"`
+	Minimum              *float64        `json:"minimum"`
+	Maximum              *float64        `json:"maximum"`
+	// Platforms are the platforms supporting the setting, as named by
+	// Node.js, e.g. "win32"; all of them if empty.
+	Platforms []string `json:"x-rd-platforms"`
+}
+
+// Problem is a setting that doesn't match the schema.
+type Problem struct {
+	// Field is the dotted path of the setting, e.g. "kubernetes.enabled".
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%s: %s", p.Field, p.Message)
+}
+
+// Validate checks the settings, as decoded from JSON, against the schema, on
+// the platform given by its GOOS, and returns the problems sorted by field.
+// The version of the settings isn't part of the schema, so it isn't checked.
+func Validate(schema *Schema, settings map[string]any, goos string) []Problem {
+	settings = copyWithout(settings, "version")
+	problems := validateValue(nil, schema, settings, "", platformName(goos))
+	sort.SliceStable(problems, func(i, j int) bool {
+		return problems[i].Field < problems[j].Field
+	})
+	return problems
+}
+
+func copyWithout(settings map[string]any, key string) map[string]any {
+	result := make(map[string]any, len(settings))
+	for k, v := range settings {
+		if k != key {
+			result[k] = v
+		}
+	}
+	return result
+}
+
+// platformName returns the name Node.js gives to the platform.
+func platformName(goos string) string {
+	if goos == "windows" {
+		return "win32"
+	}
+	return goos
+}
+
+func validateValue(problems []Problem, schema *Schema, value any, path, platform string) []Problem {
+	if len(schema.Platforms) > 0 && !contains(schema.Platforms, platform) {
+		return append(problems, Problem{path, fmt.Sprintf("only supported on %s", strings.Join(schema.Platforms, ", "))})
+	}
+	switch schema.Type {
+	case "object":
+		object, ok := value.(map[string]any)
+		if !ok {
+			return append(problems, typeProblem(path, "an object", value))
+		}
+		return validateObject(problems, schema, object, path, platform)
+	case "array":
+		values, ok := value.([]any)
+		if !ok {
+			return append(problems, typeProblem(path, "an array", value))
+		}
+		if schema.Items != nil {
+			for i, element := range values {
+				problems = validateValue(problems, schema.Items, element, fmt.Sprintf("%s[%d]", path, i), platform)
+			}
+		}
+		return problems
+	case "string":
+		if _, ok := value.(string); !ok {
+			return append(problems, typeProblem(path, "a string", value))
+		}
+	case "boolean":
+		// The application also accepts booleans as strings.
+		if _, ok := value.(bool); !ok && value != "true" && value != "false" {
+			return append(problems, typeProblem(path, "a boolean", value))
+		}
+	case "integer", "number":
+		number, ok := value.(float64)
+		if !ok || (schema.Type == "integer" && number != float64(int64(number))) {
+			return append(problems, typeProblem(path, "an "+schema.Type, value))
+		}
+		if schema.Minimum != nil && number < *schema.Minimum {
+			return append(problems, Problem{path, fmt.Sprintf("must be at least %v", *schema.Minimum)})
+		}
+		if schema.Maximum != nil && number > *schema.Maximum {
+			return append(problems, Problem{path, fmt.Sprintf("must be at most %v", *schema.Maximum)})
+		}
+	}
+	if len(schema.Enum) > 0 && !containsValue(schema.Enum, value) {
+		allowed := make([]string, len(schema.Enum))
+		for i, v := range schema.Enum {
+			allowed[i] = fmt.Sprintf("%q", fmt.Sprint(v))
+		}
+		return append(problems, Problem{path, fmt.Sprintf("invalid value %s; must be one of %s", encode(value), strings.Join(allowed, ", "))})
+	}
+	return problems
+}
+
+func validateObject(problems []Problem, schema *Schema, object map[string]any, path, platform string) []Problem {
+	var additional *Schema
+	allowAdditional := false
+	if len(schema.AdditionalProperties) > 0 {
+		additional = &Schema{}
+		if err := json.Unmarshal(schema.AdditionalProperties, additional); err != nil {
+			// additionalProperties: true
+			additional = nil
+			allowAdditional = string(schema.AdditionalProperties) == "true"
+		} else {
+			allowAdditional = true
+		}
+	}
+	for key, value := range object {
+		fieldPath := key
+		if path != "" {
+			fieldPath = path + "." + key
+		}
+		if property, ok := schema.Properties[key]; ok {
+			problems = validateValue(problems, property, value, fieldPath, platform)
+		} else if additional != nil {
+			problems = validateValue(problems, additional, value, fieldPath, platform)
+		} else if !allowAdditional {
+			message := "unknown setting"
+			if suggestion := suggest(key, schema.Properties); suggestion != "" {
+				message += fmt.Sprintf("; did you mean %q?", suggestion)
+			}
+			problems = append(problems, Problem{fieldPath, message})
+		}
+	}
+	return problems
+}
+
+// suggest returns the name of a property the key is likely a typo of, or an
+// empty string.
+func suggest(key string, properties map[string]*Schema) string {
+	best, bestDistance := "", 3
+	for name := range properties {
+		distance := editDistance(strings.ToLower(key), strings.ToLower(name))
+		if distance < bestDistance || (distance == bestDistance && name < best) {
+			best, bestDistance = name, distance
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between the strings.
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+func typeProblem(path, expected string, value any) Problem {
+	return Problem{path, fmt.Sprintf("expecting %s, got %s", expected, encode(value))}
+}
+
+func encode(value any) string {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(encoded)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsValue(values []any, value any) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,108 @@
+package settingsschema
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// schema mirrors the shape of the schema of the settings.
+const schema = `{
+	"type": "object",
+	"properties": {
+		"containerEngine": {
+			"type": "object",
+			"properties": {
+				"name": {"type": "string", "enum": ["containerd", "moby"]},
+				"allowedImages": {
+					"type": "object",
+					"properties": {
+						"enabled": {"type": "boolean"},
+						"patterns": {"type": "array", "items": {"type": "string"}}
+					}
+				}
+			}
+		},
+		"kubernetes": {
+			"type": "object",
+			"properties": {
+				"enabled": {"type": "boolean"},
+				"port": {"type": "integer", "minimum": 1, "maximum": 65535}
+			}
+		},
+		"virtualMachine": {
+			"type": "object",
+			"properties": {
+				"hostResolver": {"type": "boolean", "x-rd-platforms": ["win32"]}
+			}
+		},
+		"WSL": {
+			"type": "object",
+			"properties": {
+				"integrations": {"type": "object", "additionalProperties": {"type": "boolean"}}
+			}
+		},
+		"diagnostics": {
+			"type": "object",
+			"properties": {
+				"mutedChecks": {"type": "object", "additionalProperties": true}
+			}
+		}
+	}
+}`
+
+func validate(t *testing.T, settings string, goos string) []Problem {
+	var parsedSchema Schema
+	require.NoError(t, json.Unmarshal([]byte(schema), &parsedSchema))
+	var parsed map[string]any
+	require.NoError(t, json.Unmarshal([]byte(settings), &parsed))
+	return Validate(&parsedSchema, parsed, goos)
+}
+
+func TestValidate(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		problems := validate(t, `{
+			"version": 10,
+			"containerEngine": {"name": "moby", "allowedImages": {"enabled": "true", "patterns": ["busybox"]}},
+			"kubernetes": {"enabled": false, "port": 6443},
+			"virtualMachine": {"hostResolver": true},
+			"WSL": {"integrations": {"Ubuntu": true}},
+			"diagnostics": {"mutedChecks": {"PATH_MANAGEMENT": true}}
+		}`, "windows")
+		assert.Empty(t, problems)
+	})
+	t.Run("invalid", func(t *testing.T) {
+		problems := validate(t, `{
+			"containerEngine": {"name": "docker", "allowedImages": {"enabled": "yes", "patterns": [1]}},
+			"kubernetes": {"enabled": 1, "port": 0, "prot": 6443},
+			"kubernets": {},
+			"WSL": {"integrations": {"Ubuntu": "on"}},
+			"colour": "blue"
+		}`, "linux")
+		assert.Equal(t, []Problem{
+			{Field: "WSL.integrations.Ubuntu", Message: `expecting a boolean, got "on"`},
+			{Field: "colour", Message: "unknown setting"},
+			{Field: "containerEngine.allowedImages.enabled", Message: `expecting a boolean, got "yes"`},
+			{Field: "containerEngine.allowedImages.patterns[0]", Message: "expecting a string, got 1"},
+			{Field: "containerEngine.name", Message: `invalid value "docker"; must be one of "containerd", "moby"`},
+			{Field: "kubernetes.enabled", Message: "expecting a boolean, got 1"},
+			{Field: "kubernetes.port", Message: "must be at least 1"},
+			{Field: "kubernetes.prot", Message: `unknown setting; did you mean "port"?`},
+			{Field: "kubernets", Message: `unknown setting; did you mean "kubernetes"?`},
+		}, problems)
+	})
+	t.Run("platforms", func(t *testing.T) {
+		problems := validate(t, `{"virtualMachine": {"hostResolver": true}}`, "darwin")
+		assert.Equal(t, []Problem{
+			{Field: "virtualMachine.hostResolver", Message: "only supported on win32"},
+		}, problems)
+	})
+	t.Run("integers", func(t *testing.T) {
+		problems := validate(t, `{"kubernetes": {"port": 6443.5}}`, "linux")
+		assert.Equal(t, []Problem{
+			{Field: "kubernetes.port", Message: "expecting an integer, got 6443.5"},
+		}, problems)
+	})
+}
@@ -0,0 +1,35 @@
+package shutdown
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/vm"
+)
+
+// drainScript stops the running containers of the engine "$1", giving each of
+// them "$2" seconds to exit before it is killed.
+const drainScript = `
+set -o errexit
+if [ "$1" = moby ]; then
+  CLI=docker
+else
+  CLI=nerdctl
+fi
+ids="$($CLI ps --quiet)"
+if [ -n "$ids" ]; then
+  $CLI stop --time "$2" $ids
+fi
+`
+
+// DrainContainers stops the running containers in the VM, sending them
+// SIGTERM and then SIGKILL if they are still running after timeoutSeconds.
+func DrainContainers(runner vm.Runner, engineName string, timeoutSeconds int) error {
+	if timeoutSeconds < 0 {
+		return fmt.Errorf("invalid drain timeout %d", timeoutSeconds)
+	}
+	if _, err := runner.RootOutput("sh", "-c", drainScript, "sh", engineName, strconv.Itoa(timeoutSeconds)); err != nil {
+		return fmt.Errorf("failed to stop the running containers: %w", err)
+	}
+	return nil
+}
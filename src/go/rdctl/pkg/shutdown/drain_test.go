@@ -0,0 +1,40 @@
+package shutdown
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeVM struct {
+	args []string
+	err  error
+}
+
+func (v *fakeVM) RootOutput(args ...string) ([]byte, error) {
+	v.args = args
+	return nil, v.err
+}
+
+func (v *fakeVM) RootStream(stdin io.Reader, stdout io.Writer, args ...string) error {
+	return errors.New("unexpected command")
+}
+
+func TestDrainContainers(t *testing.T) {
+	t.Run("passes the engine and timeout", func(t *testing.T) {
+		runner := &fakeVM{}
+		assert.NoError(t, DrainContainers(runner, "containerd", 30))
+		assert.Equal(t, []string{"sh", "-c", drainScript, "sh", "containerd", "30"}, runner.args)
+	})
+	t.Run("reports failures", func(t *testing.T) {
+		runner := &fakeVM{err: errors.New("exit status 1")}
+		assert.ErrorContains(t, DrainContainers(runner, "moby", 10), "failed to stop the running containers")
+	})
+	t.Run("rejects negative timeouts", func(t *testing.T) {
+		runner := &fakeVM{}
+		assert.Error(t, DrainContainers(runner, "moby", -1))
+		assert.Nil(t, runner.args)
+	})
+}
@@ -33,6 +33,8 @@ import (
 
 type shutdownData struct {
 	waitForShutdown bool
+	// force skips the graceful stop of the VM.
+	force bool
 }
 
 type InitiatingCommand string
@@ -44,17 +46,24 @@ const (
 
 var limaCtlPath string
 
-func newShutdownData(waitForShutdown bool) *shutdownData {
-	return &shutdownData{waitForShutdown: waitForShutdown}
+// wslDistros are the WSL distributions that Rancher Desktop runs.
+var wslDistros = []string{"rancher-desktop", "rancher-desktop-data"}
+
+func newShutdownData(waitForShutdown, force bool) *shutdownData {
+	// Forcing the shutdown kills everything without waiting.
+	return &shutdownData{waitForShutdown: waitForShutdown && !force, force: force}
 }
 
 // FinishShutdown - ensures that none of the Rancher Desktop related processes are around
 // after a graceful shutdown command has been sent as part of either `rdctl shutdown` or
-// `rdctl factory-reset`.
-func FinishShutdown(waitForShutdown bool, initiatingCommand InitiatingCommand) error {
-	s := newShutdownData(waitForShutdown)
+// `rdctl factory-reset`. With force, the VM and the app are killed immediately.
+func FinishShutdown(waitForShutdown, force bool, initiatingCommand InitiatingCommand) error {
+	s := newShutdownData(waitForShutdown, force)
 	if runtime.GOOS == "windows" {
-		return s.waitForAppToDieOrKillIt(factoryreset.CheckProcessWindows, factoryreset.KillRancherDesktop, 15, 2, "the app")
+		err := s.waitForAppToDieOrKillIt(factoryreset.CheckProcessWindows, factoryreset.KillRancherDesktop, 15, 2, "the app")
+		// The distributions can keep running after the app is gone.
+		terminateWSLDistros()
+		return err
 	}
 	var err error
 	paths, err := p.GetPaths()
@@ -69,9 +78,11 @@ func FinishShutdown(waitForShutdown bool, initiatingCommand InitiatingCommand) e
 		} else {
 			switch initiatingCommand {
 			case Shutdown:
-				err = s.waitForAppToDieOrKillIt(checkLima, stopLima, 15, 2, "lima")
-				if err != nil {
-					logrus.Errorf("Ignoring error trying to stop lima: %s", err)
+				if !s.force {
+					err = s.waitForAppToDieOrKillIt(checkLima, stopLima, 15, 2, "lima")
+					if err != nil {
+						logrus.Errorf("Ignoring error trying to stop lima: %s", err)
+					}
 				}
 				// Check once more to see if lima is still running, and if so, run `limactl stop --force 0`
 				err = s.waitForAppToDieOrKillIt(checkLima, stopLimaWithForce, 1, 0, "lima")
@@ -208,6 +219,16 @@ func deleteLima() error {
 	return runCommandIgnoreOutput(exec.Command(limaCtlPath, "delete", "--force", "0"))
 }
 
+// terminateWSLDistros stops the WSL distributions of Rancher Desktop; WSL
+// reports success for the distributions that aren't running.
+func terminateWSLDistros() {
+	for _, distro := range wslDistros {
+		if output, err := exec.Command("wsl", "--terminate", distro).CombinedOutput(); err != nil {
+			logrus.Debugf("Ignoring error trying to terminate %s: %s: %s", distro, err, output)
+		}
+	}
+}
+
 func pkillDarwin() error {
 	err := pkill("-9", "-a", "-l", "-f", "Contents/MacOS/Rancher Desktop")
 	if err != nil {
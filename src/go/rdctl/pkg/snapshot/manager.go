@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"time"
@@ -190,6 +191,28 @@ func (manager *Manager) List(includeIncomplete bool) ([]Snapshot, error) {
 	return snapshots, nil
 }
 
+// Size returns the disk space the files of the snapshot take up. Disks of
+// the VM cloned with copy-on-write share their unchanged blocks with the
+// working copies, but are counted in full.
+func (manager *Manager) Size(snapshot Snapshot) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(manager.SnapshotDirectory(snapshot), func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || !entry.Type().IsRegular() {
+			return err
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		size += allocatedSize(info)
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get the size of snapshot %q: %w", snapshot.Name, err)
+	}
+	return size, nil
+}
+
 // Delete a snapshot.
 func (manager *Manager) Delete(name string) error {
 	snapshot, err := manager.Snapshot(name)
@@ -210,6 +210,36 @@ func TestManager(t *testing.T) {
 		}
 	})
 
+	t.Run("Size should count the files of the snapshot", func(t *testing.T) {
+		paths, _ := populateFiles(t, true)
+		manager := newTestManager(paths)
+		snapshot, err := manager.Create(context.Background(), "test-snapshot", "")
+		if err != nil {
+			t.Fatalf("failed to create snapshot: %s", err)
+		}
+		size, err := manager.Size(snapshot)
+		if err != nil {
+			t.Fatalf("failed to get snapshot size: %s", err)
+		}
+		if size <= 0 {
+			t.Fatalf("unexpected snapshot size %d", size)
+		}
+		contents := []byte(strings.Repeat("x", 1<<20))
+		if err := os.WriteFile(filepath.Join(manager.SnapshotDirectory(snapshot), "extra"), contents, 0o644); err != nil {
+			t.Fatalf("failed to write extra file: %s", err)
+		}
+		newSize, err := manager.Size(snapshot)
+		if err != nil {
+			t.Fatalf("failed to get snapshot size: %s", err)
+		}
+		if newSize-size < int64(len(contents)) {
+			t.Errorf("snapshot size grew by %d bytes after adding %d", newSize-size, len(contents))
+		}
+		if _, err := manager.Size(Snapshot{Name: "missing", ID: "missing"}); err == nil {
+			t.Errorf("expected an error for a missing snapshot")
+		}
+	})
+
 	t.Run("Restore should return an error if asked to restore a nonexistent snapshot", func(t *testing.T) {
 		paths, _ := populateFiles(t, true)
 		manager := newTestManager(paths)
@@ -0,0 +1,17 @@
+//go:build unix
+
+package snapshot
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// allocatedSize returns the space the file uses on disk, which is less than
+// its size if it is sparse, like the disks of the VM.
+func allocatedSize(info fs.FileInfo) int64 {
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		return int64(stat.Blocks) * 512
+	}
+	return info.Size()
+}
@@ -0,0 +1,11 @@
+package snapshot
+
+import (
+	"io/fs"
+)
+
+// allocatedSize returns the space the file uses on disk; the exported WSL
+// distros in snapshots are not sparse.
+func allocatedSize(info fs.FileInfo) int64 {
+	return info.Size()
+}
@@ -10,6 +10,9 @@ type Snapshot struct {
 	Name        string    `json:"name"`
 	ID          string    `json:"id,omitempty"`
 	Description string    `json:"description"`
+	// Size is the disk space the snapshot takes up; it is only set for
+	// listing, and not stored in the metadata.
+	Size int64 `json:"size,omitempty"`
 }
 
 func (s *Snapshot) getTimeString() string {
@@ -0,0 +1,97 @@
+// Package sshconfig generates ssh_config stanzas that let ssh, and tools built
+// on it such as VS Code Remote-SSH and rsync, connect to the Rancher Desktop
+// VM. The stanzas use `rdctl ssh-config --proxy` as their ProxyCommand, so
+// they keep working when the VM is restarted.
+package sshconfig
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DefaultHost is the name of the host in the generated stanza.
+const DefaultHost = "rancher-desktop"
+
+// option is a keyword and its arguments in an ssh_config file.
+type option struct {
+	keyword string
+	value   string
+}
+
+// replacedKeywords are the keywords of the Lima configuration that are
+// replaced in the generated stanza, as the connection goes through the proxy.
+var replacedKeywords = map[string]bool{
+	"host":     true,
+	"hostname": true,
+	"port":     true,
+}
+
+// parseOptions returns the options of an ssh_config file; comments and blank
+// lines are skipped.
+func parseOptions(config string) ([]option, error) {
+	var options []option
+	scanner := bufio.NewScanner(strings.NewReader(config))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		// Keywords are separated from their arguments by whitespace or '='.
+		end := strings.IndexAny(line, " \t=")
+		if end < 0 {
+			options = append(options, option{keyword: line})
+			continue
+		}
+		value := strings.TrimPrefix(strings.TrimLeft(line[end:], " \t"), "=")
+		options = append(options, option{keyword: line[:end], value: strings.TrimSpace(value)})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read ssh config: %w", err)
+	}
+	return options, nil
+}
+
+// limaStanza turns the ssh config written by Lima for the VM into a stanza
+// for the host, connecting through the proxy command.
+func limaStanza(limaConfig, host, proxyCommand string) (string, error) {
+	options, err := parseOptions(limaConfig)
+	if err != nil {
+		return "", err
+	}
+	lines := []string{"Host " + host, "  ProxyCommand " + proxyCommand}
+	for _, opt := range options {
+		if !replacedKeywords[strings.ToLower(opt.keyword)] {
+			lines = append(lines, fmt.Sprintf("  %s %s", opt.keyword, opt.value))
+		}
+	}
+	return strings.Join(lines, "\n") + "\n", nil
+}
+
+// limaPort returns the local port of the ssh server of the VM, from the ssh
+// config written by Lima.
+func limaPort(limaConfig string) (int, error) {
+	options, err := parseOptions(limaConfig)
+	if err != nil {
+		return 0, err
+	}
+	for _, opt := range options {
+		if strings.EqualFold(opt.keyword, "port") {
+			port, err := strconv.Atoi(opt.value)
+			if err != nil {
+				return 0, fmt.Errorf("invalid port %q in ssh config: %w", opt.value, err)
+			}
+			return port, nil
+		}
+	}
+	return 0, errors.New("no port in ssh config")
+}
+
+// proxyCommand returns the ProxyCommand running rdctl at the given path.
+func proxyCommand(rdctlPath string) string {
+	// ssh_config doesn't support escapes in quoted arguments; paths can't
+	// contain quotes anyway on Windows, and rarely do elsewhere.
+	return fmt.Sprintf(`"%s" ssh-config --proxy`, rdctlPath)
+}
@@ -0,0 +1,40 @@
+package sshconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const limaConfig = `# This SSH config file can be passed to 'ssh -F'.
+# Modifications to this file will be lost on restarting the Lima instance.
+Host lima-0
+  IdentityFile "/home/user/.local/share/rancher-desktop/lima/_config/user"
+  StrictHostKeyChecking no
+  User user
+  ControlPath "/home/user/.local/share/rancher-desktop/lima/0/ssh.sock"
+  Hostname 127.0.0.1
+  Port=60022
+`
+
+func TestLimaStanza(t *testing.T) {
+	stanza, err := limaStanza(limaConfig, "rd", proxyCommand("/opt/rancher-desktop/rdctl"))
+	require.NoError(t, err)
+	assert.Equal(t, `Host rd
+  ProxyCommand "/opt/rancher-desktop/rdctl" ssh-config --proxy
+  IdentityFile "/home/user/.local/share/rancher-desktop/lima/_config/user"
+  StrictHostKeyChecking no
+  User user
+  ControlPath "/home/user/.local/share/rancher-desktop/lima/0/ssh.sock"
+`, stanza)
+}
+
+func TestLimaPort(t *testing.T) {
+	port, err := limaPort(limaConfig)
+	require.NoError(t, err)
+	assert.Equal(t, 60022, port)
+
+	_, err = limaPort("Host lima-0\n  User user\n")
+	assert.ErrorContains(t, err, "no port")
+}
@@ -0,0 +1,68 @@
+//go:build unix
+
+package sshconfig
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+)
+
+// limaConfigPath returns the path of the ssh config that Lima writes for the
+// VM when it starts.
+func limaConfigPath(appPaths paths.Paths) string {
+	return filepath.Join(appPaths.Lima, "0", "ssh.config")
+}
+
+func readLimaConfig(appPaths paths.Paths) (string, error) {
+	contents, err := os.ReadFile(limaConfigPath(appPaths))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", errors.New("the Rancher Desktop VM has not been started yet")
+	} else if err != nil {
+		return "", fmt.Errorf("failed to read ssh config of the VM: %w", err)
+	}
+	return string(contents), nil
+}
+
+// Generate returns the stanza for the host, with rdctl at rdctlPath as the
+// proxy. It is based on the ssh config written by Lima, so that the keys and
+// user of Lima are used.
+func Generate(appPaths paths.Paths, host, rdctlPath string) (string, error) {
+	limaConfig, err := readLimaConfig(appPaths)
+	if err != nil {
+		return "", err
+	}
+	return limaStanza(limaConfig, host, proxyCommand(rdctlPath))
+}
+
+// Proxy connects stdin and stdout to the ssh server of the VM, which Lima
+// forwards to a local port.
+func Proxy(appPaths paths.Paths, stdin io.Reader, stdout io.Writer) error {
+	limaConfig, err := readLimaConfig(appPaths)
+	if err != nil {
+		return err
+	}
+	port, err := limaPort(limaConfig)
+	if err != nil {
+		return err
+	}
+	conn, err := net.Dial("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(port)))
+	if err != nil {
+		return fmt.Errorf("failed to connect to the VM: %w", err)
+	}
+	defer conn.Close()
+	go func() {
+		_, _ = io.Copy(conn, stdin)
+		// Let the server know that the client is done.
+		_ = conn.(*net.TCPConn).CloseWrite()
+	}()
+	// The session is over once the server closes the connection.
+	_, err = io.Copy(stdout, conn)
+	return err
+}
@@ -0,0 +1,103 @@
+package sshconfig
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/vm"
+)
+
+// installScript installs sshd in the WSL distribution, which doesn't ship it,
+// from the Alpine package repositories. Its output goes to stderr, as stdout
+// carries the ssh connection when it is run by the proxy.
+const installScript = `
+set -o errexit
+if ! command -v sshd >/dev/null; then
+  if ! command -v apk >/dev/null; then
+    echo "sshd is not installed in the Rancher Desktop VM, and can't be installed" >&2
+    exit 1
+  fi
+  echo "Installing sshd in the Rancher Desktop VM" >&2
+  apk add --quiet --no-progress openssh-server >&2
+fi
+ssh-keygen -A >/dev/null
+`
+
+// proxyScript runs sshd in inetd mode in the WSL distribution, after
+// installing it if needed and authorizing the public key given as its
+// argument.
+const proxyScript = installScript + `
+mkdir -p -m 700 /root/.ssh
+touch /root/.ssh/authorized_keys
+grep -qxF "$1" /root/.ssh/authorized_keys || echo "$1" >> /root/.ssh/authorized_keys
+exec "$(command -v sshd)" -i
+`
+
+// keyPath returns the path of the private key used to connect to the VM.
+func keyPath(appPaths paths.Paths) string {
+	return filepath.Join(appPaths.AppHome, "ssh", "id_ed25519")
+}
+
+// ensureKey creates the key used to connect to the VM, if needed.
+func ensureKey(appPaths paths.Paths) error {
+	key := keyPath(appPaths)
+	if _, err := os.Stat(key); err == nil {
+		return nil
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to check %q: %w", key, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(key), 0o700); err != nil {
+		return fmt.Errorf("failed to create directory for the ssh key: %w", err)
+	}
+	output, err := exec.Command("ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-C", "rancher-desktop", "-f", key).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to create ssh key: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// Generate returns the stanza for the host, with rdctl at rdctlPath as the
+// proxy. It creates the key used to connect to the VM, as root, and installs
+// sshd in the VM, so that the first connection doesn't have to.
+func Generate(appPaths paths.Paths, host, rdctlPath string) (string, error) {
+	if err := ensureKey(appPaths); err != nil {
+		return "", err
+	}
+	runner, err := vm.New(appPaths)
+	if err != nil {
+		return "", err
+	}
+	if _, err := runner.RootOutput("/bin/sh", "-c", installScript); err != nil {
+		return "", fmt.Errorf("failed to install sshd in the VM: %w", err)
+	}
+	lines := []string{
+		"Host " + host,
+		"  ProxyCommand " + proxyCommand(rdctlPath),
+		"  User root",
+		fmt.Sprintf(`  IdentityFile "%s"`, keyPath(appPaths)),
+		"  IdentitiesOnly yes",
+		// sshd generates its host keys in the VM, which may be recreated.
+		"  StrictHostKeyChecking no",
+		"  UserKnownHostsFile NUL",
+	}
+	return strings.Join(lines, "\n") + "\n", nil
+}
+
+// Proxy connects stdin and stdout to sshd in the WSL distribution.
+func Proxy(appPaths paths.Paths, stdin io.Reader, stdout io.Writer) error {
+	publicKey, err := os.ReadFile(keyPath(appPaths) + ".pub")
+	if err != nil {
+		return fmt.Errorf("failed to read ssh key; run 'rdctl ssh-config' first: %w", err)
+	}
+	runner, err := vm.New(appPaths)
+	if err != nil {
+		return err
+	}
+	return runner.RootStream(stdin, stdout, "/bin/sh", "-c", proxyScript, "sh", strings.TrimSpace(string(publicKey)))
+}
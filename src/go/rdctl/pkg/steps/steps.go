@@ -0,0 +1,140 @@
+// Package steps runs the independent steps of compound commands (such as
+// factory-reset) and reports on their outcome. By default every step is run
+// even if an earlier one failed, and the command is reported as a partial
+// success; with fail-fast enabled, the first failure stops the remaining steps.
+package steps
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/exitcode"
+	"github.com/sirupsen/logrus"
+)
+
+// Outcome describes what happened to a single step.
+type Outcome string
+
+const (
+	Succeeded Outcome = "succeeded"
+	Failed    Outcome = "failed"
+	Skipped   Outcome = "skipped"
+)
+
+// Step is the record of a single step that was run (or skipped).
+type Step struct {
+	Name    string
+	Outcome Outcome
+	Err     error
+}
+
+// Runner runs steps and records their outcomes.
+type Runner struct {
+	// FailFast causes all steps after the first failure to be skipped.
+	FailFast bool
+	steps    []Step
+	failed   bool
+	// aborted is set when a required step failed.
+	aborted bool
+}
+
+// Run runs the step unless an earlier step failed in fail-fast mode, or a
+// required step failed. It returns whether the step was run and succeeded.
+func (r *Runner) Run(name string, step func() error) bool {
+	if r.aborted || (r.failed && r.FailFast) {
+		r.steps = append(r.steps, Step{Name: name, Outcome: Skipped})
+		return false
+	}
+	logrus.Debugf("Running step: %s", name)
+	if err := step(); err != nil {
+		logrus.Errorf("Failed to %s: %s", name, err)
+		r.failed = true
+		r.steps = append(r.steps, Step{Name: name, Outcome: Failed, Err: err})
+		return false
+	}
+	r.steps = append(r.steps, Step{Name: name, Outcome: Succeeded})
+	return true
+}
+
+// RunRequired runs a step that the following steps depend on: if it fails,
+// all the following steps are skipped, even without fail-fast.
+func (r *Runner) RunRequired(name string, step func() error) bool {
+	if r.Run(name, step) {
+		return true
+	}
+	r.aborted = true
+	return false
+}
+
+// Steps returns the record of all steps run so far.
+func (r *Runner) Steps() []Step {
+	return r.steps
+}
+
+// Err returns nil if every step succeeded. Otherwise, it returns an error
+// whose exit code is exitcode.Failure if no step succeeded, and
+// exitcode.PartialSuccess if some did.
+func (r *Runner) Err() error {
+	if !r.failed {
+		return nil
+	}
+	return &Error{Steps: r.steps}
+}
+
+// WriteReport writes a summary of the outcome of each step.
+func (r *Runner) WriteReport(w io.Writer, command string) error {
+	succeeded := 0
+	for _, step := range r.steps {
+		if step.Outcome == Succeeded {
+			succeeded++
+		}
+	}
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "%s: %d of %d steps succeeded\n", command, succeeded, len(r.steps))
+	for _, step := range r.steps {
+		fmt.Fprintf(&builder, "  %-9s %s", step.Outcome, step.Name)
+		if step.Err != nil {
+			fmt.Fprintf(&builder, ": %s", step.Err)
+		}
+		builder.WriteString("\n")
+	}
+	_, err := io.WriteString(w, builder.String())
+	return err
+}
+
+// Error is returned by Runner.Err when at least one step failed.
+type Error struct {
+	Steps []Step
+}
+
+func (e *Error) Error() string {
+	var failures []string
+	for _, step := range e.Steps {
+		if step.Outcome == Failed {
+			failures = append(failures, fmt.Sprintf("failed to %s: %s", step.Name, step.Err))
+		}
+	}
+	return strings.Join(failures, "; ")
+}
+
+// Unwrap returns the errors of the failed steps.
+func (e *Error) Unwrap() []error {
+	var errs []error
+	for _, step := range e.Steps {
+		if step.Err != nil {
+			errs = append(errs, step.Err)
+		}
+	}
+	return errs
+}
+
+// ExitCode implements exitcode.Coder.
+func (e *Error) ExitCode() int {
+	for _, step := range e.Steps {
+		if step.Outcome == Succeeded {
+			return exitcode.PartialSuccess
+		}
+	}
+	return exitcode.Failure
+}
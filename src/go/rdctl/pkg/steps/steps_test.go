@@ -0,0 +1,80 @@
+package steps
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/exitcode"
+)
+
+func TestRunner(t *testing.T) {
+	fail := func() error { return errors.New("boom") }
+	succeed := func() error { return nil }
+
+	testCases := []struct {
+		Name     string
+		FailFast bool
+		Steps    []func() error
+		Outcomes []Outcome
+		ExitCode int
+	}{
+		{"all succeed", false, []func() error{succeed, succeed}, []Outcome{Succeeded, Succeeded}, exitcode.Success},
+		{"partial", false, []func() error{fail, succeed}, []Outcome{Failed, Succeeded}, exitcode.PartialSuccess},
+		{"all fail", false, []func() error{fail, fail}, []Outcome{Failed, Failed}, exitcode.Failure},
+		{"fail fast", true, []func() error{fail, succeed}, []Outcome{Failed, Skipped}, exitcode.Failure},
+		{"fail fast after success", true, []func() error{succeed, fail, succeed}, []Outcome{Succeeded, Failed, Skipped}, exitcode.PartialSuccess},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(t *testing.T) {
+			runner := &Runner{FailFast: testCase.FailFast}
+			for _, step := range testCase.Steps {
+				runner.Run("step", step)
+			}
+			if len(runner.Steps()) != len(testCase.Outcomes) {
+				t.Fatalf("expected %d steps, got %d", len(testCase.Outcomes), len(runner.Steps()))
+			}
+			for i, step := range runner.Steps() {
+				if step.Outcome != testCase.Outcomes[i] {
+					t.Errorf("step %d: expected %s, got %s", i, testCase.Outcomes[i], step.Outcome)
+				}
+			}
+			if code := exitcode.FromError(runner.Err()); code != testCase.ExitCode {
+				t.Errorf("expected exit code %d, got %d", testCase.ExitCode, code)
+			}
+		})
+	}
+}
+
+func TestRunRequired(t *testing.T) {
+	runner := &Runner{}
+	runner.Run("optional", func() error { return errors.New("boom") })
+	runner.RunRequired("required", func() error { return errors.New("boom") })
+	ran := false
+	runner.Run("dependent", func() error { ran = true; return nil })
+	if ran {
+		t.Error("a step after a failed required step was run")
+	}
+	var outcomes []Outcome
+	for _, step := range runner.Steps() {
+		outcomes = append(outcomes, step.Outcome)
+	}
+	if expected := []Outcome{Failed, Failed, Skipped}; !slices.Equal(outcomes, expected) {
+		t.Errorf("expected outcomes %v, got %v", expected, outcomes)
+	}
+}
+
+func TestWriteReport(t *testing.T) {
+	runner := &Runner{}
+	runner.Run("do one thing", func() error { return nil })
+	runner.Run("do another", func() error { return errors.New("boom") })
+	var buf bytes.Buffer
+	if err := runner.WriteReport(&buf, "test"); err != nil {
+		t.Fatalf("failed to write report: %s", err)
+	}
+	expected := "test: 1 of 2 steps succeeded\n  succeeded do one thing\n  failed    do another: boom\n"
+	if buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+}
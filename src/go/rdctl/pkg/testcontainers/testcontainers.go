@@ -0,0 +1,155 @@
+// Package testcontainers checks that Testcontainers libraries (such as
+// testcontainers-java and testcontainers-go) can use the Rancher Desktop
+// container engine, and computes the environment variables they need.
+package testcontainers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/checks"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/vm"
+)
+
+// VMDockerSocket is the path of the docker socket in the VM; Testcontainers
+// mounts it into its reaper (Ryuk) container.
+const VMDockerSocket = "/var/run/docker.sock"
+
+// bridgedInterface is the interface of the VM on the network of the host,
+// when the VM has one (on macOS, with administrative access).
+const bridgedInterface = "rd0"
+
+// Preset holds the settings that Testcontainers needs.
+var Preset = map[string]any{
+	"containerEngine": map[string]any{"name": "moby"},
+}
+
+// Variable is an environment variable that Testcontainers reads.
+type Variable struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Verifier computes and checks the environment of Testcontainers.
+type Verifier struct {
+	// EngineName is the container engine in the settings.
+	EngineName string
+	// DockerHost is the endpoint of the Rancher Desktop docker engine.
+	DockerHost string
+	// DefaultDockerHost is the endpoint docker uses without DOCKER_HOST; it
+	// is empty if it is always the Rancher Desktop engine (on Windows).
+	DefaultDockerHost string
+	// Getenv returns the value of an environment variable.
+	Getenv func(name string) string
+	// VM runs commands in the VM; it is nil on Windows, where published
+	// ports are always reachable on localhost.
+	VM vm.Runner
+	// FailFast skips the remaining checks once one has failed.
+	FailFast bool
+}
+
+// Environment returns the environment variables Testcontainers needs.
+func (v *Verifier) Environment() []Variable {
+	if v.DefaultDockerHost == "" {
+		return nil
+	}
+	variables := []Variable{
+		{Name: "DOCKER_HOST", Value: v.DockerHost},
+		// The socket on the host can't be mounted into containers, as it
+		// doesn't exist in the VM.
+		{Name: "TESTCONTAINERS_DOCKER_SOCKET_OVERRIDE", Value: VMDockerSocket},
+	}
+	if address := v.bridgedAddress(); address != "" {
+		// Published ports are reachable on the address of the VM, not on
+		// localhost.
+		variables = append(variables, Variable{Name: "TESTCONTAINERS_HOST_OVERRIDE", Value: address})
+	}
+	return variables
+}
+
+// bridgedAddress returns the IPv4 address of the VM on the network of the
+// host, if it has one.
+func (v *Verifier) bridgedAddress() string {
+	if v.VM == nil {
+		return ""
+	}
+	// The output looks like "3: rd0    inet 192.168.205.2/24 brd ...".
+	output, err := v.VM.RootOutput("ip", "-4", "-o", "addr", "show", "dev", bridgedInterface)
+	if err != nil {
+		return ""
+	}
+	fields := strings.Fields(string(output))
+	for i, field := range fields {
+		if field == "inet" && i+1 < len(fields) {
+			address, _, _ := strings.Cut(fields[i+1], "/")
+			return address
+		}
+	}
+	return ""
+}
+
+// Run runs all the checks.
+func (v *Verifier) Run() []checks.Check {
+	results := []checks.Check{v.checkEngine()}
+	if v.FailFast && results[0].Status == checks.Failed {
+		return append(results, checks.Check{
+			Name:    "environment",
+			Status:  checks.Skipped,
+			Message: "skipped after an earlier failure",
+		})
+	}
+	expected := map[string]string{}
+	for _, variable := range v.Environment() {
+		expected[variable.Name] = variable.Value
+	}
+	if v.DefaultDockerHost == "" {
+		return append(results, checks.Check{
+			Name:    "environment",
+			Status:  checks.OK,
+			Message: "Testcontainers uses the default docker endpoint, which is Rancher Desktop's",
+		})
+	}
+	for _, name := range []string{"DOCKER_HOST", "TESTCONTAINERS_DOCKER_SOCKET_OVERRIDE", "TESTCONTAINERS_HOST_OVERRIDE"} {
+		results = append(results, v.checkVariable(name, expected[name]))
+	}
+	return results
+}
+
+func (v *Verifier) checkEngine() checks.Check {
+	check := checks.Check{Name: "container engine"}
+	if v.EngineName == "moby" {
+		check.Status = checks.OK
+		check.Message = "the moby engine is selected"
+	} else {
+		check.Status = checks.Failed
+		check.Message = fmt.Sprintf("Testcontainers requires the moby engine, not %s; run 'rdctl verify --testcontainers --apply-preset'", v.EngineName)
+	}
+	return check
+}
+
+func (v *Verifier) checkVariable(name, expected string) checks.Check {
+	check := checks.Check{Name: name}
+	actual := v.Getenv(name)
+	switch {
+	case actual == expected:
+		check.Status = checks.OK
+		if expected == "" {
+			check.Message = "not set; published ports are reachable on localhost"
+		} else {
+			check.Message = fmt.Sprintf("set to %s", actual)
+		}
+	case name == "DOCKER_HOST" && actual == "" && checks.SameDockerEndpoint(v.DefaultDockerHost, expected):
+		check.Status = checks.OK
+		check.Message = fmt.Sprintf("not set; the default endpoint %s is Rancher Desktop's", v.DefaultDockerHost)
+	case expected == "":
+		check.Status = checks.Warning
+		check.Message = fmt.Sprintf("set to %s, but published ports are reachable on localhost; unset it", actual)
+	case actual == "":
+		check.Status = checks.Warning
+		check.Message = fmt.Sprintf("not set; set it to %s (see 'rdctl verify --testcontainers --env')", expected)
+	default:
+		check.Status = checks.Warning
+		check.Message = fmt.Sprintf("set to %s instead of %s", actual, expected)
+	}
+	return check
+}
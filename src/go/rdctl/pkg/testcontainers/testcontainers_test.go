@@ -0,0 +1,106 @@
+package testcontainers
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/checks"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeVM struct {
+	output string
+	err    error
+}
+
+func (f fakeVM) RootOutput(args ...string) ([]byte, error) {
+	return []byte(f.output), f.err
+}
+
+func (f fakeVM) RootStream(stdin io.Reader, stdout io.Writer, args ...string) error {
+	return errors.New("not implemented")
+}
+
+func environ(variables map[string]string) func(string) string {
+	return func(name string) string {
+		return variables[name]
+	}
+}
+
+func statuses(results []checks.Check) map[string]checks.Status {
+	result := map[string]checks.Status{}
+	for _, check := range results {
+		result[check.Name] = check.Status
+	}
+	return result
+}
+
+func TestEnvironment(t *testing.T) {
+	verifier := Verifier{
+		DockerHost:        "unix:///home/user/.rd/docker.sock",
+		DefaultDockerHost: "unix:///var/run/docker.sock",
+		VM:                fakeVM{err: errors.New(`Device "rd0" does not exist.`)},
+	}
+	t.Run("shared network", func(t *testing.T) {
+		assert.Equal(t, []Variable{
+			{Name: "DOCKER_HOST", Value: "unix:///home/user/.rd/docker.sock"},
+			{Name: "TESTCONTAINERS_DOCKER_SOCKET_OVERRIDE", Value: "/var/run/docker.sock"},
+		}, verifier.Environment())
+	})
+	t.Run("bridged network", func(t *testing.T) {
+		verifier := verifier
+		verifier.VM = fakeVM{output: "3: rd0    inet 192.168.205.2/24 brd 192.168.205.255 scope global dynamic rd0\n"}
+		assert.Contains(t, verifier.Environment(), Variable{Name: "TESTCONTAINERS_HOST_OVERRIDE", Value: "192.168.205.2"})
+	})
+	t.Run("windows", func(t *testing.T) {
+		verifier := Verifier{DockerHost: "npipe:////./pipe/docker_engine"}
+		assert.Empty(t, verifier.Environment())
+	})
+}
+
+func TestRun(t *testing.T) {
+	verifier := Verifier{
+		EngineName:        "moby",
+		DockerHost:        "unix:///home/user/.rd/docker.sock",
+		DefaultDockerHost: "unix:///var/run/docker.sock",
+		VM:                fakeVM{err: errors.New(`Device "rd0" does not exist.`)},
+	}
+	t.Run("configured", func(t *testing.T) {
+		verifier := verifier
+		verifier.Getenv = environ(map[string]string{
+			"DOCKER_HOST":                           "unix:///home/user/.rd/docker.sock",
+			"TESTCONTAINERS_DOCKER_SOCKET_OVERRIDE": "/var/run/docker.sock",
+		})
+		results := verifier.Run()
+		assert.Equal(t, map[string]checks.Status{
+			"container engine":                      checks.OK,
+			"DOCKER_HOST":                           checks.OK,
+			"TESTCONTAINERS_DOCKER_SOCKET_OVERRIDE": checks.OK,
+			"TESTCONTAINERS_HOST_OVERRIDE":          checks.OK,
+		}, statuses(results))
+	})
+	t.Run("unconfigured", func(t *testing.T) {
+		verifier := verifier
+		verifier.EngineName = "containerd"
+		verifier.Getenv = environ(map[string]string{"TESTCONTAINERS_HOST_OVERRIDE": "10.0.0.1"})
+		results := verifier.Run()
+		assert.Equal(t, map[string]checks.Status{
+			"container engine":                      checks.Failed,
+			"DOCKER_HOST":                           checks.Warning,
+			"TESTCONTAINERS_DOCKER_SOCKET_OVERRIDE": checks.Warning,
+			"TESTCONTAINERS_HOST_OVERRIDE":          checks.Warning,
+		}, statuses(results))
+	})
+	t.Run("fail fast", func(t *testing.T) {
+		verifier := verifier
+		verifier.EngineName = "containerd"
+		verifier.FailFast = true
+		verifier.Getenv = environ(nil)
+		results := verifier.Run()
+		assert.Equal(t, map[string]checks.Status{
+			"container engine": checks.Failed,
+			"environment":      checks.Skipped,
+		}, statuses(results))
+	})
+}
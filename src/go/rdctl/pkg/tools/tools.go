@@ -0,0 +1,38 @@
+// Package tools locates the command line tools shipped with Rancher Desktop,
+// such as kubectl, docker and nerdctl.
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+)
+
+// Find returns the path to the named tool. The integration directory (which
+// holds the symlinks managed by the application) is preferred over the copies
+// in the application resources, where binaries for the architecture of the
+// machine are preferred over those for the architecture of rdctl.
+func Find(appPaths paths.Paths, name string) (string, error) {
+	var candidates []string
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	} else {
+		candidates = append(candidates, filepath.Join(appPaths.Integration, name))
+	}
+	for _, dir := range appPaths.ResourceDirs("bin") {
+		candidates = append(candidates, filepath.Join(dir, name))
+	}
+	for _, candidate := range candidates {
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate, nil
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("failed to check %q: %w", candidate, err)
+		}
+	}
+	return "", fmt.Errorf("could not find %s in %s", name, strings.Join(candidates, ", "))
+}
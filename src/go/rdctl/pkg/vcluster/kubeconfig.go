@@ -0,0 +1,93 @@
+package vcluster
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// credentials are the parts of the K3s admin kubeconfig needed to connect to
+// the virtual cluster.
+type credentials struct {
+	CertificateAuthorityData string
+	ClientCertificateData    string
+	ClientKeyData            string
+}
+
+// parseCredentials extracts the credentials from the kubeconfig generated by
+// K3s. As that file has a fixed layout with a single cluster and user, the
+// keys are scanned for instead of parsing the YAML.
+func parseCredentials(kubeconfig []byte) (*credentials, error) {
+	var result credentials
+	fields := map[string]*string{
+		"certificate-authority-data": &result.CertificateAuthorityData,
+		"client-certificate-data":    &result.ClientCertificateData,
+		"client-key-data":            &result.ClientKeyData,
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(kubeconfig))
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		key, value, found := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if target, ok := fields[key]; ok && found {
+			*target = strings.Trim(strings.TrimSpace(value), `"'`)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read virtual cluster kubeconfig: %w", err)
+	}
+	for key, value := range fields {
+		if *value == "" {
+			return nil, fmt.Errorf("virtual cluster kubeconfig is missing %s", key)
+		}
+	}
+	return &result, nil
+}
+
+// addContext adds (or replaces) the cluster, user, and context for the
+// virtual cluster in the user's kubeconfig.
+func (m *Manager) addContext(name, server string, creds *credentials) error {
+	entry := ContextName(name)
+	commands := [][]string{
+		{"config", "set-cluster", entry, "--server", server},
+		{"config", "set", fmt.Sprintf("clusters.%s.certificate-authority-data", entry), creds.CertificateAuthorityData},
+		{"config", "set-credentials", entry},
+		{"config", "set", fmt.Sprintf("users.%s.client-certificate-data", entry), creds.ClientCertificateData},
+		{"config", "set", fmt.Sprintf("users.%s.client-key-data", entry), creds.ClientKeyData},
+		{"config", "set-context", entry, "--cluster", entry, "--user", entry},
+	}
+	for _, args := range commands {
+		if _, err := m.kubectl.Output(nil, args...); err != nil {
+			return fmt.Errorf("failed to add context %q to the kubeconfig: %w", entry, err)
+		}
+	}
+	return nil
+}
+
+// removeContext removes the entries added by addContext, returning whether
+// the context existed.
+func (m *Manager) removeContext(name string) (bool, error) {
+	entry := ContextName(name)
+	output, err := m.kubectl.Output(nil, "config", "get-contexts", "--output", "name")
+	if err != nil {
+		return false, fmt.Errorf("failed to read the kubeconfig: %w", err)
+	}
+	found := false
+	for _, context := range strings.Fields(string(output)) {
+		found = found || context == entry
+	}
+	if !found {
+		return false, nil
+	}
+	var errs []error
+	for _, kind := range []string{"context", "cluster", "user"} {
+		if _, err := m.kubectl.Output(nil, "config", "delete-"+kind, entry); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return true, fmt.Errorf("failed to remove context %q from the kubeconfig: %w", entry, err)
+	}
+	return true, nil
+}
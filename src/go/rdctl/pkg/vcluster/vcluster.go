@@ -0,0 +1,306 @@
+// Package vcluster manages virtual clusters: additional, lightweight K3s
+// clusters that run as workloads inside the Rancher Desktop cluster. They
+// allow testing against other Kubernetes versions without resetting the main
+// cluster.
+//
+// Each virtual cluster lives in its own namespace, runs K3s in a privileged
+// pod, and exposes its API server through a NodePort service that Rancher
+// Desktop forwards to the host. Access is provided through a kubeconfig
+// context named after the virtual cluster.
+package vcluster
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/kubectl"
+)
+
+const (
+	// managedByLabel marks the resources that belong to a virtual cluster.
+	managedByLabel = "app.kubernetes.io/managed-by=rdctl-vcluster"
+	nameLabel      = "rancherdesktop.io/vcluster"
+	versionLabel   = "rancherdesktop.io/vcluster-version"
+	// kubeconfigPath is the location of the admin kubeconfig in the K3s pod.
+	kubeconfigPath = "/etc/rancher/k3s/k3s.yaml"
+)
+
+var namePattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]{0,38}[a-z0-9])?$`)
+
+// ErrNotFound is returned when a virtual cluster does not exist.
+var ErrNotFound = errors.New("virtual cluster not found")
+
+// VCluster describes an existing virtual cluster.
+type VCluster struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Ready   bool   `json:"ready"`
+	Context string `json:"context"`
+}
+
+// Manager creates and removes virtual clusters in the Rancher Desktop cluster.
+type Manager struct {
+	// Timeout is how long Create waits for the virtual cluster to come up.
+	Timeout time.Duration
+	kubectl kubectl.Runner
+}
+
+func NewManager(runner kubectl.Runner) *Manager {
+	return &Manager{
+		Timeout: 5 * time.Minute,
+		kubectl: runner,
+	}
+}
+
+// ContextName returns the kubeconfig context for the virtual cluster.
+func ContextName(name string) string {
+	return "vcluster-" + name
+}
+
+func namespace(name string) string {
+	return "vcluster-" + name
+}
+
+// ValidateName checks that the name can be used for the Kubernetes resources
+// of a virtual cluster.
+func ValidateName(name string) error {
+	if !namePattern.MatchString(name) {
+		return fmt.Errorf("invalid virtual cluster name %q: must be at most 40 lowercase letters, digits, and dashes, and must start and end with a letter or digit", name)
+	}
+	return nil
+}
+
+// cluster runs kubectl against the Rancher Desktop cluster.
+func (m *Manager) cluster(stdin []byte, args ...string) ([]byte, error) {
+	args = append(args, "--context", kubectl.Context)
+	if stdin == nil {
+		return m.kubectl.Output(nil, args...)
+	}
+	return m.kubectl.Output(bytes.NewReader(stdin), args...)
+}
+
+// Create starts a virtual cluster running the given full K3s version, waits
+// for it to be ready, and adds its context to the kubeconfig.
+func (m *Manager) Create(name, version string) (*VCluster, error) {
+	if err := ValidateName(name); err != nil {
+		return nil, err
+	}
+	existing, err := m.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, vcluster := range existing {
+		if vcluster.Name == name {
+			return nil, fmt.Errorf("virtual cluster %q already exists", name)
+		}
+	}
+	manifest, err := Manifest(name, version)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := m.cluster(manifest, "apply", "--filename", "-"); err != nil {
+		return nil, fmt.Errorf("failed to create virtual cluster: %w", err)
+	}
+	ns := namespace(name)
+	if _, err := m.cluster(nil, "rollout", "status", "statefulset/k3s", "--namespace", ns, "--timeout", m.Timeout.String()); err != nil {
+		return nil, fmt.Errorf("virtual cluster did not become ready: %w", err)
+	}
+	nodePort, err := m.cluster(nil, "get", "service", "k3s", "--namespace", ns, "--output", "jsonpath={.spec.ports[0].nodePort}")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the API server port: %w", err)
+	}
+	port, err := strconv.Atoi(strings.TrimSpace(string(nodePort)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid API server port %q: %w", nodePort, err)
+	}
+	credentials, err := m.waitForCredentials(ns)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.addContext(name, fmt.Sprintf("https://127.0.0.1:%d", port), credentials); err != nil {
+		return nil, err
+	}
+	return &VCluster{Name: name, Version: version, Ready: true, Context: ContextName(name)}, nil
+}
+
+// waitForCredentials reads the admin credentials from the K3s pod; K3s only
+// writes them some time after the pod has started.
+func (m *Manager) waitForCredentials(ns string) (*credentials, error) {
+	const interval = 2 * time.Second
+	deadline := time.Now().Add(m.Timeout)
+	for {
+		data, err := m.cluster(nil, "exec", "k3s-0", "--namespace", ns, "--", "cat", kubeconfigPath)
+		if err == nil {
+			return parseCredentials(data)
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("failed to read the virtual cluster kubeconfig: %w", err)
+		}
+		time.Sleep(interval)
+	}
+}
+
+// Delete removes the virtual cluster and its kubeconfig context.
+func (m *Manager) Delete(name string) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+	found := false
+	existing, err := m.List()
+	if err != nil {
+		return err
+	}
+	for _, vcluster := range existing {
+		found = found || vcluster.Name == name
+	}
+	if found {
+		if _, err := m.cluster(nil, "delete", "namespace", namespace(name)); err != nil {
+			return fmt.Errorf("failed to delete virtual cluster: %w", err)
+		}
+	}
+	removed, err := m.removeContext(name)
+	if err != nil {
+		return err
+	}
+	if !found && !removed {
+		return fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	return nil
+}
+
+// List returns the virtual clusters, sorted by name.
+func (m *Manager) List() ([]VCluster, error) {
+	data, err := m.cluster(nil, "get", "statefulsets", "--all-namespaces", "--selector", managedByLabel, "--output", "json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list virtual clusters: %w", err)
+	}
+	var statefulSets struct {
+		Items []struct {
+			Metadata struct {
+				Labels map[string]string `json:"labels"`
+			} `json:"metadata"`
+			Status struct {
+				ReadyReplicas int `json:"readyReplicas"`
+			} `json:"status"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(data, &statefulSets); err != nil {
+		return nil, fmt.Errorf("failed to parse virtual clusters: %w", err)
+	}
+	vclusters := []VCluster{}
+	for _, item := range statefulSets.Items {
+		name := item.Metadata.Labels[nameLabel]
+		vclusters = append(vclusters, VCluster{
+			Name:    name,
+			Version: versionFromLabel(item.Metadata.Labels[versionLabel]),
+			Ready:   item.Status.ReadyReplicas > 0,
+			Context: ContextName(name),
+		})
+	}
+	sort.Slice(vclusters, func(i, j int) bool { return vclusters[i].Name < vclusters[j].Name })
+	return vclusters, nil
+}
+
+var manifestTemplate = template.Must(template.New("manifest").Parse(`apiVersion: v1
+kind: Namespace
+metadata:
+  name: {{ .Namespace }}
+  labels:
+    app.kubernetes.io/managed-by: rdctl-vcluster
+    rancherdesktop.io/vcluster: {{ .Name }}
+    rancherdesktop.io/vcluster-version: {{ .VersionLabel }}
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: k3s
+  namespace: {{ .Namespace }}
+  labels:
+    app.kubernetes.io/managed-by: rdctl-vcluster
+    rancherdesktop.io/vcluster: {{ .Name }}
+    rancherdesktop.io/vcluster-version: {{ .VersionLabel }}
+spec:
+  type: NodePort
+  selector:
+    rancherdesktop.io/vcluster: {{ .Name }}
+  ports:
+    - name: https
+      port: 6443
+      targetPort: 6443
+---
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: k3s
+  namespace: {{ .Namespace }}
+  labels:
+    app.kubernetes.io/managed-by: rdctl-vcluster
+    rancherdesktop.io/vcluster: {{ .Name }}
+    rancherdesktop.io/vcluster-version: {{ .VersionLabel }}
+spec:
+  replicas: 1
+  serviceName: k3s
+  selector:
+    matchLabels:
+      rancherdesktop.io/vcluster: {{ .Name }}
+  template:
+    metadata:
+      labels:
+        app.kubernetes.io/managed-by: rdctl-vcluster
+        rancherdesktop.io/vcluster: {{ .Name }}
+    spec:
+      containers:
+        - name: k3s
+          image: {{ .Image }}
+          args:
+            - server
+            - --snapshotter=native
+            - --disable=traefik,servicelb,metrics-server
+            - --tls-san=127.0.0.1
+          securityContext:
+            privileged: true
+          ports:
+            - containerPort: 6443
+          readinessProbe:
+            tcpSocket:
+              port: 6443
+          volumeMounts:
+            - name: data
+              mountPath: /var/lib/rancher/k3s
+      volumes:
+        - name: data
+          emptyDir: {}
+`))
+
+// versionFromLabel reverses the replacement of the "+" in the version done
+// by Manifest; the build information is always the last part.
+func versionFromLabel(label string) string {
+	if index := strings.LastIndex(label, "-"); index >= 0 {
+		return label[:index] + "+" + label[index+1:]
+	}
+	return label
+}
+
+// Manifest returns the Kubernetes resources for a virtual cluster.
+func Manifest(name, version string) ([]byte, error) {
+	var buf bytes.Buffer
+	err := manifestTemplate.Execute(&buf, map[string]string{
+		"Name":      name,
+		"Namespace": namespace(name),
+		// Label values may not contain "+".
+		"VersionLabel": strings.ReplaceAll(version, "+", "-"),
+		"Image":        Image(version),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate virtual cluster manifest: %w", err)
+	}
+	return buf.Bytes(), nil
+}
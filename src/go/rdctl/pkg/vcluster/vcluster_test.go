@@ -0,0 +1,165 @@
+package vcluster
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeKubectl records the commands it is asked to run, and responds with the
+// output registered for the first matching command prefix.
+type fakeKubectl struct {
+	commands  []string
+	responses map[string]string
+}
+
+func (f *fakeKubectl) Output(stdin io.Reader, args ...string) ([]byte, error) {
+	command := strings.Join(args, " ")
+	f.commands = append(f.commands, command)
+	for prefix, response := range f.responses {
+		if strings.HasPrefix(command, prefix) {
+			if response == "error" {
+				return nil, errors.New("failed")
+			}
+			return []byte(response), nil
+		}
+	}
+	return nil, nil
+}
+
+func TestResolveVersion(t *testing.T) {
+	cacheDir := t.TempDir()
+	cache := `{
+		"cacheVersion": 2,
+		"versions": ["v1.26.7+k3s1", "v1.27.4+k3s1", "v1.27.4+k3s2", "v1.28.1+k3s1"],
+		"channels": {"stable": "1.27.4", "latest": "1.28.1", "v1.26": "1.26.7", "v1.27": "1.27.4"}
+	}`
+	require.NoError(t, os.WriteFile(filepath.Join(cacheDir, "k3s-versions.json"), []byte(cache), 0o644))
+
+	testCases := map[string]string{
+		"1.27":          "v1.27.4+k3s2",
+		"v1.26":         "v1.26.7+k3s1",
+		"stable":        "v1.27.4+k3s2",
+		"latest":        "v1.28.1+k3s1",
+		"1.28.1":        "v1.28.1+k3s1",
+		"1.25.3":        "v1.25.3+k3s1",
+		"v1.27.4+k3s1":  "v1.27.4+k3s1",
+		"1.29.0-rc1":    "v1.29.0-rc1+k3s1",
+		"1.24":          "",
+		"not-a-version": "",
+	}
+	for input, expected := range testCases {
+		t.Run(input, func(t *testing.T) {
+			actual, err := ResolveVersion(cacheDir, input)
+			if expected == "" {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, expected, actual)
+			}
+		})
+	}
+
+	t.Run("without cache", func(t *testing.T) {
+		actual, err := ResolveVersion(t.TempDir(), "1.27.4")
+		assert.NoError(t, err)
+		assert.Equal(t, "v1.27.4+k3s1", actual)
+		_, err = ResolveVersion(t.TempDir(), "1.27")
+		assert.Error(t, err)
+	})
+}
+
+func TestValidateName(t *testing.T) {
+	for _, name := range []string{"a", "test", "k8s-1-27", strings.Repeat("a", 40)} {
+		assert.NoError(t, ValidateName(name), name)
+	}
+	for _, name := range []string{"", "-a", "a-", "Test", "a_b", "a.b", strings.Repeat("a", 41)} {
+		assert.Error(t, ValidateName(name), name)
+	}
+}
+
+func TestManifest(t *testing.T) {
+	manifest, err := Manifest("test", "v1.27.4+k3s1")
+	require.NoError(t, err)
+	assert.Contains(t, string(manifest), "image: rancher/k3s:v1.27.4-k3s1\n")
+	assert.Contains(t, string(manifest), "rancherdesktop.io/vcluster-version: v1.27.4-k3s1\n")
+	assert.Contains(t, string(manifest), "namespace: vcluster-test\n")
+	assert.Equal(t, "v1.27.4+k3s1", versionFromLabel("v1.27.4-k3s1"))
+	assert.Equal(t, "v1.28.0-rc1+k3s1", versionFromLabel("v1.28.0-rc1-k3s1"))
+}
+
+func TestParseCredentials(t *testing.T) {
+	kubeconfig := `apiVersion: v1
+clusters:
+- cluster:
+    certificate-authority-data: Q0EK
+    server: https://127.0.0.1:6443
+  name: default
+contexts:
+- context:
+    cluster: default
+    user: default
+  name: default
+current-context: default
+kind: Config
+preferences: {}
+users:
+- name: default
+  user:
+    client-certificate-data: Q0VSVAo=
+    client-key-data: S0VZCg==
+`
+	creds, err := parseCredentials([]byte(kubeconfig))
+	require.NoError(t, err)
+	assert.Equal(t, credentials{
+		CertificateAuthorityData: "Q0EK",
+		ClientCertificateData:    "Q0VSVAo=",
+		ClientKeyData:            "S0VZCg==",
+	}, *creds)
+
+	_, err = parseCredentials([]byte("apiVersion: v1\n"))
+	assert.Error(t, err)
+}
+
+func TestList(t *testing.T) {
+	runner := &fakeKubectl{responses: map[string]string{
+		"get statefulsets": `{"items": [
+			{"metadata": {"labels": {"rancherdesktop.io/vcluster": "b", "rancherdesktop.io/vcluster-version": "v1.26.7-k3s1"}}, "status": {}},
+			{"metadata": {"labels": {"rancherdesktop.io/vcluster": "a", "rancherdesktop.io/vcluster-version": "v1.27.4-k3s1"}}, "status": {"readyReplicas": 1}}
+		]}`,
+	}}
+	vclusters, err := NewManager(runner).List()
+	require.NoError(t, err)
+	assert.Equal(t, []VCluster{
+		{Name: "a", Version: "v1.27.4+k3s1", Ready: true, Context: "vcluster-a"},
+		{Name: "b", Version: "v1.26.7+k3s1", Ready: false, Context: "vcluster-b"},
+	}, vclusters)
+	assert.Equal(t, []string{"get statefulsets --all-namespaces --selector app.kubernetes.io/managed-by=rdctl-vcluster --output json --context rancher-desktop"}, runner.commands)
+}
+
+func TestDelete(t *testing.T) {
+	t.Run("existing", func(t *testing.T) {
+		runner := &fakeKubectl{responses: map[string]string{
+			"get statefulsets":    `{"items": [{"metadata": {"labels": {"rancherdesktop.io/vcluster": "a"}}}]}`,
+			"config get-contexts": "rancher-desktop\nvcluster-a\n",
+		}}
+		require.NoError(t, NewManager(runner).Delete("a"))
+		assert.Contains(t, runner.commands, "delete namespace vcluster-a --context rancher-desktop")
+		assert.Contains(t, runner.commands, "config delete-context vcluster-a")
+		assert.Contains(t, runner.commands, "config delete-cluster vcluster-a")
+		assert.Contains(t, runner.commands, "config delete-user vcluster-a")
+	})
+	t.Run("missing", func(t *testing.T) {
+		runner := &fakeKubectl{responses: map[string]string{
+			"get statefulsets":    `{"items": []}`,
+			"config get-contexts": "rancher-desktop\n",
+		}}
+		assert.ErrorIs(t, NewManager(runner).Delete("a"), ErrNotFound)
+	})
+}
@@ -0,0 +1,67 @@
+package vcluster
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// versionsCache is the subset of the K3s versions cache, maintained by the
+// application in k3s-versions.json, that is needed to resolve versions.
+type versionsCache struct {
+	// Available versions, including build information, e.g. "v1.27.4+k3s1".
+	Versions []string `json:"versions"`
+	// Mapping of channel names (e.g. "stable", "v1.27") to versions without
+	// build information, e.g. "1.27.4".
+	Channels map[string]string `json:"channels"`
+}
+
+// ResolveVersion turns a version as given by the user ("1.27", "v1.27.4",
+// "stable", ...) into a full K3s version such as "v1.27.4+k3s1", using the
+// versions cache in the given directory.
+func ResolveVersion(cacheDir, version string) (string, error) {
+	var cache versionsCache
+	data, err := os.ReadFile(filepath.Join(cacheDir, "k3s-versions.json"))
+	if err == nil {
+		if err := json.Unmarshal(data, &cache); err != nil {
+			return "", fmt.Errorf("failed to parse K3s versions cache: %w", err)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("failed to read K3s versions cache: %w", err)
+	}
+
+	if strings.Contains(version, "+") {
+		return "v" + strings.TrimPrefix(version, "v"), nil
+	}
+	target := strings.TrimPrefix(version, "v")
+	if channelVersion, ok := cache.Channels[version]; ok {
+		target = channelVersion
+	} else if channelVersion, ok := cache.Channels["v"+target]; ok {
+		target = channelVersion
+	}
+	// The cache is sorted, so the last match has the newest build.
+	result := ""
+	for _, candidate := range cache.Versions {
+		release, _, _ := strings.Cut(strings.TrimPrefix(candidate, "v"), "+")
+		if release == target {
+			result = candidate
+		}
+	}
+	if result != "" {
+		return result, nil
+	}
+	if strings.Count(target, ".") == 2 {
+		// Not in the cache (possibly because the cache doesn't exist yet); assume
+		// it's the first build of a valid release.
+		return "v" + target + "+k3s1", nil
+	}
+	return "", fmt.Errorf("unknown Kubernetes version %q; specify a full version such as 1.27.4", version)
+}
+
+// Image returns the K3s container image for the full K3s version.
+func Image(version string) string {
+	return "rancher/k3s:" + strings.ReplaceAll(version, "+", "-")
+}
@@ -0,0 +1,85 @@
+package version
+
+import (
+	"cmp"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// semver is a parsed version, e.g. v1.12.0-rc.1; build metadata is ignored.
+type semver struct {
+	numbers    [3]int
+	prerelease []string
+}
+
+func parse(version string) (semver, error) {
+	var result semver
+	core, _, _ := strings.Cut(strings.TrimPrefix(strings.TrimSpace(version), "v"), "+")
+	core, prerelease, hasPrerelease := strings.Cut(core, "-")
+	fields := strings.Split(core, ".")
+	if len(fields) != 3 {
+		return result, fmt.Errorf("invalid version %q", version)
+	}
+	for i, field := range fields {
+		number, err := strconv.Atoi(field)
+		if err != nil || number < 0 {
+			return result, fmt.Errorf("invalid version %q", version)
+		}
+		result.numbers[i] = number
+	}
+	if hasPrerelease {
+		result.prerelease = strings.Split(prerelease, ".")
+	}
+	return result, nil
+}
+
+// Compare compares two versions, e.g. 1.11.1 and v1.12.0-rc.1, by the rules
+// of semantic versioning; it returns -1, 0 or 1 as a is older than, the same
+// as, or newer than b.
+func Compare(a, b string) (int, error) {
+	versionA, err := parse(a)
+	if err != nil {
+		return 0, err
+	}
+	versionB, err := parse(b)
+	if err != nil {
+		return 0, err
+	}
+	for i := range versionA.numbers {
+		if result := cmp.Compare(versionA.numbers[i], versionB.numbers[i]); result != 0 {
+			return result, nil
+		}
+	}
+	// A pre-release comes before the release.
+	switch {
+	case len(versionA.prerelease) == 0 && len(versionB.prerelease) == 0:
+		return 0, nil
+	case len(versionA.prerelease) == 0:
+		return 1, nil
+	case len(versionB.prerelease) == 0:
+		return -1, nil
+	}
+	for i := 0; i < len(versionA.prerelease) && i < len(versionB.prerelease); i++ {
+		if result := comparePrerelease(versionA.prerelease[i], versionB.prerelease[i]); result != 0 {
+			return result, nil
+		}
+	}
+	return cmp.Compare(len(versionA.prerelease), len(versionB.prerelease)), nil
+}
+
+// comparePrerelease compares identifiers of pre-releases: numbers compare
+// numerically, and before the other identifiers.
+func comparePrerelease(a, b string) int {
+	numberA, errA := strconv.Atoi(a)
+	numberB, errB := strconv.Atoi(b)
+	switch {
+	case errA == nil && errB == nil:
+		return cmp.Compare(numberA, numberB)
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
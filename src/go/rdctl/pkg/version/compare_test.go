@@ -0,0 +1,34 @@
+package version
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompare(t *testing.T) {
+	for _, testCase := range []struct {
+		a, b     string
+		expected int
+	}{
+		{"1.11.1", "v1.11.1", 0},
+		{"1.11.1", "1.12.0", -1},
+		{"1.10.0", "1.9.3", 1},
+		{"1.12.0-rc.1", "1.12.0", -1},
+		{"1.12.0-rc.2", "1.12.0-rc.10", -1},
+		{"1.12.0-rc.1", "1.12.0-beta.1", 1},
+		{"1.12.0-rc", "1.12.0-rc.1", -1},
+		{"1.12.0+build.1", "1.12.0", 0},
+	} {
+		t.Run(testCase.a+" "+testCase.b, func(t *testing.T) {
+			result, err := Compare(testCase.a, testCase.b)
+			require.NoError(t, err)
+			assert.Equal(t, testCase.expected, result)
+		})
+	}
+	for _, invalid := range []string{"dev", "1.11", "1.11.x", ""} {
+		_, err := Compare(invalid, "1.11.1")
+		assert.Error(t, err, invalid)
+	}
+}
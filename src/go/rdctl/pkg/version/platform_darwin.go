@@ -0,0 +1,16 @@
+package version
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+)
+
+// platformVersion returns the version of macOS, e.g. 14.2.1.
+func platformVersion(ctx context.Context) string {
+	output, err := exec.CommandContext(ctx, "sw_vers", "-productVersion").Output()
+	if err != nil {
+		return "0.0.0"
+	}
+	return strings.TrimSpace(string(output))
+}
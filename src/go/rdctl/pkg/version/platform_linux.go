@@ -0,0 +1,9 @@
+package version
+
+import "context"
+
+// platformVersion returns 0.0.0, as the application does: the releases don't
+// depend on the distribution.
+func platformVersion(ctx context.Context) string {
+	return "0.0.0"
+}
@@ -0,0 +1,15 @@
+package version
+
+import (
+	"context"
+	"fmt"
+
+	"golang.org/x/sys/windows"
+)
+
+// platformVersion returns the version of Windows, e.g. 10.0.22631, as the
+// application reports it.
+func platformVersion(ctx context.Context) string {
+	info := windows.RtlGetVersion()
+	return fmt.Sprintf("%d.%d.%d", info.MajorVersion, info.MinorVersion, info.BuildNumber)
+}
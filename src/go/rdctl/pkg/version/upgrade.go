@@ -0,0 +1,129 @@
+package version
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime"
+	"sort"
+)
+
+// UpgradeURL is the Upgrade Responder server that the application asks for
+// the releases of its release channel.
+const UpgradeURL = "https://desktop.version.rancher.io/v1/checkupgrade"
+
+// Release is a release of Rancher Desktop, as listed by the Upgrade Responder
+// server.
+type Release struct {
+	Name        string `json:"name"`
+	ReleaseDate string `json:"releaseDate,omitempty"`
+}
+
+// Upgrade is the result of checking the release channel for updates.
+type Upgrade struct {
+	// Latest is the newest release supported on this host.
+	Latest Release `json:"latest"`
+	// Available is whether Latest is newer than the current version.
+	Available bool `json:"available"`
+	// UnsupportedAvailable is whether there is an even newer release, which
+	// isn't supported on this host, e.g. because the OS is too old.
+	UnsupportedAvailable bool `json:"unsupportedAvailable,omitempty"`
+}
+
+// upgradeRequest is the payload the application sends to the Upgrade
+// Responder server; the server picks the supported releases from it.
+type upgradeRequest struct {
+	AppVersion string `json:"appVersion"`
+	ExtraInfo  struct {
+		Platform        string `json:"platform"`
+		PlatformVersion string `json:"platformVersion"`
+	} `json:"extraInfo"`
+}
+
+type upgradeResponse struct {
+	Versions []upgradeVersion `json:"versions"`
+}
+
+type upgradeVersion struct {
+	Name        string
+	ReleaseDate string
+	// Supported is true if missing.
+	Supported *bool
+}
+
+// nodeNames maps the names of the Go platforms to those the application
+// reports, which are Node's.
+var nodeNames = map[string]string{
+	"windows": "win32",
+	"amd64":   "x64",
+}
+
+func nodeName(name string) string {
+	if nodeName, ok := nodeNames[name]; ok {
+		return nodeName
+	}
+	return name
+}
+
+// CheckUpgrade asks the Upgrade Responder server at url for the releases, as
+// the application does, and returns whether one is newer than current.
+func CheckUpgrade(ctx context.Context, url, current string) (*Upgrade, error) {
+	payload := upgradeRequest{AppVersion: current}
+	payload.ExtraInfo.Platform = nodeName(runtime.GOOS) + "-" + nodeName(runtime.GOARCH)
+	payload.ExtraInfo.PlatformVersion = platformVersion(ctx)
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response %s", response.Status)
+	}
+	var result upgradeResponse
+	if err := json.NewDecoder(response.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to parse the releases: %w", err)
+	}
+	return newUpgrade(result, current)
+}
+
+// newUpgrade picks the newest supported release; releases that don't have a
+// valid version are ignored.
+func newUpgrade(response upgradeResponse, current string) (*Upgrade, error) {
+	var versions []upgradeVersion
+	for _, version := range response.Versions {
+		if _, err := parse(version.Name); err == nil {
+			versions = append(versions, version)
+		}
+	}
+	sort.SliceStable(versions, func(i, j int) bool {
+		result, _ := Compare(versions[i].Name, versions[j].Name)
+		return result > 0
+	})
+	for i, version := range versions {
+		if version.Supported != nil && !*version.Supported {
+			continue
+		}
+		result, err := Compare(version.Name, current)
+		if err != nil {
+			return nil, err
+		}
+		return &Upgrade{
+			Latest:               Release{Name: version.Name, ReleaseDate: version.ReleaseDate},
+			Available:            result > 0,
+			UnsupportedAvailable: i > 0,
+		}, nil
+	}
+	return nil, errors.New("could not find the latest version")
+}
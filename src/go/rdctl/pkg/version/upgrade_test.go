@@ -0,0 +1,65 @@
+package version
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckUpgrade(t *testing.T) {
+	var request upgradeRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		_, _ = w.Write([]byte(`{
+			"versions": [
+				{"Name": "v1.11.1", "ReleaseDate": "2023-11-21T00:00:00Z", "Tags": ["v1.11.1"]},
+				{"Name": "v1.13.0", "ReleaseDate": "2024-03-01T00:00:00Z", "Supported": false, "Tags": ["v1.13.0"]},
+				{"Name": "v1.12.0", "ReleaseDate": "2024-01-10T00:00:00Z", "Supported": true, "Tags": ["v1.12.0"]},
+				{"Name": "latest", "Tags": []}
+			],
+			"requestIntervalInMinutes": 60
+		}`))
+	}))
+	defer server.Close()
+
+	upgrade, err := CheckUpgrade(context.Background(), server.URL, "1.11.1")
+	require.NoError(t, err)
+	assert.Equal(t, &Upgrade{
+		Latest:               Release{Name: "v1.12.0", ReleaseDate: "2024-01-10T00:00:00Z"},
+		Available:            true,
+		UnsupportedAvailable: true,
+	}, upgrade)
+	assert.Equal(t, "1.11.1", request.AppVersion)
+	assert.NotEmpty(t, request.ExtraInfo.PlatformVersion)
+	if runtime.GOOS == "linux" && runtime.GOARCH == "amd64" {
+		assert.Equal(t, "linux-x64", request.ExtraInfo.Platform)
+	}
+
+	upgrade, err = CheckUpgrade(context.Background(), server.URL, "1.12.0")
+	require.NoError(t, err)
+	assert.False(t, upgrade.Available)
+}
+
+func TestCheckUpgradeErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/unsupported":
+			_, _ = w.Write([]byte(`{"versions": [{"Name": "v1.12.0", "Supported": false}]}`))
+		default:
+			http.Error(w, "boom", http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	_, err := CheckUpgrade(context.Background(), server.URL+"/unsupported", "1.11.1")
+	assert.EqualError(t, err, "could not find the latest version")
+	_, err = CheckUpgrade(context.Background(), server.URL+"/failing", "1.11.1")
+	assert.EqualError(t, err, "unexpected response 500 Internal Server Error")
+}
@@ -0,0 +1,57 @@
+// Package version identifies the build of rdctl. The build sets the variables
+// with linker flags, e.g.
+//
+//	go build -ldflags "-X github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/version.Version=1.11.1"
+package version
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Set by the build.
+var (
+	// Version is the version of Rancher Desktop that rdctl is part of.
+	Version = "dev"
+	// Commit is the git commit rdctl was built from.
+	Commit = ""
+	// BuildDate is the time of the build, in RFC 3339 format.
+	BuildDate = ""
+)
+
+// Info describes the build of rdctl.
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	BuildDate string `json:"buildDate,omitempty"`
+	GoVersion string `json:"goVersion"`
+	Platform  string `json:"platform"`
+}
+
+// Get returns the description of the build. If the build didn't set the
+// commit, it comes from the version control information that go embeds when
+// building in a git checkout.
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuildDate: BuildDate,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+	if buildInfo, ok := debug.ReadBuildInfo(); ok && info.Commit == "" {
+		modified := false
+		for _, setting := range buildInfo.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				info.Commit = setting.Value
+			case "vcs.modified":
+				modified = setting.Value == "true"
+			}
+		}
+		if info.Commit != "" && modified {
+			info.Commit += "-dirty"
+		}
+	}
+	return info
+}
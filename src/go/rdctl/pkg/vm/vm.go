@@ -0,0 +1,97 @@
+// Package vm runs commands inside the Rancher Desktop virtual machine (the
+// Lima VM on macOS and Linux, or the WSL distribution on Windows).
+package vm
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/directories"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/guestcmd"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/profile"
+)
+
+// DistroName is the name of the WSL distribution running Rancher Desktop.
+const DistroName = "rancher-desktop"
+
+// Runner runs commands as root in the VM; it exists so that callers can be
+// tested without a VM.
+type Runner interface {
+	// RootOutput runs the command and returns its standard output. The error
+	// includes anything the command wrote to standard error.
+	RootOutput(args ...string) ([]byte, error)
+	// RootStream runs the command, connecting its standard input and output
+	// to the given streams, which may be nil.
+	RootStream(stdin io.Reader, stdout io.Writer, args ...string) error
+}
+
+// VM is a Runner for the Rancher Desktop VM.
+type VM struct {
+	limactl string
+}
+
+// New prepares to run commands in the VM. It does not check that the VM is
+// running.
+func New(appPaths paths.Paths) (*VM, error) {
+	if runtime.GOOS == "windows" {
+		return &VM{}, nil
+	}
+	if err := directories.SetupLimaHome(appPaths.AppHome); err != nil {
+		return nil, err
+	}
+	limactl, err := directories.GetLimactlPath()
+	if err != nil {
+		return nil, err
+	}
+	return &VM{limactl: limactl}, nil
+}
+
+// Command returns a command that runs the given command in the VM as the
+// default user, which is root on Windows; without arguments, it runs a shell.
+func (v *VM) Command(args ...string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		args = append([]string{"--distribution", DistroName, "--exec", "/usr/local/bin/wsl-exec"}, args...)
+		return exec.Command("wsl", args...)
+	}
+	if len(args) == 0 {
+		return exec.Command(v.limactl, "shell", "0")
+	}
+	// limactl passes the command to the shell as a single line.
+	return exec.Command(v.limactl, "shell", "0", guestcmd.Join(args))
+}
+
+func (v *VM) RootOutput(args ...string) ([]byte, error) {
+	var stdout bytes.Buffer
+	if err := v.RootStream(nil, &stdout, args...); err != nil {
+		return nil, err
+	}
+	return stdout.Bytes(), nil
+}
+
+func (v *VM) RootStream(stdin io.Reader, stdout io.Writer, args ...string) error {
+	if len(args) > 0 {
+		// Only the command is mentioned, as its arguments may contain
+		// credentials.
+		defer profile.Start(profile.Process, "vm "+args[0])()
+	}
+	if runtime.GOOS != "windows" {
+		args = append([]string{"sudo"}, args...)
+	}
+	var stderr bytes.Buffer
+	cmd := v.Command(args...)
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return fmt.Errorf("%w: %s", err, message)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package vmdisk
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/vm"
+)
+
+// trimStatePath records when the file systems of the VM were last trimmed;
+// it is written by the periodic job the application installs.
+const trimStatePath = "/var/lib/rancher-desktop/fstrim"
+
+// Image is a disk image of the VM.
+type Image struct {
+	Path string `json:"path"`
+	// Size is the space allocated to the image on the host, in bytes.
+	Size int64 `json:"size"`
+}
+
+// FileSystem is a file system of the VM backed by a disk.
+type FileSystem struct {
+	Device    string `json:"device"`
+	Mount     string `json:"mount"`
+	Size      int64  `json:"size"`
+	Used      int64  `json:"used"`
+	Available int64  `json:"available"`
+}
+
+// Trim describes the last periodic trim of the file systems of the VM.
+type Trim struct {
+	Time  time.Time `json:"time"`
+	Bytes int64     `json:"bytes"`
+}
+
+// Usage is the disk usage of the VM, on the host and in the VM.
+type Usage struct {
+	Images  []Image `json:"images"`
+	Running bool    `json:"running"`
+	// FileSystems and LastTrim are only known while the VM is running;
+	// LastTrim is nil if the file systems were never trimmed periodically.
+	FileSystems []FileSystem `json:"fileSystems,omitempty"`
+	LastTrim    *Trim        `json:"lastTrim,omitempty"`
+}
+
+// GetUsage returns the disk usage of the VM.
+func GetUsage(appPaths paths.Paths, runner vm.Runner) (*Usage, error) {
+	usage := &Usage{}
+	for _, image := range images(appPaths) {
+		size, err := allocatedSize(image)
+		if err != nil {
+			return nil, err
+		}
+		usage.Images = append(usage.Images, Image{Path: image, Size: size})
+	}
+	var err error
+	if usage.Running, err = Running(); err != nil || !usage.Running {
+		return usage, err
+	}
+	output, err := runner.RootOutput("df", "-P", "-k")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the file systems of the VM: %w", err)
+	}
+	usage.FileSystems = parseDF(string(output))
+	// The state is missing until the first periodic trim.
+	if output, err := runner.RootOutput("cat", trimStatePath); err == nil {
+		usage.LastTrim = parseTrimState(string(output))
+	}
+	return usage, nil
+}
+
+// parseDF parses the output of `df -P -k`, keeping the file systems backed
+// by devices.
+func parseDF(output string) []FileSystem {
+	var fileSystems []FileSystem
+	seen := map[string]bool{}
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 6 || !strings.HasPrefix(fields[0], "/dev/") {
+			continue
+		}
+		// The same device may be mounted at several places.
+		if seen[fields[0]] {
+			continue
+		}
+		seen[fields[0]] = true
+		fileSystem := FileSystem{Device: fields[0], Mount: strings.Join(fields[5:], " ")}
+		for i, value := range []*int64{&fileSystem.Size, &fileSystem.Used, &fileSystem.Available} {
+			kib, _ := strconv.ParseInt(fields[i+1], 10, 64)
+			*value = kib * 1024
+		}
+		fileSystems = append(fileSystems, fileSystem)
+	}
+	return fileSystems
+}
+
+// parseTrimState parses the state written by the periodic trim job, made of
+// time=SECONDS and bytes=BYTES lines.
+func parseTrimState(output string) *Trim {
+	var trim Trim
+	for _, line := range strings.Split(output, "\n") {
+		key, value, _ := strings.Cut(strings.TrimSpace(line), "=")
+		number, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			continue
+		}
+		switch key {
+		case "time":
+			trim.Time = time.Unix(number, 0)
+		case "bytes":
+			trim.Bytes = number
+		}
+	}
+	if trim.Time.IsZero() {
+		return nil
+	}
+	return &trim
+}
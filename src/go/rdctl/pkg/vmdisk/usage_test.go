@@ -0,0 +1,26 @@
+package vmdisk
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDF(t *testing.T) {
+	output := `Filesystem           1024-blocks    Used Available Capacity Mounted on
+/dev/vda1               102687672  5242880  97444792   5% /
+tmpfs                     4038312        0   4038312   0% /dev/shm
+/dev/vdb                 10218772   204800  10013972   2% /mnt/data
+/dev/vdb                 10218772   204800  10013972   2% /var/lib/docker
+`
+	assert.Equal(t, []FileSystem{
+		{Device: "/dev/vda1", Mount: "/", Size: 102687672 * 1024, Used: 5242880 * 1024, Available: 97444792 * 1024},
+		{Device: "/dev/vdb", Mount: "/mnt/data", Size: 10218772 * 1024, Used: 204800 * 1024, Available: 10013972 * 1024},
+	}, parseDF(output))
+}
+
+func TestParseTrimState(t *testing.T) {
+	assert.Equal(t, &Trim{Time: time.Unix(1700000000, 0), Bytes: 1288490188}, parseTrimState("time=1700000000\nbytes=1288490188\n"))
+	assert.Nil(t, parseTrimState(""))
+}
@@ -0,0 +1,64 @@
+// Package vmdisk reports on and reclaims the space of the disk images of the
+// VM, which otherwise only ever grow: while the VM runs, the file systems in
+// it are trimmed, so that freed blocks are released to the image; while it is
+// stopped, the images themselves are compacted.
+package vmdisk
+
+import (
+	"fmt"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/vm"
+)
+
+// Disk is the space a disk image of the VM uses on the host.
+type Disk struct {
+	Path string `json:"path"`
+	// Before and After are the space allocated to the image, in bytes.
+	Before int64 `json:"before"`
+	After  int64 `json:"after"`
+}
+
+// Reclaimed returns the space released to the host, in bytes.
+func (d Disk) Reclaimed() int64 {
+	return d.Before - d.After
+}
+
+// Result describes what Compact did.
+type Result struct {
+	// Trimmed is set if the VM was running, and its file systems were
+	// trimmed; otherwise, the images were compacted.
+	Trimmed bool   `json:"trimmed"`
+	Disks   []Disk `json:"disks"`
+}
+
+// trim releases the unused blocks of the file systems of the VM.
+func trim(runner vm.Runner) error {
+	if _, err := runner.RootOutput("fstrim", "--all"); err != nil {
+		return fmt.Errorf("failed to trim the file systems of the VM: %w", err)
+	}
+	return nil
+}
+
+// measure runs the action, recording the space used by the images before and
+// after it.
+func measure(images []string, action func() error) ([]Disk, error) {
+	disks := make([]Disk, 0, len(images))
+	for _, image := range images {
+		size, err := allocatedSize(image)
+		if err != nil {
+			return nil, err
+		}
+		disks = append(disks, Disk{Path: image, Before: size})
+	}
+	if err := action(); err != nil {
+		return nil, err
+	}
+	for i := range disks {
+		size, err := allocatedSize(disks[i].Path)
+		if err != nil {
+			return nil, err
+		}
+		disks[i].After = size
+	}
+	return disks, nil
+}
@@ -0,0 +1,32 @@
+package vmdisk
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMeasure(t *testing.T) {
+	image := filepath.Join(t.TempDir(), "disk.img")
+	require.NoError(t, os.WriteFile(image, make([]byte, 64*1024), 0o644))
+	disks, err := measure([]string{image}, func() error {
+		return os.Truncate(image, 0)
+	})
+	require.NoError(t, err)
+	require.Len(t, disks, 1)
+	assert.Equal(t, image, disks[0].Path)
+	assert.Greater(t, disks[0].Before, int64(0))
+	assert.Equal(t, int64(0), disks[0].After)
+	assert.Equal(t, disks[0].Before, disks[0].Reclaimed())
+}
+
+func TestMeasureMissingImage(t *testing.T) {
+	_, err := measure([]string{filepath.Join(t.TempDir(), "missing.img")}, func() error {
+		t.Fatal("the action must not run")
+		return nil
+	})
+	assert.Error(t, err)
+}
@@ -0,0 +1,110 @@
+//go:build unix
+
+package vmdisk
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/directories"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/vm"
+)
+
+// instanceName is the name of the Lima instance of Rancher Desktop.
+const instanceName = "0"
+
+// images returns the disk images of the VM.
+func images(appPaths paths.Paths) []string {
+	return []string{filepath.Join(appPaths.Lima, instanceName, "diffdisk")}
+}
+
+// Compact trims the file systems of the VM if it is running, or compacts its
+// disk image (with qemu-img, which skips unused blocks) if it is stopped.
+func Compact(appPaths paths.Paths, runner vm.Runner) (*Result, error) {
+	running, err := Running()
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{Trimmed: running}
+	action := func() error { return trim(runner) }
+	if !running {
+		action = func() error { return convert(appPaths, images(appPaths)[0]) }
+	}
+	if result.Disks, err = measure(images(appPaths), action); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Running returns whether the Lima instance is running.
+func Running() (bool, error) {
+	limactl, err := directories.GetLimactlPath()
+	if err != nil {
+		return false, err
+	}
+	output, err := exec.Command(limactl, "list", "--json", instanceName).Output()
+	if err != nil {
+		return false, fmt.Errorf("failed to get the status of the VM: %w", err)
+	}
+	return parseLimaStatus(output) == "Running", nil
+}
+
+// parseLimaStatus returns the status of the instance in the output of
+// `limactl list --json`, which has one JSON object per line.
+func parseLimaStatus(output []byte) string {
+	for _, line := range strings.Split(string(output), "\n") {
+		var instance struct {
+			Name   string `json:"name"`
+			Status string `json:"status"`
+		}
+		if json.Unmarshal([]byte(line), &instance) == nil && instance.Name == instanceName {
+			return instance.Status
+		}
+	}
+	return ""
+}
+
+// convert rewrites the image in its own format, leaving out unused blocks.
+func convert(appPaths paths.Paths, image string) error {
+	qemuImg := filepath.Join(appPaths.Resources, paths.PlatformDir(), "lima", "bin", "qemu-img")
+	output, err := exec.Command(qemuImg, "info", "--output=json", image).Output()
+	if err != nil {
+		return fmt.Errorf("failed to get the format of %s: %w", image, err)
+	}
+	var info struct {
+		Format string `json:"format"`
+	}
+	if err := json.Unmarshal(output, &info); err != nil {
+		return fmt.Errorf("failed to parse the information about %s: %w", image, err)
+	}
+	compacted := image + ".compact"
+	cmd := exec.Command(qemuImg, "convert", "-O", info.Format, image, compacted)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		_ = os.Remove(compacted)
+		return fmt.Errorf("failed to compact %s: %w: %s", image, err, strings.TrimSpace(string(output)))
+	}
+	if err := os.Rename(compacted, image); err != nil {
+		return errors.Join(fmt.Errorf("failed to replace %s: %w", image, err), os.Remove(compacted))
+	}
+	return nil
+}
+
+// allocatedSize returns the space the file uses on disk, which is less than
+// its size if it is sparse.
+func allocatedSize(path string) (int64, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get the size of %s: %w", path, err)
+	}
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		return int64(stat.Blocks) * 512, nil
+	}
+	return info.Size(), nil
+}
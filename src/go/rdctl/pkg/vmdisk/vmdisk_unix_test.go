@@ -0,0 +1,18 @@
+//go:build unix
+
+package vmdisk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLimaStatus(t *testing.T) {
+	output := `{"name":"default","status":"Stopped","dir":"/Users/user/.lima/default"}
+{"name":"0","status":"Running","dir":"/Users/user/Library/Application Support/rancher-desktop/lima/0"}
+`
+	assert.Equal(t, "Running", parseLimaStatus([]byte(output)))
+	assert.Equal(t, "", parseLimaStatus([]byte(`{"name":"default","status":"Running"}`)))
+	assert.Equal(t, "", parseLimaStatus(nil))
+}
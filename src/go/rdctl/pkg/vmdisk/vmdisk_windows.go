@@ -0,0 +1,161 @@
+package vmdisk
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/text/encoding/unicode"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/paths"
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/vm"
+)
+
+// releaseTimeout bounds the wait for WSL to release the virtual disks once it
+// is shut down.
+const releaseTimeout = 30 * time.Second
+
+// Compact trims the file systems of the WSL distributions if they are
+// running, or compacts their virtual disks (with diskpart, which requires
+// administrative access and prompts for it) if they are stopped. Trimming
+// alone only shrinks the disks if WSL makes them sparse.
+func Compact(appPaths paths.Paths, runner vm.Runner) (*Result, error) {
+	running, err := Running()
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{Trimmed: running}
+	action := func() error { return trim(runner) }
+	if !running {
+		action = func() error {
+			if err := shutdownWSL(images(appPaths)); err != nil {
+				return err
+			}
+			return compactVHDs(images(appPaths))
+		}
+	}
+	if result.Disks, err = measure(images(appPaths), action); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// images returns the virtual disks of the WSL distributions.
+func images(appPaths paths.Paths) []string {
+	return []string{
+		filepath.Join(appPaths.WslDistro, "ext4.vhdx"),
+		filepath.Join(appPaths.WslDistroData, "ext4.vhdx"),
+	}
+}
+
+// Running returns whether the Rancher Desktop distribution is running.
+func Running() (bool, error) {
+	cmd := exec.Command("wsl", "--list", "--running", "--quiet")
+	cmd.SysProcAttr = &windows.SysProcAttr{CreationFlags: windows.CREATE_NO_WINDOW}
+	rawBytes, err := cmd.Output()
+	if err != nil {
+		// wsl exits with an error when no distributions are running.
+		return false, nil
+	}
+	decoder := unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM).NewDecoder()
+	output, err := decoder.String(string(rawBytes))
+	if err != nil {
+		return false, fmt.Errorf("failed to decode the running WSL distributions: %w", err)
+	}
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) == vm.DistroName {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// shutdownWSL shuts down the WSL VM, which keeps the virtual disks attached
+// for a while after the distributions stop, and waits until the disks are
+// released, as diskpart can't compact them before.
+func shutdownWSL(images []string) error {
+	cmd := exec.Command("wsl", "--shutdown")
+	cmd.SysProcAttr = &windows.SysProcAttr{CreationFlags: windows.CREATE_NO_WINDOW}
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to shut down WSL: %w", err)
+	}
+	deadline := time.Now().Add(releaseTimeout)
+	for _, image := range images {
+		for {
+			err := checkReleased(image)
+			if err == nil {
+				break
+			}
+			if time.Now().After(deadline) {
+				return fmt.Errorf("%s is still in use after shutting down WSL: %w", image, err)
+			}
+			time.Sleep(500 * time.Millisecond)
+		}
+	}
+	return nil
+}
+
+// checkReleased returns an error if the file is open in another process; it
+// opens the file without sharing it, which fails in that case.
+func checkReleased(path string) error {
+	name, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return err
+	}
+	handle, err := windows.CreateFile(name, windows.GENERIC_READ, 0, nil, windows.OPEN_EXISTING, windows.FILE_ATTRIBUTE_NORMAL, 0)
+	if err != nil {
+		return err
+	}
+	return windows.CloseHandle(handle)
+}
+
+// compactVHDs runs a diskpart script compacting the virtual disks, in an
+// elevated process.
+func compactVHDs(images []string) error {
+	script, err := os.CreateTemp("", "rdctl-compact-*.txt")
+	if err != nil {
+		return fmt.Errorf("failed to create diskpart script: %w", err)
+	}
+	defer os.Remove(script.Name())
+	_, err = script.WriteString(diskpartScript(images))
+	if closeErr := script.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write diskpart script: %w", err)
+	}
+	command := fmt.Sprintf(
+		"$p = Start-Process -FilePath diskpart.exe -ArgumentList '/s','\"%s\"' -Verb RunAs -Wait -PassThru -WindowStyle Hidden; exit $p.ExitCode",
+		script.Name())
+	cmd := exec.Command("powershell.exe", "-NoProfile", "-NonInteractive", "-Command", command)
+	cmd.SysProcAttr = &windows.SysProcAttr{CreationFlags: windows.CREATE_NO_WINDOW}
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to compact the virtual disks: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// diskpartScript returns the diskpart commands compacting the virtual disks.
+func diskpartScript(images []string) string {
+	var builder strings.Builder
+	for _, image := range images {
+		fmt.Fprintf(&builder, "select vdisk file=\"%s\"\r\n", image)
+		builder.WriteString("attach vdisk readonly\r\n")
+		builder.WriteString("compact vdisk\r\n")
+		builder.WriteString("detach vdisk\r\n")
+	}
+	return builder.String()
+}
+
+// allocatedSize returns the space the file uses on disk.
+func allocatedSize(path string) (int64, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get the size of %s: %w", path, err)
+	}
+	return info.Size(), nil
+}
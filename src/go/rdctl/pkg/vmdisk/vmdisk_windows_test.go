@@ -0,0 +1,15 @@
+package vmdisk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiskpartScript(t *testing.T) {
+	script := diskpartScript([]string{`C:\Users\user\AppData\Local\rancher-desktop\distro\ext4.vhdx`})
+	assert.Equal(t, "select vdisk file=\"C:\\Users\\user\\AppData\\Local\\rancher-desktop\\distro\\ext4.vhdx\"\r\n"+
+		"attach vdisk readonly\r\n"+
+		"compact vdisk\r\n"+
+		"detach vdisk\r\n", script)
+}
@@ -0,0 +1,55 @@
+// Package vmswap reports the swap space of the VM, which the application sets
+// up as the experimental.virtualMachine.swap setting asks: compressed memory
+// with zram, or a file on the disk of the VM.
+package vmswap
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/rdctl/pkg/vm"
+)
+
+// Device is a swap device of the VM.
+type Device struct {
+	Name string `json:"name"`
+	// Type is "zram" for compressed memory, or the type the kernel reports,
+	// e.g. "file" or "partition".
+	Type string `json:"type"`
+	// Size and Used are in bytes.
+	Size     int64 `json:"size"`
+	Used     int64 `json:"used"`
+	Priority int   `json:"priority"`
+}
+
+// Get returns the swap devices of the running VM.
+func Get(runner vm.Runner) ([]Device, error) {
+	output, err := runner.RootOutput("cat", "/proc/swaps")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the swap space of the VM: %w", err)
+	}
+	return parseSwaps(string(output)), nil
+}
+
+// parseSwaps parses /proc/swaps, whose sizes are in KiB.
+func parseSwaps(output string) []Device {
+	var devices []Device
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 5 || fields[0] == "Filename" {
+			continue
+		}
+		device := Device{Name: fields[0], Type: fields[1]}
+		if strings.HasPrefix(device.Name, "/dev/zram") {
+			device.Type = "zram"
+		}
+		for i, value := range []*int64{&device.Size, &device.Used} {
+			kib, _ := strconv.ParseInt(fields[i+2], 10, 64)
+			*value = kib * 1024
+		}
+		device.Priority, _ = strconv.Atoi(fields[4])
+		devices = append(devices, device)
+	}
+	return devices
+}
@@ -0,0 +1,19 @@
+package vmswap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSwaps(t *testing.T) {
+	output := `Filename				Type		Size		Used		Priority
+/dev/zram0                              partition	4194300		524288		100
+/var/lib/rancher-desktop/swapfile       file		2097148		0		-2
+`
+	assert.Equal(t, []Device{
+		{Name: "/dev/zram0", Type: "zram", Size: 4194300 * 1024, Used: 524288 * 1024, Priority: 100},
+		{Name: "/var/lib/rancher-desktop/swapfile", Type: "file", Size: 2097148 * 1024, Priority: -2},
+	}, parseSwaps(output))
+	assert.Nil(t, parseSwaps("Filename\tType\tSize\tUsed\tPriority\n"))
+}
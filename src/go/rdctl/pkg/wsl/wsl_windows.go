@@ -3,6 +3,7 @@ package wsl
 import (
 	"fmt"
 	"os/exec"
+	"slices"
 	"strings"
 
 	"github.com/sirupsen/logrus"
@@ -22,9 +23,22 @@ type WSL interface {
 	ImportDistro(distroName, installLocation, fileName string) error
 }
 
+// The names of the WSL distributions of Rancher Desktop: the one running it,
+// and the one holding its data.
+const (
+	DistroName     = "rancher-desktop"
+	DataDistroName = "rancher-desktop-data"
+)
+
 type WSLImpl struct{}
 
 func (wsl WSLImpl) UnregisterDistros() error {
+	return wsl.UnregisterDistrosExcept()
+}
+
+// UnregisterDistrosExcept deletes the WSL distros pertaining to Rancher
+// Desktop, except the given ones.
+func (wsl WSLImpl) UnregisterDistrosExcept(keep ...string) error {
 	cmd := exec.Command("wsl", "--list", "--quiet")
 	cmd.SysProcAttr = &windows.SysProcAttr{CreationFlags: windows.CREATE_NO_WINDOW}
 	rawBytes, err := cmd.CombinedOutput()
@@ -40,7 +54,7 @@ func (wsl WSLImpl) UnregisterDistros() error {
 	wsls := strings.Split(actualOutput, "\n")
 	wslsToKill := []string{}
 	for _, s := range wsls {
-		if s == "rancher-desktop" || s == "rancher-desktop-data" {
+		if (s == DistroName || s == DataDistroName) && !slices.Contains(keep, s) {
 			wslsToKill = append(wslsToKill, s)
 		}
 	}
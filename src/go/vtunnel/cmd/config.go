@@ -0,0 +1,65 @@
+/*
+Copyright © 2022 SUSE LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/vtunnel/pkg/config"
+)
+
+// addConfigFlags adds the flags of the configuration file to the command.
+func addConfigFlags(cmd *cobra.Command) {
+	cmd.Flags().String("config-path", "", "Path to the vtunnel's yaml or json configuration file")
+	cmd.MarkFlagRequired("config-path")
+	cmd.Flags().Bool("check-config", false, "Check the configuration file, print the tunnels and exit")
+}
+
+// loadConfig reads and validates the configuration file of the command; with
+// --check-config, it prints the tunnels and returns done as there is nothing
+// else to do.
+func loadConfig(cmd *cobra.Command) (conf *config.Config, done bool, err error) {
+	path, err := cmd.Flags().GetString("config-path")
+	if err != nil {
+		return nil, false, err
+	}
+	conf, err = config.NewConfig(path)
+	if err != nil {
+		return nil, false, err
+	}
+	checkOnly, err := cmd.Flags().GetBool("check-config")
+	if err != nil {
+		return nil, false, err
+	}
+	if checkOnly {
+		printTunnels(cmd.OutOrStdout(), conf)
+		return conf, true, nil
+	}
+	return conf, false, nil
+}
+
+func printTunnels(w io.Writer, conf *config.Config) {
+	for i, tun := range conf.Tunnel {
+		name := tun.Name
+		if name == "" {
+			name = fmt.Sprintf("tunnel %d", i+1)
+		}
+		fmt.Fprintf(w, "%s: %s\n", name, tun.String())
+	}
+}
@@ -21,7 +21,6 @@ import (
 	"github.com/spf13/cobra"
 	"golang.org/x/sync/errgroup"
 
-	"github.com/rancher-sandbox/rancher-desktop/src/go/vtunnel/pkg/config"
 	"github.com/rancher-sandbox/rancher-desktop/src/go/vtunnel/pkg/vmsock"
 )
 
@@ -30,19 +29,25 @@ var hostCmd = &cobra.Command{
 	Use:   "host",
 	Short: "vtunnel host process",
 	Long: `vtunnel host process runs on the host machine and binds to localhost
-and a given port acting as a host end of the tunnel.`,
+and a given port acting as a host end of the tunnel; for the tunnels into
+the VM, it listens on a given named pipe instead.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		cmd.SilenceUsage = true
-		configPath, err := cmd.Flags().GetString("config-path")
-		if err != nil {
-			return err
-		}
-		conf, err := config.NewConfig(configPath)
-		if err != nil {
+		conf, done, err := loadConfig(cmd)
+		if err != nil || done {
 			return err
 		}
 		errs, _ := errgroup.WithContext(context.Background())
 		for _, tun := range conf.Tunnel {
+			if tun.ToVM() {
+				hostListener := vmsock.HostListener{
+					ListenAddress:     tun.HostListenAddress,
+					VsockPeerPort:     tun.VsockPeerPort,
+					PeerHandshakePort: tun.HandshakePort,
+				}
+				errs.Go(hostListener.ListenAndDial)
+				continue
+			}
 			hostConnector := vmsock.HostConnector{
 				UpstreamServerAddress: tun.UpstreamServerAddress,
 				VsockListenPort:       tun.VsockHostPort,
@@ -55,7 +60,6 @@ and a given port acting as a host end of the tunnel.`,
 }
 
 func init() {
-	hostCmd.Flags().String("config-path", "", "Path to the vtunnel's yaml configuration file")
-	hostCmd.MarkFlagRequired("config-path")
+	addConfigFlags(hostCmd)
 	rootCmd.AddCommand(hostCmd)
 }
@@ -21,7 +21,6 @@ import (
 	"github.com/spf13/cobra"
 	"golang.org/x/sync/errgroup"
 
-	"github.com/rancher-sandbox/rancher-desktop/src/go/vtunnel/pkg/config"
 	"github.com/rancher-sandbox/rancher-desktop/src/go/vtunnel/pkg/vmsock"
 )
 
@@ -30,20 +29,27 @@ var peerCmd = &cobra.Command{
 	Use:   "peer",
 	Short: "vtunnel peer process",
 	Long: `vtunnel peer process runs in the WSL VM and binds to a given
-IP and port acting as a peer end of the tunnel.`,
+IP and port acting as a peer end of the tunnel; for the tunnels into
+the VM, it dials a given address instead.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		cmd.SilenceUsage = true
-		path, err := cmd.Flags().GetString("config-path")
-		if err != nil {
-			return err
-		}
-		conf, err := config.NewConfig(path)
-		if err != nil {
+		conf, done, err := loadConfig(cmd)
+		if err != nil || done {
 			return err
 		}
 
 		errs, _ := errgroup.WithContext(context.Background())
 		for _, tun := range conf.Tunnel {
+			if tun.ToVM() {
+				peerConnector := vmsock.PeerConnector{VsockHandshakePort: tun.HandshakePort}
+				peerDialer := vmsock.PeerDialer{
+					VsockListenPort: tun.VsockPeerPort,
+					UpstreamAddress: tun.PeerUpstreamAddress,
+				}
+				go peerConnector.ListenAndHandshake()
+				errs.Go(peerDialer.ListenAndDial)
+				continue
+			}
 			peerConnector := vmsock.PeerConnector{
 				IPv4ListenAddress:  tun.PeerAddress,
 				TCPListenPort:      tun.PeerPort,
@@ -58,7 +64,6 @@ IP and port acting as a peer end of the tunnel.`,
 }
 
 func init() {
-	peerCmd.Flags().String("config-path", "", "Path to the vtunnel's yaml configuration file")
-	peerCmd.MarkFlagRequired("config-path")
+	addConfigFlags(peerCmd)
 	rootCmd.AddCommand(peerCmd)
 }
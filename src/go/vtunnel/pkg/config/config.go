@@ -16,23 +16,178 @@ limitations under the License.
 package config
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
 	"os"
+	"strconv"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
 
+// Tunnel is either a tunnel from the VM to the host, where the peer listens
+// on PeerAddress:PeerPort and the host dials UpstreamServerAddress, or, when
+// HostListenAddress is set, a tunnel from the host to the VM, where the host
+// listens on the named pipe HostListenAddress and the peer dials
+// PeerUpstreamAddress.
 type Tunnel struct {
 	Name                  string `yaml:"name"`
 	HandshakePort         uint32 `yaml:"handshake-port"`
-	VsockHostPort         uint32 `yaml:"vsock-host-port"`
-	PeerAddress           string `yaml:"peer-address"`
-	PeerPort              int    `yaml:"peer-port"`
-	UpstreamServerAddress string `yaml:"upstream-server-address"`
+	VsockHostPort         uint32 `yaml:"vsock-host-port,omitempty"`
+	PeerAddress           string `yaml:"peer-address,omitempty"`
+	PeerPort              int    `yaml:"peer-port,omitempty"`
+	UpstreamServerAddress string `yaml:"upstream-server-address,omitempty"`
+	// HostListenAddress is a named pipe, e.g. npipe:////./pipe/docker_engine.
+	HostListenAddress string `yaml:"host-listen-address,omitempty"`
+	VsockPeerPort     uint32 `yaml:"vsock-peer-port,omitempty"`
+	// PeerUpstreamAddress is either IP:Port, or a unix socket, e.g.
+	// unix:///var/run/docker.sock.
+	PeerUpstreamAddress string `yaml:"peer-upstream-address,omitempty"`
 }
+
+// ToVM reports whether the tunnel goes from the host to the VM.
+func (t *Tunnel) ToVM() bool {
+	return t.HostListenAddress != ""
+}
+
+// String describes where the tunnel goes, e.g.
+// "127.0.0.1:3030 in the VM -> 127.0.0.1:4444 on the host".
+func (t *Tunnel) String() string {
+	if t.ToVM() {
+		return fmt.Sprintf("%s on the host -> %s in the VM", t.HostListenAddress, t.PeerUpstreamAddress)
+	}
+	return fmt.Sprintf("%s in the VM -> %s on the host", t.peerListenAddress(), t.UpstreamServerAddress)
+}
+
+func (t *Tunnel) peerListenAddress() string {
+	address := t.PeerAddress
+	if address == "" {
+		address = "0.0.0.0"
+	}
+	return net.JoinHostPort(address, fmt.Sprint(t.PeerPort))
+}
+
+// vsockPort is an AF_VSOCK port of a tunnel, with its setting.
+type vsockPort struct {
+	setting string
+	port    uint32
+}
+
+func (t *Tunnel) vsockPorts() []vsockPort {
+	if t.ToVM() {
+		return []vsockPort{{"handshake-port", t.HandshakePort}, {"vsock-peer-port", t.VsockPeerPort}}
+	}
+	return []vsockPort{{"handshake-port", t.HandshakePort}, {"vsock-host-port", t.VsockHostPort}}
+}
+
+func (t *Tunnel) validate() error {
+	if t.HandshakePort == 0 {
+		return errors.New("handshake-port is required")
+	}
+	if t.ToVM() {
+		if !strings.HasPrefix(t.HostListenAddress, NamedPipePrefix) {
+			return fmt.Errorf("host-listen-address must be a named pipe starting with %s", NamedPipePrefix)
+		}
+		if t.VsockPeerPort == 0 || t.PeerUpstreamAddress == "" {
+			return errors.New("vsock-peer-port and peer-upstream-address are required with host-listen-address")
+		}
+		if t.UpstreamServerAddress != "" || t.PeerPort != 0 {
+			return errors.New("upstream-server-address and peer-port can't be used with host-listen-address")
+		}
+		if !strings.HasPrefix(t.PeerUpstreamAddress, UnixSocketPrefix) {
+			if err := validateHostPort(t.PeerUpstreamAddress); err != nil {
+				return fmt.Errorf("peer-upstream-address must be IP:Port or start with %s: %w", UnixSocketPrefix, err)
+			}
+		}
+		return nil
+	}
+	if t.VsockHostPort == 0 || t.PeerPort == 0 || t.UpstreamServerAddress == "" {
+		return errors.New("vsock-host-port, peer-port and upstream-server-address are required")
+	}
+	if t.VsockPeerPort != 0 || t.PeerUpstreamAddress != "" {
+		return errors.New("vsock-peer-port and peer-upstream-address need host-listen-address")
+	}
+	if t.PeerPort < 0 || t.PeerPort > 65535 {
+		return fmt.Errorf("peer-port %d is not a valid port", t.PeerPort)
+	}
+	if t.PeerAddress != "" && net.ParseIP(t.PeerAddress) == nil {
+		return fmt.Errorf("peer-address %q is not an IP address", t.PeerAddress)
+	}
+	if !strings.HasPrefix(t.UpstreamServerAddress, NamedPipePrefix) {
+		if err := validateHostPort(t.UpstreamServerAddress); err != nil {
+			return fmt.Errorf("upstream-server-address must be IP:Port or start with %s: %w", NamedPipePrefix, err)
+		}
+	}
+	return nil
+}
+
+func validateHostPort(address string) error {
+	_, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+		return fmt.Errorf("invalid port %q", port)
+	}
+	return nil
+}
+
 type Config struct {
 	Tunnel []Tunnel `yaml:"tunnel"`
 }
 
+// Validate checks the tunnels, each on their own and against each other, and
+// returns all the problems found.
+func (c *Config) Validate() error {
+	if len(c.Tunnel) == 0 {
+		return errors.New("no tunnel is configured")
+	}
+	var errs []error
+	names := make(map[string]string)
+	vsockPorts := make(map[uint32]string)
+	listenAddresses := make(map[string]string)
+	for i, tun := range c.Tunnel {
+		label := fmt.Sprintf("tunnel %d", i+1)
+		if tun.Name != "" {
+			label = fmt.Sprintf("%s (%s)", label, tun.Name)
+			if other, ok := names[tun.Name]; ok {
+				errs = append(errs, fmt.Errorf("%s: name is already used by %s", label, other))
+			}
+			names[tun.Name] = label
+		}
+		if err := tun.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", label, err))
+			continue
+		}
+		for _, port := range tun.vsockPorts() {
+			if other, ok := vsockPorts[port.port]; ok {
+				errs = append(errs, fmt.Errorf("%s: %s %d is already used by %s", label, port.setting, port.port, other))
+			}
+			vsockPorts[port.port] = label
+		}
+		listenAddress := tun.HostListenAddress
+		if !tun.ToVM() {
+			listenAddress = tun.peerListenAddress()
+		}
+		if other, ok := listenAddresses[listenAddress]; ok {
+			errs = append(errs, fmt.Errorf("%s: %s is already listened on by %s", label, listenAddress, other))
+		}
+		listenAddresses[listenAddress] = label
+	}
+	return errors.Join(errs...)
+}
+
+const (
+	// NamedPipePrefix is the prefix of the addresses of named pipes.
+	NamedPipePrefix = "npipe://"
+	// UnixSocketPrefix is the prefix of the addresses of unix sockets.
+	UnixSocketPrefix = "unix://"
+)
+
+// NewConfig reads the configuration file, in YAML or JSON, and validates it;
+// unknown settings are rejected, so that typos don't go unnoticed.
 func NewConfig(path string) (*Config, error) {
 	conf := &Config{}
 	file, err := os.Open(path)
@@ -42,8 +197,15 @@ func NewConfig(path string) (*Config, error) {
 	defer file.Close()
 
 	d := yaml.NewDecoder(file)
+	d.KnownFields(true)
 	if err := d.Decode(&conf); err != nil {
-		return nil, err
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("configuration file %s is empty", path)
+		}
+		return nil, fmt.Errorf("could not parse configuration file %s: %w", path, err)
+	}
+	if err := conf.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration file %s:\n%w", path, err)
 	}
 
 	return conf, nil
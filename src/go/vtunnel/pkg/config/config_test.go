@@ -0,0 +1,148 @@
+/*
+Copyright © 2022 SUSE LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestNewConfig(t *testing.T) {
+	path := writeConfig(t, `
+tunnel:
+  - name: tcpTunnel
+    handshake-port: 9090
+    vsock-host-port: 8989
+    peer-address: 127.0.0.1
+    peer-port: 3030
+    upstream-server-address: 127.0.0.1:4444
+  - name: dockerEngine
+    handshake-port: 9091
+    host-listen-address: npipe:////./pipe/docker_engine
+    vsock-peer-port: 8990
+    peer-upstream-address: unix:///var/run/docker.sock
+`)
+	conf, err := NewConfig(path)
+	require.NoError(t, err)
+	require.Len(t, conf.Tunnel, 2)
+	assert.False(t, conf.Tunnel[0].ToVM())
+	assert.Equal(t, "127.0.0.1:4444", conf.Tunnel[0].UpstreamServerAddress)
+	assert.True(t, conf.Tunnel[1].ToVM())
+	assert.Equal(t, "npipe:////./pipe/docker_engine", conf.Tunnel[1].HostListenAddress)
+	assert.Equal(t, uint32(8990), conf.Tunnel[1].VsockPeerPort)
+	assert.Equal(t, "unix:///var/run/docker.sock", conf.Tunnel[1].PeerUpstreamAddress)
+	assert.Equal(t, "127.0.0.1:3030 in the VM -> 127.0.0.1:4444 on the host", conf.Tunnel[0].String())
+	assert.Equal(t, "npipe:////./pipe/docker_engine on the host -> unix:///var/run/docker.sock in the VM", conf.Tunnel[1].String())
+}
+
+func TestNewConfigJSON(t *testing.T) {
+	path := writeConfig(t, `{"tunnel": [{"name": "t", "handshake-port": 9090, "vsock-host-port": 8989, "peer-port": 3030, "upstream-server-address": "npipe:////./pipe/p"}]}`)
+	conf, err := NewConfig(path)
+	require.NoError(t, err)
+	require.Len(t, conf.Tunnel, 1)
+	assert.Equal(t, "0.0.0.0:3030 in the VM -> npipe:////./pipe/p on the host", conf.Tunnel[0].String())
+}
+
+func TestNewConfigErrors(t *testing.T) {
+	t.Run("empty", func(t *testing.T) {
+		_, err := NewConfig(writeConfig(t, ""))
+		assert.ErrorContains(t, err, "is empty")
+	})
+	t.Run("no tunnel", func(t *testing.T) {
+		_, err := NewConfig(writeConfig(t, "tunnel: []\n"))
+		assert.ErrorContains(t, err, "no tunnel is configured")
+	})
+	t.Run("unknown setting", func(t *testing.T) {
+		_, err := NewConfig(writeConfig(t, "tunnel:\n  - name: t\n    peer-adress: 127.0.0.1\n"))
+		assert.ErrorContains(t, err, "line 3: field peer-adress not found")
+	})
+	t.Run("conflicts", func(t *testing.T) {
+		_, err := NewConfig(writeConfig(t, `
+tunnel:
+  - {name: a, handshake-port: 9090, vsock-host-port: 8989, peer-port: 3030, upstream-server-address: 127.0.0.1:4444}
+  - {name: a, handshake-port: 9091, vsock-host-port: 8989, peer-address: 0.0.0.0, peer-port: 3030, upstream-server-address: 127.0.0.1:4445}
+`))
+		require.Error(t, err)
+		lines := strings.Split(err.Error(), "\n")
+		assert.Equal(t, []string{
+			"tunnel 2 (a): name is already used by tunnel 1 (a)",
+			"tunnel 2 (a): vsock-host-port 8989 is already used by tunnel 1 (a)",
+			"tunnel 2 (a): 0.0.0.0:3030 is already listened on by tunnel 1 (a)",
+		}, lines[1:])
+	})
+}
+
+func TestNewConfigInvalid(t *testing.T) {
+	testCases := []struct {
+		name   string
+		tunnel string
+		error  string
+	}{
+		{
+			"no upstream",
+			"{name: t, handshake-port: 9090, vsock-host-port: 8989, peer-port: 3030}",
+			"vsock-host-port, peer-port and upstream-server-address are required",
+		},
+		{
+			"peer upstream without pipe",
+			"{name: t, handshake-port: 9090, vsock-host-port: 8989, peer-port: 3030, upstream-server-address: 127.0.0.1:4444, peer-upstream-address: 127.0.0.1:80}",
+			"vsock-peer-port and peer-upstream-address need host-listen-address",
+		},
+		{
+			"not a pipe",
+			"{name: t, handshake-port: 9090, host-listen-address: 127.0.0.1:2375, vsock-peer-port: 8990, peer-upstream-address: 127.0.0.1:80}",
+			"host-listen-address must be a named pipe starting with npipe://",
+		},
+		{
+			"no peer upstream",
+			"{name: t, handshake-port: 9090, host-listen-address: npipe:////./pipe/p, vsock-peer-port: 8990}",
+			"vsock-peer-port and peer-upstream-address are required with host-listen-address",
+		},
+		{
+			"bad peer address",
+			"{name: t, handshake-port: 9090, vsock-host-port: 8989, peer-address: localhost, peer-port: 3030, upstream-server-address: 127.0.0.1:4444}",
+			`peer-address "localhost" is not an IP address`,
+		},
+		{
+			"bad upstream",
+			"{name: t, handshake-port: 9090, vsock-host-port: 8989, peer-port: 3030, upstream-server-address: //./pipe/p}",
+			"upstream-server-address must be IP:Port or start with npipe://: address //./pipe/p: missing port in address",
+		},
+		{
+			"both directions",
+			"{name: t, handshake-port: 9090, host-listen-address: npipe:////./pipe/p, vsock-peer-port: 8990, peer-upstream-address: 127.0.0.1:80, upstream-server-address: 127.0.0.1:4444}",
+			"upstream-server-address and peer-port can't be used with host-listen-address",
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			_, err := NewConfig(writeConfig(t, "tunnel:\n  - "+testCase.tunnel+"\n"))
+			require.Error(t, err)
+			assert.True(t, strings.HasSuffix(err.Error(), ":\ntunnel 1 (t): "+testCase.error), err.Error())
+		})
+	}
+}
@@ -16,10 +16,12 @@ package vmsock
 import (
 	"fmt"
 	"net"
+	"strings"
 
 	"github.com/linuxkit/virtsock/pkg/vsock"
 	"github.com/sirupsen/logrus"
 
+	"github.com/rancher-sandbox/rancher-desktop/src/go/vtunnel/pkg/config"
 	"github.com/rancher-sandbox/rancher-desktop/src/go/wsl-helper/pkg/dockerproxy/util"
 )
 
@@ -90,3 +92,47 @@ func (p *PeerConnector) handleTCP(tConn net.Conn) {
 		return
 	}
 }
+
+// PeerDialer accepts the AF_VSOCK connections of the Host process, and
+// dials into the upstream server in the VM, for a tunnel into the VM.
+type PeerDialer struct {
+	VsockListenPort uint32
+	UpstreamAddress string
+}
+
+// ListenAndDial listens for incoming VSOCK connections from the Host process
+// and dials into the upstream address, TCP or unix socket, to pipe the payload.
+func (p *PeerDialer) ListenAndDial() error {
+	l, err := vsock.Listen(vsock.CIDAny, p.VsockListenPort)
+	if err != nil {
+		return fmt.Errorf("ListenAndDial listen for incoming vsock: %w", err)
+	}
+	defer l.Close()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			logrus.Errorf("ListenAndDial accept connection: %v", err)
+			continue
+		}
+		go p.handleConn(conn)
+	}
+}
+
+func (p *PeerDialer) handleConn(vConn net.Conn) {
+	defer vConn.Close()
+	network, address := "tcp", p.UpstreamAddress
+	if strings.HasPrefix(address, config.UnixSocketPrefix) {
+		network, address = "unix", address[len(config.UnixSocketPrefix):]
+	}
+	conn, err := net.Dial(network, address)
+	if err != nil {
+		logrus.Errorf("handleConn failed dialing into %s: %v", p.UpstreamAddress, err)
+		return
+	}
+	defer conn.Close()
+
+	if err := util.Pipe(vConn, conn); err != nil {
+		logrus.Errorf("handleConn, stream error: %v", err)
+	}
+}
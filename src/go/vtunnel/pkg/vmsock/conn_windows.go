@@ -27,13 +27,11 @@ import (
 	"github.com/sirupsen/logrus"
 	"golang.org/x/sys/windows/registry"
 
+	"github.com/rancher-sandbox/rancher-desktop/src/go/vtunnel/pkg/config"
 	"github.com/rancher-sandbox/rancher-desktop/src/go/wsl-helper/pkg/dockerproxy/util"
 )
 
-const (
-	timeoutSeconds = 10
-	npipePrefix    = "npipe://"
-)
+const timeoutSeconds = 10
 
 type HostConnector struct {
 	UpstreamServerAddress string
@@ -53,7 +51,7 @@ func (h *HostConnector) ListenAndDial() error {
 	for {
 		conn, err := vl.Accept()
 		if err != nil {
-			logrus.Errorf("ListenAndDial accept connection: %v")
+			logrus.Errorf("ListenAndDial accept connection: %v", err)
 			continue
 		}
 		go h.handleConn(conn)
@@ -64,8 +62,8 @@ func (h *HostConnector) handleConn(vConn net.Conn) {
 	var conn net.Conn
 	var err error
 	logrus.Debugf("handleConn dialing into upstream: %v", h.UpstreamServerAddress)
-	if strings.HasPrefix(h.UpstreamServerAddress, npipePrefix) {
-		conn, err = winio.DialPipe(h.UpstreamServerAddress[len(npipePrefix):], nil)
+	if strings.HasPrefix(h.UpstreamServerAddress, config.NamedPipePrefix) {
+		conn, err = winio.DialPipe(h.UpstreamServerAddress[len(config.NamedPipePrefix):], nil)
 	} else {
 		conn, err = net.Dial("tcp", h.UpstreamServerAddress)
 	}
@@ -86,7 +84,7 @@ func (h *HostConnector) handleConn(vConn net.Conn) {
 }
 
 func (h *HostConnector) vsockListen() (net.Listener, error) {
-	vmGuid, err := h.vmGuid()
+	vmGuid, err := findVMGuid(h.PeerHandshakePort)
 	if err != nil {
 		return nil, fmt.Errorf("vsockListen, could not determine VM GUID: %v", err)
 	}
@@ -103,10 +101,67 @@ func (h *HostConnector) vsockListen() (net.Listener, error) {
 	return hvsock.Listen(addr)
 }
 
-// vmGuid retrieves the GUID for a correct hyper-v VM (most likely WSL).
+// HostListener listens on a named pipe of the host, and forwards
+// the connections to the peer over AF_VSOCK, for a tunnel into the VM.
+type HostListener struct {
+	ListenAddress     string
+	VsockPeerPort     uint32
+	PeerHandshakePort uint32
+}
+
+// ListenAndDial listens on the named pipe and dials into the
+// peer for every connection to pipe the payload.
+func (h *HostListener) ListenAndDial() error {
+	vmGuid, err := findVMGuid(h.PeerHandshakePort)
+	if err != nil {
+		return fmt.Errorf("ListenAndDial, could not determine VM GUID: %w", err)
+	}
+	svcPort, err := hvsock.GUIDFromString(winio.VsockServiceID(h.VsockPeerPort).String())
+	if err != nil {
+		return fmt.Errorf("ListenAndDial, could not parse Hyper-v service GUID: %w", err)
+	}
+	addr := hvsock.Addr{
+		VMID:      vmGuid,
+		ServiceID: svcPort,
+	}
+
+	l, err := winio.ListenPipe(strings.TrimPrefix(h.ListenAddress, config.NamedPipePrefix), nil)
+	if err != nil {
+		return fmt.Errorf("ListenAndDial listen on %s: %w", h.ListenAddress, err)
+	}
+	defer l.Close()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			logrus.Errorf("ListenAndDial accept connection: %v", err)
+			continue
+		}
+		go h.handleConn(conn, addr)
+	}
+}
+
+func (h *HostListener) handleConn(pConn net.Conn, addr hvsock.Addr) {
+	defer pConn.Close()
+	vConn, err := hvsock.Dial(addr)
+	if err != nil {
+		logrus.Errorf("handleConn failed dialing into peer port %d: %v", h.VsockPeerPort, err)
+		return
+	}
+	defer vConn.Close()
+	if err := util.Pipe(pConn, vConn); err != nil {
+		// the client of the named pipe may close it as soon as it is done
+		if errors.Is(err, syscall.ERROR_BROKEN_PIPE) {
+			return
+		}
+		logrus.Errorf("handleConn, stream error: %v", err)
+	}
+}
+
+// findVMGuid retrieves the GUID for a correct hyper-v VM (most likely WSL).
 // It performs a handshake with a running peer process in the WSL distro
 // to make sure we establish the AF_VSOCK connection with a right VM.
-func (h *HostConnector) vmGuid() (hvsock.GUID, error) {
+func findVMGuid(handshakePort uint32) (hvsock.GUID, error) {
 	key, err := registry.OpenKey(
 		registry.LOCAL_MACHINE,
 		`SOFTWARE\Microsoft\Windows NT\CurrentVersion\HostComputeService\VolatileStore\ComputeSystem`,
@@ -134,15 +189,15 @@ func (h *HostConnector) vmGuid() (hvsock.GUID, error) {
 			logrus.Errorf("invalid VM name: [%s], err: %v", name, err)
 			continue
 		}
-		go h.handshake(vmGuid, found, done)
+		go handshake(handshakePort, vmGuid, found, done)
 	}
 	return tryFindGuid(found)
 }
 
 // handshake attempts to perform a handshake by verifying the seed with a running
 // af_vsock peer in WSL distro, it attempts once per second
-func (h *HostConnector) handshake(vmGuid hvsock.GUID, found chan<- hvsock.GUID, done <-chan bool) {
-	svcPort, err := hvsock.GUIDFromString(winio.VsockServiceID(h.PeerHandshakePort).String())
+func handshake(handshakePort uint32, vmGuid hvsock.GUID, found chan<- hvsock.GUID, done <-chan bool) {
+	svcPort, err := hvsock.GUIDFromString(winio.VsockServiceID(handshakePort).String())
 	if err != nil {
 		logrus.Errorf("hostHandshake parsing svc port: %v", err)
 	}
@@ -173,7 +228,7 @@ func (h *HostConnector) handshake(vmGuid hvsock.GUID, found chan<- hvsock.GUID,
 				logrus.Errorf("hosthandshake closing connection: %v", err)
 			}
 			if seed == SeedPhrase {
-				logrus.Infof("successfully established a handshake with a peer: %s on port: %v", vmGuid.String(), h.PeerHandshakePort)
+				logrus.Infof("successfully established a handshake with a peer: %s on port: %v", vmGuid.String(), handshakePort)
 				found <- vmGuid
 				return
 			}
@@ -21,6 +21,8 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/wsl-helper/pkg/systemlog"
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -30,6 +32,14 @@ var rootCmd = &cobra.Command{
 	Long:  `This command handles various WSL2 integration tasks for Rancher Desktop.`,
 	PersistentPreRun: func(cmd *cobra.Command, args []string) {
 		logrus.SetLevel(logrus.InfoLevel + logrus.Level(viper.GetInt("verbose")))
+		if viper.GetBool("system-log") {
+			hook, err := systemlog.New()
+			if err != nil {
+				logrus.WithError(err).Warn("Could not log to the system log")
+			} else {
+				logrus.AddHook(hook)
+			}
+		}
 	},
 }
 
@@ -41,6 +51,7 @@ func Execute() {
 
 func init() {
 	rootCmd.PersistentFlags().Count("verbose", "enable extra logging")
+	rootCmd.PersistentFlags().Bool("system-log", false, "also send warnings and errors to the system log (Event Log on Windows)")
 	cobra.OnInitialize(initConfig)
 }
 
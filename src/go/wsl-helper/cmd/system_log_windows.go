@@ -0,0 +1,57 @@
+/*
+Copyright © 2024 SUSE LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/rancher-sandbox/rancher-desktop/src/go/wsl-helper/pkg/systemlog"
+)
+
+// systemLogCmd represents the `system-log` command.
+var systemLogCmd = &cobra.Command{
+	Use:   "system-log",
+	Short: "Manages the event source used by --system-log",
+}
+
+// systemLogRegisterCmd represents the `system-log register` command.
+var systemLogRegisterCmd = &cobra.Command{
+	Use:   "register",
+	Short: "Registers the event source with the Windows Event Log (requires administrator)",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		return systemlog.Register()
+	},
+}
+
+// systemLogUnregisterCmd represents the `system-log unregister` command.
+var systemLogUnregisterCmd = &cobra.Command{
+	Use:   "unregister",
+	Short: "Removes the event source from the Windows Event Log (requires administrator)",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		return systemlog.Unregister()
+	},
+}
+
+func init() {
+	systemLogCmd.AddCommand(systemLogRegisterCmd)
+	systemLogCmd.AddCommand(systemLogUnregisterCmd)
+	rootCmd.AddCommand(systemLogCmd)
+}
@@ -0,0 +1,93 @@
+/*
+Copyright © 2024 SUSE LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package systemlog forwards log messages to the system log (the Windows Event
+// Log, or syslog on other platforms), so that failures of background helpers
+// are visible to monitoring tools without having to scrape log files.
+package systemlog
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Source is the name messages are logged under.
+const Source = "RancherDesktopWSLHelper"
+
+// Hook is a logrus hook that writes warnings and errors to the system log.
+type Hook struct {
+	writer writer
+}
+
+// writer is the platform-specific system log.
+type writer interface {
+	Error(message string) error
+	Warning(message string) error
+	Info(message string) error
+	Close() error
+}
+
+// New opens the system log. On Windows, the event source must have been
+// registered for messages to be displayed correctly; installing the
+// privileged service registers it, and so does Register.
+func New() (*Hook, error) {
+	w, err := open(Source)
+	if err != nil {
+		return nil, err
+	}
+	return &Hook{writer: w}, nil
+}
+
+// Levels implements logrus.Hook.
+func (h *Hook) Levels() []logrus.Level {
+	return []logrus.Level{logrus.PanicLevel, logrus.FatalLevel, logrus.ErrorLevel, logrus.WarnLevel}
+}
+
+// Fire implements logrus.Hook.
+func (h *Hook) Fire(entry *logrus.Entry) error {
+	message := format(entry)
+	switch entry.Level {
+	case logrus.PanicLevel, logrus.FatalLevel, logrus.ErrorLevel:
+		return h.writer.Error(message)
+	case logrus.WarnLevel:
+		return h.writer.Warning(message)
+	default:
+		return h.writer.Info(message)
+	}
+}
+
+// Close closes the system log.
+func (h *Hook) Close() error {
+	return h.writer.Close()
+}
+
+// format returns the message with its fields, if any, appended.
+func format(entry *logrus.Entry) string {
+	keys := make([]string, 0, len(entry.Data))
+	for key := range entry.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var builder strings.Builder
+	builder.WriteString(entry.Message)
+	for _, key := range keys {
+		fmt.Fprintf(&builder, " %s=%v", key, entry.Data[key])
+	}
+	return builder.String()
+}
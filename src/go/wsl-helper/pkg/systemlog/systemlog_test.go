@@ -0,0 +1,64 @@
+/*
+Copyright © 2024 SUSE LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package systemlog
+
+import (
+	"io"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeWriter struct {
+	messages []string
+}
+
+func (w *fakeWriter) Error(message string) error {
+	w.messages = append(w.messages, "error: "+message)
+	return nil
+}
+
+func (w *fakeWriter) Warning(message string) error {
+	w.messages = append(w.messages, "warning: "+message)
+	return nil
+}
+
+func (w *fakeWriter) Info(message string) error {
+	w.messages = append(w.messages, "info: "+message)
+	return nil
+}
+
+func (w *fakeWriter) Close() error {
+	return nil
+}
+
+func TestHook(t *testing.T) {
+	w := &fakeWriter{}
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	logger.AddHook(&Hook{writer: w})
+
+	logger.Info("not forwarded")
+	logger.WithField("port", 2375).Warn("slow")
+	logger.WithError(assert.AnError).WithField("endpoint", "docker").Error("failed")
+
+	require.Len(t, w.messages, 2)
+	assert.Equal(t, "warning: slow port=2375", w.messages[0])
+	assert.Equal(t, "error: failed endpoint=docker error="+assert.AnError.Error(), w.messages[1])
+}
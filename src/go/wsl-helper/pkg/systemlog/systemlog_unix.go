@@ -0,0 +1,52 @@
+//go:build !windows
+// +build !windows
+
+/*
+Copyright © 2024 SUSE LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package systemlog
+
+import (
+	"fmt"
+	"log/syslog"
+)
+
+func open(source string) (writer, error) {
+	// On Linux, syslog messages are picked up by journald if it is running.
+	w, err := syslog.New(syslog.LOG_DAEMON|syslog.LOG_INFO, source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to syslog: %w", err)
+	}
+	return &syslogWriter{w}, nil
+}
+
+type syslogWriter struct {
+	*syslog.Writer
+}
+
+func (w *syslogWriter) Error(message string) error {
+	return w.Err(message)
+}
+
+// Register is only needed on Windows.
+func Register() error {
+	return nil
+}
+
+// Unregister is only needed on Windows.
+func Unregister() error {
+	return nil
+}
@@ -0,0 +1,85 @@
+/*
+Copyright © 2024 SUSE LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package systemlog
+
+import (
+	"errors"
+	"fmt"
+
+	"golang.org/x/sys/windows/registry"
+	"golang.org/x/sys/windows/svc/eventlog"
+)
+
+// eventID is the ID of all events; InstallAsEventCreate only supports IDs
+// from 1 to 1000, which all map to the same message.
+const eventID = 1
+
+type eventLogWriter struct {
+	log *eventlog.Log
+}
+
+func open(source string) (writer, error) {
+	log, err := eventlog.Open(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open event log for %s: %w", source, err)
+	}
+	return &eventLogWriter{log: log}, nil
+}
+
+func (w *eventLogWriter) Error(message string) error {
+	return w.log.Error(eventID, message)
+}
+
+func (w *eventLogWriter) Warning(message string) error {
+	return w.log.Warning(eventID, message)
+}
+
+func (w *eventLogWriter) Info(message string) error {
+	return w.log.Info(eventID, message)
+}
+
+func (w *eventLogWriter) Close() error {
+	return w.log.Close()
+}
+
+// Register adds the event source to the registry, so that the Event Viewer
+// can display the messages. This requires administrative privileges; it is
+// not an error if the source is already registered.
+func Register() error {
+	err := eventlog.InstallAsEventCreate(Source, eventlog.Error|eventlog.Warning|eventlog.Info)
+	if err != nil {
+		// The eventlog package doesn't expose a typed error for this case.
+		key, openErr := registry.OpenKey(registry.LOCAL_MACHINE, eventLogKey+Source, registry.QUERY_VALUE)
+		if openErr == nil {
+			key.Close()
+			return nil
+		}
+		return fmt.Errorf("failed to register event source %s: %w", Source, err)
+	}
+	return nil
+}
+
+// Unregister removes the event source from the registry. It is not an error
+// if the source was not registered.
+func Unregister() error {
+	if err := eventlog.Remove(Source); err != nil && !errors.Is(err, registry.ErrNotExist) {
+		return fmt.Errorf("failed to unregister event source %s: %w", Source, err)
+	}
+	return nil
+}
+
+const eventLogKey = `SYSTEM\CurrentControlSet\Services\EventLog\Application\`